@@ -0,0 +1,598 @@
+package balance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/pager"
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/common/timehist"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+// Chain is the chain specific part of the Controller, the balance records are saved in clickhouse,
+// the block (or checkpoint) number of them is called number here.
+type Chain interface {
+	HashCodec
+
+	// KeyText returns the readable text of the key of an address/token pair
+	KeyText(key []byte) string
+	// KeyUpperBound returns a key greater than the keys of all the address/token pairs
+	KeyUpperBound() []byte
+
+	// Reload calls set with the latest balance item not after number of each key in clickhouse,
+	// the keys without any balance record are not called.
+	Reload(ctx context.Context, number uint64, keys [][]byte, set func(key []byte, item Item) error) error
+	// RebuildPage rebuilds the balance records in [from,to] in clickhouse from the raw data using
+	// Controller.IncrBalance and Controller.IncrCursor, tooBig means the page should be split and retried.
+	RebuildPage(ctx context.Context, from, to uint64) (updated int, tooBig bool, err error)
+}
+
+// Controller keeps the latest balance of all address/token pairs in a local pebble store, so the balance
+// records can be computed incrementally, and repairs the store after a reorg using the records in clickhouse.
+type Controller struct {
+	enable bool
+
+	chain Chain
+
+	store *pebble.DB // the latest balance of all address/token pairs has been persisted here.
+	clean bool       // not clean means store may have data beyond the current progress.
+
+	addUsed            timehist.Histogram
+	addTotalUsed       time.Duration
+	loadUsed           timehist.Histogram
+	loadTotalUsed      time.Duration
+	loadFailed         uint64
+	loadItemCount      uint64
+	reorgCount         uint64
+	reorgTotalUsed     time.Duration
+	rebuildCount       uint64
+	rebuildTotalUsed   time.Duration
+	alignCount         uint64
+	alignTotalUsed     time.Duration
+	doneFlushCount     uint64
+	doneFlushTotalUsed time.Duration
+}
+
+func (s *Controller) resetCurrent(storePath string) error {
+	file := path.Join(storePath, "RESET_CURRENT")
+	defer func() {
+		if err := os.Remove(file); err != nil {
+			log.Warnfe(err, "remove %s failed", file)
+		}
+	}()
+	raw, readErr := os.ReadFile(file)
+	if readErr != nil {
+		return nil
+	}
+	if len(raw) == 0 {
+		if err := s.delAll(); err != nil {
+			return err
+		}
+		log.Warnf("BALANCE STORE RESET TO EMPTY BECAUSE %s", file)
+		return nil
+	}
+	current, parseErr := strconv.ParseUint(string(raw), 10, 64)
+	if parseErr != nil {
+		return errors.Wrapf(parseErr, "failed to parse current %q in %s", string(raw), file)
+	}
+	if err := s.setCurrent(current); err != nil {
+		return err
+	}
+	log.Warnf("BALANCE STORE RESET TO %d BECAUSE %s", current, file)
+	return nil
+}
+
+// Init opens the store at storePath, the controller is disabled if storePath is empty
+func (s *Controller) Init(chain Chain, storePath string) (err error) {
+	s.enable = storePath != ""
+	if !s.enable {
+		return nil
+	}
+	s.chain = chain
+	var opts pebble.Options
+	opts.EnsureDefaults()
+	s.store, err = pebble.Open(storePath, &opts)
+	if err != nil {
+		return errors.Wrapf(err, "open store at %s failed", storePath)
+	}
+	if err = s.resetCurrent(storePath); err != nil {
+		return errors.Wrap(err, "reset current failed")
+	}
+	return nil
+}
+
+func (s *Controller) Enabled() bool {
+	return s.enable
+}
+
+func (s *Controller) Close() error {
+	if !s.enable {
+		return nil
+	}
+	return s.store.Close()
+}
+
+func (s *Controller) recordLoad(used time.Duration, count uint64, succeed bool) {
+	s.loadUsed = s.loadUsed.Incr(used)
+	s.loadTotalUsed += used
+	if !succeed {
+		s.loadFailed++
+	}
+	s.loadItemCount += count
+}
+
+func (s *Controller) recordAdd(used time.Duration) {
+	s.addUsed = s.addUsed.Incr(used)
+	s.addTotalUsed += used
+}
+
+func (s *Controller) recordAlign(used time.Duration) {
+	s.alignCount += 1
+	s.alignTotalUsed += used
+}
+
+func (s *Controller) recordReorg(used time.Duration) {
+	s.reorgCount += 1
+	s.reorgTotalUsed += used
+}
+
+func (s *Controller) recordRebuild(used time.Duration) {
+	s.rebuildCount += 1
+	s.rebuildTotalUsed += used
+}
+
+func (s *Controller) recordDoneFlush(used time.Duration) {
+	s.doneFlushCount += 1
+	s.doneFlushTotalUsed += used
+}
+
+// collect keys of the balance items in store in [number,INF)
+func (s *Controller) collect(ctx context.Context, number uint64) (reload [][]byte, err error) {
+	iter, newIterErr := s.store.NewIterWithContext(ctx, nil)
+	if newIterErr != nil {
+		return nil, errors.Wrapf(newIterErr, "new iterator for balance store failed")
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.First(); iter.Valid(); iter.Next() {
+		if bytes.Equal(iter.Key(), []byte(currentKey)) {
+			continue
+		}
+		value, valueErr := iter.ValueAndErr()
+		if valueErr != nil {
+			return nil, errors.Wrapf(valueErr, "get value from balance store iterator failed")
+		}
+		// value is Item.Bytes(), the first part of it is Item.Number,
+		// so here can use BytesToUint64(value)
+		valueNumber, _, convertErr := BytesToUint64(value)
+		if convertErr != nil {
+			return nil, errors.Wrapf(convertErr, "get value from balance store iterator failed")
+		}
+		if valueNumber < number {
+			continue
+		}
+		reload = append(reload, bytes.Clone(iter.Key()))
+	}
+	return reload, nil
+}
+
+// reload data from clickhouse and repair the balance items in store
+func (s *Controller) reload(ctx context.Context, number uint64, missing [][]byte) (updated int, err error) {
+	startAt := time.Now()
+	defer func() {
+		s.recordLoad(time.Since(startAt), uint64(updated), err == nil)
+	}()
+	notCreated := make(map[string]struct{})
+	for _, key := range missing {
+		notCreated[string(key)] = struct{}{}
+	}
+	err = s.chain.Reload(ctx, number, missing, func(key []byte, item Item) error {
+		delete(notCreated, string(key))
+		if setErr := s.setItem(key, item); setErr != nil {
+			return setErr
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for key := range notCreated {
+		if delErr := s.delItem([]byte(key)); delErr != nil {
+			return 0, delErr
+		}
+	}
+	return updated, nil
+}
+
+const currentKey = "###current###" // the key of an address/token pair never equals it
+
+func (s *Controller) getCurrent() (uint64, bool, error) {
+	b, closer, err := s.store.Get([]byte(currentKey))
+	if err != nil {
+		if !errors.Is(err, pebble.ErrNotFound) {
+			return 0, false, errors.Wrapf(err, "get current from balance store failed")
+		}
+		return 0, false, nil
+	}
+	defer func() {
+		_ = closer.Close()
+	}()
+	current, _, convertErr := BytesToUint64(b)
+	if convertErr != nil {
+		return 0, false, errors.Wrapf(convertErr, "get current from balance store failed")
+	}
+	return current, true, nil
+}
+
+func (s *Controller) setCurrent(current uint64) error {
+	if err := s.store.Set([]byte(currentKey), Uint64ToBytes(current), pebble.NoSync); err != nil {
+		return errors.Wrapf(err, "save current %d to balance store failed", current)
+	}
+	return nil
+}
+
+func (s *Controller) getItem(key []byte) (Item, bool, error) {
+	itemBytes, closer, err := s.store.Get(key)
+	if err != nil {
+		if !errors.Is(err, pebble.ErrNotFound) {
+			return Item{}, false, errors.Wrapf(err, "get balance of %s failed", s.chain.KeyText(key))
+		}
+		// miss
+		return Item{}, false, nil
+	}
+	defer func() {
+		_ = closer.Close()
+	}()
+	item, convertErr := NewItemFromBytes(itemBytes, s.chain)
+	if convertErr != nil {
+		return Item{}, false, errors.Wrapf(convertErr, "build balance item failed")
+	}
+	return item, true, nil
+}
+
+func (s *Controller) setItem(key []byte, item Item) error {
+	if err := s.store.Set(key, item.Bytes(s.chain), pebble.NoSync); err != nil {
+		return errors.Wrapf(err, "save balance of %s failed", s.chain.KeyText(key))
+	}
+	return nil
+}
+
+func (s *Controller) delItem(key []byte) error {
+	if err := s.store.Delete(key, pebble.NoSync); err != nil {
+		return errors.Wrapf(err, "delete balance of %s failed", s.chain.KeyText(key))
+	}
+	return nil
+}
+
+func (s *Controller) delAll() error {
+	if err := s.store.DeleteRange([]byte{0}, s.chain.KeyUpperBound(), pebble.NoSync); err != nil {
+		return errors.Wrapf(err, "delete all failed")
+	}
+	s.clean = true
+	return nil
+}
+
+func (s *Controller) flushStore() error {
+	if err := s.store.Flush(); err != nil {
+		return errors.Wrapf(err, "flush balance store failed")
+	}
+	return nil
+}
+
+// rebuildPaging sizes each rebuild page to yield roughly 50k balance-change records, so the fixed
+// per-page overhead (raw data query + delete probe + insert round-trip) is amortized across both
+// dense and sparse ranges. Page size stays on a 100-number grid and within [100, 5000]; see common/pager.
+var rebuildPaging = pager.Config{Target: 50000, Min: 100, Max: 5000, Step: 100, Initial: 500}
+
+func (s *Controller) rebuild(ctx context.Context, from, to uint64) (err error) {
+	_, logger := log.FromContext(ctx)
+	logger.Warnf("will rebuild balance in clickhouse in [%d,%d]", from, to)
+
+	if !s.clean {
+		if from == 0 {
+			logger.Infof("will truncate the balance store")
+			if err = s.delAll(); err != nil {
+				return err
+			}
+		} else {
+			if err = s.reorg(ctx, from-1); err != nil {
+				return errors.Wrapf(err, "reorg balance store to %d failed", from-1)
+			}
+		}
+	}
+
+	const flushInterval = time.Minute * 5
+	lastFlush := time.Now()
+	return pager.Walk(from, to, rebuildPaging, func(start, end uint64) (uint64, bool, error) {
+		page := fmt.Sprintf("%d-%d", start, end)
+		if from < start {
+			page = fmt.Sprintf("%d..%s", from, page)
+		}
+		if end < to {
+			page = fmt.Sprintf("%s..%d", page, to)
+		}
+
+		startAt := time.Now()
+		updated, tooBig, pageErr := s.chain.RebuildPage(ctx, start, end)
+		s.recordRebuild(time.Since(startAt))
+		if pageErr != nil {
+			return 0, false, errors.Wrapf(pageErr, "rebuild balance in page [%s] failed", page)
+		}
+		if tooBig {
+			logger.Infof("rebuild page [%s] (pageSize=%d) exceeded record cap, will retry smaller", page, end-start+1)
+			return 0, true, nil
+		}
+		logger.Infof("rebuilt %d balances in page [%s] (pageSize=%d)", updated, page, end-start+1)
+
+		if time.Since(lastFlush) > flushInterval {
+			if flushErr := s.Done(rg.NewRange(from, end)); flushErr != nil {
+				return 0, false, flushErr
+			}
+			lastFlush = time.Now()
+		}
+		return uint64(updated), false, nil
+	})
+}
+
+func doByPage(
+	ctx context.Context,
+	total int,
+	maxPageSize int,
+	succeedLimit int,
+	what string,
+	fn func(ctx context.Context, start, end int) (string, error),
+) error {
+	_, logger := log.FromContext(ctx)
+	pageStart, pageSize, succeed := 0, maxPageSize, 0
+	for pageStart < total {
+		for {
+			pageEnd := min(pageStart+pageSize, total)
+			page := fmt.Sprintf("%s in page [%d,%d)/%d", what, pageStart, pageEnd, total)
+			report, err := fn(ctx, pageStart, pageEnd)
+			if err == nil {
+				succeed++
+				pageStart = pageEnd
+				if pageSize < maxPageSize && succeed >= succeedLimit {
+					// increase page size
+					pageSize = min(pageSize*2, maxPageSize)
+					logger.Infof("%s succeed, %s, continuous succeed %d times, will increase page size to %d",
+						page, report, succeed, pageSize)
+					succeed = 0
+				} else {
+					logger.Infof("%s succeed, %s", page, report)
+				}
+				break
+			}
+			if pageSize == 1 {
+				logger.Errorfe(err, "%s failed", page)
+				return errors.Wrapf(err, "%s failed", page)
+			}
+			// decrease page size and retry
+			pageSize, succeed = pageSize/2, 0
+			logger.Warnfe(err, "%s failed, will decrease page size to %d and retry", page, pageSize)
+		}
+	}
+	return nil
+}
+
+func (s *Controller) reorg(ctx context.Context, number uint64) (err error) {
+	_, logger := log.FromContext(ctx)
+	logger.Warnf("will reorg balance store to %d", number)
+
+	startAt := time.Now()
+	defer func() {
+		s.recordReorg(time.Since(startAt))
+	}()
+
+	s.clean = false
+	if err = s.setCurrent(number); err != nil {
+		return err
+	}
+
+	// collect balance items in range [number+1, INF)
+	reload, collectErr := s.collect(ctx, number+1)
+	if collectErr != nil {
+		return errors.Wrapf(collectErr, "collect balance items in [%d,INF) failed", number+1)
+	}
+	logger.Infof("collected %d balance items in [%d,INF)", len(reload), number+1)
+
+	// restore them using clickhouse data
+	err = doByPage(
+		ctx,
+		len(reload),
+		512,
+		100,
+		"reload balance items",
+		func(ctx context.Context, start, end int) (string, error) {
+			updated, reloadErr := s.reload(ctx, number, reload[start:end])
+			return fmt.Sprintf("%d updated and %d deleted", updated, end-start-updated), reloadErr
+		})
+	if err != nil {
+		return err
+	}
+	s.clean = true
+	return nil
+}
+
+// ResetToGenesis clears the balance store so the genesis can be applied from an empty base.
+// There is nothing before genesis to Align to (number-1 would underflow uint64 into a huge
+// rebuild target), and a failed retry of the genesis still needs its partial writes rolled back;
+// delAll both empties the store and drops the current cursor (getCurrent -> has=false).
+func (s *Controller) ResetToGenesis(ctx context.Context) error {
+	if !s.enable {
+		return nil
+	}
+	_, logger := log.FromContext(ctx)
+	logger.Warnf("will reset balance store to empty for genesis")
+	return s.delAll()
+}
+
+// Align makes the store and the balance records in clickhouse consistent with the raw data in [-INF,number]
+func (s *Controller) Align(ctx context.Context, number uint64) error {
+	if !s.enable {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		s.recordAlign(time.Since(start))
+	}()
+
+	current, has, err := s.getCurrent()
+	if err != nil {
+		return err
+	}
+	if !has || current < number {
+		// raw data in clickhouse in [-INF,number] is ok,
+		// balance items in store in [-INF, current] is ok, so balance in clickhouse in [-INF, current] is also ok,
+		// now need to rebuild balance in clickhouse in [current+1,number]
+		from := utils.Select(has, current+1, 0)
+		if err = s.rebuild(ctx, from, number); err != nil {
+			return errors.Wrapf(err, "rebuild balance in clickhouse in [%d,%d] failed", from, number)
+		}
+	} else if number < current || !s.clean {
+		if err = s.reorg(ctx, number); err != nil {
+			return errors.Wrapf(err, "reorg balance store to %d failed", number)
+		}
+	}
+	return nil
+}
+
+var bigIntZero = big.NewInt(0)
+
+// IncrBalance adds amount to the balance of the key, returns the item before and the balance after
+func (s *Controller) IncrBalance(
+	key []byte,
+	number uint64,
+	txIndex uint64,
+	txHash string,
+	amount *big.Int,
+) (pre Item, balance *big.Int, err error) {
+	if !s.enable {
+		balance = bigIntZero
+		return
+	}
+
+	startAt := time.Now()
+	defer func() {
+		s.recordAdd(time.Since(startAt))
+	}()
+	s.clean = false
+
+	// load from balance store
+	var has bool
+	if pre, has, err = s.getItem(key); err != nil {
+		return
+	}
+	if !has { // no balance item before
+		pre = Item{Balance: bigIntZero}
+	}
+	// incr balance
+	after := Item{
+		Number:  number,
+		TxIndex: txIndex,
+		TxHash:  txHash,
+		Balance: new(big.Int).Add(pre.Balance, amount),
+	}
+	// save new balance
+	if err = s.setItem(key, after); err != nil {
+		return
+	}
+	return pre, after.Balance, nil
+}
+
+func (s *Controller) IncrCursor(number uint64) error {
+	if !s.enable {
+		return nil
+	}
+	if err := s.setCurrent(number); err != nil {
+		return err
+	}
+	s.clean = true
+	return nil
+}
+
+func (s *Controller) Done(r rg.Range) error {
+	if !s.enable {
+		return nil
+	}
+
+	startAt := time.Now()
+	defer func() {
+		s.recordDoneFlush(time.Since(startAt))
+	}()
+	// All balances up to the number are written to the store, always before all data up to the number is
+	// written to ClickHouse.
+	// If the former fails, then the latter will certainly not be completed.
+	// If the latter fails, the former will always be rollback to the correct value during the Align phase.
+	return s.flushStore()
+}
+
+func avgUsed(total time.Duration, count uint64) time.Duration {
+	if count > 0 {
+		return total / time.Duration(count)
+	}
+	return 0
+}
+
+// Snapshot might be concurrent with other methods, but this method only reads data, so it doesn't matter.
+// currentName is the name of the current number in the result.
+func (s *Controller) Snapshot(currentName string) any {
+	if !s.enable {
+		return nil
+	}
+	var current *uint64
+	if c, has, _ := s.getCurrent(); has {
+		current = &c
+	}
+	return map[string]any{
+		currentName: current,
+		"add": map[string]any{
+			"count":     s.addUsed.Sum(),
+			"used":      s.addUsed.String(),
+			"avgUsed":   avgUsed(s.addTotalUsed, uint64(s.addUsed.Sum())).String(),
+			"totalUsed": s.addTotalUsed.String(),
+		},
+		"load": map[string]any{
+			"itemCount": s.loadItemCount,
+			"failed":    s.loadFailed,
+			"count":     s.loadUsed.Sum(),
+			"used":      s.loadUsed.String(),
+			"avgUsed":   avgUsed(s.loadTotalUsed, uint64(s.loadUsed.Sum())).String(),
+			"totalUsed": s.loadTotalUsed.String(),
+		},
+		"reorg": map[string]any{
+			"count":     s.reorgCount,
+			"totalUsed": s.reorgTotalUsed.String(),
+			"avgUsed":   avgUsed(s.reorgTotalUsed, s.reorgCount).String(),
+		},
+		"align": map[string]any{
+			"count":     s.alignCount,
+			"totalUsed": s.alignTotalUsed.String(),
+			"avgUsed":   avgUsed(s.alignTotalUsed, s.alignCount).String(),
+		},
+		"rebuild": map[string]any{
+			"count":     s.rebuildCount,
+			"totalUsed": s.rebuildTotalUsed.String(),
+			"avgUsed":   avgUsed(s.rebuildTotalUsed, s.rebuildCount).String(),
+		},
+		"doneFlush": map[string]any{
+			"count":     s.doneFlushCount,
+			"totalUsed": s.doneFlushTotalUsed.String(),
+			"avgUsed":   avgUsed(s.doneFlushTotalUsed, s.doneFlushCount).String(),
+		},
+	}
+}
@@ -0,0 +1,187 @@
+package balance
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChange struct {
+	key    string
+	amount int64
+}
+
+// testChain keeps the raw changes and the balance records of the "clickhouse" in memory,
+// the transaction hash is at most 8 bytes.
+type testChain struct {
+	ctrl    *Controller
+	changes map[uint64][]testChange
+	records map[string][]Item
+}
+
+func (c *testChain) EncodeHash(hash string) []byte {
+	b := make([]byte, 8)
+	copy(b, hash)
+	return b
+}
+
+func (c *testChain) DecodeHash(b []byte) (string, []byte, error) {
+	if len(b) < 8 {
+		return "", nil, errors.Errorf("miss data when loading hash")
+	}
+	return string(bytes.TrimRight(b[:8], "\x00")), b[8:], nil
+}
+
+func (c *testChain) KeyText(key []byte) string {
+	return string(key)
+}
+
+func (c *testChain) KeyUpperBound() []byte {
+	return []byte{0xff}
+}
+
+func (c *testChain) Reload(_ context.Context, number uint64, keys [][]byte, set func([]byte, Item) error) error {
+	for _, key := range keys {
+		var latest *Item
+		for i, item := range c.records[string(key)] {
+			if item.Number <= number {
+				latest = &c.records[string(key)][i]
+			}
+		}
+		if latest == nil {
+			continue
+		}
+		if err := set(key, *latest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *testChain) RebuildPage(_ context.Context, from, to uint64) (int, bool, error) {
+	for key, items := range c.records {
+		var kept []Item
+		for _, item := range items {
+			if item.Number < from || item.Number > to {
+				kept = append(kept, item)
+			}
+		}
+		c.records[key] = kept
+	}
+	var updated int
+	for number := from; number <= to; number++ {
+		for i, change := range c.changes[number] {
+			if err := c.apply(number, uint64(i), change); err != nil {
+				return 0, false, err
+			}
+			updated++
+		}
+	}
+	return updated, false, c.ctrl.IncrCursor(to)
+}
+
+func (c *testChain) apply(number, txIndex uint64, change testChange) error {
+	_, balance, err := c.ctrl.IncrBalance([]byte(change.key), number, txIndex, "tx", big.NewInt(change.amount))
+	if err != nil {
+		return err
+	}
+	c.records[change.key] = append(c.records[change.key], Item{
+		Number:  number,
+		TxIndex: txIndex,
+		TxHash:  "tx",
+		Balance: balance,
+	})
+	return nil
+}
+
+func (c *testChain) balanceOf(t *testing.T, key string) *big.Int {
+	item, has, err := c.ctrl.getItem([]byte(key))
+	assert.NoError(t, err)
+	if !has {
+		return nil
+	}
+	return item.Balance
+}
+
+func Test_itemBytes(t *testing.T) {
+	var codec testChain
+	item := Item{Number: 4294967296, TxIndex: 256, TxHash: "tx", Balance: big.NewInt(-1)}
+	b := item.Bytes(&codec)
+	assert.Equal(t, []byte{
+		5, 0, 0, 0, 0, 1, // number
+		2, 0, 1, // txIndex
+		't', 'x', 0, 0, 0, 0, 0, 0, // txHash
+		1, 1, // balance
+	}, b)
+	loaded, err := NewItemFromBytes(b, &codec)
+	assert.NoError(t, err)
+	assert.Equal(t, item, loaded)
+
+	_, err = NewItemFromBytes(b[:10], &codec)
+	assert.Error(t, err)
+}
+
+func Test_controllerAlign(t *testing.T) {
+	ctx := context.Background()
+	var ctrl Controller
+	chain := &testChain{
+		ctrl: &ctrl,
+		changes: map[uint64][]testChange{
+			0: {{key: "a", amount: 10}},
+			1: {{key: "a", amount: -3}, {key: "b", amount: 5}},
+			2: {{key: "b", amount: 1}},
+		},
+		records: make(map[string][]Item),
+	}
+	assert.NoError(t, ctrl.Init(chain, t.TempDir()))
+	defer func() {
+		_ = ctrl.Close()
+	}()
+
+	// rebuild [0,2] from the raw changes
+	assert.NoError(t, ctrl.Align(ctx, 2))
+	assert.Equal(t, big.NewInt(7), chain.balanceOf(t, "a"))
+	assert.Equal(t, big.NewInt(6), chain.balanceOf(t, "b"))
+
+	// apply block 3 without finishing it, then align to 2 again
+	assert.NoError(t, chain.apply(3, 0, testChange{key: "a", amount: 100}))
+	assert.NoError(t, chain.apply(3, 1, testChange{key: "c", amount: 1}))
+	assert.NoError(t, ctrl.Align(ctx, 2))
+	assert.Equal(t, big.NewInt(7), chain.balanceOf(t, "a"))
+	assert.Nil(t, chain.balanceOf(t, "c"))
+
+	// reorg to 0, the balance changed at 0 is kept and the one created after 0 is deleted
+	assert.NoError(t, ctrl.Align(ctx, 0))
+	assert.Equal(t, big.NewInt(10), chain.balanceOf(t, "a"))
+	assert.Nil(t, chain.balanceOf(t, "b"))
+	current, has, err := ctrl.getCurrent()
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, uint64(0), current)
+
+	assert.NoError(t, ctrl.ResetToGenesis(ctx))
+	assert.Nil(t, chain.balanceOf(t, "a"))
+	_, has, err = ctrl.getCurrent()
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func Test_doByPage(t *testing.T) {
+	ok := make(map[int]bool)
+	err := doByPage(context.Background(), 100, 16, 5, "test",
+		func(ctx context.Context, start, end int) (string, error) {
+			if end-start > 10 {
+				return "", errors.Errorf("page size too large")
+			}
+			for i := start; i < end; i++ {
+				ok[i] = true
+			}
+			return "good", nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, len(ok))
+}
@@ -0,0 +1,107 @@
+package balance
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/common/utils"
+)
+
+func Uint64ToBytes(x uint64) []byte {
+	var bits [8]byte
+	var n byte
+	for x > 0 {
+		bits[n] = byte(x & 0xff)
+		x >>= 8
+		n++
+	}
+	r := make([]byte, n+1)
+	r[0] = n
+	copy(r[1:], bits[:n])
+	return r
+}
+
+func BytesToUint64(b []byte) (x uint64, r []byte, err error) {
+	if len(b) == 0 {
+		return 0, nil, errors.Errorf("miss data when loading uint64")
+	}
+	n := b[0]
+	if n > 8 {
+		return 0, nil, errors.Errorf("length %d > 8 when loading uint64", n)
+	}
+	if len(b) < int(n)+1 {
+		return 0, nil, errors.Errorf("miss data when loading uint64")
+	}
+	for i := byte(0); i < n; i++ {
+		x += uint64(b[i+1]) << (8 * i)
+	}
+	return x, b[n+1:], nil
+}
+
+func BigIntToBytes(i *big.Int) []byte {
+	b := i.Bytes()
+	r := make([]byte, len(b)+1)
+	r[0] = utils.Select[byte](i.Sign() < 0, 1, 0)
+	copy(r[1:], b)
+	return r
+}
+
+func BytesToBigInt(b []byte) (i *big.Int, err error) {
+	if len(b) == 0 {
+		return nil, errors.Errorf("miss data when loading bigInt")
+	}
+	sign := b[0]
+	i = new(big.Int).SetBytes(b[1:])
+	if sign == 1 {
+		i.Neg(i)
+	}
+	return i, nil
+}
+
+// HashCodec converts the transaction hash of the chain, the encoded hash always has the same length
+type HashCodec interface {
+	EncodeHash(hash string) []byte
+	DecodeHash(b []byte) (hash string, r []byte, err error)
+}
+
+// Item is the latest balance of an address/token pair, changed by the transaction TxIndex in the
+// block (or checkpoint) Number
+type Item struct {
+	Number  uint64
+	TxIndex uint64
+	TxHash  string
+
+	Balance *big.Int
+}
+
+// NewItemFromBytes loads the item saved by Item.Bytes
+func NewItemFromBytes(b []byte, codec HashCodec) (item Item, err error) {
+	item.Number, b, err = BytesToUint64(b)
+	if err != nil {
+		return item, errors.Wrapf(err, "load number failed")
+	}
+	item.TxIndex, b, err = BytesToUint64(b)
+	if err != nil {
+		return item, errors.Wrapf(err, "load txIndex failed")
+	}
+	item.TxHash, b, err = codec.DecodeHash(b)
+	if err != nil {
+		return item, err
+	}
+	item.Balance, err = BytesToBigInt(b)
+	return item, err
+}
+
+// Bytes always starts with Uint64ToBytes(item.Number), so the number can be read without the codec
+func (item Item) Bytes(codec HashCodec) []byte {
+	p1 := Uint64ToBytes(item.Number)
+	p2 := Uint64ToBytes(item.TxIndex)
+	p3 := codec.EncodeHash(item.TxHash)
+	p4 := BigIntToBytes(item.Balance)
+	r := make([]byte, 0, len(p1)+len(p2)+len(p3)+len(p4))
+	r = append(r, p1...)
+	r = append(r, p2...)
+	r = append(r, p3...)
+	return append(r, p4...)
+}
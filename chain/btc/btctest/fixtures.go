@@ -0,0 +1,60 @@
+// Package btctest provides the recorded regtest blocks and a bitcoind JSON-RPC server replaying them,
+// it is used by the tests of the Bitcoin chain packages.
+//
+// The regtest chain has 4 blocks [100,103], every block has a segwit coinbase transaction:
+//
+//	block 101: TxA spends the coinbase of block 1, creates 10 BTC for AddressX and the change for AddressC
+//	block 102: TxB spends TxA:0, creates 4 BTC for the legacy AddressY and the change for AddressX
+//	           TxC spends TxA:1, creates the change for AddressX
+//	block 103: TxD spends TxB:0 with the legacy script sig, creates the output for AddressMiner
+package btctest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+)
+
+//go:embed testdata/regtest/*.json
+var testdata embed.FS
+
+const (
+	FirstHeight  uint64 = 100
+	LatestHeight uint64 = 103
+
+	AddressMiner = "bcrt1q3vfn5wrgnychddsnwwypvfr60axn2l9weav648"
+	AddressX     = "bcrt1q94c3vs4hy6cygqtz0j5lhtpj7hy9xra3cpcc0y"
+	AddressY     = "mvHU39MbeWv17JU6kzYzGEbmwRka1wXr73"
+	AddressC     = "bcrt1q9e7jcqaf2pawye0v7k6n26y955ee8gszrwzkyg"
+
+	// ScriptY is the P2PKH locking script of AddressY
+	ScriptY = "76a914a1fce4363854ff888cff4b8e7875d600c268239088ac"
+
+	// TxCoinbase1 is the coinbase transaction of block 1, the first output is spent by TxA
+	TxCoinbase1 = "532c30b20948ab760b9077ffe4c69ca273c5fa7f6149741ee8d7e3c50df4b61d"
+
+	TxA = "dd329dc7b0f241db2b3be0ea28ba6161464f4fca909529ee9e8cc80914148b66"
+	TxB = "a9794c4191aca235c8cf09474c08026c3b66bf9eff77b7403045b5873c7d940f"
+	TxC = "510bcd6159ad2b41c814dd0cf48c45885f5b211701d1a9e032b7c7931d67eb0d"
+	TxD = "19ba88367eae0b4c7b9b0ff8cf525dc24ba64a2266575abd357a567aa3d6df1e"
+)
+
+// BlockJSON returns the result of getblock with verbosity 3 for the block, panic if the block does not exist
+func BlockJSON(height uint64) []byte {
+	raw, err := testdata.ReadFile(fmt.Sprintf("testdata/regtest/block_%d.json", height))
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// BlockHash returns the hash of the block, panic if the block does not exist
+func BlockHash(height uint64) string {
+	var block struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(BlockJSON(height), &block); err != nil {
+		panic(err)
+	}
+	return block.Hash
+}
@@ -0,0 +1,130 @@
+package btctest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []any           `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// headerFields are the fields of the getblock result which are also returned by getblockheader
+var headerFields = []string{
+	"hash", "confirmations", "height", "version", "versionHex", "merkleroot", "time", "mediantime", "nonce",
+	"bits", "difficulty", "chainwork", "nTx", "previousblockhash", "nextblockhash",
+}
+
+// Server replays the recorded blocks like bitcoind, only the methods getbestblockhash, getblockhash,
+// getblockheader and getblock with verbosity 3 are supported
+type Server struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	calls []string
+
+	hashes map[uint64]string
+	blocks map[string]map[string]any
+}
+
+func NewServer(t *testing.T) *Server {
+	s := &Server{
+		hashes: make(map[uint64]string),
+		blocks: make(map[string]map[string]any),
+	}
+	for height := FirstHeight; height <= LatestHeight; height++ {
+		var block map[string]any
+		if err := json.Unmarshal(BlockJSON(height), &block); err != nil {
+			t.Fatalf("invalid block %d: %v", height, err)
+		}
+		hash := block["hash"].(string)
+		s.hashes[height] = hash
+		s.blocks[hash] = block
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.calls = append(s.calls, req.Method)
+	s.mu.Unlock()
+	result, rpcErr := s.call(req.Method, req.Params)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr})
+}
+
+// Calls returns the methods called in order
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func (s *Server) call(method string, params []any) (any, *rpcError) {
+	switch method {
+	case "getbestblockhash":
+		return s.hashes[LatestHeight], nil
+	case "getblockhash":
+		height, ok := param[float64](params, 0)
+		if hash, has := s.hashes[uint64(height)]; ok && has {
+			return hash, nil
+		}
+		return nil, &rpcError{Code: -8, Message: "Block height out of range"}
+	case "getblockheader":
+		hash, _ := param[string](params, 0)
+		block, has := s.blocks[hash]
+		if !has {
+			return nil, &rpcError{Code: -5, Message: "Block not found"}
+		}
+		header := make(map[string]any)
+		for _, field := range headerFields {
+			if v, has := block[field]; has {
+				header[field] = v
+			}
+		}
+		return header, nil
+	case "getblock":
+		hash, _ := param[string](params, 0)
+		block, has := s.blocks[hash]
+		if !has {
+			return nil, &rpcError{Code: -5, Message: "Block not found"}
+		}
+		if verbosity, _ := param[float64](params, 1); verbosity != 3 {
+			return nil, &rpcError{Code: -8, Message: "only verbosity 3 is recorded"}
+		}
+		return block, nil
+	default:
+		return nil, &rpcError{Code: -32601, Message: "Method not found"}
+	}
+}
+
+func param[T any](params []any, i int) (v T, ok bool) {
+	if i < len(params) {
+		v, ok = params[i].(T)
+	}
+	return v, ok
+}
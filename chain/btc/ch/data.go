@@ -0,0 +1,84 @@
+package ch
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/common/objectx"
+)
+
+// ClickhouseBlock is one row of the blocks table, it is the link table of the chain, so a block
+// replaced by a reorg can be detected by the parent hash.
+type ClickhouseBlock struct {
+	BlockHeight     uint64    `clickhouse:"block_height" number_field:"true"`
+	BlockHash       string    `clickhouse:"block_hash"`
+	ParentHash      string    `clickhouse:"parent_hash"`
+	BlockTime       time.Time `clickhouse:"block_time"`
+	BlockHeaderJSON string    `clickhouse:"block_header_json" compression:"CODEC(ZSTD(1))"`
+}
+
+// ClickhouseTransaction is one row of the transactions table. addresses and scripts are the
+// locking scripts of both the spent and the created outputs, so one bloom filter lookup finds
+// the transactions touching an address in either direction.
+type ClickhouseTransaction struct {
+	BlockHeight      uint64    `clickhouse:"block_height" required:"true" number_field:"true"`
+	BlockHash        string    `clickhouse:"block_hash" required:"true"`
+	BlockTime        time.Time `clickhouse:"block_time" required:"true"`
+	TransactionIndex uint32    `clickhouse:"transaction_index" required:"true"`
+	TxID             string    `clickhouse:"txid" required:"true"`
+	IsCoinbase       bool      `clickhouse:"is_coinbase"`
+	Addresses        []string  `clickhouse:"addresses" index:"bloom_filter GRANULARITY 1"`
+	Scripts          []string  `clickhouse:"scripts"   index:"bloom_filter GRANULARITY 1"`
+	Fee              int64     `clickhouse:"fee"`
+	TransactionJSON  string    `clickhouse:"transaction_json" compression:"CODEC(ZSTD(1))" required:"true"`
+}
+
+// ClickhouseOutput is one row of the outputs table, each row is an UTXO created by a transaction.
+type ClickhouseOutput struct {
+	BlockHeight uint64    `clickhouse:"block_height" number_field:"true"`
+	BlockHash   string    `clickhouse:"block_hash"`
+	BlockTime   time.Time `clickhouse:"block_time"`
+	TxID        string    `clickhouse:"txid"`
+	Vout        uint32    `clickhouse:"vout"`
+	Value       int64     `clickhouse:"value"`
+	Address     string    `clickhouse:"address" index:"bloom_filter GRANULARITY 1"`
+	Script      string    `clickhouse:"script"  index:"bloom_filter GRANULARITY 1"`
+	ScriptType  string    `clickhouse:"script_type"`
+}
+
+// ClickhouseInput is one row of the inputs table, each row is an UTXO spent by a transaction,
+// the coinbase inputs are not included because they spend nothing.
+type ClickhouseInput struct {
+	BlockHeight uint64    `clickhouse:"block_height" number_field:"true"`
+	BlockHash   string    `clickhouse:"block_hash"`
+	BlockTime   time.Time `clickhouse:"block_time"`
+	TxID        string    `clickhouse:"txid"`
+	Vin         uint32    `clickhouse:"vin"`
+	PrevTxID    string    `clickhouse:"prev_txid" index:"bloom_filter GRANULARITY 1"`
+	PrevVout    uint32    `clickhouse:"prev_vout"`
+	Value       int64     `clickhouse:"value"`
+	Address     string    `clickhouse:"address" index:"bloom_filter GRANULARITY 1"`
+	Script      string    `clickhouse:"script"  index:"bloom_filter GRANULARITY 1"`
+}
+
+func values[T any](row *T) []any {
+	return objectx.CollectFieldValues(row, objectx.HasTag("clickhouse"))
+}
+
+// only need the fields have tag required:"true"
+func (ct *ClickhouseTransaction) toWrappedTransaction() (btc.WrappedTransaction, error) {
+	txn := btc.WrappedTransaction{
+		BlockHash:        ct.BlockHash,
+		BlockHeight:      ct.BlockHeight,
+		BlockTime:        ct.BlockTime.Unix(),
+		TransactionIndex: uint64(ct.TransactionIndex),
+	}
+	if err := json.Unmarshal([]byte(ct.TransactionJSON), &txn.Transaction); err != nil {
+		return txn, errors.Wrapf(err, "unmarshal txn %s in block %d/%s failed",
+			ct.TxID, ct.BlockHeight, ct.BlockHash)
+	}
+	return txn, nil
+}
@@ -0,0 +1,161 @@
+package ch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/chain/clickhouse"
+	"sentioxyz/sentio-core/common/chx"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+const (
+	tableNameBlocks       = "blocks"
+	tableNameTransactions = "transactions"
+	tableNameOutputs      = "outputs"
+	tableNameInputs       = "inputs"
+)
+
+type ClickhouseSchemaMgr struct {
+	tablesMeta         clickhouse.TablesMeta
+	convertConcurrency uint
+}
+
+func NewClickhouseSchemaMgr(
+	ctrl chx.Controller,
+	blockPartitionSize uint64,
+	convertConcurrency uint,
+) *ClickhouseSchemaMgr {
+	buildTable := func(name string, obj any, orderBy []string, withProjection bool) clickhouse.TableSchema {
+		settings := make(map[string]string)
+		chx.WithLightDeleteTableSettings(settings)
+		if withProjection {
+			chx.WithProjectionTableSettings(settings)
+		}
+		return clickhouse.BuildTable(
+			name,
+			obj,
+			chx.TableConfig{
+				Engine:      ctrl.NewDefaultMergeTreeEngine(),
+				PartitionBy: fmt.Sprintf("intDiv(block_height, %d)", blockPartitionSize),
+				OrderBy:     orderBy,
+				Settings:    settings,
+			},
+			"",
+		)
+	}
+	return &ClickhouseSchemaMgr{
+		tablesMeta: clickhouse.TablesMeta{
+			// blocks is index 0 so CheckMissing uses its dense one-row-per-block count.
+			Tables: []clickhouse.TableSchema{
+				buildTable(tableNameBlocks, &ClickhouseBlock{}, []string{"block_height"}, false),
+				buildTable(tableNameTransactions, &ClickhouseTransaction{}, []string{"block_height", "transaction_index"}, true),
+				buildTable(tableNameOutputs, &ClickhouseOutput{}, []string{"block_height", "txid", "vout"}, true),
+				buildTable(tableNameInputs, &ClickhouseInput{}, []string{"block_height", "txid", "vin"}, true),
+			},
+			LinkTableIndex:           0,
+			LinkTableNumberField:     "block_height",
+			LinkTableHashField:       "block_hash",
+			LinkTableParentHashField: "parent_hash",
+			BlockTableIndex:          -1,
+		},
+		convertConcurrency: convertConcurrency,
+	}
+}
+
+func (m *ClickhouseSchemaMgr) GetTablesMeta() clickhouse.TablesMeta {
+	return m.tablesMeta
+}
+
+func (m *ClickhouseSchemaMgr) Convert(_ context.Context, st *btc.Slot) (clickhouse.Chunk, error) {
+	blockTime := time.Unix(st.Time, 0)
+	headerJSON, err := json.Marshal(st.BlockHeader)
+	if err != nil {
+		return clickhouse.Chunk{}, errors.Wrapf(err, "marshal header of block %d/%s failed", st.Height, st.Hash)
+	}
+	var txRows, outputRows, inputRows [][]any
+	for i, tx := range st.Transactions {
+		txJSON, err := json.Marshal(tx)
+		if err != nil {
+			return clickhouse.Chunk{}, errors.Wrapf(err, "marshal txn %s in block %d/%s failed", tx.TxID, st.Height, st.Hash)
+		}
+		var fee int64
+		if tx.Fee != nil {
+			fee = int64(*tx.Fee)
+		}
+		txRows = append(txRows, values(&ClickhouseTransaction{
+			BlockHeight:      st.Height,
+			BlockHash:        st.Hash,
+			BlockTime:        blockTime,
+			TransactionIndex: uint32(i),
+			TxID:             tx.TxID,
+			IsCoinbase:       tx.IsCoinbase(),
+			Addresses:        tx.Addresses(),
+			Scripts:          tx.Scripts(),
+			Fee:              fee,
+			TransactionJSON:  string(txJSON),
+		}))
+		for _, out := range tx.Vout {
+			outputRows = append(outputRows, values(&ClickhouseOutput{
+				BlockHeight: st.Height,
+				BlockHash:   st.Hash,
+				BlockTime:   blockTime,
+				TxID:        tx.TxID,
+				Vout:        out.N,
+				Value:       int64(out.Value),
+				Address:     out.ScriptPubKey.Address,
+				Script:      out.ScriptPubKey.Hex,
+				ScriptType:  out.ScriptPubKey.Type,
+			}))
+		}
+		for j, in := range tx.Vin {
+			if in.IsCoinbase() {
+				continue
+			}
+			if in.PrevOut == nil {
+				return clickhouse.Chunk{}, errors.Errorf("input %d of txn %s in block %d/%s miss prevout",
+					j, tx.TxID, st.Height, st.Hash)
+			}
+			inputRows = append(inputRows, values(&ClickhouseInput{
+				BlockHeight: st.Height,
+				BlockHash:   st.Hash,
+				BlockTime:   blockTime,
+				TxID:        tx.TxID,
+				Vin:         uint32(j),
+				PrevTxID:    in.TxID,
+				PrevVout:    in.Vout,
+				Value:       int64(in.PrevOut.Value),
+				Address:     in.PrevOut.ScriptPubKey.Address,
+				Script:      in.PrevOut.ScriptPubKey.Hex,
+			}))
+		}
+	}
+	rows := make([][]any, 0, 1+len(txRows)+len(outputRows)+len(inputRows))
+	rows = append(rows, values(&ClickhouseBlock{
+		BlockHeight:     st.Height,
+		BlockHash:       st.Hash,
+		ParentHash:      st.PreviousBlockHash,
+		BlockTime:       blockTime,
+		BlockHeaderJSON: string(headerJSON),
+	}))
+	rows = append(rows, txRows...)
+	rows = append(rows, outputRows...)
+	rows = append(rows, inputRows...)
+	return clickhouse.Chunk{
+		RowNum:  []int{1, len(txRows), len(outputRows), len(inputRows)},
+		RowData: rows,
+	}, nil
+}
+
+func (m *ClickhouseSchemaMgr) ConvertConcurrency() uint {
+	return m.convertConcurrency
+}
+
+func (m *ClickhouseSchemaMgr) Done(r rg.Range) error {
+	return nil
+}
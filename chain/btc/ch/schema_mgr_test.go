@@ -0,0 +1,118 @@
+package ch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/chain/btc/btctest"
+	"sentioxyz/sentio-core/common/chx"
+	"sentioxyz/sentio-core/common/objectx"
+)
+
+func loadSlot(t *testing.T, height uint64) *btc.Slot {
+	var block btc.Block
+	require.NoError(t, json.Unmarshal(btctest.BlockJSON(height), &block))
+	return btc.NewSlot(&block)
+}
+
+func scanRow[T any](t *testing.T, values []any) T {
+	var row T
+	pointers := objectx.CollectFieldPointers(&row, objectx.HasTag("clickhouse"))
+	require.Len(t, pointers, len(values))
+	for i, v := range values {
+		switch p := pointers[i].(type) {
+		case *uint64:
+			*p = v.(uint64)
+		case *uint32:
+			*p = v.(uint32)
+		case *int64:
+			*p = v.(int64)
+		case *bool:
+			*p = v.(bool)
+		case *string:
+			*p = v.(string)
+		case *time.Time:
+			*p = v.(time.Time)
+		case *[]string:
+			*p = v.([]string)
+		default:
+			t.Fatalf("unexpected field type %T", p)
+		}
+	}
+	return row
+}
+
+func Test_Convert(t *testing.T) {
+	m := NewClickhouseSchemaMgr(chx.New(nil, chx.WithDatabase("db")), 1000, 1)
+	meta := m.GetTablesMeta()
+	var tableNames []string
+	for _, tbl := range meta.Tables {
+		tableNames = append(tableNames, tbl.Table.Name)
+	}
+	assert.Equal(t, []string{tableNameBlocks, tableNameTransactions, tableNameOutputs, tableNameInputs}, tableNames)
+
+	st := loadSlot(t, 102)
+	chunk, err := m.Convert(context.Background(), st)
+	require.NoError(t, err)
+	// 3 transactions: the coinbase with 2 outputs, TxB with 2 outputs and 1 input, TxC with 1 output and 1 input
+	require.Equal(t, []int{1, 3, 5, 2}, chunk.RowNum)
+	require.Len(t, chunk.RowData, 11)
+	blockTime := time.Unix(1719000000+102*600, 0)
+
+	block := scanRow[ClickhouseBlock](t, chunk.RowData[0])
+	assert.Equal(t, uint64(102), block.BlockHeight)
+	assert.Equal(t, btctest.BlockHash(102), block.BlockHash)
+	assert.Equal(t, btctest.BlockHash(101), block.ParentHash)
+	assert.Equal(t, blockTime, block.BlockTime)
+	var header btc.BlockHeader
+	require.NoError(t, json.Unmarshal([]byte(block.BlockHeaderJSON), &header))
+	assert.Equal(t, st.BlockHeader, header)
+
+	txB := scanRow[ClickhouseTransaction](t, chunk.RowData[2])
+	assert.Equal(t, btctest.TxB, txB.TxID)
+	assert.Equal(t, uint32(1), txB.TransactionIndex)
+	assert.False(t, txB.IsCoinbase)
+	assert.Equal(t, []string{btctest.AddressX, btctest.AddressY}, txB.Addresses)
+	assert.Contains(t, txB.Scripts, btctest.ScriptY)
+	assert.Equal(t, int64(2820), txB.Fee)
+	wrapped, err := txB.toWrappedTransaction()
+	require.NoError(t, err)
+	assert.Equal(t, st.GetTransactions()[1], wrapped)
+	assert.True(t, scanRow[ClickhouseTransaction](t, chunk.RowData[1]).IsCoinbase)
+
+	assert.Equal(t, ClickhouseOutput{
+		BlockHeight: 102,
+		BlockHash:   btctest.BlockHash(102),
+		BlockTime:   blockTime,
+		TxID:        btctest.TxB,
+		Vout:        0,
+		Value:       4 * 100000000,
+		Address:     btctest.AddressY,
+		Script:      btctest.ScriptY,
+		ScriptType:  "pubkeyhash",
+	}, scanRow[ClickhouseOutput](t, chunk.RowData[6]))
+
+	assert.Equal(t, ClickhouseInput{
+		BlockHeight: 102,
+		BlockHash:   btctest.BlockHash(102),
+		BlockTime:   blockTime,
+		TxID:        btctest.TxC,
+		Vin:         0,
+		PrevTxID:    btctest.TxA,
+		PrevVout:    1,
+		Value:       3999998590,
+		Address:     btctest.AddressC,
+		Script:      "00142e7d2c03a9507ae265ecf5b5356885a53393a202",
+	}, scanRow[ClickhouseInput](t, chunk.RowData[10]))
+
+	// the spent output is required to index the spends
+	st.Transactions[2].Vin[0].PrevOut = nil
+	_, err = m.Convert(context.Background(), st)
+	assert.ErrorContains(t, err, "miss prevout")
+}
@@ -0,0 +1,169 @@
+package ch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/common/chx"
+	"sentioxyz/sentio-core/common/objectx"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+// Store reads the Bitcoin blocks, transactions and spends from ClickHouse for the super node.
+//
+// The tables are plain MergeTree without deduplication, re-ingesting a block leaves identical rows
+// behind, so every query selects DISTINCT.
+type Store struct {
+	ctrl chx.Controller
+
+	statistic
+}
+
+func NewStore(ctrl chx.Controller) *Store {
+	s := &Store{ctrl: ctrl}
+	s.init()
+	return s
+}
+
+func (s *Store) QueryBlockLinks(ctx context.Context, from, to uint64) (result []btc.BlockLink, err error) {
+	start := time.Now()
+	defer func() { s.record(ctx, "queryBlockLinks", time.Since(start), len(result)) }()
+
+	sql := fmt.Sprintf(
+		"SELECT DISTINCT block_height, block_hash, parent_hash, block_time FROM %s "+
+			"WHERE block_height >= ? AND block_height <= ? ORDER BY block_height",
+		s.ctrl.FullLogicName(tableNameBlocks))
+	err = s.ctrl.Query(ctx, func(rows driver.Rows) error {
+		var link btc.BlockLink
+		var blockTime time.Time
+		if scanErr := rows.Scan(&link.Number, &link.Hash, &link.ParentHash, &blockTime); scanErr != nil {
+			return scanErr
+		}
+		link.Time = blockTime.Unix()
+		result = append(result, link)
+		return nil
+	}, sql, from, to)
+	return result, err
+}
+
+// buildTransactionsWhere builds the where condition of the transactions table, the condition is looser
+// than the filters because the address and the script of one filter may be matched by different outputs,
+// so the result should be checked by btc.CheckTransaction again.
+func buildTransactionsWhere(startBlock, endBlock uint64, filters []btc.TransactionFilter) (string, []any) {
+	where := "block_height >= ? AND block_height <= ?"
+	args := []any{startBlock, endBlock}
+	if len(filters) == 0 || utils.HasAny(filters, btc.TransactionFilter.IsEmpty) {
+		return where, args
+	}
+	var conditions []string
+	for _, filter := range filters {
+		var condition []string
+		if filter.Address != "" {
+			condition = append(condition, "has(addresses, ?)")
+			args = append(args, filter.Address)
+		}
+		if filter.Script != "" {
+			condition = append(condition, "has(scripts, ?)")
+			args = append(args, strings.ToLower(filter.Script))
+		}
+		conditions = append(conditions, strings.Join(condition, " AND "))
+	}
+	if len(conditions) == 1 {
+		return where + " AND " + conditions[0], args
+	}
+	return where + " AND ((" + strings.Join(conditions, ") OR (") + "))", args
+}
+
+func (s *Store) QueryTransactions(
+	ctx context.Context,
+	startBlock uint64,
+	endBlock uint64,
+	filters []btc.TransactionFilter,
+	limit int,
+) (result []btc.WrappedTransaction, err error) {
+	start := time.Now()
+	defer func() { s.record(ctx, "queryTransactions", time.Since(start), len(result)) }()
+
+	where, args := buildTransactionsWhere(startBlock, endBlock, filters)
+	fieldFilter := objectx.HasTag("clickhouse").And(objectx.AnyHasTagEqualTo("required", "true"))
+	columns := objectx.CollectTagValue(&ClickhouseTransaction{}, "clickhouse", fieldFilter)
+	sql := fmt.Sprintf("SELECT DISTINCT `%s` FROM %s WHERE %s ORDER BY block_height, transaction_index",
+		strings.Join(columns, "`,`"),
+		s.ctrl.FullLogicName(tableNameTransactions),
+		where)
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	var rawRows int
+	err = s.ctrl.Query(ctx, func(rows driver.Rows) error {
+		var row ClickhouseTransaction
+		if scanErr := rows.Scan(objectx.CollectFieldPointers(&row, fieldFilter)...); scanErr != nil {
+			return scanErr
+		}
+		rawRows++
+		tx, parseErr := row.toWrappedTransaction()
+		if parseErr != nil {
+			return parseErr
+		}
+		if btc.CheckTransaction(tx, filters) {
+			result = append(result, tx)
+		}
+		return nil
+	}, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	// the raw rows are counted before the Go-side post filter, so the check is conservative, but a
+	// returned result is always complete
+	if limit > 0 && rawRows >= limit {
+		return nil, chain.NewTooManyResultsError()
+	}
+	return result, nil
+}
+
+// QuerySpentBy returns the inputs spending the outputs, the outputs not spent yet are not included.
+// An output spent in more than one block means the rows of a replaced fork are still in the table,
+// all of them are returned and the caller should check the block hash.
+func (s *Store) QuerySpentBy(ctx context.Context, outPoints []btc.OutPoint) (result []btc.SpentBy, err error) {
+	start := time.Now()
+	defer func() { s.record(ctx, "querySpentBy", time.Since(start), len(result)) }()
+
+	if len(outPoints) == 0 {
+		return nil, nil
+	}
+	wanted := make(map[btc.OutPoint]bool)
+	txIDs := make(map[string]bool)
+	for _, op := range outPoints {
+		wanted[op] = true
+		txIDs[op.TxID] = true
+	}
+	sql := fmt.Sprintf(
+		"SELECT DISTINCT prev_txid, prev_vout, txid, vin, block_hash, block_height FROM %s "+
+			"WHERE prev_txid IN ? ORDER BY block_height, txid, vin",
+		s.ctrl.FullLogicName(tableNameInputs))
+	err = s.ctrl.Query(ctx, func(rows driver.Rows) error {
+		var spent btc.SpentBy
+		if scanErr := rows.Scan(
+			&spent.OutPoint.TxID, &spent.OutPoint.Vout,
+			&spent.TxID, &spent.Vin, &spent.BlockHash, &spent.BlockHeight,
+		); scanErr != nil {
+			return scanErr
+		}
+		// the other outputs of the same transaction are also selected by prev_txid
+		if wanted[spent.OutPoint] {
+			result = append(result, spent)
+		}
+		return nil
+	}, sql, utils.GetOrderedMapKeys(txIDs))
+	if err != nil {
+		return nil, errors.Wrapf(err, "query spent by of %d outpoints failed", len(outPoints))
+	}
+	return result, nil
+}
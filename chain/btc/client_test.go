@@ -0,0 +1,87 @@
+package btc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/btc/btctest"
+	"sentioxyz/sentio-core/chain/clientpool"
+)
+
+func newTestClient(t *testing.T) (*Client, *btctest.Server) {
+	svr := btctest.NewServer(t)
+	config := ClientConfig{JSONRPCConfig: clientpool.JSONRPCConfig{Endpoint: svr.URL}}.Trim()
+	cli, err := NewClient(config, func(string, time.Duration, bool) {})
+	require.NoError(t, err)
+	return cli, svr
+}
+
+func Test_ClientInit(t *testing.T) {
+	cli, svr := newTestClient(t)
+	latest, err := cli.Init(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, btctest.LatestHeight, latest.Number)
+	assert.Equal(t, btctest.BlockHash(btctest.LatestHeight), latest.Hash)
+	assert.Equal(t, int64(1719000000+103*600), latest.Timestamp.Unix())
+	assert.Equal(t, []string{"getbestblockhash", "getblockheader"}, svr.Calls())
+}
+
+func Test_ClientGetBlock(t *testing.T) {
+	ctx := context.Background()
+	cli, _ := newTestClient(t)
+
+	header, r := cli.GetBlockHeader(ctx, "test", 102)
+	require.NoError(t, r.Err)
+	assert.Equal(t, BlockLink{
+		Number:     102,
+		Hash:       btctest.BlockHash(102),
+		ParentHash: btctest.BlockHash(101),
+		Time:       1719000000 + 102*600,
+	}, header.Link())
+	assert.Equal(t, uint64(3), header.NTx)
+
+	block, r := cli.GetBlock(ctx, "test", 102)
+	require.NoError(t, r.Err)
+	assert.Equal(t, *header, block.BlockHeader)
+	require.Len(t, block.Transactions, 3)
+	assert.True(t, block.Transactions[0].IsCoinbase())
+	txB := block.Transactions[1]
+	assert.Equal(t, btctest.TxB, txB.TxID)
+	require.Len(t, txB.Vin, 1)
+	assert.Equal(t, btctest.TxA, txB.Vin[0].TxID)
+	assert.Equal(t, uint32(0), txB.Vin[0].Vout)
+	require.NotNil(t, txB.Vin[0].PrevOut)
+	assert.Equal(t, Amount(10*satoshisPerBTC), txB.Vin[0].PrevOut.Value)
+	assert.Equal(t, btctest.AddressX, txB.Vin[0].PrevOut.ScriptPubKey.Address)
+	assert.Equal(t, Amount(599997180), txB.Vout[1].Value)
+	assert.Equal(t, []string{btctest.AddressX, btctest.AddressY}, txB.Addresses())
+
+	_, r = cli.GetBlock(ctx, "test", 104)
+	assert.Error(t, r.Err)
+
+	// the method black list
+	cli.config.MethodBlackList = []string{"getblock"}
+	_, r = cli.GetBlock(ctx, "test", 102)
+	assert.Error(t, r.Err)
+}
+
+func Test_checkBlock(t *testing.T) {
+	block := &Block{
+		BlockHeader: BlockHeader{Hash: "h", Height: 1, NTx: 2},
+		Transactions: []Transaction{
+			{TxID: "t0", Vin: []Input{{Coinbase: "00"}}},
+			{TxID: "t1", Vin: []Input{{TxID: "t0", PrevOut: &PrevOut{}}}},
+		},
+	}
+	assert.NoError(t, checkBlock(1, "h", block))
+	assert.Error(t, checkBlock(2, "h", block))
+	assert.Error(t, checkBlock(1, "x", block))
+	block.Transactions[1].Vin[0].PrevOut = nil
+	assert.ErrorContains(t, checkBlock(1, "h", block), "miss prevout")
+	block.NTx = 3
+	assert.ErrorContains(t, checkBlock(1, "h", block), "should have 3 transactions")
+}
@@ -0,0 +1,82 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/clientpool"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+type ExtServerDimension struct {
+	client *ClientPool
+
+	*chain.ExtServerDimension[*Slot]
+}
+
+var _ chain.Dimension[*Slot] = (*ExtServerDimension)(nil)
+
+func NewExtServerDimension(
+	client *ClientPool,
+	loadConcurrency uint,
+	loadBatchSize uint,
+	loadRetry int,
+	validRange rg.Range,
+	fallBehind time.Duration,
+) *ExtServerDimension {
+	dim := &ExtServerDimension{client: client}
+	dim.ExtServerDimension = chain.NewExtServerDimension[*Slot](
+		client,
+		loadConcurrency,
+		loadBatchSize,
+		loadRetry,
+		validRange,
+		fallBehind,
+		dim)
+	return dim
+}
+
+func (d *ExtServerDimension) GetSlotHeader(ctx context.Context, sn uint64) (chain.Slot, error) {
+	var header *BlockHeader
+	r := d.client.UseClient(
+		ctx,
+		fmt.Sprintf("ext.GetSlotHeader/%d", sn),
+		func(ctx context.Context, cli *Client) (r clientpool.Result) {
+			header, r = cli.GetBlockHeader(ctx, "ext", sn)
+			r.BrokenForTask = r.Err != nil // always retry using other client
+			return r
+		},
+	)
+	if r.Err != nil {
+		return nil, errors.Wrapf(r.Err, "get header for block %d (%s) failed", sn, r.ConfigName)
+	}
+	return NewSlot(&Block{BlockHeader: *header}), nil
+}
+
+func (d *ExtServerDimension) GetSlots(ctx context.Context, sr rg.Range) ([]*Slot, error) {
+	slots := make([]*Slot, 0, *sr.Size())
+	r := d.client.UseClient(
+		ctx,
+		fmt.Sprintf("ext.GetSlots/%s", sr),
+		func(ctx context.Context, cli *Client) (r clientpool.Result) {
+			slots = slots[:0]
+			var block *Block
+			for sn := sr.Start; sn <= *sr.End; sn++ {
+				if block, r = cli.GetBlock(ctx, "ext", sn); r.Err != nil {
+					r.BrokenForTask = true // always retry using other client
+					return r
+				}
+				slots = append(slots, NewSlot(block))
+			}
+			return r
+		},
+	)
+	if r.Err != nil {
+		return nil, errors.Wrapf(r.Err, "get blocks %s (%s) failed", sr, r.ConfigName)
+	}
+	return slots, nil
+}
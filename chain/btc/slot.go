@@ -0,0 +1,69 @@
+package btc
+
+import (
+	"sentioxyz/sentio-core/chain/chain"
+)
+
+type Slot struct {
+	*Block
+}
+
+var _ chain.Slot = (*Slot)(nil)
+
+func NewSlot(block *Block) *Slot {
+	return &Slot{block}
+}
+
+func (s *Slot) GetNumber() uint64 {
+	return s.Height
+}
+
+func (s *Slot) GetHash() string {
+	return s.Hash
+}
+
+func (s *Slot) GetParentHash() string {
+	return s.PreviousBlockHash
+}
+
+func (s *Slot) Features() []string {
+	return nil
+}
+
+func (s *Slot) Linked() bool {
+	return true
+}
+
+func (s *Slot) GetTransactions() []WrappedTransaction {
+	txns := make([]WrappedTransaction, len(s.Transactions))
+	for i, raw := range s.Transactions {
+		txns[i] = WrappedTransaction{
+			BlockHash:        s.Hash,
+			BlockHeight:      s.Height,
+			BlockTime:        s.Time,
+			TransactionIndex: uint64(i),
+			Transaction:      raw,
+		}
+	}
+	return txns
+}
+
+// GetSpends returns the spent outputs of all the non-coinbase inputs in the block
+func (s *Slot) GetSpends() []SpentBy {
+	var spends []SpentBy
+	for _, tx := range s.Transactions {
+		for i, in := range tx.Vin {
+			if in.IsCoinbase() {
+				continue
+			}
+			spends = append(spends, SpentBy{
+				OutPoint:    OutPoint{TxID: in.TxID, Vout: in.Vout},
+				TxID:        tx.TxID,
+				Vin:         uint32(i),
+				BlockHash:   s.Hash,
+				BlockHeight: s.Height,
+			})
+		}
+	}
+	return spends
+}
@@ -0,0 +1,243 @@
+package supernode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/clientpool"
+	"sentioxyz/sentio-core/common/jsonrpc"
+	"sentioxyz/sentio-core/common/log"
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+func NewSuperNode(
+	client *btc.ClientPool,
+	slotCache chain.LatestSlotCache[*btc.Slot],
+	rangeStore chain.RangeStore,
+	store Storage,
+) []jsonrpc.Middleware {
+	rpcSvr := &RPCService{
+		client:     client,
+		slotCache:  slotCache,
+		rangeStore: rangeStore,
+		store:      store,
+	}
+	return []jsonrpc.Middleware{
+		func(next jsonrpc.MethodHandler) jsonrpc.MethodHandler {
+			return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+				switch method {
+				case "btc_getLatestHeight":
+					return rpcSvr.GetLatestHeight(ctx)
+				case "btc_getLatestHeader":
+					return jsonrpc.CallMethod(rpcSvr.GetLatestHeader, ctx, params)
+				case "btc_getBlockHeader":
+					return jsonrpc.CallMethod(rpcSvr.GetBlockHeader, ctx, params)
+				case "btc_getBlockLinks":
+					return jsonrpc.CallMethod(rpcSvr.GetBlockLinks, ctx, params)
+				case "btc_getTransactions":
+					return jsonrpc.CallMethod(rpcSvr.GetTransactions, ctx, params)
+				case "btc_getSpentBy":
+					return jsonrpc.CallMethod(rpcSvr.GetSpentBy, ctx, params)
+				default:
+					return next(ctx, method, params)
+				}
+			}
+		},
+		jsonrpc.NewHTTPProxyMiddleware("", client.ClientPool),
+	}
+}
+
+type RPCService struct {
+	client     *btc.ClientPool
+	slotCache  chain.LatestSlotCache[*btc.Slot]
+	rangeStore chain.RangeStore
+	store      Storage
+}
+
+func (s *RPCService) GetLatestHeight(ctx context.Context) (uint64, error) {
+	r, err := s.slotCache.GetRange(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return *r.End, nil
+}
+
+func (s *RPCService) GetLatestHeader(ctx context.Context, blockHeightGt uint64) (btc.GetLatestBlockResponse, error) {
+	jsonrpc.GetCtxData(ctx).NotSlowRequest = true
+	resp := btc.GetLatestBlockResponse{APIVersion: btc.APIVersion}
+	latest, err := s.slotCache.Wait(ctx, blockHeightGt)
+	if err != nil {
+		return resp, err
+	}
+	latestSlot, err := s.slotCache.GetByNumber(ctx, latest)
+	if err != nil {
+		return resp, err
+	}
+	resp.Latest = latestSlot.Link()
+	return resp, nil
+}
+
+func (s *RPCService) GetBlockHeader(ctx context.Context, height uint64) (btc.BlockHeader, error) {
+	headers, err := chain.QueryRangeWithCache[*btc.Slot, btc.BlockHeader](
+		ctx,
+		rg.NewSingleRange(height),
+		s.slotCache,
+		func(st *btc.Slot) ([]btc.BlockHeader, error) {
+			return []btc.BlockHeader{st.BlockHeader}, nil
+		},
+		func(ctx context.Context, queryRange rg.Range) ([]btc.BlockHeader, error) {
+			// here queryRange always be [height,height]
+			var header *btc.BlockHeader
+			err := s.client.UseClient(ctx, fmt.Sprintf("proxy.GetBlockHeader/%d", height),
+				func(ctx context.Context, cli *btc.Client) (r clientpool.Result) {
+					header, r = cli.GetBlockHeader(ctx, "proxy.GetBlockHeader", height)
+					r.BrokenForTask = r.Err != nil // always retry using other client
+					return r
+				},
+			).Err
+			if err != nil {
+				return nil, err
+			}
+			return []btc.BlockHeader{*header}, nil
+		},
+	)
+	if err != nil {
+		return btc.BlockHeader{}, err
+	}
+	if len(headers) == 0 {
+		return btc.BlockHeader{}, chain.ErrSlotNotFound
+	}
+	return headers[0], err
+}
+
+// maxQuerySpan / maxTransactions bound a single btc_getTransactions or btc_getBlockLinks query like
+// the other chains: a multi-block query returning more than maxTransactions fails with
+// chain.NewTooManyResultsError so the caller shrinks the range and retries. A block holds a few
+// thousand transactions at most, so the single-block query exempt from the cap stays bounded.
+// maxOutPoints bounds the outpoints of one btc_getSpentBy query.
+const (
+	maxQuerySpan    = 100000
+	maxTransactions = 5000
+	maxOutPoints    = 1000
+)
+
+// GetBlockLinks returns the chain identity of every block in [from, to], the caller can compare them
+// with the result of the previous call to detect a reorg.
+func (s *RPCService) GetBlockLinks(ctx context.Context, from, to uint64) ([]btc.BlockLink, error) {
+	if err := chain.CheckQuerySpan(from, to, maxQuerySpan); err != nil {
+		return nil, err
+	}
+	links, err := chain.QueryRangeWithCache[*btc.Slot, btc.BlockLink](
+		ctx,
+		rg.NewRange(from, to),
+		s.slotCache,
+		func(st *btc.Slot) ([]btc.BlockLink, error) {
+			return []btc.BlockLink{st.Link()}, nil
+		},
+		chain.CheckRange(s.rangeStore, func(ctx context.Context, queryRange rg.Range) ([]btc.BlockLink, error) {
+			links, err := s.store.QueryBlockLinks(ctx, queryRange.Start, *queryRange.End)
+			if err != nil {
+				return nil, err
+			}
+			if err = btc.CheckBlockLinks(links, queryRange.Start, *queryRange.End); err != nil {
+				return nil, errors.Wrapf(err, "the store has invalid block links, retry later")
+			}
+			return links, nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// the cached part and the stored part should also be linked
+	if err = btc.CheckBlockLinks(links, from, to); err != nil {
+		return nil, errors.Wrapf(err, "invalid block links, retry later")
+	}
+	return links, nil
+}
+
+func (s *RPCService) GetTransactions(
+	ctx context.Context,
+	param btc.GetTransactionsParam,
+) ([]btc.WrappedTransaction, error) {
+	_, logger := log.FromContext(ctx)
+	if err := chain.CheckQuerySpan(param.StartHeight, param.EndHeight, maxQuerySpan); err != nil {
+		return nil, err
+	}
+	for _, filter := range param.Filters {
+		if filter.IsEmpty() {
+			logger.Warn("there is an empty filter, which is equivalent to no filter")
+			param.Filters = nil
+			break
+		}
+	}
+	limit := chain.RangeQueryLimit(param.StartHeight, param.EndHeight, maxTransactions)
+	result, err := chain.QueryRangeWithCache[*btc.Slot, btc.WrappedTransaction](
+		ctx,
+		rg.NewRange(param.StartHeight, param.EndHeight),
+		s.slotCache,
+		func(st *btc.Slot) ([]btc.WrappedTransaction, error) {
+			return utils.FilterArr(st.GetTransactions(), func(tx btc.WrappedTransaction) bool {
+				return btc.CheckTransaction(tx, param.Filters)
+			}), nil
+		},
+		chain.CheckRange(s.rangeStore, func(ctx context.Context, queryRange rg.Range) ([]btc.WrappedTransaction, error) {
+			return s.store.QueryTransactions(ctx, queryRange.Start, *queryRange.End, param.Filters, chain.StoreQueryLimit(limit))
+		}),
+	)
+	return chain.CheckTooManyResults(result, err, limit)
+}
+
+// GetSpentBy returns the inputs spending the outpoints, the unspent outpoints are not included.
+// The spends in the latest slot cache are authoritative for the blocks it holds, the store only
+// answers for the outpoints not spent in the cache, and only with the spends below the cache,
+// because the spends in the range of the cache may be replaced by a reorg.
+// The spends older than the range of the store are unknown, so an outpoint missing in the result
+// means it is not spent in the range of the store or the cache.
+func (s *RPCService) GetSpentBy(ctx context.Context, outPoints []btc.OutPoint) ([]btc.SpentBy, error) {
+	if len(outPoints) > maxOutPoints {
+		return nil, errors.Errorf("too many outpoints %d, the limit is %d", len(outPoints), maxOutPoints)
+	}
+	wanted := make(map[btc.OutPoint]bool)
+	for _, op := range outPoints {
+		wanted[op] = true
+	}
+	var cached []btc.SpentBy
+	cacheRange, err := s.slotCache.GetRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheRange, err = s.slotCache.Traverse(ctx, cacheRange, func(ctx context.Context, st *btc.Slot) error {
+		for _, spent := range st.GetSpends() {
+			if wanted[spent.OutPoint] {
+				cached = append(cached, spent)
+				delete(wanted, spent.OutPoint)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(wanted) == 0 {
+		return cached, nil
+	}
+	remain := utils.FilterArr(outPoints, func(op btc.OutPoint) bool {
+		return wanted[op]
+	})
+	stored, err := s.store.QuerySpentBy(ctx, remain)
+	if err != nil {
+		return nil, err
+	}
+	if !cacheRange.IsEmpty() {
+		stored = utils.FilterArr(stored, func(spent btc.SpentBy) bool {
+			return spent.BlockHeight < cacheRange.Start
+		})
+	}
+	return append(stored, cached...), nil
+}
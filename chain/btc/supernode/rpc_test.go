@@ -0,0 +1,240 @@
+package supernode
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/chain/btc/btctest"
+	"sentioxyz/sentio-core/chain/chain"
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+func loadSlot(t *testing.T, height uint64) *btc.Slot {
+	var block btc.Block
+	require.NoError(t, json.Unmarshal(btctest.BlockJSON(height), &block))
+	return btc.NewSlot(&block)
+}
+
+// testSlotCache holds the slots in order
+type testSlotCache struct {
+	chain.LatestSlotCache[*btc.Slot]
+
+	slots []*btc.Slot
+}
+
+func (c *testSlotCache) GetRange(context.Context) (rg.Range, error) {
+	return rg.NewRange(c.slots[0].GetNumber(), c.slots[len(c.slots)-1].GetNumber()), nil
+}
+
+func (c *testSlotCache) Traverse(
+	ctx context.Context,
+	interval rg.Range,
+	fn func(ctx context.Context, st *btc.Slot) error,
+) (rg.Range, error) {
+	r, _ := c.GetRange(ctx)
+	cached := r.Intersection(interval)
+	for _, st := range c.slots {
+		if cached.Contains(st.GetNumber()) {
+			if err := fn(ctx, st); err != nil {
+				return rg.EmptyRange, err
+			}
+		}
+	}
+	return cached, nil
+}
+
+type testRangeStore struct {
+	chain.RangeStore
+
+	r rg.Range
+}
+
+func (s testRangeStore) Get(context.Context) (rg.Range, error) {
+	return s.r, nil
+}
+
+// testStorage holds the slots in order, the extra spends are returned by QuerySpentBy after the spends of the slots
+type testStorage struct {
+	slots       []*btc.Slot
+	extraSpends []btc.SpentBy
+}
+
+func (s *testStorage) QueryBlockLinks(_ context.Context, from, to uint64) (result []btc.BlockLink, err error) {
+	for _, st := range s.slots {
+		if st.GetNumber() >= from && st.GetNumber() <= to {
+			result = append(result, st.Link())
+		}
+	}
+	return result, nil
+}
+
+func (s *testStorage) QueryTransactions(
+	_ context.Context,
+	startBlock uint64,
+	endBlock uint64,
+	filters []btc.TransactionFilter,
+	limit int,
+) (result []btc.WrappedTransaction, err error) {
+	for _, st := range s.slots {
+		if st.GetNumber() >= startBlock && st.GetNumber() <= endBlock {
+			result = append(result, utils.FilterArr(st.GetTransactions(), func(tx btc.WrappedTransaction) bool {
+				return btc.CheckTransaction(tx, filters)
+			})...)
+		}
+	}
+	if limit > 0 && len(result) >= limit {
+		return nil, chain.NewTooManyResultsError()
+	}
+	return result, nil
+}
+
+func (s *testStorage) QuerySpentBy(_ context.Context, outPoints []btc.OutPoint) (result []btc.SpentBy, err error) {
+	for _, st := range s.slots {
+		for _, spent := range append(st.GetSpends(), s.extraSpends...) {
+			if utils.IndexOf(outPoints, spent.OutPoint) >= 0 {
+				result = append(result, spent)
+			}
+		}
+	}
+	return result, nil
+}
+
+// newTestService returns the service with the blocks [102,103] in the cache and the blocks [100,101] in the store
+func newTestService(t *testing.T) (*RPCService, *testStorage) {
+	var slots []*btc.Slot
+	for height := btctest.FirstHeight; height <= btctest.LatestHeight; height++ {
+		slots = append(slots, loadSlot(t, height))
+	}
+	store := &testStorage{slots: slots[:2]}
+	return &RPCService{
+		slotCache:  &testSlotCache{slots: slots[2:]},
+		rangeStore: testRangeStore{r: rg.NewRange(btctest.FirstHeight, 101)},
+		store:      store,
+	}, store
+}
+
+func Test_GetBlockLinks(t *testing.T) {
+	ctx := context.Background()
+	s, store := newTestService(t)
+
+	latest, err := s.GetLatestHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, btctest.LatestHeight, latest)
+
+	links, err := s.GetBlockLinks(ctx, 100, 103)
+	require.NoError(t, err)
+	require.Len(t, links, 4)
+	for i, link := range links {
+		assert.Equal(t, btctest.BlockHash(100+uint64(i)), link.Hash)
+	}
+
+	links, err = s.GetBlockLinks(ctx, 103, 103)
+	require.NoError(t, err)
+	assert.Equal(t, []btc.BlockLink{loadSlot(t, 103).Link()}, links)
+
+	// out of the range of the store
+	_, err = s.GetBlockLinks(ctx, 99, 103)
+	assert.Error(t, err)
+	_, err = s.GetBlockLinks(ctx, 103, 102)
+	assert.Error(t, err)
+
+	// the store holds the block 101 of another fork
+	forked := *store.slots[1].Block
+	forked.Hash = "fork"
+	store.slots[1] = btc.NewSlot(&forked)
+	_, err = s.GetBlockLinks(ctx, 100, 103)
+	assert.ErrorContains(t, err, "mid-reorg")
+	_, err = s.GetBlockLinks(ctx, 100, 101)
+	assert.NoError(t, err)
+
+	// the store misses the block 101
+	store.slots = store.slots[:1]
+	_, err = s.GetBlockLinks(ctx, 100, 101)
+	assert.ErrorContains(t, err, "the store has invalid block links")
+}
+
+func Test_GetTransactions(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t)
+
+	txIDs := func(txs []btc.WrappedTransaction) []string {
+		return utils.MapSliceNoError(txs, func(tx btc.WrappedTransaction) string {
+			return tx.TxID
+		})
+	}
+
+	txs, err := s.GetTransactions(ctx, btc.GetTransactionsParam{
+		StartHeight: 100,
+		EndHeight:   103,
+		Filters:     []btc.TransactionFilter{{Address: btctest.AddressX}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{btctest.TxA, btctest.TxB, btctest.TxC}, txIDs(txs))
+	assert.Equal(t, btctest.BlockHash(101), txs[0].BlockHash)
+	assert.Equal(t, btctest.BlockHash(102), txs[1].BlockHash)
+
+	txs, err = s.GetTransactions(ctx, btc.GetTransactionsParam{
+		StartHeight: 100,
+		EndHeight:   103,
+		Filters:     []btc.TransactionFilter{{Address: btctest.AddressY}, {Address: btctest.AddressC}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{btctest.TxA, btctest.TxB, btctest.TxC, btctest.TxD}, txIDs(txs))
+
+	// the empty filter matches all the transactions
+	txs, err = s.GetTransactions(ctx, btc.GetTransactionsParam{
+		StartHeight: 101,
+		EndHeight:   102,
+		Filters:     []btc.TransactionFilter{{Address: btctest.AddressY}, {}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, txs, 5)
+}
+
+func Test_GetSpentBy(t *testing.T) {
+	ctx := context.Background()
+	s, store := newTestService(t)
+
+	// the spend of TxB:1 in the store is replaced by a reorg
+	store.extraSpends = []btc.SpentBy{{
+		OutPoint:    btc.OutPoint{TxID: btctest.TxB, Vout: 1},
+		TxID:        "replaced",
+		BlockHash:   "fork",
+		BlockHeight: 103,
+	}}
+	spent, err := s.GetSpentBy(ctx, []btc.OutPoint{
+		{TxID: btctest.TxCoinbase1, Vout: 0},
+		{TxID: btctest.TxA, Vout: 1},
+		{TxID: btctest.TxB, Vout: 0},
+		{TxID: btctest.TxB, Vout: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []btc.SpentBy{{
+		OutPoint:    btc.OutPoint{TxID: btctest.TxCoinbase1, Vout: 0},
+		TxID:        btctest.TxA,
+		Vin:         0,
+		BlockHash:   btctest.BlockHash(101),
+		BlockHeight: 101,
+	}, {
+		OutPoint:    btc.OutPoint{TxID: btctest.TxA, Vout: 1},
+		TxID:        btctest.TxC,
+		Vin:         0,
+		BlockHash:   btctest.BlockHash(102),
+		BlockHeight: 102,
+	}, {
+		OutPoint:    btc.OutPoint{TxID: btctest.TxB, Vout: 0},
+		TxID:        btctest.TxD,
+		Vin:         0,
+		BlockHash:   btctest.BlockHash(103),
+		BlockHeight: 103,
+	}}, spent)
+
+	_, err = s.GetSpentBy(ctx, make([]btc.OutPoint, maxOutPoints+1))
+	assert.ErrorContains(t, err, "too many outpoints")
+}
@@ -0,0 +1,25 @@
+package supernode
+
+import (
+	"context"
+
+	"sentioxyz/sentio-core/chain/btc"
+)
+
+type Storage interface {
+	// QueryBlockLinks returns the chain identity of every block in [from, to], ascending.
+	QueryBlockLinks(ctx context.Context, from, to uint64) ([]btc.BlockLink, error)
+	// QueryTransactions scans at most limit raw rows (0 = unlimited; a SQL LIMIT bounding the
+	// ClickHouse-side resource use of one query) and fails with chain.NewTooManyResultsError when
+	// the scan hits it, so a returned result is always complete. The super node passes its record
+	// cap + 1 (chain.StoreQueryLimit), so a query matching exactly the cap still succeeds.
+	QueryTransactions(
+		ctx context.Context,
+		startBlock uint64,
+		endBlock uint64,
+		filters []btc.TransactionFilter,
+		limit int,
+	) ([]btc.WrappedTransaction, error)
+	// QuerySpentBy returns the inputs spending the outpoints, the unspent outpoints are not included.
+	QuerySpentBy(ctx context.Context, outPoints []btc.OutPoint) ([]btc.SpentBy, error)
+}
@@ -0,0 +1,293 @@
+package btc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/common/utils"
+)
+
+// Amount is the value in satoshis, it is encoded in JSON as the decimal BTC number like bitcoind does,
+// so the value is never rounded by float64
+type Amount int64
+
+const satoshisPerBTC = 100000000
+
+func (a Amount) String() string {
+	sign, v := "", int64(a)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, v/satoshisPerBTC, v%satoshisPerBTC)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	neg := strings.HasPrefix(s, "-")
+	intPart, fracPart, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if len(fracPart) > 8 || intPart == "" {
+		return errors.Errorf("invalid amount %s", data)
+	}
+	i, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid amount %s", data)
+	}
+	var f int64
+	if fracPart != "" {
+		if f, err = strconv.ParseInt(fracPart+strings.Repeat("0", 8-len(fracPart)), 10, 64); err != nil {
+			return errors.Wrapf(err, "invalid amount %s", data)
+		}
+	}
+	*a = Amount(i*satoshisPerBTC + f)
+	if neg {
+		*a = -*a
+	}
+	return nil
+}
+
+type ScriptSig struct {
+	Asm string `json:"asm"`
+	Hex string `json:"hex"`
+}
+
+type ScriptPubKey struct {
+	Asm     string `json:"asm"`
+	Desc    string `json:"desc,omitempty"`
+	Hex     string `json:"hex"`
+	Address string `json:"address,omitempty"`
+	Type    string `json:"type"`
+}
+
+// PrevOut is the output spent by an input, returned by getblock with verbosity 3
+type PrevOut struct {
+	Generated    bool         `json:"generated"`
+	Height       uint64       `json:"height"`
+	Value        Amount       `json:"value"`
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+type Input struct {
+	Coinbase string `json:"coinbase,omitempty"`
+	TxID     string `json:"txid,omitempty"`
+	// Vout is 0 for the coinbase input
+	Vout        uint32     `json:"vout"`
+	ScriptSig   *ScriptSig `json:"scriptSig,omitempty"`
+	TxInWitness []string   `json:"txinwitness,omitempty"`
+	PrevOut     *PrevOut   `json:"prevout,omitempty"`
+	Sequence    uint32     `json:"sequence"`
+}
+
+func (in Input) IsCoinbase() bool {
+	return in.Coinbase != ""
+}
+
+type Output struct {
+	Value        Amount       `json:"value"`
+	N            uint32       `json:"n"`
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+type Transaction struct {
+	TxID     string   `json:"txid"`
+	Hash     string   `json:"hash"`
+	Version  int32    `json:"version"`
+	Size     uint64   `json:"size"`
+	VSize    uint64   `json:"vsize"`
+	Weight   uint64   `json:"weight"`
+	LockTime uint32   `json:"locktime"`
+	Vin      []Input  `json:"vin"`
+	Vout     []Output `json:"vout"`
+	Fee      *Amount  `json:"fee,omitempty"`
+	Hex      string   `json:"hex,omitempty"`
+}
+
+func (tx Transaction) IsCoinbase() bool {
+	return len(tx.Vin) == 1 && tx.Vin[0].IsCoinbase()
+}
+
+// ScriptPubKeys returns the locking scripts of the spent outputs and the created outputs
+func (tx Transaction) ScriptPubKeys() []ScriptPubKey {
+	scripts := make([]ScriptPubKey, 0, len(tx.Vin)+len(tx.Vout))
+	for _, in := range tx.Vin {
+		if in.PrevOut != nil {
+			scripts = append(scripts, in.PrevOut.ScriptPubKey)
+		}
+	}
+	for _, out := range tx.Vout {
+		scripts = append(scripts, out.ScriptPubKey)
+	}
+	return scripts
+}
+
+// Addresses returns the distinct addresses of the spent outputs and the created outputs in order
+func (tx Transaction) Addresses() []string {
+	var addresses []string
+	for _, script := range tx.ScriptPubKeys() {
+		if script.Address != "" && utils.IndexOf(addresses, script.Address) < 0 {
+			addresses = append(addresses, script.Address)
+		}
+	}
+	return addresses
+}
+
+// Scripts returns the distinct hex of the locking scripts of the spent outputs and the created outputs in order
+func (tx Transaction) Scripts() []string {
+	var scripts []string
+	for _, script := range tx.ScriptPubKeys() {
+		if utils.IndexOf(scripts, script.Hex) < 0 {
+			scripts = append(scripts, script.Hex)
+		}
+	}
+	return scripts
+}
+
+// BlockHeader is the result of getblockheader, the fields changed with the chain growing like
+// confirmations and nextblockhash are dropped
+type BlockHeader struct {
+	Hash              string  `json:"hash"`
+	Height            uint64  `json:"height"`
+	Version           int32   `json:"version"`
+	VersionHex        string  `json:"versionHex"`
+	MerkleRoot        string  `json:"merkleroot"`
+	Time              int64   `json:"time"`
+	MedianTime        int64   `json:"mediantime"`
+	Nonce             uint32  `json:"nonce"`
+	Bits              string  `json:"bits"`
+	Difficulty        float64 `json:"difficulty"`
+	ChainWork         string  `json:"chainwork"`
+	NTx               uint64  `json:"nTx"`
+	PreviousBlockHash string  `json:"previousblockhash,omitempty"`
+}
+
+func (h BlockHeader) Link() BlockLink {
+	return BlockLink{
+		Number:     h.Height,
+		Hash:       h.Hash,
+		ParentHash: h.PreviousBlockHash,
+		Time:       h.Time,
+	}
+}
+
+// Block is the result of getblock with verbosity 3
+type Block struct {
+	BlockHeader
+
+	StrippedSize uint64        `json:"strippedsize"`
+	Size         uint64        `json:"size"`
+	Weight       uint64        `json:"weight"`
+	Transactions []Transaction `json:"tx"`
+}
+
+// BlockLink is the chain identity of one block, the parent hash links the blocks into a chain,
+// so a block from a different fork will be detected by comparing the links
+type BlockLink struct {
+	Number     uint64 `json:"height"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"previousblockhash"`
+	Time       int64  `json:"time"`
+}
+
+// CheckBlockLinks checks that the links are ascending, contiguous, linked by the parent hash, and
+// cover the whole range [from,to]
+func CheckBlockLinks(links []BlockLink, from, to uint64) error {
+	for i, link := range links {
+		if link.Number != from+uint64(i) {
+			return errors.Errorf("block links are not contiguous: got block %d at %d, want %d",
+				link.Number, i, from+uint64(i))
+		}
+		if i > 0 && link.ParentHash != links[i-1].Hash {
+			return errors.Errorf("link mismatch between [%d:->%s] and [%d:%s->]: the blocks were read mid-reorg",
+				links[i-1].Number, links[i-1].Hash, link.Number, link.ParentHash)
+		}
+	}
+	if uint64(len(links)) != to-from+1 {
+		return errors.Errorf("got %d block links but range [%d,%d] has %d blocks", len(links), from, to, to-from+1)
+	}
+	return nil
+}
+
+type GetLatestBlockResponse struct {
+	Latest     BlockLink `json:"latest"`
+	APIVersion int       `json:"api_version"`
+}
+
+const APIVersion = 0 // api version, if api version increased, all driver client will restart
+
+func (r GetLatestBlockResponse) CheckAPIVersion() error {
+	if r.APIVersion <= APIVersion {
+		return nil
+	}
+	return errors.Errorf("remote api version %d is greater than %d", r.APIVersion, APIVersion)
+}
+
+// WrappedTransaction is the transaction with the position in the chain, the JSON fields are the same as
+// getrawtransaction with verbose except txindex
+type WrappedTransaction struct {
+	BlockHash        string `json:"blockhash"`
+	BlockHeight      uint64 `json:"blockheight"`
+	BlockTime        int64  `json:"blocktime"`
+	TransactionIndex uint64 `json:"txindex"`
+	Transaction
+}
+
+// TransactionFilter matches the transactions spending or creating the outputs locked by the address or
+// the script, the conditions are linked with AND
+type TransactionFilter struct {
+	Address string `json:"address,omitempty"`
+	// Script is the hex of the locking script, used for the outputs without address like P2PK and OP_RETURN
+	Script string `json:"script,omitempty"`
+}
+
+func (f TransactionFilter) IsEmpty() bool {
+	return f.Address == "" && f.Script == ""
+}
+
+func (f TransactionFilter) Check(tx Transaction) bool {
+	return utils.HasAny(tx.ScriptPubKeys(), func(script ScriptPubKey) bool {
+		return (f.Address == "" || f.Address == script.Address) && (f.Script == "" || strings.EqualFold(f.Script, script.Hex))
+	})
+}
+
+// CheckTransaction filters are linked by OR
+func CheckTransaction(tx WrappedTransaction, filters []TransactionFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	return utils.HasAny(filters, func(f TransactionFilter) bool {
+		return f.Check(tx.Transaction)
+	})
+}
+
+type GetTransactionsParam struct {
+	StartHeight uint64 `json:"start_height"`
+	EndHeight   uint64 `json:"end_height"`
+
+	// filters are linked by OR
+	Filters []TransactionFilter `json:"filters"`
+}
+
+type OutPoint struct {
+	TxID string `json:"txid"`
+	Vout uint32 `json:"vout"`
+}
+
+func (o OutPoint) String() string {
+	return fmt.Sprintf("%s:%d", o.TxID, o.Vout)
+}
+
+// SpentBy is the input spending the output
+type SpentBy struct {
+	OutPoint OutPoint `json:"outpoint"`
+
+	TxID        string `json:"txid"`
+	Vin         uint32 `json:"vin"`
+	BlockHash   string `json:"blockhash"`
+	BlockHeight uint64 `json:"blockheight"`
+}
@@ -0,0 +1,113 @@
+package btc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/btc/btctest"
+)
+
+func loadSlot(t *testing.T, height uint64) *Slot {
+	var block Block
+	require.NoError(t, json.Unmarshal(btctest.BlockJSON(height), &block))
+	return NewSlot(&block)
+}
+
+func Test_Amount(t *testing.T) {
+	testcases := []struct {
+		raw      string
+		expected Amount
+	}{
+		{raw: "0", expected: 0},
+		{raw: "0.00000001", expected: 1},
+		{raw: "39.9999859", expected: 3999998590},
+		{raw: "50.00000000", expected: 5000000000},
+		{raw: "-1.5", expected: -150000000},
+		{raw: "20999999.97690000", expected: 2099999997690000},
+	}
+	for _, tc := range testcases {
+		var a Amount
+		require.NoError(t, json.Unmarshal([]byte(tc.raw), &a), tc.raw)
+		assert.Equal(t, tc.expected, a, tc.raw)
+		encoded, err := json.Marshal(a)
+		require.NoError(t, err)
+		var b Amount
+		require.NoError(t, json.Unmarshal(encoded, &b))
+		assert.Equal(t, a, b, tc.raw)
+	}
+	assert.Equal(t, "39.99998590", Amount(3999998590).String())
+	assert.Equal(t, "-0.00000001", Amount(-1).String())
+
+	var a Amount
+	assert.Error(t, json.Unmarshal([]byte("0.000000001"), &a))
+	assert.Error(t, json.Unmarshal([]byte(".1"), &a))
+	assert.Error(t, json.Unmarshal([]byte("1e8"), &a))
+}
+
+func Test_TransactionFilter(t *testing.T) {
+	txs := loadSlot(t, 102).GetTransactions()
+	require.Len(t, txs, 3)
+	coinbase, txB, txC := txs[0], txs[1], txs[2]
+
+	check := func(filters ...TransactionFilter) []bool {
+		return []bool{CheckTransaction(coinbase, filters), CheckTransaction(txB, filters), CheckTransaction(txC, filters)}
+	}
+	assert.Equal(t, []bool{true, true, true}, check())
+	assert.Equal(t, []bool{false, true, true}, check(TransactionFilter{Address: btctest.AddressX}))
+	assert.Equal(t, []bool{false, true, false}, check(TransactionFilter{Address: btctest.AddressY}))
+	// the spent output of TxC is locked by AddressC
+	assert.Equal(t, []bool{false, false, true}, check(TransactionFilter{Address: btctest.AddressC}))
+	assert.Equal(t, []bool{true, false, false}, check(TransactionFilter{Address: btctest.AddressMiner}))
+	assert.Equal(t, []bool{true, false, true},
+		check(TransactionFilter{Address: btctest.AddressMiner}, TransactionFilter{Address: btctest.AddressC}))
+	// the script is case-insensitive
+	assert.Equal(t, []bool{false, true, false}, check(TransactionFilter{Script: strings.ToUpper(btctest.ScriptY)}))
+	// both the address and the script should be matched by one output
+	assert.Equal(t, []bool{false, false, false}, check(TransactionFilter{Address: btctest.AddressX, Script: btctest.ScriptY}))
+	assert.Equal(t, []bool{false, true, false}, check(TransactionFilter{Address: btctest.AddressY, Script: btctest.ScriptY}))
+}
+
+func Test_CheckBlockLinks(t *testing.T) {
+	var links []BlockLink
+	for height := btctest.FirstHeight; height <= btctest.LatestHeight; height++ {
+		links = append(links, loadSlot(t, height).Link())
+	}
+	assert.NoError(t, CheckBlockLinks(links, 100, 103))
+	assert.NoError(t, CheckBlockLinks(links[1:3], 101, 102))
+	assert.NoError(t, CheckBlockLinks(nil, 101, 100))
+	assert.ErrorContains(t, CheckBlockLinks(links, 100, 104), "range [100,104] has 5 blocks")
+	assert.ErrorContains(t, CheckBlockLinks(links[1:], 100, 103), "not contiguous")
+	links[2].ParentHash = "fork"
+	assert.ErrorContains(t, CheckBlockLinks(links, 100, 103), "mid-reorg")
+}
+
+func Test_SlotGetSpends(t *testing.T) {
+	assert.Empty(t, loadSlot(t, 100).GetSpends())
+	assert.Equal(t, []SpentBy{{
+		OutPoint:    OutPoint{TxID: btctest.TxA, Vout: 0},
+		TxID:        btctest.TxB,
+		Vin:         0,
+		BlockHash:   btctest.BlockHash(102),
+		BlockHeight: 102,
+	}, {
+		OutPoint:    OutPoint{TxID: btctest.TxA, Vout: 1},
+		TxID:        btctest.TxC,
+		Vin:         0,
+		BlockHash:   btctest.BlockHash(102),
+		BlockHeight: 102,
+	}}, loadSlot(t, 102).GetSpends())
+
+	st := loadSlot(t, 103)
+	assert.Equal(t, uint64(103), st.GetNumber())
+	assert.Equal(t, btctest.BlockHash(102), st.GetParentHash())
+	txs := st.GetTransactions()
+	require.Len(t, txs, 2)
+	assert.Equal(t, btctest.TxD, txs[1].TxID)
+	assert.Equal(t, uint64(1), txs[1].TransactionIndex)
+	assert.Equal(t, int64(1719000000+103*600), txs[1].BlockTime)
+	assert.NotNil(t, txs[1].Vin[0].ScriptSig)
+}
@@ -0,0 +1,65 @@
+// Package chaintest is the conformance suite of the stores of the chain package, every implementation of
+// RangeStore, SimpleSlotStore and Dimension is expected to pass it.
+package chaintest
+
+import (
+	"fmt"
+
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+// Slot is the slot used by the suite, the slots on a fork have different hashes with the main chain
+type Slot struct {
+	Number     uint64 `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+}
+
+func (s *Slot) GetNumber() uint64 {
+	return s.Number
+}
+
+func (s *Slot) GetHash() string {
+	return s.Hash
+}
+
+func (s *Slot) GetParentHash() string {
+	return s.ParentHash
+}
+
+func (s *Slot) Features() []string {
+	return nil
+}
+
+func (s *Slot) Linked() bool {
+	return true
+}
+
+func slotHash(sn uint64, fork string, forkAt uint64) string {
+	if fork == "" || sn < forkAt {
+		return fmt.Sprintf("main-%d", sn)
+	}
+	return fmt.Sprintf("%s-%d", fork, sn)
+}
+
+// NewSlot returns the slot sn of the fork branched out of the main chain at forkAt,
+// the fork is empty means the main chain
+func NewSlot(sn uint64, fork string, forkAt uint64) *Slot {
+	st := &Slot{
+		Number: sn,
+		Hash:   slotHash(sn, fork, forkAt),
+	}
+	if sn > 0 {
+		st.ParentHash = slotHash(sn-1, fork, forkAt)
+	}
+	return st
+}
+
+// NewSlots returns the slots in the interval of the fork branched out of the main chain at forkAt
+func NewSlots(interval rg.Range, fork string, forkAt uint64) []*Slot {
+	slots := make([]*Slot, 0, *interval.Size())
+	for sn := interval.Start; sn <= *interval.End; sn++ {
+		slots = append(slots, NewSlot(sn, fork, forkAt))
+	}
+	return slots
+}
@@ -0,0 +1,261 @@
+package chaintest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/chain"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+// Capabilities are the optional abilities of the store being tested
+type Capabilities struct {
+	// Load is false if the store only supports LoadHeader, like the clickhouse SimpleSlotStore
+	Load bool
+}
+
+type missingChecker interface {
+	CheckMissing(ctx context.Context, interval rg.Range, missing chan<- rg.Range) error
+}
+
+type slotLoader interface {
+	Load(ctx context.Context, interval rg.Range, slotChan chan<- *Slot) error
+}
+
+func send(slots []*Slot) <-chan *Slot {
+	ch := make(chan *Slot, len(slots))
+	for _, st := range slots {
+		ch <- st
+	}
+	close(ch)
+	return ch
+}
+
+func save(ctx context.Context, store chain.SimpleSlotStore[*Slot], interval rg.Range, slots []*Slot) (rg.RangeSet, error) {
+	doneChan := make(chan rg.Range)
+	collected := make(chan rg.RangeSet)
+	go func() {
+		done := rg.EmptyRangeSet
+		for r := range doneChan {
+			done = done.Union(r)
+		}
+		collected <- done
+	}()
+	err := store.Save(ctx, interval, send(slots), doneChan)
+	close(doneChan)
+	return <-collected, err
+}
+
+func checkMissing(t *testing.T, ctx context.Context, store missingChecker, interval rg.Range) rg.RangeSet {
+	missingChan := make(chan rg.Range)
+	collected := make(chan rg.RangeSet)
+	go func() {
+		missing := rg.EmptyRangeSet
+		for r := range missingChan {
+			missing = missing.Union(r)
+		}
+		collected <- missing
+	}()
+	err := store.CheckMissing(ctx, interval, missingChan)
+	close(missingChan)
+	missing := <-collected
+	require.NoError(t, err)
+	return missing
+}
+
+func load(t *testing.T, ctx context.Context, store slotLoader, interval rg.Range) []*Slot {
+	slotChan := make(chan *Slot)
+	collected := make(chan []*Slot)
+	go func() {
+		var slots []*Slot
+		for st := range slotChan {
+			slots = append(slots, st)
+		}
+		collected <- slots
+	}()
+	err := store.Load(ctx, interval, slotChan)
+	close(slotChan)
+	slots := <-collected
+	require.NoError(t, err)
+	return slots
+}
+
+func assertHeader(t *testing.T, ctx context.Context, store chain.SimpleSlotStore[*Slot], expected *Slot) {
+	h, err := store.LoadHeader(ctx, expected.Number)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Number, h.GetNumber())
+	assert.Equal(t, expected.Hash, h.GetHash())
+	assert.Equal(t, expected.ParentHash, h.GetParentHash())
+}
+
+func assertNoHeader(t *testing.T, ctx context.Context, store chain.SimpleSlotStore[*Slot], sn uint64) {
+	_, err := store.LoadHeader(ctx, sn)
+	assert.ErrorIs(t, err, chain.ErrSlotNotFound)
+}
+
+// RunRangeStore checks the RangeStore returned by newStore, which should be empty
+func RunRangeStore(t *testing.T, newStore func(t *testing.T) chain.RangeStore) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	r, err := s.Get(ctx)
+	require.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+
+	r, err = s.Update(ctx, rg.RangeSetter(rg.NewRange(10, 19)))
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(10, 19), r)
+
+	r, err = s.Update(ctx, rg.NewRange(20, 29).Cover)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(10, 29), r)
+
+	_, err = s.Update(ctx, rg.RangeSetter(rg.Range{Start: 10}))
+	assert.Error(t, err)
+
+	r, err = s.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(10, 29), r)
+
+	r, err = s.Update(ctx, func(cur rg.Range) rg.Range {
+		return cur.Remove(rg.Range{Start: 20}).First()
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(10, 19), r)
+
+	r, err = s.Update(ctx, rg.RangeSetter(rg.EmptyRange))
+	require.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+	r, err = s.Get(ctx)
+	require.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+}
+
+// RunSimpleSlotStore checks the SimpleSlotStore returned by newStore, which should be empty
+func RunSimpleSlotStore(t *testing.T, newStore func(t *testing.T) chain.SimpleSlotStore[*Slot], caps Capabilities) {
+	ctx := context.Background()
+
+	t.Run("save then load", func(t *testing.T) {
+		s := newStore(t)
+		interval := rg.NewRange(100, 199)
+		slots := NewSlots(interval, "", 0)
+		done, err := save(ctx, s, interval, slots)
+		require.NoError(t, err)
+		assert.Equal(t, rg.NewRangeSet(interval), done)
+
+		for _, st := range slots {
+			assertHeader(t, ctx, s, st)
+		}
+		assertNoHeader(t, ctx, s, 99)
+		assertNoHeader(t, ctx, s, 200)
+
+		assert.Equal(t, rg.NewRangeSet(rg.NewRange(90, 99), rg.NewRange(200, 209)),
+			checkMissing(t, ctx, s, rg.NewRange(90, 209)))
+		assert.True(t, checkMissing(t, ctx, s, interval).IsEmpty())
+
+		if caps.Load {
+			assert.Equal(t, slots, load(t, ctx, s, interval))
+			assert.Equal(t, slots[10:20], load(t, ctx, s, rg.NewRange(110, 119)))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := newStore(t)
+		interval := rg.NewRange(0, 99)
+		_, err := save(ctx, s, interval, NewSlots(interval, "", 0))
+		require.NoError(t, err)
+
+		require.NoError(t, s.Delete(ctx, rg.NewRange(40, 59)))
+		assert.Equal(t, rg.NewRangeSet(rg.NewRange(40, 59)), checkMissing(t, ctx, s, interval))
+		assertHeader(t, ctx, s, NewSlot(39, "", 0))
+		assertNoHeader(t, ctx, s, 40)
+		assertNoHeader(t, ctx, s, 59)
+		assertHeader(t, ctx, s, NewSlot(60, "", 0))
+
+		// delete all in the right
+		require.NoError(t, s.Delete(ctx, rg.Range{Start: 80}))
+		assert.Equal(t, rg.NewRangeSet(rg.NewRange(40, 59), rg.NewRange(80, 99)), checkMissing(t, ctx, s, interval))
+		assertHeader(t, ctx, s, NewSlot(79, "", 0))
+		assertNoHeader(t, ctx, s, 80)
+	})
+
+	t.Run("save cleans the interval", func(t *testing.T) {
+		s := newStore(t)
+		interval := rg.NewRange(0, 99)
+		_, err := save(ctx, s, interval, NewSlots(interval, "", 0))
+		require.NoError(t, err)
+
+		// only 50~54 of the fork is saved, so 55~59 are missing
+		_, err = save(ctx, s, rg.NewRange(50, 59), NewSlots(rg.NewRange(50, 54), "fork", 50))
+		require.NoError(t, err)
+		assert.Equal(t, rg.NewRangeSet(rg.NewRange(55, 59)), checkMissing(t, ctx, s, interval))
+		assertHeader(t, ctx, s, NewSlot(49, "", 0))
+		assertHeader(t, ctx, s, NewSlot(50, "fork", 50))
+		assertHeader(t, ctx, s, NewSlot(54, "fork", 50))
+		assertNoHeader(t, ctx, s, 55)
+		assertHeader(t, ctx, s, NewSlot(60, "", 0))
+	})
+}
+
+// RunDimension checks the Dimension returned by newDimension, which should be empty
+func RunDimension(t *testing.T, newDimension func(t *testing.T) chain.Dimension[*Slot], caps Capabilities) {
+	ctx := context.Background()
+
+	getRange := func(t *testing.T, d chain.Dimension[*Slot]) rg.Range {
+		r, err := d.GetRange(ctx)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("save and reorg", func(t *testing.T) {
+		d := newDimension(t)
+		require.NoError(t, d.Init(ctx))
+		assert.True(t, getRange(t, d).IsEmpty())
+
+		require.NoError(t, d.Save(ctx, rg.NewRange(0, 99), send(NewSlots(rg.NewRange(0, 99), "", 0))))
+		assert.Equal(t, rg.NewRange(0, 99), getRange(t, d))
+
+		// not continuous with the current range
+		err := d.Save(ctx, rg.NewRange(150, 159), send(NewSlots(rg.NewRange(150, 159), "", 0)))
+		assert.ErrorIs(t, err, chain.ErrDiscontinuous)
+		// the fork branched out at 90 does not link to the main chain at 99
+		err = d.Save(ctx, rg.NewRange(100, 109), send(NewSlots(rg.NewRange(100, 109), "fork", 90)))
+		assert.ErrorIs(t, err, chain.ErrLink)
+		assert.Equal(t, rg.NewRange(0, 99), getRange(t, d))
+
+		// reorg: drop the slots since the fork point, then save the fork
+		require.NoError(t, d.Delete(ctx, rg.Range{Start: 90}))
+		assert.Equal(t, rg.NewRange(0, 89), getRange(t, d))
+		_, err = d.LoadHeader(ctx, 95)
+		assert.ErrorIs(t, err, chain.ErrSlotNotFound)
+
+		require.NoError(t, d.Save(ctx, rg.NewRange(90, 119), send(NewSlots(rg.NewRange(90, 119), "fork", 90))))
+		assert.Equal(t, rg.NewRange(0, 119), getRange(t, d))
+		h, err := d.LoadHeader(ctx, 95)
+		require.NoError(t, err)
+		assert.Equal(t, NewSlot(95, "fork", 90).Hash, h.GetHash())
+		assert.True(t, checkMissing(t, ctx, d, rg.NewRange(0, 119)).IsEmpty())
+
+		if caps.Load {
+			expected := append(NewSlots(rg.NewRange(0, 89), "", 0), NewSlots(rg.NewRange(90, 119), "fork", 90)...)
+			assert.Equal(t, expected, load(t, ctx, d, rg.NewRange(0, 119)))
+		}
+	})
+
+	t.Run("delete head", func(t *testing.T) {
+		d := newDimension(t)
+		require.NoError(t, d.Init(ctx))
+		require.NoError(t, d.Save(ctx, rg.NewRange(0, 99), send(NewSlots(rg.NewRange(0, 99), "", 0))))
+
+		require.NoError(t, d.Delete(ctx, rg.NewRange(0, 9)))
+		assert.Equal(t, rg.NewRange(10, 99), getRange(t, d))
+		assert.Equal(t, rg.NewRangeSet(rg.NewRange(0, 9)), checkMissing(t, ctx, d, rg.NewRange(0, 99)))
+
+		// the dimension is still extendable in the right
+		require.NoError(t, d.Save(ctx, rg.NewRange(100, 109), send(NewSlots(rg.NewRange(100, 109), "", 0))))
+		assert.Equal(t, rg.NewRange(10, 109), getRange(t, d))
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"sentioxyz/sentio-core/common/utils"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -122,7 +123,8 @@ type tagInfo struct {
 }
 
 type entryExtra struct {
-	tags map[string]tagInfo
+	tags    map[string]tagInfo
+	latency *latencyStat
 	*ban
 	*active
 }
@@ -204,6 +206,14 @@ type ClientPool[CONFIG EntryConfig[CONFIG], CLIENT Client] struct {
 	statDowngrade *timewin.TimeWindowsManager[*downgradeStatWindow]
 	statEntryUsed *timewin.TimeWindowsManager[*usedStatWindow]
 
+	// latencySelection is whether config.Selection.Policy chooses the entries by latency, so that chooseOne
+	// does not need mu for the other policies
+	latencySelection atomic.Bool
+
+	// hedgeTimer starts the timer of the hedge delay in UseClientHedged, replaced in the tests to fire
+	// the hedge call on demand
+	hedgeTimer func(delay time.Duration) (fire <-chan time.Time, stop func() bool)
+
 	// protect all properties below.
 	// Lock ordering: never call pool methods while holding mu.
 	// pool.mu can call back into ClientPool (e.g. via poolStatusBuilder, Enable's status goroutine),
@@ -238,6 +248,7 @@ func NewClientPool[CONFIG EntryConfig[CONFIG], CLIENT Client](
 		consumer:      make(map[uint64]consumer),
 		statDowngrade: timewin.NewTimeWindowsManager[*downgradeStatWindow](time.Minute),
 		statEntryUsed: timewin.NewTimeWindowsManager[*usedStatWindow](time.Minute),
+		hedgeTimer:    newHedgeTimer,
 	}
 	p.pool = pool.NewPool[ClientConfig[CONFIG], entryStatus[CLIENT], poolStatus](
 		name,
@@ -482,6 +493,9 @@ var rd = rand.New(rand.NewSource(time.Now().UnixNano()))
 func (p *ClientPool[CONFIG, CLIENT]) chooseOne(
 	set map[string]pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]],
 ) (string, pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]]) {
+	if p.latencySelection.Load() {
+		return p.chooseByLatency(set)
+	}
 	var target pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]]
 	var targetName string
 	var targetPriority uint32
@@ -500,6 +514,32 @@ func (p *ClientPool[CONFIG, CLIENT]) chooseOne(
 	return targetName, target
 }
 
+// chooseByLatency chooses one of the entries with the highest priority by the recent latencies
+func (p *ClientPool[CONFIG, CLIENT]) chooseByLatency(
+	set map[string]pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]],
+) (string, pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var targetPriority uint32 = math.MaxUint32
+	for _, ent := range set {
+		targetPriority = min(targetPriority, ent.Config.Priority)
+	}
+	now := time.Now()
+	var candidates []scoredEntry
+	for name, ent := range set {
+		if ent.Config.Priority != targetPriority {
+			continue
+		}
+		cand := scoredEntry{name: name}
+		if extra, has := p.entryExtra[name]; has {
+			cand.score, cand.scored = extra.latency.score(p.config.Selection, now)
+		}
+		candidates = append(candidates, cand)
+	}
+	targetName := selectByScore(p.config.Selection.Policy, candidates, rd)
+	return targetName, set[targetName]
+}
+
 type option[CONFIG any] struct {
 	noTags        []string
 	withTags      []string
@@ -656,7 +696,7 @@ func (p *ClientPool[CONFIG, CLIENT]) consumerExecuting(id uint64, client string)
 	p.consumer[id] = c
 }
 
-func (p *ClientPool[CONFIG, CLIENT]) consumerExecuted(id uint64, used time.Duration, result Result) {
+func (p *ClientPool[CONFIG, CLIENT]) consumerExecuted(id uint64, entName string, used time.Duration, result Result) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	c := p.consumer[id]
@@ -664,6 +704,28 @@ func (p *ClientPool[CONFIG, CLIENT]) consumerExecuted(id uint64, used time.Durat
 	c.executedDuration += used
 	c.executedLast = result
 	p.consumer[id] = c
+	p._addLatency(entName, used, result.Broken)
+}
+
+func (p *ClientPool[CONFIG, CLIENT]) addLatency(entName string, used time.Duration, broken bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p._addLatency(entName, used, broken)
+}
+
+func (p *ClientPool[CONFIG, CLIENT]) _addLatency(entName string, used time.Duration, broken bool) {
+	if _, has := p.configEntries[entName]; !has {
+		return // the client is already removed
+	}
+	extra, has := p.entryExtra[entName]
+	if !has {
+		extra = &entryExtra{}
+		p.entryExtra[entName] = extra
+	}
+	if extra.latency == nil {
+		extra.latency = &latencyStat{}
+	}
+	extra.latency.add(p.config.Selection, used, broken, time.Now())
 }
 
 func (p *ClientPool[CONFIG, CLIENT]) consumerCollectDoing(doing string) (themes []string) {
@@ -769,9 +831,38 @@ func (p *ClientPool[CONFIG, CLIENT]) UseClient(
 	theme string,
 	fn func(ctx context.Context, cli CLIENT) Result,
 	opts ...Option[CONFIG],
+) Report {
+	return p.useClient(ctx, theme, opts, func(
+		curCtx context.Context,
+		cid uint64,
+		entries map[string]pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]],
+		blackList set.Set[string],
+	) (Report, bool) {
+		_, logger := log.FromContext(curCtx)
+		entName, ent := p.chooseOne(entries)
+		logger.Debugw("choose client", "client", entName, "count", len(entries))
+		p.consumerExecuting(cid, entName)
+		startAt := time.Now()
+		result := fn(ctx, ent.Status.Client)
+		return p.handleResult(curCtx, cid, theme, entName, ent.Status.ClientName, time.Since(startAt), result, blackList)
+	})
+}
+
+// useClient finds the valid entries and calls attempt with them until attempt returns true.
+// attempt should add the entries broken for the call to blackList.
+func (p *ClientPool[CONFIG, CLIENT]) useClient(
+	ctx context.Context,
+	theme string,
+	opts []Option[CONFIG],
+	attempt func(
+		curCtx context.Context,
+		cid uint64,
+		entries map[string]pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]],
+		blackList set.Set[string],
+	) (Report, bool),
 ) Report {
 	cid := p.consumerCome(theme)
-	curCtx, logger := log.FromContext(ctx, "pool", p.pool.Name(), "pcid", cid, "theme", theme)
+	curCtx, _ := log.FromContext(ctx, "pool", p.pool.Name(), "pcid", cid, "theme", theme)
 	defer func() {
 		p.consumerLeave(cid)
 	}()
@@ -799,30 +890,43 @@ func (p *ClientPool[CONFIG, CLIENT]) UseClient(
 			}
 			continue
 		}
-		entName, ent := p.chooseOne(entries)
-		logger.Debugw("choose client", "client", entName, "count", len(entries))
-		p.consumerExecuting(cid, entName)
-		startAt := time.Now()
-		result := fn(ctx, ent.Status.Client)
-		p.consumerExecuted(cid, time.Since(startAt), result)
-		logger.Debugw("got use result", "client", entName, "result", result.String())
-		for _, tag := range result.AddTags {
-			p.clientAddTag(curCtx, entName, tag, result.Err)
-		}
-		if result.Broken {
-			p.clientBan(curCtx, entName, result.Err)
-		}
-		if result.BrokenForTask {
-			blackList.Add(entName)
-			p.consumerBlackListAdd(cid, entName, result.Err)
-		}
-		if !result.Broken && !result.BrokenForTask {
-			p.clientActive(curCtx, entName, theme)
-			return Report{Err: result.Err, ConfigName: entName, ClientName: ent.Status.ClientName}
+		if report, done := attempt(curCtx, cid, entries, blackList); done {
+			return report
 		}
 	}
 }
 
+// handleResult records the result of using the entry, returns true if the result should be returned to the caller
+func (p *ClientPool[CONFIG, CLIENT]) handleResult(
+	curCtx context.Context,
+	cid uint64,
+	theme string,
+	entName string,
+	clientName string,
+	used time.Duration,
+	result Result,
+	blackList set.Set[string],
+) (Report, bool) {
+	_, logger := log.FromContext(curCtx)
+	p.consumerExecuted(cid, entName, used, result)
+	logger.Debugw("got use result", "client", entName, "result", result.String())
+	for _, tag := range result.AddTags {
+		p.clientAddTag(curCtx, entName, tag, result.Err)
+	}
+	if result.Broken {
+		p.clientBan(curCtx, entName, result.Err)
+	}
+	if result.BrokenForTask {
+		blackList.Add(entName)
+		p.consumerBlackListAdd(cid, entName, result.Err)
+	}
+	if !result.Broken && !result.BrokenForTask {
+		p.clientActive(curCtx, entName, theme)
+		return Report{Err: result.Err, ConfigName: entName, ClientName: clientName}, true
+	}
+	return Report{}, false
+}
+
 func (p *ClientPool[CONFIG, CLIENT]) clientAddTag(ctx context.Context, name string, tag string, err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -959,6 +1063,7 @@ func (p *ClientPool[CONFIG, CLIENT]) updateConfig(c PoolConfig[CONFIG]) {
 		logger.Infow("pool will remove client", "client", name, "preConfig", cc)
 	}
 	p.config = c
+	p.latencySelection.Store(c.Selection.Policy == SelectionLeastLatency || c.Selection.Policy == SelectionP2C)
 	p.priorityCursor = 0
 	p.configPriorities = nil
 	if len(c.ClientConfigs) > 0 {
@@ -1101,7 +1101,7 @@ func Test_consumerWaitTooLong_executedDurationSubtracted(t *testing.T) {
 
 	// Simulate 15ms elapsed with 14ms execution time → net wait < ConsumerMaxWait
 	time.Sleep(15 * time.Millisecond)
-	p.consumerExecuted(cid, 14*time.Millisecond, Result{})
+	p.consumerExecuted(cid, "c1", 14*time.Millisecond, Result{})
 	assert.False(t, p.consumerWaitTooLong(cid))
 }
 
@@ -1184,8 +1184,8 @@ func Test_consumerExecuted_accumulatesStats(t *testing.T) {
 	cid := p.consumerCome("test")
 	defer p.consumerLeave(cid)
 
-	p.consumerExecuted(cid, 10*time.Millisecond, Result{})
-	p.consumerExecuted(cid, 20*time.Millisecond, Result{})
+	p.consumerExecuted(cid, "c1", 10*time.Millisecond, Result{})
+	p.consumerExecuted(cid, "c1", 20*time.Millisecond, Result{})
 
 	p.mu.Lock()
 	c := p.consumer[cid]
@@ -80,6 +80,8 @@ type PoolConfig[CONFIG EntryConfig[CONFIG]] struct {
 	// services that want a default interval should set it when loading their configuration.
 	ReInitInterval time.Duration `json:"re_init_interval" yaml:"re_init_interval"`
 
+	Selection SelectionConfig `json:"selection" yaml:"selection"`
+
 	ClientConfigs []ClientConfig[CONFIG] `json:"endpoints" yaml:"endpoints"`
 }
 
@@ -97,6 +99,7 @@ func (c PoolConfig[CONFIG]) Trim(configModifiers []ConfigModifier[CONFIG]) PoolC
 		TagDuration:            utils.Select(c.TagDuration > 0, c.TagDuration, time.Minute*30),
 		ConsumerMaxWait:        utils.Select(c.ConsumerMaxWait > 0, c.ConsumerMaxWait, time.Minute*2),
 		ReInitInterval:         max(c.ReInitInterval, 0),
+		Selection:              c.Selection.Trim(),
 		ClientConfigs: utils.MapSliceNoErrWithIndex(c.ClientConfigs, func(index int, cc ClientConfig[CONFIG]) (ClientConfig[CONFIG], bool) {
 			ccc := cc.Config
 			for _, m := range configModifiers {
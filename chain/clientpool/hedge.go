@@ -0,0 +1,137 @@
+package clientpool
+
+import (
+	"context"
+	"time"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/pool"
+	"sentioxyz/sentio-core/common/set"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+// HedgeConfig controls when UseClientHedged fires the second call.
+// The delay is the Percentile of the recent latencies of the first entry, limited in [MinDelay, MaxDelay],
+// MaxDelay is used if the first entry has no recent latency.
+type HedgeConfig struct {
+	Percentile float64
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+}
+
+func (c HedgeConfig) Trim() HedgeConfig {
+	r := HedgeConfig{
+		Percentile: utils.Select(c.Percentile > 0 && c.Percentile <= 1, c.Percentile, 0.95),
+		MinDelay:   max(c.MinDelay, 0),
+		MaxDelay:   utils.Select(c.MaxDelay > 0, c.MaxDelay, time.Second),
+	}
+	r.MaxDelay = max(r.MaxDelay, r.MinDelay)
+	return r
+}
+
+func (p *ClientPool[CONFIG, CLIENT]) hedgeDelay(entName string, c HedgeConfig) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	extra, has := p.entryExtra[entName]
+	if !has {
+		return c.MaxDelay
+	}
+	delay, has := extra.latency.percentile(p.config.Selection, c.Percentile, time.Now())
+	if !has {
+		return c.MaxDelay
+	}
+	return min(max(delay, c.MinDelay), c.MaxDelay)
+}
+
+func newHedgeTimer(delay time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(delay)
+	return timer.C, timer.Stop
+}
+
+type hedgedResult struct {
+	entName    string
+	clientName string
+	used       time.Duration
+	result     Result
+}
+
+// UseClientHedged is like UseClient, but if the chosen entry does not return in the hedge delay, fn will be
+// called again with another valid entry, the first acceptable result wins and the ctx of the other call will
+// be canceled. A broken result does not cancel the other call. fn must be safe to be called concurrently.
+// The latency of the loser is still recorded when it returns, it is at least the used time of the winner
+// even if the loser returns because of the cancellation, so a slow entry is not preferred by the selection.
+func (p *ClientPool[CONFIG, CLIENT]) UseClientHedged(
+	ctx context.Context,
+	theme string,
+	hedge HedgeConfig,
+	fn func(ctx context.Context, cli CLIENT) Result,
+	opts ...Option[CONFIG],
+) Report {
+	hedge = hedge.Trim()
+	return p.useClient(ctx, theme, opts, func(
+		curCtx context.Context,
+		cid uint64,
+		entries map[string]pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]],
+		blackList set.Set[string],
+	) (Report, bool) {
+		_, logger := log.FromContext(curCtx)
+		callCtx, cancel := context.WithCancel(ctx)
+		defer cancel() // cancel the loser
+		results := make(chan hedgedResult, 2)
+		call := func(entName string, ent pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]]) {
+			p.consumerExecuting(cid, entName)
+			go func() {
+				startAt := time.Now()
+				result := fn(callCtx, ent.Status.Client)
+				results <- hedgedResult{
+					entName:    entName,
+					clientName: ent.Status.ClientName,
+					used:       time.Since(startAt),
+					result:     result,
+				}
+			}()
+		}
+
+		first, ent := p.chooseOne(entries)
+		delay := p.hedgeDelay(first, hedge)
+		logger.Debugw("choose client", "client", first, "count", len(entries), "hedgeDelay", delay.String())
+		call(first, ent)
+		fire, stop := p.hedgeTimer(delay)
+		defer stop()
+		for running := 1; running > 0; {
+			select {
+			case r := <-results:
+				running--
+				report, done := p.handleResult(curCtx, cid, theme, r.entName, r.clientName, r.used, r.result, blackList)
+				if done {
+					go p.recordLosers(results, running)
+					return report, true
+				}
+			case <-fire:
+				others := make(map[string]pool.Entry[ClientConfig[CONFIG], entryStatus[CLIENT]], len(entries))
+				for name, ent := range entries {
+					if name != first && !blackList.Contains(name) {
+						others[name] = ent
+					}
+				}
+				if len(others) == 0 {
+					logger.Debugw("no other client to hedge", "client", first)
+					continue
+				}
+				second, secondEnt := p.chooseOne(others)
+				logger.Debugw("hedge client", "client", second, "first", first, "count", len(others))
+				call(second, secondEnt)
+				running++
+			}
+		}
+		return Report{}, false
+	})
+}
+
+// recordLosers waits for the calls still running after the winner returned and records their latencies
+func (p *ClientPool[CONFIG, CLIENT]) recordLosers(results <-chan hedgedResult, running int) {
+	for ; running > 0; running-- {
+		r := <-results
+		p.addLatency(r.entName, r.used, r.result.Broken)
+	}
+}
@@ -0,0 +1,168 @@
+package clientpool
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HedgeConfig_Trim(t *testing.T) {
+	assert.Equal(t, HedgeConfig{Percentile: 0.95, MaxDelay: time.Second}, HedgeConfig{}.Trim())
+	assert.Equal(t, HedgeConfig{Percentile: 0.5, MinDelay: time.Minute, MaxDelay: time.Minute},
+		HedgeConfig{Percentile: 0.5, MinDelay: time.Minute}.Trim())
+}
+
+func Test_hedgeDelay(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionLeastLatency, quickClientCfg("c1", 1))
+	c := HedgeConfig{MinDelay: 5 * time.Millisecond, MaxDelay: 50 * time.Millisecond}.Trim()
+	assert.Equal(t, 50*time.Millisecond, p.hedgeDelay("c1", c))
+	seedLatency(p, "c1", time.Millisecond, false)
+	assert.Equal(t, 5*time.Millisecond, p.hedgeDelay("c1", c))
+	seedLatency(p, "c1", 20*time.Millisecond, false)
+	assert.Equal(t, 20*time.Millisecond, p.hedgeDelay("c1", c))
+	seedLatency(p, "c1", time.Second, false)
+	assert.Equal(t, 50*time.Millisecond, p.hedgeDelay("c1", c))
+}
+
+// manualHedgeTimer replaces the hedge timer of p, the hedge call is fired when the returned channel is sent,
+// the send returns after UseClientHedged received it. The hedge delays are appended to delays.
+func manualHedgeTimer(p *ClientPool[testClientConfig, *testClient], delays *[]time.Duration) chan<- time.Time {
+	fire := make(chan time.Time)
+	p.hedgeTimer = func(delay time.Duration) (<-chan time.Time, func() bool) {
+		*delays = append(*delays, delay)
+		return fire, func() bool { return true }
+	}
+	return fire
+}
+
+func latencyExecuted(p *ClientPool[testClientConfig, *testClient], entName string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if extra, has := p.entryExtra[entName]; has && extra.latency != nil {
+		return extra.latency.executed
+	}
+	return 0
+}
+
+func Test_UseClientHedged_secondWins(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionLeastLatency, quickClientCfg("c1", 1), quickClientCfg("c2", 1))
+	// c1 is chosen first, the hedge delay is its latency
+	seedLatency(p, "c1", 10*time.Millisecond, false)
+	seedLatency(p, "c2", 50*time.Millisecond, false)
+	var delays []time.Duration
+	fire := manualHedgeTimer(p, &delays)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var called []string
+	loserCanceled := make(chan struct{})
+	r := p.UseClientHedged(ctx, "test", HedgeConfig{}, func(ctx context.Context, cli *testClient) Result {
+		mu.Lock()
+		called = append(called, cli.config.Name)
+		mu.Unlock()
+		if cli.config.Name == "c1" {
+			fire <- time.Now()
+			<-ctx.Done()
+			close(loserCanceled)
+			return Result{Err: ctx.Err()}
+		}
+		return Result{}
+	})
+	require.NoError(t, r.Err)
+	assert.Equal(t, "c2", r.ConfigName)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond}, delays)
+	select {
+	case <-loserCanceled:
+	case <-ctx.Done():
+		require.Fail(t, "the loser is not canceled")
+	}
+	mu.Lock()
+	assert.Equal(t, []string{"c1", "c2"}, called)
+	mu.Unlock()
+	// the latency of the loser is recorded after it returned
+	require.Eventually(t, func() bool {
+		return latencyExecuted(p, "c1") == 2
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 2, latencyExecuted(p, "c2"))
+}
+
+func Test_UseClientHedged_firstInTime(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionLeastLatency, quickClientCfg("c1", 1), quickClientCfg("c2", 1))
+	seedLatency(p, "c1", 10*time.Millisecond, false)
+	seedLatency(p, "c2", 50*time.Millisecond, false)
+	var delays []time.Duration
+	manualHedgeTimer(p, &delays)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var called []string
+	r := p.UseClientHedged(ctx, "test", HedgeConfig{MinDelay: time.Minute}, func(ctx context.Context, cli *testClient) Result {
+		called = append(called, cli.config.Name)
+		return Result{}
+	})
+	require.NoError(t, r.Err)
+	assert.Equal(t, "c1", r.ConfigName)
+	assert.Equal(t, []string{"c1"}, called)
+	assert.Equal(t, []time.Duration{time.Minute}, delays)
+}
+
+func Test_UseClientHedged_brokenWaitsForOther(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionLeastLatency, quickClientCfg("c1", 1), quickClientCfg("c2", 1))
+	seedLatency(p, "c1", 10*time.Millisecond, false)
+	seedLatency(p, "c2", 50*time.Millisecond, false)
+	var delays []time.Duration
+	fire := manualHedgeTimer(p, &delays)
+	banned := func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.entryExtra["c1"].ban != nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	release := make(chan struct{})
+	r := p.UseClientHedged(ctx, "test", HedgeConfig{}, func(ctx context.Context, cli *testClient) Result {
+		if cli.config.Name == "c1" {
+			// returns broken after the hedge call started
+			fire <- time.Now()
+			<-release
+			return Result{Broken: true, Err: fmt.Errorf("broken")}
+		}
+		close(release)
+		// returns after the broken result of c1 is handled
+		for !banned() {
+			runtime.Gosched()
+		}
+		return Result{}
+	})
+	require.NoError(t, r.Err)
+	assert.Equal(t, "c2", r.ConfigName)
+	assert.True(t, banned())
+}
+
+func Test_UseClientHedged_singleEntry(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionRandom, quickClientCfg("c1", 1))
+	var delays []time.Duration
+	fire := manualHedgeTimer(p, &delays)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var called int
+	r := p.UseClientHedged(ctx, "test", HedgeConfig{MaxDelay: time.Millisecond}, func(ctx context.Context, cli *testClient) Result {
+		called++
+		// the hedge delay passed but there is no other entry
+		fire <- time.Now()
+		return Result{}
+	})
+	require.NoError(t, r.Err)
+	assert.Equal(t, "c1", r.ConfigName)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, []time.Duration{time.Millisecond}, delays)
+}
@@ -0,0 +1,157 @@
+package clientpool
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"sentioxyz/sentio-core/common/utils"
+)
+
+const (
+	// SelectionRandom chooses a random entry of the highest enabled priority, it is the default policy
+	SelectionRandom = "random"
+	// SelectionLeastLatency chooses the entry with the lowest score of the highest enabled priority
+	SelectionLeastLatency = "least_latency"
+	// SelectionP2C chooses two random entries of the highest enabled priority and uses the one with lower score
+	SelectionP2C = "p2c"
+)
+
+// SelectionConfig controls how UseClient chooses among the valid entries of the highest enabled priority.
+//
+// The score of an entry is its EWMA latency multiplied by (1 + ErrorPenalty * EWMA error rate), only the
+// results marked Broken count as errors. Entries without samples in SampleTTL have no score, they are
+// preferred over the scored ones so that new or recovered entries are probed again.
+type SelectionConfig struct {
+	Policy string `json:"policy" yaml:"policy"`
+	// Decay is the weight of the newest sample in the EWMA, in (0, 1]
+	Decay        float64       `json:"decay"         yaml:"decay"`
+	ErrorPenalty float64       `json:"error_penalty" yaml:"error_penalty"`
+	SampleTTL    time.Duration `json:"sample_ttl"    yaml:"sample_ttl"`
+}
+
+func (c SelectionConfig) Trim() SelectionConfig {
+	return SelectionConfig{
+		Policy:       utils.Select(c.Policy != "", c.Policy, SelectionRandom),
+		Decay:        utils.Select(c.Decay > 0 && c.Decay <= 1, c.Decay, 0.3),
+		ErrorPenalty: utils.Select(c.ErrorPenalty > 0, c.ErrorPenalty, 10),
+		SampleTTL:    utils.Select(c.SampleTTL > 0, c.SampleTTL, time.Minute*5),
+	}
+}
+
+const latencySampleSize = 128
+
+// latencyStat is the statistic of the recent executions of an entry
+type latencyStat struct {
+	latency  float64 // EWMA of the used time in nanoseconds
+	errRate  float64 // EWMA of the broken results
+	lastAt   time.Time
+	executed int
+
+	// the used time of the recent successful executions, used to calculate the hedge delay
+	samples    [latencySampleSize]time.Duration
+	sampleNum  int
+	sampleNext int
+}
+
+func (s *latencyStat) expired(c SelectionConfig, now time.Time) bool {
+	return s.executed == 0 || now.Sub(s.lastAt) > c.SampleTTL
+}
+
+func (s *latencyStat) add(c SelectionConfig, used time.Duration, broken bool, now time.Time) {
+	if s.expired(c, now) {
+		*s = latencyStat{}
+	}
+	errSample := utils.Select(broken, 1., 0.)
+	if s.executed == 0 {
+		s.latency, s.errRate = float64(used), errSample
+	} else {
+		s.latency = c.Decay*float64(used) + (1-c.Decay)*s.latency
+		s.errRate = c.Decay*errSample + (1-c.Decay)*s.errRate
+	}
+	s.executed++
+	s.lastAt = now
+	if !broken {
+		s.samples[s.sampleNext] = used
+		s.sampleNext = (s.sampleNext + 1) % latencySampleSize
+		s.sampleNum = min(s.sampleNum+1, latencySampleSize)
+	}
+}
+
+func (s *latencyStat) score(c SelectionConfig, now time.Time) (float64, bool) {
+	if s == nil || s.expired(c, now) {
+		return 0, false
+	}
+	return s.latency * (1 + c.ErrorPenalty*s.errRate), true
+}
+
+// percentile returns the q-quantile of the recent successful executions
+func (s *latencyStat) percentile(c SelectionConfig, q float64, now time.Time) (time.Duration, bool) {
+	if s == nil || s.expired(c, now) || s.sampleNum == 0 {
+		return 0, false
+	}
+	samples := make([]time.Duration, s.sampleNum)
+	copy(samples, s.samples[:s.sampleNum])
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	index := int(math.Ceil(q*float64(len(samples)))) - 1
+	return samples[min(max(index, 0), len(samples)-1)], true
+}
+
+func (s *latencyStat) Snapshot(c SelectionConfig, now time.Time) any {
+	if s.expired(c, now) {
+		return map[string]any{"expired": true}
+	}
+	sn := map[string]any{
+		"latency":  time.Duration(s.latency).String(),
+		"errRate":  s.errRate,
+		"executed": s.executed,
+		"lastAt":   s.lastAt.String(),
+	}
+	if p95, has := s.percentile(c, 0.95, now); has {
+		sn["p95"] = p95.String()
+	}
+	return sn
+}
+
+type scoredEntry struct {
+	name   string
+	score  float64
+	scored bool
+}
+
+func (e scoredEntry) better(a scoredEntry) bool {
+	if e.scored != a.scored {
+		return !e.scored
+	}
+	return e.score < a.score
+}
+
+// selectByScore chooses one of the candidates according to the policy, ties are broken randomly
+func selectByScore(policy string, candidates []scoredEntry, rd *rand.Rand) string {
+	switch len(candidates) {
+	case 0:
+		return ""
+	case 1:
+		return candidates[0].name
+	}
+	rd.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	switch policy {
+	case SelectionLeastLatency:
+		best := candidates[0]
+		for _, cand := range candidates[1:] {
+			if cand.better(best) {
+				best = cand
+			}
+		}
+		return best.name
+	case SelectionP2C:
+		return utils.Select(candidates[1].better(candidates[0]), candidates[1], candidates[0]).name
+	default:
+		return candidates[0].name
+	}
+}
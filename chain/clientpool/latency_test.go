@@ -0,0 +1,145 @@
+package clientpool
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sentioxyz/sentio-core/common/set"
+)
+
+func Test_latencyStat(t *testing.T) {
+	c := SelectionConfig{Decay: 0.5, ErrorPenalty: 10}.Trim()
+	now := time.Now()
+	var s latencyStat
+	_, scored := s.score(c, now)
+	assert.False(t, scored)
+
+	s.add(c, 100*time.Millisecond, false, now)
+	s.add(c, 200*time.Millisecond, false, now)
+	score, scored := s.score(c, now)
+	assert.True(t, scored)
+	assert.Equal(t, float64(150*time.Millisecond), score)
+
+	// broken results raise the error rate, but are not used as the hedge samples
+	s.add(c, time.Millisecond*50, true, now)
+	assert.Equal(t, 0.5, s.errRate)
+	score, _ = s.score(c, now)
+	assert.Equal(t, float64(100*time.Millisecond)*6, score)
+	p, has := s.percentile(c, 0.5, now)
+	assert.True(t, has)
+	assert.Equal(t, 100*time.Millisecond, p)
+	p, _ = s.percentile(c, 0.95, now)
+	assert.Equal(t, 200*time.Millisecond, p)
+
+	// the samples expire
+	later := now.Add(c.SampleTTL + time.Second)
+	_, scored = s.score(c, later)
+	assert.False(t, scored)
+	s.add(c, time.Millisecond, false, later)
+	assert.Equal(t, 1, s.executed)
+	assert.Equal(t, float64(0), s.errRate)
+
+	// only the recent samples are kept
+	for i := 1; i <= latencySampleSize*2; i++ {
+		s.add(c, time.Duration(i)*time.Millisecond, false, later)
+	}
+	p, _ = s.percentile(c, 0, later)
+	assert.Equal(t, time.Duration(latencySampleSize+1)*time.Millisecond, p)
+}
+
+func Test_selectByScore(t *testing.T) {
+	rd := rand.New(rand.NewSource(1))
+	candidates := func() []scoredEntry {
+		return []scoredEntry{
+			{name: "slow", score: 300, scored: true},
+			{name: "fast", score: 100, scored: true},
+			{name: "middle", score: 200, scored: true},
+		}
+	}
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, "fast", selectByScore(SelectionLeastLatency, candidates(), rd))
+		// the worst one always loses the comparison
+		assert.NotEqual(t, "slow", selectByScore(SelectionP2C, candidates(), rd))
+	}
+	// entries without score are probed first
+	assert.Equal(t, "new", selectByScore(SelectionLeastLatency, append(candidates(), scoredEntry{name: "new"}), rd))
+	assert.Equal(t, "", selectByScore(SelectionP2C, nil, rd))
+}
+
+// seedLatency records an execution of the entry with the used time
+func seedLatency(p *ClientPool[testClientConfig, *testClient], entName string, used time.Duration, broken bool) {
+	cid := p.consumerCome("seed")
+	defer p.consumerLeave(cid)
+	p.consumerExecuted(cid, entName, used, Result{Broken: broken})
+}
+
+func startPoolWithSelection(
+	t *testing.T,
+	policy string,
+	clients ...ClientConfig[testClientConfig],
+) *ClientPool[testClientConfig, *testClient] {
+	p := startPoolWith(t, clients...)
+	p.mu.Lock()
+	p.config.Selection = SelectionConfig{Policy: policy}.Trim()
+	p.latencySelection.Store(policy == SelectionLeastLatency || policy == SelectionP2C)
+	p.mu.Unlock()
+	// wait for all the enabled entries, otherwise the choice depends on which one initialized first
+	var enabled int
+	for _, cc := range clients {
+		if cc.Priority == clients[0].Priority {
+			enabled++
+		}
+	}
+	require.Eventually(t, func() bool {
+		entries, _, _ := p.findEntries(set.New[string](), option[testClientConfig]{})
+		return len(entries) == enabled
+	}, 5*time.Second, time.Millisecond)
+	return p
+}
+
+func Test_UseClient_leastLatency(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionLeastLatency,
+		quickClientCfg("c1", 1), quickClientCfg("c2", 1), quickClientCfg("c3", 1), quickClientCfg("c4", 2))
+	seedLatency(p, "c1", 30*time.Millisecond, false)
+	seedLatency(p, "c2", 10*time.Millisecond, false)
+	seedLatency(p, "c3", 20*time.Millisecond, false)
+	seedLatency(p, "c4", time.Millisecond, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	use := func() string {
+		r := p.UseClient(ctx, "test", func(_ context.Context, cli *testClient) Result {
+			return Result{}
+		})
+		require.NoError(t, r.Err)
+		return r.ConfigName
+	}
+	// c4 is faster but has lower priority
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "c2", use())
+	}
+
+	// the errors make c2 worse than c3
+	seedLatency(p, "c2", 50*time.Millisecond, true)
+	assert.Equal(t, "c3", use())
+}
+
+func Test_UseClient_p2c(t *testing.T) {
+	p := startPoolWithSelection(t, SelectionP2C, quickClientCfg("c1", 1), quickClientCfg("c2", 1))
+	seedLatency(p, "c1", 30*time.Millisecond, false)
+	seedLatency(p, "c2", 10*time.Millisecond, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 10; i++ {
+		r := p.UseClient(ctx, "test", func(_ context.Context, cli *testClient) Result {
+			return Result{}
+		})
+		require.NoError(t, r.Err)
+		assert.Equal(t, "c2", r.ConfigName)
+	}
+}
@@ -122,6 +122,9 @@ func (p *ClientPool[CONFIG, CLIENT]) Snapshot() any {
 			if has && extra.active != nil {
 				stateDetail["lastActive"] = extra.active.String()
 			}
+			if has && extra.latency != nil {
+				stateDetail["latency"] = extra.latency.Snapshot(p.config.Selection, now)
+			}
 			return map[string]any{
 				"name":        cli.name,
 				"publicName":  cli.publicName,
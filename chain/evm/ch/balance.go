@@ -0,0 +1,237 @@
+package ch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/balance"
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/common/chx"
+	"sentioxyz/sentio-core/common/objectx"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+// balanceSource is where the balance changes are rebuilt from
+type balanceSource interface {
+	QueryBlockLinks(ctx context.Context, from, to uint64) ([]evm.BlockLink, error)
+	QueryLogs(ctx context.Context, where string, limit int, args ...any) ([]types.Log, error)
+	QueryTraces(ctx context.Context, where string, limit int, args ...any) ([]evm.ParityTrace, error)
+}
+
+// balanceController is the EVM part of balance.Controller, the number of it is the block number,
+// see collectBalanceChanges for the balance changes.
+type balanceController struct {
+	balance.Controller
+
+	ctrl   chx.Controller
+	source balanceSource
+}
+
+func (s *balanceController) Init(
+	ctrl chx.Controller,
+	source balanceSource,
+	storePath string,
+) error {
+	s.ctrl = ctrl
+	s.source = source
+	return s.Controller.Init(s, storePath)
+}
+
+func (s *balanceController) EncodeHash(txHash string) []byte {
+	return common.HexToHash(txHash).Bytes() // length is a const common.HashLength
+}
+
+func (s *balanceController) DecodeHash(b []byte) (string, []byte, error) {
+	if len(b) < common.HashLength {
+		return "", nil, errors.Errorf("miss data when loading hash")
+	}
+	return common.BytesToHash(b[:common.HashLength]).String(), b[common.HashLength:], nil
+}
+
+func (s *balanceController) KeyText(key []byte) string {
+	addr, token := cutItemKey(key)
+	return fmt.Sprintf("%s/%s", addr, token)
+}
+
+func (s *balanceController) KeyUpperBound() []byte {
+	// {0xff} * (2 * common.AddressLength + 1) will greater than all keys
+	return bytes.Repeat([]byte{0xff}, common.AddressLength*2+1)
+}
+
+// Reload loads the latest balance items not after the block number from clickhouse
+func (s *balanceController) Reload(
+	ctx context.Context,
+	blockNumber uint64,
+	keys [][]byte,
+	set func(key []byte, item balance.Item) error,
+) error {
+	missSet := strings.Join(utils.MapSliceNoError(keys, func(key []byte) string {
+		addr, token := cutItemKey(key)
+		return fmt.Sprintf("('%s','%s')", addr, token)
+	}), ",")
+	// Pick the latest row (by (block_number, transaction_index)) per (address, token) with argMax, it is
+	// order-independent, see the balance controller of sui for the details.
+	// reorg(blockNumber) keeps state through blockNumber inclusive, so the restore base must include the row
+	// at exactly blockNumber.
+	sql := fmt.Sprintf("SELECT"+
+		" address,"+
+		" token,"+
+		" argMax(block_number, (block_number, transaction_index)),"+
+		" argMax(transaction_index, (block_number, transaction_index)),"+
+		" argMax(transaction_hash, (block_number, transaction_index)),"+
+		" argMax(balance, (block_number, transaction_index)) "+
+		"FROM %s "+
+		"WHERE (address, token) IN [%s] AND block_number <= %d "+
+		"GROUP BY address, token", s.ctrl.FullLogicName(tableNameBalances), missSet, blockNumber)
+	return s.ctrl.Query(ctx, func(rows driver.Rows) error {
+		var addr, token string
+		var item balance.Item
+		scanErr := rows.Scan(&addr, &token, &item.Number, &item.TxIndex, &item.TxHash, &item.Balance)
+		if scanErr != nil {
+			return scanErr
+		}
+		return set(buildItemKey(addr, token), item)
+	}, sql)
+}
+
+func buildItemKey(addr, token string) []byte {
+	a := common.HexToAddress(addr)
+	b := common.HexToAddress(token)
+	key := make([]byte, common.AddressLength*2)
+	copy(key, a[:])
+	copy(key[common.AddressLength:], b[:])
+	return key
+}
+
+func cutItemKey(b []byte) (addr, token string) {
+	return AddressToLowerString(common.BytesToAddress(b[:common.AddressLength])),
+		AddressToLowerString(common.BytesToAddress(b[common.AddressLength:]))
+}
+
+// maxRebuildRecordsPerPage bounds how many logs and traces a single rebuild page loads in memory, the
+// page bails early with tooBig == true once one of them exceeds it, so the pager can split the span and retry.
+const maxRebuildRecordsPerPage = 200000
+
+// rebuildTracesWhere selects the traces may transfer value, and the failed ones to find the reverted transfers
+const rebuildTracesWhere = "type IN ('call', 'create', 'suicide') AND " +
+	"(error != '' OR type = 'suicide' OR value NOT IN ('', '0x0'))"
+
+// RebuildPage rebuilds the balance records in [from,to] from the transfer logs and the value traces
+func (s *balanceController) RebuildPage(ctx context.Context, from, to uint64) (updated int, tooBig bool, err error) {
+	// 1. Query the blocks, transfer logs and value traces in [from, to].
+	// A span of a single block cannot be split, so it is loaded without limit.
+	limit := utils.Select(to > from, maxRebuildRecordsPerPage+1, 0)
+	where := fmt.Sprintf("block_number >= %d AND block_number <= %d", from, to)
+	links, err := s.source.QueryBlockLinks(ctx, from, to)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "query blocks in [%d,%d] failed", from, to)
+	}
+	logs, err := s.source.QueryLogs(ctx, where+" AND topics[1] = ?", limit, transferEventTopic.String())
+	if chain.IsTooManyResultsError(err) {
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "query transfer logs in [%d,%d] failed", from, to)
+	}
+	traces, err := s.source.QueryTraces(ctx, where+" AND "+rebuildTracesWhere, limit)
+	if chain.IsTooManyResultsError(err) {
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "query traces in [%d,%d] failed", from, to)
+	}
+
+	// 2. Sequentially compute correct balance values using the store state, updating the store along the way.
+	blockLogs := make(map[uint64][]types.Log)
+	for _, l := range logs {
+		blockLogs[l.BlockNumber] = append(blockLogs[l.BlockNumber], l)
+	}
+	blockTraces := make(map[uint64][]evm.ParityTrace)
+	for _, t := range traces {
+		blockTraces[t.BlockNumber] = append(blockTraces[t.BlockNumber], t)
+	}
+	var records []Balance
+	for _, link := range links {
+		var changes []balanceChange
+		if changes, err = collectBalanceChanges(blockLogs[link.Number], blockTraces[link.Number]); err != nil {
+			return 0, false, errors.Wrapf(err, "collect balance changes in block %d failed", link.Number)
+		}
+		blockIndex := BlockIndex{
+			BlockNumber:    link.Number,
+			BlockHash:      link.Hash.String(),
+			BlockTimestamp: time.Unix(int64(link.Timestamp), 0).UTC(),
+		}
+		for _, change := range changes {
+			var record Balance
+			if record, err = s.newBalance(blockIndex, change); err != nil {
+				return 0, false, err
+			}
+			records = append(records, record)
+		}
+	}
+
+	// 3. Delete old records from clickhouse, see the balance controller of sui for why not a lightweight delete.
+	if _, err = s.ctrl.Delete(ctx, tableNameBalances, where, false); err != nil {
+		return 0, false, errors.Wrapf(err, "delete balance records in [%d,%d] failed", from, to)
+	}
+
+	// 4. Insert new records with corrected balance values
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (`%s`)",
+		s.ctrl.FullLogicName(tableNameBalances),
+		strings.Join(objectx.CollectTagValue(&Balance{}, "clickhouse"), "`,`"),
+	)
+	fieldFilter := objectx.HasTag("clickhouse")
+	const insertBatchSize = 100000
+	err = s.ctrl.BatchInsert(ctx, sql, insertBatchSize, chx.NewGetter(records, func(record Balance) []any {
+		return objectx.CollectFieldValues(&record, fieldFilter)
+	}))
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "insert balance records in [%d,%d] failed", from, to)
+	}
+
+	// 5. Increase current in store
+	if err = s.IncrCursor(to); err != nil {
+		return 0, false, err
+	}
+
+	return len(records), false, nil
+}
+
+// newBalance applies the change to the store and returns the record of it
+func (s *balanceController) newBalance(blockIndex BlockIndex, change balanceChange) (Balance, error) {
+	pre, balance, err := s.IncrBalance(
+		buildItemKey(change.Address, change.Token),
+		blockIndex.BlockNumber, change.TransactionIndex, change.TransactionHash, change.Amount)
+	if err != nil {
+		return Balance{}, errors.Wrapf(err, "compute balance for %s/%s at %d/%d/%s failed",
+			change.Address, change.Token, blockIndex.BlockNumber, change.TransactionIndex, change.TransactionHash)
+	}
+	return Balance{
+		BlockNumber:         blockIndex.BlockNumber,
+		BlockHash:           blockIndex.BlockHash,
+		BlockTimestamp:      blockIndex.BlockTimestamp,
+		TransactionIndex:    change.TransactionIndex,
+		TransactionHash:     change.TransactionHash,
+		Address:             change.Address,
+		Token:               change.Token,
+		Amount:              change.Amount,
+		PreBlockNumber:      pre.Number,
+		PreTransactionIndex: pre.TxIndex,
+		PreTransactionHash:  pre.TxHash,
+		Balance:             balance,
+	}, nil
+}
+
+func (s *balanceController) Snapshot() any {
+	return s.Controller.Snapshot("currentBlockNumber")
+}
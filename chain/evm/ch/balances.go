@@ -0,0 +1,317 @@
+package ch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/clickhouse"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/common/chx"
+	"sentioxyz/sentio-core/common/objectx"
+)
+
+const tableNameBalances = "balances"
+
+// NativeToken is the token of the native balance changes, no contract can be deployed at the zero address
+const NativeToken = "0x0000000000000000000000000000000000000000"
+
+// transferEventTopic is the topic of Transfer(address,address,uint256)
+var transferEventTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+type Balance struct {
+	BlockNumber    uint64    `clickhouse:"block_number" number_field:"true" projection:"holder/3"`
+	BlockHash      string    `clickhouse:"block_hash" type:"FixedString(66)"`
+	BlockTimestamp time.Time `clickhouse:"block_timestamp"`
+
+	TransactionIndex uint64 `clickhouse:"transaction_index" projection:"holder/4"`
+	TransactionHash  string `clickhouse:"transaction_hash"  projection:"holder/5" type:"FixedString(66)"`
+
+	Address string   `clickhouse:"address"                        projection:"holder/1" type:"FixedString(42)"`
+	Token   string   `clickhouse:"token"   index:"bloom_filter" projection:"holder/2" type:"FixedString(42)"`
+	Amount  *big.Int `clickhouse:"amount"`
+
+	PreBlockNumber      uint64   `clickhouse:"pre_block_number"`
+	PreTransactionIndex uint64   `clickhouse:"pre_transaction_index"`
+	PreTransactionHash  string   `clickhouse:"pre_transaction_hash"` // empty means no pre-transaction
+	Balance             *big.Int `clickhouse:"balance" projection:"holder/6"`
+}
+
+func (b *Balance) ToBalanceChange() evm.BalanceChange {
+	return evm.BalanceChange{
+		BlockNumber:      hexutil.Uint64(b.BlockNumber),
+		BlockHash:        common.HexToHash(b.BlockHash),
+		BlockTimestamp:   hexutil.Uint64(b.BlockTimestamp.Unix()),
+		TransactionIndex: hexutil.Uint64(b.TransactionIndex),
+		TransactionHash:  common.HexToHash(b.TransactionHash),
+		Address:          common.HexToAddress(b.Address),
+		Token:            common.HexToAddress(b.Token),
+		Amount:           (*hexutil.Big)(b.Amount),
+		Balance:          (*hexutil.Big)(b.Balance),
+	}
+}
+
+func buildBalanceTable(ctrl chx.Controller, blockPartitionSize uint64) clickhouse.TableSchema {
+	tableSettings := make(map[string]string)
+	chx.WithLightDeleteTableSettings(tableSettings)
+	chx.WithProjectionTableSettings(tableSettings)
+	return clickhouse.BuildTable(tableNameBalances, &Balance{}, chx.TableConfig{
+		Engine:      ctrl.NewDefaultMergeTreeEngine(),
+		PartitionBy: fmt.Sprintf("intDiv(block_number, %d)", blockPartitionSize),
+		OrderBy:     []string{"block_number", "transaction_index", "address", "token"},
+		Settings:    tableSettings,
+	}, "")
+}
+
+// balanceChange is the net change of the balance of an address for a token in a transaction
+type balanceChange struct {
+	TxnIndex
+	Address string
+	Token   string
+	Amount  *big.Int
+}
+
+type balanceChangeKey struct {
+	txIndex uint64
+	address string
+	token   string
+}
+
+type balanceChangeCollector struct {
+	txHashes map[uint64]string
+	amounts  map[balanceChangeKey]*big.Int
+}
+
+func (c *balanceChangeCollector) add(txIndex uint64, txHash string, address, token common.Address, amount *big.Int) {
+	c.txHashes[txIndex] = txHash
+	key := balanceChangeKey{txIndex: txIndex, address: AddressToLowerString(address), token: AddressToLowerString(token)}
+	if pre, has := c.amounts[key]; has {
+		c.amounts[key] = new(big.Int).Add(pre, amount)
+	} else {
+		c.amounts[key] = new(big.Int).Set(amount)
+	}
+}
+
+func (c *balanceChangeCollector) transfer(txIndex uint64, txHash string, from, to, token common.Address, amount *big.Int) {
+	if amount.Sign() == 0 {
+		return
+	}
+	// the zero address is the counterparty of mint and burn, its balance is meaningless
+	if from != (common.Address{}) {
+		c.add(txIndex, txHash, from, token, new(big.Int).Neg(amount))
+	}
+	if to != (common.Address{}) {
+		c.add(txIndex, txHash, to, token, amount)
+	}
+}
+
+func parseTraceValue(value string) (*big.Int, error) {
+	value = strings.TrimPrefix(value, "0x")
+	if value == "" {
+		return new(big.Int), nil
+	}
+	v, ok := new(big.Int).SetString(value, 16)
+	if !ok || v.Sign() < 0 {
+		return nil, errors.Errorf("invalid value %q", value)
+	}
+	return v, nil
+}
+
+// traceReverted returns true if the trace or any of its ancestors failed, the value transfers in it are reverted
+func traceReverted(failed [][]int, trace evm.ParityTrace) bool {
+	for _, f := range failed {
+		if len(f) <= len(trace.TraceAddress) && slices.Equal(f, trace.TraceAddress[:len(f)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectBalanceChanges derives the balance changes from the ERC20 Transfer logs and the value transfers in
+// the traces, which should all belong to one block and the traces of a transaction should be in the order
+// returned by the node. Gas fees, block rewards and withdrawals are not transfers, so the native balances
+// are the sums of the transferred values, not the real balances of the accounts.
+// The result is ordered by (transaction_index, address, token).
+func collectBalanceChanges(logs []types.Log, traces []evm.ParityTrace) ([]balanceChange, error) {
+	c := balanceChangeCollector{
+		txHashes: make(map[uint64]string),
+		amounts:  make(map[balanceChangeKey]*big.Int),
+	}
+	for _, l := range logs {
+		// ERC721 also emits Transfer(address,address,uint256) but the token id is indexed
+		if l.Removed || len(l.Topics) != 3 || l.Topics[0] != transferEventTopic || len(l.Data) != 32 {
+			continue
+		}
+		c.transfer(
+			uint64(l.TxIndex),
+			l.TxHash.String(),
+			common.BytesToAddress(l.Topics[1].Bytes()),
+			common.BytesToAddress(l.Topics[2].Bytes()),
+			l.Address,
+			new(big.Int).SetBytes(l.Data),
+		)
+	}
+	native := common.HexToAddress(NativeToken)
+	var failed [][]int
+	var lastTx uint64 = math.MaxUint64
+	for _, t := range traces {
+		if t.TransactionHash == nil {
+			continue // block rewards
+		}
+		if t.TransactionPosition != lastTx {
+			lastTx, failed = t.TransactionPosition, nil
+		}
+		if t.Error != "" {
+			failed = append(failed, t.TraceAddress)
+		}
+		if traceReverted(failed, t) {
+			continue
+		}
+		txIndex, txHash := t.TransactionPosition, t.TransactionHash.String()
+		switch t.Type {
+		case "call":
+			// delegatecall and staticcall do not transfer value, callcode transfers to the caller itself
+			if t.Action.CallType != "call" || t.Action.From == nil {
+				continue
+			}
+			value, err := parseTraceValue(t.Action.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse value of trace %v in tx %s failed", t.TraceAddress, txHash)
+			}
+			c.transfer(txIndex, txHash, *t.Action.From, common.HexToAddress(t.Action.To), native, value)
+		case "create":
+			if t.Action.From == nil || t.Result == nil || t.Result.Address == nil {
+				continue
+			}
+			value, err := parseTraceValue(t.Action.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse value of trace %v in tx %s failed", t.TraceAddress, txHash)
+			}
+			c.transfer(txIndex, txHash, *t.Action.From, *t.Result.Address, native, value)
+		case "suicide":
+			if t.Action.Address == nil || t.Action.RefundAddress == nil || t.Action.Balance == nil {
+				continue
+			}
+			c.transfer(txIndex, txHash, *t.Action.Address, *t.Action.RefundAddress, native, t.Action.Balance.ToInt())
+		}
+	}
+	changes := make([]balanceChange, 0, len(c.amounts))
+	for key, amount := range c.amounts {
+		if amount.Sign() == 0 {
+			continue
+		}
+		changes = append(changes, balanceChange{
+			TxnIndex: TxnIndex{TransactionIndex: key.txIndex, TransactionHash: c.txHashes[key.txIndex]},
+			Address:  key.address,
+			Token:    key.token,
+			Amount:   amount,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].TransactionIndex != changes[j].TransactionIndex {
+			return changes[i].TransactionIndex < changes[j].TransactionIndex
+		}
+		if changes[i].Address != changes[j].Address {
+			return changes[i].Address < changes[j].Address
+		}
+		return changes[i].Token < changes[j].Token
+	})
+	return changes, nil
+}
+
+// slotBalanceChanges collects the balance changes of the logs in the receipts and the traces of the slot
+func slotBalanceChanges(st *evm.Slot) ([]balanceChange, error) {
+	var logs []types.Log
+	for _, r := range st.Receipts {
+		for _, l := range r.Logs {
+			logs = append(logs, *l)
+		}
+	}
+	return collectBalanceChanges(logs, st.Traces)
+}
+
+// BalanceStore queries the balances table maintained by ClickhouseSchemaMgr, the native balances in it
+// only sum the transferred values, see collectBalanceChanges.
+type BalanceStore struct {
+	ctrl chx.Controller
+}
+
+func NewBalanceStore(ctrl chx.Controller) *BalanceStore {
+	return &BalanceStore{ctrl: ctrl}
+}
+
+func (s *BalanceStore) query(ctx context.Context, where, suffix string, args ...any) (result []evm.BalanceChange, err error) {
+	fieldFilter := objectx.HasTag("clickhouse")
+	// see the comment above blockLinksSQL for why DISTINCT
+	sql := fmt.Sprintf("SELECT DISTINCT `%s` FROM %s WHERE %s %s",
+		strings.Join(objectx.CollectTagValue(&Balance{}, "clickhouse", fieldFilter), "`,`"),
+		s.ctrl.FullLogicName(tableNameBalances),
+		where,
+		suffix)
+	err = s.ctrl.Query(ctx, func(rows driver.Rows) error {
+		var b Balance
+		if scanErr := rows.Scan(objectx.CollectFieldPointers(&b, fieldFilter)...); scanErr != nil {
+			return scanErr
+		}
+		result = append(result, b.ToBalanceChange())
+		return nil
+	}, sql, args...)
+	return result, err
+}
+
+// QueryBalanceHistory returns the balance changes of the address in [from, to] ordered by
+// (block_number, transaction_index, token), token nil means all tokens.
+// limit is applied like EthVariationCtrl.QueryLogs, hitting it fails with chain.NewTooManyResultsError.
+func (s *BalanceStore) QueryBalanceHistory(
+	ctx context.Context,
+	address common.Address,
+	token *common.Address,
+	from, to uint64,
+	limit int,
+) ([]evm.BalanceChange, error) {
+	where := "address = ? AND block_number >= ? AND block_number <= ?"
+	args := []any{AddressToLowerString(address), from, to}
+	if token != nil {
+		where += " AND token = ?"
+		args = append(args, AddressToLowerString(*token))
+	}
+	suffix := "ORDER BY block_number, transaction_index, token"
+	if limit > 0 {
+		suffix += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	result, err := s.query(ctx, where, suffix, args...)
+	if err == nil && limit > 0 && len(result) >= limit {
+		err = chain.NewTooManyResultsError()
+	}
+	return result, err
+}
+
+// QueryBalanceAt returns the last balance change of the address for the token at or before the block,
+// nil means the balance has never been changed.
+func (s *BalanceStore) QueryBalanceAt(
+	ctx context.Context,
+	address common.Address,
+	token common.Address,
+	blockNumber uint64,
+) (*evm.BalanceChange, error) {
+	result, err := s.query(ctx,
+		"address = ? AND token = ? AND block_number <= ?",
+		"ORDER BY block_number DESC, transaction_index DESC LIMIT 1",
+		AddressToLowerString(address), AddressToLowerString(token), blockNumber)
+	if err != nil || len(result) == 0 {
+		return nil, err
+	}
+	return &result[0], nil
+}
@@ -0,0 +1,181 @@
+package ch
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+
+	"sentioxyz/sentio-core/chain/balance"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/common/chx"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+var (
+	testAlice = common.HexToAddress("0x00000000000000000000000000000000000a11ce")
+	testBob   = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	testToken = common.HexToAddress("0x00000000000000000000000000000000000070c1")
+	testTx0   = common.HexToHash("0x1000")
+	testTx1   = common.HexToHash("0x1001")
+)
+
+func transferLog(txIndex uint, txHash common.Hash, from, to common.Address, amount int64) types.Log {
+	return types.Log{
+		Address: testToken,
+		Topics:  []common.Hash{transferEventTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+		TxIndex: txIndex,
+		TxHash:  txHash,
+	}
+}
+
+func valueTrace(txIndex uint64, txHash common.Hash, traceAddress []int, from, to common.Address, value string) evm.ParityTrace {
+	return evm.ParityTrace{
+		Type:                "call",
+		Action:              evm.ParityTraceAction{CallType: "call", From: &from, To: to.String(), Value: value},
+		TraceAddress:        traceAddress,
+		TransactionHash:     &txHash,
+		TransactionPosition: txIndex,
+	}
+}
+
+func changeOf(txIndex uint64, txHash common.Hash, addr, token common.Address, amount int64) balanceChange {
+	return balanceChange{
+		TxnIndex: TxnIndex{TransactionIndex: txIndex, TransactionHash: txHash.String()},
+		Address:  AddressToLowerString(addr),
+		Token:    AddressToLowerString(token),
+		Amount:   big.NewInt(amount),
+	}
+}
+
+func TestCollectBalanceChanges(t *testing.T) {
+	native := common.HexToAddress(NativeToken)
+	erc721 := transferLog(0, testTx0, testAlice, testBob, 0)
+	erc721.Topics = append(erc721.Topics, common.BigToHash(big.NewInt(7)))
+	erc721.Data = nil
+	mint := transferLog(0, testTx0, common.Address{}, testAlice, 100)
+
+	failed := valueTrace(1, testTx1, []int{0}, testBob, testAlice, "0x5")
+	failed.Error = "Reverted"
+	reverted := valueTrace(1, testTx1, []int{0, 0}, testAlice, testBob, "0x6")
+	delegate := valueTrace(1, testTx1, []int{1}, testBob, testAlice, "0x7")
+	delegate.Action.CallType = "delegatecall"
+	created := common.HexToAddress("0xc0ffee")
+	create := evm.ParityTrace{
+		Type:                "create",
+		Action:              evm.ParityTraceAction{From: &testBob, Value: "0x3"},
+		Result:              &evm.ParityTraceResult{Address: &created},
+		TraceAddress:        []int{2},
+		TransactionHash:     &testTx1,
+		TransactionPosition: 1,
+	}
+	suicide := evm.ParityTrace{
+		Type: "suicide",
+		Action: evm.ParityTraceAction{
+			Address:       &created,
+			RefundAddress: &testAlice,
+			Balance:       (*hexutil.Big)(big.NewInt(2)),
+		},
+		TraceAddress:        []int{3},
+		TransactionHash:     &testTx1,
+		TransactionPosition: 1,
+	}
+	reward := evm.ParityTrace{Type: "reward", Action: evm.ParityTraceAction{Author: &testAlice, Value: "0x100"}}
+
+	changes, err := collectBalanceChanges(
+		[]types.Log{
+			mint,
+			transferLog(0, testTx0, testAlice, testBob, 30),
+			transferLog(1, testTx1, testBob, testAlice, 10),
+			transferLog(1, testTx1, testAlice, testBob, 10),
+			erc721,
+		},
+		[]evm.ParityTrace{
+			valueTrace(0, testTx0, nil, testAlice, testBob, "0x10"),
+			valueTrace(0, testTx0, []int{0}, testBob, testAlice, "0x0"),
+			failed,
+			reverted,
+			delegate,
+			create,
+			suicide,
+			reward,
+		},
+	)
+	assert.NoError(t, err)
+	// the zero address of mint is skipped, the transfers in tx1 offset each other, the subtree of the failed
+	// trace, delegatecall and block rewards transfer nothing
+	assert.Equal(t, []balanceChange{
+		changeOf(0, testTx0, testBob, native, 16),
+		changeOf(0, testTx0, testBob, testToken, 30),
+		changeOf(0, testTx0, testAlice, native, -16),
+		changeOf(0, testTx0, testAlice, testToken, 70),
+		changeOf(1, testTx1, testBob, native, -3),
+		changeOf(1, testTx1, testAlice, native, 2),
+		changeOf(1, testTx1, created, native, 1),
+	}, changes)
+}
+
+func TestCollectBalanceChangesInvalidValue(t *testing.T) {
+	_, err := collectBalanceChanges(nil, []evm.ParityTrace{valueTrace(0, testTx0, nil, testAlice, testBob, "0xzz")})
+	assert.Error(t, err)
+}
+
+func TestBalanceItemBytes(t *testing.T) {
+	var codec balanceController
+	for _, item := range []balance.Item{
+		{Balance: big.NewInt(0)},
+		{Number: 123456789, TxIndex: 300, TxHash: testTx0.String(), Balance: new(big.Int).Lsh(big.NewInt(1), 200)},
+		{Number: 1, TxIndex: 0, TxHash: testTx1.String(), Balance: big.NewInt(-42)},
+	} {
+		loaded, err := balance.NewItemFromBytes(item.Bytes(&codec), &codec)
+		assert.NoError(t, err)
+		assert.Equal(t, item.Number, loaded.Number)
+		assert.Equal(t, item.TxIndex, loaded.TxIndex)
+		assert.Equal(t, common.HexToHash(item.TxHash), common.HexToHash(loaded.TxHash))
+		assert.Equal(t, 0, item.Balance.Cmp(loaded.Balance))
+	}
+	_, err := balance.NewItemFromBytes([]byte{9}, &codec)
+	assert.Error(t, err)
+}
+
+func TestBalanceItemKey(t *testing.T) {
+	key := buildItemKey(testAlice.String(), NativeToken)
+	assert.Len(t, key, common.AddressLength*2)
+	addr, token := cutItemKey(key)
+	assert.Equal(t, AddressToLowerString(testAlice), addr)
+	assert.Equal(t, NativeToken, token)
+}
+
+func TestBalanceControllerNewBalance(t *testing.T) {
+	var c balanceController
+	assert.NoError(t, c.Init(chx.Controller{}, nil, t.TempDir()))
+	defer func() {
+		_ = c.Close()
+	}()
+	ctx := context.Background()
+	assert.NoError(t, c.ResetToGenesis(ctx))
+
+	alice, token := AddressToLowerString(testAlice), AddressToLowerString(testToken)
+	b0, err := c.newBalance(BlockIndex{BlockNumber: 0}, changeOf(0, testTx0, testAlice, testToken, 5))
+	assert.NoError(t, err)
+	assert.Equal(t, alice, b0.Address)
+	assert.Equal(t, token, b0.Token)
+	assert.Equal(t, "", b0.PreTransactionHash)
+	assert.Equal(t, big.NewInt(5), b0.Balance)
+	assert.NoError(t, c.IncrCursor(0))
+
+	// store is clean and aligned, nothing to rebuild or reorg
+	assert.NoError(t, c.Align(ctx, 0))
+	b1, err := c.newBalance(BlockIndex{BlockNumber: 1}, changeOf(2, testTx1, testAlice, testToken, -7))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), b1.PreBlockNumber)
+	assert.Equal(t, testTx0.String(), b1.PreTransactionHash)
+	assert.Equal(t, big.NewInt(-2), b1.Balance)
+	assert.NoError(t, c.IncrCursor(1))
+	assert.NoError(t, c.Done(rg.NewRange(0, 1)))
+}
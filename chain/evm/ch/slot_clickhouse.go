@@ -2,44 +2,125 @@ package ch
 
 import (
 	"context"
+
+	"github.com/pkg/errors"
+
 	"sentioxyz/sentio-core/chain/clickhouse"
 	"sentioxyz/sentio-core/chain/evm"
 	"sentioxyz/sentio-core/common/chx"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/objectx"
 	rg "sentioxyz/sentio-core/common/range"
 )
 
 type ClickhouseSchemaMgr struct {
 	tablesMeta         clickhouse.TablesMeta
 	ethVarCtrl         EthVariationCtrl
+	balanceCtrl        balanceController
 	convertConcurrency uint
 }
 
+// NewClickhouseSchemaMgr builds the schema manager of the evm tables, the balances table is also maintained
+// if balanceStorePath is not empty, which is the path of the local store of the latest balances.
 func NewClickhouseSchemaMgr(
 	chainID string,
 	ctrl chx.Controller,
 	blockPartitionSize uint64,
 	convertConcurrency uint,
-) *ClickhouseSchemaMgr {
+	balanceStorePath string,
+) (*ClickhouseSchemaMgr, error) {
 	ethVarCtrl := NewEthVarCtrl(chainID, ctrl)
-	return &ClickhouseSchemaMgr{
+	m := &ClickhouseSchemaMgr{
 		ethVarCtrl:         ethVarCtrl,
 		tablesMeta:         ethVarCtrl.BuildTablesMeta(blockPartitionSize),
 		convertConcurrency: convertConcurrency,
 	}
+	if balanceStorePath != "" {
+		m.tablesMeta.Tables = append(m.tablesMeta.Tables, buildBalanceTable(ctrl, blockPartitionSize))
+	}
+	if err := m.balanceCtrl.Init(ctrl, ethVarCtrl, balanceStorePath); err != nil {
+		return nil, errors.Wrapf(err, "init balance controller failed")
+	}
+	return m, nil
 }
 
 func (m *ClickhouseSchemaMgr) GetTablesMeta() clickhouse.TablesMeta {
 	return m.tablesMeta
 }
 
-func (m *ClickhouseSchemaMgr) Convert(_ context.Context, slot *evm.Slot) (clickhouse.Chunk, error) {
-	return m.ethVarCtrl.Convert(slot)
+func (m *ClickhouseSchemaMgr) convertBalances(ctx context.Context, slot *evm.Slot) (balances []Balance, err error) {
+	bn := uint64(slot.GetNumber())
+	defer func() {
+		if err == nil {
+			err = m.balanceCtrl.IncrCursor(bn)
+		}
+		if err != nil {
+			_, logger := log.FromContext(ctx, "blockNumber", bn)
+			logger.Errorfe(err, "convert balances failed")
+			err = errors.Wrapf(err, "convert balances of block %d failed", bn)
+		}
+	}()
+	// Normally, all blocks will call convert sequentially.
+	// If failed midway, the segment at the tail will be restarted, and the saved balance data will need to be rollback.
+	// Genesis block 0 has no predecessor to Align to, so reset the store to empty instead.
+	if bn == 0 {
+		if err = m.balanceCtrl.ResetToGenesis(ctx); err != nil {
+			return nil, errors.Wrapf(err, "reset balance store for genesis block failed")
+		}
+	} else if err = m.balanceCtrl.Align(ctx, bn-1); err != nil {
+		return nil, errors.Wrapf(err, "align balance store for block %d failed", bn)
+	}
+	changes, err := slotBalanceChanges(slot)
+	if err != nil {
+		return nil, err
+	}
+	blockIndex := BuildBlockIndex(slot)
+	for _, change := range changes {
+		var balance Balance
+		if balance, err = m.balanceCtrl.newBalance(blockIndex, change); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
 }
 
+func (m *ClickhouseSchemaMgr) Convert(ctx context.Context, slot *evm.Slot) (clickhouse.Chunk, error) {
+	if !m.balanceCtrl.Enabled() {
+		return m.ethVarCtrl.Convert(slot)
+	}
+	balances, err := m.convertBalances(ctx, slot)
+	if err != nil {
+		return clickhouse.Chunk{}, err
+	}
+	chunk, err := m.ethVarCtrl.Convert(slot)
+	if err != nil {
+		return clickhouse.Chunk{}, err
+	}
+	fieldFilter := objectx.HasTag("clickhouse")
+	chunk.RowNum = append(chunk.RowNum, len(balances))
+	for _, b := range balances {
+		chunk.RowData = append(chunk.RowData, objectx.CollectFieldValues(&b, fieldFilter))
+	}
+	return chunk, nil
+}
+
+// ConvertConcurrency should always 1 if the balances table is maintained, because balance increase must be
+// one by one.
 func (m *ClickhouseSchemaMgr) ConvertConcurrency() uint {
+	if m.balanceCtrl.Enabled() {
+		return 1
+	}
 	return m.convertConcurrency
 }
 
 func (m *ClickhouseSchemaMgr) Done(r rg.Range) error {
-	return nil
+	return m.balanceCtrl.Done(r)
+}
+
+func (m *ClickhouseSchemaMgr) Snapshot() any {
+	return map[string]any{
+		"tablesMeta":  m.tablesMeta,
+		"balanceCtrl": m.balanceCtrl.Snapshot(),
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"sentioxyz/sentio-core/chain/clientpool"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/chain/evm/pq"
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/common/errgroup"
+	"sentioxyz/sentio-core/common/flags"
+	"sentioxyz/sentio-core/common/log"
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/service/processor/storage"
+)
+
+// exports the finalized blocks from the RPC nodes to the parquet files of the cold tier, the partitions
+// already exported are skipped, so it can be run periodically
+func main() {
+	var (
+		config     pq.ColdTierConfig
+		endpoints  flags.StringSlice
+		chainID    = flag.Uint64("chain", 1, "Chain ID")
+		from       = flag.Uint64("from", 0, "First block to export")
+		to         = flag.Uint64("to", 0, "Last block to export, 0 means the latest finalized block")
+		fallBehind = flag.Duration("fall-behind", time.Hour,
+			"Blocks newer than this are not finalized and will not be exported")
+		concurrency  = flag.Uint("concurrency", 10, "Number of blocks fetched concurrently")
+		disableTrace = flag.Bool("disable-trace", false, "Do not fetch the traces of the blocks")
+		s3Endpoint   = flag.String("s3-endpoint", "", "Endpoint of the S3 compatible store, "+
+			"the credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		s3Region = flag.String("s3-region", "", "Region of the S3 compatible store")
+	)
+	flag.Var(&endpoints, "endpoint", "RPC endpoint of the chain, can be specified multiple times")
+	flag.StringVar(&config.Dir, "dir", "", "Local directory of the parquet files")
+	flag.StringVar(&config.S3Bucket, "s3-bucket", "", "Bucket of the parquet files in the S3 compatible store")
+	flag.StringVar(&config.S3Prefix, "s3-prefix", "", "Key prefix of the parquet files in the bucket")
+	flag.StringVar(&config.CacheDir, "cache-dir", os.TempDir(), "Local directory caching the S3 objects")
+	flag.Int64Var(&config.CacheSize, "cache-size", parquet.DefaultS3CacheSize, "Max total size in bytes of the cached S3 objects")
+	flag.Uint64Var(&config.PartitionSize, "partition-size", parquet.DefaultPartitionSize, "Blocks of a partition")
+	flag.Int64Var(&config.RowGroupSize, "row-group-size", parquet.DefaultRowGroupSize, "Rows of a row group")
+	flags.ParseAndInitLogFlag()
+
+	if len(endpoints) == 0 {
+		log.Fatalf("-endpoint is required")
+	}
+	var s3 parquet.S3Engine
+	if config.S3Bucket != "" {
+		engine, err := storage.NewS3StorageEngine(storage.S3Config{
+			Endpoint:    *s3Endpoint,
+			Region:      *s3Region,
+			AccessKeyID: os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:   os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			Bucket:      config.S3Bucket,
+			PathStyle:   true,
+		})
+		if err != nil {
+			log.Fatalf("create s3 engine failed: %v", err)
+		}
+		s3 = engine
+	}
+	tier, err := pq.NewColdTier(config, s3)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g, gctx := errgroup.WithContext(ctx)
+
+	client := evm.NewClientPool("export", nil)
+	poolConfig := clientpool.PoolConfig[evm.ClientConfig]{}
+	for _, endpoint := range endpoints {
+		poolConfig.ClientConfigs = append(poolConfig.ClientConfigs, clientpool.ClientConfig[evm.ClientConfig]{
+			Config: evm.ClientConfig{
+				JSONRPCConfig:        clientpool.JSONRPCConfig{Endpoint: endpoint},
+				ChainID:              *chainID,
+				IgnoreStateFromCheck: true,
+			},
+		})
+	}
+	configs := make(chan clientpool.PoolConfig[evm.ClientConfig], 1)
+	configs <- poolConfig
+	g.Go(func() error {
+		client.Start(gctx, configs)
+		return nil
+	})
+	g.Go(func() error {
+		defer cancel()
+		hot := evm.NewExtServerDimension(client, *concurrency, 3, rg.Range{}, strconv.FormatUint(*chainID, 10),
+			evm.NetworkOptions{DisableTrace: *disableTrace}, *fallBehind, 0)
+		interval := rg.Range{Start: *from}
+		if *to > 0 {
+			interval = rg.NewRange(*from, *to)
+		}
+		exported, exportErr := tier.Export(gctx, hot, interval)
+		log.Infow("export finished", "interval", interval, "exported", len(exported))
+		return exportErr
+	})
+	if err = g.Wait(); err != nil {
+		log.Errorfe(err, "export failed")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,295 @@
+package pq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/chain/evm/supernode"
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/common/log"
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/common/set"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+// NewDimension creates the dimension with the cold tier of the parquet files, the logs and the transactions
+// are also exported so the Storage can answer the queries of them
+func NewDimension(
+	hot chain.Dimension[*evm.Slot],
+	store *parquet.Store,
+	config parquet.Config,
+) *parquet.Dimension[*evm.Slot] {
+	return parquet.NewDimension[*evm.Slot](hot, store, parquet.JSONCodec[*evm.Slot]{}, config,
+		LogsTable{}, TransactionsTable{})
+}
+
+// Storage answers QueryLogs and QueryTxs of the blocks below the watermark from the parquet files exported by
+// the dimension created by NewDimension, the partitions not exported yet and the other queries go to the hot storage.
+// Only the where conditions generated by the super node are supported, see parseWhere.
+type Storage struct {
+	supernode.Storage
+
+	store  *parquet.Store
+	config parquet.Config
+}
+
+func NewStorage(hot supernode.Storage, store *parquet.Store, config parquet.Config) *Storage {
+	return &Storage{
+		Storage: hot,
+		store:   store,
+		config:  config,
+	}
+}
+
+type part struct {
+	interval rg.Range
+	cold     bool
+}
+
+// plan splits the interval into the parts in order, each part in the cold range is in one partition
+func (s *Storage) plan(interval rg.Range) (parts []part) {
+	if interval.IsEmpty() {
+		return nil
+	}
+	cold := s.config.ColdRange()
+	if cold.IsEmpty() {
+		return []part{{interval: interval}}
+	}
+	if cold.Start > 0 {
+		if left := interval.Intersection(rg.NewRange(0, cold.Start-1)); !left.IsEmpty() {
+			parts = append(parts, part{interval: left})
+		}
+	}
+	for _, partition := range s.store.Partitions(interval.Intersection(cold)) {
+		parts = append(parts, part{interval: partition.Intersection(interval), cold: true})
+	}
+	if right := interval.Intersection(rg.Range{Start: s.config.Watermark}); !right.IsEmpty() {
+		parts = append(parts, part{interval: right})
+	}
+	return parts
+}
+
+// hotWhere limits the where condition in the interval
+func hotWhere(where string, interval rg.Range) string {
+	where = fmt.Sprintf("(%s) AND block_number >= %d", where, interval.Start)
+	if interval.End != nil {
+		where += fmt.Sprintf(" AND block_number <= %d", *interval.End)
+	}
+	return where
+}
+
+// parse parses the where condition and resolves the sub queries of the blocks
+func (s *Storage) parse(ctx context.Context, where string, args ...any) (filter, error) {
+	f, err := parseWhere(where, args...)
+	if err != nil {
+		return f, errors.Wrapf(err, "where condition %q is not supported by parquet storage", where)
+	}
+	for _, sub := range f.blocksSubQueries {
+		logs, err := s.QueryLogs(ctx, sub, 0)
+		if err != nil {
+			return f, errors.Wrapf(err, "query blocks by %q failed", sub)
+		}
+		f.restrictBlocks(set.New(utils.MapSliceNoError(logs, func(l types.Log) uint64 {
+			return l.BlockNumber
+		})...))
+	}
+	f.blocksSubQueries = nil
+	return f, nil
+}
+
+// open opens the partition of the table contains the interval, returns nil if the partition is not exported yet
+func (s *Storage) open(ctx context.Context, table string, interval rg.Range) (*parquet.TableFile, error) {
+	partition := s.store.Partition(interval.Start)
+	exported, err := s.store.Has(ctx, parquet.SlotTableName, partition)
+	if err != nil || !exported {
+		return nil, err
+	}
+	f, err := s.store.Open(ctx, table, partition)
+	if errors.Is(err, os.ErrNotExist) {
+		// the dimension does not export the table
+		return nil, nil
+	}
+	return f, err
+}
+
+func (s *Storage) QueryLogs(ctx context.Context, where string, limit int, args ...any) ([]types.Log, error) {
+	_, logger := log.FromContext(ctx)
+	f, err := s.parse(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+	var result []types.Log
+	for _, p := range s.plan(f.blocksRange()) {
+		remaining := limit - len(result)
+		if limit > 0 && remaining <= 0 {
+			return result, chain.NewTooManyResultsError()
+		}
+		partLimit := utils.Select(limit > 0, remaining, 0)
+		var logs []types.Log
+		var file *parquet.TableFile
+		if p.cold {
+			if file, err = s.open(ctx, tableNameLogs, p.interval); err != nil {
+				return result, err
+			}
+		}
+		if file != nil {
+			logs, err = s.queryColdLogs(ctx, file, p.interval, f, partLimit)
+		} else {
+			if p.cold {
+				logger.Warnf("logs in %s are not exported, query from the hot storage", p.interval)
+			}
+			logs, err = s.Storage.QueryLogs(ctx, hotWhere(where, p.interval), partLimit, args...)
+		}
+		result = append(result, logs...)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// queryColdLogs queries the logs from the file, the row groups are skipped by the statistics of the block number,
+// the address and the topics, returns chain.NewTooManyResultsError if got limit logs
+func (s *Storage) queryColdLogs(
+	ctx context.Context,
+	file *parquet.TableFile,
+	interval rg.Range,
+	f filter,
+	limit int,
+) (result []types.Log, err error) {
+	defer file.Close()
+	rowGroups := make([]int, 0, file.NumRowGroups())
+	for i := 0; i < file.NumRowGroups(); i++ {
+		if !file.MayContainUint(i, "block_number", interval) {
+			continue
+		}
+		if f.addresses != nil && !file.MayContainString(i, "address", f.addresses) {
+			continue
+		}
+		matched := true
+		for j, values := range f.topics {
+			if values != nil && !file.MayContainString(i, fmt.Sprintf("topic%d", j), values) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			rowGroups = append(rowGroups, i)
+		}
+	}
+	err = file.Read(ctx, rowGroups, func(rec arrow.Record) error {
+		return readLogRows(rec, func(row logRow) error {
+			if !interval.Contains(row.BlockNumber) || !f.matchLog(row) {
+				return nil
+			}
+			result = append(result, row.toLog())
+			if limit > 0 && len(result) >= limit {
+				return chain.NewTooManyResultsError()
+			}
+			return nil
+		})
+	})
+	// the rows are ordered by the address in the file
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BlockNumber != result[j].BlockNumber {
+			return result[i].BlockNumber < result[j].BlockNumber
+		}
+		return result[i].Index < result[j].Index
+	})
+	return result, err
+}
+
+func (s *Storage) QueryTxs(ctx context.Context, where string, args ...any) ([]evm.ExtendedTransaction, error) {
+	_, logger := log.FromContext(ctx)
+	f, err := s.parse(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if f.hasLogConditions() {
+		return nil, errors.Errorf("where condition %q of transactions is not supported by parquet storage", where)
+	}
+	var result []evm.ExtendedTransaction
+	for _, p := range s.plan(f.blocksRange()) {
+		var txs []evm.ExtendedTransaction
+		var file *parquet.TableFile
+		if p.cold {
+			if file, err = s.open(ctx, tableNameTransactions, p.interval); err != nil {
+				return nil, err
+			}
+		}
+		if file != nil {
+			txs, err = s.queryColdTxs(ctx, file, p.interval, f)
+		} else {
+			if p.cold {
+				logger.Warnf("transactions in %s are not exported, query from the hot storage", p.interval)
+			}
+			txs, err = s.Storage.QueryTxs(ctx, hotWhere(where, p.interval), args...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, txs...)
+	}
+	return result, nil
+}
+
+func (s *Storage) queryColdTxs(
+	ctx context.Context,
+	file *parquet.TableFile,
+	interval rg.Range,
+	f filter,
+) (result []evm.ExtendedTransaction, err error) {
+	defer file.Close()
+	rowGroups := make([]int, 0, file.NumRowGroups())
+	for i := 0; i < file.NumRowGroups(); i++ {
+		if file.MayContainUint(i, "block_number", interval) {
+			rowGroups = append(rowGroups, i)
+		}
+	}
+	err = file.Read(ctx, rowGroups, func(rec arrow.Record) error {
+		r := &columnReader{rec: rec}
+		blockNumber := column[*array.Uint64](r, "block_number")
+		blockHash := column[*array.String](r, "block_hash")
+		blockTimestamp := column[*array.Int64](r, "block_timestamp")
+		transaction := column[*array.Binary](r, "transaction")
+		receipt := column[*array.Binary](r, "receipt")
+		if r.err != nil {
+			return r.err
+		}
+		for i := 0; i < int(rec.NumRows()); i++ {
+			bn := blockNumber.Value(i)
+			if !interval.Contains(bn) || !f.matchBlock(bn) {
+				continue
+			}
+			tx := evm.ExtendedTransaction{
+				BlockNumber:    bn,
+				BlockHash:      strings.Clone(blockHash.Value(i)),
+				BlockTimestamp: time.UnixMicro(blockTimestamp.Value(i)).UTC(),
+			}
+			if err := json.Unmarshal(transaction.Value(i), &tx.RPCTransaction); err != nil {
+				return errors.Wrapf(err, "decode transaction of block %d failed", bn)
+			}
+			if data := receipt.Value(i); len(data) > 0 {
+				tx.ExtendedReceipt = &evm.ExtendedReceipt{}
+				if err := json.Unmarshal(data, tx.ExtendedReceipt); err != nil {
+					return errors.Wrapf(err, "decode receipt of transaction %s failed", tx.RPCTransaction.Hash)
+				}
+			}
+			result = append(result, tx)
+		}
+		return nil
+	})
+	return result, err
+}
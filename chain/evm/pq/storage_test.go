@@ -0,0 +1,217 @@
+package pq
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/chain/evm/supernode"
+	"sentioxyz/sentio-core/chain/parquet"
+	chainpebble "sentioxyz/sentio-core/chain/pebble"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+var (
+	testAddresses = []common.Address{
+		common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"),
+		common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+	}
+	testTopics = []common.Hash{
+		common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+		common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"),
+	}
+)
+
+func blockHash(bn uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(bn + 1000))
+}
+
+// newTestSlot returns the block with 2 transactions, each transaction emits a log of a different address,
+// the topic0 of the logs is different in the odd and even blocks
+func newTestSlot(bn uint64) *evm.Slot {
+	st := &evm.Slot{
+		Header: &evm.ExtendedHeader{
+			Header: types.Header{
+				ParentHash: blockHash(bn - 1),
+				Number:     new(big.Int).SetUint64(bn),
+				Time:       1700000000 + bn,
+				Difficulty: big.NewInt(0),
+			},
+			Hash: blockHash(bn),
+		},
+		Block: &evm.RPCBlock{},
+	}
+	for i := uint64(0); i < 2; i++ {
+		txHash := common.BigToHash(new(big.Int).SetUint64(bn*10 + i))
+		st.Block.Transactions = append(st.Block.Transactions, evm.RPCTransaction{
+			Hash:             txHash,
+			TransactionIndex: hexutil.Uint64(i),
+			Value:            (*hexutil.Big)(big.NewInt(int64(bn))),
+			Input:            hexutil.Bytes{},
+		})
+		st.Receipts = append(st.Receipts, evm.ExtendedReceipt{
+			TransactionIndex: hexutil.Uint(i),
+			TxHash:           txHash,
+			Status:           1,
+			Logs: []*types.Log{{
+				Address:     testAddresses[i],
+				Topics:      []common.Hash{testTopics[bn%2], txHash},
+				Data:        []byte{byte(bn), byte(i)},
+				BlockNumber: bn,
+				TxHash:      txHash,
+				TxIndex:     uint(i),
+				BlockHash:   blockHash(bn),
+				Index:       uint(i),
+			}},
+		})
+	}
+	return st
+}
+
+func expectedLog(bn uint64, i int) types.Log {
+	l := *newTestSlot(bn).Receipts[i].Logs[0]
+	l.BlockTimestamp = 1700000000 + bn
+	return l
+}
+
+// hotStorage is the hot storage which returns a log for each query, the block number of the log is the start of
+// the block range of the query
+type hotStorage struct {
+	supernode.Storage
+
+	wheres []string
+}
+
+func (s *hotStorage) QueryLogs(ctx context.Context, where string, limit int, args ...any) ([]types.Log, error) {
+	s.wheres = append(s.wheres, where)
+	f, err := parseWhere(where, args...)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Log{{BlockNumber: f.interval.Start}}, nil
+}
+
+func (s *hotStorage) QueryTxs(ctx context.Context, where string, args ...any) ([]evm.ExtendedTransaction, error) {
+	s.wheres = append(s.wheres, where)
+	f, err := parseWhere(where, args...)
+	if err != nil {
+		return nil, err
+	}
+	return []evm.ExtendedTransaction{{BlockNumber: f.interval.Start}}, nil
+}
+
+// newTestStorage exports the blocks [0,19] into the partitions of 10 blocks, the cold range is [0,29]
+func newTestStorage(t *testing.T) (*Storage, *hotStorage) {
+	ctx := context.Background()
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	dim := chainpebble.NewDimension[*evm.Slot](db, "test/", chainpebble.JSONCodec[*evm.Slot]{}, 10)
+	require.NoError(t, dim.Init(ctx))
+	slots := make(chan *evm.Slot, 20)
+	for bn := uint64(0); bn < 20; bn++ {
+		slots <- newTestSlot(bn)
+	}
+	close(slots)
+	require.NoError(t, dim.Save(ctx, rg.NewRange(0, 19), slots))
+
+	store := parquet.NewStore(parquet.NewLocalObjectStore(t.TempDir()), 10, 4)
+	config := parquet.Config{Start: 0, Watermark: 30}
+	exported, err := NewDimension(dim, store, config).Export(ctx, rg.NewRange(0, 19))
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+
+	hot := &hotStorage{}
+	return NewStorage(hot, store, config), hot
+}
+
+func Test_StorageQueryLogs(t *testing.T) {
+	ctx := context.Background()
+	s, hot := newTestStorage(t)
+
+	where := "address IN ('0xdAC17F958D2ee523a2206206994597C13D831ec7','0xdac17f958d2ee523a2206206994597c13d831ec7') " +
+		"AND topics[1] IN ('" + testTopics[1].Hex() + "') AND block_number >= 5 AND block_number <= 35"
+	logs, err := s.QueryLogs(ctx, where, 0)
+	require.NoError(t, err)
+	expected := []types.Log{
+		expectedLog(5, 0), expectedLog(7, 0), expectedLog(9, 0),
+		expectedLog(11, 0), expectedLog(13, 0), expectedLog(15, 0), expectedLog(17, 0), expectedLog(19, 0),
+		{BlockNumber: 20}, {BlockNumber: 30},
+	}
+	assert.Equal(t, expected, logs)
+	// the partition [20,29] is not exported
+	assert.Equal(t, []string{
+		"(" + where + ") AND block_number >= 20 AND block_number <= 29",
+		"(" + where + ") AND block_number >= 30 AND block_number <= 35",
+	}, hot.wheres)
+
+	// the second topic of the log is the transaction hash
+	logs, err = s.QueryLogs(ctx, "block_number >= 0 AND block_number <= 19 AND topics[2] = ?", 0,
+		common.BigToHash(big.NewInt(121)).String())
+	require.NoError(t, err)
+	assert.Equal(t, []types.Log{expectedLog(12, 1)}, logs)
+
+	logs, err = s.QueryLogs(ctx, "block_number = 3", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Log{expectedLog(3, 0), expectedLog(3, 1)}, logs)
+
+	// 20 logs in [0,9]
+	_, err = s.QueryLogs(ctx, "block_number >= 0 AND block_number <= 9", 20)
+	assert.True(t, chain.IsTooManyResultsError(err))
+	logs, err = s.QueryLogs(ctx, "block_number >= 0 AND block_number <= 9", 21)
+	require.NoError(t, err)
+	assert.Len(t, logs, 20)
+	_, err = s.QueryLogs(ctx, "block_number >= 0 AND block_number <= 19", 21)
+	assert.True(t, chain.IsTooManyResultsError(err))
+
+	_, err = s.QueryLogs(ctx, "block_number >= 0 OR block_number <= 19", 0)
+	assert.Error(t, err)
+}
+
+func Test_StorageQueryTxs(t *testing.T) {
+	ctx := context.Background()
+	s, hot := newTestStorage(t)
+
+	txs, err := s.QueryTxs(ctx, "block_number = 12")
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	for i, tx := range txs {
+		assert.Equal(t, uint64(12), tx.BlockNumber)
+		assert.Equal(t, blockHash(12).String(), tx.BlockHash)
+		assert.Equal(t, int64(1700000012), tx.BlockTimestamp.Unix())
+		assert.Equal(t, newTestSlot(12).Block.Transactions[i], tx.RPCTransaction)
+		require.NotNil(t, tx.ExtendedReceipt)
+		assert.Equal(t, hexutil.Uint(i), tx.ExtendedReceipt.TransactionIndex)
+		assert.Empty(t, tx.ExtendedReceipt.Logs)
+	}
+
+	// the blocks of the logs matched by the sub query
+	txs, err = s.QueryTxs(ctx, "block_number >= 0 AND block_number <= 25 AND block_number IN "+
+		"(SELECT block_number FROM logs WHERE topics[1] IN ('"+testTopics[0].Hex()+"') AND block_number >= 15)")
+	require.NoError(t, err)
+	var blocks []uint64
+	for _, tx := range txs {
+		blocks = append(blocks, tx.BlockNumber)
+	}
+	assert.Equal(t, []uint64{16, 16, 18, 18, 20}, blocks)
+	// the hot storage returns the blocks 20 and 30 for the sub query, only the block 20 is in the range
+	require.Len(t, hot.wheres, 3)
+	assert.Equal(t, "(block_number >= 0 AND block_number <= 25 AND block_number IN (SELECT block_number FROM logs WHERE "+
+		"topics[1] IN ('"+testTopics[0].Hex()+"') AND block_number >= 15)) AND block_number >= 20 AND block_number <= 20",
+		hot.wheres[2])
+
+	_, err = s.QueryTxs(ctx, "address IN ('0x1') AND block_number = 1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,263 @@
+package pq
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/common/utils"
+)
+
+const (
+	tableNameLogs         = "logs"
+	tableNameTransactions = "transactions"
+)
+
+var logsSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "block_number", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "block_hash", Type: arrow.BinaryTypes.String},
+	{Name: "block_timestamp", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "transaction_hash", Type: arrow.BinaryTypes.String},
+	{Name: "transaction_index", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "log_index", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "address", Type: arrow.BinaryTypes.String},
+	{Name: "topic0", Type: arrow.BinaryTypes.String},
+	{Name: "topic1", Type: arrow.BinaryTypes.String},
+	{Name: "topic2", Type: arrow.BinaryTypes.String},
+	{Name: "topic3", Type: arrow.BinaryTypes.String},
+	{Name: "topics_count", Type: arrow.PrimitiveTypes.Uint8},
+	{Name: "data", Type: arrow.BinaryTypes.Binary},
+	{Name: "removed", Type: arrow.FixedWidthTypes.Boolean},
+}, nil)
+
+var transactionsSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "block_number", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "block_hash", Type: arrow.BinaryTypes.String},
+	{Name: "block_timestamp", Type: arrow.PrimitiveTypes.Int64},
+	{Name: "transaction_index", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "hash", Type: arrow.BinaryTypes.String},
+	{Name: "transaction", Type: arrow.BinaryTypes.Binary},
+	{Name: "receipt", Type: arrow.BinaryTypes.Binary},
+}, nil)
+
+// logRow is a row of the logs table, the address and the topics are lower case hex strings
+type logRow struct {
+	BlockNumber      uint64
+	BlockHash        string
+	BlockTimestamp   uint64
+	TransactionHash  string
+	TransactionIndex uint64
+	LogIndex         uint64
+	Address          string
+	Topics           []string
+	Data             []byte
+	Removed          bool
+}
+
+// LogsTable exports the logs of the receipts. The rows are ordered by address, topic0 and block number, so the
+// row group statistics of the address and topic0 columns can skip most of the row groups of a usual log query.
+type LogsTable struct{}
+
+func (LogsTable) Name() string {
+	return tableNameLogs
+}
+
+func (LogsTable) Build(mem memory.Allocator, slots []*evm.Slot) (arrow.Record, error) {
+	var rows []logRow
+	for _, st := range slots {
+		for _, r := range st.Receipts {
+			for _, l := range r.Logs {
+				rows = append(rows, logRow{
+					BlockNumber:      st.GetNumber(),
+					BlockHash:        st.GetHash(),
+					BlockTimestamp:   st.Header.Time,
+					TransactionHash:  r.TxHash.String(),
+					TransactionIndex: uint64(r.TransactionIndex),
+					LogIndex:         uint64(l.Index),
+					Address:          strings.ToLower(l.Address.Hex()),
+					Topics:           lowerTopics(l.Topics),
+					Data:             l.Data,
+					Removed:          l.Removed,
+				})
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.topic(0) != b.topic(0) {
+			return a.topic(0) < b.topic(0)
+		}
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		return a.LogIndex < b.LogIndex
+	})
+	b := array.NewRecordBuilder(mem, logsSchema)
+	defer b.Release()
+	for _, row := range rows {
+		if len(row.Topics) > 4 {
+			return nil, errors.Errorf("log %d of block %d has %d topics", row.LogIndex, row.BlockNumber, len(row.Topics))
+		}
+		b.Field(0).(*array.Uint64Builder).Append(row.BlockNumber)
+		b.Field(1).(*array.StringBuilder).Append(row.BlockHash)
+		b.Field(2).(*array.Uint64Builder).Append(row.BlockTimestamp)
+		b.Field(3).(*array.StringBuilder).Append(row.TransactionHash)
+		b.Field(4).(*array.Uint64Builder).Append(row.TransactionIndex)
+		b.Field(5).(*array.Uint64Builder).Append(row.LogIndex)
+		b.Field(6).(*array.StringBuilder).Append(row.Address)
+		for i := 0; i < 4; i++ {
+			b.Field(7 + i).(*array.StringBuilder).Append(row.topic(i))
+		}
+		b.Field(11).(*array.Uint8Builder).Append(uint8(len(row.Topics)))
+		b.Field(12).(*array.BinaryBuilder).Append(row.Data)
+		b.Field(13).(*array.BooleanBuilder).Append(row.Removed)
+	}
+	return b.NewRecord(), nil
+}
+
+func (r logRow) topic(i int) string {
+	if i < len(r.Topics) {
+		return r.Topics[i]
+	}
+	return ""
+}
+
+// columnReader gets the columns of a record, the first error is kept and the later calls return nil columns
+type columnReader struct {
+	rec arrow.Record
+	err error
+}
+
+func column[A arrow.Array](r *columnReader, name string) (col A) {
+	if r.err == nil {
+		col, r.err = parquet.Column[A](r.rec, name)
+	}
+	return col
+}
+
+// readLogRows reads the rows of a record of the logs table, the strings of the row are only valid in fn
+func readLogRows(rec arrow.Record, fn func(row logRow) error) error {
+	r := &columnReader{rec: rec}
+	blockNumber := column[*array.Uint64](r, "block_number")
+	blockHash := column[*array.String](r, "block_hash")
+	blockTimestamp := column[*array.Uint64](r, "block_timestamp")
+	txHash := column[*array.String](r, "transaction_hash")
+	txIndex := column[*array.Uint64](r, "transaction_index")
+	logIndex := column[*array.Uint64](r, "log_index")
+	address := column[*array.String](r, "address")
+	topics := [4]*array.String{
+		column[*array.String](r, "topic0"),
+		column[*array.String](r, "topic1"),
+		column[*array.String](r, "topic2"),
+		column[*array.String](r, "topic3"),
+	}
+	topicsCount := column[*array.Uint8](r, "topics_count")
+	data := column[*array.Binary](r, "data")
+	removed := column[*array.Boolean](r, "removed")
+	if r.err != nil {
+		return r.err
+	}
+	for i := 0; i < int(rec.NumRows()); i++ {
+		row := logRow{
+			BlockNumber:      blockNumber.Value(i),
+			BlockHash:        blockHash.Value(i),
+			BlockTimestamp:   blockTimestamp.Value(i),
+			TransactionHash:  txHash.Value(i),
+			TransactionIndex: txIndex.Value(i),
+			LogIndex:         logIndex.Value(i),
+			Address:          address.Value(i),
+			Topics:           make([]string, topicsCount.Value(i)),
+			Data:             bytes.Clone(data.Value(i)),
+			Removed:          removed.Value(i),
+		}
+		for j := range row.Topics {
+			row.Topics[j] = topics[j].Value(i)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toLog converts the row to the log, same as the one returned by eth_getLogs
+func (r logRow) toLog() types.Log {
+	return types.Log{
+		Address:        common.HexToAddress(r.Address),
+		Topics:         utils.MapSliceNoError(r.Topics, common.HexToHash),
+		Data:           r.Data,
+		BlockNumber:    r.BlockNumber,
+		TxHash:         common.HexToHash(r.TransactionHash),
+		TxIndex:        uint(r.TransactionIndex),
+		BlockHash:      common.HexToHash(r.BlockHash),
+		BlockTimestamp: r.BlockTimestamp,
+		Index:          uint(r.LogIndex),
+		Removed:        r.Removed,
+	}
+}
+
+// TransactionsTable exports the transactions with the receipts, the rows are ordered by block number and
+// transaction index. The logs of the receipts are not included, they are in the logs table.
+type TransactionsTable struct{}
+
+func (TransactionsTable) Name() string {
+	return tableNameTransactions
+}
+
+func (TransactionsTable) Build(mem memory.Allocator, slots []*evm.Slot) (arrow.Record, error) {
+	b := array.NewRecordBuilder(mem, transactionsSchema)
+	defer b.Release()
+	for _, st := range slots {
+		receipts := make(map[uint64]evm.ExtendedReceipt)
+		for _, r := range st.Receipts {
+			r.Logs = nil
+			receipts[uint64(r.TransactionIndex)] = r
+		}
+		txs := make([]evm.RPCTransaction, len(st.Block.Transactions))
+		copy(txs, st.Block.Transactions)
+		sort.Slice(txs, func(i, j int) bool {
+			return txs[i].TransactionIndex < txs[j].TransactionIndex
+		})
+		for _, tx := range txs {
+			txData, err := json.Marshal(tx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "encode transaction %s failed", tx.Hash)
+			}
+			var receiptData []byte
+			if r, has := receipts[uint64(tx.TransactionIndex)]; has {
+				if receiptData, err = json.Marshal(r); err != nil {
+					return nil, errors.Wrapf(err, "encode receipt of transaction %s failed", tx.Hash)
+				}
+			}
+			b.Field(0).(*array.Uint64Builder).Append(st.GetNumber())
+			b.Field(1).(*array.StringBuilder).Append(st.GetHash())
+			b.Field(2).(*array.Int64Builder).Append(st.Header.GetBlockTime().UnixMicro())
+			b.Field(3).(*array.Uint64Builder).Append(uint64(tx.TransactionIndex))
+			b.Field(4).(*array.StringBuilder).Append(tx.Hash.String())
+			b.Field(5).(*array.BinaryBuilder).Append(txData)
+			b.Field(6).(*array.BinaryBuilder).Append(receiptData)
+		}
+	}
+	return b.NewRecord(), nil
+}
+
+func lowerTopics(topics []common.Hash) []string {
+	result := make([]string, len(topics))
+	for i, t := range topics {
+		result[i] = strings.ToLower(t.Hex())
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package pq
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/chain/evm/supernode"
+	"sentioxyz/sentio-core/chain/parquet"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+// ColdTierConfig is the part of the chain config of an EVM super node about the parquet cold tier.
+// The files are kept in the local directory Dir, or in the bucket S3Bucket of the S3 compatible store.
+type ColdTierConfig struct {
+	Dir string `json:"dir" yaml:"dir"`
+
+	S3Bucket string `json:"s3_bucket" yaml:"s3_bucket"`
+	S3Prefix string `json:"s3_prefix" yaml:"s3_prefix"`
+	// CacheDir keeps at most CacheSize bytes of the parquet files downloaded from the S3 compatible store,
+	// see parquet.NewS3ObjectStore
+	CacheDir  string `json:"cache_dir" yaml:"cache_dir"`
+	CacheSize int64  `json:"cache_size" yaml:"cache_size"`
+
+	// PartitionSize should never be changed once there are files, see parquet.NewStore
+	PartitionSize uint64 `json:"partition_size" yaml:"partition_size"`
+	RowGroupSize  int64  `json:"row_group_size" yaml:"row_group_size"`
+
+	// the blocks in [Start, Watermark) are read from the parquet files, see parquet.Config
+	Start     uint64 `json:"start" yaml:"start"`
+	Watermark uint64 `json:"watermark" yaml:"watermark"`
+}
+
+func (c ColdTierConfig) buildObjectStore(s3 parquet.S3Engine) (parquet.ObjectStore, error) {
+	switch {
+	case c.S3Bucket != "":
+		if s3 == nil {
+			return nil, errors.Errorf("s3 bucket %q is configured but no s3 engine", c.S3Bucket)
+		}
+		if c.CacheDir == "" {
+			return nil, errors.Errorf("cache_dir is required for s3 bucket %q", c.S3Bucket)
+		}
+		return parquet.NewS3ObjectStore(s3, c.S3Bucket, c.S3Prefix, c.CacheDir, c.CacheSize), nil
+	case c.Dir != "":
+		return parquet.NewLocalObjectStore(c.Dir), nil
+	default:
+		return nil, errors.Errorf("one of dir and s3_bucket is required")
+	}
+}
+
+// ColdTier serves the blocks below the watermark from the parquet files. A nil *ColdTier is the disabled tier,
+// the dimension and the storage are returned as is.
+type ColdTier struct {
+	store  *parquet.Store
+	config parquet.Config
+}
+
+// NewColdTier returns nil if the config is empty, s3 is only needed if S3Bucket is configured
+func NewColdTier(config ColdTierConfig, s3 parquet.S3Engine) (*ColdTier, error) {
+	if config == (ColdTierConfig{}) {
+		return nil, nil
+	}
+	objects, err := config.buildObjectStore(s3)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cold tier config")
+	}
+	return &ColdTier{
+		store:  parquet.NewStore(objects, config.PartitionSize, config.RowGroupSize),
+		config: parquet.Config{Start: config.Start, Watermark: config.Watermark},
+	}, nil
+}
+
+// Dimension adds the cold tier to the slot dimension of the super node
+func (t *ColdTier) Dimension(hot chain.Dimension[*evm.Slot]) chain.Dimension[*evm.Slot] {
+	if t == nil || t.config.ColdRange().IsEmpty() {
+		return hot
+	}
+	return NewDimension(hot, t.store, t.config)
+}
+
+// Storage adds the cold tier to the storage of the super node, see supernode.NewRPCService
+func (t *ColdTier) Storage(hot supernode.Storage) supernode.Storage {
+	if t == nil || t.config.ColdRange().IsEmpty() {
+		return hot
+	}
+	return NewStorage(hot, t.store, t.config)
+}
+
+// Export exports the finalized blocks in the interval from the hot dimension to the parquet files,
+// see parquet.Dimension.Export
+func (t *ColdTier) Export(
+	ctx context.Context,
+	hot chain.Dimension[*evm.Slot],
+	interval rg.Range,
+) ([]rg.Range, error) {
+	if t == nil {
+		return nil, errors.Errorf("cold tier is not configured")
+	}
+	return NewDimension(hot, t.store, t.config).Export(ctx, interval)
+}
@@ -0,0 +1,64 @@
+package pq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/evm"
+	chainpebble "sentioxyz/sentio-core/chain/pebble"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+func Test_ColdTier(t *testing.T) {
+	ctx := context.Background()
+
+	// disabled
+	tier, err := NewColdTier(ColdTierConfig{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, tier)
+	hot := &hotStorage{}
+	assert.Equal(t, hot, tier.Storage(hot))
+	_, err = NewColdTier(ColdTierConfig{S3Bucket: "bucket", CacheDir: t.TempDir()}, nil)
+	assert.Error(t, err)
+
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	defer db.Close()
+	dim := chainpebble.NewDimension[*evm.Slot](db, "test/", chainpebble.JSONCodec[*evm.Slot]{}, 10)
+	require.NoError(t, dim.Init(ctx))
+	slots := make(chan *evm.Slot, 25)
+	for bn := uint64(0); bn < 25; bn++ {
+		slots <- newTestSlot(bn)
+	}
+	close(slots)
+	require.NoError(t, dim.Save(ctx, rg.NewRange(0, 24), slots))
+
+	// the partition [20,29] is not complete and will not be exported
+	dir := t.TempDir()
+	tier, err = NewColdTier(ColdTierConfig{Dir: dir, PartitionSize: 10}, nil)
+	require.NoError(t, err)
+	exported, err := tier.Export(ctx, dim, rg.Range{})
+	require.NoError(t, err)
+	assert.Equal(t, []rg.Range{rg.NewRange(0, 9), rg.NewRange(10, 19)}, exported)
+	exported, err = tier.Export(ctx, dim, rg.Range{})
+	require.NoError(t, err)
+	assert.Empty(t, exported)
+	// no watermark, nothing is read from the parquet files
+	assert.Equal(t, hot, tier.Storage(hot))
+
+	tier, err = NewColdTier(ColdTierConfig{Dir: dir, PartitionSize: 10, Watermark: 20}, nil)
+	require.NoError(t, err)
+	logs, err := tier.Storage(hot).QueryLogs(ctx, "block_number = 3", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Log{expectedLog(3, 0), expectedLog(3, 1)}, logs)
+	assert.Empty(t, hot.wheres)
+	st, err := tier.Dimension(dim).LoadHeader(ctx, 12)
+	require.NoError(t, err)
+	assert.Equal(t, blockHash(12).String(), st.GetHash())
+}
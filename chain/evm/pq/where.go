@@ -0,0 +1,329 @@
+package pq
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/common/set"
+)
+
+// filter is the parsed where condition generated by the super node for the Storage, only the conjunction of
+// the conditions on block_number, address and topics is supported
+type filter struct {
+	// interval is the range of the block number
+	interval rg.Range
+	// blocks is the allowed block numbers, nil means no limit
+	blocks set.Set[uint64]
+	// blocksSubQueries are the where conditions of the logs, the block numbers of the matched logs are allowed
+	blocksSubQueries []string
+	// addresses is the allowed lower case addresses, nil means no limit
+	addresses []string
+	// topics is the allowed lower case topics of each position, nil means no limit
+	topics [4][]string
+}
+
+var (
+	blockCompareRegex = regexp.MustCompile(`^block_number\s*(=|>=|<=|>|<)\s*(\d+|\?)$`)
+	blockInRegex      = regexp.MustCompile(`(?is)^block_number\s+IN\s*\((.*)\)$`)
+	blockSubQueryRe   = regexp.MustCompile(`(?is)^SELECT\s+block_number\s+FROM\s+\S+\s+WHERE\s+(.+)$`)
+	fieldEqualRegex   = regexp.MustCompile(`^(address|topics\[(\d)])\s*=\s*('[^']*'|\?)$`)
+	fieldInRegex      = regexp.MustCompile(`(?is)^(address|topics\[(\d)])\s+IN\s*\((.*)\)$`)
+)
+
+// parseWhere parses the where condition, the placeholders are replaced by args in order
+func parseWhere(where string, args ...any) (f filter, err error) {
+	f.interval = rg.Range{Start: 0}
+	conditions, err := splitConjunction(where)
+	if err != nil {
+		return f, err
+	}
+	nextArg := func() (string, error) {
+		if len(args) == 0 {
+			return "", errors.Errorf("not enough args for %q", where)
+		}
+		arg := fmt.Sprint(args[0])
+		args = args[1:]
+		return arg, nil
+	}
+	value := func(v string) (string, error) {
+		if v == "?" {
+			return nextArg()
+		}
+		return strings.Trim(v, "'"), nil
+	}
+	for _, cond := range conditions {
+		if m := blockCompareRegex.FindStringSubmatch(cond); m != nil {
+			v, err := value(m[2])
+			if err != nil {
+				return f, err
+			}
+			bn, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return f, errors.Wrapf(err, "invalid block number in %q", cond)
+			}
+			f.interval = f.interval.Intersection(compareRange(m[1], bn))
+		} else if m = blockInRegex.FindStringSubmatch(cond); m != nil {
+			if sub := blockSubQueryRe.FindStringSubmatch(strings.TrimSpace(m[1])); sub != nil {
+				f.blocksSubQueries = append(f.blocksSubQueries, sub[1])
+				continue
+			}
+			blocks := set.New[uint64]()
+			for _, item := range strings.Split(m[1], ",") {
+				bn, err := strconv.ParseUint(strings.TrimSpace(item), 10, 64)
+				if err != nil {
+					return f, errors.Wrapf(err, "invalid block number in %q", cond)
+				}
+				blocks.Add(bn)
+			}
+			f.restrictBlocks(blocks)
+		} else if m = fieldEqualRegex.FindStringSubmatch(cond); m != nil {
+			v, err := value(m[3])
+			if err != nil {
+				return f, err
+			}
+			if err = f.restrictField(m[1], m[2], []string{v}); err != nil {
+				return f, err
+			}
+		} else if m = fieldInRegex.FindStringSubmatch(cond); m != nil {
+			var values []string
+			for _, item := range strings.Split(m[3], ",") {
+				if item = strings.TrimSpace(item); len(item) < 2 || item[0] != '\'' || item[len(item)-1] != '\'' {
+					return f, errors.Errorf("invalid value %q in %q", item, cond)
+				}
+				values = append(values, strings.Trim(item, "'"))
+			}
+			if err = f.restrictField(m[1], m[2], values); err != nil {
+				return f, err
+			}
+		} else {
+			return f, errors.Errorf("unsupported condition %q", cond)
+		}
+	}
+	if len(args) > 0 {
+		return f, errors.Errorf("too many args for %q", where)
+	}
+	return f, nil
+}
+
+func compareRange(op string, bn uint64) rg.Range {
+	switch op {
+	case "=":
+		return rg.NewSingleRange(bn)
+	case ">=":
+		return rg.Range{Start: bn}
+	case ">":
+		if bn == math.MaxUint64 {
+			return rg.EmptyRange
+		}
+		return rg.Range{Start: bn + 1}
+	case "<=":
+		return rg.NewRange(0, bn)
+	default: // "<"
+		if bn == 0 {
+			return rg.EmptyRange
+		}
+		return rg.NewRange(0, bn-1)
+	}
+}
+
+func (f *filter) restrictBlocks(blocks set.Set[uint64]) {
+	if f.blocks == nil {
+		f.blocks = blocks
+		return
+	}
+	both := set.New[uint64]()
+	f.blocks.Traverse(func(bn uint64) {
+		if blocks.Contains(bn) {
+			both.Add(bn)
+		}
+	})
+	f.blocks = both
+}
+
+func (f *filter) restrictField(field, topicIndex string, values []string) error {
+	values = intersect(nil, lower(values))
+	if field == "address" {
+		f.addresses = intersect(f.addresses, values)
+		return nil
+	}
+	// topics in the where condition are 1-based like the Array of clickhouse
+	i, _ := strconv.Atoi(topicIndex)
+	if i < 1 || i > len(f.topics) {
+		return errors.Errorf("invalid topic index %d", i)
+	}
+	f.topics[i-1] = intersect(f.topics[i-1], values)
+	return nil
+}
+
+// blocksRange returns the range of the allowed block numbers
+func (f *filter) blocksRange() rg.Range {
+	if f.blocks == nil {
+		return f.interval
+	}
+	var lo, hi uint64 = math.MaxUint64, 0
+	f.blocks.Traverse(func(bn uint64) {
+		if f.interval.Contains(bn) {
+			lo, hi = min(lo, bn), max(hi, bn)
+		}
+	})
+	if lo > hi {
+		return rg.EmptyRange
+	}
+	return rg.NewRange(lo, hi)
+}
+
+// matchBlock checks the block number, the sub queries should be resolved into blocks before
+func (f *filter) matchBlock(bn uint64) bool {
+	return f.interval.Contains(bn) && (f.blocks == nil || f.blocks.Contains(bn))
+}
+
+func (f *filter) matchLog(row logRow) bool {
+	if !f.matchBlock(row.BlockNumber) {
+		return false
+	}
+	if f.addresses != nil && !contains(f.addresses, row.Address) {
+		return false
+	}
+	for i, values := range f.topics {
+		if values != nil && (i >= len(row.Topics) || !contains(values, row.Topics[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasLogConditions returns whether there are the conditions only for the logs
+func (f *filter) hasLogConditions() bool {
+	if f.addresses != nil {
+		return true
+	}
+	for _, values := range f.topics {
+		if values != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// splitConjunction splits the where condition by the top level AND, the parentheses around the whole condition
+// or the sub conditions are removed
+func splitConjunction(where string) (conditions []string, err error) {
+	where = strings.TrimSpace(where)
+	for {
+		inner, wrapped := unwrap(where)
+		if !wrapped {
+			break
+		}
+		where = inner
+	}
+	depth, quoted, last := 0, false, 0
+	for i := 0; i < len(where); i++ {
+		switch c := where[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth < 0 {
+				return nil, errors.Errorf("unbalanced parentheses in %q", where)
+			}
+		case depth == 0 && isSpace(c) && hasKeyword(where[i:], "AND"):
+			conditions = append(conditions, where[last:i])
+			for isSpace(where[i]) {
+				i++
+			}
+			i += len("AND") - 1
+			last = i + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, errors.Errorf("unbalanced parentheses or quotes in %q", where)
+	}
+	conditions = append(conditions, where[last:])
+	var result []string
+	for _, cond := range conditions {
+		cond = strings.TrimSpace(cond)
+		if cond == "" {
+			return nil, errors.Errorf("empty condition in %q", where)
+		}
+		if _, wrapped := unwrap(cond); wrapped {
+			// (a AND b) in a conjunction
+			sub, err := splitConjunction(cond)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, sub...)
+		} else {
+			result = append(result, cond)
+		}
+	}
+	return result, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// hasKeyword checks whether s is like " AND ..."
+func hasKeyword(s, keyword string) bool {
+	s = strings.TrimLeft(s, " \t\n")
+	return len(s) > len(keyword) && strings.EqualFold(s[:len(keyword)], keyword) &&
+		(isSpace(s[len(keyword)]) || s[len(keyword)] == '(')
+}
+
+// unwrap removes the parentheses around s if they are a pair
+func unwrap(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return s, false
+	}
+	depth, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 && i < len(s)-1 {
+				return s, false
+			}
+		}
+	}
+	return strings.TrimSpace(s[1 : len(s)-1]), true
+}
+
+func lower(values []string) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strings.ToLower(v)
+	}
+	return result
+}
+
+// intersect returns the distinct values in both a and b, nil a means no limit
+func intersect(a, b []string) []string {
+	result := make([]string, 0, len(b))
+	for _, v := range b {
+		if (a == nil || contains(a, v)) && !contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
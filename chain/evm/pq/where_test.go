@@ -0,0 +1,67 @@
+package pq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rg "sentioxyz/sentio-core/common/range"
+	"sentioxyz/sentio-core/common/set"
+)
+
+func Test_parseWhere(t *testing.T) {
+	f, err := parseWhere("address IN ('0xdAC17F958D2ee523a2206206994597C13D831ec7','0xdac17f958d2ee523a2206206994597c13d831ec7') " +
+		"AND topics[1] IN ('0xAB','0xcd') AND topics[3] IN ('0x01') AND block_number >= 10 AND block_number <= 20")
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(10, 20), f.interval)
+	assert.Equal(t, []string{"0xdac17f958d2ee523a2206206994597c13d831ec7"}, f.addresses)
+	assert.Equal(t, [4][]string{{"0xab", "0xcd"}, nil, {"0x01"}, nil}, f.topics)
+	assert.True(t, f.hasLogConditions())
+
+	f, err = parseWhere("(block_number = 15) AND topics[1] = ? AND block_number > 3", "0xAB")
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewSingleRange(15), f.interval)
+	assert.Equal(t, []string{"0xab"}, f.topics[0])
+
+	f, err = parseWhere("(block_number >= 10 AND block_number <= 20) AND block_number IN (12,18,25)")
+	require.NoError(t, err)
+	assert.Equal(t, set.New[uint64](12, 18, 25), f.blocks)
+	assert.Equal(t, rg.NewRange(12, 18), f.blocksRange())
+	assert.True(t, f.matchBlock(12))
+	assert.False(t, f.matchBlock(13))
+	assert.False(t, f.matchBlock(25))
+	assert.False(t, f.hasLogConditions())
+
+	f, err = parseWhere("block_number >= 10 AND block_number <= 20 AND block_number IN " +
+		"(SELECT block_number FROM `db`.`logs` WHERE address IN ('0xa') AND block_number >= 10 AND block_number <= 20)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"address IN ('0xa') AND block_number >= 10 AND block_number <= 20"}, f.blocksSubQueries)
+	assert.Nil(t, f.blocks)
+
+	// the values of different conditions on the same field are intersected
+	f, err = parseWhere("topics[2] IN ('0x1','0x2') AND topics[2] = '0x2' AND block_number < 1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x2"}, f.topics[1])
+	assert.Equal(t, rg.NewSingleRange(0), f.interval)
+
+	for _, where := range []string{
+		"block_number >= 10 OR block_number <= 20",
+		"transaction_hash = '0x1'",
+		"topics[5] = '0x1'",
+		"topics[1] = ?",
+		"block_number IN (1,a)",
+		"(block_number = 1",
+	} {
+		_, err = parseWhere(where)
+		assert.Error(t, err, where)
+	}
+	_, err = parseWhere("block_number = 1", 1)
+	assert.Error(t, err)
+}
+
+func Test_splitConjunction(t *testing.T) {
+	conditions, err := splitConjunction("((a = 1)  AND\tb IN ('x AND y') and (c = 2 AND (d = 3)))")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a = 1", "b IN ('x AND y')", "c = 2", "d = 3"}, conditions)
+}
@@ -171,3 +171,25 @@ func stripJSONFields(raw json.RawMessage, fields ...string) (json.RawMessage, er
 	}
 	return json.Marshal(m)
 }
+
+// BalanceChange is the net change of the balance of an address for a token in one transaction,
+// the zero address as the token stands for the native token.
+type BalanceChange struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockTimestamp   hexutil.Uint64 `json:"blockTimestamp"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	Address          common.Address `json:"address"`
+	Token            common.Address `json:"token"`
+	Amount           *hexutil.Big   `json:"amount"`
+	Balance          *hexutil.Big   `json:"balance"`
+}
+
+// BalanceAt is the balance of an address for a token at a block, LastChange is the last change at or
+// before the block, nil means the balance has never been changed.
+type BalanceAt struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Balance     *hexutil.Big   `json:"balance"`
+	LastChange  *BalanceChange `json:"lastChange"`
+}
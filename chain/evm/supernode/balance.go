@@ -0,0 +1,162 @@
+package supernode
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/evm"
+	"sentioxyz/sentio-core/common/jsonrpc"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+// BalanceStorage is the balance history index, ch.BalanceStore is the implementation
+type BalanceStorage interface {
+	// QueryBalanceHistory applies limit like Storage.QueryLogs, token nil means all tokens.
+	QueryBalanceHistory(
+		ctx context.Context,
+		address common.Address,
+		token *common.Address,
+		from, to uint64,
+		limit int,
+	) ([]evm.BalanceChange, error)
+	// QueryBalanceAt returns the last balance change at or before the block, nil means never changed.
+	QueryBalanceAt(
+		ctx context.Context,
+		address common.Address,
+		token common.Address,
+		blockNumber uint64,
+	) (*evm.BalanceChange, error)
+}
+
+func NewBalanceMiddleware(rangeStore chain.RangeStore, store BalanceStorage) jsonrpc.Middleware {
+	s := BalanceService{
+		rangeStore: rangeStore,
+		store:      store,
+	}
+	return func(next jsonrpc.MethodHandler) jsonrpc.MethodHandler {
+		return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+			switch method {
+			case "sentio_getBalanceHistory":
+				return jsonrpc.CallMethod(s.GetBalanceHistory, ctx, params)
+			case "sentio_getBalanceAt":
+				return jsonrpc.CallMethod(s.GetBalanceAt, ctx, params)
+			default:
+				return next(ctx, method, params)
+			}
+		}
+	}
+}
+
+// maxBalanceChanges caps how many balance changes a sentio_getBalanceHistory query may return,
+// an over-cap query fails with chain.NewTooManyResultsError like eth_getLogs.
+const maxBalanceChanges = 4000
+
+// BalanceService serves the balance history index. The index only lives in the store, the blocks
+// only in the latest slot cache are out of its scope.
+type BalanceService struct {
+	rangeStore chain.RangeStore
+	store      BalanceStorage
+}
+
+// resolveBlockNumber maps the block tag to a block number in the current range of the store,
+// latest is the end of it and earliest is the start of it.
+func resolveBlockNumber(bn rpc.BlockNumber, cur rg.Range) (uint64, error) {
+	var r uint64
+	switch bn {
+	case rpc.LatestBlockNumber:
+		if cur.End == nil {
+			return 0, errors.Errorf("range store %s has no end", cur)
+		}
+		r = *cur.End
+	case rpc.EarliestBlockNumber:
+		r = cur.Start
+	default:
+		if bn < 0 {
+			return 0, errors.Errorf("block number cannot be %s", bn.String())
+		}
+		r = uint64(bn)
+	}
+	if !cur.Contains(r) {
+		return 0, errors.Errorf("block %d not in scope of range store %s", r, cur)
+	}
+	return r, nil
+}
+
+// GetBalanceHistory returns the balance changes of the address in [fromBlock, toBlock] ordered by
+// (blockNumber, transactionIndex, token), token null means all tokens, the zero address means the native token.
+// limit 0 means the max limit.
+func (s *BalanceService) GetBalanceHistory(
+	ctx context.Context,
+	address common.Address,
+	token *common.Address,
+	fromBlock rpc.BlockNumber,
+	toBlock rpc.BlockNumber,
+	limit hexutil.Uint64,
+) ([]evm.BalanceChange, error) {
+	cur, err := s.rangeStore.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := resolveBlockNumber(fromBlock, cur)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid fromBlock")
+	}
+	to, err := resolveBlockNumber(toBlock, cur)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid toBlock")
+	}
+	if from > to {
+		return nil, errors.Errorf("fromBlock %d is greater than toBlock %d", from, to)
+	}
+	n := maxBalanceChanges
+	if limit > 0 && limit < maxBalanceChanges {
+		n = int(limit)
+	}
+	result, err := s.store.QueryBalanceHistory(ctx, address, token, from, to, chain.StoreQueryLimit(n))
+	if err == nil && result == nil {
+		result = []evm.BalanceChange{}
+	}
+	return chain.CheckTooManyResults(result, err, n)
+}
+
+// GetBalanceAt returns the balance of the address for the token at the block, token null means the native token,
+// the native balance is the sum of the value transfer deltas in the traces, see ch.BalanceStore.
+func (s *BalanceService) GetBalanceAt(
+	ctx context.Context,
+	address common.Address,
+	token *common.Address,
+	block rpc.BlockNumber,
+) (*evm.BalanceAt, error) {
+	cur, err := s.rangeStore.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bn, err := resolveBlockNumber(block, cur)
+	if err != nil {
+		return nil, err
+	}
+	var tk common.Address // the native token
+	if token != nil {
+		tk = *token
+	}
+	last, err := s.store.QueryBalanceAt(ctx, address, tk, bn)
+	if err != nil {
+		return nil, err
+	}
+	result := &evm.BalanceAt{
+		BlockNumber: hexutil.Uint64(bn),
+		Balance:     (*hexutil.Big)(new(big.Int)),
+		LastChange:  last,
+	}
+	if last != nil {
+		result.Balance = last.Balance
+	}
+	return result, nil
+}
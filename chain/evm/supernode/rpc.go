@@ -33,6 +33,7 @@ func NewRPCService(
 	rangeStore chain.RangeStore,
 	client *evm.ClientPool,
 	forcedProxyMethods []string,
+	balanceStore BalanceStorage,
 ) []jsonrpc.Middleware {
 
 	chainIDNum, err := strconv.ParseUint(chainID, 0, 64)
@@ -40,13 +41,19 @@ func NewRPCService(
 		panic(errors.Errorf("chainID %q is not a number", chainID))
 	}
 
-	return []jsonrpc.Middleware{
+	middlewares := []jsonrpc.Middleware{
 		NewForcedProxyMiddleware(client, forcedProxyMethods),
 		NewClientVersionMiddleware(),
 		NewCustomFunctionProxyMiddleware(client),
 		NewExtraMiddleware(slotCache, rangeStore, store),
+	}
+	if balanceStore != nil {
+		// balance history index is optional, see ch.NewClickhouseSchemaMgr
+		middlewares = append(middlewares, NewBalanceMiddleware(rangeStore, balanceStore))
+	}
+	return append(middlewares,
 		NewSubscribeMiddleware(slotCache),
 		NewStandardMiddleware(chainIDNum, slotCache, rangeStore, store),
 		NewProxyMiddleware(client),
-	}
+	)
 }
@@ -0,0 +1,394 @@
+package parquet
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/common/log"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+// Table is a table the slots are exported to besides the slot table, usually used by the queries like the logs
+type Table[SLOT chain.Slot] interface {
+	Name() string
+	// Build converts the slots of a partition to a record. The rows can be ordered by the columns usually
+	// filtered, so the row group statistics can skip the row groups not matched.
+	Build(mem memory.Allocator, slots []SLOT) (arrow.Record, error)
+}
+
+// SlotTableName is the name of the table with a row for each slot, a partition is complete if it has the slot table
+const SlotTableName = "slots"
+
+var slotSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "number", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "hash", Type: arrow.BinaryTypes.String},
+	{Name: "parent_hash", Type: arrow.BinaryTypes.String},
+	{Name: "data", Type: arrow.BinaryTypes.Binary},
+}, nil)
+
+// Config is the range of the cold tier of a Dimension
+type Config struct {
+	// Start is the first slot of the cold tier
+	Start uint64
+	// Watermark is the end (exclusive) of the cold tier, the slots in [Start, Watermark) are read from the
+	// parquet files. The cold tier is disabled if Watermark is not greater than Start.
+	Watermark uint64
+}
+
+func (c Config) ColdRange() rg.Range {
+	if c.Watermark <= c.Start {
+		return rg.EmptyRange
+	}
+	return rg.NewRange(c.Start, c.Watermark-1)
+}
+
+// Dimension adds a cold tier of parquet files to the hot dimension. The slots below the watermark are read from
+// the parquet files and the others from the hot dimension, a partition not exported yet falls back to the hot
+// dimension. Writes always go to the hot dimension, the exported partitions are immutable.
+type Dimension[SLOT chain.Slot] struct {
+	hot    chain.Dimension[SLOT]
+	store  *Store
+	codec  Codec[SLOT]
+	tables []Table[SLOT]
+	config Config
+
+	// exportedUntil is the end (exclusive) of the contiguous exported partitions from the start of the cold range,
+	// the exported partitions are immutable so it only grows
+	exportedUntil   uint64
+	exportedUntilMu sync.Mutex
+}
+
+func NewDimension[SLOT chain.Slot](
+	hot chain.Dimension[SLOT],
+	store *Store,
+	codec Codec[SLOT],
+	config Config,
+	tables ...Table[SLOT],
+) *Dimension[SLOT] {
+	return &Dimension[SLOT]{
+		hot:           hot,
+		store:         store,
+		codec:         codec,
+		tables:        tables,
+		config:        config,
+		exportedUntil: config.Start,
+	}
+}
+
+type segment struct {
+	interval rg.Range
+	cold     bool
+}
+
+// split splits the interval into the segments in order, the one in the cold range is marked
+func (d *Dimension[SLOT]) split(interval rg.Range) (segments []segment) {
+	cold := d.config.ColdRange()
+	if cold.IsEmpty() {
+		return []segment{{interval: interval}}
+	}
+	if cold.Start > 0 {
+		if left := interval.Intersection(rg.NewRange(0, cold.Start-1)); !left.IsEmpty() {
+			segments = append(segments, segment{interval: left})
+		}
+	}
+	if middle := interval.Intersection(cold); !middle.IsEmpty() {
+		segments = append(segments, segment{interval: middle, cold: true})
+	}
+	if right := interval.Intersection(rg.Range{Start: d.config.Watermark}); !right.IsEmpty() {
+		segments = append(segments, segment{interval: right})
+	}
+	return segments
+}
+
+func (d *Dimension[SLOT]) Init(ctx context.Context) error {
+	return d.hot.Init(ctx)
+}
+
+func (d *Dimension[SLOT]) GetRange(ctx context.Context) (rg.Range, error) {
+	hot, err := d.hot.GetRange(ctx)
+	if err != nil {
+		return hot, err
+	}
+	cold, err := d.exportedRange(ctx)
+	if err != nil {
+		return rg.EmptyRange, err
+	}
+	if cold.IsEmpty() {
+		return hot, nil
+	}
+	if !hot.IsEmpty() && cold.GetDistance(hot) > 0 {
+		// the slots between the two tiers are missing, only the latest part is available
+		return hot, nil
+	}
+	return cold.Cover(hot), nil
+}
+
+// exportedRange returns the part of the cold range in the contiguous exported partitions from the start, the
+// partitions not exported yet are only available if they are in the hot tier
+func (d *Dimension[SLOT]) exportedRange(ctx context.Context) (rg.Range, error) {
+	cold := d.config.ColdRange()
+	if cold.IsEmpty() {
+		return cold, nil
+	}
+	d.exportedUntilMu.Lock()
+	defer d.exportedUntilMu.Unlock()
+	for d.exportedUntil < d.config.Watermark {
+		partition := d.store.Partition(d.exportedUntil)
+		has, err := d.store.Has(ctx, SlotTableName, partition)
+		if err != nil {
+			return rg.EmptyRange, errors.Wrapf(err, "check partition %s failed", partition)
+		}
+		if !has {
+			break
+		}
+		d.exportedUntil = *partition.End + 1
+	}
+	if d.exportedUntil <= cold.Start {
+		return rg.EmptyRange, nil
+	}
+	return cold.Intersection(rg.NewRange(cold.Start, d.exportedUntil-1)), nil
+}
+
+// readPartition calls fn with the slots in the interval from the partition contains it, exported is false
+// if the partition is not exported yet
+func (d *Dimension[SLOT]) readPartition(
+	ctx context.Context,
+	interval rg.Range,
+	fn func(st SLOT) error,
+) (exported bool, err error) {
+	partition := d.store.Partition(interval.Start)
+	f, err := d.store.Open(ctx, SlotTableName, partition)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	rowGroups := make([]int, 0, f.NumRowGroups())
+	for i := 0; i < f.NumRowGroups(); i++ {
+		if f.MayContainUint(i, "number", interval) {
+			rowGroups = append(rowGroups, i)
+		}
+	}
+	next := interval.Start
+	err = f.Read(ctx, rowGroups, func(rec arrow.Record) error {
+		numbers, err := Column[*array.Uint64](rec, "number")
+		if err != nil {
+			return err
+		}
+		data, err := Column[*array.Binary](rec, "data")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < int(rec.NumRows()); i++ {
+			sn := numbers.Value(i)
+			if !interval.Contains(sn) {
+				continue
+			}
+			if sn != next {
+				return errors.Errorf("partition %s is broken, slot %d is missing", partition, next)
+			}
+			st, err := d.codec.Decode(data.Value(i))
+			if err != nil {
+				return errors.Wrapf(err, "decode slot %d failed", sn)
+			}
+			if err = fn(st); err != nil {
+				return err
+			}
+			next++
+		}
+		return nil
+	})
+	if err != nil {
+		return true, err
+	}
+	if next <= *interval.End {
+		return true, errors.Errorf("partition %s is broken, slot %d is missing", partition, next)
+	}
+	return true, nil
+}
+
+func (d *Dimension[SLOT]) Load(ctx context.Context, interval rg.Range, slotChan chan<- SLOT) error {
+	_, logger := log.FromContext(ctx, "interval", interval)
+	send := func(st SLOT) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case slotChan <- st:
+			return nil
+		}
+	}
+	for _, seg := range d.split(interval) {
+		if !seg.cold {
+			if err := d.hot.Load(ctx, seg.interval, slotChan); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, partition := range d.store.Partitions(seg.interval) {
+			part := partition.Intersection(seg.interval)
+			exported, err := d.readPartition(ctx, part, send)
+			if err != nil {
+				logger.Warnfe(err, "load %s from parquet failed", part)
+				return err
+			}
+			if !exported {
+				logger.Warnf("partition %s is not exported, load %s from the hot tier", partition, part)
+				if err = d.hot.Load(ctx, part, slotChan); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dimension[SLOT]) LoadHeader(ctx context.Context, sn uint64) (chain.Slot, error) {
+	if !d.config.ColdRange().Contains(sn) {
+		return d.hot.LoadHeader(ctx, sn)
+	}
+	var header chain.Slot
+	exported, err := d.readPartition(ctx, rg.NewSingleRange(sn), func(st SLOT) error {
+		header = st
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !exported {
+		return d.hot.LoadHeader(ctx, sn)
+	}
+	return header, nil
+}
+
+func (d *Dimension[SLOT]) CheckMissing(ctx context.Context, interval rg.Range, missing chan<- rg.Range) error {
+	// the exported partitions are complete, only check the hot tier
+	for _, seg := range d.split(interval) {
+		if seg.cold {
+			continue
+		}
+		if err := d.hot.CheckMissing(ctx, seg.interval, missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dimension[SLOT]) Save(ctx context.Context, interval rg.Range, slotChan <-chan SLOT) error {
+	return d.hot.Save(ctx, interval, slotChan)
+}
+
+// Delete deletes the slots in the hot tier, the exported partitions are not changed
+func (d *Dimension[SLOT]) Delete(ctx context.Context, interval rg.Range) error {
+	return d.hot.Delete(ctx, interval)
+}
+
+// Export exports the partitions fully inside the interval from the hot tier to the parquet files, the exported
+// partitions are skipped. The slots should be final, a reorg of the exported slots is not seen by the cold tier.
+// Returns the partitions exported by this call.
+func (d *Dimension[SLOT]) Export(ctx context.Context, interval rg.Range) (exported []rg.Range, err error) {
+	ctx, logger := log.FromContext(ctx, "interval", interval)
+	hot, err := d.hot.GetRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target := interval.Intersection(hot)
+	if target.End == nil {
+		return nil, errors.Errorf("cannot export an open range %s", target)
+	}
+	for _, partition := range d.store.Partitions(target) {
+		if !target.Include(partition) {
+			logger.Infof("partition %s is not fully inside %s, skipped", partition, target)
+			continue
+		}
+		has, err := d.store.Has(ctx, SlotTableName, partition)
+		if err != nil {
+			return exported, err
+		}
+		if has {
+			continue
+		}
+		if err = d.exportPartition(ctx, partition); err != nil {
+			logger.Warnfe(err, "export partition %s failed", partition)
+			return exported, err
+		}
+		logger.Infof("partition %s exported", partition)
+		exported = append(exported, partition)
+	}
+	return exported, nil
+}
+
+func (d *Dimension[SLOT]) exportPartition(ctx context.Context, partition rg.Range) error {
+	slots, err := chain.Load[SLOT](d.hot, ctx, partition)
+	if err != nil {
+		return errors.Wrapf(err, "load slots failed")
+	}
+	if uint64(len(slots)) != *partition.Size() {
+		return errors.Errorf("only got %d slots", len(slots))
+	}
+	mem := d.store.Allocator()
+	// the slot table is written at last, so a partition with the slot table is complete
+	for _, table := range d.tables {
+		rec, err := table.Build(mem, slots)
+		if err != nil {
+			return errors.Wrapf(err, "build table %s failed", table.Name())
+		}
+		err = d.store.Write(ctx, table.Name(), partition, rec)
+		rec.Release()
+		if err != nil {
+			return err
+		}
+	}
+	rec, err := d.buildSlotTable(mem, slots)
+	if err != nil {
+		return errors.Wrapf(err, "build table %s failed", SlotTableName)
+	}
+	defer rec.Release()
+	return d.store.Write(ctx, SlotTableName, partition, rec)
+}
+
+func (d *Dimension[SLOT]) buildSlotTable(mem memory.Allocator, slots []SLOT) (arrow.Record, error) {
+	b := array.NewRecordBuilder(mem, slotSchema)
+	defer b.Release()
+	for _, st := range slots {
+		data, err := d.codec.Encode(st)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encode slot %d failed", st.GetNumber())
+		}
+		b.Field(0).(*array.Uint64Builder).Append(st.GetNumber())
+		b.Field(1).(*array.StringBuilder).Append(st.GetHash())
+		b.Field(2).(*array.StringBuilder).Append(st.GetParentHash())
+		b.Field(3).(*array.BinaryBuilder).Append(data)
+	}
+	return b.NewRecord(), nil
+}
+
+func (d *Dimension[SLOT]) Snapshot() any {
+	return map[string]any{
+		"coldRange":     d.config.ColdRange().String(),
+		"partitionSize": d.store.partitionSize,
+		"rowGroupSize":  d.store.rowGroupSize,
+	}
+}
+
+// Column returns the column of the record with the name
+func Column[A arrow.Array](rec arrow.Record, name string) (A, error) {
+	var col A
+	indices := rec.Schema().FieldIndices(name)
+	if len(indices) == 0 {
+		return col, errors.Errorf("column %s not found", name)
+	}
+	col, is := rec.Column(indices[0]).(A)
+	if !is {
+		return col, errors.Errorf("column %s is %s", name, rec.Column(indices[0]).DataType())
+	}
+	return col, nil
+}
@@ -0,0 +1,178 @@
+package parquet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/chain/chaintest"
+	chainpebble "sentioxyz/sentio-core/chain/pebble"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+func newHot(t *testing.T) chain.Dimension[*chaintest.Slot] {
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return chainpebble.NewDimension[*chaintest.Slot](db, "test/", chainpebble.JSONCodec[*chaintest.Slot]{}, 7)
+}
+
+func newTestStore(t *testing.T) *Store {
+	return NewStore(NewLocalObjectStore(t.TempDir()), 10, 3)
+}
+
+func sendSlots(slots []*chaintest.Slot) <-chan *chaintest.Slot {
+	ch := make(chan *chaintest.Slot, len(slots))
+	for _, st := range slots {
+		ch <- st
+	}
+	close(ch)
+	return ch
+}
+
+func loadSlots(t *testing.T, d chain.Dimension[*chaintest.Slot], interval rg.Range) []*chaintest.Slot {
+	slots, err := chain.Load[*chaintest.Slot](d, context.Background(), interval)
+	require.NoError(t, err)
+	return slots
+}
+
+func Test_Dimension(t *testing.T) {
+	// without the cold range the dimension behaves like the hot one
+	chaintest.RunDimension(t, func(t *testing.T) chain.Dimension[*chaintest.Slot] {
+		return NewDimension[*chaintest.Slot](newHot(t), newTestStore(t), JSONCodec[*chaintest.Slot]{}, Config{})
+	}, chaintest.Capabilities{Load: true})
+}
+
+func Test_DimensionExport(t *testing.T) {
+	ctx := context.Background()
+	hot := newHot(t)
+	store := newTestStore(t)
+	codec := JSONCodec[*chaintest.Slot]{}
+	require.NoError(t, hot.Init(ctx))
+	require.NoError(t, hot.Save(ctx, rg.NewRange(0, 34), sendSlots(chaintest.NewSlots(rg.NewRange(0, 34), "", 0))))
+
+	d := NewDimension[*chaintest.Slot](hot, store, codec, Config{})
+	// the partition [30,39] is not complete
+	exported, err := d.Export(ctx, rg.NewRange(0, 34))
+	require.NoError(t, err)
+	assert.Equal(t, []rg.Range{rg.NewRange(0, 9), rg.NewRange(10, 19), rg.NewRange(20, 29)}, exported)
+	exported, err = d.Export(ctx, rg.NewRange(0, 34))
+	require.NoError(t, err)
+	assert.Empty(t, exported)
+	_, err = d.Export(ctx, rg.Range{Start: 0})
+	require.NoError(t, err)
+
+	// the exported slots are not needed by the hot tier anymore
+	require.NoError(t, hot.Delete(ctx, rg.NewRange(0, 19)))
+	d = NewDimension[*chaintest.Slot](hot, store, codec, Config{Start: 0, Watermark: 30})
+	require.NoError(t, d.Init(ctx))
+	r, err := d.GetRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(0, 34), r)
+
+	assert.Equal(t, chaintest.NewSlots(rg.NewRange(5, 33), "", 0), loadSlots(t, d, rg.NewRange(5, 33)))
+	h, err := d.LoadHeader(ctx, 15)
+	require.NoError(t, err)
+	assert.Equal(t, "main-15", h.GetHash())
+	h, err = d.LoadHeader(ctx, 31)
+	require.NoError(t, err)
+	assert.Equal(t, "main-31", h.GetHash())
+
+	missing := make(chan rg.Range, 10)
+	require.NoError(t, d.CheckMissing(ctx, rg.NewRange(0, 34), missing))
+	close(missing)
+	assert.Empty(t, missing)
+
+	// new slots are saved into the hot tier
+	require.NoError(t, d.Save(ctx, rg.NewRange(35, 39), sendSlots(chaintest.NewSlots(rg.NewRange(35, 39), "", 0))))
+	r, err = d.GetRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(0, 39), r)
+}
+
+func Test_DimensionFallback(t *testing.T) {
+	ctx := context.Background()
+	hot := newHot(t)
+	store := newTestStore(t)
+	codec := JSONCodec[*chaintest.Slot]{}
+	require.NoError(t, hot.Init(ctx))
+	require.NoError(t, hot.Save(ctx, rg.NewRange(0, 24), sendSlots(chaintest.NewSlots(rg.NewRange(0, 24), "", 0))))
+	_, err := NewDimension[*chaintest.Slot](hot, store, codec, Config{}).Export(ctx, rg.NewRange(0, 9))
+	require.NoError(t, err)
+
+	// the partition [10,19] is in the cold range but not exported yet
+	d := NewDimension[*chaintest.Slot](hot, store, codec, Config{Start: 0, Watermark: 20})
+	assert.Equal(t, chaintest.NewSlots(rg.NewRange(8, 22), "", 0), loadSlots(t, d, rg.NewRange(8, 22)))
+	h, err := d.LoadHeader(ctx, 12)
+	require.NoError(t, err)
+	assert.Equal(t, "main-12", h.GetHash())
+
+	// the partition [10,19] not exported is available from the hot tier
+	r, err := d.GetRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(0, 24), r)
+
+	// the hot tier is not continuous with the cold range
+	require.NoError(t, hot.Delete(ctx, rg.NewRange(0, 21)))
+	d = NewDimension[*chaintest.Slot](hot, store, codec, Config{Start: 0, Watermark: 10})
+	r, err = d.GetRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(22, 24), r)
+
+	// the hot tier is continuous with the cold range, but the partition [10,19] is not exported
+	d = NewDimension[*chaintest.Slot](hot, store, codec, Config{Start: 0, Watermark: 30})
+	r, err = d.GetRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(22, 24), r)
+}
+
+func Test_StoreStatistics(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	d := NewDimension[*chaintest.Slot](nil, store, JSONCodec[*chaintest.Slot]{}, Config{})
+	rec, err := d.buildSlotTable(store.Allocator(), chaintest.NewSlots(rg.NewRange(10, 19), "", 0))
+	require.NoError(t, err)
+	partition := store.Partition(15)
+	require.NoError(t, store.Write(ctx, SlotTableName, partition, rec))
+	rec.Release()
+
+	has, err := store.Has(ctx, SlotTableName, partition)
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = store.Has(ctx, SlotTableName, store.Partition(25))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	f, err := store.Open(ctx, SlotTableName, partition)
+	require.NoError(t, err)
+	defer f.Close()
+	// 10 rows in the row groups of 3 rows
+	require.Equal(t, 4, f.NumRowGroups())
+	var matched []int
+	for i := 0; i < f.NumRowGroups(); i++ {
+		if f.MayContainUint(i, "number", rg.NewRange(14, 16)) {
+			matched = append(matched, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2}, matched)
+	assert.True(t, f.MayContainString(0, "hash", []string{"main-11", "x"}))
+	assert.False(t, f.MayContainString(0, "hash", []string{"main-15"}))
+
+	var numbers []uint64
+	require.NoError(t, f.Read(ctx, matched, func(rec arrow.Record) error {
+		col, err := Column[*array.Uint64](rec, "number")
+		require.NoError(t, err)
+		numbers = append(numbers, col.Uint64Values()...)
+		return nil
+	}))
+	assert.Equal(t, []uint64{13, 14, 15, 16, 17, 18}, numbers)
+}
@@ -0,0 +1,193 @@
+package parquet
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// File is an opened parquet file
+type File interface {
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
+// ObjectStore keeps the parquet files. The files are immutable once put, so they can be cached locally.
+type ObjectStore interface {
+	// Put uploads the local file as the object with the key
+	Put(ctx context.Context, key, localPath string) error
+	// Exists checks whether the object with the key exists
+	Exists(ctx context.Context, key string) (bool, error)
+	// Open opens the object with the key, returns an error wrapping os.ErrNotExist if not exists
+	Open(ctx context.Context, key string) (File, error)
+}
+
+// LocalObjectStore keeps the objects as the files in a local directory
+type LocalObjectStore struct {
+	root string
+}
+
+func NewLocalObjectStore(root string) *LocalObjectStore {
+	return &LocalObjectStore{root: root}
+}
+
+func (s *LocalObjectStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *LocalObjectStore) Put(ctx context.Context, key, localPath string) error {
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrapf(err, "create directory of %s failed", key)
+	}
+	src, err := os.Open(localPath)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", localPath)
+	}
+	defer src.Close()
+	// write to a temp file first, so a half written file will never be seen
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "create temp file of %s failed", key)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "write %s failed", key)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrapf(err, "write %s failed", key)
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalObjectStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalObjectStore) Open(ctx context.Context, key string) (File, error) {
+	return os.Open(s.path(key))
+}
+
+// S3Engine is the client of the S3 compatible store, implemented by storage.S3StorageEngine of the processor service
+type S3Engine interface {
+	UploadLocalFile(ctx context.Context, bucket, object, contentType, filePath string) (string, error)
+	ObjectExists(ctx context.Context, bucket string, object string) (bool, error)
+	DownloadToLocalFile(ctx context.Context, bucket, object, filePath string) error
+}
+
+// DefaultS3CacheSize is the default max total size of the objects cached by S3ObjectStore
+const DefaultS3CacheSize = 8 << 30
+
+// S3ObjectStore keeps the objects in a bucket of a S3 compatible store, the objects are downloaded to
+// the cache directory before being opened. The total size of the cached objects is limited by cacheSize,
+// the least recently opened ones are removed first.
+type S3ObjectStore struct {
+	engine    S3Engine
+	bucket    string
+	prefix    string
+	cacheDir  string
+	cacheSize int64
+
+	mu sync.Mutex
+	// cached are the cached objects, the most recently opened one is at the front
+	cached *list.List
+	index  map[string]*list.Element
+	total  int64
+}
+
+type cachedObject struct {
+	key  string
+	size int64
+}
+
+// NewS3ObjectStore creates the store, DefaultS3CacheSize is used if cacheSize is not positive
+func NewS3ObjectStore(engine S3Engine, bucket, prefix, cacheDir string, cacheSize int64) *S3ObjectStore {
+	if cacheSize <= 0 {
+		cacheSize = DefaultS3CacheSize
+	}
+	return &S3ObjectStore{
+		engine:    engine,
+		bucket:    bucket,
+		prefix:    prefix,
+		cacheDir:  cacheDir,
+		cacheSize: cacheSize,
+		cached:    list.New(),
+		index:     make(map[string]*list.Element),
+	}
+}
+
+func (s *S3ObjectStore) object(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+func (s *S3ObjectStore) cachePath(key string) string {
+	return filepath.Join(s.cacheDir, filepath.FromSlash(key))
+}
+
+func (s *S3ObjectStore) Put(ctx context.Context, key, localPath string) error {
+	_, err := s.engine.UploadLocalFile(ctx, s.bucket, s.object(key), "application/vnd.apache.parquet", localPath)
+	return err
+}
+
+func (s *S3ObjectStore) Exists(ctx context.Context, key string) (bool, error) {
+	return s.engine.ObjectExists(ctx, s.bucket, s.object(key))
+}
+
+// touch records the cached object as the most recently used one, and removes the least recently used ones
+// if the total size is over the limit. The opened files of the removed objects can still be read.
+func (s *S3ObjectStore) touch(key string, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, has := s.index[key]; has {
+		s.cached.MoveToFront(elem)
+		return
+	}
+	s.index[key] = s.cached.PushFront(cachedObject{key: key, size: size})
+	s.total += size
+	for s.total > s.cacheSize && s.cached.Len() > 1 {
+		obj := s.cached.Remove(s.cached.Back()).(cachedObject)
+		delete(s.index, obj.key)
+		s.total -= obj.size
+		_ = os.Remove(s.cachePath(obj.key))
+	}
+}
+
+func (s *S3ObjectStore) Open(ctx context.Context, key string) (File, error) {
+	cached := s.cachePath(key)
+	if f, err := os.Open(cached); err == nil {
+		if info, statErr := f.Stat(); statErr == nil {
+			s.touch(key, info.Size())
+			return f, nil
+		}
+		_ = f.Close()
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return nil, errors.Wrapf(err, "create cache directory of %s failed", key)
+	}
+	if err := s.engine.DownloadToLocalFile(ctx, s.bucket, s.object(key), cached); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(cached)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "stat cached %s failed", key)
+	}
+	s.touch(key, info.Size())
+	return f, nil
+}
@@ -0,0 +1,88 @@
+package parquet
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testS3Engine keeps the objects in memory and counts the downloads
+type testS3Engine struct {
+	objects   map[string][]byte
+	downloads map[string]int
+}
+
+func (e *testS3Engine) UploadLocalFile(_ context.Context, _, object, _, filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	e.objects[object] = data
+	return object, nil
+}
+
+func (e *testS3Engine) ObjectExists(_ context.Context, _ string, object string) (bool, error) {
+	_, has := e.objects[object]
+	return has, nil
+}
+
+func (e *testS3Engine) DownloadToLocalFile(_ context.Context, _, object, filePath string) error {
+	data, has := e.objects[object]
+	if !has {
+		return errors.Wrapf(os.ErrNotExist, "object %s", object)
+	}
+	e.downloads[object]++
+	return os.WriteFile(filePath, data, 0644)
+}
+
+func Test_S3ObjectStoreCache(t *testing.T) {
+	ctx := context.Background()
+	engine := &testS3Engine{objects: make(map[string][]byte), downloads: make(map[string]int)}
+	cacheDir := t.TempDir()
+	s := NewS3ObjectStore(engine, "bucket", "prefix", cacheDir, 25)
+
+	local := filepath.Join(t.TempDir(), "data")
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, os.WriteFile(local, []byte(key+"123456789"), 0644))
+		require.NoError(t, s.Put(ctx, key, local))
+	}
+	read := func(key string) string {
+		f, err := s.Open(ctx, key)
+		require.NoError(t, err)
+		defer f.Close()
+		data, err := io.ReadAll(io.NewSectionReader(f, 0, 100))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "a123456789", read("a"))
+	assert.Equal(t, "b123456789", read("b"))
+	assert.Equal(t, "a123456789", read("a"))
+	// over the limit, the least recently opened b is removed
+	assert.Equal(t, "c123456789", read("c"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "b"))
+	assert.Equal(t, "a123456789", read("a"))
+	assert.Equal(t, "b123456789", read("b"))
+	assert.Equal(t, map[string]int{"prefix/a": 1, "prefix/b": 2, "prefix/c": 1}, engine.downloads)
+	assert.Equal(t, int64(20), s.total)
+
+	// an opened file can still be read after removed from the cache
+	f, err := s.Open(ctx, "a")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, "b123456789", read("b"))
+	assert.Equal(t, "c123456789", read("c"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "a"))
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 100))
+	require.NoError(t, err)
+	assert.Equal(t, "a123456789", string(data))
+
+	_, err = s.Open(ctx, "d")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,213 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	pq "github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/compress"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/metadata"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/pkg/errors"
+
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+const (
+	DefaultPartitionSize = 10000
+	DefaultRowGroupSize  = 8192
+)
+
+// Store keeps the tables in parquet files, each table is split into the partitions of PartitionSize slots,
+// a partition of a table is a file with the key like "<table>/000000010000-000000019999.parquet"
+type Store struct {
+	objects       ObjectStore
+	partitionSize uint64
+	rowGroupSize  int64
+	mem           memory.Allocator
+}
+
+// NewStore creates the store, the default sizes are used if partitionSize or rowGroupSize is zero.
+// The partition size should never be changed once there are files in the store.
+func NewStore(objects ObjectStore, partitionSize uint64, rowGroupSize int64) *Store {
+	if partitionSize == 0 {
+		partitionSize = DefaultPartitionSize
+	}
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	return &Store{
+		objects:       objects,
+		partitionSize: partitionSize,
+		rowGroupSize:  rowGroupSize,
+		mem:           memory.DefaultAllocator,
+	}
+}
+
+func (s *Store) Allocator() memory.Allocator {
+	return s.mem
+}
+
+// Partition returns the partition contains the slot
+func (s *Store) Partition(sn uint64) rg.Range {
+	start := sn / s.partitionSize * s.partitionSize
+	return rg.NewRange(start, start+s.partitionSize-1)
+}
+
+// Partitions returns the partitions overlapped with the interval in order, the interval should be closed
+func (s *Store) Partitions(interval rg.Range) (partitions []rg.Range) {
+	if interval.IsEmpty() || interval.End == nil {
+		return nil
+	}
+	for p := s.Partition(interval.Start); p.Start <= *interval.End; p = s.Partition(*p.End + 1) {
+		partitions = append(partitions, p)
+	}
+	return partitions
+}
+
+func (s *Store) key(table string, partition rg.Range) string {
+	return fmt.Sprintf("%s/%012d-%012d.parquet", table, partition.Start, *partition.End)
+}
+
+// Has checks whether the partition of the table exists
+func (s *Store) Has(ctx context.Context, table string, partition rg.Range) (bool, error) {
+	return s.objects.Exists(ctx, s.key(table, partition))
+}
+
+// Write writes the record as the partition of the table, an existing partition will be replaced
+func (s *Store) Write(ctx context.Context, table string, partition rg.Range, rec arrow.Record) error {
+	tmp, err := os.CreateTemp("", "parquet-*.parquet")
+	if err != nil {
+		return errors.Wrapf(err, "create temp file failed")
+	}
+	defer os.Remove(tmp.Name())
+	props := pq.NewWriterProperties(
+		pq.WithCompression(compress.Codecs.Zstd),
+		pq.WithMaxRowGroupLength(s.rowGroupSize),
+		pq.WithStats(true),
+		pq.WithAllocator(s.mem),
+	)
+	w, err := pqarrow.NewFileWriter(rec.Schema(), tmp, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "create writer of %s failed", table)
+	}
+	if err = w.Write(rec); err != nil {
+		_ = w.Close()
+		return errors.Wrapf(err, "write %s failed", table)
+	}
+	// close the writer will also close the file
+	if err = w.Close(); err != nil {
+		return errors.Wrapf(err, "write %s failed", table)
+	}
+	return s.objects.Put(ctx, s.key(table, partition), tmp.Name())
+}
+
+// Open opens the partition of the table, returns an error wrapping os.ErrNotExist if not exists
+func (s *Store) Open(ctx context.Context, table string, partition rg.Range) (*TableFile, error) {
+	key := s.key(table, partition)
+	f, err := s.objects.Open(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s failed", key)
+	}
+	rdr, err := file.NewParquetReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "read %s failed", key)
+	}
+	fr, err := pqarrow.NewFileReader(rdr, pqarrow.ArrowReadProperties{BatchSize: s.rowGroupSize}, s.mem)
+	if err != nil {
+		_ = rdr.Close()
+		return nil, errors.Wrapf(err, "read %s failed", key)
+	}
+	return &TableFile{name: filepath.Base(key), rdr: rdr, fr: fr}, nil
+}
+
+// TableFile is an opened partition of a table
+type TableFile struct {
+	name string
+	rdr  *file.Reader
+	fr   *pqarrow.FileReader
+}
+
+func (t *TableFile) Close() error {
+	return t.rdr.Close()
+}
+
+func (t *TableFile) NumRowGroups() int {
+	return t.rdr.NumRowGroups()
+}
+
+func (t *TableFile) statistics(rowGroup int, column string) metadata.TypedStatistics {
+	idx := t.rdr.MetaData().Schema.ColumnIndexByName(column)
+	if idx < 0 {
+		return nil
+	}
+	chunk, err := t.rdr.MetaData().RowGroup(rowGroup).ColumnChunk(idx)
+	if err != nil {
+		return nil
+	}
+	if ok, _ := chunk.StatsSet(); !ok {
+		return nil
+	}
+	stats, err := chunk.Statistics()
+	if err != nil || stats == nil || !stats.HasMinMax() {
+		return nil
+	}
+	return stats
+}
+
+// MayContainUint checks by the statistics whether the uint64 column of the row group may have the values in the
+// interval, returns true if there is no statistics
+func (t *TableFile) MayContainUint(rowGroup int, column string, interval rg.Range) bool {
+	stats, is := t.statistics(rowGroup, column).(*metadata.Int64Statistics)
+	if !is {
+		return true
+	}
+	return !interval.Intersection(rg.NewRange(uint64(stats.Min()), uint64(stats.Max()))).IsEmpty()
+}
+
+// MayContainString checks by the statistics whether the string column of the row group may have any of the values,
+// returns true if there is no statistics
+func (t *TableFile) MayContainString(rowGroup int, column string, values []string) bool {
+	stats, is := t.statistics(rowGroup, column).(*metadata.ByteArrayStatistics)
+	if !is {
+		return true
+	}
+	for _, v := range values {
+		if bytes.Compare(stats.Min(), []byte(v)) <= 0 && bytes.Compare([]byte(v), stats.Max()) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Read calls fn with the records of the row groups, all row groups are read if rowGroups is nil.
+// The record is released after fn returns.
+func (t *TableFile) Read(ctx context.Context, rowGroups []int, fn func(rec arrow.Record) error) error {
+	if rowGroups != nil && len(rowGroups) == 0 {
+		return nil
+	}
+	rr, err := t.fr.GetRecordReader(ctx, nil, rowGroups)
+	if err != nil {
+		return errors.Wrapf(err, "read %s failed", t.name)
+	}
+	defer rr.Release()
+	for rr.Next() {
+		if err = fn(rr.Record()); err != nil {
+			return err
+		}
+	}
+	// the reader reports io.EOF after the last record
+	if err = rr.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrapf(err, "read %s failed", t.name)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package pebble
+
+import (
+	"encoding/json"
+
+	"sentioxyz/sentio-core/chain/chain"
+)
+
+// Codec encodes the slots into the values of the store
+type Codec[SLOT chain.Slot] interface {
+	Encode(st SLOT) ([]byte, error)
+	Decode(data []byte) (SLOT, error)
+}
+
+// JSONCodec encodes the slots as json, SLOT is usually a pointer type and will be allocated when decoding
+type JSONCodec[SLOT chain.Slot] struct{}
+
+func (JSONCodec[SLOT]) Encode(st SLOT) ([]byte, error) {
+	return json.Marshal(st)
+}
+
+func (JSONCodec[SLOT]) Decode(data []byte) (SLOT, error) {
+	var st SLOT
+	err := json.Unmarshal(data, &st)
+	return st, err
+}
@@ -0,0 +1,21 @@
+package pebble
+
+import (
+	"github.com/cockroachdb/pebble"
+
+	"sentioxyz/sentio-core/chain/chain"
+)
+
+// NewDimension stores the slots and the range of them in the same pebble db, different dimensions
+// in one db should use different key prefixes
+func NewDimension[SLOT chain.Slot](
+	db *pebble.DB,
+	keyPrefix string,
+	codec Codec[SLOT],
+	saveBatchSize int,
+) *chain.SimpleDimension[SLOT] {
+	return chain.NewSimpleDimension[SLOT](
+		NewRangeStore(db, keyPrefix),
+		NewSimpleSlotStore(db, keyPrefix, codec, saveBatchSize),
+	)
+}
@@ -0,0 +1,82 @@
+package pebble
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/chain/chain/chaintest"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+func openTestDB(t *testing.T) *pebble.DB {
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func Test_RangeStore(t *testing.T) {
+	chaintest.RunRangeStore(t, func(t *testing.T) chain.RangeStore {
+		return NewRangeStore(openTestDB(t), "test/")
+	})
+}
+
+func Test_SimpleSlotStore(t *testing.T) {
+	chaintest.RunSimpleSlotStore(t, func(t *testing.T) chain.SimpleSlotStore[*chaintest.Slot] {
+		return NewSimpleSlotStore[*chaintest.Slot](openTestDB(t), "test/", JSONCodec[*chaintest.Slot]{}, 7)
+	}, chaintest.Capabilities{Load: true})
+}
+
+func Test_Dimension(t *testing.T) {
+	chaintest.RunDimension(t, func(t *testing.T) chain.Dimension[*chaintest.Slot] {
+		return NewDimension[*chaintest.Slot](openTestDB(t), "test/", JSONCodec[*chaintest.Slot]{}, 7)
+	}, chaintest.Capabilities{Load: true})
+}
+
+func Test_DimensionReopen(t *testing.T) {
+	db := openTestDB(t)
+	ctx := t.Context()
+	codec := JSONCodec[*chaintest.Slot]{}
+
+	// the slots in the right of the range are left by an interrupted save
+	d := NewDimension[*chaintest.Slot](db, "a/", codec, 10)
+	require.NoError(t, d.Init(ctx))
+	require.NoError(t, d.Save(ctx, rg.NewRange(0, 49), sendSlots(chaintest.NewSlots(rg.NewRange(0, 49), "", 0))))
+	store := NewSimpleSlotStore[*chaintest.Slot](db, "a/", codec, 10)
+	doneChan := make(chan rg.Range, 10)
+	require.NoError(t, store.Save(ctx, rg.NewRange(50, 59), sendSlots(chaintest.NewSlots(rg.NewRange(50, 59), "", 0)), doneChan))
+
+	// other dimensions in the same db are isolated by the key prefix
+	other := NewDimension[*chaintest.Slot](db, "b/", codec, 10)
+	require.NoError(t, other.Init(ctx))
+	r, err := other.GetRange(ctx)
+	require.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+
+	d = NewDimension[*chaintest.Slot](db, "a/", codec, 10)
+	require.NoError(t, d.Init(ctx))
+	r, err = d.GetRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rg.NewRange(0, 49), r)
+	_, err = d.LoadHeader(ctx, 50)
+	assert.ErrorIs(t, err, chain.ErrSlotNotFound)
+	h, err := d.LoadHeader(ctx, 49)
+	require.NoError(t, err)
+	assert.Equal(t, "main-49", h.GetHash())
+}
+
+func sendSlots(slots []*chaintest.Slot) <-chan *chaintest.Slot {
+	ch := make(chan *chaintest.Slot, len(slots))
+	for _, st := range slots {
+		ch <- st
+	}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,83 @@
+package pebble
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+const rangeKey = "range"
+
+var _ chain.RangeStore = (*RangeStore)(nil)
+
+type RangeStore struct {
+	db  *pebble.DB
+	key []byte
+
+	current *rg.Range
+	mu      sync.Mutex
+}
+
+func NewRangeStore(db *pebble.DB, keyPrefix string) *RangeStore {
+	return &RangeStore{
+		db:  db,
+		key: []byte(keyPrefix + rangeKey),
+	}
+}
+
+func (s *RangeStore) get() (rg.Range, error) {
+	if s.current != nil {
+		return *s.current, nil
+	}
+	raw, closer, err := s.db.Get(s.key)
+	if err != nil {
+		if !errors.Is(err, pebble.ErrNotFound) {
+			return rg.EmptyRange, errors.Wrapf(err, "get range failed")
+		}
+		s.current = &rg.EmptyRange
+		return rg.EmptyRange, nil
+	}
+	defer func() {
+		_ = closer.Close()
+	}()
+	cur, err := rg.RangeParser{}.Unmarshal(bytes.NewReader(raw))
+	if err != nil {
+		return rg.EmptyRange, errors.Wrapf(err, "parse range %q failed", string(raw))
+	}
+	s.current = &cur
+	return cur, nil
+}
+
+func (s *RangeStore) Get(ctx context.Context) (rg.Range, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get()
+}
+
+func (s *RangeStore) Update(ctx context.Context, operator rg.RangeOperator) (rg.Range, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	curRange, err := s.get()
+	if err != nil {
+		return rg.EmptyRange, err
+	}
+	newRange := operator(curRange)
+	if newRange.End == nil {
+		return rg.EmptyRange, errors.New("new range is infinity")
+	}
+	var buf bytes.Buffer
+	_ = rg.RangeParser{}.Marshal(newRange, &buf)
+	// the slots are written without sync, syncing the range also persists all the slots written before it,
+	// so the range never covers slots lost in a crash
+	if err = s.db.Set(s.key, buf.Bytes(), pebble.Sync); err != nil {
+		return rg.EmptyRange, errors.Wrapf(err, "save range %s failed", newRange)
+	}
+	s.current = &newRange
+	return newRange, nil
+}
@@ -0,0 +1,245 @@
+package pebble
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/chain"
+	"sentioxyz/sentio-core/common/concurrency"
+	"sentioxyz/sentio-core/common/log"
+	rg "sentioxyz/sentio-core/common/range"
+)
+
+const slotKeyPrefix = "slot-"
+
+var _ chain.SimpleSlotStore[chain.Slot] = (*SimpleSlotStore[chain.Slot])(nil)
+
+type SimpleSlotStore[SLOT chain.Slot] struct {
+	db            *pebble.DB
+	keyPrefix     []byte
+	codec         Codec[SLOT]
+	saveBatchSize int
+}
+
+func NewSimpleSlotStore[SLOT chain.Slot](
+	db *pebble.DB,
+	keyPrefix string,
+	codec Codec[SLOT],
+	saveBatchSize int,
+) *SimpleSlotStore[SLOT] {
+	return &SimpleSlotStore[SLOT]{
+		db:            db,
+		keyPrefix:     []byte(keyPrefix + slotKeyPrefix),
+		codec:         codec,
+		saveBatchSize: max(saveBatchSize, 1),
+	}
+}
+
+// slotKey is the key prefix followed by the big-endian slot number, so the keys are ordered by the slot number
+func (s *SimpleSlotStore[SLOT]) slotKey(sn uint64) []byte {
+	key := make([]byte, len(s.keyPrefix)+8)
+	copy(key, s.keyPrefix)
+	binary.BigEndian.PutUint64(key[len(s.keyPrefix):], sn)
+	return key
+}
+
+func (s *SimpleSlotStore[SLOT]) slotNumber(key []byte) uint64 {
+	return binary.BigEndian.Uint64(key[len(s.keyPrefix):])
+}
+
+// bounds returns the key range [lower, upper) of the interval
+func (s *SimpleSlotStore[SLOT]) bounds(interval rg.Range) ([]byte, []byte) {
+	lower := s.slotKey(interval.Start)
+	if interval.End == nil || *interval.End == math.MaxUint64 {
+		// all slot keys have the same length, so it is greater than all of them
+		return lower, append(s.slotKey(math.MaxUint64), 0)
+	}
+	return lower, s.slotKey(*interval.End + 1)
+}
+
+func (s *SimpleSlotStore[SLOT]) get(sn uint64) (SLOT, error) {
+	var st SLOT
+	raw, closer, err := s.db.Get(s.slotKey(sn))
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return st, errors.Wrapf(chain.ErrSlotNotFound, "slot %d not found", sn)
+		}
+		return st, errors.Wrapf(err, "get slot %d failed", sn)
+	}
+	defer func() {
+		_ = closer.Close()
+	}()
+	if st, err = s.codec.Decode(raw); err != nil {
+		return st, errors.Wrapf(err, "decode slot %d failed", sn)
+	}
+	return st, nil
+}
+
+func (s *SimpleSlotStore[SLOT]) LoadHeader(ctx context.Context, sn uint64) (chain.Slot, error) {
+	return s.get(sn)
+}
+
+func (s *SimpleSlotStore[SLOT]) CheckMissing(ctx context.Context, interval rg.Range, missing chan<- rg.Range) error {
+	if interval.End == nil {
+		panic(errors.Errorf("interval is infinity"))
+	}
+	if interval.IsEmpty() {
+		return nil
+	}
+	lower, upper := s.bounds(interval)
+	iter, err := s.db.NewIterWithContext(ctx, &pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return errors.Wrapf(err, "create iterator for %s failed", interval)
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	report := func(r rg.Range) error {
+		select {
+		case missing <- r:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	next := interval.Start
+	for iter.First(); iter.Valid(); iter.Next() {
+		sn := s.slotNumber(iter.Key())
+		if sn > next {
+			if err = report(rg.NewRange(next, sn-1)); err != nil {
+				return err
+			}
+		}
+		if sn == *interval.End {
+			return nil
+		}
+		next = sn + 1
+	}
+	if err = iter.Error(); err != nil {
+		return errors.Wrapf(err, "iterate slots in %s failed", interval)
+	}
+	return report(rg.NewRange(next, *interval.End))
+}
+
+func (s *SimpleSlotStore[SLOT]) Save(
+	ctx context.Context,
+	interval rg.Range,
+	slotChan <-chan SLOT,
+	doneChan chan<- rg.Range,
+) error {
+	if interval.End == nil {
+		panic(errors.Errorf("interval is infinity"))
+	}
+	_, logger := log.FromContext(ctx, "interval", interval.String())
+
+	// clean up the scene
+	if err := s.Delete(ctx, interval); err != nil {
+		logger.Errorfe(err, "truncate before save failed")
+		return errors.Wrapf(err, "truncate interval %s before save failed", interval)
+	}
+
+	batch := s.db.NewBatch()
+	defer func() {
+		_ = batch.Close()
+	}()
+	batchSlots := rg.EmptyRangeSet
+	flush := func(ctx context.Context) error {
+		if batchSlots.IsEmpty() {
+			return nil
+		}
+		if err := batch.Commit(pebble.NoSync); err != nil {
+			return errors.Wrapf(err, "write slots %s failed", batchSlots)
+		}
+		for _, r := range batchSlots.GetRanges() {
+			select {
+			case doneChan <- r:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		batch.Reset()
+		batchSlots = rg.EmptyRangeSet
+		return nil
+	}
+	var count int
+	err := concurrency.ForEach(ctx, slotChan, func(ctx context.Context, _ int, st SLOT) error {
+		value, err := s.codec.Encode(st)
+		if err != nil {
+			return errors.Wrapf(err, "encode slot %d failed", st.GetNumber())
+		}
+		if err = batch.Set(s.slotKey(st.GetNumber()), value, nil); err != nil {
+			return errors.Wrapf(err, "write slot %d into batch failed", st.GetNumber())
+		}
+		batchSlots = batchSlots.Union(rg.NewSingleRange(st.GetNumber()))
+		if count++; count%s.saveBatchSize > 0 {
+			return nil
+		}
+		return flush(ctx)
+	})
+	if err == nil {
+		err = flush(ctx)
+	}
+	if err != nil {
+		logger.Errorfe(err, "save into pebble failed")
+		return errors.Wrapf(err, "save %s into pebble failed", interval)
+	}
+	logger.Infow("save into pebble succeed", "slotTotal", count)
+	return nil
+}
+
+func (s *SimpleSlotStore[SLOT]) Load(ctx context.Context, interval rg.Range, slotChan chan<- SLOT) error {
+	if interval.IsEmpty() {
+		return nil
+	}
+	lower, upper := s.bounds(interval)
+	iter, err := s.db.NewIterWithContext(ctx, &pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return errors.Wrapf(err, "create iterator for %s failed", interval)
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	next := interval.Start
+	for iter.First(); iter.Valid(); iter.Next() {
+		sn := s.slotNumber(iter.Key())
+		if sn != next {
+			return errors.Wrapf(chain.ErrSlotNotFound, "slot %d not found when loading at %s", next, interval)
+		}
+		var st SLOT
+		if st, err = s.codec.Decode(iter.Value()); err != nil {
+			return errors.Wrapf(err, "decode slot %d failed", sn)
+		}
+		select {
+		case slotChan <- st:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if sn == math.MaxUint64 {
+			return nil
+		}
+		next = sn + 1
+	}
+	if err = iter.Error(); err != nil {
+		return errors.Wrapf(err, "iterate slots in %s failed", interval)
+	}
+	if interval.End != nil && next <= *interval.End {
+		return errors.Wrapf(chain.ErrSlotNotFound, "slot %d not found when loading at %s", next, interval)
+	}
+	return nil
+}
+
+func (s *SimpleSlotStore[SLOT]) Delete(ctx context.Context, interval rg.Range) error {
+	if interval.IsEmpty() {
+		return nil
+	}
+	lower, upper := s.bounds(interval)
+	if err := s.db.DeleteRange(lower, upper, pebble.NoSync); err != nil {
+		return errors.Wrapf(err, "delete slots in %s failed", interval)
+	}
+	log.Debugf("delete slots in %s succeed", interval)
+	return nil
+}
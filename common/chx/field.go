@@ -62,8 +62,51 @@ func (t FieldTypeNormal) String() string {
 	return string(t)
 }
 
+// widenedNormalTypes is the types each type can be modified to without losing any value
+var widenedNormalTypes = map[FieldTypeNormal][]FieldTypeNormal{
+	"Int8":    {"Int16", "Int32", "Int64", "Int128", "Int256", "Float32", "Float64", "String"},
+	"Int16":   {"Int32", "Int64", "Int128", "Int256", "Float32", "Float64", "String"},
+	"Int32":   {"Int64", "Int128", "Int256", "Float64", "String"},
+	"Int64":   {"Int128", "Int256", "String"},
+	"Int128":  {"Int256", "String"},
+	"Int256":  {"String"},
+	"UInt8":   {"UInt16", "UInt32", "UInt64", "UInt128", "UInt256", "Int16", "Int32", "Int64", "Int128", "Int256"},
+	"UInt16":  {"UInt32", "UInt64", "UInt128", "UInt256", "Int32", "Int64", "Int128", "Int256"},
+	"UInt32":  {"UInt64", "UInt128", "UInt256", "Int64", "Int128", "Int256"},
+	"UInt64":  {"UInt128", "UInt256", "Int128", "Int256"},
+	"UInt128": {"UInt256", "Int256"},
+	"Float32": {"Float64"},
+}
+
+// integerDigits is the max number of decimal digits of the integer types
+var integerDigits = map[FieldTypeNormal]uint8{
+	"Int8": 3, "Int16": 5, "Int32": 10, "Int64": 19, "Int128": 39,
+	"UInt8": 3, "UInt16": 5, "UInt32": 10, "UInt64": 20, "UInt128": 39,
+}
+
+// checkModifyToNullable returns true if a is a nullable type and t is the same as or can be modified to the inner one
+func checkModifyToNullable(t FieldType, a FieldType) bool {
+	x, is := a.(FieldTypeNullable)
+	return is && (t.SameAs(x.Inner) || t.CheckModify(x.Inner))
+}
+
+// CheckModify returns true if the column can be modified from type t to a without losing any value,
+// only widening the numeric types and wrapping with Nullable are allowed
 func (t FieldTypeNormal) CheckModify(a FieldType) bool {
-	return false
+	switch x := a.(type) {
+	case FieldTypeNormal:
+		for _, w := range widenedNormalTypes[FieldTypeNormal(strings.ReplaceAll(string(t), " ", ""))] {
+			if w.SameAs(x) {
+				return true
+			}
+		}
+		return false
+	case FieldTypeDecimal:
+		digits, is := integerDigits[t]
+		return is && x.Precision-x.Scale >= digits
+	default:
+		return checkModifyToNullable(t, a)
+	}
 }
 
 func (t FieldTypeNormal) SameAs(a FieldType) bool {
@@ -102,8 +145,10 @@ func (t FieldTypeEnum) String() string {
 }
 
 func (t FieldTypeEnum) CheckModify(a FieldType) bool {
-	_, is := a.(FieldTypeEnum)
-	return is
+	if _, is := a.(FieldTypeEnum); is {
+		return true
+	}
+	return checkModifyToNullable(t, a)
 }
 
 func (t FieldTypeEnum) SameAs(a FieldType) bool {
@@ -132,8 +177,10 @@ func (t FieldTypeDecimal) String() string {
 }
 
 func (t FieldTypeDecimal) CheckModify(a FieldType) bool {
-	_, is := a.(FieldTypeDecimal)
-	return is
+	if _, is := a.(FieldTypeDecimal); is {
+		return true
+	}
+	return checkModifyToNullable(t, a)
 }
 
 func (t FieldTypeDecimal) SameAs(a FieldType) bool {
@@ -154,8 +201,10 @@ func (t FieldTypeDateTime64) String() string {
 }
 
 func (t FieldTypeDateTime64) CheckModify(a FieldType) bool {
-	_, is := a.(FieldTypeDateTime64)
-	return is
+	if _, is := a.(FieldTypeDateTime64); is {
+		return true
+	}
+	return checkModifyToNullable(t, a)
 }
 
 func (t FieldTypeDateTime64) SameAs(a FieldType) bool {
@@ -82,6 +82,31 @@ func Test_CheckModify(t *testing.T) {
 		b := BuildFieldType("Nullable(Enum('AAA', 'BBB', 'CCC', 'DDD'))")
 		assert.False(t, a.CheckModify(b))
 	}
+	// widening
+	for _, c := range []struct {
+		from, to string
+		can      bool
+	}{
+		{from: "Int32", to: "Int64", can: true},
+		{from: "Int32", to: "Int256", can: true},
+		{from: "Int32", to: "Float64", can: true},
+		{from: "Int32", to: "String", can: true},
+		{from: "Int32", to: "Decimal(76, 30)", can: true},
+		{from: "Int64", to: "Decimal(76, 30)", can: true},
+		{from: "Int32", to: "Nullable(Int32)", can: true},
+		{from: "Int32", to: "Nullable(Int64)", can: true},
+		{from: "Array(Int32)", to: "Array(Int64)", can: true},
+		{from: "Enum('AAA')", to: "Nullable(Enum('AAA', 'BBB'))", can: true},
+		{from: "Int64", to: "Int32", can: false},
+		{from: "Int64", to: "Float64", can: false},
+		{from: "Int256", to: "Decimal(76, 30)", can: false},
+		{from: "Int64", to: "Tuple(Bool, Int8, UInt256)", can: false},
+		{from: "UInt64", to: "Int64", can: false},
+		{from: "Nullable(Int32)", to: "Int64", can: false},
+		{from: "String", to: "Int64", can: false},
+	} {
+		assert.Equal(t, c.can, BuildFieldType(c.from).CheckModify(BuildFieldType(c.to)), "%s => %s", c.from, c.to)
+	}
 }
 
 func Test_BuildFieldType_Tuple(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sentioxyz/sentio-core/common/log"
 	"sentioxyz/sentio-core/common/utils"
+	"slices"
 	"strings"
 	"time"
 
@@ -431,6 +432,39 @@ func (c Controller) Create(ctx context.Context, tableOrView TableOrView) (err er
 	return nil
 }
 
+// CheckSyncTable checks whether table pre can be synced to cur in place by SyncTable without
+// ignoring any change, which means the engine, partition and order by are not changed and
+// the type of all the kept columns can be modified.
+func CheckSyncTable(pre, cur Table) error {
+	if pre.Name != cur.Name {
+		return errors.Errorf("try to change table name from %q to %q", pre.Name, cur.Name)
+	}
+	if pe, ce := pre.Config.Engine.Name(), cur.Config.Engine.Name(); pe != ce {
+		return errors.Errorf("engine of table %s changed from %q to %q", cur.Name, pe, ce)
+	}
+	if pp, cp := pre.Config.PartitionBy, cur.Config.PartitionBy; pp != cp {
+		return errors.Errorf("partition by of table %s changed from %q to %q", cur.Name, pp, cp)
+	}
+	if po, co := pre.Config.OrderBy, cur.Config.OrderBy; !utils.ArrEqual(po, co) {
+		// VersionedCollapsingMergeTree will auto add version field to tail of the order by list
+		vc, is := cur.Config.Engine.(engineVersionedCollapsingMergeTree)
+		if !is || !utils.ArrEqual(po, append(slices.Clone(co), vc.VersionFieldName)) {
+			return errors.Errorf("order by of table %s changed from %v to %v", cur.Name, po, co)
+		}
+	}
+	for _, field := range cur.Fields {
+		pf, i := pre.Fields.FindByName(field.Name)
+		if i < 0 || pf.Type.SameAs(field.Type) {
+			continue
+		}
+		if !pf.Type.CheckModify(field.Type) {
+			return errors.Errorf("cannot modify type from %s to %s for column %s of table %s",
+				pf.Type, field.Type, field.Name, cur.Name)
+		}
+	}
+	return nil
+}
+
 func (c Controller) SyncTable(ctx context.Context, pre, cur Table) (err error) {
 	// === check diff
 	if pre.Name != cur.Name {
@@ -593,6 +627,11 @@ func (c Controller) SyncTable(ctx context.Context, pre, cur Table) (err error) {
 		if err != nil {
 			return errors.Wrapf(err, "add index %q failed", index.Name)
 		}
+		// new index only works for the new parts, need to build it for the exists parts
+		err = c.AlterTable(ctx, cur.Name, fmt.Sprintf("MATERIALIZE INDEX `%s`", index.Name))
+		if err != nil {
+			return errors.Wrapf(err, "materialize index %q failed", index.Name)
+		}
 		logger.With("index", index).Infof("added index %s", index.Name)
 	}
 	// drop projections
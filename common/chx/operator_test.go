@@ -0,0 +1,38 @@
+package chx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckSyncTable(t *testing.T) {
+	build := func(engine Engine, orderBy []string, fields ...Field) Table {
+		return Table{
+			Name:   "t",
+			Config: TableConfig{Engine: engine, OrderBy: orderBy},
+			Fields: fields,
+		}
+	}
+	mt := NewDefaultMergeTreeEngine(false)
+	vc := NewDefaultVersionedCollapsingMergeTreeEngine(false, "sign", "version")
+	idField := Field{Name: "id", Type: FieldTypeString}
+
+	// widen the type and add a new column
+	pre := build(mt, []string{"id"}, idField, Field{Name: "a", Type: FieldTypeInt32})
+	cur := build(mt, []string{"id"}, idField, Field{Name: "a", Type: FieldTypeInt64},
+		Field{Name: "b", Type: FieldTypeNullable{Inner: FieldTypeString}})
+	assert.NoError(t, CheckSyncTable(pre, cur))
+
+	// narrow the type
+	assert.Error(t, CheckSyncTable(cur, pre))
+
+	// order by changed
+	assert.Error(t, CheckSyncTable(pre, build(mt, []string{"id", "a"}, idField, Field{Name: "a", Type: FieldTypeInt32})))
+
+	// engine changed
+	assert.Error(t, CheckSyncTable(pre, build(vc, []string{"id"}, idField, Field{Name: "a", Type: FieldTypeInt32})))
+
+	// version field is appended to the order by list of the loaded VersionedCollapsingMergeTree table
+	assert.NoError(t, CheckSyncTable(build(vc, []string{"id", "version"}, idField), build(vc, []string{"id"}, idField)))
+}
@@ -45,6 +45,12 @@ func ContextMergeSettings(ctx context.Context, settings clickhouse.Settings) con
 	return context.WithValue(ctx, connSettingsKey, merged)
 }
 
+// ContextSettings returns the settings merged into the context by ContextMergeSettings
+func ContextSettings(ctx context.Context) clickhouse.Settings {
+	settings, _ := ctx.Value(connSettingsKey).(clickhouse.Settings)
+	return settings
+}
+
 var (
 	rawConnections *utils.SafeMap[uint64, driver.Conn]
 	connections    *utils.SafeMap[string, Conn]
@@ -0,0 +1,354 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/requestlimiter"
+	"sentioxyz/sentio-core/common/tokenbucket"
+	"sentioxyz/sentio-core/service/common/protos"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+)
+
+// LimitExceededErrorCode is the error code returned to the requests rejected by the rate limit middleware,
+// same as the one defined in EIP-1474.
+const LimitExceededErrorCode = -32005
+
+const (
+	defaultRateLimitTier          = "FREE"
+	anonymousRateLimitTier        = "ANONYMOUS"
+	defaultConcurrencyTimeout     = time.Minute
+	rateLimitConcurrencyRetryHint = time.Second
+)
+
+var (
+	defaultRateLimitKeyHeaders     = []string{"X-Api-Key"}
+	defaultRateLimitKeyQueryParams = []string{"api-key", "apikey"}
+)
+
+// RateLimitRule limits the number of calls of the methods in a sliding window for each caller.
+// All the methods of the rule share one bucket, empty Methods means the rule applies to every method.
+type RateLimitRule struct {
+	Methods []string      `yaml:"methods"`
+	Limit   int64         `yaml:"limit"`
+	Window  time.Duration `yaml:"window"`
+}
+
+func (r RateLimitRule) match(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RateLimitRule) String() string {
+	if len(r.Methods) == 0 {
+		return "all methods"
+	}
+	return strings.Join(r.Methods, ",")
+}
+
+// RateLimitPolicy is the policy of the rate limit middleware, see ratelimit_policy.yaml for an example.
+//
+// The caller is identified by the api key found in the headers or the query parameters, the callers
+// without an api key are identified by their ip and use the ANONYMOUS tier. The tier of an api key is
+// looked up in Keys, DefaultTier is used for the unknown ones.
+//
+// Methods are the methods served by the handler, the calls of the other methods are rejected before
+// any counter is touched. Empty Methods means all the methods are accepted.
+type RateLimitPolicy struct {
+	Methods        []string                   `yaml:"methods"`
+	KeyHeaders     []string                   `yaml:"key_headers"`
+	KeyQueryParams []string                   `yaml:"key_query_params"`
+	DefaultTier    string                     `yaml:"default_tier"`
+	Tiers          map[string][]RateLimitRule `yaml:"tiers"`
+	Keys           map[string]string          `yaml:"keys"`
+
+	// Concurrency limits the number of in-flight calls by requestlimiter, nil means no limit
+	Concurrency        *requestlimiter.LimiterConfig `yaml:"concurrency"`
+	ConcurrencyTimeout time.Duration                 `yaml:"concurrency_timeout"`
+}
+
+func LoadRateLimitPolicy(path string) (RateLimitPolicy, error) {
+	var policy RateLimitPolicy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, errors.Wrapf(err, "read rate limit policy %s failed", path)
+	}
+	if err = yaml.Unmarshal(data, &policy); err != nil {
+		return policy, errors.Wrapf(err, "unmarshal rate limit policy %s failed", path)
+	}
+	return policy, policy.Check()
+}
+
+func (p RateLimitPolicy) Check() error {
+	if p.DefaultTier != "" {
+		if _, has := p.Tiers[p.DefaultTier]; !has {
+			return errors.Errorf("default tier %s is not defined", p.DefaultTier)
+		}
+	}
+	for key, tier := range p.Keys {
+		if _, has := p.Tiers[tier]; !has {
+			return errors.Errorf("tier %s of key %s is not defined", tier, key)
+		}
+	}
+	methods := p.methodSet()
+	for tier, rules := range p.Tiers {
+		for i, rule := range rules {
+			if rule.Limit <= 0 || rule.Window < time.Second {
+				return errors.Errorf("rule #%d of tier %s is invalid, limit should be positive and window should be "+
+					"at least 1s, got limit %d and window %s", i, tier, rule.Limit, rule.Window)
+			}
+			for _, method := range rule.Methods {
+				if methods != nil && !methods[strings.ToLower(method)] {
+					return errors.Errorf("method %s of rule #%d of tier %s is not in methods", method, i, tier)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// methodSet returns the lower case names of Methods, nil if all the methods are accepted
+func (p RateLimitPolicy) methodSet() map[string]bool {
+	if len(p.Methods) == 0 {
+		return nil
+	}
+	methods := make(map[string]bool, len(p.Methods))
+	for _, method := range p.Methods {
+		methods[strings.ToLower(method)] = true
+	}
+	return methods
+}
+
+type rateLimitCaller struct {
+	// id is the api key or "ip:<remote host>"
+	id   string
+	ip   string
+	tier string
+}
+
+func (p RateLimitPolicy) identify(ctxData *CtxData) rateLimitCaller {
+	caller := rateLimitCaller{ip: ctxData.ReqSrc.RemoteHost}
+	if r := ctxData.RawReq; r != nil {
+		headers := p.KeyHeaders
+		if len(headers) == 0 {
+			headers = defaultRateLimitKeyHeaders
+		}
+		for _, name := range headers {
+			if caller.id = r.Header.Get(name); caller.id != "" {
+				break
+			}
+		}
+		if caller.id == "" {
+			params := p.KeyQueryParams
+			if len(params) == 0 {
+				params = defaultRateLimitKeyQueryParams
+			}
+			query := r.URL.Query()
+			for _, name := range params {
+				if caller.id = query.Get(name); caller.id != "" {
+					break
+				}
+			}
+		}
+	}
+	if caller.id == "" {
+		caller.id, caller.tier = "ip:"+caller.ip, anonymousRateLimitTier
+		return caller
+	}
+	var has bool
+	if caller.tier, has = p.Keys[caller.id]; !has {
+		caller.tier = p.DefaultTier
+		if caller.tier == "" {
+			caller.tier = defaultRateLimitTier
+		}
+	}
+	return caller
+}
+
+// RateLimitErrorData is the data of the error returned to the rejected requests
+type RateLimitErrorData struct {
+	Method string `json:"method"`
+	Tier   string `json:"tier"`
+	Limit  int64  `json:"limit,omitempty"`
+	Window string `json:"window,omitempty"`
+	// RetryAfter is the number of seconds to wait before the next call may be accepted
+	RetryAfter int64 `json:"retryAfter"`
+}
+
+type rateLimitRequestData struct {
+	caller rateLimitCaller
+	method string
+}
+
+func (d rateLimitRequestData) String() string {
+	return d.caller.id + ":" + d.method
+}
+
+type rateLimiter struct {
+	policy RateLimitPolicy
+	// methods is nil if all the methods are accepted, see RateLimitPolicy.Methods
+	methods map[string]bool
+	buckets tokenbucket.TokenBucket
+	// concurrency is nil if the number of in-flight calls is not limited
+	concurrency requestlimiter.Limiter
+}
+
+// NewRateLimitMiddleware builds the middleware limiting the calls of each caller according to the policy,
+// the counters are kept in redis so they are shared by all the replicas. Every entry of a batch request
+// is a separate call and is limited separately.
+//
+// The calls are accepted if the redis is unavailable, and the simple http requests are never limited.
+func NewRateLimitMiddleware(policy RateLimitPolicy, client *redis.Client) (Middleware, error) {
+	if err := policy.Check(); err != nil {
+		return nil, err
+	}
+	l := &rateLimiter{
+		policy:  policy,
+		methods: policy.methodSet(),
+		buckets: tokenbucket.NewTokenBucket(client),
+	}
+	if policy.Concurrency != nil {
+		timeout := policy.ConcurrencyTimeout
+		if timeout <= 0 {
+			timeout = defaultConcurrencyTimeout
+		}
+		l.concurrency = requestlimiter.NewLimiterWithConfig("jsonrpc", client, timeout, *policy.Concurrency, nil)
+	}
+	return l.middleware, nil
+}
+
+// RegisterRateLimit registers the rate limit middleware built by NewRateLimitMiddleware, it should be
+// registered before the middlewares serving the methods.
+func (s *Handler) RegisterRateLimit(policy RateLimitPolicy, client *redis.Client) error {
+	m, err := NewRateLimitMiddleware(policy, client)
+	if err != nil {
+		return err
+	}
+	s.RegisterMiddleware(m)
+	return nil
+}
+
+func (l *rateLimiter) middleware(next MethodHandler) MethodHandler {
+	return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+		ctxData := GetCtxData(ctx)
+		if ctxData == nil || method == HTTPRequestMethod {
+			return next(ctx, method, params)
+		}
+		if l.methods != nil && !l.methods[strings.ToLower(method)] {
+			return nil, NewJSONError(MethodNotFoundErrorCode, fmt.Sprintf("method %s is not supported", method), nil)
+		}
+		caller := l.policy.identify(ctxData)
+		if err := l.checkRules(ctx, caller, method); err != nil {
+			return nil, err
+		}
+		if l.concurrency == nil {
+			return next(ctx, method, params)
+		}
+		vars := requestlimiter.RequestVars{
+			OwnerID:   caller.id,
+			RequestIP: caller.ip,
+			Data:      rateLimitRequestData{caller: caller, method: method},
+		}
+		if tier, has := protos.Tier_value[caller.tier]; has {
+			vars.Tier = protos.Tier(tier)
+		}
+		limiterID, ok, err := l.concurrency.Acquire(ctx, vars)
+		if !ok {
+			return nil, NewJSONError(
+				LimitExceededErrorCode,
+				fmt.Sprintf("too many concurrent requests: %v", err),
+				RateLimitErrorData{
+					Method:     method,
+					Tier:       caller.tier,
+					RetryAfter: int64(rateLimitConcurrencyRetryHint.Seconds()),
+				},
+			)
+		}
+		if err == nil {
+			defer l.concurrency.Release(context.WithoutCancel(ctx), vars, limiterID)
+		}
+		return next(ctx, method, params)
+	}
+}
+
+// checkRules checks the remaining tokens of all the matched rules before consuming any of them, so the
+// calls rejected by one rule are not counted by the others. Concurrent calls may still pass the check
+// together, the bucket of each rule rejects the ones over its limit when the tokens are consumed.
+func (l *rateLimiter) checkRules(ctx context.Context, caller rateLimitCaller, method string) error {
+	_, logger := log.FromContext(ctx)
+	var (
+		rules []RateLimitRule
+		cfgs  []*tokenbucket.RateLimitConfig
+	)
+	for i, rule := range l.policy.Tiers[caller.tier] {
+		if !rule.match(method) {
+			continue
+		}
+		cfg := &tokenbucket.RateLimitConfig{
+			Key:    fmt.Sprintf("jsonrpc:%s:%d", caller.tier, i),
+			Limit:  rule.Limit,
+			Window: rule.Window,
+			UserID: caller.id,
+		}
+		remaining, ttl, err := l.buckets.GetRemainingTokens(ctx, cfg)
+		if err != nil {
+			logger.Warnf("check rate limit of %s for %s failed, accept it: %v", method, caller.id, err)
+			return nil
+		}
+		if remaining <= 0 {
+			return l.limitExceeded(caller, method, rule, ttl)
+		}
+		rules, cfgs = append(rules, rule), append(cfgs, cfg)
+	}
+	for i, cfg := range cfgs {
+		allowed, _, err := l.buckets.Allow(ctx, cfg)
+		if err != nil {
+			logger.Warnf("check rate limit of %s for %s failed, accept it: %v", method, caller.id, err)
+			return nil
+		}
+		if !allowed {
+			var ttl time.Duration
+			if _, t, err := l.buckets.GetRemainingTokens(ctx, cfg); err == nil {
+				ttl = t
+			}
+			return l.limitExceeded(caller, method, rules[i], ttl)
+		}
+	}
+	return nil
+}
+
+// limitExceeded builds the error of the call rejected by the rule, ttl is the time before the
+// oldest call of the window expires, the whole window is used if it is unknown
+func (l *rateLimiter) limitExceeded(caller rateLimitCaller, method string, rule RateLimitRule, ttl time.Duration) error {
+	retryAfter := rule.Window
+	if ttl > 0 {
+		retryAfter = ttl
+	}
+	return NewJSONError(
+		LimitExceededErrorCode,
+		fmt.Sprintf("rate limit exceeded: %d calls of %s per %s", rule.Limit, rule, rule.Window),
+		RateLimitErrorData{
+			Method:     method,
+			Tier:       caller.tier,
+			Limit:      rule.Limit,
+			Window:     rule.Window.String(),
+			RetryAfter: int64(math.Ceil(retryAfter.Seconds())),
+		},
+	)
+}
@@ -0,0 +1,227 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sentioxyz/sentio-core/common/requestlimiter"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimitHandler(t *testing.T, policy RateLimitPolicy) (*Handler, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	h := NewHandler("test", false, false, nil, nil, "")
+	assert.NoError(t, h.RegisterRateLimit(policy, client))
+	h.RegisterMiddleware(func(next MethodHandler) MethodHandler {
+		return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+			return method, nil
+		}
+	})
+	return h, server
+}
+
+func callRateLimitHandler(t *testing.T, h *Handler, target string, apiKey string, body string) []JsonrpcMessage {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-Api-Key", apiKey)
+	}
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	raw := resp.Body.Bytes()
+	if !strings.HasPrefix(body, "[") {
+		raw = append(append([]byte{'['}, raw...), ']')
+	}
+	var messages []JsonrpcMessage
+	assert.NoError(t, json.Unmarshal(raw, &messages))
+	return messages
+}
+
+func rateLimitErrorData(t *testing.T, msg JsonrpcMessage) RateLimitErrorData {
+	var data RateLimitErrorData
+	raw, err := json.Marshal(msg.Error.Data)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(raw, &data))
+	return data
+}
+
+func Test_RateLimitMiddleware(t *testing.T) {
+	h, _ := newTestRateLimitHandler(t, RateLimitPolicy{
+		Tiers: map[string][]RateLimitRule{
+			"ANONYMOUS": {{Limit: 1, Window: time.Minute}},
+			"FREE": {
+				{Limit: 6, Window: time.Minute},
+				{Methods: []string{"eth_getLogs"}, Limit: 1, Window: time.Minute},
+			},
+			"PRO": {{Limit: 10, Window: time.Minute}},
+		},
+		Keys: map[string]string{"pro-key": "PRO"},
+	})
+	call := `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[]}`
+
+	// the rule of the method and the rule of all methods both apply
+	msgs := callRateLimitHandler(t, h, "/", "free-key", call)
+	assert.Nil(t, msgs[0].Error)
+	msgs = callRateLimitHandler(t, h, "/", "free-key", call)
+	assert.NotNil(t, msgs[0].Error)
+	assert.Equal(t, LimitExceededErrorCode, msgs[0].Error.Code)
+	data := rateLimitErrorData(t, msgs[0])
+	assert.Equal(t, "eth_getLogs", data.Method)
+	assert.Equal(t, "FREE", data.Tier)
+	assert.Equal(t, int64(1), data.Limit)
+	assert.Greater(t, data.RetryAfter, int64(0))
+	assert.LessOrEqual(t, data.RetryAfter, int64(60))
+
+	// the key in the query parameter, other methods are only limited by the rule of all methods
+	msgs = callRateLimitHandler(t, h, "/?apikey=free-key", "", `{"jsonrpc":"2.0","id":1,"method":"eth_call"}`)
+	assert.Nil(t, msgs[0].Error)
+
+	// every entry of the batch is counted, the rejected call of eth_getLogs is not counted by the rule of
+	// all methods, so 4 calls are left in its bucket
+	batch := `[` +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},` +
+		`{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"},` +
+		`{"jsonrpc":"2.0","id":3,"method":"eth_blockNumber"},` +
+		`{"jsonrpc":"2.0","id":4,"method":"eth_blockNumber"},` +
+		`{"jsonrpc":"2.0","id":5,"method":"eth_blockNumber"}` +
+		`]`
+	msgs = callRateLimitHandler(t, h, "/", "free-key", batch)
+	assert.Len(t, msgs, 5)
+	var rejected int
+	for _, msg := range msgs {
+		if msg.Error != nil {
+			assert.Equal(t, LimitExceededErrorCode, msg.Error.Code)
+			rejected++
+		}
+	}
+	assert.Equal(t, 1, rejected)
+
+	// known key uses its own tier
+	msgs = callRateLimitHandler(t, h, "/", "pro-key", batch)
+	for _, msg := range msgs {
+		assert.Nil(t, msg.Error)
+	}
+
+	// caller without an api key is identified by ip
+	msgs = callRateLimitHandler(t, h, "/", "", call)
+	assert.Nil(t, msgs[0].Error)
+	msgs = callRateLimitHandler(t, h, "/", "", call)
+	assert.NotNil(t, msgs[0].Error)
+	assert.Equal(t, "ANONYMOUS", rateLimitErrorData(t, msgs[0]).Tier)
+}
+
+func Test_RateLimitMiddlewareMethods(t *testing.T) {
+	h, server := newTestRateLimitHandler(t, RateLimitPolicy{
+		Methods: []string{"eth_blockNumber", "eth_getLogs", "trace_filter"},
+		Tiers: map[string][]RateLimitRule{
+			"FREE": {{Methods: []string{"eth_getLogs", "trace_filter"}, Limit: 2, Window: time.Minute}},
+		},
+	})
+
+	// unknown methods are rejected before any counter is created
+	msgs := callRateLimitHandler(t, h, "/", "free-key", `{"jsonrpc":"2.0","id":1,"method":"eth_unknown"}`)
+	assert.NotNil(t, msgs[0].Error)
+	assert.Equal(t, MethodNotFoundErrorCode, msgs[0].Error.Code)
+	assert.Empty(t, server.Keys())
+
+	// methods of one rule share one bucket
+	batch := `[` +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs"},` +
+		`{"jsonrpc":"2.0","id":2,"method":"trace_filter"},` +
+		`{"jsonrpc":"2.0","id":3,"method":"trace_filter"},` +
+		`{"jsonrpc":"2.0","id":4,"method":"eth_blockNumber"},` +
+		`{"jsonrpc":"2.0","id":5,"method":"eth_blockNumber"}` +
+		`]`
+	msgs = callRateLimitHandler(t, h, "/", "free-key", batch)
+	assert.Len(t, msgs, 5)
+	var rejected int
+	for i, msg := range msgs {
+		if msg.Error != nil {
+			assert.Equal(t, LimitExceededErrorCode, msg.Error.Code)
+			assert.NotEqual(t, 3, i)
+			rejected++
+		}
+	}
+	assert.Equal(t, 1, rejected)
+
+	// methods of the rules should be served
+	_, err := NewRateLimitMiddleware(RateLimitPolicy{
+		Methods: []string{"eth_call"},
+		Tiers:   map[string][]RateLimitRule{"FREE": {{Methods: []string{"eth_getLogs"}, Limit: 1, Window: time.Minute}}},
+	}, nil)
+	assert.Error(t, err)
+}
+
+func Test_RateLimitMiddlewareConcurrency(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer func() {
+		_ = client.Close()
+	}()
+	m, err := NewRateLimitMiddleware(RateLimitPolicy{
+		Concurrency: &requestlimiter.LimiterConfig{ConcurrentQuotaPerUser: 1},
+	}, client)
+	assert.NoError(t, err)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	chain := MiddlewareChain{m, func(next MethodHandler) MethodHandler {
+		return func(ctx context.Context, method string, params json.RawMessage) (any, error) {
+			if method == "block" {
+				close(entered)
+				<-release
+			}
+			return method, nil
+		}
+	}}
+	rawReq := httptest.NewRequest(http.MethodPost, "/?api-key=k", nil)
+	newCtx := func(method string) context.Context {
+		return setCtxData(context.Background(), &CtxData{RawReq: rawReq, Method: method})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = chain.CallMethod(newCtx("block"), "block", nil)
+	}()
+	<-entered
+	_, err = chain.CallMethod(newCtx("eth_call"), "eth_call", nil)
+	assert.Error(t, err)
+	assert.Equal(t, LimitExceededErrorCode, err.(*jsonError).Code)
+
+	close(release)
+	<-done
+	r, err := chain.CallMethod(newCtx("eth_call"), "eth_call", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "eth_call", r)
+}
+
+func Test_LoadRateLimitPolicy(t *testing.T) {
+	policy, err := LoadRateLimitPolicy("ratelimit_policy.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "FREE", policy.DefaultTier)
+	assert.Contains(t, policy.Methods, "eth_getLogs")
+	assert.Equal(t, []RateLimitRule{
+		{Limit: 50, Window: time.Second},
+		{Methods: []string{"eth_getLogs", "trace_filter", "trace_block"}, Limit: 100, Window: time.Minute},
+	}, policy.Tiers["FREE"])
+	assert.Equal(t, 20, policy.Concurrency.ConcurrentQuotaPerUser)
+	assert.Equal(t, time.Minute, policy.ConcurrencyTimeout)
+
+	policy.Keys = map[string]string{"k": "UNKNOWN"}
+	assert.Error(t, policy.Check())
+}
@@ -51,3 +51,7 @@ func (s *LRUKVStore[T]) Del(ctx context.Context, keys ...string) error {
 	}
 	return nil
 }
+
+func (s *LRUKVStore[T]) Purge() {
+	s.cache.Purge()
+}
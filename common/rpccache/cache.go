@@ -109,13 +109,45 @@ type Loader[X Request, Y Response] func(ctx context.Context, req X, argv ...any)
 
 type rpcCache[X Request, Y Response] struct {
 	client *redis.Client
+	codec  compression.Codec
+	l1     *l1Cache[Y]
+}
+
+type Config struct {
+	// Codec compresses the responses saved in redis, nil means compression.Gzip.
+	// The codec is saved with the response, so replicas using different codecs can read each other's responses.
+	Codec compression.Codec
+	// L1 enables the in-process tier in front of redis if not nil, see L1Config.
+	L1 *L1Config
 }
 
 func NewRpcCache[X Request, Y Response](client *redis.Client) RpcCache[X, Y] {
+	cache, _ := NewRpcCacheWithConfig[X, Y](context.Background(), client, Config{})
+	return cache
+}
+
+// NewRpcCacheWithConfig creates the cache with config, the invalidation subscription of the in-process tier
+// stops when ctx is done.
+func NewRpcCacheWithConfig[X Request, Y Response](
+	ctx context.Context,
+	client *redis.Client,
+	config Config,
+) (RpcCache[X, Y], error) {
 	cache := &rpcCache[X, Y]{
 		client: client,
+		codec:  config.Codec,
 	}
-	return cache
+	if cache.codec == nil {
+		cache.codec = compression.Gzip
+	}
+	if config.L1 != nil {
+		l1, err := newL1Cache[Y](ctx, client, *config.L1)
+		if err != nil {
+			return nil, goerrors.Wrapf(err, "create rpc cache l1 failed")
+		}
+		cache.l1 = l1
+	}
+	return cache, nil
 }
 
 func cacheMiss(err error) bool {
@@ -130,7 +162,7 @@ func (r *rpcCache[X, Y]) set(ctx context.Context, key string, response Y, ttl, l
 	)
 	ctx, logger := log.FromContext(ctx)
 	logger = logger.With("key", key, "ttl", ttl, "lazy", lazy)
-	cr, err = newCachedResponse(logger, response)
+	cr, err = newCachedResponse(logger, r.codec, response)
 	if err != nil {
 		logger.Warnf("create new cached response failed: %v", err)
 		return err
@@ -153,7 +185,9 @@ func (r *rpcCache[X, Y]) set(ctx context.Context, key string, response Y, ttl, l
 			}
 			if err := r.client.SetEx(context.Background(), key, string(responseBytes), ttl).Err(); err != nil {
 				logger.Warnf("rpc cache lazy overwrite failed: %v", err)
+				return
 			}
+			r.l1.invalidate(context.Background(), key)
 		})
 		logger.Debugf("rpc cache lazy set success")
 	default:
@@ -162,6 +196,7 @@ func (r *rpcCache[X, Y]) set(ctx context.Context, key string, response Y, ttl, l
 			logger.Warnf("rpc cache set failed: %v", err)
 			return err
 		}
+		r.l1.invalidate(ctx, key)
 		logger.Debugf("rpc cache set success")
 	}
 	return nil
@@ -330,6 +365,7 @@ func (r *rpcCache[X, Y]) Query(ctx context.Context,
 
 	var (
 		data    []byte
+		ttl     time.Duration
 		refresh = false
 		cr      *cachedResponse[Y]
 		decoded *Y
@@ -351,7 +387,14 @@ func (r *rpcCache[X, Y]) Query(ctx context.Context,
 		return r.Load(ctx, req, loader, option)
 	}
 
-	data, err = r.client.Get(context.WithoutCancel(ctx), key).Bytes()
+	if cached, hit := r.l1.get(ctx, req.Key()); hit {
+		if option.refreshBackground {
+			r.refresh(ctx, req, loader, option)
+		}
+		return cached, nil
+	}
+	version := r.l1.version()
+	data, ttl, err = r.read(context.WithoutCancel(ctx), key)
 	if err != nil {
 		if cacheMiss(err) {
 			logger.Debugf("rpc cache miss")
@@ -379,6 +422,10 @@ func (r *rpcCache[X, Y]) Query(ctx context.Context,
 		refresh = r.refresh(ctx, req, loader, option)
 	}
 	embedUpdateComputeStats(resp, true, refresh)
+	if !refresh {
+		// the refreshing one will be replaced soon, and its compute stats is not for the later hits
+		r.l1.add(ctx, req.Key(), resp, ttl, version)
+	}
 	return resp, nil
 }
 
@@ -387,7 +434,27 @@ func (r *rpcCache[X, Y]) Set(ctx context.Context, req X, response Y) error {
 }
 
 func (r *rpcCache[X, Y]) Delete(ctx context.Context, req X) error {
-	return r.client.Del(context.WithoutCancel(ctx), req.Key().String()).Err()
+	if err := r.client.Del(context.WithoutCancel(ctx), req.Key().String()).Err(); err != nil {
+		return err
+	}
+	r.l1.invalidate(ctx, req.Key().String())
+	return nil
+}
+
+// read gets the cached data from redis, also the remaining TTL of it if the in-process tier is enabled
+func (r *rpcCache[X, Y]) read(ctx context.Context, key string) (data []byte, ttl time.Duration, err error) {
+	if r.l1 == nil {
+		data, err = r.client.Get(ctx, key).Bytes()
+		return data, 0, err
+	}
+	pipe := r.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err = pipe.Exec(ctx); err != nil && !cacheMiss(err) {
+		return nil, 0, err
+	}
+	data, err = get.Bytes()
+	return data, pttl.Val(), err
 }
 
 func (r *rpcCache[X, Y]) Get(ctx context.Context,
@@ -395,6 +462,7 @@ func (r *rpcCache[X, Y]) Get(ctx context.Context,
 	option := mergeOptions(options)
 	var (
 		data    []byte
+		ttl     time.Duration
 		key     = req.Key().String()
 		err     error
 		refresh = false
@@ -419,7 +487,11 @@ func (r *rpcCache[X, Y]) Get(ctx context.Context,
 	if option.refreshBackground {
 		refresh = r.refresh(ctx, req, loader, option)
 	}
-	data, err = r.client.Get(context.WithoutCancel(ctx), key).Bytes()
+	if cached, hit := r.l1.get(ctx, req.Key()); hit {
+		return cached, true
+	}
+	version := r.l1.version()
+	data, ttl, err = r.read(context.WithoutCancel(ctx), key)
 	if err != nil {
 		if cacheMiss(err) {
 			logger.Debugf("rpc cache miss")
@@ -446,5 +518,8 @@ func (r *rpcCache[X, Y]) Get(ctx context.Context,
 	response = *decoded
 	ok = true
 	embedUpdateComputeStats(response, true, refresh)
+	if !refresh {
+		r.l1.add(ctx, req.Key(), response, ttl, version)
+	}
 	return
 }
@@ -0,0 +1,142 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec compresses the gob encoded data, the name of it is saved beside the compressed data,
+// so the data can always be decoded no matter which codec is used by the reader.
+type Codec interface {
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	Gzip   Codec = gzipCodec{}
+	Zstd   Codec = zstdCodec{}
+	Snappy Codec = snappyCodec{}
+	None   Codec = noneCodec{}
+)
+
+// CodecByName returns the codec with the name, empty name means Gzip, which is used by the data without codec name.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", Gzip.Name():
+		return Gzip, nil
+	case Zstd.Name():
+		return Zstd, nil
+	case Snappy.Name():
+		return Snappy, nil
+	case None.Name():
+		return None, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+}
+
+func EncodeWith[T any](codec Codec, data *T) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return "", err
+	}
+	compressed, err := codec.Compress(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(compressed), nil
+}
+
+func DecodeWith[T any](codec Codec, encoded string) (*T, error) {
+	raw, err := codec.Decompress([]byte(encoded))
+	if err != nil {
+		return nil, err
+	}
+	var decoded T
+	if err = gob.NewDecoder(bytes.NewReader(raw)).Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return &decoded, nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string {
+	return "gzip"
+}
+
+func (gzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decompress(data []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gr.Close() }()
+	return io.ReadAll(gr)
+}
+
+// zstdEncoder and zstdDecoder are safe for concurrent EncodeAll and DecodeAll
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string {
+	return "zstd"
+}
+
+func (zstdCodec) Compress(data []byte) ([]byte, error) {
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+func (zstdCodec) Decompress(data []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(data, nil)
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string {
+	return "snappy"
+}
+
+func (snappyCodec) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (snappyCodec) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+type noneCodec struct{}
+
+func (noneCodec) Name() string {
+	return "none"
+}
+
+func (noneCodec) Compress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (noneCodec) Decompress(data []byte) ([]byte, error) {
+	return data, nil
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sentioxyz/sentio-core/common/kvstore"
+	"sentioxyz/sentio-core/common/log"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultInvalidationChannel = defaultPrefixKey + "invalidation"
+
+	l1ResubscribeInterval = time.Second
+)
+
+// L1Config configures the in-process tier in front of redis.
+//
+// The responses in it are decoded once and shared by all the callers hitting it, so they must be
+// treated as read-only, and their compute stats are always (IsCached=true, IsRefreshing=false).
+type L1Config struct {
+	// Size is the max number of responses in the tier, the least recently used one will be evicted.
+	Size int
+	// Channel is the redis pub/sub channel used to invalidate the tier across replicas,
+	// empty means the default one, replicas sharing the same redis should use the same channel.
+	Channel string
+}
+
+type l1Entry[Y Response] struct {
+	response Y
+	expireAt time.Time
+}
+
+type l1Metrics struct {
+	hitCount          metric.Int64Counter
+	missCount         metric.Int64Counter
+	invalidationCount metric.Int64Counter
+	purgeCount        metric.Int64Counter
+}
+
+// l1Cache keeps the decoded responses read from redis. An entry expires together with the redis key,
+// and is removed on all replicas once the redis key is overwritten or deleted by any of them.
+// All methods are no-op for a nil *l1Cache.
+type l1Cache[Y Response] struct {
+	store   *kvstore.LRUKVStore[l1Entry[Y]]
+	client  *redis.Client
+	channel string
+	metrics l1Metrics
+
+	// generation is increased by every invalidation, a response read from redis is only added if no
+	// invalidation happened since the read, otherwise it may be older than the invalidated one.
+	// mu makes the check and the add atomic against the invalidations.
+	generation atomic.Uint64
+	mu         sync.Mutex
+}
+
+func newL1Cache[Y Response](ctx context.Context, client *redis.Client, config L1Config) (*l1Cache[Y], error) {
+	store, err := kvstore.NewLRUKVStore[l1Entry[Y]](config.Size)
+	if err != nil {
+		return nil, err
+	}
+	c := &l1Cache[Y]{
+		store:   store,
+		client:  client,
+		channel: config.Channel,
+	}
+	if c.channel == "" {
+		c.channel = defaultInvalidationChannel
+	}
+	meter := otel.Meter("rpccache")
+	c.metrics.hitCount, _ = meter.Int64Counter("rpccache.l1.hit_total",
+		metric.WithDescription("Counter of the in-process rpc cache hits"))
+	c.metrics.missCount, _ = meter.Int64Counter("rpccache.l1.miss_total",
+		metric.WithDescription("Counter of the in-process rpc cache misses"))
+	c.metrics.invalidationCount, _ = meter.Int64Counter("rpccache.l1.invalidation_total",
+		metric.WithDescription("Counter of the invalidation messages received by the in-process rpc cache"))
+	c.metrics.purgeCount, _ = meter.Int64Counter("rpccache.l1.purge_total",
+		metric.WithDescription("Counter of the in-process rpc cache purges caused by (re)subscribing"))
+
+	// Subscribe sends the SUBSCRIBE command before returning, so no invalidation published after
+	// the cache is created will be missed.
+	pubsub := client.Subscribe(ctx, c.channel)
+	go c.listen(ctx, pubsub)
+	return c, nil
+}
+
+// listen removes the invalidated keys. The messages published while the connection is broken are lost,
+// so the whole tier is purged every time the channel is (re)subscribed.
+func (c *l1Cache[Y]) listen(ctx context.Context, pubsub *redis.PubSub) {
+	_, logger := log.FromContext(ctx)
+	logger = logger.With("channel", c.channel)
+	defer func() {
+		_ = pubsub.Close()
+	}()
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		switch m := msg.(type) {
+		case *redis.Message:
+			c.metrics.invalidationCount.Add(ctx, 1)
+			c.remove(ctx, m.Payload)
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				c.metrics.purgeCount.Add(ctx, 1)
+				c.purge()
+				logger.Infof("rpc cache l1 subscribed, purged")
+			}
+		default:
+			if err == nil {
+				continue
+			}
+			c.purge()
+			logger.Warnf("rpc cache l1 receive invalidation failed: %v, will retry", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(l1ResubscribeInterval):
+			}
+		}
+	}
+}
+
+func (c *l1Cache[Y]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation.Add(1)
+	c.store.Purge()
+}
+
+func (c *l1Cache[Y]) remove(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation.Add(1)
+	_ = c.store.Del(ctx, key)
+}
+
+// version should be got before reading redis, and passed to add
+func (c *l1Cache[Y]) version() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.generation.Load()
+}
+
+func (c *l1Cache[Y]) get(ctx context.Context, key Key) (resp Y, ok bool) {
+	if c == nil {
+		return resp, false
+	}
+	attr := metric.WithAttributes(attribute.String("prefix", key.Prefix))
+	r, _ := c.store.Get(ctx, key.String())
+	entry, has := r[key.String()]
+	if has && !time.Now().Before(entry.expireAt) {
+		_ = c.store.Del(ctx, key.String())
+		has = false
+	}
+	if !has {
+		c.metrics.missCount.Add(ctx, 1, attr)
+		return resp, false
+	}
+	c.metrics.hitCount.Add(ctx, 1, attr)
+	return entry.response, true
+}
+
+// add keeps the response read from redis with the version until the redis key expires after ttl
+func (c *l1Cache[Y]) add(ctx context.Context, key Key, response Y, ttl time.Duration, version uint64) {
+	if c == nil || ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation.Load() != version {
+		return
+	}
+	_ = c.store.Set(ctx, map[string]l1Entry[Y]{
+		key.String(): {response: response, expireAt: time.Now().Add(ttl)},
+	})
+}
+
+// invalidate removes the key in the tier of all replicas, should be called after the redis key is changed
+func (c *l1Cache[Y]) invalidate(ctx context.Context, key string) {
+	if c == nil {
+		return
+	}
+	c.remove(ctx, key)
+	if err := c.client.Publish(context.WithoutCancel(ctx), c.channel, key).Err(); err != nil {
+		_, logger := log.FromContext(ctx)
+		logger.With("key", key, "channel", c.channel).Warnf("rpc cache l1 publish invalidation failed: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sentioxyz/sentio-core/common/rpccache/compression"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func newTestReplica(
+	t *testing.T,
+	ctx context.Context,
+	server *miniredis.Miniredis,
+	config Config,
+) RpcCache[*MockRequest, *MockResponse] {
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	c, err := NewRpcCacheWithConfig[*MockRequest, *MockResponse](ctx, client, config)
+	require.NoError(t, err)
+	return c
+}
+
+func mockLoader(ctx context.Context, req *MockRequest, argv ...any) (*MockResponse, error) {
+	return &MockResponse{Data: req.Data}, nil
+}
+
+func Test_L1Hit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+	c := newTestReplica(t, ctx, server, Config{L1: &L1Config{Size: 10}})
+
+	request := NewMockRequest(1)
+	response, err := c.Query(ctx, request, mockLoader)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Data)
+
+	// read from redis and kept in l1
+	response, err = c.Query(ctx, request, mockLoader)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Data)
+
+	// l1 still has it after the redis key is gone without invalidation
+	server.Del(request.Key().String())
+	request.Data = 2
+	response, err = c.Query(ctx, request, mockLoader)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Data)
+	got, ok := c.Get(ctx, request, mockLoader)
+	assert.True(t, ok)
+	assert.Equal(t, 1, got.Data)
+
+	// the local delete invalidates l1
+	require.NoError(t, c.Delete(ctx, request))
+	response, err = c.Query(ctx, request, mockLoader)
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Data)
+}
+
+func Test_L1InvalidateAcrossReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+	a := newTestReplica(t, ctx, server, Config{L1: &L1Config{Size: 10}})
+	b := newTestReplica(t, ctx, server, Config{L1: &L1Config{Size: 10}})
+
+	request := NewMockRequest(1)
+	require.NoError(t, a.Set(ctx, request, &MockResponse{Data: 1}))
+	for _, c := range []RpcCache[*MockRequest, *MockResponse]{a, b} {
+		got, ok := c.Get(ctx, request, mockLoader)
+		require.True(t, ok)
+		assert.Equal(t, 1, got.Data)
+	}
+
+	require.NoError(t, b.Set(ctx, request, &MockResponse{Data: 2}))
+	assert.Eventually(t, func() bool {
+		got, ok := a.Get(ctx, request, mockLoader)
+		return ok && got.Data == 2
+	}, time.Second*3, time.Millisecond*10)
+
+	require.NoError(t, b.Delete(ctx, request))
+	assert.Eventually(t, func() bool {
+		_, ok := a.Get(ctx, request, mockLoader)
+		return !ok
+	}, time.Second*3, time.Millisecond*10)
+}
+
+func Test_L1Expire(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer func() {
+		_ = client.Close()
+	}()
+	l1, err := newL1Cache[*MockResponse](ctx, client, L1Config{Size: 10})
+	require.NoError(t, err)
+
+	key := NewMockRequest(1).Key()
+	l1.add(ctx, key, &MockResponse{Data: 1}, time.Millisecond*50, l1.version())
+	got, ok := l1.get(ctx, key)
+	require.True(t, ok)
+	assert.Equal(t, 1, got.Data)
+	time.Sleep(time.Millisecond * 100)
+	_, ok = l1.get(ctx, key)
+	assert.False(t, ok)
+
+	// read before an invalidation is not kept
+	version := l1.version()
+	l1.invalidate(ctx, key.String())
+	l1.add(ctx, key, &MockResponse{Data: 1}, time.Minute, version)
+	_, ok = l1.get(ctx, key)
+	assert.False(t, ok)
+}
+
+func Test_Codec(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	reader := newTestReplica(t, ctx, server, Config{})
+	for _, codec := range []compression.Codec{compression.Gzip, compression.Zstd, compression.Snappy, compression.None} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			writer := newTestReplica(t, ctx, server, Config{Codec: codec})
+			request := NewMockRequest(7)
+			require.NoError(t, writer.Set(ctx, request, &MockResponse{Data: 7}))
+
+			raw, err := server.Get(request.Key().String())
+			require.NoError(t, err)
+			var cr cachedResponse[*MockResponse]
+			require.NoError(t, msgpack.Unmarshal([]byte(raw), &cr))
+			if codec == compression.Gzip {
+				// readable by the versions without codec
+				assert.Equal(t, "", cr.Codec)
+				decoded, decodeErr := compression.Decode[*MockResponse](cr.Body)
+				require.NoError(t, decodeErr)
+				assert.Equal(t, 7, (*decoded).Data)
+			} else {
+				assert.Equal(t, codec.Name(), cr.Codec)
+			}
+
+			got, ok := reader.Get(ctx, request, mockLoader)
+			require.True(t, ok)
+			assert.Equal(t, 7, got.Data)
+		})
+	}
+}
@@ -17,6 +17,8 @@ var refreshBgKey = ctxKey{}
 type cachedResponse[Y Response] struct {
 	Body      string
 	Timestamp int64
+	// Codec is the name of the compression.Codec of Body, empty means compression.Gzip
+	Codec string `msgpack:",omitempty"`
 }
 
 func unmarshalCachedResponse[Y Response](data []byte) (*cachedResponse[Y], error) {
@@ -27,21 +29,33 @@ func unmarshalCachedResponse[Y Response](data []byte) (*cachedResponse[Y], error
 	return resp, nil
 }
 
-func newCachedResponse[Y Response](logger *log.SentioLogger, response Y) (*cachedResponse[Y], error) {
-	bytes, err := compression.Encode[Y](&response)
+func newCachedResponse[Y Response](
+	logger *log.SentioLogger,
+	codec compression.Codec,
+	response Y,
+) (*cachedResponse[Y], error) {
+	bytes, err := compression.EncodeWith[Y](codec, &response)
 	if err != nil {
 		logger.Warnf("rpc cache response encode failed: %v", err)
 		return nil, err
 	}
-	return &cachedResponse[Y]{Body: bytes, Timestamp: time.Now().UnixMilli()}, nil
+	cr := &cachedResponse[Y]{Body: bytes, Timestamp: time.Now().UnixMilli()}
+	if codec != compression.Gzip {
+		// keep the data encoded by gzip readable by the versions without codec
+		cr.Codec = codec.Name()
+	}
+	return cr, nil
 }
 
 func (r *cachedResponse[Y]) Response() (resp *Y, err error) {
 	if r == nil {
 		return nil, errors.Errorf("nil cached response")
 	}
-	resp, err = compression.Decode[Y](r.Body)
-	return
+	codec, err := compression.CodecByName(r.Codec)
+	if err != nil {
+		return nil, err
+	}
+	return compression.DecodeWith[Y](codec, r.Body)
 }
 
 func (r *cachedResponse[Y]) Marshal() ([]byte, error) {
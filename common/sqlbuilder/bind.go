@@ -0,0 +1,342 @@
+package builder
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrPlaceholderInLiteral = errors.New("placeholder inside string literal or comment")
+	ErrUnsupportedValue     = errors.New("unsupported parameter value")
+)
+
+// Raw is a parameter value which is put into the SQL as it is by BindSQLTemplate.
+// It is the explicit opt-in of the plain substitution, never use it for user input.
+type Raw string
+
+// Identifier is a parameter value which is bound as a quoted ClickHouse identifier, like a table or column name
+type Identifier string
+
+// decimalMaxPrecision is the precision of Decimal256
+const decimalMaxPrecision = 76
+
+// QuoteString returns the ClickHouse string literal of s
+func QuoteString(s string) string {
+	return quote(s, '\'')
+}
+
+// QuoteIdentifier returns the back-quoted ClickHouse identifier of s
+func QuoteIdentifier(s string) string {
+	return quote(s, '`')
+}
+
+func quote(s string, q byte) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte(q)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' || c == q:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == 0:
+			b.WriteString(`\0`)
+		case c < 0x20 || c == 0x7f:
+			_, _ = fmt.Fprintf(&b, `\x%02X`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(q)
+	return b.String()
+}
+
+// wrapNegative puts negative numbers into parentheses, so that they will not be merged with the
+// previous minus sign into a comment, like `a-{v}` with v=-1
+func wrapNegative(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "(-inf)"
+	}
+	return wrapNegative(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+func formatBigInt(x *big.Int) (string, error) {
+	switch {
+	case x.IsInt64() || x.IsUint64():
+		return wrapNegative(x.String()), nil
+	case x.BitLen() < 256:
+		return fmt.Sprintf("toInt256('%s')", x.String()), nil
+	case x.BitLen() == 256 && x.Sign() > 0:
+		return fmt.Sprintf("toUInt256('%s')", x.String()), nil
+	}
+	return "", errors.Wrapf(ErrUnsupportedValue, "integer %s out of the range of Int256 and UInt256", x.String())
+}
+
+func formatDecimal(d decimal.Decimal) (string, error) {
+	scale := max(-d.Exponent(), 0)
+	fixed := d.StringFixed(scale)
+	if digits := len(strings.TrimLeft(strings.ReplaceAll(fixed, ".", ""), "-")); digits > decimalMaxPrecision {
+		return "", errors.Wrapf(ErrUnsupportedValue, "decimal %s has %d digits, more than Decimal256", fixed, digits)
+	}
+	return fmt.Sprintf("toDecimal256('%s', %d)", fixed, scale), nil
+}
+
+func formatTime(t time.Time) string {
+	t = t.UTC()
+	if t.Nanosecond() == 0 {
+		return fmt.Sprintf("toDateTime('%s', 'UTC')", t.Format(time.DateTime))
+	}
+	return fmt.Sprintf("toDateTime64('%s', 9, 'UTC')", t.Format("2006-01-02 15:04:05.000000000"))
+}
+
+// FormatLiteral returns the ClickHouse literal of v.
+//
+// Strings and []byte are quoted and escaped, integers, floats, big.Int and decimal.Decimal are formatted as
+// numbers (with conversion functions if out of the range of the plain number literals), time.Time is
+// formatted as DateTime or DateTime64 in UTC, slices and arrays are formatted as arrays, nil and nil
+// pointers are NULL. Identifier and Raw are formatted as described in their comments.
+func FormatLiteral(v any) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case Raw:
+		return string(x), nil
+	case Identifier:
+		if x == "" {
+			return "", errors.Wrapf(ErrUnsupportedValue, "empty identifier")
+		}
+		return QuoteIdentifier(string(x)), nil
+	case []byte:
+		return QuoteString(string(x)), nil
+	case big.Int:
+		return formatBigInt(&x)
+	case *big.Int:
+		if x == nil {
+			return "NULL", nil
+		}
+		return formatBigInt(x)
+	case decimal.Decimal:
+		return formatDecimal(x)
+	case time.Time:
+		return formatTime(x), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return QuoteString(rv.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return wrapNegative(strconv.FormatInt(rv.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return formatFloat(rv.Float(), 32), nil
+	case reflect.Float64:
+		return formatFloat(rv.Float(), 64), nil
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return FormatLiteral(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, rv.Len())
+		for i := range items {
+			item, err := FormatLiteral(rv.Index(i).Interface())
+			if err != nil {
+				return "", errors.Wrapf(err, "format item %d failed", i)
+			}
+			items[i] = item
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", errors.Wrapf(ErrUnsupportedValue, "type %T", v)
+}
+
+type scanState int
+
+const (
+	scanCode scanState = iota
+	scanString
+	scanQuotedIdentifier
+	scanBackQuotedIdentifier
+	scanLineComment
+	scanBlockComment
+)
+
+func (s scanState) String() string {
+	switch s {
+	case scanString:
+		return "string literal"
+	case scanQuotedIdentifier, scanBackQuotedIdentifier:
+		return "quoted identifier"
+	case scanLineComment, scanBlockComment:
+		return "comment"
+	default:
+		return "code"
+	}
+}
+
+func (s scanState) quote() byte {
+	switch s {
+	case scanString:
+		return '\''
+	case scanQuotedIdentifier:
+		return '"'
+	case scanBackQuotedIdentifier:
+		return '`'
+	default:
+		return 0
+	}
+}
+
+type placeholder struct {
+	token string
+	name  string
+	// suffixless placeholder must not be followed by an identifier character, so `$a` does not match `$ab`
+	suffixless bool
+}
+
+func (p placeholder) matchAt(s string, i int) bool {
+	if !strings.HasPrefix(s[i:], p.token) {
+		return false
+	}
+	if end := i + len(p.token); p.suffixless && end < len(s) {
+		c := s[end]
+		return !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z')
+	}
+	return true
+}
+
+// BindSQLTemplate is the safe version of FormatSQLTemplate, it replaces the placeholders with the
+// ClickHouse literals of the parameter values formatted by FormatLiteral instead of their plain string.
+// Use Raw for the values which should be substituted as they are, like SQL fragments built by the caller.
+//
+// Placeholders of known parameters inside string literals, quoted identifiers or comments are rejected
+// with ErrPlaceholderInLiteral, placeholders of unknown parameters are left unchanged.
+// If a key exists in both the RichStruct and context, the one in context wins.
+func BindSQLTemplate(sqlTemplate string, context map[string]any, opts ...FormatOption) (string, error) {
+	opt := &FormatOption{}
+	opt.Merge(opts...)
+
+	prefixList, suffixList := opt.parameterPrefixList, opt.parameterSuffixList
+	if len(prefixList) == 0 || len(suffixList) == 0 {
+		prefixList, suffixList = []string{defaultPrefix}, []string{defaultSuffix}
+	}
+
+	values := make(map[string]any, len(opt.typedParameter)+len(context))
+	maps.Copy(values, opt.typedParameter)
+	maps.Copy(values, context)
+
+	placeholders := make([]placeholder, 0, len(values)*len(prefixList))
+	for name := range values {
+		for i := range prefixList {
+			placeholders = append(placeholders, placeholder{
+				token:      prefixList[i] + name + suffixList[i],
+				name:       name,
+				suffixless: suffixList[i] == "",
+			})
+		}
+	}
+	// longer placeholders first, like FormatSQLTemplate
+	sort.Slice(placeholders, func(i, j int) bool {
+		if len(placeholders[i].token) != len(placeholders[j].token) {
+			return len(placeholders[i].token) > len(placeholders[j].token)
+		}
+		return placeholders[i].token < placeholders[j].token
+	})
+
+	literals := make(map[string]string)
+	var out strings.Builder
+	out.Grow(len(sqlTemplate))
+	state := scanCode
+	for i := 0; i < len(sqlTemplate); {
+		var matched *placeholder
+		for k := range placeholders {
+			if placeholders[k].matchAt(sqlTemplate, i) {
+				matched = &placeholders[k]
+				break
+			}
+		}
+		if matched != nil {
+			if state != scanCode {
+				return "", errors.Wrapf(ErrPlaceholderInLiteral, "placeholder %s at offset %d is inside %s",
+					matched.token, i, state)
+			}
+			lit, has := literals[matched.name]
+			if !has {
+				var err error
+				if lit, err = FormatLiteral(values[matched.name]); err != nil {
+					return "", errors.Wrapf(err, "bind parameter %s failed", matched.name)
+				}
+				literals[matched.name] = lit
+			}
+			out.WriteString(lit)
+			i += len(matched.token)
+			continue
+		}
+
+		c, n := sqlTemplate[i], 1
+		switch state {
+		case scanCode:
+			switch {
+			case c == '\'':
+				state = scanString
+			case c == '"':
+				state = scanQuotedIdentifier
+			case c == '`':
+				state = scanBackQuotedIdentifier
+			case c == '#' || strings.HasPrefix(sqlTemplate[i:], "--"):
+				state = scanLineComment
+			case strings.HasPrefix(sqlTemplate[i:], "/*"):
+				state, n = scanBlockComment, 2
+			}
+		case scanString, scanQuotedIdentifier, scanBackQuotedIdentifier:
+			switch {
+			case c == '\\' && i+1 < len(sqlTemplate):
+				n = 2
+			case c == state.quote():
+				state = scanCode
+			}
+		case scanLineComment:
+			if c == '\n' {
+				state = scanCode
+			}
+		case scanBlockComment:
+			if strings.HasPrefix(sqlTemplate[i:], "*/") {
+				state, n = scanCode, 2
+			}
+		}
+		out.WriteString(sqlTemplate[i : i+n])
+		i += n
+	}
+	return out.String(), nil
+}
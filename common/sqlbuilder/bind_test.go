@@ -0,0 +1,463 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	protoscommon "sentioxyz/sentio-core/service/common/protos"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// number is a parsed number literal
+type number string
+
+// call is a parsed function call, like toDateTime('2020-01-01 00:00:00', 'UTC')
+type call struct {
+	fn   string
+	args []any
+}
+
+// readQuoted reads a quoted string following the ClickHouse escape rules
+func readQuoted(s string) (string, string, error) {
+	q := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == q && i+1 < len(s) && s[i+1] == q:
+			b.WriteByte(q)
+			i++
+		case c == q:
+			return b.String(), s[i+1:], nil
+		case c == '\\':
+			if i+1 >= len(s) {
+				return "", "", errors.New("unterminated escape")
+			}
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '0':
+				b.WriteByte(0)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'x':
+				if i+2 >= len(s) {
+					return "", "", errors.New("invalid \\x escape")
+				}
+				v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+				if err != nil {
+					return "", "", err
+				}
+				b.WriteByte(byte(v))
+				i += 2
+			default:
+				b.WriteByte(e)
+			}
+		case c == '\n' || c == '\r':
+			return "", "", errors.New("unescaped line break")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated quoted string")
+}
+
+// readLiteral reads one literal generated by FormatLiteral from the head of s and returns the rest
+func readLiteral(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", errors.New("empty")
+	case strings.HasPrefix(s, "NULL"):
+		return nil, s[4:], nil
+	case strings.HasPrefix(s, "true"):
+		return true, s[4:], nil
+	case strings.HasPrefix(s, "false"):
+		return false, s[5:], nil
+	case strings.HasPrefix(s, "nan"):
+		return number("nan"), s[3:], nil
+	case strings.HasPrefix(s, "inf"):
+		return number("inf"), s[3:], nil
+	case s[0] == '\'':
+		return readQuoted(s)
+	case s[0] == '`':
+		v, rest, err := readQuoted(s)
+		return Identifier(v), rest, err
+	case s[0] == '(':
+		v, rest, err := readLiteral(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		n, ok := v.(number)
+		if !ok || !strings.HasPrefix(string(n), "-") || !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("unexpected parenthesized literal %q", s)
+		}
+		return n, rest[1:], nil
+	case s[0] == '-' || s[0] >= '0' && s[0] <= '9':
+		end := 1
+		for end < len(s) && strings.IndexByte("0123456789.e+-infa", s[end]) >= 0 {
+			end++
+		}
+		return number(s[:end]), s[end:], nil
+	case s[0] == '[':
+		items := []any{}
+		rest := s[1:]
+		for !strings.HasPrefix(rest, "]") {
+			if len(items) > 0 {
+				if !strings.HasPrefix(rest, ", ") {
+					return nil, "", fmt.Errorf("missing separator in %q", s)
+				}
+				rest = rest[2:]
+			}
+			item, r, err := readLiteral(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			items, rest = append(items, item), r
+		}
+		return items, rest[1:], nil
+	}
+	open := strings.IndexByte(s, '(')
+	if open < 0 {
+		return nil, "", fmt.Errorf("unknown literal %q", s)
+	}
+	c := call{fn: s[:open]}
+	rest := s[open+1:]
+	for !strings.HasPrefix(rest, ")") {
+		if len(c.args) > 0 {
+			if !strings.HasPrefix(rest, ", ") {
+				return nil, "", fmt.Errorf("missing separator in %q", s)
+			}
+			rest = rest[2:]
+		}
+		arg, r, err := readLiteral(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		c.args, rest = append(c.args, arg), r
+	}
+	return c, rest[1:], nil
+}
+
+func parseLiteral(lit string) (any, error) {
+	v, rest, err := readLiteral(lit)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected tail %q of %q", rest, lit)
+	}
+	return v, nil
+}
+
+// sqlString is a string generator biased to the characters meaningful in SQL
+type sqlString string
+
+func (sqlString) Generate(r *rand.Rand, size int) reflect.Value {
+	pieces := []string{"'", "''", `\`, `\'`, `"`, "`", "\n", "\r", "\t", "\x00", "\x7f", "\xff", "--", "#",
+		"/*", "*/", "{v}", "$v", ")", ";", "a", "Z", "0", " ", "é", "中"}
+	var b strings.Builder
+	for n := r.Intn(size + 1); n > 0; n-- {
+		b.WriteString(pieces[r.Intn(len(pieces))])
+	}
+	return reflect.ValueOf(sqlString(b.String()))
+}
+
+func checkProperty(t *testing.T, name string, f any) {
+	t.Helper()
+	if err := quick.Check(f, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Errorf("%s: %v", name, err)
+	}
+}
+
+func Test_FormatLiteral_RoundTrip(t *testing.T) {
+	checkProperty(t, "string", func(s sqlString) bool {
+		lit, err := FormatLiteral(string(s))
+		v, perr := parseLiteral(lit)
+		return err == nil && perr == nil && v == string(s)
+	})
+	checkProperty(t, "bytes", func(bs []byte) bool {
+		lit, err := FormatLiteral(bs)
+		v, perr := parseLiteral(lit)
+		return err == nil && perr == nil && v == string(bs)
+	})
+	checkProperty(t, "identifier", func(s sqlString) bool {
+		lit, err := FormatLiteral(Identifier(s))
+		if s == "" {
+			return err != nil
+		}
+		v, perr := parseLiteral(lit)
+		return err == nil && perr == nil && v == Identifier(s)
+	})
+	checkProperty(t, "int64", func(x int64) bool {
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		n, ok := v.(number)
+		y, cerr := strconv.ParseInt(string(n), 10, 64)
+		return ok && cerr == nil && x == y
+	})
+	checkProperty(t, "uint64", func(x uint64) bool {
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		n, ok := v.(number)
+		y, cerr := strconv.ParseUint(string(n), 10, 64)
+		return ok && cerr == nil && x == y
+	})
+	checkProperty(t, "float64", func(x float64, special uint8) bool {
+		switch special % 8 {
+		case 0:
+			x = math.NaN()
+		case 1:
+			x = math.Inf(1)
+		case 2:
+			x = math.Inf(-1)
+		case 3:
+			x = -x
+		}
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		n, ok := v.(number)
+		y, cerr := strconv.ParseFloat(string(n), 64)
+		return ok && cerr == nil && (x == y || math.IsNaN(x) && math.IsNaN(y))
+	})
+	checkProperty(t, "bool", func(x bool) bool {
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		return err == nil && perr == nil && v == x
+	})
+	checkProperty(t, "big.Int", func(bs []byte, negative bool) bool {
+		var x big.Int
+		x.SetBytes(bs[:min(len(bs), 32)])
+		if negative && x.BitLen() < 256 {
+			x.Neg(&x)
+		}
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		var text string
+		switch v := v.(type) {
+		case number:
+			text = string(v)
+		case call:
+			if len(v.args) != 1 || v.fn != "toInt256" && v.fn != "toUInt256" {
+				return false
+			}
+			text, _ = v.args[0].(string)
+		}
+		y, ok := new(big.Int).SetString(text, 10)
+		return ok && x.Cmp(y) == 0
+	})
+	checkProperty(t, "decimal", func(coef int64, exp int8) bool {
+		x := decimal.New(coef, int32(exp%40))
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		c, ok := v.(call)
+		if !ok || c.fn != "toDecimal256" || len(c.args) != 2 {
+			return false
+		}
+		text, _ := c.args[0].(string)
+		y, derr := decimal.NewFromString(text)
+		scale, _ := c.args[1].(number)
+		return derr == nil && x.Equal(y) && string(scale) == strconv.Itoa(int(max(-x.Exponent(), 0)))
+	})
+	checkProperty(t, "time", func(sec uint32, nsec uint32, hasNsec bool) bool {
+		x := time.Unix(int64(sec), 0).In(time.FixedZone("X", 3600))
+		if hasNsec {
+			x = x.Add(time.Duration(nsec % 1e9))
+		}
+		lit, err := FormatLiteral(x)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		c, ok := v.(call)
+		if !ok || len(c.args) < 2 || c.args[len(c.args)-1] != "UTC" {
+			return false
+		}
+		text, _ := c.args[0].(string)
+		y, terr := time.Parse("2006-01-02 15:04:05.999999999", text)
+		return terr == nil && x.Equal(y)
+	})
+	checkProperty(t, "array", func(ss []sqlString) bool {
+		lit, err := FormatLiteral(ss)
+		v, perr := parseLiteral(lit)
+		if err != nil || perr != nil {
+			return false
+		}
+		items, ok := v.([]any)
+		if !ok || len(items) != len(ss) {
+			return false
+		}
+		for i := range ss {
+			if items[i] != string(ss[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func Test_BindSQLTemplate_Property(t *testing.T) {
+	// whatever the value is, the bound literal takes exactly the place of the placeholder
+	const tail = ", 'lit' -- {x}"
+	checkProperty(t, "bound", func(s sqlString, x int64, cut bool) bool {
+		var value any = string(s)
+		if cut {
+			value = x
+		}
+		sql, err := BindSQLTemplate("select {v}, 'lit' -- {x}", map[string]any{"v": value})
+		if err != nil || !strings.HasPrefix(sql, "select ") {
+			return false
+		}
+		lit, rest, err := readLiteral(strings.TrimPrefix(sql, "select "))
+		if err != nil || rest != tail {
+			return false
+		}
+		if n, ok := lit.(number); ok {
+			return string(n) == strconv.FormatInt(x, 10)
+		}
+		return lit == string(s)
+	})
+}
+
+func Test_BindSQLTemplate(t *testing.T) {
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	richStruct := WithRichStructParameter(&protoscommon.RichStruct{
+		Fields: map[string]*protoscommon.RichValue{
+			"start_time": {Value: &protoscommon.RichValue_TimestampValue{TimestampValue: timestamppb.New(ts)}},
+			"name":       {Value: &protoscommon.RichValue_StringValue{StringValue: "x' or 1=1 --"}},
+		},
+	})
+	tests := []struct {
+		name        string
+		sqlTemplate string
+		args        map[string]any
+		options     []FormatOption
+		expected    string
+		err         error
+	}{
+		{
+			name:        "typed values",
+			sqlTemplate: "select {column} from {table} where a = {a} and b in {b} and c - {c} > 0 and d = {d} and e = {e}",
+			args: map[string]any{
+				"column": Identifier("id"),
+				"table":  Raw("db.users"),
+				"a":      "it's",
+				"b":      []int{1, 2},
+				"c":      -1,
+				"d":      nil,
+				"e":      decimal.RequireFromString("-1.50"),
+			},
+			expected: "select `id` from db.users where a = 'it\\'s' and b in [1, 2] and c - (-1) > 0 and d = NULL " +
+				"and e = toDecimal256('-1.50', 2)",
+		},
+		{
+			name:        "rich struct",
+			sqlTemplate: "select * from t where ts >= $start_time and name = $name and id = $missing",
+			options:     []FormatOption{WithParameterIdentity("$", ""), richStruct},
+			expected: "select * from t where ts >= toDateTime('2020-01-01 00:00:00', 'UTC') and " +
+				"name = 'x\\' or 1=1 --' and id = $missing",
+		},
+		{
+			name:        "context overrides rich struct",
+			sqlTemplate: "select {name}",
+			args:        map[string]any{"name": "y"},
+			options:     []FormatOption{richStruct},
+			expected:    "select 'y'",
+		},
+		{
+			name:        "suffixless placeholder is not a prefix of a longer name",
+			sqlTemplate: "select $a, $ab, ${a}, $a.b",
+			args:        map[string]any{"a": 1},
+			options:     []FormatOption{WithParameterIdentity("$", ""), WithParameterIdentity("${", "}")},
+			expected:    "select 1, $ab, 1, 1.b",
+		},
+		{
+			name:        "unknown placeholders in literals and comments are kept",
+			sqlTemplate: "select '{x}', `{x}` /* {x} */ -- {x}\n, {a}",
+			args:        map[string]any{"a": 1},
+			expected:    "select '{x}', `{x}` /* {x} */ -- {x}\n, 1",
+		},
+		{
+			name:        "escaped quote in string literal",
+			sqlTemplate: `select 'a\'{a}' , 'b''c', {a}`,
+			args:        map[string]any{"a": 1},
+			err:         ErrPlaceholderInLiteral,
+		},
+		{
+			name:        "placeholder in string literal",
+			sqlTemplate: "select * from t where name = '{a}'",
+			args:        map[string]any{"a": "x"},
+			err:         ErrPlaceholderInLiteral,
+		},
+		{
+			name:        "placeholder in quoted identifier",
+			sqlTemplate: `select "{a}" from t`,
+			args:        map[string]any{"a": "x"},
+			err:         ErrPlaceholderInLiteral,
+		},
+		{
+			name:        "placeholder in line comment",
+			sqlTemplate: "select 1 # {a}\n",
+			args:        map[string]any{"a": "x"},
+			err:         ErrPlaceholderInLiteral,
+		},
+		{
+			name:        "placeholder in block comment",
+			sqlTemplate: "select 1 /* {a} */",
+			args:        map[string]any{"a": "x"},
+			err:         ErrPlaceholderInLiteral,
+		},
+		{
+			name:        "unsupported value",
+			sqlTemplate: "select {a}",
+			args:        map[string]any{"a": map[string]int{}},
+			err:         ErrUnsupportedValue,
+		},
+	}
+	for _, tt := range tests {
+		actual, err := BindSQLTemplate(tt.sqlTemplate, tt.args, tt.options...)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		} else if actual != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, actual)
+		}
+	}
+}
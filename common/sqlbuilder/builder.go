@@ -49,6 +49,8 @@ type FormatOption struct {
 
 	richStructParameter *protoscommon.RichStruct
 	parsedParameter     map[string]any
+	// typedParameter is the same as parsedParameter, but keeps the timestamps as time.Time for BindSQLTemplate
+	typedParameter map[string]any
 }
 
 func (f *FormatOption) Merge(opts ...FormatOption) {
@@ -60,6 +62,7 @@ func (f *FormatOption) Merge(opts ...FormatOption) {
 		case richStruct:
 			f.richStructParameter = opt.richStructParameter
 			f.parsedParameter = opt.parsedParameter
+			f.typedParameter = opt.typedParameter
 		}
 	}
 }
@@ -74,6 +77,7 @@ func WithParameterIdentity(prefix, suffix string) FormatOption {
 
 func WithRichStructParameter(richStructParameter *protoscommon.RichStruct) FormatOption {
 	m := make(map[string]any)
+	typed := make(map[string]any)
 
 	for k, v := range richStructParameter.GetFields() {
 		switch v.Value.(type) {
@@ -98,16 +102,20 @@ func WithRichStructParameter(richStructParameter *protoscommon.RichStruct) Forma
 		case *protoscommon.RichValue_TimestampValue:
 			t := v.GetTimestampValue().AsTime().UTC()
 			m[k] = fmt.Sprintf("toDateTime('%s', 'UTC')", t.Format("2006-01-02 15:04:05"))
+			typed[k] = t
+			continue
 		default:
 			log.Warnf("unsupported rich value type: %T", v.Value)
 			continue
 		}
+		typed[k] = m[k]
 	}
 
 	return FormatOption{
 		OptID:               richStruct,
 		richStructParameter: richStructParameter,
 		parsedParameter:     m,
+		typedParameter:      typed,
 	}
 }
 
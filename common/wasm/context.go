@@ -19,6 +19,8 @@ type CallStat struct {
 	ExportFuncCalled   map[string]uint
 	ImportFuncCalled   map[string]uint
 	ImportFuncCallUsed map[string]time.Duration
+	// GasUsed is the gas used by the calls entering the instance, zero if the metering is disabled
+	GasUsed uint64
 }
 
 func (s *CallStat) incExportFunc(name string) {
@@ -3,6 +3,7 @@ package wasm
 import (
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"time"
 
@@ -65,14 +66,17 @@ type CallResult struct {
 	ImportFuncCalled   map[string]uint
 	ImportFuncCallUsed map[string]time.Duration
 	MemoryUsed         uint32 // Include only the main module's memory usage
+	GasUsed            uint64 // Zero if the instruction metering is disabled
 }
 
 var (
 	ErrPrepareCallExportFunc = errors.New("prepare call export function failed")
 	ErrPanic                 = errors.New("panic in wasm")
+	ErrOutOfGas              = errors.New("out of gas")
 )
 
-// CallExportFunction error returned may be an ErrCallingImportFunc or wrapped ErrPrepareCallExportFunc or ErrPanic
+// CallExportFunction error returned may be an ErrCallingImportFunc or wrapped ErrPrepareCallExportFunc, ErrPanic
+// or ErrOutOfGas
 func (inst *Instance[DATA]) CallExportFunction(
 	ctx *CallContext[DATA],
 	params CallParams[DATA],
@@ -129,6 +133,18 @@ func (inst *Instance[DATA]) CallExportFunction(
 	inst.callExportFuncDebugLog(fmt.Sprintf("arg:%v", nativeArgs))
 	inst.debugShowMemoryReview()
 
+	// each call entering the instance has the whole gas limit, the calls made by the host outside
+	// of it, such as allocating memory for the args and the return values, are not limited
+	metered := inst.gasGlobal != nil && calling.EnterInstance
+	if metered {
+		if err = inst.setGasLeft(int64(inst.gasLimit)); err != nil {
+			err = fmt.Errorf("%w: %w", ErrPrepareCallExportFunc, err)
+			ctx.stack.pop()
+			inst.callCtx = nil
+			return
+		}
+	}
+
 	// call export function
 	var nativeReturn any
 	nativeReturn, err = nativeFunc(nativeArgs...)
@@ -140,6 +156,23 @@ func (inst *Instance[DATA]) CallExportFunction(
 			err = fmt.Errorf("%w: %s", ErrPanic, trapErr.Error())
 		}
 	}
+	if metered {
+		left, getErr := inst.gasLeft()
+		if getErr != nil && err == nil {
+			err = getErr
+		}
+		if left < 0 {
+			calling.GasUsed = inst.gasLimit
+			if err != nil {
+				err = fmt.Errorf("%w: %s used more than %d gas: %w", ErrOutOfGas, fullName, inst.gasLimit, err)
+			}
+		} else {
+			calling.GasUsed = inst.gasLimit - uint64(left)
+		}
+		if setErr := inst.setGasLeft(math.MaxInt64); setErr != nil && err == nil {
+			err = setErr
+		}
+	}
 	inst.memoryMgr.setMemoryUsed()
 
 	// prepare return objects
@@ -165,6 +198,7 @@ func (inst *Instance[DATA]) CallExportFunction(
 	result.ImportFuncCalled = calling.ImportFuncCalled
 	result.ImportFuncCallUsed = calling.ImportFuncCallUsed
 	result.MemoryUsed = inst.memoryMgr.memoryUsed - initMemoryUsed
+	result.GasUsed = calling.GasUsed
 	inst.exportFuncCalled++
 
 	if err == nil {
@@ -183,6 +217,10 @@ func (inst *Instance[DATA]) CallExportFunction(
 		top := ctx.stack.top()
 		top.mergeFrom(&calling.CallStat)
 		top.incExportFunc(fullName)
+		if calling.EnterInstance {
+			// gas of the nested calls in the same instance is already counted by the outer call
+			top.GasUsed += calling.GasUsed
+		}
 	}
 
 	return
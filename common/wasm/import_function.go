@@ -93,6 +93,7 @@ func (inst *Instance[DATA]) newImportBox(namespace, name string, fn any) (box im
 			}
 			top.CallingImportFunc = ""
 		}()
+		inst.chargeImportFunc()
 		callArgs := make([]reflect.Value, ft.NumIn())
 		callArgs[0] = reflect.ValueOf(inst.callCtx) // first arg of fn must be *CallContext[DATA]
 		for i := 1; i < ft.NumIn(); i++ {
@@ -2,7 +2,9 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/wasmerio/wasmer-go/wasmer"
@@ -16,6 +18,7 @@ type Instance[DATA fmt.Stringer] struct {
 	name         string
 	modBytes     []byte
 	memHardLimit uint32
+	gasLimit     uint64
 
 	exportDefTable   map[string]reflect.Type
 	importDefTable   map[string]map[string]importBox
@@ -23,12 +26,18 @@ type Instance[DATA fmt.Stringer] struct {
 	initialed        bool
 	exportFuncCalled uint
 	resetCounter     uint
+	restoreCounter   uint
+
+	initSnapshotEnabled bool
+	snapshotGlobals     []string
+	initSnapshot        *initSnapshot
 
 	store        *wasmer.Store
 	module       *wasmer.Module
 	instance     *wasmer.Instance
 	exportedFunc map[string]wasmer.NativeFunction
 	memoryMgr    *MemoryManager
+	gasGlobal    *wasmer.Global
 
 	callCtx *CallContext[DATA]
 }
@@ -57,7 +66,11 @@ func (inst *Instance[DATA]) Init(logger *log.SentioLogger) error {
 	}
 	var err error
 	if inst.module == nil {
-		inst.module, err = wasmer.NewModule(inst.store, inst.modBytes)
+		var modBytes []byte
+		if modBytes, err = inst.rewriteModule(logger); err != nil {
+			return err
+		}
+		inst.module, err = wasmer.NewModule(inst.store, modBytes)
 		if err != nil {
 			return fmt.Errorf("new wasm module failed: %w", err)
 		}
@@ -77,6 +90,65 @@ func (inst *Instance[DATA]) Init(logger *log.SentioLogger) error {
 		return err
 	}
 
+	// get gas global, the start function is not limited
+	if inst.gasLimit > 0 {
+		if inst.gasGlobal, err = inst.instance.Exports.GetGlobal(GasGlobalExportName); err != nil {
+			return fmt.Errorf("get gas global failed: %w", err)
+		}
+		if err = inst.setGasLeft(math.MaxInt64); err != nil {
+			return err
+		}
+	}
+
+	if inst.initSnapshot != nil {
+		// no need to call start function
+		if err = inst.restoreInitSnapshot(); err != nil {
+			return fmt.Errorf("restore init snapshot failed: %w", err)
+		}
+		inst.restoreCounter++
+	} else {
+		if err = inst.callStart(logger); err != nil {
+			return err
+		}
+		if inst.initSnapshotEnabled {
+			if inst.initSnapshot, err = inst.takeInitSnapshot(); err != nil {
+				return fmt.Errorf("take init snapshot failed: %w", err)
+			}
+		}
+	}
+
+	// prepare for reserved mem
+	inst.memoryMgr.init()
+
+	inst.initialed = true
+	inst.exportFuncCalled = 0
+	return nil
+}
+
+// rewriteModule returns the module bytes rewritten as the enabled features need
+func (inst *Instance[DATA]) rewriteModule(logger *log.SentioLogger) (modBytes []byte, err error) {
+	modBytes = inst.modBytes
+	if inst.initSnapshotEnabled {
+		var rewritten []byte
+		rewritten, inst.snapshotGlobals, err = exportGlobals(modBytes)
+		if errors.Is(err, errInitSnapshotUnsupported) {
+			logger.Warnfe(err, "init snapshot is disabled for wasm instance %s", inst.name)
+			inst.initSnapshotEnabled = false
+		} else if err != nil {
+			return nil, fmt.Errorf("export globals of wasm module failed: %w", err)
+		} else {
+			modBytes = rewritten
+		}
+	}
+	if inst.gasLimit > 0 {
+		if modBytes, err = instrumentGas(modBytes); err != nil {
+			return nil, fmt.Errorf("instrument wasm module failed: %w", err)
+		}
+	}
+	return modBytes, nil
+}
+
+func (inst *Instance[DATA]) callStart(logger *log.SentioLogger) error {
 	// get start function
 	start, err := inst.instance.Exports.GetWasiStartFunction()
 	if err != nil {
@@ -102,12 +174,6 @@ func (inst *Instance[DATA]) Init(logger *log.SentioLogger) error {
 	if _, err = start(); err != nil {
 		return fmt.Errorf("call start function failed: %w", err)
 	}
-
-	// prepare for reserved mem
-	inst.memoryMgr.init()
-
-	inst.initialed = true
-	inst.exportFuncCalled = 0
 	return nil
 }
 
@@ -126,6 +192,9 @@ func (inst *Instance[DATA]) Close() {
 	}
 	inst.memoryMgr = nil
 	inst.exportedFunc = nil
+	inst.gasGlobal = nil
+	// the module will be created again, so will the snapshot
+	inst.initSnapshot = nil
 }
 
 func (inst *Instance[DATA]) Reset(logger *log.SentioLogger) error {
@@ -153,6 +222,7 @@ func (inst *Instance[DATA]) reset(logger *log.SentioLogger) error {
 	}
 	inst.memoryMgr = nil
 	inst.exportedFunc = nil
+	inst.gasGlobal = nil
 	logger.Infof("will reset wasm instance %s", inst.name)
 	inst.resetCounter++
 	return inst.Init(logger)
@@ -169,6 +239,14 @@ func (inst *Instance[DATA]) SetDebugLevel(level int) *Instance[DATA] {
 	return inst
 }
 
+// SetGasLimit enables the instruction metering, each call of an export function from outside the instance
+// can use up to limit gas, otherwise it will fail with ErrOutOfGas. Zero means no limit.
+// The module is instrumented in init, so metering can only be enabled before init.
+func (inst *Instance[DATA]) SetGasLimit(limit uint64) *Instance[DATA] {
+	inst.gasLimit = min(limit, math.MaxInt64)
+	return inst
+}
+
 func (inst *Instance[DATA]) callImportFuncDebugLog(msg string) {
 	if inst.debugLevel >= DebugLevelTrace {
 		inst.callCtx.Logger().AddCallerSkip(1).Infof("calling import function: %s", msg)
@@ -194,10 +272,17 @@ func (inst *Instance[DATA]) Snapshot() any {
 		"name":             inst.name,
 		"modBytesLen":      len(inst.modBytes),
 		"memHardLimit":     inst.memHardLimit,
+		"gasLimit":         inst.gasLimit,
 		"debugLevel":       inst.debugLevel,
 		"initialed":        inst.initialed,
 		"exportFuncCalled": inst.exportFuncCalled,
 		"resetCounter":     inst.resetCounter,
+		"restoreCounter":   inst.restoreCounter,
+		"initSnapshot":     inst.initSnapshotEnabled,
+	}
+	if snapshot := inst.initSnapshot; snapshot != nil {
+		sn["initSnapshotMemory"] = len(snapshot.memory)
+		sn["initSnapshotGlobals"] = len(snapshot.globals)
 	}
 	if memMgr := inst.memoryMgr; memMgr != nil {
 		sn["memoryUsedInitial"] = memMgr.memoryUsedInitial
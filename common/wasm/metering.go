@@ -0,0 +1,580 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+// GasGlobalExportName is the name of the exported global injected by instrumentGas, it holds the gas left.
+const GasGlobalExportName = "__sentio_gas_left"
+
+// ImportFuncGas is the gas charged for each call of an import function, the host work behind an import
+// function is not metered by instructions, so a fixed cost makes loops around import functions bounded too.
+const ImportFuncGas = 1000
+
+const (
+	sectionImport    = 2
+	sectionGlobal    = 6
+	sectionExport    = 7
+	sectionCode      = 10
+	sectionDataCount = 12
+	sectionTag       = 13
+
+	importKindFunc   = 0
+	importKindTable  = 1
+	importKindMemory = 2
+	importKindGlobal = 3
+	importKindTag    = 4
+
+	exportKindGlobal = 3
+
+	opBlock       = 0x02
+	opLoop        = 0x03
+	opIf          = 0x04
+	opElse        = 0x05
+	opEnd         = 0x0b
+	opTableSet    = 0x26
+	opPrefixFC    = 0xfc
+	opPrefixSIMD  = 0xfd
+	opPrefixAtoms = 0xfe
+)
+
+// sectionRank is the order of the known sections in a module
+var sectionRank = map[byte]int{
+	1: 1, sectionImport: 2, 3: 3, 4: 4, 5: 5, sectionTag: 6, sectionGlobal: 7, sectionExport: 8,
+	8: 9, 9: 10, sectionDataCount: 11, sectionCode: 12, 11: 13,
+}
+
+type wasmReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wasmReader) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.eof() {
+		return 0, fmt.Errorf("unexpected end at %d", r.pos)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *wasmReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("unexpected end at %d, need %d bytes", r.pos, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *wasmReader) u32() (uint32, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 || v > 0xffffffff {
+		return 0, fmt.Errorf("invalid u32 at %d", r.pos)
+	}
+	r.pos += n
+	return uint32(v), nil
+}
+
+// skipLEB skips a signed or unsigned LEB128 number, also works for the single byte immediates
+func (r *wasmReader) skipLEB() error {
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+}
+
+func (r *wasmReader) skipLEBs(n int) error {
+	for i := 0; i < n; i++ {
+		if err := r.skipLEB(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *wasmReader) skipName() error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	_, err = r.bytes(int(n))
+	return err
+}
+
+func (r *wasmReader) skipLimits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if err = r.skipLEB(); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		return r.skipLEB()
+	}
+	return nil
+}
+
+func (r *wasmReader) skipMemArg() error {
+	align, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if align&0x40 != 0 {
+		// multi-memory, memory index follows
+		if err = r.skipLEB(); err != nil {
+			return err
+		}
+	}
+	return r.skipLEB()
+}
+
+// skipValType skips a value type, the typed references have a heap type following
+func (r *wasmReader) skipValType() (byte, error) {
+	t, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	if t == 0x63 || t == 0x64 {
+		return t, r.skipLEB()
+	}
+	return t, nil
+}
+
+// skipLocals skips the local declarations at the beginning of a function body
+func (r *wasmReader) skipLocals() error {
+	groups, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < groups; i++ {
+		if err = r.skipLEB(); err != nil {
+			return err
+		}
+		if _, err = r.skipValType(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instruction reads an instruction and skips its immediates, sub is the sub opcode of the prefixed instructions
+func (r *wasmReader) instruction() (op byte, sub uint32, err error) {
+	if op, err = r.byte(); err != nil {
+		return
+	}
+	switch {
+	case op == opBlock || op == opLoop || op == opIf:
+		err = r.skipLEB() // block type
+	case op == 0x0c || op == 0x0d: // br br_if
+		err = r.skipLEB()
+	case op == 0x0e: // br_table
+		var n uint32
+		if n, err = r.u32(); err == nil {
+			err = r.skipLEBs(int(n) + 1)
+		}
+	case op == 0x10 || op == 0x12: // call return_call
+		err = r.skipLEB()
+	case op == 0x11 || op == 0x13: // call_indirect return_call_indirect
+		err = r.skipLEBs(2)
+	case op == 0x1c: // select t*
+		var n uint32
+		if n, err = r.u32(); err == nil {
+			err = r.skipLEBs(int(n))
+		}
+	case op >= 0x20 && op <= opTableSet: // local.* global.* table.get table.set
+		err = r.skipLEB()
+	case op >= 0x28 && op <= 0x3e: // load and store
+		err = r.skipMemArg()
+	case op == 0x3f || op == 0x40: // memory.size memory.grow
+		err = r.skipLEB()
+	case op == 0x41 || op == 0x42: // i32.const i64.const
+		err = r.skipLEB()
+	case op == 0x43: // f32.const
+		_, err = r.bytes(4)
+	case op == 0x44: // f64.const
+		_, err = r.bytes(8)
+	case op == 0xd0 || op == 0xd2: // ref.null ref.func
+		err = r.skipLEB()
+	case op == opPrefixFC:
+		sub, err = r.skipPrefixedFC()
+	case op == opPrefixSIMD:
+		sub, err = r.skipPrefixedFD()
+	case op == opPrefixAtoms:
+		sub, err = r.skipPrefixedFE()
+	case op <= 0x01, op == opElse, op == opEnd, op == 0x0f, op == 0x1a, op == 0x1b, op == 0xd1:
+	case op >= 0x45 && op <= 0xc4: // numeric instructions
+	default:
+		err = fmt.Errorf("unsupported opcode 0x%02x at %d", op, r.pos-1)
+	}
+	return
+}
+
+func (r *wasmReader) skipPrefixedFC() (uint32, error) {
+	sub, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case sub <= 7: // saturating truncation
+	case sub == 9 || sub == 11 || sub == 13 || sub >= 15 && sub <= 17: // data.drop memory.fill elem.drop table.*
+		err = r.skipLEB()
+	case sub == 8 || sub == 10 || sub == 12 || sub == 14: // memory.init memory.copy table.init table.copy
+		err = r.skipLEBs(2)
+	default:
+		err = fmt.Errorf("unsupported opcode 0xfc %d at %d", sub, r.pos)
+	}
+	return sub, err
+}
+
+func (r *wasmReader) skipPrefixedFD() (uint32, error) {
+	sub, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case sub <= 11 || sub == 92 || sub == 93: // v128 load and store
+		err = r.skipMemArg()
+	case sub == 12 || sub == 13: // v128.const i8x16.shuffle
+		_, err = r.bytes(16)
+	case sub >= 21 && sub <= 34: // extract_lane replace_lane
+		_, err = r.byte()
+	case sub >= 84 && sub <= 91: // load_lane store_lane
+		if err = r.skipMemArg(); err == nil {
+			_, err = r.byte()
+		}
+	}
+	return sub, err
+}
+
+func (r *wasmReader) skipPrefixedFE() (uint32, error) {
+	sub, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if sub == 3 { // atomic.fence
+		_, err = r.byte()
+	} else {
+		err = r.skipMemArg()
+	}
+	return sub, err
+}
+
+func appendU32(out []byte, v uint32) []byte {
+	return binary.AppendUvarint(out, uint64(v))
+}
+
+func appendS64(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendName(out []byte, name string) []byte {
+	return append(appendU32(out, uint32(len(name))), name...)
+}
+
+type wasmSection struct {
+	id      byte
+	payload []byte
+}
+
+type wasmModule struct {
+	header   []byte
+	sections []wasmSection
+}
+
+func parseWasmModule(mod []byte) (*wasmModule, error) {
+	if len(mod) < 8 || !bytes.Equal(mod[:4], []byte("\x00asm")) {
+		return nil, fmt.Errorf("invalid wasm module")
+	}
+	m := &wasmModule{header: mod[:8]}
+	r := &wasmReader{data: mod, pos: 8}
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		m.sections = append(m.sections, wasmSection{id: id, payload: payload})
+	}
+	return m, nil
+}
+
+func (m *wasmModule) encode() []byte {
+	size := len(m.header)
+	for _, s := range m.sections {
+		size += len(s.payload) + 6
+	}
+	out := append(make([]byte, 0, size), m.header...)
+	for _, s := range m.sections {
+		out = appendU32(append(out, s.id), uint32(len(s.payload)))
+		out = append(out, s.payload...)
+	}
+	return out
+}
+
+func (m *wasmModule) find(id byte) *wasmSection {
+	for i := range m.sections {
+		if m.sections[i].id == id {
+			return &m.sections[i]
+		}
+	}
+	return nil
+}
+
+// vector returns the count and the raw entries of a vector section, a missing section is an empty vector
+func (m *wasmModule) vector(id byte) (*wasmReader, uint32, error) {
+	s := m.find(id)
+	if s == nil {
+		return &wasmReader{}, 0, nil
+	}
+	r := &wasmReader{data: s.payload}
+	count, err := r.u32()
+	return r, count, err
+}
+
+// appendEntries appends entries to a vector section, the section is created at the right position if missing,
+// the index of the first appended entry in the section is returned
+func (m *wasmModule) appendEntries(id byte, entries ...[]byte) (uint32, error) {
+	r, count, err := m.vector(id)
+	if err != nil {
+		return 0, err
+	}
+	payload := appendU32(nil, count+uint32(len(entries)))
+	payload = append(payload, r.data[r.pos:]...)
+	for _, entry := range entries {
+		payload = append(payload, entry...)
+	}
+	if s := m.find(id); s != nil {
+		s.payload = payload
+		return count, nil
+	}
+	// insert the new section before the first known section ordered after it
+	pos := len(m.sections)
+	for i, s := range m.sections {
+		if rank, known := sectionRank[s.id]; known && rank > sectionRank[id] {
+			pos = i
+			break
+		}
+	}
+	m.sections = append(m.sections[:pos], append([]wasmSection{{id: id, payload: payload}}, m.sections[pos:]...)...)
+	return count, nil
+}
+
+func (m *wasmModule) importedGlobals() (n uint32, err error) {
+	r, count, err := m.vector(sectionImport)
+	if err != nil {
+		return 0, err
+	}
+	for i := uint32(0); i < count; i++ {
+		if err = r.skipName(); err != nil {
+			return 0, err
+		}
+		if err = r.skipName(); err != nil {
+			return 0, err
+		}
+		var kind byte
+		if kind, err = r.byte(); err != nil {
+			return 0, err
+		}
+		switch kind {
+		case importKindFunc:
+			err = r.skipLEB()
+		case importKindTable:
+			if _, err = r.skipValType(); err == nil {
+				err = r.skipLimits()
+			}
+		case importKindMemory:
+			err = r.skipLimits()
+		case importKindGlobal:
+			n++
+			if _, err = r.skipValType(); err == nil {
+				_, err = r.byte()
+			}
+		case importKindTag:
+			err = r.skipLEBs(2)
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// rewriteCode calls fn with the body of each function in the code section and replaces the body with the result
+func (m *wasmModule) rewriteCode(fn func(body []byte) ([]byte, error)) error {
+	s := m.find(sectionCode)
+	if s == nil {
+		return nil
+	}
+	r := &wasmReader{data: s.payload}
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	out := appendU32(make([]byte, 0, len(s.payload)), count)
+	for i := uint32(0); i < count; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		if body, err = fn(body); err != nil {
+			return fmt.Errorf("function #%d: %w", i, err)
+		}
+		out = append(appendU32(out, uint32(len(body))), body...)
+	}
+	s.payload = out
+	return nil
+}
+
+// appendCharge appends the instructions that subtract cost from the gas global and trap if it goes below zero
+func appendCharge(out []byte, gasIdx uint32, cost int64) []byte {
+	out = appendU32(append(out, 0x23), gasIdx) // global.get
+	out = appendS64(append(out, 0x42), cost)   // i64.const
+	out = append(out, 0x7d)                    // i64.sub
+	out = appendU32(append(out, 0x24), gasIdx) // global.set
+	out = appendU32(append(out, 0x23), gasIdx) // global.get
+	out = append(out, 0x42, 0x00)              // i64.const 0
+	out = append(out, 0x53)                    // i64.lt_s
+	out = append(out, opIf, 0x40)              // if
+	out = append(out, 0x00)                    // unreachable
+	out = append(out, opEnd)                   // end
+	return out
+}
+
+// instrumentBody splits the function body into segments ending with block, loop, if, else or end, and charges
+// each segment at its start with its instruction count. So every instruction costs one gas and a loop is charged
+// for every iteration.
+func instrumentBody(body []byte, gasIdx uint32) ([]byte, error) {
+	r := &wasmReader{data: body}
+	if err := r.skipLocals(); err != nil {
+		return nil, err
+	}
+	out := append(make([]byte, 0, len(body)*2), body[:r.pos]...)
+	segStart, count, depth := r.pos, int64(0), 1
+	for !r.eof() {
+		if depth == 0 {
+			return nil, fmt.Errorf("unexpected instructions after the end of function at %d", r.pos)
+		}
+		op, _, err := r.instruction()
+		if err != nil {
+			return nil, err
+		}
+		count++
+		switch op {
+		case opBlock, opLoop, opIf:
+			depth++
+		case opElse:
+		case opEnd:
+			depth--
+		default:
+			continue
+		}
+		out = appendCharge(out, gasIdx, count)
+		out = append(out, body[segStart:r.pos]...)
+		segStart, count = r.pos, 0
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("function body is not ended")
+	}
+	return out, nil
+}
+
+// instrumentGas injects instruction metering into the module. A mutable i64 global exported as
+// GasGlobalExportName holds the gas left, it is decreased as the instructions are executed, and the
+// execution traps once it goes below zero.
+func instrumentGas(mod []byte) ([]byte, error) {
+	m, err := parseWasmModule(mod)
+	if err != nil {
+		return nil, err
+	}
+	// the new global is appended after all imported and defined globals, so no index need to be shifted
+	imported, err := m.importedGlobals()
+	if err != nil {
+		return nil, fmt.Errorf("parse import section failed: %w", err)
+	}
+	// mutable i64 initialized to 0
+	defined, err := m.appendEntries(sectionGlobal, []byte{0x7e, 0x01, 0x42, 0x00, opEnd})
+	if err != nil {
+		return nil, fmt.Errorf("parse global section failed: %w", err)
+	}
+	gasIdx := imported + defined
+	exportEntry := appendU32(append(appendName(nil, GasGlobalExportName), exportKindGlobal), gasIdx)
+	if _, err = m.appendEntries(sectionExport, exportEntry); err != nil {
+		return nil, fmt.Errorf("parse export section failed: %w", err)
+	}
+	err = m.rewriteCode(func(body []byte) ([]byte, error) {
+		return instrumentBody(body, gasIdx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("instrument code section failed: %w", err)
+	}
+	return m.encode(), nil
+}
+
+func (inst *Instance[DATA]) gasLeft() (int64, error) {
+	v, err := inst.gasGlobal.Get()
+	if err != nil {
+		return 0, fmt.Errorf("get gas left failed: %w", err)
+	}
+	return v.(int64), nil
+}
+
+func (inst *Instance[DATA]) setGasLeft(gas int64) error {
+	if err := inst.gasGlobal.Set(gas, wasmer.I64); err != nil {
+		return fmt.Errorf("set gas left failed: %w", err)
+	}
+	return nil
+}
+
+// chargeImportFunc charges ImportFuncGas for calling an import function, panics with ErrOutOfGas if
+// the gas is not enough, the panic will be recovered by the wrapper of the import function
+func (inst *Instance[DATA]) chargeImportFunc() {
+	if inst.gasGlobal == nil {
+		return
+	}
+	left, err := inst.gasLeft()
+	if err != nil {
+		panic(err)
+	}
+	left -= ImportFuncGas
+	if err = inst.setGasLeft(left); err != nil {
+		panic(err)
+	}
+	if left < 0 {
+		panic(ErrOutOfGas)
+	}
+}
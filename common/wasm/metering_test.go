@@ -0,0 +1,125 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sentioxyz/sentio-core/common/log"
+)
+
+// buildLoopModBytes builds a module with an export function 'loop' which never returns
+func buildLoopModBytes() []byte {
+	section := func(out []byte, id byte, entries ...[]byte) []byte {
+		payload := appendU32(nil, uint32(len(entries)))
+		for _, entry := range entries {
+			payload = append(payload, entry...)
+		}
+		out = appendU32(append(out, id), uint32(len(payload)))
+		return append(out, payload...)
+	}
+	export := func(name string, kind byte, idx uint32) []byte {
+		entry := appendU32(nil, uint32(len(name)))
+		entry = append(entry, name...)
+		return appendU32(append(entry, kind), idx)
+	}
+	code := func(instructions ...byte) []byte {
+		entry := appendU32(nil, uint32(len(instructions)+1))
+		entry = append(entry, 0x00) // no locals
+		return append(entry, instructions...)
+	}
+	mod := []byte("\x00asm\x01\x00\x00\x00")
+	mod = section(mod, 1, []byte{0x60, 0x00, 0x00}, []byte{0x60, 0x01, 0x7f, 0x01, 0x7f}) // () -> (), (i32) -> i32
+	mod = section(mod, 3, []byte{0x00}, []byte{0x01}, []byte{0x00})
+	mod = section(mod, 5, []byte{0x00, 0x01}) // one page
+	mod = section(mod, 7,
+		export(MemoryName, 0x02, 0),
+		export("_start", 0x00, 0),
+		export(AllocateFunctionName, 0x00, 1),
+		export("loop", 0x00, 2))
+	mod = section(mod, 10,
+		code(0x0b),                               // _start: end
+		code(0x41, 0x80, 0x08, 0x0b),             // allocate: i32.const 1024 end
+		code(0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b)) // loop: loop br 0 end end
+	return mod
+}
+
+func Test_gasInfiniteLoop(t *testing.T) {
+	inst := NewInstance[testCtxData]("testInst", buildLoopModBytes(), 1024*1024).
+		MustExportFunction("loop", (func())(nil)).
+		SetGasLimit(1000000)
+	defer inst.Close()
+
+	assert.NoError(t, inst.Init(log.With()))
+
+	for i := 0; i < 2; i++ {
+		_, report, err := inst.CallExportFunction(
+			NewCallContext[testCtxData](context.Background()),
+			CallParams[testCtxData]{
+				ExportFuncName: "loop",
+				Logger:         log.With(),
+			})
+		assert.True(t, errors.Is(err, ErrOutOfGas), "%v", err)
+		assert.Equal(t, uint64(1000000), report.GasUsed)
+	}
+}
+
+func Test_gasUsed(t *testing.T) {
+	//export function add(a: i32, b: i32): i32 {
+	//  log.info("a = {}, b = {}", [a.toString(), b.toString()])
+	//  return a + b
+	//}
+	inst := newTestInst("testInst").
+		MustExportFunction("add", (func(I32, I32) I32)(nil)).
+		SetGasLimit(100000000).
+		SetDebugLevel(testDebugLevel)
+	defer inst.Close()
+
+	assert.NoError(t, inst.Init(log.With()))
+
+	var gasUsed []uint64
+	for i := 0; i < 2; i++ {
+		result, report, err := inst.CallExportFunction(
+			NewCallContext[testCtxData](context.Background()),
+			CallParams[testCtxData]{
+				ExportFuncName: "add",
+				Logger:         log.With(),
+			},
+			I32(123), I32(234))
+		assert.NoError(t, err)
+		assert.Equal(t, I32(357), result)
+		// 'log.log' is called
+		assert.Greater(t, report.GasUsed, uint64(ImportFuncGas))
+		gasUsed = append(gasUsed, report.GasUsed)
+	}
+	// same call uses the same gas
+	assert.Equal(t, gasUsed[0], gasUsed[1])
+
+	// not enough for the import function
+	inst.SetGasLimit(gasUsed[0] - ImportFuncGas/2)
+	_, report, err := inst.CallExportFunction(
+		NewCallContext[testCtxData](context.Background()),
+		CallParams[testCtxData]{
+			ExportFuncName: "add",
+			Logger:         log.With(),
+		},
+		I32(123), I32(234))
+	assert.True(t, errors.Is(err, ErrOutOfGas), "%v", err)
+	assert.Equal(t, gasUsed[0]-ImportFuncGas/2, report.GasUsed)
+}
+
+func Test_instrumentGas(t *testing.T) {
+	_, err := instrumentGas([]byte("not a wasm module"))
+	assert.Error(t, err)
+
+	// exception handling is not supported
+	mod := buildLoopModBytes()
+	loopBody := []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b}
+	for i := len(mod) - len(loopBody); i < len(mod); i++ {
+		mod[i] = 0x06 // try
+	}
+	_, err = instrumentGas(mod)
+	assert.ErrorContains(t, err, "unsupported opcode 0x06")
+}
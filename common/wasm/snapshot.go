@@ -0,0 +1,157 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+// globalExportPrefix is the prefix of the names of the globals exported by exportGlobals
+const globalExportPrefix = "__sentio_global_"
+
+var errInitSnapshotUnsupported = errors.New("init snapshot is not supported by the module")
+
+// exportGlobals exports all mutable globals defined in the module, so their values can be saved and restored
+// by the host. The names of the exported globals are returned.
+//
+// The restored instance is a new instantiation of the same module, so the tables and the data segments are
+// initialized again as the module declared. If the module may change them at runtime, the state after init
+// cannot be restored by the linear memory and the globals, errInitSnapshotUnsupported will be returned.
+func exportGlobals(mod []byte) ([]byte, []string, error) {
+	m, err := parseWasmModule(mod)
+	if err != nil {
+		return nil, nil, err
+	}
+	imported, err := m.importedGlobals()
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse import section failed: %w", err)
+	}
+	r, count, err := m.vector(sectionGlobal)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse global section failed: %w", err)
+	}
+	var names []string
+	var exports [][]byte
+	for i := uint32(0); i < count; i++ {
+		valType, err := r.skipValType()
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse global #%d failed: %w", i, err)
+		}
+		mut, err := r.byte()
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse global #%d failed: %w", i, err)
+		}
+		// init expression
+		for op := byte(0); op != opEnd; {
+			if op, _, err = r.instruction(); err != nil {
+				return nil, nil, fmt.Errorf("parse global #%d failed: %w", i, err)
+			}
+		}
+		if mut == 0 {
+			continue
+		}
+		if valType < 0x7c || valType > 0x7f {
+			return nil, nil, fmt.Errorf("%w: global #%d has type 0x%02x", errInitSnapshotUnsupported, i, valType)
+		}
+		name := globalExportPrefix + strconv.FormatUint(uint64(imported+i), 10)
+		names = append(names, name)
+		exports = append(exports, appendU32(append(appendName(nil, name), exportKindGlobal), imported+i))
+	}
+	err = m.rewriteCode(func(body []byte) ([]byte, error) {
+		r := &wasmReader{data: body}
+		if err := r.skipLocals(); err != nil {
+			return nil, err
+		}
+		for !r.eof() {
+			op, sub, err := r.instruction()
+			if err != nil {
+				return nil, err
+			}
+			// table.set, data.drop table.init table.copy table.grow table.fill
+			if op == opTableSet || op == opPrefixFC && (sub == 9 || sub == 12 || sub == 14 || sub == 15 || sub == 17) {
+				return nil, fmt.Errorf("%w: opcode 0x%02x %d at %d", errInitSnapshotUnsupported, op, sub, r.pos)
+			}
+		}
+		return body, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(exports) > 0 {
+		if _, err = m.appendEntries(sectionExport, exports...); err != nil {
+			return nil, nil, fmt.Errorf("parse export section failed: %w", err)
+		}
+	}
+	return m.encode(), names, nil
+}
+
+type savedGlobal struct {
+	name  string
+	kind  wasmer.ValueKind
+	value any
+}
+
+// initSnapshot is the state of an instance just after the start function is called
+type initSnapshot struct {
+	memory  []byte
+	globals []savedGlobal
+}
+
+func (inst *Instance[DATA]) takeInitSnapshot() (*initSnapshot, error) {
+	snapshot := &initSnapshot{
+		memory: append([]byte(nil), inst.memoryMgr.memory.Data()...),
+	}
+	for _, name := range inst.snapshotGlobals {
+		global, err := inst.instance.Exports.GetGlobal(name)
+		if err != nil {
+			return nil, fmt.Errorf("get global %q failed: %w", name, err)
+		}
+		value, err := global.Get()
+		if err != nil {
+			return nil, fmt.Errorf("get value of global %q failed: %w", name, err)
+		}
+		snapshot.globals = append(snapshot.globals, savedGlobal{
+			name:  name,
+			kind:  global.Type().ValueType().Kind(),
+			value: value,
+		})
+	}
+	return snapshot, nil
+}
+
+func (inst *Instance[DATA]) restoreInitSnapshot() error {
+	memory := inst.memoryMgr.memory
+	size := memory.Size()
+	if pages := uint32(uint(len(inst.initSnapshot.memory)) / wasmer.WasmPageSize); pages > size.ToUint32() {
+		if !memory.Grow(wasmer.Pages(pages - size.ToUint32())) {
+			return fmt.Errorf("grow memory to %d pages failed", pages)
+		}
+	}
+	data := memory.Data()
+	if len(data) != len(inst.initSnapshot.memory) {
+		return fmt.Errorf("memory size %d is not equal to the size %d in init snapshot",
+			len(data), len(inst.initSnapshot.memory))
+	}
+	copy(data, inst.initSnapshot.memory)
+	for _, saved := range inst.initSnapshot.globals {
+		global, err := inst.instance.Exports.GetGlobal(saved.name)
+		if err != nil {
+			return fmt.Errorf("get global %q failed: %w", saved.name, err)
+		}
+		if err = global.Set(saved.value, saved.kind); err != nil {
+			return fmt.Errorf("set value of global %q failed: %w", saved.name, err)
+		}
+	}
+	return nil
+}
+
+// EnableInitSnapshot makes the instance save its state after the start function is called in the first init,
+// then the later resets will restore the state to a new instantiation instead of calling the start function
+// again. If the module does not support it, the instance falls back to calling the start function.
+// Should be called before init.
+func (inst *Instance[DATA]) EnableInitSnapshot() *Instance[DATA] {
+	inst.initSnapshotEnabled = true
+	return inst
+}
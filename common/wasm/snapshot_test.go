@@ -0,0 +1,133 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sentioxyz/sentio-core/common/log"
+)
+
+type testCall struct {
+	name string
+	args []any
+}
+
+var testCalls = []testCall{
+	{name: "add", args: []any{I32(123), I32(234)}},
+	{name: "returnString", args: []any{I32(100)}},
+	{name: "foo", args: []any{BuildString("a"), BuildString("b"), BuildString("c")}},
+	{name: "indexOf", args: []any{BuildString("abcdef"), BuildString("cd")}},
+	{name: "returnMyEvent"},
+	{name: "add", args: []any{I32(1111111), I32(2345678)}},
+}
+
+func newTestSnapshotInst(name string) *Instance[testCtxData] {
+	return newTestInst(name).
+		MustExportFunction("add", (func(I32, I32) I32)(nil)).
+		MustExportFunction("returnString", (func(I32) *String)(nil)).
+		MustExportFunction("foo", (func(*String, *String, *String) *String)(nil)).
+		MustExportFunction("indexOf", (func(*String, *String) I32)(nil)).
+		MustExportFunction("returnMyEvent", (func() *myEvent)(nil)).
+		SetDebugLevel(testDebugLevel)
+}
+
+type testCallOutput struct {
+	ret        any
+	memoryUsed uint32
+}
+
+func callAll(t *testing.T, inst *Instance[testCtxData]) (outputs []testCallOutput) {
+	for _, c := range testCalls {
+		ret, _, err := inst.CallExportFunction(
+			NewCallContext[testCtxData](context.Background()),
+			CallParams[testCtxData]{
+				ExportFuncName: c.name,
+				Logger:         log.With(),
+			},
+			c.args...)
+		assert.NoError(t, err)
+		outputs = append(outputs, testCallOutput{ret: ret, memoryUsed: inst.memoryMgr.memoryUsed})
+	}
+	return outputs
+}
+
+func getGlobals(t *testing.T, inst *Instance[testCtxData]) map[string]any {
+	values := make(map[string]any)
+	for _, name := range inst.snapshotGlobals {
+		global, err := inst.instance.Exports.GetGlobal(name)
+		assert.NoError(t, err)
+		values[name], err = global.Get()
+		assert.NoError(t, err)
+	}
+	return values
+}
+
+func Test_initSnapshot(t *testing.T) {
+	// inst0 calls the start function in every reset, inst1 restores from the init snapshot
+	inst0 := newTestSnapshotInst("testInst0")
+	defer inst0.Close()
+	inst1 := newTestSnapshotInst("testInst1").EnableInitSnapshot()
+	defer inst1.Close()
+
+	assert.NoError(t, inst0.Init(log.With()))
+	assert.NoError(t, inst1.Init(log.With()))
+	assert.NotNil(t, inst1.initSnapshot)
+	assert.NotEmpty(t, inst1.initSnapshot.globals)
+	assert.Equal(t, inst0.memoryMgr.memory.Data(), inst1.memoryMgr.memory.Data())
+	initGlobals := getGlobals(t, inst1)
+
+	expected := callAll(t, inst0)
+	assert.Equal(t, expected, callAll(t, inst1))
+
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, inst0.reset(log.With()))
+		assert.NoError(t, inst1.reset(log.With()))
+		assert.Equal(t, uint(0), inst0.restoreCounter)
+		assert.Equal(t, uint(i), inst1.restoreCounter)
+
+		// same state as a fresh init
+		assert.Equal(t, inst0.memoryMgr.memoryUsed, inst1.memoryMgr.memoryUsed)
+		assert.Equal(t, inst0.memoryMgr.memoryUsedInitial, inst1.memoryMgr.memoryUsedInitial)
+		assert.Equal(t, inst0.memoryMgr.memory.Data(), inst1.memoryMgr.memory.Data())
+		assert.Equal(t, initGlobals, getGlobals(t, inst1))
+
+		// same output as before
+		assert.Equal(t, expected, callAll(t, inst0))
+		assert.Equal(t, expected, callAll(t, inst1))
+	}
+}
+
+func Test_initSnapshotWithGasLimit(t *testing.T) {
+	inst := newTestSnapshotInst("testInst").EnableInitSnapshot().SetGasLimit(100000000)
+	defer inst.Close()
+
+	assert.NoError(t, inst.Init(log.With()))
+	for _, saved := range inst.initSnapshot.globals {
+		assert.NotEqual(t, GasGlobalExportName, saved.name)
+	}
+	expected := callAll(t, inst)
+
+	assert.NoError(t, inst.reset(log.With()))
+	assert.Equal(t, uint(1), inst.restoreCounter)
+	assert.Equal(t, expected, callAll(t, inst))
+}
+
+func Test_exportGlobals(t *testing.T) {
+	// table.set in the function 'loop'
+	mod := buildLoopModBytes()
+	copy(mod[len(mod)-6:], []byte{0x41, 0x00, 0xd0, 0x70, opTableSet, 0x00})
+	_, _, err := exportGlobals(mod)
+	assert.True(t, errors.Is(err, errInitSnapshotUnsupported), "%v", err)
+
+	// a module without any mutable global can be restored by the memory only
+	inst := NewInstance[testCtxData]("testInst", buildLoopModBytes(), 1024*1024).EnableInitSnapshot()
+	defer inst.Close()
+	assert.NoError(t, inst.Init(log.With()))
+	assert.NotNil(t, inst.initSnapshot)
+	assert.Empty(t, inst.initSnapshot.globals)
+	assert.NoError(t, inst.reset(log.With()))
+	assert.Equal(t, uint(1), inst.restoreCounter)
+}
@@ -176,6 +176,10 @@ type CheckpointController interface {
 	// CleanCheckpoint Delete checkpoints and templates greater than or equal to blockNumberGE, executed after reorg
 	CleanCheckpoint(ctx context.Context, curBlockNumber, blockNumberGE uint64) *ExternalError
 
+	// RollbackCheckpoint Delete checkpoints from the first one which is not valid, executed at the start of a round
+	// in cross-chain ordered mode to align the progress of all chains
+	RollbackCheckpoint(ctx context.Context, valid func(Checkpoint) bool) *ExternalError
+
 	// MakeCheckpoint Try to construct a checkpoint at the blockData block, indicating that all bindings of this block
 	// have been processed.
 	MakeCheckpoint(
@@ -221,11 +225,13 @@ type CheckpointController interface {
 		id string,
 		blockNumber uint64,
 	) (box *persistent.EntityBox, err *ExternalError)
-	// ListEntity list entity
+	// ListEntity list entity matching the filters in the orders, page by page with the cursor,
+	// id is always the last term of the orders, cursor should be empty or the next cursor of the previous page
 	ListEntity(
 		ctx context.Context,
 		entityType *schema.Entity,
 		filters []persistent.EntityFilter,
+		orders []persistent.EntityOrder,
 		cursor string,
 		limit int,
 		blockNumber uint64,
@@ -440,10 +446,29 @@ func (c *checkpointController) CleanCheckpoint(
 ) *ExternalError {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, logger := log.FromContext(ctx)
-	logger = logger.UserVisible()
 	detectedMsg := fmt.Sprintf("Reorg detected when processing block %d, all blocks from block %d are invalid",
 		curBlockNumber, blockNumberGE)
+	return c.cleanCheckpoint(ctx, blockNumberGE, detectedMsg)
+}
+
+func (c *checkpointController) RollbackCheckpoint(ctx context.Context, valid func(Checkpoint) bool) *ExternalError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var cc int
+	for cc < len(c.checkpoints) && valid(c.checkpoints[cc]) {
+		cc++
+	}
+	if cc == len(c.checkpoints) {
+		return nil
+	}
+	rollbackMsg := fmt.Sprintf("Progress is ahead of other chains, all blocks from block %d are invalid",
+		c.checkpoints[cc].BlockNumber)
+	return c.cleanCheckpoint(ctx, c.checkpoints[cc].BlockNumber, rollbackMsg)
+}
+
+func (c *checkpointController) cleanCheckpoint(ctx context.Context, blockNumberGE uint64, detectedMsg string) *ExternalError {
+	_, logger := log.FromContext(ctx)
+	logger = logger.UserVisible()
 	c.stopped = true
 	var cc int
 	for cc < len(c.checkpoints) && c.checkpoints[cc].BlockNumber < blockNumberGE {
@@ -922,13 +947,14 @@ func (c *checkpointController) ListEntity(
 	ctx context.Context,
 	entityType *schema.Entity,
 	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
 	cursor string,
 	limit int,
 	blockNumber uint64,
 ) (boxes []*persistent.EntityBox, next *string, err *ExternalError) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.entityCtrl.ListEntity(ctx, entityType, filters, cursor, limit, blockNumber)
+	return c.entityCtrl.ListEntity(ctx, entityType, filters, orders, cursor, limit, blockNumber)
 }
 
 func (c *checkpointController) ListRelated(
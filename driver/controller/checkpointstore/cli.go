@@ -0,0 +1,152 @@
+package checkpointstore
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/driver/controller"
+)
+
+const cliUsage = `usage: <command> [flags]
+
+commands:
+  chains     list the chains of a processor which have saved checkpoints
+  list       list the revisions and the checkpoints of a chain
+  templates  show the template instances added between two blocks
+  rewind     rewind a chain of a processor to a saved checkpoint
+`
+
+// RunCLI runs one inspection command against the backend, args are the command and its flags
+func RunCLI(ctx context.Context, backend Backend, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(out, cliUsage)
+		return errors.New("command is required")
+	}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(out)
+	processorID := fs.String("processor", "", "processor ID")
+	chainID := fs.String("chain", "", "chain ID")
+	var run func() error
+	switch args[0] {
+	case "chains":
+		run = func() error {
+			return cliChains(ctx, backend, *processorID, out)
+		}
+	case "list":
+		all := fs.Bool("all", false, "list the checkpoints of all the revisions instead of only the latest one")
+		run = func() error {
+			return cliList(ctx, backend, *processorID, *chainID, *all, out)
+		}
+	case "templates":
+		from := fs.Uint64("from", 0, "start block number, inclusive")
+		to := fs.Uint64("to", 0, "end block number, inclusive, 0 means no limit")
+		run = func() error {
+			return cliTemplates(ctx, backend, *processorID, *chainID, *from, *to, out)
+		}
+	case "rewind":
+		block := fs.Uint64("block", 0, "block number of the checkpoint to rewind to")
+		run = func() error {
+			cp, err := NewStore(backend, *processorID, *chainID).Rewind(ctx, *block)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "rewound chain %s of processor %s to checkpoint of block %d (%s)\n",
+				*chainID, *processorID, cp.BlockNumber, cp.BlockHash)
+			return nil
+		}
+	default:
+		_, _ = fmt.Fprint(out, cliUsage)
+		return errors.Errorf("unknown command %q", args[0])
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *processorID == "" {
+		return errors.New("-processor is required")
+	}
+	if *chainID == "" && args[0] != "chains" {
+		return errors.New("-chain is required")
+	}
+	return run()
+}
+
+func cliChains(ctx context.Context, backend Backend, processorID string, out io.Writer) error {
+	chains, err := backend.Chains(ctx, processorID)
+	if err != nil {
+		return err
+	}
+	for _, chainID := range chains {
+		h, err := LoadHistory(ctx, backend, processorID, chainID)
+		if err != nil {
+			return err
+		}
+		line := fmt.Sprintf("%s\trevisions=%d", chainID, len(h))
+		if cp := latestCheckpoint(h.Checkpoints(h.Latest())); cp != nil {
+			line += fmt.Sprintf("\tcheckpoint=%d", cp.BlockNumber)
+		}
+		record, err := backend.GetError(ctx, processorID, chainID)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			line += fmt.Sprintf("\terror=[%d] %s", record.Code, record.Message)
+		}
+		_, _ = fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+func cliList(ctx context.Context, backend Backend, processorID, chainID string, all bool, out io.Writer) error {
+	h, err := LoadHistory(ctx, backend, processorID, chainID)
+	if err != nil {
+		return err
+	}
+	if len(h) == 0 {
+		return errors.Wrapf(ErrCheckpointNotFound, "no revision of chain %s", chainID)
+	}
+	var checkpoints []controller.Checkpoint
+	for i, rev := range h {
+		checkpoints = applyCheckpoints(checkpoints, rev)
+		line := fmt.Sprintf("revision %d\tsavedAt=%s\tcheckpoints=%d\taddedCheckpoints=%d\tremovedCheckpoints=%d"+
+			"\taddedTemplates=%d\tremovedTemplates=%d",
+			rev.Seq, rev.SavedAt.Format(time.RFC3339), len(checkpoints), len(rev.AddedCheckpoints),
+			len(rev.RemovedCheckpoints), len(rev.AddedTemplates), len(rev.RemovedTemplates))
+		if rev.RewindTo != nil {
+			line += fmt.Sprintf("\trewindTo=%d", *rev.RewindTo)
+		}
+		_, _ = fmt.Fprintln(out, line)
+		if !all && i != h.Latest() {
+			continue
+		}
+		for _, cp := range checkpoints {
+			_, _ = fmt.Fprintf(out, "  block=%d\thash=%s\ttime=%s\tlatestBlock=%d\n",
+				cp.BlockNumber, cp.BlockHash, cp.BlockTime.Format(time.RFC3339), cp.LatestBlockNumber)
+		}
+	}
+	return nil
+}
+
+func cliTemplates(ctx context.Context, backend Backend, processorID, chainID string, from, to uint64, out io.Writer) error {
+	h, err := LoadHistory(ctx, backend, processorID, chainID)
+	if err != nil {
+		return err
+	}
+	if to == 0 {
+		to = ^uint64(0)
+	}
+	templates := h.TemplatesBetween(from, to)
+	for _, bn := range slices.Sorted(maps.Keys(templates)) {
+		for _, ins := range templates[bn] {
+			_, _ = fmt.Fprintf(out, "block=%d\ttemplate=%d:%s\taddress=%s\tlabels=%s\tremoved=%t\n",
+				bn, ins.TemplateID, ins.TemplateName, ins.Address, ins.Labels, ins.Removed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/cockroachdb/pebble"
+
+	"sentioxyz/sentio-core/common/flags"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/controller/checkpointstore"
+)
+
+func main() {
+	var (
+		pebbleDir    = flag.String("pebble", "", "Directory of the pebble database keeping the checkpoints")
+		pebblePrefix = flag.String("pebble-key-prefix", "", "Key prefix of the checkpoints in the pebble database")
+		postgresDSN  = flag.String("postgres", "", "DSN of the PostgreSQL database keeping the checkpoints")
+	)
+	flags.ParseAndInitLogFlag()
+
+	var backend checkpointstore.Backend
+	switch {
+	case *pebbleDir != "" && *postgresDSN != "":
+		log.Fatalf("only one of -pebble and -postgres can be specified")
+	case *pebbleDir != "":
+		db, err := pebble.Open(*pebbleDir, &pebble.Options{})
+		if err != nil {
+			log.Fatalf("open pebble database %s failed: %v", *pebbleDir, err)
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+		backend = checkpointstore.NewPebbleBackend(db, *pebblePrefix)
+	case *postgresDSN != "":
+		pg, err := checkpointstore.NewPostgresBackend(*postgresDSN)
+		if err != nil {
+			log.Fatalf("connect postgres failed: %v", err)
+		}
+		defer func() {
+			_ = pg.Close()
+		}()
+		backend = pg
+	default:
+		log.Fatalf("one of -pebble and -postgres is required")
+	}
+
+	if err := checkpointstore.RunCLI(context.Background(), backend, flag.Args(), os.Stdout); err != nil {
+		log.Errorfe(err, "run command failed")
+		os.Exit(1)
+	}
+}
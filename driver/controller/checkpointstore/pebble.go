@@ -0,0 +1,204 @@
+package checkpointstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/common/compress"
+)
+
+var _ Backend = (*PebbleBackend)(nil)
+
+// PebbleBackend keeps the revisions in a local pebble database.
+//
+// Keys are:
+//
+//	<prefix>rev\x00<processorID>\x00<chainID>\x00<seq in big-endian uint64> => compressed revision
+//	<prefix>snap\x00<processorID>\x00<chainID> => seq of the latest snapshot in big-endian uint64
+//	<prefix>err\x00<processorID>\x00<chainID> => error record
+//
+// Pebble has no transactions, so the backend supports a single writer only: pebble holds an exclusive lock on
+// the directory so only one process can open the database, and the appends in the process are serialized by
+// appendMu. Append also refuses to overwrite an existing revision in case the database is shared some other way.
+type PebbleBackend struct {
+	db        *pebble.DB
+	keyPrefix string
+
+	appendMu sync.Mutex
+}
+
+func NewPebbleBackend(db *pebble.DB, keyPrefix string) *PebbleBackend {
+	return &PebbleBackend{db: db, keyPrefix: keyPrefix}
+}
+
+func (b *PebbleBackend) key(kind string, parts ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(b.keyPrefix)
+	buf.WriteString(kind)
+	for _, part := range parts {
+		buf.WriteByte(0)
+		buf.WriteString(part)
+	}
+	return buf.Bytes()
+}
+
+// revBounds returns the key range of all the revisions of the chain
+func (b *PebbleBackend) revBounds(processorID, chainID string) (lower, upper []byte) {
+	lower = append(b.key("rev", processorID, chainID), 0)
+	upper = append(b.key("rev", processorID, chainID), 1)
+	return lower, upper
+}
+
+func (b *PebbleBackend) revKey(processorID, chainID string, seq uint64) []byte {
+	lower, _ := b.revBounds(processorID, chainID)
+	return binary.BigEndian.AppendUint64(lower, seq)
+}
+
+func (b *PebbleBackend) Append(ctx context.Context, processorID, chainID string, rev Revision) (uint64, error) {
+	b.appendMu.Lock()
+	defer b.appendMu.Unlock()
+
+	lower, upper := b.revBounds(processorID, chainID)
+	iter, err := b.db.NewIterWithContext(ctx, &pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return 0, errors.Wrap(err, "create iterator failed")
+	}
+	var seq uint64
+	if iter.Last() {
+		seq = binary.BigEndian.Uint64(iter.Key()[len(lower):]) + 1
+	}
+	if err = iter.Close(); err != nil {
+		return 0, errors.Wrap(err, "find the last revision failed")
+	}
+
+	rev.Seq = seq
+	value, err := compress.Dump(rev)
+	if err != nil {
+		return 0, errors.Wrapf(err, "encode revision %d failed", seq)
+	}
+	key := b.revKey(processorID, chainID, seq)
+	if _, closer, getErr := b.db.Get(key); getErr == nil {
+		_ = closer.Close()
+		return 0, errors.Wrapf(ErrConcurrentAppend, "revision %d of chain %s already exists", seq, chainID)
+	} else if !errors.Is(getErr, pebble.ErrNotFound) {
+		return 0, errors.Wrapf(getErr, "check revision %d failed", seq)
+	}
+	batch := b.db.NewBatch()
+	defer func() {
+		_ = batch.Close()
+	}()
+	_ = batch.Set(key, value, nil)
+	if rev.Snapshot {
+		_ = batch.Set(b.key("snap", processorID, chainID), binary.BigEndian.AppendUint64(nil, seq), nil)
+	}
+	if err = batch.Commit(pebble.Sync); err != nil {
+		return 0, errors.Wrapf(err, "write revision %d failed", seq)
+	}
+	return seq, nil
+}
+
+func (b *PebbleBackend) Revisions(ctx context.Context, processorID, chainID string) ([]Revision, error) {
+	lower, _ := b.revBounds(processorID, chainID)
+	return b.revisions(ctx, processorID, chainID, lower)
+}
+
+func (b *PebbleBackend) RevisionsSinceSnapshot(ctx context.Context, processorID, chainID string) ([]Revision, error) {
+	raw, closer, err := b.db.Get(b.key("snap", processorID, chainID))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return b.Revisions(ctx, processorID, chainID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get the latest snapshot failed")
+	}
+	seq := binary.BigEndian.Uint64(raw)
+	_ = closer.Close()
+	return b.revisions(ctx, processorID, chainID, b.revKey(processorID, chainID, seq))
+}
+
+// revisions returns the revisions of the chain from the key of a revision
+func (b *PebbleBackend) revisions(ctx context.Context, processorID, chainID string, from []byte) ([]Revision, error) {
+	lower, upper := b.revBounds(processorID, chainID)
+	iter, err := b.db.NewIterWithContext(ctx, &pebble.IterOptions{LowerBound: from, UpperBound: upper})
+	if err != nil {
+		return nil, errors.Wrap(err, "create iterator failed")
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	var revisions []Revision
+	for iter.First(); iter.Valid(); iter.Next() {
+		var rev Revision
+		if err = compress.Load(iter.Value(), &rev); err != nil {
+			return nil, errors.Wrapf(err, "decode revision %d failed", binary.BigEndian.Uint64(iter.Key()[len(lower):]))
+		}
+		revisions = append(revisions, rev)
+	}
+	if err = iter.Error(); err != nil {
+		return nil, errors.Wrap(err, "iterate revisions failed")
+	}
+	return revisions, nil
+}
+
+func (b *PebbleBackend) Chains(ctx context.Context, processorID string) ([]string, error) {
+	lower := append(b.key("rev", processorID), 0)
+	upper := append(b.key("rev", processorID), 1)
+	iter, err := b.db.NewIterWithContext(ctx, &pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return nil, errors.Wrap(err, "create iterator failed")
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	var chains []string
+	for iter.First(); iter.Valid(); {
+		rest := iter.Key()[len(lower):]
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return nil, errors.Errorf("invalid revision key %q", iter.Key())
+		}
+		chainID := string(rest[:end])
+		chains = append(chains, chainID)
+		// skip all the other revisions of the chain
+		_, next := b.revBounds(processorID, chainID)
+		iter.SeekGE(next)
+	}
+	if err = iter.Error(); err != nil {
+		return nil, errors.Wrap(err, "iterate chains failed")
+	}
+	return chains, nil
+}
+
+func (b *PebbleBackend) SetError(ctx context.Context, processorID, chainID string, record ErrorRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "encode error record failed")
+	}
+	if err = b.db.Set(b.key("err", processorID, chainID), value, pebble.Sync); err != nil {
+		return errors.Wrap(err, "write error record failed")
+	}
+	return nil
+}
+
+func (b *PebbleBackend) GetError(ctx context.Context, processorID, chainID string) (*ErrorRecord, error) {
+	raw, closer, err := b.db.Get(b.key("err", processorID, chainID))
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "get error record failed")
+	}
+	defer func() {
+		_ = closer.Close()
+	}()
+	var record ErrorRecord
+	if err = json.Unmarshal(raw, &record); err != nil {
+		return nil, errors.Wrap(err, "decode error record failed")
+	}
+	return &record, nil
+}
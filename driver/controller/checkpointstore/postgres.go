@@ -0,0 +1,191 @@
+package checkpointstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sentioxyz/sentio-core/common/compress"
+)
+
+var _ Backend = (*PostgresBackend)(nil)
+
+// PostgresBackend keeps the revisions in PostgreSQL, so that processors running on different hosts
+// can share the same storage.
+type PostgresBackend struct {
+	db *gorm.DB
+}
+
+type CheckpointRevisionRow struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement;column:id"`
+	ProcessorID string    `gorm:"uniqueIndex:checkpoint_revision_processor_id_chain_id_seq_unique;column:processor_id"`
+	ChainID     string    `gorm:"uniqueIndex:checkpoint_revision_processor_id_chain_id_seq_unique;column:chain_id"`
+	Seq         uint64    `gorm:"uniqueIndex:checkpoint_revision_processor_id_chain_id_seq_unique;column:seq"`
+	SavedAt     time.Time `gorm:"not null;column:saved_at"`
+	Snapshot    bool      `gorm:"not null;default:false;column:snapshot"`
+	Data        []byte    `gorm:"not null;column:data"`
+}
+
+func (CheckpointRevisionRow) TableName() string { return "driver_checkpoint_revisions" }
+
+type CheckpointErrorRow struct {
+	ProcessorID string    `gorm:"primaryKey;column:processor_id"`
+	ChainID     string    `gorm:"primaryKey;column:chain_id"`
+	Code        int       `gorm:"not null;column:code"`
+	Message     string    `gorm:"not null;column:message"`
+	At          time.Time `gorm:"not null;column:at"`
+}
+
+func (CheckpointErrorRow) TableName() string { return "driver_checkpoint_errors" }
+
+func NewPostgresBackend(dsn string) (*PostgresBackend, error) {
+	if dsn == "" {
+		return nil, errors.New("postgres dsn is required")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, errors.Wrap(err, "open postgres failed")
+	}
+	if err = db.AutoMigrate(&CheckpointRevisionRow{}, &CheckpointErrorRow{}); err != nil {
+		return nil, errors.Wrap(err, "migrate checkpoint tables failed")
+	}
+	return &PostgresBackend{db: db}, nil
+}
+
+func (b *PostgresBackend) Close() error {
+	sqlDB, err := b.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (b *PostgresBackend) Append(ctx context.Context, processorID, chainID string, rev Revision) (uint64, error) {
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last CheckpointRevisionRow
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("processor_id = ? AND chain_id = ?", processorID, chainID).
+			Order("seq DESC").
+			Limit(1).
+			Find(&last).Error
+		if err != nil {
+			return errors.Wrap(err, "find the last revision failed")
+		}
+		if last.ID > 0 {
+			rev.Seq = last.Seq + 1
+		} else {
+			rev.Seq = 0
+		}
+		data, err := compress.Dump(rev)
+		if err != nil {
+			return errors.Wrapf(err, "encode revision %d failed", rev.Seq)
+		}
+		row := CheckpointRevisionRow{
+			ProcessorID: processorID,
+			ChainID:     chainID,
+			Seq:         rev.Seq,
+			SavedAt:     rev.SavedAt,
+			Snapshot:    rev.Snapshot,
+			Data:        data,
+		}
+		if err = tx.Create(&row).Error; err != nil {
+			return errors.Wrapf(err, "write revision %d failed", rev.Seq)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rev.Seq, nil
+}
+
+func (b *PostgresBackend) Revisions(ctx context.Context, processorID, chainID string) ([]Revision, error) {
+	return b.revisions(ctx, processorID, chainID, 0)
+}
+
+func (b *PostgresBackend) RevisionsSinceSnapshot(ctx context.Context, processorID, chainID string) ([]Revision, error) {
+	var seqs []uint64
+	err := b.db.WithContext(ctx).
+		Model(&CheckpointRevisionRow{}).
+		Where("processor_id = ? AND chain_id = ? AND snapshot", processorID, chainID).
+		Order("seq DESC").
+		Limit(1).
+		Pluck("seq", &seqs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "query the latest snapshot failed")
+	}
+	if len(seqs) == 0 {
+		return b.revisions(ctx, processorID, chainID, 0)
+	}
+	return b.revisions(ctx, processorID, chainID, seqs[0])
+}
+
+// revisions returns the revisions of the chain from the seq
+func (b *PostgresBackend) revisions(ctx context.Context, processorID, chainID string, from uint64) ([]Revision, error) {
+	var rows []CheckpointRevisionRow
+	err := b.db.WithContext(ctx).
+		Where("processor_id = ? AND chain_id = ? AND seq >= ?", processorID, chainID, from).
+		Order("seq").
+		Find(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "query revisions failed")
+	}
+	revisions := make([]Revision, len(rows))
+	for i, row := range rows {
+		if err = compress.Load(row.Data, &revisions[i]); err != nil {
+			return nil, errors.Wrapf(err, "decode revision %d failed", row.Seq)
+		}
+	}
+	return revisions, nil
+}
+
+func (b *PostgresBackend) Chains(ctx context.Context, processorID string) ([]string, error) {
+	var chains []string
+	err := b.db.WithContext(ctx).
+		Model(&CheckpointRevisionRow{}).
+		Where("processor_id = ?", processorID).
+		Distinct("chain_id").
+		Order("chain_id").
+		Pluck("chain_id", &chains).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "query chains failed")
+	}
+	return chains, nil
+}
+
+func (b *PostgresBackend) SetError(ctx context.Context, processorID, chainID string, record ErrorRecord) error {
+	row := CheckpointErrorRow{
+		ProcessorID: processorID,
+		ChainID:     chainID,
+		Code:        record.Code,
+		Message:     record.Message,
+		At:          record.At,
+	}
+	err := b.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "processor_id"}, {Name: "chain_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"code", "message", "at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return errors.Wrap(err, "write error record failed")
+	}
+	return nil
+}
+
+func (b *PostgresBackend) GetError(ctx context.Context, processorID, chainID string) (*ErrorRecord, error) {
+	var rows []CheckpointErrorRow
+	err := b.db.WithContext(ctx).
+		Where("processor_id = ? AND chain_id = ?", processorID, chainID).
+		Limit(1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "query error record failed")
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &ErrorRecord{Code: rows[0].Code, Message: rows[0].Message, At: rows[0].At}, nil
+}
@@ -0,0 +1,23 @@
+package checkpointstore
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PostgresBackend(t *testing.T) {
+	dsn := os.Getenv("SENTIO_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("set SENTIO_TEST_POSTGRES_DSN to run the PostgreSQL checkpoint store integration test")
+	}
+	backend, err := NewPostgresBackend(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Close()
+	})
+	runBackendTests(t, backend, fmt.Sprintf("p-%d", time.Now().UnixNano()))
+}
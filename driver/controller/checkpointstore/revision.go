@@ -0,0 +1,260 @@
+package checkpointstore
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"sentioxyz/sentio-core/driver/controller"
+)
+
+// Revision is one saved version of the checkpoints and template instances of a chain.
+// Revisions of a chain are append-only, the latest one is the current state of the chain.
+type Revision struct {
+	Seq     uint64
+	SavedAt time.Time
+	// RewindTo is the block number of the checkpoint rewound to, nil if it is saved by the processor
+	RewindTo *uint64 `json:",omitempty"`
+	// Snapshot means the revision holds all the checkpoints and template instances instead of the changes since
+	// the previous revision, the state of the chain can be replayed from the latest snapshot, see SnapshotInterval
+	Snapshot bool `json:",omitempty"`
+
+	// AddedCheckpoints are the checkpoints which are new or changed since the previous revision, ordered by block number
+	AddedCheckpoints []controller.Checkpoint `json:",omitempty"`
+	// RemovedCheckpoints are the block numbers of the checkpoints dropped since the previous revision
+	RemovedCheckpoints []uint64 `json:",omitempty"`
+	// AddedTemplates are the template instances of the blocks which are new or changed since the previous revision
+	AddedTemplates map[uint64][]controller.TemplateInstance `json:",omitempty"`
+	// RemovedTemplates are the blocks whose template instances are dropped since the previous revision
+	RemovedTemplates []uint64 `json:",omitempty"`
+
+	AgentStat map[string]int `json:",omitempty"`
+}
+
+// latestCheckpoint returns the last checkpoint, nil if there is no checkpoint
+func latestCheckpoint(checkpoints []controller.Checkpoint) *controller.Checkpoint {
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	return &checkpoints[len(checkpoints)-1]
+}
+
+// ErrorRecord is the latest error of a chain saved by Store.SaveError
+type ErrorRecord struct {
+	Code    int
+	Message string
+	At      time.Time
+}
+
+// SnapshotInterval is the max number of the revisions between two snapshots, so loading the latest state of a
+// chain replays at most SnapshotInterval revisions however long the history is
+const SnapshotInterval = 100
+
+// Backend keeps the revisions of the chains of all the processors
+type Backend interface {
+	// Append saves rev as the next revision of the chain, the Seq of rev is ignored, the assigned one is returned
+	Append(ctx context.Context, processorID, chainID string, rev Revision) (uint64, error)
+	// Revisions returns all the revisions of the chain ordered by Seq
+	Revisions(ctx context.Context, processorID, chainID string) ([]Revision, error)
+	// RevisionsSinceSnapshot returns the revisions of the chain from the latest snapshot ordered by Seq,
+	// all the revisions if there is no snapshot
+	RevisionsSinceSnapshot(ctx context.Context, processorID, chainID string) ([]Revision, error)
+	// Chains returns the chains of the processor which have at least one revision
+	Chains(ctx context.Context, processorID string) ([]string, error)
+
+	SetError(ctx context.Context, processorID, chainID string, record ErrorRecord) error
+	// GetError returns nil if there is no error saved
+	GetError(ctx context.Context, processorID, chainID string) (*ErrorRecord, error)
+}
+
+func cloneTemplates(templates map[uint64][]controller.TemplateInstance) map[uint64][]controller.TemplateInstance {
+	result := make(map[uint64][]controller.TemplateInstance, len(templates))
+	for bn, instances := range templates {
+		result[bn] = slices.Clone(instances)
+	}
+	return result
+}
+
+// diffTemplates returns the blocks whose template instances are new or changed in cur,
+// and the blocks whose template instances are dropped
+func diffTemplates(
+	pre, cur map[uint64][]controller.TemplateInstance,
+) (added map[uint64][]controller.TemplateInstance, removed []uint64) {
+	for bn, instances := range cur {
+		if pi, has := pre[bn]; !has || !reflect.DeepEqual(pi, instances) {
+			if added == nil {
+				added = make(map[uint64][]controller.TemplateInstance)
+			}
+			added[bn] = slices.Clone(instances)
+		}
+	}
+	for bn := range pre {
+		if _, has := cur[bn]; !has {
+			removed = append(removed, bn)
+		}
+	}
+	slices.Sort(removed)
+	return added, removed
+}
+
+// sameCheckpoint compares the checkpoints by the encoded form, the loaded times have a different location
+// from the ones built by the processor, so reflect.DeepEqual can not be used
+func sameCheckpoint(a, b controller.Checkpoint) bool {
+	ea, errA := json.Marshal(a)
+	eb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ea, eb)
+}
+
+// diffCheckpoints returns the checkpoints which are new or changed in cur, and the block numbers of the
+// checkpoints which are dropped. The processor only appends the new checkpoints and sparsifies the old ones,
+// so a revision is usually much smaller than all the checkpoints.
+func diffCheckpoints(pre, cur []controller.Checkpoint) (added []controller.Checkpoint, removed []uint64) {
+	preByBlock := make(map[uint64]controller.Checkpoint, len(pre))
+	for _, cp := range pre {
+		preByBlock[cp.BlockNumber] = cp
+	}
+	curBlocks := make(map[uint64]bool, len(cur))
+	for _, cp := range cur {
+		curBlocks[cp.BlockNumber] = true
+		if pc, has := preByBlock[cp.BlockNumber]; !has || !sameCheckpoint(pc, cp) {
+			added = append(added, cp)
+		}
+	}
+	for _, cp := range pre {
+		if !curBlocks[cp.BlockNumber] {
+			removed = append(removed, cp.BlockNumber)
+		}
+	}
+	return added, removed
+}
+
+// applyCheckpoints returns the checkpoints after the changes of the revision
+func applyCheckpoints(checkpoints []controller.Checkpoint, rev Revision) []controller.Checkpoint {
+	if rev.Snapshot {
+		return slices.Clone(rev.AddedCheckpoints)
+	}
+	dropped := make(map[uint64]bool, len(rev.RemovedCheckpoints)+len(rev.AddedCheckpoints))
+	for _, bn := range rev.RemovedCheckpoints {
+		dropped[bn] = true
+	}
+	for _, cp := range rev.AddedCheckpoints {
+		dropped[cp.BlockNumber] = true
+	}
+	result := make([]controller.Checkpoint, 0, len(checkpoints)+len(rev.AddedCheckpoints))
+	for _, cp := range checkpoints {
+		if !dropped[cp.BlockNumber] {
+			result = append(result, cp)
+		}
+	}
+	result = append(result, rev.AddedCheckpoints...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].BlockNumber < result[j].BlockNumber
+	})
+	return result
+}
+
+// applyTemplates applies the template changes of the revision to templates
+func applyTemplates(templates map[uint64][]controller.TemplateInstance, rev Revision) {
+	if rev.Snapshot {
+		clear(templates)
+	}
+	for _, bn := range rev.RemovedTemplates {
+		delete(templates, bn)
+	}
+	maps.Copy(templates, cloneTemplates(rev.AddedTemplates))
+}
+
+// History is the revisions of a chain ordered by Seq, all of them or the ones from the latest snapshot
+type History []Revision
+
+// LoadHistory returns all the revisions of the chain
+func LoadHistory(ctx context.Context, backend Backend, processorID, chainID string) (History, error) {
+	return backend.Revisions(ctx, processorID, chainID)
+}
+
+// LoadLatestHistory returns the revisions of the chain from the latest snapshot, enough to build the latest state
+func LoadLatestHistory(ctx context.Context, backend Backend, processorID, chainID string) (History, error) {
+	return backend.RevisionsSinceSnapshot(ctx, processorID, chainID)
+}
+
+// base returns the index of the nearest snapshot at or before the i-th revision, 0 if there is none
+func (h History) base(i int) int {
+	for j := i; j > 0; j-- {
+		if h[j].Snapshot {
+			return j
+		}
+	}
+	return 0
+}
+
+// Checkpoints returns the checkpoints of the i-th revision
+func (h History) Checkpoints(i int) []controller.Checkpoint {
+	var checkpoints []controller.Checkpoint
+	for _, rev := range h[h.base(i) : i+1] {
+		checkpoints = applyCheckpoints(checkpoints, rev)
+	}
+	return checkpoints
+}
+
+// Templates returns the template instances of the i-th revision
+func (h History) Templates(i int) map[uint64][]controller.TemplateInstance {
+	templates := make(map[uint64][]controller.TemplateInstance)
+	for _, rev := range h[h.base(i) : i+1] {
+		applyTemplates(templates, rev)
+	}
+	return templates
+}
+
+// Latest returns the index of the latest revision, -1 if there is no revision
+func (h History) Latest() int {
+	return len(h) - 1
+}
+
+// FindCheckpoint returns the latest revision which contains the checkpoint of the block number,
+// and the index of the checkpoint in the checkpoints of the revision, -1 if not found
+func (h History) FindCheckpoint(blockNumber uint64) (revIndex int, cpIndex int) {
+	// only track whether the checkpoint is in each revision, the checkpoints are built once for the found one
+	revIndex = -1
+	var has bool
+	for i, rev := range h {
+		if rev.Snapshot || slices.Contains(rev.RemovedCheckpoints, blockNumber) {
+			has = false
+		}
+		if slices.ContainsFunc(rev.AddedCheckpoints, func(cp controller.Checkpoint) bool {
+			return cp.BlockNumber == blockNumber
+		}) {
+			has = true
+		}
+		if has {
+			revIndex = i
+		}
+	}
+	if revIndex < 0 {
+		return -1, -1
+	}
+	checkpoints := h.Checkpoints(revIndex)
+	cpIndex, _ = slices.BinarySearchFunc(checkpoints, blockNumber, func(cp controller.Checkpoint, bn uint64) int {
+		return cmp.Compare(cp.BlockNumber, bn)
+	})
+	return revIndex, cpIndex
+}
+
+// TemplatesBetween returns the template instances of the latest revision added in the blocks [from, to]
+func (h History) TemplatesBetween(from, to uint64) map[uint64][]controller.TemplateInstance {
+	result := make(map[uint64][]controller.TemplateInstance)
+	if len(h) == 0 {
+		return result
+	}
+	for bn, instances := range h.Templates(h.Latest()) {
+		if bn >= from && bn <= to {
+			result[bn] = instances
+		}
+	}
+	return result
+}
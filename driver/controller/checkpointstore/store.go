@@ -0,0 +1,164 @@
+package checkpointstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/controller"
+)
+
+var (
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	// ErrConcurrentAppend means another writer has appended the revision with the same seq
+	ErrConcurrentAppend = errors.New("concurrent append")
+)
+
+var _ controller.CheckpointStore = (*Store)(nil)
+
+// Store is the controller.CheckpointStore of one chain of a processor, every Save appends a new revision
+// to the backend, so all the history is kept and there is no size limit like the processor service one.
+type Store struct {
+	backend     Backend
+	processorID string
+	chainID     string
+
+	// checkpoints and template instances of the latest revision, nil templates means not loaded
+	checkpoints []controller.Checkpoint
+	templates   map[uint64][]controller.TemplateInstance
+	// sinceSnapshot is the number of the revisions after the latest snapshot
+	sinceSnapshot int
+}
+
+func NewStore(backend Backend, processorID, chainID string) *Store {
+	return &Store{
+		backend:     backend,
+		processorID: processorID,
+		chainID:     chainID,
+	}
+}
+
+func (s *Store) Load(ctx context.Context) (
+	checkpoints []controller.Checkpoint,
+	templates map[uint64][]controller.TemplateInstance,
+	err error,
+) {
+	h, err := LoadLatestHistory(ctx, s.backend, s.processorID, s.chainID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "load checkpoints of chain %s failed", s.chainID)
+	}
+	s.setLatest(h)
+	return slices.Clone(s.checkpoints), cloneTemplates(s.templates), nil
+}
+
+// setLatest keeps the latest state of h, which contains at least the revisions from the latest snapshot
+func (s *Store) setLatest(h History) {
+	if len(h) == 0 {
+		// the first revision will be a snapshot
+		s.checkpoints, s.templates, s.sinceSnapshot = nil, make(map[uint64][]controller.TemplateInstance), SnapshotInterval
+		return
+	}
+	s.checkpoints, s.templates = h.Checkpoints(h.Latest()), h.Templates(h.Latest())
+	s.sinceSnapshot = h.Latest() - h.base(h.Latest())
+	if !h[h.base(h.Latest())].Snapshot {
+		s.sinceSnapshot++
+	}
+}
+
+func (s *Store) Save(
+	ctx context.Context,
+	checkpoints []controller.Checkpoint,
+	templates map[uint64][]controller.TemplateInstance,
+	agentStat map[string]int,
+) error {
+	if s.templates == nil {
+		if _, _, err := s.Load(ctx); err != nil {
+			return err
+		}
+	}
+	return s.append(ctx, Revision{
+		SavedAt:   time.Now(),
+		AgentStat: agentStat,
+	}, checkpoints, templates)
+}
+
+// append saves the revision with the changes from the latest revision to checkpoints and templates
+func (s *Store) append(
+	ctx context.Context,
+	rev Revision,
+	checkpoints []controller.Checkpoint,
+	templates map[uint64][]controller.TemplateInstance,
+) error {
+	rev.Snapshot = s.sinceSnapshot >= SnapshotInterval
+	if rev.Snapshot {
+		rev.AddedCheckpoints, rev.AddedTemplates = slices.Clone(checkpoints), cloneTemplates(templates)
+	} else {
+		rev.AddedCheckpoints, rev.RemovedCheckpoints = diffCheckpoints(s.checkpoints, checkpoints)
+		rev.AddedTemplates, rev.RemovedTemplates = diffTemplates(s.templates, templates)
+	}
+	seq, err := s.backend.Append(ctx, s.processorID, s.chainID, rev)
+	if err != nil {
+		return errors.Wrapf(err, "save checkpoints of chain %s failed", s.chainID)
+	}
+	s.checkpoints, s.templates = slices.Clone(checkpoints), cloneTemplates(templates)
+	if rev.Snapshot {
+		s.sinceSnapshot = 0
+	} else {
+		s.sinceSnapshot++
+	}
+	_, logger := log.FromContext(ctx, "processorID", s.processorID, "chainID", s.chainID)
+	logger.Debugw("saved checkpoints", "seq", seq, "checkpoint", latestCheckpoint(checkpoints),
+		"addedCheckpoints", len(rev.AddedCheckpoints), "removedCheckpoints", len(rev.RemovedCheckpoints),
+		"addedTemplates", len(rev.AddedTemplates), "removedTemplates", len(rev.RemovedTemplates), "snapshot", rev.Snapshot)
+	return nil
+}
+
+func (s *Store) SaveError(ctx context.Context, extErr *controller.ExternalError) error {
+	record := ErrorRecord{Code: extErr.Code(), Message: extErr.Error(), At: time.Now()}
+	if err := s.backend.SetError(ctx, s.processorID, s.chainID, record); err != nil {
+		return errors.Wrapf(err, "save error of chain %s failed", s.chainID)
+	}
+	return nil
+}
+
+// Rewind appends a revision which is the same as the one with the checkpoint of the block number, with
+// the checkpoints and template instances after the block number dropped. When the processor restarts,
+// it will continue from the checkpoint and the data generated after it will be cleaned.
+// Usually the checkpoint is still in the revisions from the latest snapshot, all the revisions are loaded only
+// if it has been dropped before.
+func (s *Store) Rewind(ctx context.Context, blockNumber uint64) (*controller.Checkpoint, error) {
+	h, err := LoadLatestHistory(ctx, s.backend, s.processorID, s.chainID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load checkpoints of chain %s failed", s.chainID)
+	}
+	revIndex, cpIndex := h.FindCheckpoint(blockNumber)
+	if revIndex < 0 && len(h) > 0 && h[0].Seq > 0 {
+		if h, err = LoadHistory(ctx, s.backend, s.processorID, s.chainID); err != nil {
+			return nil, errors.Wrapf(err, "load checkpoints of chain %s failed", s.chainID)
+		}
+		revIndex, cpIndex = h.FindCheckpoint(blockNumber)
+	}
+	if revIndex < 0 {
+		return nil, errors.Wrapf(ErrCheckpointNotFound, "no checkpoint of block %d in chain %s", blockNumber, s.chainID)
+	}
+	templates := make(map[uint64][]controller.TemplateInstance)
+	for bn, instances := range h.Templates(revIndex) {
+		if bn <= blockNumber {
+			templates[bn] = instances
+		}
+	}
+	checkpoints := h.Checkpoints(revIndex)[:cpIndex+1]
+	s.setLatest(h)
+	rev := Revision{
+		SavedAt:   time.Now(),
+		RewindTo:  &blockNumber,
+		AgentStat: h[h.Latest()].AgentStat,
+	}
+	if err = s.append(ctx, rev, checkpoints, templates); err != nil {
+		return nil, err
+	}
+	return latestCheckpoint(checkpoints), nil
+}
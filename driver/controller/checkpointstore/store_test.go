@@ -0,0 +1,269 @@
+package checkpointstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/driver/controller"
+)
+
+func makeCheckpoints(bs ...uint64) []controller.Checkpoint {
+	r := make([]controller.Checkpoint, len(bs))
+	for i, bn := range bs {
+		r[i] = controller.Checkpoint{
+			BlockNumber:       bn,
+			BlockHash:         "0x" + string(rune('a'+bn%26)),
+			BlockTime:         time.Unix(int64(1700000000+bn), 0).UTC(),
+			LatestBlockNumber: 1000,
+			LatestBlockTime:   time.Unix(1700001000, 0).UTC(),
+		}
+	}
+	return r
+}
+
+func makeTemplate(id int32, address string, startBlock uint64) controller.TemplateInstance {
+	return controller.TemplateInstance{
+		TemplateID:   id,
+		TemplateName: "tpl",
+		Address:      address,
+		BlockRange:   controller.BlockRange{StartBlock: startBlock},
+	}
+}
+
+// runBackendTests checks that a Store over the backend behaves like the in-memory testCheckpointStore
+// of the controller package: Load always returns exactly what the last Save stored.
+func runBackendTests(t *testing.T, backend Backend, processorID string) {
+	ctx := context.Background()
+	tplA, tplB, tplC := makeTemplate(1, "0xa", 0), makeTemplate(1, "0xb", 1), makeTemplate(2, "0xc", 2)
+
+	t.Run("empty", func(t *testing.T) {
+		cs := NewStore(backend, processorID, "1")
+		checkpoints, templates, err := cs.Load(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, checkpoints)
+		assert.Empty(t, templates)
+		record, err := backend.GetError(ctx, processorID, "1")
+		assert.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		cs := NewStore(backend, processorID, "1")
+		_, templates, err := cs.Load(ctx)
+		require.NoError(t, err)
+
+		templates[0] = []controller.TemplateInstance{tplA}
+		require.NoError(t, cs.Save(ctx, makeCheckpoints(0), templates, map[string]int{"agent": 1}))
+		checkpoints, loaded, err := cs.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(0), checkpoints)
+		assert.Equal(t, templates, loaded)
+
+		// the controller mutates the loaded templates and saves them again
+		loaded[1] = []controller.TemplateInstance{tplB}
+		require.NoError(t, cs.Save(ctx, makeCheckpoints(0, 1), loaded, nil))
+
+		delete(loaded, 1)
+		loaded[2] = []controller.TemplateInstance{tplC}
+		require.NoError(t, cs.Save(ctx, makeCheckpoints(0, 2), loaded, nil))
+
+		// a new store is what the restarted processor sees
+		checkpoints, reloaded, err := NewStore(backend, processorID, "1").Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(0, 2), checkpoints)
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{
+			0: {tplA},
+			2: {tplC},
+		}, reloaded)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		h, err := LoadHistory(ctx, backend, processorID, "1")
+		require.NoError(t, err)
+		require.Len(t, h, 3)
+		for i, rev := range h {
+			assert.Equal(t, uint64(i), rev.Seq)
+		}
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{0: {tplA}}, h[0].AddedTemplates)
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{1: {tplB}}, h[1].AddedTemplates)
+		assert.Empty(t, h[1].RemovedTemplates)
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{2: {tplC}}, h[2].AddedTemplates)
+		assert.Equal(t, []uint64{1}, h[2].RemovedTemplates)
+		assert.Equal(t, map[string]int{"agent": 1}, h[0].AgentStat)
+
+		// only the checkpoints made since the previous revision are kept in a revision
+		assert.Equal(t, makeCheckpoints(0), h[0].AddedCheckpoints)
+		assert.Equal(t, makeCheckpoints(1), h[1].AddedCheckpoints)
+		assert.Empty(t, h[1].RemovedCheckpoints)
+		assert.Equal(t, makeCheckpoints(2), h[2].AddedCheckpoints)
+		assert.Equal(t, []uint64{1}, h[2].RemovedCheckpoints)
+		assert.Equal(t, makeCheckpoints(0, 1), h.Checkpoints(1))
+
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{
+			0: {tplA},
+			1: {tplB},
+		}, h.Templates(1))
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{
+			2: {tplC},
+		}, h.TemplatesBetween(1, 2))
+
+		revIndex, cpIndex := h.FindCheckpoint(1)
+		assert.Equal(t, 1, revIndex)
+		assert.Equal(t, 1, cpIndex)
+		revIndex, cpIndex = h.FindCheckpoint(3)
+		assert.Equal(t, -1, revIndex)
+		assert.Equal(t, -1, cpIndex)
+	})
+
+	t.Run("rewind", func(t *testing.T) {
+		cs := NewStore(backend, processorID, "1")
+		_, err := cs.Rewind(ctx, 3)
+		assert.True(t, errors.Is(err, ErrCheckpointNotFound))
+
+		// checkpoint of block 1 is only in the second revision, template instance of block 1 comes back
+		cp, err := cs.Rewind(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(1)[0], *cp)
+		checkpoints, templates, err := NewStore(backend, processorID, "1").Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(0, 1), checkpoints)
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{
+			0: {tplA},
+			1: {tplB},
+		}, templates)
+
+		h, err := LoadHistory(ctx, backend, processorID, "1")
+		require.NoError(t, err)
+		require.Len(t, h, 4)
+		require.NotNil(t, h[3].RewindTo)
+		assert.Equal(t, uint64(1), *h[3].RewindTo)
+
+		// save after rewind continues from the rewound templates
+		templates[3] = []controller.TemplateInstance{tplC}
+		require.NoError(t, cs.Save(ctx, makeCheckpoints(0, 1, 3), templates, nil))
+		h, err = LoadHistory(ctx, backend, processorID, "1")
+		require.NoError(t, err)
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{3: {tplC}}, h[h.Latest()].AddedTemplates)
+		assert.Empty(t, h[h.Latest()].RemovedTemplates)
+	})
+
+	t.Run("save error", func(t *testing.T) {
+		cs := NewStore(backend, processorID, "1")
+		extErr := controller.NewExternalError(controller.ErrCodeFetchDataFailed, errors.New("boom"))
+		require.NoError(t, cs.SaveError(ctx, extErr))
+		record, err := backend.GetError(ctx, processorID, "1")
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, extErr.Code(), record.Code)
+		assert.Equal(t, extErr.Error(), record.Message)
+
+		// saved again, only the latest one is kept
+		require.NoError(t, cs.SaveError(ctx, controller.NewExternalError(controller.ErrCodeFetchDataFailed, errors.New("boom2"))))
+		record, err = backend.GetError(ctx, processorID, "1")
+		require.NoError(t, err)
+		assert.Contains(t, record.Message, "boom2")
+	})
+
+	t.Run("chains", func(t *testing.T) {
+		require.NoError(t, NewStore(backend, processorID, "10").Save(ctx, makeCheckpoints(5), nil, nil))
+		require.NoError(t, NewStore(backend, processorID+"x", "2").Save(ctx, makeCheckpoints(5), nil, nil))
+		chains, err := backend.Chains(ctx, processorID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "10"}, chains)
+		chains, err = backend.Chains(ctx, processorID+"y")
+		assert.NoError(t, err)
+		assert.Empty(t, chains)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		snapshotProcessorID := processorID + "s"
+		cs := NewStore(backend, snapshotProcessorID, "1")
+		_, templates, err := cs.Load(ctx)
+		require.NoError(t, err)
+		// the first revision is a snapshot, the checkpoint of block 1 is dropped by the second one and the revision
+		// SnapshotInterval+1 is the next snapshot
+		templates[1] = []controller.TemplateInstance{tplB}
+		require.NoError(t, cs.Save(ctx, makeCheckpoints(0, 1), templates, nil))
+		for bn := uint64(2); bn <= SnapshotInterval+5; bn++ {
+			require.NoError(t, cs.Save(ctx, makeCheckpoints(0, bn), templates, nil))
+		}
+
+		h, err := LoadLatestHistory(ctx, backend, snapshotProcessorID, "1")
+		require.NoError(t, err)
+		require.Len(t, h, 4)
+		assert.True(t, h[0].Snapshot)
+		assert.Equal(t, uint64(SnapshotInterval+1), h[0].Seq)
+		assert.Equal(t, makeCheckpoints(0, SnapshotInterval+2), h[0].AddedCheckpoints)
+		assert.Equal(t, templates, h[0].AddedTemplates)
+		assert.Equal(t, makeCheckpoints(0, SnapshotInterval+5), h.Checkpoints(h.Latest()))
+
+		checkpoints, loaded, err := NewStore(backend, snapshotProcessorID, "1").Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(0, SnapshotInterval+5), checkpoints)
+		assert.Equal(t, templates, loaded)
+
+		// the checkpoint of block 1 is only in the full history
+		cp, err := NewStore(backend, snapshotProcessorID, "1").Rewind(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(1)[0], *cp)
+		checkpoints, _, err = NewStore(backend, snapshotProcessorID, "1").Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(0, 1), checkpoints)
+
+		h, err = LoadHistory(ctx, backend, snapshotProcessorID, "1")
+		require.NoError(t, err)
+		assert.Len(t, h, SnapshotInterval+6)
+		revIndex, cpIndex := h.FindCheckpoint(SnapshotInterval + 2)
+		assert.Equal(t, SnapshotInterval+1, revIndex)
+		assert.Equal(t, 1, cpIndex)
+	})
+
+	t.Run("cli", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, RunCLI(ctx, backend, []string{"chains", "-processor", processorID}, &out))
+		assert.Contains(t, out.String(), "1\trevisions=5\tcheckpoint=3")
+		assert.Contains(t, out.String(), "boom2")
+		assert.Contains(t, out.String(), "10\trevisions=1\tcheckpoint=5")
+
+		out.Reset()
+		assert.NoError(t, RunCLI(ctx, backend, []string{"list", "-processor", processorID, "-chain", "1"}, &out))
+		assert.Contains(t, out.String(), "revision 3\t")
+		assert.Contains(t, out.String(), "rewindTo=1")
+		assert.Contains(t, out.String(), "  block=3\t")
+
+		out.Reset()
+		assert.NoError(t, RunCLI(ctx, backend,
+			[]string{"templates", "-processor", processorID, "-chain", "1", "-from", "1", "-to", "2"}, &out))
+		assert.Equal(t, "block=1\ttemplate=1:tpl\taddress=0xb\tlabels=\tremoved=false\n", out.String())
+
+		out.Reset()
+		assert.NoError(t, RunCLI(ctx, backend,
+			[]string{"rewind", "-processor", processorID, "-chain", "1", "-block", "0"}, &out))
+		assert.Contains(t, out.String(), "to checkpoint of block 0")
+		checkpoints, templates, err := NewStore(backend, processorID, "1").Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, makeCheckpoints(0), checkpoints)
+		assert.Equal(t, map[uint64][]controller.TemplateInstance{0: {tplA}}, templates)
+
+		out.Reset()
+		assert.Error(t, RunCLI(ctx, backend, []string{"list", "-processor", processorID}, &out))
+		assert.Error(t, RunCLI(ctx, backend, []string{"unknown"}, &out))
+	})
+}
+
+func Test_PebbleBackend(t *testing.T) {
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	runBackendTests(t, NewPebbleBackend(db, "cp/"), "p1")
+}
@@ -19,6 +19,14 @@ var (
 	ClientMaxConcurrency      = envconf.LoadUInt64("SENTIO_CLIENT_MAX_CONCURRENCY", 100, envconf.WithMin(10))
 	PrintProcessedInterval    = envconf.LoadDuration("SENTIO_PRINT_PROCESSED_INTERVAL", time.Second)
 	SkipStartBlockValidation  = envconf.LoadBool("SENTIO_SKIP_START_BLOCK_VALIDATION", false)
+
+	// RangeSplitMinSubRangeSize, RangeSplitSafeDistance and RangeSplitMaxBufferedBlocks are the defaults of
+	// RangeSplitConfig, see RangeSplitConfig for details
+	RangeSplitMinSubRangeSize = envconf.LoadUInt64("SENTIO_RANGE_SPLIT_MIN_SUB_RANGE_SIZE", 10000,
+		envconf.WithMin(1))
+	RangeSplitSafeDistance      = envconf.LoadUInt64("SENTIO_RANGE_SPLIT_SAFE_DISTANCE", 1000)
+	RangeSplitMaxBufferedBlocks = envconf.LoadUInt64("SENTIO_RANGE_SPLIT_MAX_BUFFERED_BLOCKS", 1000,
+		envconf.WithMin(1))
 )
 
 const (
@@ -0,0 +1,511 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"sentioxyz/sentio-core/common/errgroup"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/service/processor/models"
+
+	"github.com/pkg/errors"
+)
+
+// CrossChainController drives the chains of one processor as a single stream. The blocks of all chains are merged
+// by block time and their tasks are executed one by one in that order, so handlers on different chains observe the
+// effects of each other in a deterministic order. It is opt-in, by default each chain runs in its own MainController.
+//
+// Every chain still has its own BlockBuilder and CheckpointController. Each checkpoint carries the watermark of the
+// merged stream when its block was dispatched, which includes the latest checkpoint of every other chain at that
+// time. At the start of each round, a chain is rolled back only if its checkpoints are ahead of the watermark, that
+// is they were made after blocks of another chain which are lost because they were not saved or were reorged, so no
+// chain resumes after blocks that are still to be processed on another chain. A reorg or a new template on any
+// chain ends the round, and the merged stream is rebuilt from the aligned checkpoints in the next round.
+type CrossChainController struct {
+	// sorted by chain id, the order is also used to break ties of the block time
+	chains    []*MainController
+	processor *models.Processor
+}
+
+func NewCrossChainController(processor *models.Processor, ctrls map[string]*MainController) *CrossChainController {
+	c := &CrossChainController{processor: processor}
+	for _, chainID := range utils.GetOrderedMapKeys(ctrls) {
+		c.chains = append(c.chains, ctrls[chainID])
+	}
+	return c
+}
+
+func (c *CrossChainController) Main(ctx context.Context) error {
+	return keepRun(ctx, c.run, c.saveError)
+}
+
+// saveError saves the error to all chains, because the merged stream is blocked as a whole
+func (c *CrossChainController) saveError(ctx context.Context, extErr *ExternalError) (err error) {
+	for _, ch := range c.chains {
+		if saveErr := ch.checkpointCtrl.SaveError(ctx, extErr); saveErr != nil {
+			err = errors.Wrapf(saveErr, "save error of chain %s failed", ch.chainID)
+		}
+	}
+	return err
+}
+
+func (c *CrossChainController) Snapshot() any {
+	chains := make(map[string]any)
+	for _, ch := range c.chains {
+		chains[ch.chainID] = ch.Snapshot()
+	}
+	return map[string]any{
+		"crossChainOrdered": true,
+		"chains":            chains,
+	}
+}
+
+// CrossChainWatermarkKey is the key of the merged stream watermark in the checkpoint data
+const CrossChainWatermarkKey = "crossChainWatermark"
+
+// crossChainWatermark is the progress of the merged stream when a block is dispatched, the tasks of all blocks
+// not later than Time have been dispatched, Chains is the block number of the latest checkpoint of each other chain,
+// chains without any checkpoint are not included.
+type crossChainWatermark struct {
+	Time   time.Time         `json:"time"`
+	Chains map[string]uint64 `json:"chains,omitempty"`
+}
+
+func loadCrossChainWatermark(cp Checkpoint) (*crossChainWatermark, error) {
+	raw, has := cp.Data[CrossChainWatermarkKey]
+	if !has {
+		return nil, nil
+	}
+	var wm crossChainWatermark
+	if err := json.Unmarshal([]byte(raw), &wm); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s %q in checkpoint %s", CrossChainWatermarkKey, raw, cp.String())
+	}
+	return &wm, nil
+}
+
+// watermarkData returns the checkpoint data of the block of the chain with the current watermark of the merged stream
+func (c *CrossChainController) watermarkData(ch *MainController, blockData BlockData) (map[string]string, error) {
+	wm := crossChainWatermark{Time: blockData.GetBlockTime(), Chains: make(map[string]uint64)}
+	for _, other := range c.chains {
+		if other == ch {
+			continue
+		}
+		if cp := other.checkpointCtrl.GetLatestCheckpoint(); cp != nil {
+			wm.Chains[other.chainID] = cp.BlockNumber
+		}
+	}
+	raw, err := json.Marshal(wm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s failed", CrossChainWatermarkKey)
+	}
+	data := maps.Clone(blockData.CheckpointData())
+	if data == nil {
+		data = make(map[string]string)
+	}
+	data[CrossChainWatermarkKey] = string(raw)
+	return data, nil
+}
+
+// alignCheckpoints rolls back the chains whose checkpoints are ahead of the watermark of the merged stream, that is
+// the checkpoints were made after a block of another chain which has no checkpoint now. Rolling back a chain may make
+// others ahead, so it is repeated until all chains are consistent.
+// If the latest checkpoint of any chain has no watermark, which was not made in the merged stream, all chains are
+// rolled back to the earliest block time of their latest checkpoints instead.
+func (c *CrossChainController) alignCheckpoints(ctx context.Context) *ExternalError {
+	latest := make(map[string]*Checkpoint)
+	var withoutWatermark bool
+	for _, ch := range c.chains {
+		cp := ch.checkpointCtrl.GetLatestCheckpoint()
+		if cp == nil {
+			continue
+		}
+		latest[ch.chainID] = cp
+		if _, has := cp.Data[CrossChainWatermarkKey]; !has {
+			withoutWatermark = true
+		}
+	}
+	if withoutWatermark {
+		return c.alignCheckpointsByTime(ctx)
+	}
+	var loadErr error
+	// ahead returns true if the checkpoint was made after a block of another chain which has no checkpoint now
+	ahead := func(cp Checkpoint) bool {
+		wm, err := loadCrossChainWatermark(cp)
+		if err != nil {
+			loadErr = err
+			return true
+		}
+		if wm == nil {
+			return false
+		}
+		for chainID, blockNumber := range wm.Chains {
+			if cur, has := latest[chainID]; !has || cur.BlockNumber < blockNumber {
+				return true
+			}
+		}
+		return false
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, ch := range c.chains {
+			cp := latest[ch.chainID]
+			if cp == nil || !ahead(*cp) {
+				continue
+			}
+			if loadErr != nil {
+				return NewExternalError(ErrCodeInvalidCheckpointData, loadErr)
+			}
+			chainCtx, _ := log.FromContext(ctx, "chain_id", ch.chainID)
+			valid := func(cp Checkpoint) bool {
+				return !ahead(cp)
+			}
+			if extErr := ch.checkpointCtrl.RollbackCheckpoint(chainCtx, valid); extErr != nil {
+				return extErr
+			}
+			if loadErr != nil {
+				return NewExternalError(ErrCodeInvalidCheckpointData, loadErr)
+			}
+			if cp = ch.checkpointCtrl.GetLatestCheckpoint(); cp != nil {
+				latest[ch.chainID] = cp
+			} else {
+				delete(latest, ch.chainID)
+			}
+			changed = true
+		}
+	}
+	return nil
+}
+
+// alignCheckpointsByTime rolls back all chains to the earliest block time of their latest checkpoints.
+// Chains without any checkpoint have not processed anything yet, so they do not limit the others.
+func (c *CrossChainController) alignCheckpointsByTime(ctx context.Context) *ExternalError {
+	var bound *time.Time
+	for _, ch := range c.chains {
+		if cp := ch.checkpointCtrl.GetLatestCheckpoint(); cp != nil && (bound == nil || cp.BlockTime.Before(*bound)) {
+			bound = utils.WrapPointer(cp.BlockTime)
+		}
+	}
+	if bound == nil {
+		return nil
+	}
+	valid := func(cp Checkpoint) bool {
+		return !cp.BlockTime.After(*bound)
+	}
+	for _, ch := range c.chains {
+		chainCtx, _ := log.FromContext(ctx, "chain_id", ch.chainID)
+		if extErr := ch.checkpointCtrl.RollbackCheckpoint(chainCtx, valid); extErr != nil {
+			return extErr
+		}
+	}
+	return nil
+}
+
+func (c *CrossChainController) run(ctx context.Context) error {
+	_, logger := log.FromContext(ctx)
+	logger.Info("cross chain run started")
+	defer func() {
+		logger.Info("cross chain run finished")
+	}()
+
+	if extErr := c.alignCheckpoints(ctx); extErr != nil {
+		return extErr
+	}
+	for _, ch := range c.chains {
+		chainCtx, chainLogger := log.FromContext(ctx, "chain_id", ch.chainID)
+		checkpoint, templates := ch.checkpointCtrl.GetLatestCheckpoint(), ch.checkpointCtrl.GetTemplates()
+		agentStat, extErr := ch.blockBuilder.Start(chainCtx, checkpoint, templates)
+		if extErr != nil {
+			return extErr
+		}
+		defer ch.blockBuilder.Finish()
+		if extErr = ch.checkpointCtrl.Ready(chainCtx, agentStat); extErr != nil {
+			return extErr
+		}
+		chainLogger.Infow("chain stream is ready",
+			"checkpoint", utils.NullOrToString(checkpoint),
+			"templates", utils.CountMap(templates))
+		N.DriverStarted(chainCtx, c.processor, ch.chainID, CountTemplatesByID(templates))
+	}
+	logger.Infow("merged stream is ready", "chains", len(c.chains))
+
+	g, gctx := errgroup.WithContext(ctx)
+	allMade := make(chan struct{})
+	g.Go(func() error {
+		if err := c.process(gctx); err != nil {
+			return err
+		}
+		close(allMade)
+		return nil
+	})
+	g.Go(func() error {
+		return c.keepSave(gctx, allMade)
+	})
+	return g.Wait()
+}
+
+type crossChainHead struct {
+	chain       *MainController
+	chainCtx    context.Context
+	blockNumber uint64
+	blockData   BlockData
+	progressBar ProgressBar
+}
+
+// crossChainEvent is sent by the fetching goroutine of a chain, only one of the fields is set
+type crossChainEvent struct {
+	index int
+	// the next block with data
+	head *crossChainHead
+	// the chain has no more data until the time, the next block of the chain will not be earlier
+	watermark *time.Time
+	// the chain has no more block
+	end bool
+	// reorg is detected, reorgBlockNumber is the block being fetched
+	reorg            *uint64
+	reorgBlockNumber uint64
+	err              error
+}
+
+// process keeps picking the earliest block among the next blocks of all chains and processing it,
+// until there are no more blocks on every chain.
+// The chains are fetched concurrently. A block is processed only if every other chain either has a later next
+// block or is known to have no data until a later time, so a chain having no data for a long range or waiting
+// for new blocks does not block the others.
+func (c *CrossChainController) process(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// stop the fetching goroutines before the block builders are finished
+	defer wg.Wait()
+	defer cancel()
+
+	events := make(chan crossChainEvent)
+	resume := make([]chan struct{}, len(c.chains))
+	for i, ch := range c.chains {
+		resume[i] = make(chan struct{}, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.keepFetch(ctx, i, ch, events, resume[i])
+		}()
+	}
+
+	heads := make([]*crossChainHead, len(c.chains))
+	watermarks := make([]*time.Time, len(c.chains))
+	done := make([]bool, len(c.chains))
+	for {
+		next := -1
+		for i := range c.chains {
+			// strictly before, so the chain with the smaller id goes first in a tie
+			if heads[i] != nil && (next < 0 || heads[i].blockData.GetBlockTime().Before(heads[next].blockData.GetBlockTime())) {
+				next = i
+			}
+		}
+		if next >= 0 && c.passed(next, heads[next].blockData.GetBlockTime(), heads, watermarks, done) {
+			if err := c.processBlock(heads[next]); err != nil {
+				return err
+			}
+			watermarks[next] = utils.WrapPointer(heads[next].blockData.GetBlockTime())
+			heads[next] = nil
+			resume[next] <- struct{}{}
+			continue
+		}
+		if next < 0 && !slices.Contains(done, false) {
+			return nil
+		}
+		var ev crossChainEvent
+		select {
+		case ev = <-events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		switch {
+		case ev.err != nil:
+			return ev.err
+		case ev.reorg != nil:
+			ch := c.chains[ev.index]
+			chainCtx, _ := log.FromContext(ctx, "chain_id", ch.chainID)
+			// other chains will be aligned to this chain at the start of the next round
+			if cleanErr := ch.checkpointCtrl.CleanCheckpoint(chainCtx, ev.reorgBlockNumber, *ev.reorg); cleanErr != nil {
+				return cleanErr
+			}
+			N.ReorgDetected(chainCtx, c.processor, ch.chainID)
+			return ErrInternalReorgDetected
+		case ev.end:
+			done[ev.index] = true
+		case ev.head != nil:
+			heads[ev.index] = ev.head
+		case ev.watermark != nil:
+			if watermarks[ev.index] == nil || ev.watermark.After(*watermarks[ev.index]) {
+				watermarks[ev.index] = ev.watermark
+			}
+		}
+	}
+}
+
+// passed returns true if no chain other than the chain of the index can have a block to be processed before
+// the block time. Chains with the same block time and a smaller index go first.
+func (c *CrossChainController) passed(
+	index int,
+	blockTime time.Time,
+	heads []*crossChainHead,
+	watermarks []*time.Time,
+	done []bool,
+) bool {
+	for i := range c.chains {
+		if i == index || done[i] || heads[i] != nil {
+			// the heads are not earlier than the picked one
+			continue
+		}
+		if watermarks[i] == nil || watermarks[i].Before(blockTime) || (watermarks[i].Equal(blockTime) && i < index) {
+			return false
+		}
+	}
+	return true
+}
+
+// keepFetch fetches the blocks of the chain and sends them to events. Blocks without data have nothing to process
+// and no checkpoint need to be made for them, so they are skipped, but the time of the latest block is sent as the
+// watermark once the chain has caught up with it. After sending a block with data, it waits for resume before
+// fetching the next block.
+func (c *CrossChainController) keepFetch(
+	ctx context.Context,
+	index int,
+	ch *MainController,
+	events chan<- crossChainEvent,
+	resume <-chan struct{},
+) {
+	chainCtx, logger := log.FromContext(ctx, "chain_id", ch.chainID)
+	send := func(ev crossChainEvent) bool {
+		ev.index = index
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		fetchStartAt := time.Now()
+		blockNumber, blockData, progressBar, reorg, getErr := ch.blockBuilder.Next(chainCtx)
+		ch.analyser.fetchWait(time.Since(fetchStartAt))
+		if getErr != nil {
+			if errors.Is(getErr, ErrInternalNeedUpgrade) {
+				send(crossChainEvent{err: NewExternalError(ErrCodeNeedUpgrade, getErr)})
+			} else {
+				send(crossChainEvent{err: NewExternalError(ErrCodeFetchDataFailed, getErr)})
+			}
+			return
+		}
+		if reorg != nil {
+			send(crossChainEvent{reorg: reorg, reorgBlockNumber: blockNumber})
+			return
+		}
+		if !progressBar.FullBlockRange.Contains(blockNumber) || (ch.stopBlock != nil && blockNumber > *ch.stopBlock) {
+			logger.Infow("no more block data", "blockNumber", blockNumber, "full", progressBar.FullBlockRange.String())
+			send(crossChainEvent{end: true})
+			return
+		}
+		if blockData != nil {
+			head := &crossChainHead{
+				chain:       ch,
+				chainCtx:    chainCtx,
+				blockNumber: blockNumber,
+				blockData:   blockData,
+				progressBar: progressBar,
+			}
+			if !send(crossChainEvent{head: head}) {
+				return
+			}
+			select {
+			case <-resume:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		if latest := progressBar.LatestBlock; latest != nil && blockNumber >= latest.GetBlockNumber() {
+			if !send(crossChainEvent{watermark: utils.WrapPointer(latest.GetBlockTime())}) {
+				return
+			}
+		}
+	}
+}
+
+func (c *CrossChainController) processBlock(head *crossChainHead) error {
+	ch := head.chain
+	taskList := head.blockData.GetTaskList()
+	checkpointData, err := c.watermarkData(ch, head.blockData)
+	if err != nil {
+		return NewExternalError(ErrCodeInvalidCheckpointData, err)
+	}
+	dataSummary := BlockDataSummary{
+		BlockNumber:     head.blockData.GetBlockNumber(),
+		BlockParentHash: head.blockData.GetBlockParentHash(),
+		BlockHash:       head.blockData.GetBlockHash(),
+		BlockTime:       head.blockData.GetBlockTime(),
+		TaskCount:       len(taskList),
+		CheckpointData:  checkpointData,
+	}
+	// tasks are executed one by one, that is what makes the order across chains
+	for i, task := range taskList {
+		task.Init(head.chainCtx, TaskIndex{
+			Global:       ch.bindingIndex.Add(1),
+			InBlock:      i,
+			TotalInBlock: len(taskList),
+			ProcessID:    processIDGen.Add(1),
+		}, head.progressBar)
+		startAt := time.Now()
+		if taskErr := task.Exec(head.chainCtx, ch.checkpointCtrl); taskErr != nil {
+			return taskErr
+		}
+		ch.analyser.taskComplete(task.GetHandlerID().String(), time.Since(startAt), 0)
+	}
+	startAt := time.Now()
+	hasNewTpl, makeErr := ch.checkpointCtrl.MakeCheckpoint(head.chainCtx, dataSummary, head.progressBar)
+	if makeErr != nil {
+		return makeErr
+	} else if hasNewTpl {
+		return ErrInternalHasNewTemplate
+	}
+	ch.analyser.makeCheckpoint(time.Since(startAt))
+	return nil
+}
+
+// keepSave saves the checkpoints of all chains in the same pass, instead of running KeepSave for each chain,
+// to keep the saved progress of the chains close to each other
+func (c *CrossChainController) keepSave(ctx context.Context, allMade chan struct{}) error {
+	_, logger := log.FromContext(ctx)
+	logger.Info("keep save checkpoints of all chains started")
+	defer func() {
+		logger.Info("keep save checkpoints of all chains finished")
+	}()
+	saveAll := func(all bool) error {
+		for _, ch := range c.chains {
+			chainCtx, _ := log.FromContext(ctx, "chain_id", ch.chainID)
+			if extErr := ch.checkpointCtrl.Save(chainCtx, all); extErr != nil {
+				return extErr
+			}
+		}
+		return nil
+	}
+	ticker := time.NewTicker(SaveCheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-allMade:
+			// last save
+			return saveAll(true)
+		case <-ticker.C:
+			if err := saveAll(false); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testExecRecord struct {
+	chainID     string
+	blockNumber uint64
+	blockTime   time.Time
+}
+
+type testExecRecorder struct {
+	mu      sync.Mutex
+	records []testExecRecord
+}
+
+func (r *testExecRecorder) hook(chainID string) func(t *testTask) {
+	return func(t *testTask) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.records = append(r.records, testExecRecord{
+			chainID:     chainID,
+			blockNumber: t.GetBlockNumber(),
+			blockTime:   t.GetBlockTime(),
+		})
+	}
+}
+
+func (r *testExecRecorder) reset() []testExecRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := r.records
+	r.records = nil
+	return records
+}
+
+func assertOrdered(t *testing.T, records []testExecRecord) {
+	for i := 1; i < len(records); i++ {
+		pre, cur := records[i-1], records[i]
+		assert.False(t, cur.blockTime.Before(pre.blockTime),
+			"%s#%d executed after %s#%d", cur.chainID, cur.blockNumber, pre.chainID, pre.blockNumber)
+		if cur.blockTime.Equal(pre.blockTime) && cur.chainID != pre.chainID {
+			// tie is broken by the chain id
+			assert.Less(t, pre.chainID, cur.chainID)
+		}
+	}
+}
+
+type testChain struct {
+	client   *testClient
+	handlers *testHandlerController
+	store    *testCheckpointStore
+	ctrl     *MainController
+}
+
+func newTestChain(
+	ctx context.Context,
+	chainID string,
+	latest uint64,
+	endBlock uint64,
+	blockInterval time.Duration,
+	checkLink bool,
+	store *testCheckpointStore,
+	recorder *testExecRecorder,
+) testChain {
+	cli := newTestClient(0, latest)
+	cli.blockInterval = blockInterval
+	hc := &testHandlerController{
+		Client:      cli,
+		NewTplIndex: make(map[uint64]TemplateInstance),
+		EndBlock:    utils.WrapPointer(endBlock),
+		OnExec:      recorder.hook(chainID),
+	}
+	cc, _ := NewCheckpointController(
+		ctx,
+		chainID,
+		time.Second,
+		time.Second*2,
+		100000,
+		store,
+		EmptyQuotaService{},
+		EmptyTimeSeriesController{},
+		EmptyEntityController{},
+		EmptyWebhookController{},
+		nil,
+	)
+	return testChain{
+		client:   cli,
+		handlers: hc,
+		store:    store,
+		ctrl:     NewMainController(NewBlockBuilder(hc, cli, checkLink), cc, false, nil, chainID),
+	}
+}
+
+func Test_crossChain_ordered(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var recorder testExecRecorder
+	c1 := newTestChain(ctx, "1", 100, 60, time.Second, false, &testCheckpointStore{}, &recorder)
+	c2 := newTestChain(ctx, "2", 100, 20, time.Second*3, false, &testCheckpointStore{}, &recorder)
+	cc := NewCrossChainController(nil, map[string]*MainController{"2": c2.ctrl, "1": c1.ctrl})
+
+	err := cc.run(ctx)
+	assert.NoError(t, err)
+
+	records := recorder.reset()
+	assertOrdered(t, records)
+	chains := make(map[string]int)
+	for _, r := range records {
+		chains[r.chainID]++
+	}
+	assert.Equal(t, 2, len(chains))
+
+	last1 := c1.store.checkpoints[len(c1.store.checkpoints)-1]
+	assert.Equal(t, uint64(60), last1.BlockNumber)
+	assert.True(t, last1.AllDone())
+	last2 := c2.store.checkpoints[len(c2.store.checkpoints)-1]
+	assert.Equal(t, uint64(20), last2.BlockNumber)
+	assert.True(t, last2.AllDone())
+}
+
+func Test_crossChain_noDataForLongRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var recorder testExecRecorder
+	// chain 1 has no data after block 10 and then waits for new blocks after the latest block 100 at 100s,
+	// chain 2 has data in all blocks until block 30 at 90s
+	c1 := newTestChain(ctx, "1", 100, 1000, time.Second, false, &testCheckpointStore{}, &recorder)
+	c1.handlers.NoData = func(bn uint64) bool {
+		return bn > 10
+	}
+	c2 := newTestChain(ctx, "2", 30, 30, time.Second*3, false, &testCheckpointStore{}, &recorder)
+	cc := NewCrossChainController(nil, map[string]*MainController{"1": c1.ctrl, "2": c2.ctrl})
+
+	runCtx, runCancel := context.WithCancel(ctx)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- cc.run(runCtx)
+	}()
+
+	// the blocks of chain 2 are not blocked by chain 1 which is waiting for new blocks
+	assert.Eventually(t, func() bool {
+		last := c2.ctrl.checkpointCtrl.GetLatestCheckpoint()
+		return last != nil && last.BlockNumber == 30
+	}, time.Second*5, time.Millisecond*10)
+	runCancel()
+	assert.ErrorIs(t, <-runErr, context.Canceled)
+
+	records := recorder.reset()
+	assertOrdered(t, records)
+	for _, r := range records {
+		if r.chainID == "1" {
+			assert.LessOrEqual(t, r.blockNumber, uint64(10))
+		}
+	}
+	assert.Equal(t, uint64(10), c1.ctrl.checkpointCtrl.GetLatestCheckpoint().BlockNumber)
+}
+
+func Test_crossChain_alignCheckpoints(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	buildCheckpoints := func(cli *testClient, blockNumbers ...uint64) []Checkpoint {
+		return utils.MapSliceNoError(blockNumbers, func(bn uint64) Checkpoint {
+			h := cli.buildHeader(bn)
+			return Checkpoint{
+				BlockNumber:     h.BlockNumber,
+				BlockHash:       h.BlockHash,
+				BlockParentHash: h.BlockParentHash,
+				BlockTime:       h.BlockTime,
+			}
+		})
+	}
+
+	var recorder testExecRecorder
+	s1, s2 := &testCheckpointStore{}, &testCheckpointStore{}
+	// chain 1 is at 30s and chain 2 is at 15s, so chain 1 need to back to 10s
+	s1.checkpoints = buildCheckpoints(&testClient{}, 10, 20, 30)
+	s2.checkpoints = buildCheckpoints(&testClient{blockInterval: time.Second * 3}, 3, 5)
+	c1 := newTestChain(ctx, "1", 100, 40, time.Second, false, s1, &recorder)
+	c2 := newTestChain(ctx, "2", 100, 16, time.Second*3, false, s2, &recorder)
+	cc := NewCrossChainController(nil, map[string]*MainController{"1": c1.ctrl, "2": c2.ctrl})
+
+	assert.Nil(t, cc.alignCheckpoints(ctx))
+	assert.Equal(t, uint64(10), c1.ctrl.checkpointCtrl.GetLatestCheckpoint().BlockNumber)
+	assert.Equal(t, uint64(10), c1.ctrl.checkpointCtrl.GetSavedLatestCheckpoint().BlockNumber)
+	assert.Equal(t, []uint64{10}, utils.MapSliceNoError(s1.checkpoints, Checkpoint.GetBlockNumber))
+	assert.Equal(t, uint64(5), c2.ctrl.checkpointCtrl.GetLatestCheckpoint().BlockNumber)
+
+	err := cc.run(ctx)
+	assert.NoError(t, err)
+
+	records := recorder.reset()
+	assertOrdered(t, records)
+	assert.Equal(t, testExecRecord{
+		chainID:     "1",
+		blockNumber: 11,
+		blockTime:   c1.client.buildHeader(11).BlockTime,
+	}, records[0])
+	assert.Equal(t, uint64(40), s1.checkpoints[len(s1.checkpoints)-1].BlockNumber)
+	assert.Equal(t, uint64(16), s2.checkpoints[len(s2.checkpoints)-1].BlockNumber)
+}
+
+func Test_crossChain_alignCheckpointsByWatermark(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// buildCheckpoints builds the checkpoints of the block numbers, the value of the other chain is the block number
+	// of its latest checkpoint when the block was dispatched
+	buildCheckpoints := func(cli *testClient, other string, blocks map[uint64]uint64) []Checkpoint {
+		var checkpoints []Checkpoint
+		for _, bn := range utils.GetOrderedMapKeys(blocks) {
+			h := cli.buildHeader(bn)
+			wm := crossChainWatermark{Time: h.BlockTime}
+			if blocks[bn] > 0 {
+				wm.Chains = map[string]uint64{other: blocks[bn]}
+			}
+			raw, err := json.Marshal(wm)
+			assert.NoError(t, err)
+			checkpoints = append(checkpoints, Checkpoint{
+				BlockNumber:     h.BlockNumber,
+				BlockHash:       h.BlockHash,
+				BlockParentHash: h.BlockParentHash,
+				BlockTime:       h.BlockTime,
+				Data:            map[string]string{CrossChainWatermarkKey: string(raw)},
+			})
+		}
+		return checkpoints
+	}
+
+	var recorder testExecRecorder
+	s1, s2 := &testCheckpointStore{}, &testCheckpointStore{}
+	// chain 2 has no data after block 5 at 15s, chain 1 at 30s is not ahead of it
+	s1.checkpoints = buildCheckpoints(&testClient{}, "2", map[uint64]uint64{10: 3, 20: 5, 30: 5})
+	s2.checkpoints = buildCheckpoints(&testClient{blockInterval: time.Second * 3}, "1", map[uint64]uint64{3: 0, 5: 10})
+	c1 := newTestChain(ctx, "1", 100, 40, time.Second, false, s1, &recorder)
+	c2 := newTestChain(ctx, "2", 100, 16, time.Second*3, false, s2, &recorder)
+	cc := NewCrossChainController(nil, map[string]*MainController{"1": c1.ctrl, "2": c2.ctrl})
+	assert.Nil(t, cc.alignCheckpoints(ctx))
+	assert.Equal(t, []uint64{10, 20, 30}, utils.MapSliceNoError(s1.checkpoints, Checkpoint.GetBlockNumber))
+	assert.Equal(t, []uint64{3, 5}, utils.MapSliceNoError(s2.checkpoints, Checkpoint.GetBlockNumber))
+
+	// the checkpoint of block 8 of chain 2 is lost, so the checkpoints of chain 1 made after it are rolled back,
+	// and chain 2 is not touched
+	s1.checkpoints = buildCheckpoints(&testClient{}, "2", map[uint64]uint64{10: 3, 20: 5, 25: 8, 30: 8})
+	s2.checkpoints = buildCheckpoints(&testClient{blockInterval: time.Second * 3}, "1", map[uint64]uint64{3: 0, 5: 10})
+	c1 = newTestChain(ctx, "1", 100, 40, time.Second, false, s1, &recorder)
+	c2 = newTestChain(ctx, "2", 100, 16, time.Second*3, false, s2, &recorder)
+	cc = NewCrossChainController(nil, map[string]*MainController{"1": c1.ctrl, "2": c2.ctrl})
+	assert.Nil(t, cc.alignCheckpoints(ctx))
+	assert.Equal(t, []uint64{10, 20}, utils.MapSliceNoError(s1.checkpoints, Checkpoint.GetBlockNumber))
+	assert.Equal(t, []uint64{3, 5}, utils.MapSliceNoError(s2.checkpoints, Checkpoint.GetBlockNumber))
+
+	err := cc.run(ctx)
+	assert.NoError(t, err)
+	assertOrdered(t, recorder.reset())
+	assert.Equal(t, uint64(40), s1.checkpoints[len(s1.checkpoints)-1].BlockNumber)
+	assert.Equal(t, uint64(16), s2.checkpoints[len(s2.checkpoints)-1].BlockNumber)
+}
+
+func Test_crossChain_reorg(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var recorder testExecRecorder
+	c1 := newTestChain(ctx, "1", 30, 60, time.Second, true, &testCheckpointStore{}, &recorder)
+	c2 := newTestChain(ctx, "2", 100, 10, time.Second*3, true, &testCheckpointStore{}, &recorder)
+	cc := NewCrossChainController(nil, map[string]*MainController{"1": c1.ctrl, "2": c2.ctrl})
+
+	go func() {
+		time.Sleep(time.Second)
+		c1.client.Fork(26)
+		c1.client.UpdateLatest(60)
+	}()
+
+	roundCtx, _ := log.FromContext(ctx, "round", 0)
+	err := cc.run(roundCtx)
+	assert.ErrorIs(t, err, ErrInternalReorgDetected)
+	assertOrdered(t, recorder.reset())
+
+	// chain 2 has already processed all its blocks, some of them are later than the reorg block of chain 1
+	last1 := c1.ctrl.checkpointCtrl.GetLatestCheckpoint()
+	last2 := c2.ctrl.checkpointCtrl.GetLatestCheckpoint()
+	assert.Less(t, last1.BlockNumber, uint64(26))
+	assert.True(t, last2.BlockTime.After(last1.BlockTime))
+
+	roundCtx, _ = log.FromContext(ctx, "round", 1)
+	err = cc.run(roundCtx)
+	assert.NoError(t, err)
+
+	// the merged stream was rolled back, so the later blocks of chain 2 were processed again after chain 1
+	records := recorder.reset()
+	assertOrdered(t, records)
+	assert.False(t, records[0].blockTime.After(last1.BlockTime.Add(time.Second*3)))
+	var reprocessed bool
+	for _, r := range records {
+		reprocessed = reprocessed || r.chainID == "2"
+	}
+	assert.True(t, reprocessed)
+	assert.Equal(t, uint64(60), c1.store.checkpoints[len(c1.store.checkpoints)-1].BlockNumber)
+	assert.Equal(t, uint64(10), c2.store.checkpoints[len(c2.store.checkpoints)-1].BlockNumber)
+}
@@ -0,0 +1,27 @@
+package btc
+
+import (
+	"time"
+
+	"sentioxyz/sentio-core/chain/btc"
+)
+
+type Block struct {
+	btc.BlockLink
+}
+
+func (b Block) GetBlockNumber() uint64 {
+	return b.Number
+}
+
+func (b Block) GetBlockParentHash() string {
+	return b.ParentHash
+}
+
+func (b Block) GetBlockHash() string {
+	return b.Hash
+}
+
+func (b Block) GetBlockTime() time.Time {
+	return time.Unix(b.Time, 0)
+}
@@ -35,6 +35,7 @@ type EntityController interface {
 		ctx context.Context,
 		entityType *schema.Entity,
 		filters []persistent.EntityFilter,
+		orders []persistent.EntityOrder,
 		cursor string,
 		limit int,
 		blockNumber uint64,
@@ -99,6 +100,7 @@ func (c EmptyEntityController) ListEntity(
 	ctx context.Context,
 	entityType *schema.Entity,
 	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
 	cursor string,
 	limit int,
 	blockNumber uint64,
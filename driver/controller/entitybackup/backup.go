@@ -0,0 +1,156 @@
+package entitybackup
+
+import (
+	"context"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+var (
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	ErrStoreNotEmpty      = errors.New("entity store is not empty")
+)
+
+const (
+	// rowGroupSize is the max number of rows in a row group of the entity files
+	rowGroupSize = parquet.DefaultRowGroupSize
+	// restoreBatchSize is the max number of rows written by one SetEntities when restoring
+	restoreBatchSize = 1000
+)
+
+// Backup dumps the entities of the chain as of the checkpoint of the block number into the objects, one parquet
+// file for each persistent entity type, and then the manifest. The latest saved checkpoint is used if blockNumber
+// is zero. Cache entities are not kept by the store and the aggregations are computed from the source entities,
+// so they are not included.
+func Backup(
+	ctx context.Context,
+	store persistent.ChainStore,
+	sch *schema.Schema,
+	checkpointStore controller.CheckpointStore,
+	blockNumber uint64,
+	objects parquet.ObjectStore,
+) (*Manifest, error) {
+	checkpoints, templates, err := checkpointStore.Load(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load checkpoints failed")
+	}
+	var cp *controller.Checkpoint
+	for i := range checkpoints {
+		if blockNumber == 0 || checkpoints[i].BlockNumber == blockNumber {
+			cp = &checkpoints[i]
+		}
+	}
+	if cp == nil {
+		return nil, errors.Wrapf(ErrCheckpointNotFound, "no checkpoint of block %d in chain %s",
+			blockNumber, store.GetChain())
+	}
+	m := &Manifest{
+		Version:    ManifestVersion,
+		Chain:      store.GetChain(),
+		SchemaHash: SchemaHash(sch),
+		CreatedAt:  time.Now(),
+		Checkpoint: *cp,
+		Templates:  make(map[uint64][]controller.TemplateInstance),
+	}
+	for bn, instances := range templates {
+		if bn <= cp.BlockNumber {
+			m.Templates[bn] = instances
+		}
+	}
+	_, logger := log.FromContext(ctx, "chain", m.Chain, "checkpoint", cp.BlockNumber)
+	for _, entityType := range sch.ListEntities(false) {
+		start := time.Now()
+		f, backupErr := backupEntity(ctx, store, entityType, cp.BlockNumber, objects)
+		if backupErr != nil {
+			return nil, errors.Wrapf(backupErr, "backup entity %s failed", entityType.Name)
+		}
+		m.Entities = append(m.Entities, f)
+		logger.Infow("entity backup succeed", "entity", f.Entity, "rows", f.Rows, "used", time.Since(start).String())
+	}
+	if err = saveManifest(ctx, objects, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func backupEntity(
+	ctx context.Context,
+	store persistent.ChainStore,
+	entityType *schema.Entity,
+	blockNumber uint64,
+	objects parquet.ObjectStore,
+) (EntityFile, error) {
+	w, err := newEntityWriter(entityType, rowGroupSize, memory.DefaultAllocator)
+	if err != nil {
+		return EntityFile{}, err
+	}
+	if err = store.ScanEntities(ctx, entityType, blockNumber, w.Add); err != nil {
+		w.Abort()
+		return EntityFile{}, err
+	}
+	return w.Close(ctx, objects)
+}
+
+// Restore writes the entities of the backup in the objects into the chain store and then saves the checkpoint
+// of the backup, so the driver will resume from the checkpoint. The backup must be created with the same schema,
+// and the store should be empty, otherwise the rows of the backup will be mixed with the existing ones.
+func Restore(
+	ctx context.Context,
+	store persistent.ChainStore,
+	sch *schema.Schema,
+	checkpointStore controller.CheckpointStore,
+	objects parquet.ObjectStore,
+) (*Manifest, error) {
+	m, err := LoadManifest(ctx, objects)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.Validate(sch, store.GetChain()); err != nil {
+		return nil, err
+	}
+	for _, entityType := range sch.ListEntities(false) {
+		boxes, _, listErr := store.ListEntities(ctx, entityType, nil, nil, nil, 1)
+		if listErr != nil {
+			return nil, errors.Wrapf(listErr, "check entity %s failed", entityType.Name)
+		}
+		if len(boxes) > 0 {
+			return nil, errors.Wrapf(ErrStoreNotEmpty, "entity %s has rows", entityType.Name)
+		}
+	}
+	_, logger := log.FromContext(ctx, "chain", m.Chain, "checkpoint", m.Checkpoint.BlockNumber)
+	for _, f := range m.Entities {
+		start := time.Now()
+		entityType := sch.GetEntity(f.Entity)
+		var rows uint64
+		err = readEntities(ctx, objects, entityType, f.Key, restoreBatchSize, memory.DefaultAllocator,
+			func(boxes []persistent.EntityBox) error {
+				rows += uint64(len(boxes))
+				_, setErr := store.SetEntities(ctx, entityType, boxes)
+				return setErr
+			})
+		if err != nil {
+			return nil, errors.Wrapf(err, "restore entity %s failed", f.Entity)
+		}
+		if rows != f.Rows {
+			return nil, errors.Wrapf(ErrManifestMismatch, "%s has %d rows, but %d in the manifest", f.Key, rows, f.Rows)
+		}
+		logger.Infow("entity restore succeed", "entity", f.Entity, "rows", rows, "used", time.Since(start).String())
+	}
+	// the stores expect to be loaded before saving, the loaded checkpoints are replaced by the one of the backup
+	if _, _, err = checkpointStore.Load(ctx); err != nil {
+		return nil, errors.Wrapf(err, "load checkpoints failed")
+	}
+	err = checkpointStore.Save(ctx, []controller.Checkpoint{m.Checkpoint}, m.Templates, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "save checkpoint of block %d failed", m.Checkpoint.BlockNumber)
+	}
+	return m, nil
+}
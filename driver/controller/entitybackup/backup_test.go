@@ -0,0 +1,176 @@
+package entitybackup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/checkpointstore"
+	"sentioxyz/sentio-core/driver/entity/embedded"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/persistent/persistenttest"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+func newCheckpointStore(t *testing.T) controller.CheckpointStore {
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return checkpointstore.NewStore(checkpointstore.NewPebbleBackend(db, ""), "p1", "1")
+}
+
+func box(entity, id string, bn uint64, data map[string]any) persistent.EntityBox {
+	return persistent.EntityBox{
+		Entity:         entity,
+		ID:             id,
+		Data:           data,
+		GenBlockNumber: bn,
+		GenBlockTime:   persistenttest.BlockTime(bn),
+		GenBlockHash:   fmt.Sprintf("0x%x", bn),
+	}
+}
+
+func account(id string, bn uint64, balance int32) persistent.EntityBox {
+	return box("Account", id, bn, map[string]any{
+		"id":      id,
+		"name":    "name-" + id,
+		"nick":    (*string)(nil),
+		"balance": balance,
+		"tags":    []string{"t-" + id},
+	})
+}
+
+func swap(id int64, bn uint64, amount int64) persistent.EntityBox {
+	return box("Swap", strconv.FormatInt(id, 10), bn, map[string]any{
+		"id":        id,
+		"timestamp": persistenttest.BlockTime(bn).UnixMicro(),
+		"pool":      "p1",
+		"amount":    decimal.NewFromInt(amount),
+	})
+}
+
+func set(t *testing.T, store persistent.ChainStore, boxes ...persistent.EntityBox) {
+	_, err := store.SetEntities(context.Background(), store.GetEntityType(boxes[0].Entity), boxes)
+	require.NoError(t, err)
+}
+
+// dump returns the entities of the store as of the block number as strings
+func dump(t *testing.T, store persistent.ChainStore, sch *schema.Schema, blockNumber uint64) (r []string) {
+	for _, entityType := range sch.ListEntities(false) {
+		err := store.ScanEntities(context.Background(), entityType, blockNumber, func(box persistent.EntityBox) error {
+			r = append(r, entityType.Name+box.String()+box.GenBlockTime.String())
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	return r
+}
+
+func Test_backupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	sch := persistenttest.MustParseSchema()
+
+	source := embedded.NewChainStore(embedded.NewStore(sch), "1")
+	set(t, source, account("a1", 10, 100), account("a2", 10, 200))
+	set(t, source, account("a1", 12, 110), box("Account", "a2", 12, nil), account("a3", 12, 300))
+	set(t, source, account("a1", 20, 120), account("a4", 20, 400))
+	set(t, source, box("Transfer", "t1", 10, map[string]any{"id": "t1", "from": "0x01", "amount": int32(1)}))
+	set(t, source, swap(1, 10, 1), swap(2, 12, 2), swap(3, 20, 3))
+	set(t, source, box("Session", "s1", 12, map[string]any{"id": "s1", "value": "v1"}))
+
+	var checkpoints []controller.Checkpoint
+	for _, bn := range []uint64{10, 12, 20} {
+		checkpoints = append(checkpoints, controller.Checkpoint{
+			BlockNumber: bn,
+			BlockHash:   fmt.Sprintf("0x%x", bn),
+			BlockTime:   persistenttest.BlockTime(bn),
+		})
+	}
+	tplA := controller.TemplateInstance{TemplateID: 1, TemplateName: "tpl", Address: "0xa"}
+	tplB := controller.TemplateInstance{TemplateID: 1, TemplateName: "tpl", Address: "0xb"}
+	sourceCheckpoints := newCheckpointStore(t)
+	_, _, err := sourceCheckpoints.Load(ctx)
+	require.NoError(t, err)
+	require.NoError(t, sourceCheckpoints.Save(ctx, checkpoints,
+		map[uint64][]controller.TemplateInstance{5: {tplA}, 15: {tplB}}, nil))
+
+	objects := parquet.NewLocalObjectStore(t.TempDir())
+	_, err = Backup(ctx, source, sch, sourceCheckpoints, 11, objects)
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+	m, err := Backup(ctx, source, sch, sourceCheckpoints, 12, objects)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), m.Checkpoint.BlockNumber)
+	assert.Equal(t, map[uint64][]controller.TemplateInstance{5: {tplA}}, m.Templates)
+	assert.Equal(t, []EntityFile{
+		{Entity: "Account", Key: "entities/Account.parquet", Rows: 2},
+		{Entity: "Transfer", Key: "entities/Transfer.parquet", Rows: 1},
+		{Entity: "Swap", Key: "entities/Swap.parquet", Rows: 2},
+	}, m.Entities)
+	loaded, err := LoadManifest(ctx, objects)
+	require.NoError(t, err)
+	assert.Equal(t, m.SchemaHash, loaded.SchemaHash)
+	assert.Equal(t, m.Entities, loaded.Entities)
+
+	// restore into an empty store
+	target := embedded.NewChainStore(embedded.NewStore(sch), "1")
+	targetCheckpoints := newCheckpointStore(t)
+	_, err = Restore(ctx, target, sch, targetCheckpoints, objects)
+	require.NoError(t, err)
+	assert.Equal(t, dump(t, source, sch, 12), dump(t, target, sch, 100))
+	restoredCheckpoints, templates, err := targetCheckpoints.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, restoredCheckpoints, 1)
+	assert.Equal(t, uint64(12), restoredCheckpoints[0].BlockNumber)
+	assert.Equal(t, map[uint64][]controller.TemplateInstance{5: {tplA}}, templates)
+
+	// the store already has rows
+	_, err = Restore(ctx, target, sch, targetCheckpoints, objects)
+	assert.ErrorIs(t, err, ErrStoreNotEmpty)
+
+	// another chain
+	_, err = Restore(ctx, embedded.NewChainStore(embedded.NewStore(sch), "2"), sch, targetCheckpoints, objects)
+	assert.ErrorIs(t, err, ErrManifestMismatch)
+
+	// another schema
+	otherSch, err := schema.ParseAndVerifySchema(persistenttest.Schema + "\ntype Other @entity {\n  id: ID!\n}\n")
+	require.NoError(t, err)
+	_, err = Restore(ctx, embedded.NewChainStore(embedded.NewStore(otherSch), "1"), otherSch, targetCheckpoints, objects)
+	assert.ErrorIs(t, err, ErrManifestMismatch)
+}
+
+func Test_cli(t *testing.T) {
+	ctx := context.Background()
+	sch := persistenttest.MustParseSchema()
+	store := embedded.NewChainStore(embedded.NewStore(sch), "1")
+	set(t, store, account("a1", 10, 100))
+	checkpoints := newCheckpointStore(t)
+	_, _, err := checkpoints.Load(ctx)
+	require.NoError(t, err)
+	require.NoError(t, checkpoints.Save(ctx, []controller.Checkpoint{{BlockNumber: 10}},
+		map[uint64][]controller.TemplateInstance{}, nil))
+	objects := parquet.NewLocalObjectStore(t.TempDir())
+
+	var out bytes.Buffer
+	require.NoError(t, RunCLI(ctx, store, sch, checkpoints, objects, []string{"backup"}, &out))
+	assert.Contains(t, out.String(), "checkpoint=10")
+	assert.Contains(t, out.String(), "entity=Account\trows=1")
+
+	out.Reset()
+	require.NoError(t, RunCLI(ctx, store, sch, checkpoints, objects, []string{"show"}, &out))
+	assert.Contains(t, out.String(), "matched=true")
+
+	assert.Error(t, RunCLI(ctx, store, sch, checkpoints, objects, nil, &out))
+	assert.Error(t, RunCLI(ctx, store, sch, checkpoints, objects, []string{"unknown"}, &out))
+}
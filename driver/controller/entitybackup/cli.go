@@ -0,0 +1,85 @@
+package entitybackup
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+const cliUsage = `usage: <command> [flags]
+
+commands:
+  backup   dump the entities as of a checkpoint with the manifest
+  restore  restore the entities and the checkpoint of a backup
+  show     show the manifest of a backup
+`
+
+// RunCLI runs one backup command of the chain store, args are the command and its flags
+func RunCLI(
+	ctx context.Context,
+	store persistent.ChainStore,
+	sch *schema.Schema,
+	checkpointStore controller.CheckpointStore,
+	objects parquet.ObjectStore,
+	args []string,
+	out io.Writer,
+) error {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(out, cliUsage)
+		return errors.New("command is required")
+	}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(out)
+	var run func() (*Manifest, error)
+	switch args[0] {
+	case "backup":
+		block := fs.Uint64("block", 0, "block number of the checkpoint, 0 means the latest saved one")
+		run = func() (*Manifest, error) {
+			return Backup(ctx, store, sch, checkpointStore, *block, objects)
+		}
+	case "restore":
+		run = func() (*Manifest, error) {
+			return Restore(ctx, store, sch, checkpointStore, objects)
+		}
+	case "show":
+		run = func() (*Manifest, error) {
+			return LoadManifest(ctx, objects)
+		}
+	default:
+		_, _ = fmt.Fprint(out, cliUsage)
+		return errors.Errorf("unknown command %q", args[0])
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	m, err := run()
+	if err != nil {
+		return err
+	}
+	printManifest(m, sch, out)
+	return nil
+}
+
+func printManifest(m *Manifest, sch *schema.Schema, out io.Writer) {
+	_, _ = fmt.Fprintf(out, "chain=%s\tcheckpoint=%d\thash=%s\ttime=%s\tcreatedAt=%s\n",
+		m.Chain, m.Checkpoint.BlockNumber, m.Checkpoint.BlockHash,
+		m.Checkpoint.BlockTime.Format(time.RFC3339), m.CreatedAt.Format(time.RFC3339))
+	var templates int
+	for _, instances := range m.Templates {
+		templates += len(instances)
+	}
+	_, _ = fmt.Fprintf(out, "schemaHash=%s\tmatched=%t\ttemplates=%d\n",
+		m.SchemaHash, m.SchemaHash == SchemaHash(sch), templates)
+	for _, f := range m.Entities {
+		_, _ = fmt.Fprintf(out, "  entity=%s\trows=%d\tfile=%s\n", f.Entity, f.Rows, f.Key)
+	}
+}
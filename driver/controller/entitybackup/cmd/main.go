@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/common/flags"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/controller/entitybackup"
+	"sentioxyz/sentio-core/driver/controller/startup"
+	entitychs "sentioxyz/sentio-core/driver/entity/clickhouse"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+func main() {
+	var (
+		processorService = flag.String("processor-service", "localhost:10010", "Address of the processor service")
+		checkpointStore  = flag.String("checkpoint-store", "",
+			"Checkpoint store backend used by the driver, see startup.Config.CheckpointStore")
+		clickhouseDSN = flag.String("clickhouse", "", "DSN of the ClickHouse database keeping the entities")
+		processorID   = flag.String("processor", "", "Processor ID")
+		chainID       = flag.String("chain", "", "Chain ID")
+		dir           = flag.String("dir", "", "Directory of the backup")
+	)
+	flags.ParseAndInitLogFlag()
+	ctx := context.Background()
+
+	if *processorID == "" || *chainID == "" || *clickhouseDSN == "" || *dir == "" {
+		log.Fatalf("-processor, -chain, -clickhouse and -dir are required")
+	}
+
+	// the checkpoints are read and written through the same store as the driver, so the driver resumes from
+	// the restored checkpoint
+	cpStore, processor, release, err := startup.OpenCheckpointStore(ctx, startup.Config{
+		ProcessorID:      *processorID,
+		ProcessorService: *processorService,
+		CheckpointStore:  *checkpointStore,
+	}, *chainID)
+	if err != nil {
+		log.Fatalf("open checkpoint store failed: %v", err)
+	}
+	defer release()
+
+	feaOpt := entitychs.BuildFeatures(processor.EntitySchemaVersion)
+	sch, err := schema.ParseAndVerifySchema(processor.EntitySchema, feaOpt.BuildVerifyOptions()...)
+	if err != nil {
+		log.Fatalf("parse entity schema failed: %v", err)
+	}
+	conn := ckhmanager.NewConn(*clickhouseDSN)
+	tableNamePrefix, logicDatabase, logicTableNamePrefix := processor.TablePattern.GetProcessorDBConfig(
+		conn.GetDatabase(), processor.ID, 0)
+	ctrl := chx.New(
+		conn,
+		chx.WithTableNamePrefix(tableNamePrefix),
+		chx.WithLogicDatabase(logicDatabase),
+		chx.WithLogicTableNamePrefix(logicTableNamePrefix),
+	)
+	entityStore := entitychs.NewStore(ctrl, feaOpt, sch, entitychs.DefaultCreateTableOption, nil)
+	if flag.Arg(0) == "restore" {
+		// the tables may be lost, create them before restoring
+		if err = entityStore.InitEntitySchema(ctx); err != nil {
+			log.Fatalf("init entity schema failed: %v", err)
+		}
+	}
+	// scanning does not use the caches and the restored rows are written once, so the caches are kept small
+	chainStore := entitychs.NewChainStore(entityStore, *chainID, 1000, 0, 0)
+
+	err = entitybackup.RunCLI(
+		ctx,
+		chainStore,
+		sch,
+		cpStore,
+		parquet.NewLocalObjectStore(*dir),
+		flag.Args(),
+		os.Stdout,
+	)
+	if err != nil {
+		log.Errorfe(err, "run command failed")
+		os.Exit(1)
+	}
+}
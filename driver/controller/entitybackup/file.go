@@ -0,0 +1,235 @@
+package entitybackup
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	pq "github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/compress"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+	"sentioxyz/sentio-core/service/common/protos"
+)
+
+const (
+	columnID             = "id"
+	columnGenBlockNumber = "gen_block_number"
+	columnGenBlockTime   = "gen_block_time"
+	columnGenBlockHash   = "gen_block_hash"
+	// the fields as a protos.RichStruct, it keeps the exact types of the values and is used by restore
+	columnData = "data"
+	// the fields as a json object, only for the readers of the files
+	columnDataJSON = "data_json"
+)
+
+// rowSchema is the same for all the entity types, the fields are encoded in the data column
+var rowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: columnID, Type: arrow.BinaryTypes.String},
+	{Name: columnGenBlockNumber, Type: arrow.PrimitiveTypes.Uint64},
+	{Name: columnGenBlockTime, Type: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}},
+	{Name: columnGenBlockHash, Type: arrow.BinaryTypes.String},
+	{Name: columnData, Type: arrow.BinaryTypes.Binary},
+	{Name: columnDataJSON, Type: arrow.BinaryTypes.String},
+}, nil)
+
+func entityKey(entity string) string {
+	return "entities/" + entity + ".parquet"
+}
+
+// entityWriter writes the rows of an entity type into a local temp file, the file is put into the
+// object store when closed
+type entityWriter struct {
+	entityType   *schema.Entity
+	rowGroupSize int
+	tmp          *os.File
+	w            *pqarrow.FileWriter
+	b            *array.RecordBuilder
+	rows         uint64
+}
+
+func newEntityWriter(entityType *schema.Entity, rowGroupSize int, mem memory.Allocator) (*entityWriter, error) {
+	tmp, err := os.CreateTemp("", "entity-*.parquet")
+	if err != nil {
+		return nil, errors.Wrapf(err, "create temp file failed")
+	}
+	props := pq.NewWriterProperties(
+		pq.WithCompression(compress.Codecs.Zstd),
+		pq.WithMaxRowGroupLength(int64(rowGroupSize)),
+		pq.WithAllocator(mem),
+	)
+	w, err := pqarrow.NewFileWriter(rowSchema, tmp, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return nil, errors.Wrapf(err, "create writer of %s failed", entityType.Name)
+	}
+	return &entityWriter{
+		entityType:   entityType,
+		rowGroupSize: rowGroupSize,
+		tmp:          tmp,
+		w:            w,
+		b:            array.NewRecordBuilder(mem, rowSchema),
+	}, nil
+}
+
+func (w *entityWriter) Add(box persistent.EntityBox) error {
+	rs, err := box.ToRichStruct(w.entityType)
+	if err != nil {
+		return err
+	}
+	data, err := proto.Marshal(rs)
+	if err != nil {
+		return errors.Wrapf(err, "encode %s %s failed", w.entityType.Name, box.ID)
+	}
+	dataJSON, err := json.Marshal(box.Data)
+	if err != nil {
+		return errors.Wrapf(err, "encode %s %s as json failed", w.entityType.Name, box.ID)
+	}
+	w.b.Field(0).(*array.StringBuilder).Append(box.ID)
+	w.b.Field(1).(*array.Uint64Builder).Append(box.GenBlockNumber)
+	w.b.Field(2).(*array.TimestampBuilder).Append(arrow.Timestamp(box.GenBlockTime.UnixMicro()))
+	w.b.Field(3).(*array.StringBuilder).Append(box.GenBlockHash)
+	w.b.Field(4).(*array.BinaryBuilder).Append(data)
+	w.b.Field(5).(*array.StringBuilder).Append(string(dataJSON))
+	w.rows++
+	if w.b.Field(0).Len() >= w.rowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *entityWriter) flush() error {
+	if w.b.Field(0).Len() == 0 {
+		return nil
+	}
+	rec := w.b.NewRecord()
+	defer rec.Release()
+	if err := w.w.Write(rec); err != nil {
+		return errors.Wrapf(err, "write %s failed", w.entityType.Name)
+	}
+	return nil
+}
+
+// Close flushes the rows and puts the file into the objects
+func (w *entityWriter) Close(ctx context.Context, objects parquet.ObjectStore) (EntityFile, error) {
+	defer os.Remove(w.tmp.Name())
+	defer w.b.Release()
+	if err := w.flush(); err != nil {
+		_ = w.w.Close()
+		return EntityFile{}, err
+	}
+	// close the writer will also close the file
+	if err := w.w.Close(); err != nil {
+		return EntityFile{}, errors.Wrapf(err, "write %s failed", w.entityType.Name)
+	}
+	f := EntityFile{Entity: w.entityType.Name, Key: entityKey(w.entityType.Name), Rows: w.rows}
+	return f, objects.Put(ctx, f.Key, w.tmp.Name())
+}
+
+// Abort drops the written rows
+func (w *entityWriter) Abort() {
+	w.b.Release()
+	_ = w.w.Close()
+	_ = os.Remove(w.tmp.Name())
+}
+
+// readEntities calls fn with the rows of the entity file in batches of at most batchSize rows
+func readEntities(
+	ctx context.Context,
+	objects parquet.ObjectStore,
+	entityType *schema.Entity,
+	key string,
+	batchSize int,
+	mem memory.Allocator,
+	fn func(boxes []persistent.EntityBox) error,
+) error {
+	f, err := objects.Open(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", key)
+	}
+	rdr, err := file.NewParquetReader(f)
+	if err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "read %s failed", key)
+	}
+	defer rdr.Close()
+	fr, err := pqarrow.NewFileReader(rdr, pqarrow.ArrowReadProperties{BatchSize: int64(batchSize)}, mem)
+	if err != nil {
+		return errors.Wrapf(err, "read %s failed", key)
+	}
+	rr, err := fr.GetRecordReader(ctx, nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "read %s failed", key)
+	}
+	defer rr.Release()
+	if !sameColumns(rr.Schema()) {
+		return errors.Wrapf(ErrManifestMismatch, "columns of %s are %s", key, rr.Schema())
+	}
+	for rr.Next() {
+		boxes, decErr := decodeRecord(entityType, rr.Record())
+		if decErr != nil {
+			return errors.Wrapf(decErr, "decode %s failed", key)
+		}
+		if err = fn(boxes); err != nil {
+			return err
+		}
+	}
+	// the reader reports io.EOF after the last record
+	if err = rr.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrapf(err, "read %s failed", key)
+	}
+	return nil
+}
+
+// sameColumns checks the names and the types of the columns, the metadata added by the parquet reader is ignored
+func sameColumns(s *arrow.Schema) bool {
+	if s.NumFields() != rowSchema.NumFields() {
+		return false
+	}
+	for i, f := range s.Fields() {
+		if f.Name != rowSchema.Field(i).Name || !arrow.TypeEqual(f.Type, rowSchema.Field(i).Type) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeRecord(entityType *schema.Entity, rec arrow.Record) ([]persistent.EntityBox, error) {
+	ids := rec.Column(0).(*array.String)
+	genBlockNumbers := rec.Column(1).(*array.Uint64)
+	genBlockTimes := rec.Column(2).(*array.Timestamp)
+	genBlockHashes := rec.Column(3).(*array.String)
+	data := rec.Column(4).(*array.Binary)
+	boxes := make([]persistent.EntityBox, rec.NumRows())
+	for i := range boxes {
+		var rs protos.RichStruct
+		if err := proto.Unmarshal(data.Value(i), &rs); err != nil {
+			return nil, errors.Wrapf(err, "decode fields of %s %s failed", entityType.Name, ids.Value(i))
+		}
+		var box persistent.UncommittedEntityBox
+		if err := box.FromRichStruct(entityType, &rs); err != nil {
+			return nil, err
+		}
+		boxes[i] = persistent.EntityBox{
+			Entity:         entityType.Name,
+			ID:             ids.Value(i),
+			Data:           box.Data,
+			GenBlockNumber: genBlockNumbers.Value(i),
+			GenBlockTime:   time.UnixMicro(int64(genBlockTimes.Value(i))).UTC(),
+			GenBlockHash:   genBlockHashes.Value(i),
+		}
+	}
+	return boxes, nil
+}
@@ -0,0 +1,106 @@
+package entitybackup
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+// ManifestVersion is the version of the backup layout, a backup with another version can not be restored
+const ManifestVersion = 1
+
+const manifestKey = "manifest.json"
+
+var ErrManifestMismatch = errors.New("manifest mismatch")
+
+// Manifest describes a backup, it is written after all the entity files, so a backup without the manifest
+// is incomplete and should not be used
+type Manifest struct {
+	Version    int
+	Chain      string
+	SchemaHash string
+	CreatedAt  time.Time
+
+	// the entities are the versions as of the checkpoint, template instances are the ones added not after it
+	Checkpoint controller.Checkpoint
+	Templates  map[uint64][]controller.TemplateInstance
+
+	Entities []EntityFile
+}
+
+// EntityFile is the parquet file keeping the rows of an entity type
+type EntityFile struct {
+	Entity string
+	Key    string
+	Rows   uint64
+}
+
+// SchemaHash returns the hash of the entity schema, a backup can only be restored with the same schema
+func SchemaHash(sch *schema.Schema) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(sch.SchemaString)))
+}
+
+// Validate checks whether the backup can be restored into the chain with the schema
+func (m *Manifest) Validate(sch *schema.Schema, chain string) error {
+	if m.Version != ManifestVersion {
+		return errors.Wrapf(ErrManifestMismatch, "backup version is %d, only %d is supported", m.Version, ManifestVersion)
+	}
+	if m.Chain != chain {
+		return errors.Wrapf(ErrManifestMismatch, "backup is of chain %s, not %s", m.Chain, chain)
+	}
+	if hash := SchemaHash(sch); m.SchemaHash != hash {
+		return errors.Wrapf(ErrManifestMismatch, "backup schema hash is %s, current is %s", m.SchemaHash, hash)
+	}
+	for _, f := range m.Entities {
+		if entityType := sch.GetEntity(f.Entity); entityType == nil || entityType.IsCache() {
+			return errors.Wrapf(ErrManifestMismatch, "entity %s of the backup is not a persistent entity", f.Entity)
+		}
+	}
+	return nil
+}
+
+// LoadManifest reads the manifest of the backup in the objects
+func LoadManifest(ctx context.Context, objects parquet.ObjectStore) (*Manifest, error) {
+	f, err := objects.Open(ctx, manifestKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s failed", manifestKey)
+	}
+	defer f.Close()
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", manifestKey)
+	}
+	var m Manifest
+	if err = json.NewDecoder(io.NewSectionReader(f, 0, size)).Decode(&m); err != nil {
+		return nil, errors.Wrapf(err, "decode %s failed", manifestKey)
+	}
+	return &m, nil
+}
+
+func saveManifest(ctx context.Context, objects parquet.ObjectStore, m *Manifest) error {
+	tmp, err := os.CreateTemp("", "manifest-*.json")
+	if err != nil {
+		return errors.Wrapf(err, "create temp file failed")
+	}
+	defer os.Remove(tmp.Name())
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(m); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "write %s failed", manifestKey)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrapf(err, "write %s failed", manifestKey)
+	}
+	return objects.Put(ctx, manifestKey, tmp.Name())
+}
@@ -11,6 +11,7 @@ var (
 	ErrInternalNeedUpgrade    = errors.New("need upgrade")
 	ErrInternalHasNewTemplate = errors.New("has new template")
 	ErrInternalReorgDetected  = errors.New("reorg detected")
+	ErrInternalRangeSplitDone = errors.New("range split done")
 )
 
 func NewExternalError(code int, err error) *ExternalError {
@@ -153,6 +154,12 @@ const (
 	ErrCodeTooManyEventTypes
 	ErrCodeTooManyTimeSeries
 	ErrCodeTooManyEntityTypes
+
+	// the subgraph handler used more gas than the limit, see startup.Config.SubgraphHandlerGasLimit
+	ErrCodeWasmOutOfGas
+
+	// the order or the cursor of the entity list request is invalid, see persistent.CheckEntityOrders
+	ErrCodeInvalidListEntityOrder
 )
 
 // billing error
@@ -31,6 +31,9 @@ type MainController struct {
 
 	bindingIndex atomic.Uint64
 
+	rangeSplit *RangeSplitConfig
+	stopBlock  *uint64
+
 	analyser
 }
 
@@ -57,12 +60,39 @@ func NewMainController(
 	}
 }
 
+// SetStopBlock makes the controller stop after processing the block, the chain is treated as done then.
+// Shadow runs use it to process the same block range as the version they are compared with.
+func (c *MainController) SetStopBlock(blockNumber uint64) {
+	c.stopBlock = &blockNumber
+}
+
+// stopLimit returns the limit of the pipeline built from the stop block, nil if there is no stop block
+func (c *MainController) stopLimit() func() uint64 {
+	if c.stopBlock == nil {
+		return nil
+	}
+	stopBlock := *c.stopBlock
+	return func() uint64 {
+		return stopBlock
+	}
+}
+
 func (c *MainController) Main(ctx context.Context) error {
+	return keepRun(ctx, c.round, c.checkpointCtrl.SaveError)
+}
+
+// keepRun calls run round by round until it succeeds, restarting the round after internal errors and retrying
+// the external errors that can be retried
+func keepRun(
+	ctx context.Context,
+	run func(ctx context.Context) error,
+	saveError func(ctx context.Context, err *ExternalError) error,
+) error {
 	const maxDupErrRetryTimes = 10
 	lastExtErrCode, lastExtErrRound := 0, 0
 	for round := 0; ; round++ {
 		runCtx, logger := log.FromContext(ctx, "runID", round)
-		err := c.run(runCtx)
+		err := run(runCtx)
 		if err == nil {
 			logger.UserVisible().Info("chain is done")
 			return nil
@@ -72,11 +102,13 @@ func (c *MainController) Main(ctx context.Context) error {
 			continue
 		case errors.Is(err, ErrInternalHasNewTemplate):
 			continue
+		case errors.Is(err, ErrInternalRangeSplitDone):
+			continue
 		}
 		var extErr *ExternalError
 		if errors.As(err, &extErr) {
 			logger.Errorf("run got external error: %+v", extErr)
-			saveErr := c.checkpointCtrl.SaveError(ctx, extErr)
+			saveErr := saveError(ctx, extErr)
 			if saveErr != nil {
 				logger.Errore(saveErr, "save chain error failed")
 			}
@@ -188,6 +220,52 @@ func (c *MainController) run(ctx context.Context) error {
 
 	N.DriverStarted(ctx, c.processor, c.chainID, CountTemplatesByID(templates))
 
+	g, gctx := errgroup.WithContext(ctx)
+	makeCheckpointDone := make(chan struct{})
+	g.Go(func() error {
+		return c.pipeline(
+			gctx,
+			c.blockBuilder,
+			c.checkpointCtrl,
+			&c.bindingIndex,
+			taskProcessConcurrency,
+			c.stopLimit(),
+			func(ctx context.Context, r *BlockPanel) error {
+				if r == nil {
+					// no more checkpoint need to be make now
+					close(makeCheckpointDone)
+					return nil
+				}
+				hasNewTpl, makeErr := c.checkpointCtrl.MakeCheckpoint(ctx, *r.DataSummary, r.ProgressBar)
+				if makeErr != nil {
+					return makeErr
+				} else if hasNewTpl {
+					return ErrInternalHasNewTemplate
+				}
+				return nil
+			})
+	})
+
+	g.Go(func() error {
+		return c.checkpointCtrl.KeepSave(gctx, makeCheckpointDone)
+	})
+
+	return g.Wait()
+}
+
+// pipeline fetches the blocks from blockBuilder and executes their tasks with checkpointCtrl.
+// blockDone is called in block order for every block with data once all its tasks are done,
+// and called with nil after the last block. If limit is not nil, blocks after limit() will not be processed.
+func (c *MainController) pipeline(
+	ctx context.Context,
+	blockBuilder BlockBuilder,
+	checkpointCtrl CheckpointController,
+	bindingIndex *atomic.Uint64,
+	taskProcessConcurrency int,
+	limit func() uint64,
+	blockDone func(ctx context.Context, r *BlockPanel) error,
+) error {
+	_, logger := log.FromContext(ctx)
 	g, gctx := errgroup.WithContext(ctx)
 	// More capacity to ensure the goroutines that execute tasks and build blockData are less likely to be blocked by this
 	progressNotice := make(chan ProgressMessage, 10000)
@@ -207,7 +285,7 @@ func (c *MainController) run(ctx context.Context) error {
 			for {
 				// fetch BlockData, may be waiting some fetcher, may be waiting latest block
 				fetchStartAt := time.Now()
-				blockNumber, blockData, progressBar, reorg, getErr := c.blockBuilder.Next(ctx)
+				blockNumber, blockData, progressBar, reorg, getErr := blockBuilder.Next(ctx)
 				c.analyser.fetchWait(time.Since(fetchStartAt))
 				if getErr != nil {
 					if errors.Is(getErr, ErrInternalNeedUpgrade) {
@@ -217,15 +295,15 @@ func (c *MainController) run(ctx context.Context) error {
 					return NewExternalError(ErrCodeFetchDataFailed, getErr)
 				}
 				if reorg != nil {
-					if cleanErr := c.checkpointCtrl.CleanCheckpoint(ctx, blockNumber, *reorg); cleanErr != nil {
+					if cleanErr := checkpointCtrl.CleanCheckpoint(ctx, blockNumber, *reorg); cleanErr != nil {
 						return cleanErr
 					}
 					N.ReorgDetected(ctx, c.processor, c.chainID)
 					return ErrInternalReorgDetected
 				}
-				if !progressBar.FullBlockRange.Contains(blockNumber) {
+				if !progressBar.FullBlockRange.Contains(blockNumber) || (limit != nil && blockNumber > limit()) {
 					// no more data, build block data can finish now,
-					// blockData and progressBar.LatestBlock will always be nil here.
+					// blockData and progressBar.LatestBlock will always be nil here if out of the full range.
 					logger.Infow("no more block data", "blockNumber", blockNumber, "full", progressBar.FullBlockRange.String())
 					select {
 					case progressNotice <- ProgressMessage{BlockAllDone: &blockNumber}:
@@ -271,7 +349,7 @@ func (c *MainController) run(ctx context.Context) error {
 				}
 				for i, task := range taskList {
 					index := TaskIndex{
-						Global:       c.bindingIndex.Add(1),
+						Global:       bindingIndex.Add(1),
 						InBlock:      i,
 						TotalInBlock: len(taskList),
 						ProcessID:    processIDGen.Add(1),
@@ -288,7 +366,7 @@ func (c *MainController) run(ctx context.Context) error {
 		},
 		func(ctx context.Context, task Task) error {
 			startAt := time.Now()
-			if taskErr := task.Exec(ctx, c.checkpointCtrl); taskErr != nil {
+			if taskErr := task.Exec(ctx, checkpointCtrl); taskErr != nil {
 				return taskErr
 			}
 			completeAt := time.Now()
@@ -300,7 +378,6 @@ func (c *MainController) run(ctx context.Context) error {
 			return nil
 		})
 
-	makeCheckpointDone := make(chan struct{})
 	g.Go(func() error {
 		logger.Info("keep make checkpoint started")
 		defer func() {
@@ -331,19 +408,14 @@ func (c *MainController) run(ctx context.Context) error {
 			for {
 				r, has := waiting[bn]
 				if has && r == nil {
-					// no more checkpoint need to be make now
-					close(makeCheckpointDone)
-					return nil
+					return blockDone(gctx, nil)
 				}
 				if !has || r.TaskCount > 0 {
 					break
 				}
 				if r.DataSummary != nil {
-					hasNewTpl, makeErr := c.checkpointCtrl.MakeCheckpoint(gctx, *r.DataSummary, r.ProgressBar)
-					if makeErr != nil {
-						return makeErr
-					} else if hasNewTpl {
-						return ErrInternalHasNewTemplate
+					if err := blockDone(gctx, r); err != nil {
+						return err
 					}
 				}
 				delete(waiting, bn)
@@ -354,9 +426,5 @@ func (c *MainController) run(ctx context.Context) error {
 		}
 	})
 
-	g.Go(func() error {
-		return c.checkpointCtrl.KeepSave(gctx, makeCheckpointDone)
-	})
-
 	return g.Wait()
 }
@@ -57,6 +57,9 @@ type testClient struct {
 	fork []uint64
 
 	broken error
+
+	// blockInterval is the time between two blocks, default is one second
+	blockInterval time.Duration
 }
 
 func newTestClient(first uint64, latest uint64) *testClient {
@@ -100,10 +103,16 @@ func (c *testClient) buildBlockHash(bn uint64) string {
 }
 
 func (c *testClient) buildHeader(bn uint64) testBlockHeader {
+	var h testBlockHeader
 	if bn == 0 {
-		return newTestBlockHeader(bn, c.buildBlockHash(0), "")
+		h = newTestBlockHeader(bn, c.buildBlockHash(0), "")
+	} else {
+		h = newTestBlockHeader(bn, c.buildBlockHash(bn), c.buildBlockHash(bn-1))
+	}
+	if c.blockInterval > 0 {
+		h.BlockTime = h.BlockTime.Add(time.Duration(bn) * (c.blockInterval - time.Second))
 	}
-	return newTestBlockHeader(bn, c.buildBlockHash(bn), c.buildBlockHash(bn-1))
+	return h
 }
 
 func (c *testClient) Fork(bn uint64) {
@@ -136,6 +145,9 @@ type testBlockDataFetcher struct {
 	taskSleep    time.Duration
 	errTaskIndex uint64
 	newTplIndex  map[uint64]TemplateInstance
+	onExec       func(t *testTask)
+	effect       testTaskEffect
+	noData       func(bn uint64) bool
 
 	mu            sync.Mutex
 	latest        BlockHeader
@@ -185,6 +197,9 @@ func (f *testBlockDataFetcher) Get(ctx context.Context, bn uint64) (
 	if taskLen == 0 && bn%2 != 0 {
 		return
 	}
+	if f.noData != nil && f.noData(bn) {
+		return
+	}
 
 	header, _ := f.client.GetHeaderIgnoreCache(context.Background(), bn)
 	tasks := make([]Task, taskLen)
@@ -194,6 +209,8 @@ func (f *testBlockDataFetcher) Get(ctx context.Context, bn uint64) (
 			errIndex:    f.errTaskIndex,
 			newTplIndex: f.newTplIndex,
 			sleep:       f.taskSleep,
+			onExec:      f.onExec,
+			effect:      f.effect,
 		}
 	}
 	data = newTestBlockData(header, tasks...)
@@ -221,6 +238,10 @@ type testHandlerController struct {
 	ErrTaskIndex uint64
 	NewTplIndex  map[uint64]TemplateInstance
 	EndBlock     *uint64
+	OnExec       func(t *testTask)
+	Effect       testTaskEffect
+	// NoData returns true if the block has no data, nil means the default data pattern
+	NoData func(bn uint64) bool
 
 	BlockRange
 }
@@ -253,6 +274,9 @@ func (c *testHandlerController) BuildBlockDataFetcher(_, _ uint64, latest BlockH
 		taskSleep:     c.TaskSleep,
 		errTaskIndex:  c.ErrTaskIndex,
 		newTplIndex:   c.NewTplIndex,
+		onExec:        c.OnExec,
+		effect:        c.Effect,
+		noData:        c.NoData,
 		latest:        latest,
 		latestChanged: make(chan struct{}),
 	}
@@ -262,12 +286,17 @@ func (c *testHandlerController) Snapshot() any {
 	return nil
 }
 
+// testTaskEffect is called by testTask.Exec to make changes with the checkpoint controller
+type testTaskEffect func(ctx context.Context, t *testTask, checkpointCtrl CheckpointController) *ExternalError
+
 type testTask struct {
 	BlockHeader
 	index       TaskIndex
 	errIndex    uint64
 	newTplIndex map[uint64]TemplateInstance
 	sleep       time.Duration
+	onExec      func(t *testTask)
+	effect      testTaskEffect
 }
 
 func (t *testTask) GetHandlerID() HandlerID {
@@ -286,6 +315,9 @@ func (t *testTask) Summary() string {
 func (t *testTask) Exec(ctx context.Context, checkpointCtrl CheckpointController) *ExternalError {
 	_, logger := log.FromContext(ctx, "block", t.GetBlockNumber(), "index", t.index)
 	logger.Debug("task start")
+	if t.onExec != nil {
+		t.onExec(t)
+	}
 	if t.errIndex == t.index.Global {
 		time.Sleep(t.sleep / 2)
 		logger.Warnf("task failed")
@@ -295,6 +327,11 @@ func (t *testTask) Exec(ctx context.Context, checkpointCtrl CheckpointController
 			errors.Errorf("task {Global:%#x InBlock:%d TotalInBlock:%d} fail",
 				t.index.Global, t.index.InBlock, t.index.TotalInBlock))
 	}
+	if t.effect != nil {
+		if extErr := t.effect(ctx, t, checkpointCtrl); extErr != nil {
+			return extErr
+		}
+	}
 	if newTpl, has := t.newTplIndex[t.GetBlockNumber()]; has && t.index.InBlock == 0 {
 		time.Sleep(t.sleep / 2)
 		logger.Warnf("task has new template %s", newTpl)
@@ -385,6 +422,40 @@ func Test_main_succeed_and_end(t *testing.T) {
 	assert.True(t, last.AllDone())
 }
 
+func Test_main_stopBlock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	cs := &testCheckpointStore{}
+	cli := newTestClient(0, 100)
+	hc := &testHandlerController{
+		Client:      cli,
+		TaskSleep:   time.Millisecond * 10,
+		NewTplIndex: make(map[uint64]TemplateInstance),
+		EndBlock:    utils.WrapPointer[uint64](80),
+	}
+	cc, _ := NewCheckpointController(
+		ctx,
+		"1",
+		time.Second,
+		time.Second*2,
+		100000,
+		cs,
+		EmptyQuotaService{},
+		EmptyTimeSeriesController{},
+		EmptyEntityController{},
+		EmptyWebhookController{},
+		nil,
+	)
+	mc := NewMainController(NewBlockBuilder(hc, cli, false), cc, false, nil, "")
+	mc.SetStopBlock(60)
+	assert.NoError(t, mc.Main(ctx))
+
+	last := cs.checkpoints[len(cs.checkpoints)-1]
+	assert.Equal(t, uint64(60), last.BlockNumber)
+	assert.False(t, last.AllDone())
+}
+
 func Test_main_failed(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
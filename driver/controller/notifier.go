@@ -54,9 +54,10 @@ type Notifier interface {
 	TaskDone(ctx context.Context, task TaskInfo, succeed bool, used time.Duration)
 	// SubgraphTaskDone reports that a subgraph handler task finished, together with
 	// the extra resource usage a subgraph task exposes: the time spent in each
-	// import function and the wasm memory used.
+	// import function, the wasm memory used and the gas used (zero if the
+	// instruction metering is disabled).
 	SubgraphTaskDone(ctx context.Context, task TaskInfo, succeed bool, used time.Duration,
-		importFuncUsed map[string]time.Duration, memoryUsed uint32)
+		importFuncUsed map[string]time.Duration, memoryUsed uint32, gasUsed uint64)
 	// SubgraphRPCDone reports that an RPC call issued by a subgraph handler finished.
 	SubgraphRPCDone(ctx context.Context, task TaskInfo, succeed bool, used time.Duration)
 	// DataEmitted reports data a task produced. dataType/subtype/name describe the
@@ -94,7 +95,9 @@ func (noopNotifier) BeforeEntityOperation(ctx context.Context, _ TaskInfo) conte
 	return ctx
 }
 func (noopNotifier) TaskDone(context.Context, TaskInfo, bool, time.Duration) {}
-func (noopNotifier) SubgraphTaskDone(context.Context, TaskInfo, bool, time.Duration, map[string]time.Duration, uint32) {
+func (noopNotifier) SubgraphTaskDone(
+	context.Context, TaskInfo, bool, time.Duration, map[string]time.Duration, uint32, uint64,
+) {
 }
 func (noopNotifier) SubgraphRPCDone(context.Context, TaskInfo, bool, time.Duration)       {}
 func (noopNotifier) DataEmitted(context.Context, TaskInfo, string, string, string, int64) {}
@@ -0,0 +1,652 @@
+package controller
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sentioxyz/sentio-core/common/errgroup"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+	"sentioxyz/sentio-core/driver/timeseries"
+
+	"github.com/pkg/errors"
+)
+
+// RangeSplitConfig enables the parallel range-split backfill of MainController.
+//
+// The historical part of the full block range is split into SubRanges sub-ranges, each one is fetched and
+// processed by its own block builder against a scratch checkpoint controller, which keeps the entity,
+// time series, webhook and template changes of the sub-range in memory. The changes are merged into the
+// main checkpoint controller in block order, and the checkpoints are made by the main checkpoint controller
+// as in the sequential processing.
+//
+// The result is the same as the sequential processing only if the handlers do not depend on the changes of
+// the blocks before the sub-range, like entities set by the previous blocks. So it must only be enabled for
+// the processors declared it. Besides:
+//   - listing entities in a sub-range disables the range split, and the chain will be processed sequentially
+//   - so does getting an entity in a sub-range other than the first one, if the result depends on the changes
+//     before the sub-range, which may or may not be merged yet
+//   - a sub-range is discarded if the checkpoint data before it is different from the one it started from,
+//     the remaining blocks will be split again in the next round
+//   - a new template instance stops the round at its block, the remaining blocks will be split again in the
+//     next round with the new template instance
+type RangeSplitConfig struct {
+	// SubRanges is the number of the sub-ranges, less than 2 disables the range split
+	SubRanges int
+	// MinSubRangeSize is the minimal number of blocks in a sub-range, if the blocks to be backfilled are not
+	// enough, the round will be processed sequentially
+	MinSubRangeSize uint64
+	// SafeDistance is the number of the latest blocks which will not be backfilled in sub-ranges,
+	// the block builders of the sub-ranges do not check the block links, so these blocks must not be reorged
+	SafeDistance uint64
+	// MaxBufferedBlocks is the max number of processed blocks of a sub-range waiting to be merged
+	MaxBufferedBlocks int
+	// NewBlockBuilder builds a new block builder with its own handler controller for a sub-range
+	NewBlockBuilder func(ctx context.Context) (BlockBuilder, error)
+}
+
+// NewRangeSplitConfig returns a RangeSplitConfig with default settings from the environment
+func NewRangeSplitConfig(
+	subRanges int,
+	newBlockBuilder func(ctx context.Context) (BlockBuilder, error),
+) RangeSplitConfig {
+	return RangeSplitConfig{
+		SubRanges:         subRanges,
+		MinSubRangeSize:   RangeSplitMinSubRangeSize,
+		SafeDistance:      RangeSplitSafeDistance,
+		MaxBufferedBlocks: int(RangeSplitMaxBufferedBlocks),
+		NewBlockBuilder:   newBlockBuilder,
+	}
+}
+
+// split returns the sub-ranges of the blocks from start to be backfilled, or nil if the range split should not be used
+func (cfg RangeSplitConfig) split(start uint64, progressBar ProgressBar) []BlockRange {
+	if cfg.SubRanges < 2 || progressBar.LatestBlock == nil || !progressBar.FullBlockRange.Contains(start) {
+		return nil
+	}
+	latest := progressBar.LatestBlock.GetBlockNumber()
+	if latest < cfg.SafeDistance {
+		return nil
+	}
+	end := latest - cfg.SafeDistance
+	if progressBar.FullBlockRange.EndBlock != nil {
+		end = min(end, *progressBar.FullBlockRange.EndBlock)
+	}
+	n := uint64(cfg.SubRanges)
+	if end < start || end-start+1 < n*max(cfg.MinSubRangeSize, 1) {
+		return nil
+	}
+	size, extra := (end-start+1)/n, (end-start+1)%n
+	ranges := make([]BlockRange, n)
+	for i := range ranges {
+		ranges[i].StartBlock = start
+		start += size + utils.Select[uint64](uint64(i) < extra, 1, 0)
+		ranges[i].EndBlock = utils.WrapPointer(start - 1)
+	}
+	return ranges
+}
+
+// EnableRangeSplit enables the parallel range-split backfill, it is ignored in sequential mode
+func (c *MainController) EnableRangeSplit(cfg RangeSplitConfig) {
+	c.rangeSplit = &cfg
+}
+
+var errRangeSplitSkipped = errors.New("range split skipped")
+
+// round runs one round, the range-split backfill will be tried first if it is enabled and there is no stop block
+func (c *MainController) round(ctx context.Context) error {
+	if c.rangeSplit != nil && !c.seqMode && c.stopBlock == nil {
+		if err := c.backfill(ctx, *c.rangeSplit); !errors.Is(err, errRangeSplitSkipped) {
+			return err
+		}
+	}
+	return c.run(ctx)
+}
+
+// backfill processes the historical blocks in sub-ranges parallelly, returns ErrInternalRangeSplitDone if some
+// blocks were processed, or errRangeSplitSkipped if there are not enough blocks to be split
+func (c *MainController) backfill(ctx context.Context, cfg RangeSplitConfig) error {
+	_, logger := log.FromContext(ctx)
+
+	// probe the blocks to be backfilled
+	checkpoint, templates := c.checkpointCtrl.GetLatestCheckpoint(), c.checkpointCtrl.GetTemplates()
+	probe, err := cfg.NewBlockBuilder(ctx)
+	if err != nil {
+		return NewExternalError(ErrCodeFetchDataFailed, errors.Wrap(err, "build block builder for range split failed"))
+	}
+	agentStat, extErr := probe.Start(ctx, checkpoint, templates)
+	if extErr != nil {
+		return extErr
+	}
+	start, _, progressBar, _, err := probe.Next(ctx)
+	probe.Finish()
+	if err != nil {
+		if errors.Is(err, ErrInternalNeedUpgrade) {
+			return NewExternalError(ErrCodeNeedUpgrade, err)
+		}
+		return NewExternalError(ErrCodeFetchDataFailed, err)
+	}
+	ranges := cfg.split(start, progressBar)
+	if len(ranges) == 0 {
+		return errRangeSplitSkipped
+	}
+
+	if extErr = c.checkpointCtrl.Ready(ctx, agentStat); extErr != nil {
+		return extErr
+	}
+	logger.Infow("range split backfill is ready",
+		"checkpoint", utils.NullOrToString(checkpoint),
+		"templates", utils.CountMap(templates),
+		"ranges", utils.MapSliceNoError(ranges, BlockRange.String))
+	N.DriverStarted(ctx, c.processor, c.chainID, CountTemplatesByID(templates))
+
+	subs := make([]*subRangeCheckpointController, len(ranges))
+	for i, r := range ranges {
+		startCheckpoint := checkpoint
+		if i > 0 {
+			// handler controllers only use the data of the checkpoint to restore their states
+			startCheckpoint = &Checkpoint{BlockNumber: r.StartBlock - 1}
+			if checkpoint != nil {
+				startCheckpoint.Data = maps.Clone(checkpoint.Data)
+			}
+		}
+		subs[i] = newSubRangeCheckpointController(c.checkpointCtrl, r, startCheckpoint, maps.Clone(templates), i == 0)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	blocks := make([]chan *BlockPanel, len(subs))
+	for i, sub := range subs {
+		blocks[i] = make(chan *BlockPanel, max(cfg.MaxBufferedBlocks, 1))
+		g.Go(func() error {
+			return c.runSubRange(gctx, cfg, sub, blocks[i])
+		})
+	}
+	mergeDone := make(chan struct{})
+	g.Go(func() error {
+		if mergeErr := c.mergeSubRanges(gctx, subs, blocks); mergeErr != nil {
+			return mergeErr
+		}
+		close(mergeDone)
+		return nil
+	})
+	g.Go(func() error {
+		return c.checkpointCtrl.KeepSave(gctx, mergeDone)
+	})
+	err = g.Wait()
+
+	for _, sub := range subs {
+		if sub.unsupported.Load() {
+			logger.Warnf("range split is disabled because the handlers read the entities changed before the sub-range, " +
+				"will process sequentially")
+			c.rangeSplit = nil
+			return ErrInternalRangeSplitDone
+		}
+	}
+	if err == nil {
+		return ErrInternalRangeSplitDone
+	}
+	return err
+}
+
+// runSubRange processes the blocks of the sub-range, processed blocks will be sent to blocks in block order,
+// and blocks will be closed after all blocks processed
+func (c *MainController) runSubRange(
+	ctx context.Context,
+	cfg RangeSplitConfig,
+	sub *subRangeCheckpointController,
+	blocks chan<- *BlockPanel,
+) error {
+	ctx, logger := log.FromContext(ctx, "subRange", sub.blockRange.String())
+	blockBuilder, err := cfg.NewBlockBuilder(ctx)
+	if err != nil {
+		return NewExternalError(ErrCodeFetchDataFailed, errors.Wrap(err, "build block builder for sub-range failed"))
+	}
+	if _, extErr := blockBuilder.Start(ctx, sub.start, sub.templates); extErr != nil {
+		return extErr
+	}
+	defer blockBuilder.Finish()
+	logger.Info("sub-range started")
+	defer func() {
+		logger.Info("sub-range finished")
+	}()
+
+	// tasks in the sub-range have their own binding index, will be rebased while merging
+	var bindingIndex atomic.Uint64
+	return c.pipeline(ctx, blockBuilder, sub, &bindingIndex, int(ProcessConcurrency), sub.limit,
+		func(ctx context.Context, r *BlockPanel) error {
+			if r == nil {
+				close(blocks)
+				return nil
+			}
+			select {
+			case blocks <- r:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+}
+
+// mergeSubRanges merges the processed blocks of the sub-ranges into the main checkpoint controller in block order
+func (c *MainController) mergeSubRanges(
+	ctx context.Context,
+	subs []*subRangeCheckpointController,
+	blocks []chan *BlockPanel,
+) error {
+	_, logger := log.FromContext(ctx)
+	for i, sub := range subs {
+		if i > 0 {
+			var data map[string]string
+			if latest := c.checkpointCtrl.GetLatestCheckpoint(); latest != nil {
+				data = latest.Data
+			}
+			if !maps.Equal(data, sub.start.Data) {
+				logger.Infow("checkpoint data changed before the sub-range, will split the remaining blocks again",
+					"subRange", sub.blockRange.String())
+				return ErrInternalRangeSplitDone
+			}
+		}
+		for {
+			var r *BlockPanel
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case r, ok = <-blocks[i]:
+			}
+			if !ok {
+				break
+			}
+			if r.BlockNumber > sub.limit() {
+				// the sub-range stopped by a new template instance which was ignored
+				return ErrInternalRangeSplitDone
+			}
+			startAt := time.Now()
+			hasNewTpl, extErr := sub.merge(ctx, &c.bindingIndex, r)
+			c.analyser.makeCheckpoint(time.Since(startAt))
+			if extErr != nil {
+				return extErr
+			} else if hasNewTpl {
+				return ErrInternalHasNewTemplate
+			}
+		}
+		if sub.limit() < *sub.blockRange.EndBlock {
+			return ErrInternalRangeSplitDone
+		}
+	}
+	return nil
+}
+
+// subRangeEffect replays a change of a task on the main checkpoint controller,
+// globalBase is the binding index of the main controller before the block
+type subRangeEffect func(ctx context.Context, main CheckpointController, globalBase uint64) *ExternalError
+
+func rebaseTaskIndex(index TaskIndex, globalBase uint64) TaskIndex {
+	index.Global = globalBase + uint64(index.InBlock) + 1
+	return index
+}
+
+type entityKey struct {
+	entity string
+	id     string
+}
+
+// subRangeCheckpointController is the scratch checkpoint controller of a sub-range, it keeps all the changes
+// of the sub-range until they are merged into the main checkpoint controller
+type subRangeCheckpointController struct {
+	main       CheckpointController
+	blockRange BlockRange
+	start      *Checkpoint
+	templates  map[uint64][]TemplateInstance
+	// first is true for the first sub-range, the main checkpoint controller only has the changes before the
+	// sub-range and the merged changes of the sub-range, so the entities can be read from it
+	first bool
+
+	// unsupported will be set if the handlers used the operations which can not be done in a sub-range
+	unsupported atomic.Bool
+
+	mu sync.Mutex
+	// stopAt is the block number of the first new template instance
+	stopAt *uint64
+	// effects are the changes of each block, in the order they were made
+	effects map[uint64][]subRangeEffect
+	// entities are the unmerged versions of each entity ordered by block number, one version for each block
+	entities map[entityKey][]*persistent.UncommittedEntityBox
+	// touched are the entities changed in each block
+	touched map[uint64][]entityKey
+}
+
+func newSubRangeCheckpointController(
+	main CheckpointController,
+	blockRange BlockRange,
+	start *Checkpoint,
+	templates map[uint64][]TemplateInstance,
+	first bool,
+) *subRangeCheckpointController {
+	return &subRangeCheckpointController{
+		main:       main,
+		blockRange: blockRange,
+		start:      start,
+		templates:  templates,
+		first:      first,
+		effects:    make(map[uint64][]subRangeEffect),
+		entities:   make(map[entityKey][]*persistent.UncommittedEntityBox),
+		touched:    make(map[uint64][]entityKey),
+	}
+}
+
+// limit returns the last block number should be processed in the sub-range
+func (c *subRangeCheckpointController) limit() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopAt != nil {
+		return min(*c.stopAt, *c.blockRange.EndBlock)
+	}
+	return *c.blockRange.EndBlock
+}
+
+func (c *subRangeCheckpointController) addEffect(blockNumber uint64, effect subRangeEffect) {
+	c.effects[blockNumber] = append(c.effects[blockNumber], effect)
+}
+
+// merge replays the changes of the block on the main checkpoint controller and makes the checkpoint
+func (c *subRangeCheckpointController) merge(
+	ctx context.Context,
+	bindingIndex *atomic.Uint64,
+	r *BlockPanel,
+) (hasNewTemplate bool, extErr *ExternalError) {
+	// keep the lock until the entities are pruned, so that GetEntity will not see the changes of the block twice
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	taskCount := uint64(r.DataSummary.TaskCount)
+	globalBase := bindingIndex.Add(taskCount) - taskCount
+	for _, effect := range c.effects[r.BlockNumber] {
+		if extErr = effect(ctx, c.main, globalBase); extErr != nil {
+			return false, extErr
+		}
+	}
+	delete(c.effects, r.BlockNumber)
+	if hasNewTemplate, extErr = c.main.MakeCheckpoint(ctx, *r.DataSummary, r.ProgressBar); extErr != nil {
+		return false, extErr
+	}
+	for _, key := range c.touched[r.BlockNumber] {
+		versions := c.entities[key]
+		for len(versions) > 0 && versions[0].GenBlockNumber <= r.BlockNumber {
+			versions = versions[1:]
+		}
+		if len(versions) == 0 {
+			delete(c.entities, key)
+		} else {
+			c.entities[key] = versions
+		}
+	}
+	delete(c.touched, r.BlockNumber)
+	return hasNewTemplate, nil
+}
+
+func (c *subRangeCheckpointController) GetLatestCheckpoint() *Checkpoint {
+	return c.start
+}
+
+func (c *subRangeCheckpointController) GetSavedLatestCheckpoint() *Checkpoint {
+	return c.start
+}
+
+func (c *subRangeCheckpointController) GetTemplates() map[uint64][]TemplateInstance {
+	return c.templates
+}
+
+func (c *subRangeCheckpointController) Ready(context.Context, map[string]int) *ExternalError {
+	return nil
+}
+
+func (c *subRangeCheckpointController) CleanCheckpoint(context.Context, uint64, uint64) *ExternalError {
+	return nil
+}
+
+func (c *subRangeCheckpointController) RollbackCheckpoint(context.Context, func(Checkpoint) bool) *ExternalError {
+	return nil
+}
+
+// MakeCheckpoint will not be called, checkpoints are made by the main checkpoint controller after merged
+func (c *subRangeCheckpointController) MakeCheckpoint(context.Context, BlockDataSummary, ProgressBar) (
+	bool,
+	*ExternalError,
+) {
+	return false, nil
+}
+
+func (c *subRangeCheckpointController) Save(context.Context, bool) *ExternalError {
+	return nil
+}
+
+func (c *subRangeCheckpointController) KeepSave(ctx context.Context, allMade chan struct{}) error {
+	select {
+	case <-allMade:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *subRangeCheckpointController) SaveError(ctx context.Context, err *ExternalError) error {
+	return c.main.SaveError(ctx, err)
+}
+
+func (c *subRangeCheckpointController) NewTemplateInstance(
+	ctx context.Context,
+	task Task,
+	templates []TemplateInstance,
+) *ExternalError {
+	blockNumber := task.GetBlockNumber()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopAt == nil || *c.stopAt > blockNumber {
+		c.stopAt = &blockNumber
+	}
+	c.addEffect(blockNumber, func(ctx context.Context, main CheckpointController, _ uint64) *ExternalError {
+		return main.NewTemplateInstance(ctx, task, templates)
+	})
+	return nil
+}
+
+func (c *subRangeCheckpointController) InsertTimeSeriesData(
+	blockNumber uint64,
+	taskIndex TaskIndex,
+	data []timeseries.Dataset,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addEffect(blockNumber, func(_ context.Context, main CheckpointController, globalBase uint64) *ExternalError {
+		main.InsertTimeSeriesData(blockNumber, rebaseTaskIndex(taskIndex, globalBase), data)
+		return nil
+	})
+}
+
+func (c *subRangeCheckpointController) InsertWebhookData(
+	blockNumber uint64,
+	taskIndex TaskIndex,
+	messages []WebhookMessage,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addEffect(blockNumber, func(_ context.Context, main CheckpointController, globalBase uint64) *ExternalError {
+		main.InsertWebhookData(blockNumber, rebaseTaskIndex(taskIndex, globalBase), messages)
+		return nil
+	})
+}
+
+func (c *subRangeCheckpointController) GetEntityOrInterfaceType(entity string) schema.EntityOrInterface {
+	return c.main.GetEntityOrInterfaceType(entity)
+}
+
+func (c *subRangeCheckpointController) GetEntityType(entity string) *schema.Entity {
+	return c.main.GetEntityType(entity)
+}
+
+func (c *subRangeCheckpointController) GetEntity(
+	ctx context.Context,
+	typ schema.EntityOrInterface,
+	id string,
+	blockNumber uint64,
+) (box *persistent.EntityBox, err *ExternalError) {
+	return c.getEntityOrInterface(ctx, typ, id, blockNumber, false)
+}
+
+func (c *subRangeCheckpointController) GetEntityInBlock(
+	ctx context.Context,
+	typ schema.EntityOrInterface,
+	id string,
+	blockNumber uint64,
+) (box *persistent.EntityBox, err *ExternalError) {
+	return c.getEntityOrInterface(ctx, typ, id, blockNumber, true)
+}
+
+func (c *subRangeCheckpointController) getEntityOrInterface(
+	ctx context.Context,
+	typ schema.EntityOrInterface,
+	id string,
+	blockNumber uint64,
+	inBlock bool,
+) (box *persistent.EntityBox, err *ExternalError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entityType := range typ.ListEntities() {
+		box, err = c.getEntity(ctx, entityType, id, blockNumber, inBlock)
+		if err != nil {
+			return
+		}
+		if box != nil && box.Data != nil {
+			return // found
+		}
+	}
+	return // not found, return the last get result
+}
+
+// getEntity returns the entity with the unmerged versions of the sub-range applied to the one in the
+// main checkpoint controller, c.mu must be held.
+// The main checkpoint controller is only used by the first sub-range, for the others the changes merged into
+// it depend on the progress of the previous sub-ranges, so the sub-range will be unsupported if the entity
+// cannot be built from its own versions.
+func (c *subRangeCheckpointController) getEntity(
+	ctx context.Context,
+	entityType *schema.Entity,
+	id string,
+	blockNumber uint64,
+	inBlock bool,
+) (*persistent.EntityBox, *ExternalError) {
+	var versions []*persistent.UncommittedEntityBox
+	for _, version := range c.entities[entityKey{entity: entityType.Name, id: id}] {
+		if version.GenBlockNumber > blockNumber {
+			break
+		}
+		versions = append(versions, version)
+	}
+	if inBlock && (len(versions) == 0 || versions[len(versions)-1].GenBlockNumber != blockNumber) {
+		return nil, nil
+	}
+	cur := &persistent.UncommittedEntityBox{EntityBox: persistent.EntityBox{Entity: entityType.Name, ID: id}}
+	if len(versions) == 0 || !isEntireVersion(entityType, versions[0]) {
+		if !c.first {
+			c.unsupported.Store(true)
+			return nil, NewExternalError(ErrCodeSystem,
+				errors.New("get entity changed before the sub-range is not supported in range split"))
+		}
+		if len(versions) == 0 {
+			return c.main.GetEntity(ctx, entityType, id, blockNumber)
+		}
+		// versions before the first unmerged one are all in the main checkpoint controller
+		base, extErr := c.main.GetEntity(ctx, entityType, id, versions[0].GenBlockNumber-1)
+		if extErr != nil {
+			return nil, extErr
+		}
+		if base != nil {
+			cur.EntityBox = *base.Copy()
+		}
+	}
+	for _, version := range versions {
+		cur.Merge(entityType, copyUncommittedEntityBox(version))
+	}
+	if cur.Data != nil && !cur.IsComplete(entityType) {
+		cur.FillLostFields(nil, entityType)
+	}
+	return &cur.EntityBox, nil
+}
+
+// isEntireVersion returns whether the version does not depend on the previous one
+func isEntireVersion(entityType *schema.Entity, version *persistent.UncommittedEntityBox) bool {
+	return version.Data == nil || (len(version.Operator) == 0 && version.IsComplete(entityType))
+}
+
+func (c *subRangeCheckpointController) ListEntity(
+	context.Context,
+	*schema.Entity,
+	[]persistent.EntityFilter,
+	[]persistent.EntityOrder,
+	string,
+	int,
+	uint64,
+) ([]*persistent.EntityBox, *string, *ExternalError) {
+	c.unsupported.Store(true)
+	return nil, nil, NewExternalError(ErrCodeSystem, errors.New("list entity is not supported in range split"))
+}
+
+func (c *subRangeCheckpointController) ListRelated(
+	context.Context,
+	*schema.Entity,
+	string,
+	string,
+	uint64,
+) ([]*persistent.EntityBox, schema.EntityOrInterface, *ExternalError) {
+	c.unsupported.Store(true)
+	return nil, nil, NewExternalError(ErrCodeSystem, errors.New("list related entity is not supported in range split"))
+}
+
+func (c *subRangeCheckpointController) SetEntity(
+	_ context.Context,
+	entityType *schema.Entity,
+	box persistent.UncommittedEntityBox,
+) *ExternalError {
+	blockNumber := box.GenBlockNumber
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	replayed := copyUncommittedEntityBox(&box)
+	c.addEffect(blockNumber, func(ctx context.Context, main CheckpointController, _ uint64) *ExternalError {
+		return main.SetEntity(ctx, entityType, *replayed)
+	})
+	key := entityKey{entity: entityType.Name, id: box.ID}
+	versions := c.entities[key]
+	i := sort.Search(len(versions), func(i int) bool {
+		return versions[i].GenBlockNumber >= blockNumber
+	})
+	if i < len(versions) && versions[i].GenBlockNumber == blockNumber {
+		versions[i].Merge(entityType, copyUncommittedEntityBox(&box))
+		return nil
+	}
+	c.entities[key] = slices.Insert(versions, i, copyUncommittedEntityBox(&box))
+	c.touched[blockNumber] = append(c.touched[blockNumber], key)
+	return nil
+}
+
+func copyUncommittedEntityBox(box *persistent.UncommittedEntityBox) *persistent.UncommittedEntityBox {
+	return &persistent.UncommittedEntityBox{EntityBox: *box.Copy(), Operator: maps.Clone(box.Operator)}
+}
+
+func (c *subRangeCheckpointController) Snapshot() map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]any{
+		"blockRange":     c.blockRange.String(),
+		"start":          utils.NullOrToString(c.start),
+		"stopAt":         c.stopAt,
+		"unmergedBlocks": len(c.effects),
+		"entities":       len(c.entities),
+	}
+}
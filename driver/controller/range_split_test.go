@@ -0,0 +1,450 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rsh "sentioxyz/sentio-core/common/richstructhelper"
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+	"sentioxyz/sentio-core/driver/timeseries"
+)
+
+type testTimeSeriesRecord struct {
+	BlockNumber uint64
+	Global      uint64
+	InBlock     int
+}
+
+type testRecordTimeSeriesController struct {
+	EmptyTimeSeriesController
+
+	mu      sync.Mutex
+	records []testTimeSeriesRecord
+}
+
+func (c *testRecordTimeSeriesController) Reset(ctx context.Context, checkpoint *Checkpoint) *ExternalError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var kept []testTimeSeriesRecord
+	for _, r := range c.records {
+		if checkpoint != nil && r.BlockNumber <= checkpoint.BlockNumber {
+			kept = append(kept, r)
+		}
+	}
+	c.records = kept
+	return nil
+}
+
+func (c *testRecordTimeSeriesController) Insert(blockNumber uint64, taskIndex TaskIndex, _ []timeseries.Dataset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.records = append(c.records, testTimeSeriesRecord{
+		BlockNumber: blockNumber,
+		Global:      taskIndex.Global,
+		InBlock:     taskIndex.InBlock,
+	})
+}
+
+func (c *testRecordTimeSeriesController) sorted() []testTimeSeriesRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := slices.Clone(c.records)
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].BlockNumber != r[j].BlockNumber {
+			return r[i].BlockNumber < r[j].BlockNumber
+		}
+		return r[i].InBlock < r[j].InBlock
+	})
+	return r
+}
+
+// testRecordEntityController keeps all the changes of the entities in memory, ordered by block number like the
+// persistent controller, so the tasks of different blocks may set the entities in any order
+type testRecordEntityController struct {
+	EmptyEntityController
+
+	entityType *schema.Entity
+
+	mu      sync.Mutex
+	changes map[string][]*persistent.UncommittedEntityBox
+}
+
+func newTestRecordEntityController(t *testing.T) *testRecordEntityController {
+	sch, err := schema.ParseAndVerifySchema(`
+type Counter @entity {
+  id: ID!
+  total: Int!
+  last: Int!
+}
+`)
+	require.NoError(t, err)
+	return &testRecordEntityController{
+		entityType: sch.GetEntity("Counter"),
+		changes:    make(map[string][]*persistent.UncommittedEntityBox),
+	}
+}
+
+func (c *testRecordEntityController) Reset(ctx context.Context, checkpoint *Checkpoint) *ExternalError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, changes := range c.changes {
+		var kept []*persistent.UncommittedEntityBox
+		for _, v := range changes {
+			if checkpoint != nil && v.GenBlockNumber <= checkpoint.BlockNumber {
+				kept = append(kept, v)
+			}
+		}
+		c.changes[id] = kept
+	}
+	return nil
+}
+
+func (c *testRecordEntityController) GetEntityOrInterfaceType(string) schema.EntityOrInterface {
+	return c.entityType
+}
+
+func (c *testRecordEntityController) GetEntityType(string) *schema.Entity {
+	return c.entityType
+}
+
+// get returns the entity with all the changes not after blockNumber applied, c.mu must be held
+func (c *testRecordEntityController) get(id string, blockNumber uint64) *persistent.EntityBox {
+	var cur *persistent.UncommittedEntityBox
+	for _, v := range c.changes[id] {
+		if v.GenBlockNumber > blockNumber {
+			break
+		}
+		if cur == nil {
+			cur = &persistent.UncommittedEntityBox{EntityBox: persistent.EntityBox{Entity: v.Entity, ID: v.ID}}
+		}
+		cur.Merge(c.entityType, copyUncommittedEntityBox(v))
+		if cur.Data != nil {
+			cur.FillLostFields(nil, c.entityType)
+		}
+	}
+	if cur == nil {
+		return nil
+	}
+	return &cur.EntityBox
+}
+
+func (c *testRecordEntityController) GetEntity(
+	_ context.Context,
+	_ schema.EntityOrInterface,
+	id string,
+	blockNumber uint64,
+) (*persistent.EntityBox, *ExternalError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(id, blockNumber), nil
+}
+
+func (c *testRecordEntityController) GetEntityInBlock(
+	_ context.Context,
+	_ schema.EntityOrInterface,
+	id string,
+	blockNumber uint64,
+) (*persistent.EntityBox, *ExternalError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range c.changes[id] {
+		if v.GenBlockNumber == blockNumber {
+			return c.get(id, blockNumber), nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *testRecordEntityController) SetEntity(
+	_ context.Context,
+	entityType *schema.Entity,
+	box persistent.UncommittedEntityBox,
+) *ExternalError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changes := c.changes[box.ID]
+	i := sort.Search(len(changes), func(i int) bool {
+		return changes[i].GenBlockNumber >= box.GenBlockNumber
+	})
+	if i < len(changes) && changes[i].GenBlockNumber == box.GenBlockNumber {
+		changes[i].Merge(entityType, copyUncommittedEntityBox(&box))
+		return nil
+	}
+	c.changes[box.ID] = slices.Insert(changes, i, copyUncommittedEntityBox(&box))
+	return nil
+}
+
+func (c *testRecordEntityController) latest() map[string]map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := make(map[string]map[string]any)
+	for id := range c.changes {
+		if box := c.get(id, math.MaxUint64); box != nil {
+			r[id] = box.Data
+		}
+	}
+	return r
+}
+
+func testIncOperator() map[string]persistent.Operator {
+	return map[string]persistent.Operator{"total": {NumCalc: &persistent.OperatorNumCalc{
+		Multi: rsh.NewIntValue(1),
+		Add:   rsh.NewIntValue(1),
+	}}}
+}
+
+// testCounterEffect does not depend on the changes of the previous blocks, so the range split is allowed
+func testCounterEffect(ctx context.Context, t *testTask, checkpointCtrl CheckpointController) *ExternalError {
+	bn := t.GetBlockNumber()
+	checkpointCtrl.InsertTimeSeriesData(bn, t.index, nil)
+	id := fmt.Sprintf("c%d", bn%3)
+	return checkpointCtrl.SetEntity(ctx, checkpointCtrl.GetEntityType("Counter"), persistent.UncommittedEntityBox{
+		EntityBox: persistent.EntityBox{
+			Entity:         "Counter",
+			ID:             id,
+			Data:           map[string]any{"id": id, "last": int32(bn)},
+			GenBlockNumber: bn,
+		},
+		Operator: testIncOperator(),
+	})
+}
+
+type testRangeSplitResult struct {
+	checkpoints []Checkpoint
+	templates   map[uint64][]TemplateInstance
+	timeSeries  []testTimeSeriesRecord
+	entities    map[string]map[string]any
+}
+
+func runRangeSplitTest(t *testing.T, subRanges int, newTplIndex map[uint64]TemplateInstance) testRangeSplitResult {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	cs := &testCheckpointStore{}
+	cli := newTestClient(0, 100)
+	newHandlerCtrl := func() *testHandlerController {
+		return &testHandlerController{
+			Client:      cli,
+			TaskSleep:   time.Millisecond * 10,
+			NewTplIndex: newTplIndex,
+			EndBlock:    utils.WrapPointer[uint64](80),
+			Effect:      testCounterEffect,
+		}
+	}
+	ts, ec := &testRecordTimeSeriesController{}, newTestRecordEntityController(t)
+	cc, err := NewCheckpointController(
+		ctx,
+		"1",
+		time.Millisecond*100,
+		time.Millisecond*200,
+		100000,
+		cs,
+		EmptyQuotaService{},
+		ts,
+		ec,
+		EmptyWebhookController{},
+		nil,
+	)
+	require.NoError(t, err)
+	mc := NewMainController(NewBlockBuilder(newHandlerCtrl(), cli, false), cc, false, nil, "")
+	if subRanges > 0 {
+		mc.EnableRangeSplit(RangeSplitConfig{
+			SubRanges:         subRanges,
+			MinSubRangeSize:   5,
+			MaxBufferedBlocks: 3,
+			NewBlockBuilder: func(ctx context.Context) (BlockBuilder, error) {
+				return NewBlockBuilder(newHandlerCtrl(), cli, false), nil
+			},
+		})
+	}
+	require.NoError(t, mc.Main(ctx))
+	return testRangeSplitResult{
+		checkpoints: cs.checkpoints,
+		templates:   cs.templates,
+		timeSeries:  ts.sorted(),
+		entities:    ec.latest(),
+	}
+}
+
+func Test_rangeSplit_sameAsSequential(t *testing.T) {
+	seq := runRangeSplitTest(t, 0, nil)
+	split := runRangeSplitTest(t, 4, nil)
+
+	require.NotEmpty(t, seq.checkpoints)
+	assert.Equal(t, uint64(80), seq.checkpoints[len(seq.checkpoints)-1].BlockNumber)
+	assert.Equal(t, seq.checkpoints, split.checkpoints)
+	assert.Equal(t, seq.templates, split.templates)
+	assert.Equal(t, seq.timeSeries, split.timeSeries)
+	assert.Equal(t, seq.entities, split.entities)
+	assert.Equal(t, int32(53), seq.entities["c0"]["total"])
+}
+
+func Test_rangeSplit_newTpl(t *testing.T) {
+	newTplIndex := map[uint64]TemplateInstance{
+		31: {Address: "0x1111", BlockRange: BlockRange{StartBlock: 31}},
+		62: {Address: "0x2222", BlockRange: BlockRange{StartBlock: 62}},
+	}
+	seq := runRangeSplitTest(t, 0, newTplIndex)
+	split := runRangeSplitTest(t, 4, newTplIndex)
+
+	assert.Len(t, seq.templates, 2)
+	// the checkpoints before the new template instances may be saved or not, depends on the save interval
+	require.NotEmpty(t, seq.checkpoints)
+	require.NotEmpty(t, split.checkpoints)
+	assert.Equal(t, seq.checkpoints[len(seq.checkpoints)-1], split.checkpoints[len(split.checkpoints)-1])
+	assert.Equal(t, seq.templates, split.templates)
+	assert.Equal(t, seq.entities, split.entities)
+	// the binding index is not reset after the new template instances, so only compare the positions
+	for _, r := range [][]testTimeSeriesRecord{seq.timeSeries, split.timeSeries} {
+		for i := range r {
+			r[i].Global = 0
+		}
+	}
+	assert.Equal(t, seq.timeSeries, split.timeSeries)
+}
+
+func Test_RangeSplitConfig_split(t *testing.T) {
+	cfg := RangeSplitConfig{SubRanges: 3, MinSubRangeSize: 10, SafeDistance: 5}
+	progressBar := func(latest uint64, end *uint64) ProgressBar {
+		return ProgressBar{
+			LatestBlock:    newTestBlockHeader(latest, "", ""),
+			FullBlockRange: BlockRange{StartBlock: 0, EndBlock: end},
+		}
+	}
+	assert.Equal(t, []BlockRange{
+		{StartBlock: 10, EndBlock: utils.WrapPointer[uint64](21)},
+		{StartBlock: 22, EndBlock: utils.WrapPointer[uint64](32)},
+		{StartBlock: 33, EndBlock: utils.WrapPointer[uint64](43)},
+	}, cfg.split(10, progressBar(48, nil)))
+	assert.Equal(t, []BlockRange{
+		{StartBlock: 10, EndBlock: utils.WrapPointer[uint64](19)},
+		{StartBlock: 20, EndBlock: utils.WrapPointer[uint64](29)},
+		{StartBlock: 30, EndBlock: utils.WrapPointer[uint64](39)},
+	}, cfg.split(10, progressBar(100, utils.WrapPointer[uint64](39))))
+	// not enough blocks
+	assert.Nil(t, cfg.split(10, progressBar(43, nil)))
+	assert.Nil(t, cfg.split(10, progressBar(3, nil)))
+	// out of the full block range
+	assert.Nil(t, cfg.split(40, ProgressBar{FullBlockRange: BlockRange{EndBlock: utils.WrapPointer[uint64](39)}}))
+	// disabled
+	cfg.SubRanges = 1
+	assert.Nil(t, cfg.split(10, progressBar(48, nil)))
+}
+
+func Test_subRangeCheckpointController_entity(t *testing.T) {
+	ctx := context.Background()
+	ec := newTestRecordEntityController(t)
+	main, err := NewCheckpointController(
+		ctx,
+		"1",
+		time.Second,
+		time.Second*2,
+		100000,
+		&testCheckpointStore{},
+		EmptyQuotaService{},
+		EmptyTimeSeriesController{},
+		ec,
+		EmptyWebhookController{},
+		nil,
+	)
+	require.NoError(t, err)
+	setCounter := func(cc CheckpointController, bn uint64) {
+		require.Nil(t, cc.SetEntity(ctx, ec.entityType, persistent.UncommittedEntityBox{
+			EntityBox: persistent.EntityBox{
+				Entity:         "Counter",
+				ID:             "c",
+				Data:           map[string]any{"id": "c", "last": int32(bn)},
+				GenBlockNumber: bn,
+			},
+			Operator: testIncOperator(),
+		}))
+	}
+	getCounter := func(cc CheckpointController, bn uint64, inBlock bool) map[string]any {
+		var box *persistent.EntityBox
+		var extErr *ExternalError
+		if inBlock {
+			box, extErr = cc.GetEntityInBlock(ctx, ec.entityType, "c", bn)
+		} else {
+			box, extErr = cc.GetEntity(ctx, ec.entityType, "c", bn)
+		}
+		require.Nil(t, extErr)
+		if box == nil {
+			return nil
+		}
+		return box.Data
+	}
+	counter := func(total, last int32) map[string]any {
+		return map[string]any{"id": "c", "total": total, "last": last}
+	}
+
+	setCounter(main, 5)
+	sub := newSubRangeCheckpointController(main, BlockRange{
+		StartBlock: 10,
+		EndBlock:   utils.WrapPointer[uint64](20),
+	}, &Checkpoint{BlockNumber: 9}, nil, true)
+	setCounter(sub, 12)
+	setCounter(sub, 12)
+	setCounter(sub, 15)
+
+	assert.Equal(t, counter(1, 5), getCounter(sub, 11, false))
+	assert.Nil(t, getCounter(sub, 11, true))
+	assert.Equal(t, counter(3, 12), getCounter(sub, 12, false))
+	assert.Equal(t, counter(3, 12), getCounter(sub, 13, false))
+	assert.Nil(t, getCounter(sub, 13, true))
+	assert.Equal(t, counter(4, 15), getCounter(sub, 15, true))
+	// changes of the sub-range are not in the main controller before merged
+	assert.Equal(t, counter(1, 5), getCounter(main, 20, false))
+
+	var bindingIndex atomic.Uint64
+	bindingIndex.Store(100)
+	hasNewTpl, extErr := sub.merge(ctx, &bindingIndex, &BlockPanel{
+		BlockNumber: 12,
+		DataSummary: &BlockDataSummary{BlockNumber: 12, TaskCount: 2},
+		ProgressBar: ProgressBar{LatestBlock: newTestBlockHeader(100, "", "")},
+	})
+	assert.Nil(t, extErr)
+	assert.False(t, hasNewTpl)
+	assert.Equal(t, uint64(102), bindingIndex.Load())
+	assert.Equal(t, uint64(12), main.GetLatestCheckpoint().BlockNumber)
+	assert.Equal(t, counter(3, 12), getCounter(main, 20, false))
+	// merged changes are not applied twice
+	assert.Equal(t, counter(3, 12), getCounter(sub, 13, false))
+	assert.Equal(t, counter(4, 15), getCounter(sub, 15, false))
+
+	_, _, extErr = sub.ListEntity(ctx, ec.entityType, nil, nil, "", 10, 15)
+	assert.NotNil(t, extErr)
+	assert.True(t, sub.unsupported.Load())
+
+	// the sub-ranges after the first one only read the entities built from their own versions
+	later := newSubRangeCheckpointController(main, BlockRange{
+		StartBlock: 21,
+		EndBlock:   utils.WrapPointer[uint64](30),
+	}, &Checkpoint{BlockNumber: 20}, nil, false)
+	require.Nil(t, later.SetEntity(ctx, ec.entityType, persistent.UncommittedEntityBox{
+		EntityBox: persistent.EntityBox{
+			Entity:         "Counter",
+			ID:             "c",
+			Data:           counter(10, 22),
+			GenBlockNumber: 22,
+		},
+	}))
+	setCounter(later, 25)
+	assert.Equal(t, counter(10, 22), getCounter(later, 23, false))
+	assert.Equal(t, counter(11, 25), getCounter(later, 25, true))
+	assert.False(t, later.unsupported.Load())
+	_, extErr = later.GetEntity(ctx, ec.entityType, "c", 21)
+	assert.NotNil(t, extErr)
+	assert.True(t, later.unsupported.Load())
+}
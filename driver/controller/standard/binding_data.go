@@ -62,6 +62,8 @@ var executeOrder = map[protos.HandlerType]int{
 	protos.HandlerType_FUEL_BLOCK:        4,
 	protos.HandlerType_COSMOS_CALL:       1,
 	protos.HandlerType_STARKNET_EVENT:    1,
+	protos.HandlerType_BTC_TRANSACTION:   1,
+	protos.HandlerType_BTC_BLOCK:         2,
 }
 
 func CmpHandlerType(a, b *protos.DataBinding) int {
@@ -0,0 +1,87 @@
+package btc
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"sentioxyz/sentio-core/common/protojson"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/data/btc"
+)
+
+type BlockData struct {
+	btc.Block
+
+	mainData btc.BlockMainData
+
+	blockPb           *structpb.Struct
+	blockPbSize       int
+	transactionPb     []*structpb.Struct
+	transactionPbSize []int
+
+	taskList      []controller.Task
+	taskTotalSize int
+	dataSource    string
+
+	checkpointData map[string]string
+}
+
+func (d *BlockData) GetTaskList() []controller.Task {
+	return d.taskList
+}
+
+func (d *BlockData) CheckpointData() map[string]string {
+	return d.checkpointData
+}
+
+func (d *BlockData) DataSource() string {
+	return d.dataSource
+}
+
+func (d *BlockData) Size() int {
+	return d.taskTotalSize
+}
+
+func toStructpb(v any) (*structpb.Struct, int, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, 0, err
+	}
+	pb := new(structpb.Struct)
+	if err = protojson.Unmarshal(j, pb); err != nil {
+		return nil, 0, err
+	}
+	return pb, len(j), nil
+}
+
+func (d *BlockData) getBlockPb() (*structpb.Struct, int, error) {
+	if d.blockPb == nil {
+		blockPb, size, err := toStructpb(d.Block)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "build structpb of block %d failed", d.GetBlockNumber())
+		}
+		d.blockPb, d.blockPbSize = blockPb, size
+	}
+	return d.blockPb, d.blockPbSize, nil
+}
+
+// getTxPb uses the JSON encoding of the transaction, so the amounts are the decimal BTC numbers like bitcoind
+func (d *BlockData) getTxPb(i int) (*structpb.Struct, int, error) {
+	if i >= len(d.mainData.Txs) {
+		panic(errors.Errorf("index %d out of range [0,%d) in BlockData #%d", i, len(d.mainData.Txs), d.GetBlockNumber()))
+	}
+	if len(d.transactionPb) == 0 {
+		d.transactionPb = make([]*structpb.Struct, len(d.mainData.Txs))
+		d.transactionPbSize = make([]int, len(d.mainData.Txs))
+	}
+	if d.transactionPb[i] == nil {
+		txPb, size, err := toStructpb(d.mainData.Txs[i])
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "build structpb of transaction %s failed", d.mainData.Txs[i].TxID)
+		}
+		d.transactionPb[i], d.transactionPbSize[i] = txPb, size
+	}
+	return d.transactionPb[i], d.transactionPbSize[i], nil
+}
@@ -0,0 +1,188 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	chainBTC "sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/config"
+	"sentioxyz/sentio-core/driver/controller/data"
+	"sentioxyz/sentio-core/driver/controller/data/btc"
+	"sentioxyz/sentio-core/driver/controller/fetcher"
+	"sentioxyz/sentio-core/driver/controller/standard"
+	"sentioxyz/sentio-core/processor/protos"
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
+type BTCHandlerAgent interface {
+	standard.HandlerAgent[*BlockData]
+}
+
+type HandlerController struct {
+	*standard.BaseHandlerController[btc.Client, *BlockData, BTCHandlerAgent]
+}
+
+func NewHandlerController(
+	processor *models.Processor,
+	initResult *protos.InitResponse,
+	chainConfig *config.ChainConfig,
+	client btc.Client,
+	processorClients []protos.ProcessorV3Client,
+) *HandlerController {
+	return &HandlerController{
+		BaseHandlerController: standard.NewBaseHandlerController[btc.Client, *BlockData, BTCHandlerAgent](
+			processor, initResult, chainConfig, client, processorClients),
+	}
+}
+
+func (c *HandlerController) Prologue(
+	ctx context.Context,
+	checkpoint *controller.Checkpoint,
+	templates map[uint64][]controller.TemplateInstance,
+	first uint64,
+	latest controller.BlockHeader,
+) *controller.ExternalError {
+	if extErr := c.BaseHandlerController.SetTemplates(ctx, templates); extErr != nil {
+		return extErr
+	}
+	if extErr := c.buildAgents(ctx, first); extErr != nil {
+		return extErr
+	}
+	c.DisableAgents(ctx)
+	if extErr := c.PrepareExecute(ctx); extErr != nil {
+		return extErr
+	}
+	return nil
+}
+
+func (c *HandlerController) buildAgents(ctx context.Context, first uint64) *controller.ExternalError {
+	_, logger := log.FromContext(ctx)
+	c.Agents = nil
+	var err error
+
+	for dataSourceID, contractConfig := range c.Config.ContractConfigs {
+		// there is no contract in bitcoin, the address of the contract config is the default address filter
+		address := standard.AdjustAddress(contractConfig.GetContract().GetAddress())
+		dataSource := standard.BuildDataSource("BTC", c.ChainConfig.ChainID, "Address", address)
+		blockRange := controller.BlockRange{
+			StartBlock: max(contractConfig.GetStartBlock(), first),
+			EndBlock:   standard.AdjustEndBlock(contractConfig.GetEndBlock()),
+		}
+
+		// interval
+		for _, intervalConfig := range contractConfig.IntervalConfigs {
+			agent := HandlerAgentInterval{
+				BaseHandlerAgent: controller.NewBaseHandlerAgent(
+					dataSource, dataSourceID, "interval", intervalConfig, blockRange),
+			}
+			agent.IntervalConfig, err = standard.NewIntervalConfig(intervalConfig)
+			if err != nil {
+				return controller.NewExternalError(controller.ErrCodeUnexpectedProcessorConfig,
+					errors.Wrapf(err, "unexpected config for handler %s", agent.GetHandlerID().String()))
+			}
+			c.Agents = append(c.Agents, agent)
+			logger.Infow("has new agent", "agent", agent.Snapshot())
+		}
+
+		// transaction
+		for _, txConfig := range contractConfig.BtcTransactionConfigs {
+			agent := HandlerAgentTransaction{
+				BaseHandlerAgent: controller.NewBaseHandlerAgent(dataSource, dataSourceID, "transaction", txConfig, blockRange),
+			}
+			for _, filterConfig := range txConfig.GetFilters() {
+				filter := chainBTC.TransactionFilter{
+					Address: filterConfig.GetAddress(),
+					Script:  filterConfig.GetScript(),
+				}
+				if filter.IsEmpty() {
+					return controller.NewExternalError(controller.ErrCodeUnexpectedProcessorConfig,
+						errors.Errorf("empty filter for handler %s", agent.GetHandlerID().String()))
+				}
+				agent.Filters = append(agent.Filters, filter)
+			}
+			if len(agent.Filters) == 0 && address != "" {
+				agent.Filters = []chainBTC.TransactionFilter{{Address: address}}
+			}
+			if len(agent.Filters) == 0 {
+				// all the transactions of the chain is too much to handle
+				return controller.NewExternalError(controller.ErrCodeUnexpectedProcessorConfig,
+					errors.Errorf("no filter for handler %s", agent.GetHandlerID().String()))
+			}
+			c.Agents = append(c.Agents, agent)
+			logger.Infow("has new agent", "agent", agent.Snapshot())
+		}
+	}
+	return nil
+}
+
+func (c *HandlerController) BuildBlockDataFetcher(
+	firstBlockNumber uint64,
+	currentBlockNumber uint64,
+	latest controller.BlockHeader,
+) controller.Fetcher[controller.BlockData] {
+	req := c.getDataRequirement()
+	req.Interval = append(req.Interval, c.BuildReportRequirements(currentBlockNumber)...)
+
+	fetchNamePrefix := fmt.Sprintf("BTC::%s::", c.ChainConfig.ChainID)
+	return fetcher.TransferFetcher(
+		fetchNamePrefix+"BlockDataFetcher",
+		btc.BuildBlockMainDataFetcher(fetchNamePrefix, req, firstBlockNumber, currentBlockNumber, latest, c.Client),
+		latest,
+		controller.ProcessConcurrency,
+		256*1024*1024, // 256MB
+		100,
+		time.Second*3,
+		20,
+		time.Second,
+		func(ctx context.Context, blockNumber uint64, from btc.BlockMainData) (controller.BlockData, bool, error) {
+			if from.IsEmpty() {
+				return nil, false, nil
+			}
+			var err error
+			result := BlockData{mainData: from, checkpointData: make(map[string]string)}
+			// always need header
+			if result.Block, err = c.Client.GetBlock(ctx, blockNumber); err != nil {
+				return nil, false, err
+			}
+			// the transactions and the header may be read from different forks during a reorg
+			for _, tx := range from.Txs {
+				if tx.BlockHash != result.GetBlockHash() {
+					return nil, false, errors.Errorf("transaction %s is in block %d/%s but the header is %s",
+						tx.TxID, blockNumber, tx.BlockHash, result.GetBlockHash())
+				}
+			}
+			// build binding data
+			if result.taskList, result.taskTotalSize, err = c.BuildTaskList(ctx, &result); err != nil {
+				return nil, false, err
+			}
+			return &result, true, nil
+		},
+	)
+}
+
+func (c *HandlerController) getDataRequirement() (dr btc.DataRequirement) {
+	for _, agent := range c.Agents {
+		switch ag := agent.(type) {
+		case HandlerAgentTransaction:
+			dr.Tx = append(dr.Tx, btc.TransactionRequirement{
+				Filters:    ag.Filters,
+				BlockRange: ag.Range,
+			})
+		case HandlerAgentInterval:
+			dr.Interval = append(dr.Interval, data.IntervalRequirement{
+				IntervalConfig: ag.IntervalConfig,
+				BlockRange:     ag.Range,
+			})
+		}
+	}
+	return dr
+}
+
+func (c *HandlerController) Epilogue() {
+	c.BaseHandlerController.FinishExecute()
+}
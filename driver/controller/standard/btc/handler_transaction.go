@@ -0,0 +1,55 @@
+package btc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sentioxyz/sentio-core/chain/btc"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/standard"
+	"sentioxyz/sentio-core/processor/protos"
+)
+
+type HandlerAgentTransaction struct {
+	controller.BaseHandlerAgent
+
+	Filters []btc.TransactionFilter
+}
+
+func (a HandlerAgentTransaction) Snapshot() any {
+	return map[string]any{
+		"HandlerID": a.HandlerID,
+		"Range":     a.Range.String(),
+		"Filters":   a.Filters,
+	}
+}
+
+func (a HandlerAgentTransaction) BuildBindingDataList(
+	ctx context.Context,
+	bd *BlockData,
+) (result []standard.BindingDataInner, err error) {
+	for i, tx := range bd.mainData.Txs {
+		if !btc.CheckTransaction(tx, a.Filters) {
+			continue
+		}
+		txPb, size, err := bd.getTxPb(i)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, standard.BindingDataInner{
+			HandlerType: protos.HandlerType_BTC_TRANSACTION,
+			TxIndex:     int(tx.TransactionIndex),
+			Data: &protos.Data{
+				Value: &protos.Data_BtcTransaction{
+					BtcTransaction: &protos.Data_BTCTransaction{
+						Transaction: txPb,
+						Timestamp:   timestamppb.New(bd.GetBlockTime()),
+					},
+				},
+			},
+			DataSize: size,
+		})
+	}
+	return result, nil
+}
@@ -508,8 +508,9 @@ func (b *task) waitProcess(
 							fieldTitle, ft.GetOp().String(), b.title()))
 				}
 			}
+			// the list request of the processor has no order, use the default order
 			boxes, nextCursor, listErr := checkpointCtrl.ListEntity(
-				ctx, entityType, filters, dbReq.GetList().GetCursor(), pageSize, b.GetBlockNumber())
+				ctx, entityType, filters, nil, dbReq.GetList().GetCursor(), pageSize, b.GetBlockNumber())
 			if listErr != nil {
 				reqErrLogger().Errorfe(listErr, "list entity failed")
 				return stat, listErr.Wrapf("list entity for %s failed", b.title())
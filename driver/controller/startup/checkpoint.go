@@ -9,6 +9,7 @@ import (
 
 	"sentioxyz/sentio-core/common/compress"
 	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/checkpointstore"
 	sentioerror "sentioxyz/sentio-core/service/common/errors"
 	"sentioxyz/sentio-core/service/processor/models"
 	"sentioxyz/sentio-core/service/processor/protos"
@@ -189,3 +190,49 @@ func (s *checkpointStore) SaveError(ctx context.Context, extErr *controller.Exte
 	s.chainState = &cs
 	return nil
 }
+
+// historyCheckpointStore keeps the checkpoints in the checkpointstore backend configured by Config.CheckpointStore,
+// which keeps all the history, and also in the processor service which reports the progress of the chain.
+// The backend is the source of Load, the processor service is only read before the backend has any checkpoint
+// of the chain, so a processor switched to the backend continues from where it is.
+type historyCheckpointStore struct {
+	history *checkpointstore.Store
+	service *checkpointStore
+}
+
+func (s *historyCheckpointStore) Load(ctx context.Context) (
+	checkpoints []controller.Checkpoint,
+	templates map[uint64][]controller.TemplateInstance,
+	err error,
+) {
+	if checkpoints, templates, err = s.history.Load(ctx); err != nil {
+		return nil, nil, err
+	}
+	serviceCheckpoints, serviceTemplates, err := s.service.Load(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(checkpoints) == 0 {
+		return serviceCheckpoints, serviceTemplates, nil
+	}
+	return checkpoints, templates, nil
+}
+
+func (s *historyCheckpointStore) Save(
+	ctx context.Context,
+	checkpoints []controller.Checkpoint,
+	templates map[uint64][]controller.TemplateInstance,
+	agentStat map[string]int,
+) error {
+	if err := s.history.Save(ctx, checkpoints, templates, agentStat); err != nil {
+		return err
+	}
+	return s.service.Save(ctx, checkpoints, templates, agentStat)
+}
+
+func (s *historyCheckpointStore) SaveError(ctx context.Context, extErr *controller.ExternalError) error {
+	if err := s.history.SaveError(ctx, extErr); err != nil {
+		return err
+	}
+	return s.service.SaveError(ctx, extErr)
+}
@@ -0,0 +1,166 @@
+package startup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"sentioxyz/sentio-core/chain/parquet"
+	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/checkpointstore"
+	"sentioxyz/sentio-core/driver/controller/entitybackup"
+	"sentioxyz/sentio-core/driver/entity/embedded"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/persistent/persistenttest"
+	"sentioxyz/sentio-core/service/processor/models"
+	protossvc "sentioxyz/sentio-core/service/processor/protos"
+)
+
+// fakeProcessorService keeps the chain states updated by the driver
+type fakeProcessorService struct {
+	protossvc.ProcessorServiceClient
+
+	chainStates map[string]*models.ChainState
+}
+
+func (s *fakeProcessorService) UpdateChainProcessorStatus(
+	_ context.Context,
+	req *protossvc.UpdateChainProcessorStatusRequest,
+	_ ...grpc.CallOption,
+) (*protossvc.UpdateChainProcessorStatusResponse, error) {
+	var cs models.ChainState
+	if err := cs.FromPB(req.GetChainState(), req.GetId()); err != nil {
+		return nil, err
+	}
+	s.chainStates[cs.ChainID] = &cs
+	return &protossvc.UpdateChainProcessorStatusResponse{}, nil
+}
+
+// restart returns the startup controller of a restarted driver, which sees the saved chain states
+func (s *fakeProcessorService) restart(backend checkpointstore.Backend) *baseStartupController {
+	processor := &models.Processor{ID: "p1"}
+	for _, cs := range s.chainStates {
+		processor.ChainStates = append(processor.ChainStates, cs)
+	}
+	return &baseStartupController{
+		config:            Config{ProcessorID: "p1"},
+		processorClient:   s,
+		processor:         processor,
+		checkpointBackend: backend,
+	}
+}
+
+func newPebbleBackend(t *testing.T) checkpointstore.Backend {
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return checkpointstore.NewPebbleBackend(db, "")
+}
+
+func makeCheckpoint(bn uint64) controller.Checkpoint {
+	return controller.Checkpoint{
+		BlockNumber: bn,
+		BlockTime:   time.Unix(int64(1700000000+bn), 0).UTC(),
+	}
+}
+
+func Test_getCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	svc := &fakeProcessorService{chainStates: make(map[string]*models.ChainState)}
+	tpl := controller.TemplateInstance{TemplateID: 1, TemplateName: "tpl", Address: "0xa"}
+
+	// the processor service keeps the checkpoints
+	store, err := svc.restart(nil).getCheckpointStore(ctx, "1")
+	require.NoError(t, err)
+	_, _, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, []controller.Checkpoint{makeCheckpoint(5)},
+		map[uint64][]controller.TemplateInstance{5: {tpl}}, nil))
+
+	// switched to the backend, continue from the checkpoints in the processor service
+	backend := newPebbleBackend(t)
+	store, err = svc.restart(backend).getCheckpointStore(ctx, "1")
+	require.NoError(t, err)
+	checkpoints, templates, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []controller.Checkpoint{makeCheckpoint(5)}, checkpoints)
+	assert.Equal(t, map[uint64][]controller.TemplateInstance{5: {tpl}}, templates)
+	require.NoError(t, store.Save(ctx, []controller.Checkpoint{makeCheckpoint(5), makeCheckpoint(6)}, templates, nil))
+	assert.Equal(t, int64(6), svc.chainStates["1"].ProcessedBlockNumber)
+
+	// the backend is the source after restart, even if it differs from the processor service
+	_, err = checkpointstore.NewStore(backend, "p1", "1").Rewind(ctx, 5)
+	require.NoError(t, err)
+	store, err = svc.restart(backend).getCheckpointStore(ctx, "1")
+	require.NoError(t, err)
+	checkpoints, _, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []controller.Checkpoint{makeCheckpoint(5)}, checkpoints)
+	h, err := checkpointstore.LoadHistory(ctx, backend, "p1", "1")
+	require.NoError(t, err)
+	assert.Len(t, h, 2)
+}
+
+func Test_restoreEntityBackup(t *testing.T) {
+	ctx := context.Background()
+	sch := persistenttest.MustParseSchema()
+
+	// the backup is created at block 12
+	source := embedded.NewChainStore(embedded.NewStore(sch), "1")
+	_, err := source.SetEntities(ctx, sch.GetEntity("Session"), []persistent.EntityBox{{
+		Entity:         "Session",
+		ID:             "s1",
+		Data:           map[string]any{"id": "s1", "value": "v1"},
+		GenBlockNumber: 10,
+		GenBlockTime:   persistenttest.BlockTime(10),
+	}})
+	require.NoError(t, err)
+	sourceCheckpoints := checkpointstore.NewStore(newPebbleBackend(t), "p0", "1")
+	_, _, err = sourceCheckpoints.Load(ctx)
+	require.NoError(t, err)
+	require.NoError(t, sourceCheckpoints.Save(ctx, []controller.Checkpoint{makeCheckpoint(10), makeCheckpoint(12)},
+		map[uint64][]controller.TemplateInstance{}, nil))
+	objects := parquet.NewLocalObjectStore(t.TempDir())
+	m, err := entitybackup.Backup(ctx, source, sch, sourceCheckpoints, 12, objects)
+	require.NoError(t, err)
+
+	for name, backend := range map[string]checkpointstore.Backend{
+		"processor service": nil,
+		"pebble":            newPebbleBackend(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// the driver has processed to block 20 before the entities are lost
+			svc := &fakeProcessorService{chainStates: make(map[string]*models.ChainState)}
+			store, err := svc.restart(backend).getCheckpointStore(ctx, "1")
+			require.NoError(t, err)
+			_, _, err = store.Load(ctx)
+			require.NoError(t, err)
+			require.NoError(t, store.Save(ctx, []controller.Checkpoint{makeCheckpoint(18), makeCheckpoint(20)},
+				map[uint64][]controller.TemplateInstance{}, nil))
+
+			// restore through the store of the driver
+			store, err = svc.restart(backend).getCheckpointStore(ctx, "1")
+			require.NoError(t, err)
+			target := embedded.NewChainStore(embedded.NewStore(sch), "1")
+			_, err = entitybackup.Restore(ctx, target, sch, store, objects)
+			require.NoError(t, err)
+
+			// the restarted driver resumes from the checkpoint of the backup
+			store, err = svc.restart(backend).getCheckpointStore(ctx, "1")
+			require.NoError(t, err)
+			ctrl, err := controller.NewCheckpointController(ctx, "1", 0, 0, 0, store, nil, nil, nil, nil, nil)
+			require.NoError(t, err)
+			latest := ctrl.GetSavedLatestCheckpoint()
+			require.NotNil(t, latest)
+			assert.Equal(t, m.Checkpoint.BlockNumber, latest.BlockNumber)
+		})
+	}
+}
@@ -104,15 +104,19 @@ func (c entityController) ListEntity(
 	ctx context.Context,
 	entityType *schema.Entity,
 	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
 	cursor string,
 	limit int,
 	blockNumber uint64,
 ) ([]*persistent.EntityBox, *string, *controller.ExternalError) {
-	boxes, next, err := c.Controller.ListEntity(ctx, entityType, filters, cursor, limit, blockNumber)
+	boxes, next, err := c.Controller.ListEntity(ctx, entityType, filters, orders, cursor, limit, blockNumber)
 	if err != nil {
 		if errors.Is(err, persistent.ErrInvalidListFilter) {
 			return boxes, next, controller.NewExternalError(controller.ErrCodeInvalidListEntityFilter, err)
 		}
+		if errors.Is(err, persistent.ErrInvalidListOrder) || errors.Is(err, persistent.ErrInvalidListCursor) {
+			return boxes, next, controller.NewExternalError(controller.ErrCodeInvalidListEntityOrder, err)
+		}
 		if errors.Is(err, persistent.ErrInvalidFieldValue) {
 			return boxes, next, controller.NewExternalError(controller.ErrCodeInvalidEntityFieldValue, err)
 		}
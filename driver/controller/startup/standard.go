@@ -18,12 +18,14 @@ import (
 	"sentioxyz/sentio-core/driver/controller"
 	"sentioxyz/sentio-core/driver/controller/data"
 	aptosdata "sentioxyz/sentio-core/driver/controller/data/aptos"
+	btcdata "sentioxyz/sentio-core/driver/controller/data/btc"
 	evmdata "sentioxyz/sentio-core/driver/controller/data/evm"
 	fueldata "sentioxyz/sentio-core/driver/controller/data/fuel"
 	soldata "sentioxyz/sentio-core/driver/controller/data/sol"
 	suidata "sentioxyz/sentio-core/driver/controller/data/sui"
 	"sentioxyz/sentio-core/driver/controller/standard"
 	"sentioxyz/sentio-core/driver/controller/standard/aptos"
+	"sentioxyz/sentio-core/driver/controller/standard/btc"
 	"sentioxyz/sentio-core/driver/controller/standard/evm"
 	"sentioxyz/sentio-core/driver/controller/standard/fuel"
 	"sentioxyz/sentio-core/driver/controller/standard/sol"
@@ -210,6 +212,11 @@ func (c *standardStartupController) buildMainControllers(ctx context.Context) (
 	return
 }
 
+// backfillSubRanges returns the number of sub-ranges declared by the processor, capped by the driver config
+func (c *standardStartupController) backfillSubRanges() int {
+	return min(int(c.initResult.GetExecutionConfig().GetBackfillSubRanges()), c.config.BackfillSubRanges)
+}
+
 // SuiEnableGRPC grpc-format data is supported only for the sui variation (not iota) at DriverVersion >= 2.
 func SuiEnableGRPC(chainID string, driverVersion int32) bool {
 	return driverVersion >= 2 && suitypes.VariationFromChainID(chains.SuiChainID(chainID)) == suitypes.VariationSUI
@@ -235,23 +242,38 @@ func (c *standardStartupController) buildMainController(
 	var checkLink bool
 	var cli controller.Client
 	var handlerCtrl controller.HandlerController
+	// newBlockBuilder builds the block builders of the sub-ranges in range-split backfill,
+	// nil means the chain type does not support range split
+	var newBlockBuilder func(ctx context.Context) (controller.BlockBuilder, error)
 	switch {
 	case chainID == manifest.CustomizedChainID || chains.IsEVMChains(chainID):
 		checkLink = true
-		evmCli, newClientErr := evmdata.NewClient(
-			ctx,
-			chainConfig.Endpoint,
-			int(controller.ClientMaxConcurrency),
-			chainConfig.StartBlockOverride,
-			chainConfig.ProcessingDelayBlocks,
-			controller.SubscribeMinWatchInterval,
-			time.Second*3,
-		)
+		newEVMClient := func(ctx context.Context) (evmdata.Client, error) {
+			return evmdata.NewClient(
+				ctx,
+				chainConfig.Endpoint,
+				int(controller.ClientMaxConcurrency),
+				chainConfig.StartBlockOverride,
+				chainConfig.ProcessingDelayBlocks,
+				controller.SubscribeMinWatchInterval,
+				time.Second*3,
+			)
+		}
+		evmCli, newClientErr := newEVMClient(ctx)
 		if newClientErr != nil {
 			return nil, exitcode.NeverRetry, errors.Wrapf(newClientErr, "build evm client failed")
 		}
 		handlerCtrl = evm.NewHandlerController(c.processor, c.initResult, chainConfig, evmCli, c.processorClients)
 		cli = evmCli
+		newBlockBuilder = func(ctx context.Context) (controller.BlockBuilder, error) {
+			// each sub-range has its own client, so that their caches will not evict each other
+			subCli, err := newEVMClient(ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "build evm client failed")
+			}
+			subHandlerCtrl := evm.NewHandlerController(c.processor, c.initResult, chainConfig, subCli, c.processorClients)
+			return controller.NewBlockBuilder(subHandlerCtrl, subCli, false), nil
+		}
 	case chains.IsAptosChain(chainID):
 		aptosCli, newClientErr := aptosdata.NewClient(
 			ctx,
@@ -315,6 +337,21 @@ func (c *standardStartupController) buildMainController(
 		}
 		handlerCtrl = sol.NewHandlerController(c.processor, c.initResult, chainConfig, solCli, c.processorClients)
 		cli = solCli
+	case chains.IsChainType(chains.ChainID(chainID), chains.BTCChainType):
+		// the block links are checked to detect the reorgs, bitcoin reorgs are shallow but frequent
+		checkLink = true
+		btcCli, newClientErr := btcdata.NewClient(
+			ctx,
+			chainConfig.Endpoint,
+			int(controller.ClientMaxConcurrency),
+			chainConfig.StartBlockOverride,
+			controller.SubscribeMinWatchInterval,
+		)
+		if newClientErr != nil {
+			return nil, exitcode.NeverRetry, errors.Wrapf(newClientErr, "build btc client failed")
+		}
+		handlerCtrl = btc.NewHandlerController(c.processor, c.initResult, chainConfig, btcCli, c.processorClients)
+		cli = btcCli
 	default:
 		// chainID is OK but the chainType is not supported, so is a system error
 		return nil, exitcode.NeverRetry, errors.Errorf("chain type %s is not supported", chainType)
@@ -368,5 +405,8 @@ func (c *standardStartupController) buildMainController(
 	// main controller
 	seqMode := c.initResult.GetExecutionConfig().GetSequential()
 	ctrl = controller.NewMainController(blockBuilder, checkpointCtrl, seqMode, c.processor, chainID)
+	if subRanges := c.backfillSubRanges(); subRanges > 1 && newBlockBuilder != nil {
+		ctrl.EnableRangeSplit(controller.NewRangeSplitConfig(subRanges, newBlockBuilder))
+	}
 	return ctrl, exitcode.AlwaysRetry, nil
 }
@@ -26,6 +26,7 @@ import (
 	"sentioxyz/sentio-core/common/tracker"
 	"sentioxyz/sentio-core/common/utils"
 	"sentioxyz/sentio-core/driver/controller"
+	"sentioxyz/sentio-core/driver/controller/checkpointstore"
 	"sentioxyz/sentio-core/driver/controller/config"
 	entitychs "sentioxyz/sentio-core/driver/entity/clickhouse"
 	"sentioxyz/sentio-core/driver/entity/persistent"
@@ -54,6 +55,9 @@ type baseStartupController struct {
 	processor    *models.Processor
 	chainConfigs map[string]*config.ChainConfig
 
+	// nil if Config.CheckpointStore is empty
+	checkpointBackend checkpointstore.Backend
+
 	pubSubTopic *pubsub.Topic
 
 	// timeseries / entity clickhouse controllers, built by the injected
@@ -290,6 +294,9 @@ func (c *baseStartupController) newEntityProbe() (entitychs.Probe, error) {
 	}, nil
 }
 
+// the stop blocks of the chains if the processor is running as a shadow, see models.ShadowRun
+var shadowStopAtBlocks = envconf.LoadString(models.ShadowStopAtBlocksEnv, "")
+
 // limits the entity types defined in the GraphQL schema; chain-independent by nature
 var maxEntityTypes = envconf.LoadUInt64("SENTIO_ENTITY_TYPE_LIMIT", 500, envconf.WithMin(1))
 
@@ -321,13 +328,49 @@ func (c *baseStartupController) buildEntityStore(ctx context.Context, schemaText
 	}
 
 	c.entityStore = entitychs.NewStore(ctrl, entityFea, entitySchema, entitychs.DefaultCreateTableOption, probe)
-	if initErr := c.entityStore.InitEntitySchema(ctx); initErr != nil {
+	if initErr := initEntitySchema(ctx, c.entityStore, entityFea, c.processor.EntitySchema, schemaText); initErr != nil {
 		return controller.NewExternalError(controller.ErrCodeInitEntityFailed, initErr)
 	}
 	logger.Infow("entity store is ready", "schema", schemaText, "feature", entityFea)
 	return nil
 }
 
+type entitySchemaInitializer interface {
+	InitEntitySchema(ctx context.Context) error
+	EvolveEntitySchema(ctx context.Context, pre *schema.Schema) (schema.Changes, error)
+}
+
+// initEntitySchema creates or syncs the entity tables for schemaText. preSchemaText is the schema applied by the
+// last run of the processor, which is saved by SetProcessorEntitySchema. If it is known and different, the tables
+// are evolved in place to keep the stored entities, and the incompatible changes fall back to InitEntitySchema.
+func initEntitySchema(
+	ctx context.Context,
+	store entitySchemaInitializer,
+	entityFea entitychs.Features,
+	preSchemaText string,
+	schemaText string,
+) error {
+	_, logger := log.FromContext(ctx)
+	if preSchemaText == "" || preSchemaText == schemaText {
+		return store.InitEntitySchema(ctx)
+	}
+	preSchema, err := schema.ParseAndVerifySchema(preSchemaText, entityFea.BuildVerifyOptions()...)
+	if err != nil {
+		logger.Warnfe(err, "parse previous entity schema failed, will not evolve entity schema")
+		return store.InitEntitySchema(ctx)
+	}
+	changes, err := store.EvolveEntitySchema(ctx, preSchema)
+	if errors.Is(err, schema.ErrIncompatibleChange) {
+		logger.Warnfe(err, "entity schema cannot be evolved in place, will init entity schema")
+		return store.InitEntitySchema(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Infow("entity schema evolved", "changes", len(changes))
+	return nil
+}
+
 func (c *baseStartupController) buildIpfsShell(ctx context.Context) {
 	if c.config.IpfsNodeAddr == "" {
 		return
@@ -423,14 +466,63 @@ func (c *baseStartupController) getCheckpointStore(
 ) (controller.CheckpointStore, error) {
 	cs, has := c.findChainState(chainID)
 	store := newCheckpointStore(c.processor, chainID, c.processorClient, cs)
-	if has {
+	if !has {
+		// no chain state for the chain, create the initial one
+		if err := store.Save(ctx, nil, nil, nil); err != nil {
+			return nil, errors.Wrapf(err, "save initial chain state failed")
+		}
+	}
+	if c.checkpointBackend == nil {
 		return store, nil
 	}
-	// no chain state for the chain, create the initial one
-	if err := store.Save(ctx, nil, nil, nil); err != nil {
-		return nil, errors.Wrapf(err, "save initial chain state failed")
+	return &historyCheckpointStore{
+		history: checkpointstore.NewStore(c.checkpointBackend, c.processor.ID, chainID),
+		service: store,
+	}, nil
+}
+
+// OpenCheckpointStore builds the checkpoint store of the chain the same way the driver does, it is used by the
+// tools which change the checkpoints of a processor while its driver is stopped. The processor is also returned,
+// and the returned function releases the connections.
+func OpenCheckpointStore(
+	ctx context.Context,
+	config Config,
+	chainID string,
+) (controller.CheckpointStore, *models.Processor, func(), error) {
+	base := &baseStartupController{config: config}
+	if err := base.connectToProcessorService(ctx); err != nil {
+		base.releaseAll()
+		return nil, nil, nil, err
 	}
-	return store, nil
+	if err := base.getProcessor(ctx); err != nil {
+		base.releaseAll()
+		return nil, nil, nil, errors.Wrapf(err, "get processor failed")
+	}
+	if err := base.openCheckpointBackend(ctx); err != nil {
+		base.releaseAll()
+		return nil, nil, nil, errors.Wrapf(err, "open checkpoint store failed")
+	}
+	store, err := base.getCheckpointStore(ctx, chainID)
+	if err != nil {
+		base.releaseAll()
+		return nil, nil, nil, err
+	}
+	return store, base.processor, base.releaseAll, nil
+}
+
+func (c *baseStartupController) openCheckpointBackend(ctx context.Context) error {
+	if c.config.CheckpointStore == "" {
+		return nil
+	}
+	backend, closeBackend, err := checkpointstore.OpenBackend(c.config.CheckpointStore)
+	if err != nil {
+		return err
+	}
+	c.release = append(c.release, closeBackend)
+	c.checkpointBackend = backend
+	_, logger := log.FromContext(ctx)
+	logger.Infof("checkpoints are also kept in %T", backend)
+	return nil
 }
 
 func (c *baseStartupController) updateMetaState(ctx context.Context, metaErr error) error {
@@ -513,6 +605,11 @@ func main(ctx context.Context, config Config) (exitCode exitcode.Code, err error
 		return 0, err
 	}
 
+	// 3c. open the checkpoint store backend (optional)
+	if err = base.openCheckpointBackend(ctx); err != nil {
+		return exitcode.AlwaysRetry, errors.Wrapf(err, "open checkpoint store failed")
+	}
+
 	// 4. load chain configs
 	if err = base.loadChainsConfig(ctx); err != nil {
 		return exitcode.AlwaysRetry, errors.Wrapf(err, "load chains config failed")
@@ -535,6 +632,18 @@ func main(ctx context.Context, config Config) (exitCode exitcode.Code, err error
 		return exitcode.NeverRetry, errors.Errorf("project type %s is not supported", base.processor.Project.Type)
 	}
 
+	// a shadow run stops each chain at the block processed by the version it is compared with
+	stopAtBlocks, err := models.ParseStopAtBlocks(shadowStopAtBlocks)
+	if err != nil {
+		return exitcode.NeverRetry, errors.Wrapf(err, "parse %s failed", models.ShadowStopAtBlocksEnv)
+	}
+	for chainID, ctrl := range ctrls {
+		if stopAt, has := stopAtBlocks[chainID]; has {
+			logger.Infof("chain %s will stop at block %d as a shadow run", chainID, stopAt)
+			ctrl.SetStopBlock(stopAt)
+		}
+	}
+
 	// update meta chain state
 	if updateErr := base.updateMetaState(ctx, nil); updateErr != nil {
 		logger.Errorfe(updateErr, "update meta chain state failed")
@@ -544,13 +653,23 @@ func main(ctx context.Context, config Config) (exitCode exitcode.Code, err error
 
 	// start all main controllers
 	g, gctx := errgroup.WithContext(ctx)
-	for chainID_, ctrl_ := range ctrls {
-		chainID, ctrl := chainID_, ctrl_
-		tracker.AddOrReplaceTrackedObject("MainController::"+chainID, ctrl)
+	if config.CrossChainOrdered && len(ctrls) > 1 {
+		// all chains are processed in one stream ordered by block time
+		ctrl := controller.NewCrossChainController(base.processor, ctrls)
+		tracker.AddOrReplaceTrackedObject("CrossChainController", ctrl)
+		logger.Infof("will process %d chains in cross-chain ordered mode", len(ctrls))
 		g.Go(func() error {
-			mainCtx, _ := log.FromContext(gctx, "chain_id", chainID)
-			return ctrl.Main(mainCtx)
+			return ctrl.Main(gctx)
 		})
+	} else {
+		for chainID_, ctrl_ := range ctrls {
+			chainID, ctrl := chainID_, ctrl_
+			tracker.AddOrReplaceTrackedObject("MainController::"+chainID, ctrl)
+			g.Go(func() error {
+				mainCtx, _ := log.FromContext(gctx, "chain_id", chainID)
+				return ctrl.Main(mainCtx)
+			})
+		}
 	}
 	if err = g.Wait(); err != nil {
 		var extErr *controller.ExternalError
@@ -598,6 +717,22 @@ type Config struct {
 	EntityStoreFullIDCacheMaxCount uint64
 	SubgraphTotalMemSize           uint
 	SubgraphDebugTrace             bool
+	// SubgraphHandlerGasLimit is the max number of wasm instructions a subgraph handler can execute,
+	// calling a host function costs wasm.ImportFuncGas. It is the default of the subgraphs which do not
+	// declare execution.handlerGasLimit in the manifest. Zero disables the instruction metering.
+	SubgraphHandlerGasLimit uint64
+	// CrossChainOrdered merges the blocks of all chains of a multi-chain processor by block time and processes
+	// them in one globally ordered stream, instead of processing each chain independently.
+	CrossChainOrdered bool
+	// BackfillSubRanges is the max number of sub-ranges the historical blocks of each chain are split into and
+	// processed parallelly, see controller.RangeSplitConfig. The number is declared by the processor in
+	// ExecutionConfig.backfill_sub_ranges, which means its handlers do not depend on the data of the previous
+	// blocks, and capped by this value. Only EVM chains support it, less than 2 disables it.
+	BackfillSubRanges int
+	// CheckpointStore keeps the checkpoints with all the history in a checkpointstore backend besides the processor
+	// service, it is "pebble:<directory>" or a PostgreSQL DSN, see checkpointstore.OpenBackend. Empty keeps them in
+	// the processor service only.
+	CheckpointStore string
 	// PubSubProject is the GCP project used to create the webhook pubsub topic;
 	// empty disables pubsub topic creation. Provided by the driver binary.
 	PubSubProject string
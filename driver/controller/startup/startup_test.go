@@ -1,9 +1,15 @@
 package startup
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"sentioxyz/sentio-core/common/log"
+	entitychs "sentioxyz/sentio-core/driver/entity/clickhouse"
+	"sentioxyz/sentio-core/driver/entity/schema"
+	"sentioxyz/sentio-core/driver/subgraph/manifest"
+	"sentioxyz/sentio-core/processor/protos"
 	sentioerror "sentioxyz/sentio-core/service/common/errors"
 	"sentioxyz/sentio-core/service/processor/models"
 
@@ -41,3 +47,101 @@ func Test_buildProcessorUrls(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"aaa.bbb:9999", "aaa.bbb:10000", "aaa.bbb:10001"}, urls)
 }
+
+type testEntitySchemaStore struct {
+	evolveErr error
+	calls     []string
+	pre       *schema.Schema
+}
+
+func (s *testEntitySchemaStore) InitEntitySchema(context.Context) error {
+	s.calls = append(s.calls, "init")
+	return nil
+}
+
+func (s *testEntitySchemaStore) EvolveEntitySchema(_ context.Context, pre *schema.Schema) (schema.Changes, error) {
+	s.calls = append(s.calls, "evolve")
+	s.pre = pre
+	return nil, s.evolveErr
+}
+
+func Test_initEntitySchema(t *testing.T) {
+	const preSchema = `
+type EntityA @entity {
+	id: ID!
+	propA: Int!
+}
+`
+	const curSchema = `
+type EntityA @entity {
+	id: ID!
+	propA: Int!
+	propB: String
+}
+`
+	ctx := context.Background()
+	fea := entitychs.BuildFeatures(0)
+
+	// no previous schema
+	store := &testEntitySchemaStore{}
+	assert.NoError(t, initEntitySchema(ctx, store, fea, "", curSchema))
+	assert.Equal(t, []string{"init"}, store.calls)
+
+	// schema not changed
+	store = &testEntitySchemaStore{}
+	assert.NoError(t, initEntitySchema(ctx, store, fea, curSchema, curSchema))
+	assert.Equal(t, []string{"init"}, store.calls)
+
+	// schema changed, evolve from the previous one
+	store = &testEntitySchemaStore{}
+	assert.NoError(t, initEntitySchema(ctx, store, fea, preSchema, curSchema))
+	assert.Equal(t, []string{"evolve"}, store.calls)
+	if assert.NotNil(t, store.pre) {
+		assert.NotNil(t, store.pre.GetEntity("EntityA"))
+	}
+
+	// incompatible change, fall back to init
+	store = &testEntitySchemaStore{evolveErr: fmt.Errorf("%w: narrowed", schema.ErrIncompatibleChange)}
+	assert.NoError(t, initEntitySchema(ctx, store, fea, preSchema, curSchema))
+	assert.Equal(t, []string{"evolve", "init"}, store.calls)
+
+	// other errors are returned
+	store = &testEntitySchemaStore{evolveErr: fmt.Errorf("connection refused")}
+	assert.Error(t, initEntitySchema(ctx, store, fea, preSchema, curSchema))
+	assert.Equal(t, []string{"evolve"}, store.calls)
+
+	// invalid previous schema, fall back to init
+	store = &testEntitySchemaStore{}
+	assert.NoError(t, initEntitySchema(ctx, store, fea, "type {", curSchema))
+	assert.Equal(t, []string{"init"}, store.calls)
+}
+
+func Test_backfillSubRanges(t *testing.T) {
+	var s standardStartupController
+
+	// not declared by the processor
+	s.config.BackfillSubRanges = 8
+	assert.Equal(t, 0, s.backfillSubRanges())
+
+	s.initResult = &protos.InitResponse{ExecutionConfig: &protos.ExecutionConfig{BackfillSubRanges: 4}}
+	assert.Equal(t, 4, s.backfillSubRanges())
+
+	// capped by the driver
+	s.config.BackfillSubRanges = 2
+	assert.Equal(t, 2, s.backfillSubRanges())
+	s.config.BackfillSubRanges = 0
+	assert.Equal(t, 0, s.backfillSubRanges())
+}
+
+func Test_handlerGasLimit(t *testing.T) {
+	var s subgraphStartupController
+	s.config.SubgraphHandlerGasLimit = 100
+
+	// not declared by the subgraph
+	assert.Equal(t, uint64(100), s.handlerGasLimit(&manifest.Manifest{}))
+
+	mf := &manifest.Manifest{Execution: &manifest.ExecutionConfig{HandlerGasLimit: 1000}}
+	assert.Equal(t, uint64(1000), s.handlerGasLimit(mf))
+	s.config.SubgraphHandlerGasLimit = 0
+	assert.Equal(t, uint64(1000), s.handlerGasLimit(mf))
+}
@@ -88,6 +88,7 @@ func (c *subgraphStartupController) buildMainControllers(ctx context.Context) (
 		mf,
 		uint32(c.config.SubgraphTotalMemSize),
 		c.config.SubgraphDebugTrace,
+		c.handlerGasLimit(mf),
 	)
 	if err != nil {
 		return nil, exitcode.NeverRetry, controller.NewExternalError(controller.ErrCodeWasmInitFailed, err)
@@ -131,3 +132,11 @@ func (c *subgraphStartupController) buildMainControllers(ctx context.Context) (
 	ctrls[chainID] = controller.NewMainController(blockBuilder, checkpointCtrl, false, c.processor, chainID)
 	return ctrls, exitcode.AlwaysRetry, nil
 }
+
+// handlerGasLimit returns the handler gas limit declared in the manifest, the driver config is the default
+func (c *subgraphStartupController) handlerGasLimit(mf *manifest.Manifest) uint64 {
+	if limit := mf.GetHandlerGasLimit(); limit > 0 {
+		return limit
+	}
+	return c.config.SubgraphHandlerGasLimit
+}
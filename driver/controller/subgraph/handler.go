@@ -44,6 +44,7 @@ type HandlerController struct {
 	manifest     *manifest.Manifest
 	memHardLimit uint32
 	debugTrace   bool
+	gasLimit     uint64
 
 	instance *instance // TODO support multi instances
 
@@ -64,6 +65,7 @@ func NewHandlerController(
 	manifest *manifest.Manifest,
 	memHardLimit uint32,
 	debugTrace bool,
+	gasLimit uint64,
 ) (ctrl *HandlerController, err error) {
 	ctrl = &HandlerController{
 		processor:    processor,
@@ -73,6 +75,7 @@ func NewHandlerController(
 		manifest:     manifest,
 		memHardLimit: memHardLimit,
 		debugTrace:   debugTrace,
+		gasLimit:     gasLimit,
 	}
 	ctrl.instance, err = ctrl.newInstance(ctx)
 	return ctrl, err
@@ -489,6 +492,7 @@ func (c *HandlerController) Snapshot() any {
 		"manifest":     c.manifest,
 		"memHardLimit": c.memHardLimit,
 		"debugTrace":   c.debugTrace,
+		"gasLimit":     c.gasLimit,
 		"agents": utils.MapSliceNoError(c.agents, func(a HandlerAgent) any {
 			return a.Snapshot()
 		}),
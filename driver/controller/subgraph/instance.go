@@ -38,7 +38,7 @@ func (c *HandlerController) newInstance(ctx context.Context) (*instance, error)
 				fmt.Sprintf("%s/%s", name, hash),
 				[]byte(ds.Mapping.File.GetContent()),
 				c.memHardLimit,
-			)
+			).SetGasLimit(c.gasLimit).EnableInitSnapshot()
 			inst.importFunctions(m)
 			if c.debugTrace {
 				m.SetDebugLevel(wasm.DebugLevelTrace)
@@ -754,7 +754,9 @@ func (inst *instance) CallHandler(
 
 	// process call error
 	var errCallingImportFunc *wasm.ErrCallingImportFunc
-	if err != nil && errors.As(err, &errCallingImportFunc) {
+	if errors.Is(err, wasm.ErrOutOfGas) {
+		extErr = controller.NewExternalError(controller.ErrCodeWasmOutOfGas, err)
+	} else if err != nil && errors.As(err, &errCallingImportFunc) {
 		if !errors.As(errCallingImportFunc.Err, &extErr) {
 			extErr = controller.NewExternalError(controller.ErrCodeCallWasmExportFunctionFailed, errCallingImportFunc.Err)
 		}
@@ -775,7 +777,7 @@ func (inst *instance) CallHandler(
 
 	// report metrics
 	controller.N.SubgraphTaskDone(ctx, tk.taskInfoForCall(), err == nil,
-		report.TimeUsed, report.ImportFuncCallUsed, report.MemoryUsed)
+		report.TimeUsed, report.ImportFuncCallUsed, report.MemoryUsed, report.GasUsed)
 
 	return extErr
 }
@@ -3,7 +3,6 @@ package clickhouse
 import (
 	"context"
 	"math"
-	"sort"
 	"time"
 
 	lru "github.com/sentioxyz/golang-lru"
@@ -183,22 +182,23 @@ func (c *ChainStore) GetEntity(
 	}
 }
 
-// ListEntities returns entities matching the filters, possibly from cache.
+// ListEntities returns entities matching the filters after the order key in the orders, possibly from cache.
 // fromCache is true when all results came entirely from in-memory cache.
 func (c *ChainStore) ListEntities(
 	ctx context.Context,
 	entityType *schema.Entity,
 	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
+	after []any,
 	limit int,
 ) (boxes []*persistent.EntityBox, fromCache bool, err error) {
+	orders = persistent.CompleteEntityOrders(entityType, orders)
 	if entityType.IsCache() {
 		cache, has := c.cacheEntity[entityType.GetName()]
 		if !has {
 			return nil, true, nil
 		}
-		keys := cache.Keys()
-		sort.Strings(keys)
-		for _, key := range keys {
+		for _, key := range cache.Keys() {
 			box, _ := cache.Get(key)
 			var pass bool
 			if pass, err = persistent.CheckFilters(filters, *box); err != nil {
@@ -206,11 +206,8 @@ func (c *ChainStore) ListEntities(
 			} else if pass {
 				boxes = append(boxes, box)
 			}
-			if len(boxes) >= limit {
-				break
-			}
 		}
-		return boxes, true, nil
+		return persistent.PageEntityBoxes(orders, boxes, after, limit), true, nil
 	}
 
 	// Attempt to serve from the full-data cache.
@@ -219,7 +216,7 @@ func (c *ChainStore) ListEntities(
 		return
 	} else if !has {
 		// No full cache — query the DB.
-		rows, listErr := c.store.listEntities(ctx, entityType, c.chain, filters, true, limit)
+		rows, listErr := c.store.listEntities(ctx, entityType, c.chain, filters, orders, after, true, limit)
 		if listErr != nil {
 			err = listErr
 			return
@@ -230,29 +227,21 @@ func (c *ChainStore) ListEntities(
 		return
 	}
 	// Serve from full cache.
-	cacheSlice := make([]string, 0, len(c.fullCache[entityType.Name]))
 	for _, cached := range c.fullCache[entityType.Name] {
 		if cached.Data == nil {
 			continue
 		}
-		cacheSlice = append(cacheSlice, cached.ID)
-	}
-	sort.Strings(cacheSlice)
-	for _, id := range cacheSlice {
-		if len(boxes) >= limit {
-			break
-		}
-		box := c.fullCache[entityType.Name][id]
-		if box.Data == nil {
-			continue
-		}
 		var pass bool
-		if pass, err = persistent.CheckFilters(filters, box.EntityBox); err != nil {
+		if pass, err = persistent.CheckFilters(filters, cached.EntityBox); err != nil {
 			return nil, false, err
 		} else if pass {
-			boxes = append(boxes, box.Copy())
+			boxes = append(boxes, &cached.EntityBox)
 		}
 	}
+	boxes = persistent.PageEntityBoxes(orders, boxes, after, limit)
+	for i, box := range boxes {
+		boxes[i] = box.Copy()
+	}
 	return
 }
 
@@ -261,6 +250,17 @@ func (c *ChainStore) GetTimeSeriesEntityMaxID(ctx context.Context, entityType *s
 	return c.store.getMaxID(ctx, entityType, c.chain)
 }
 
+// ScanEntities reads the entities of the chain from the store directly, the local cache is not used because
+// it only has the latest versions.
+func (c *ChainStore) ScanEntities(
+	ctx context.Context,
+	entityType *schema.Entity,
+	maxBlockNumber uint64,
+	fn func(box persistent.EntityBox) error,
+) error {
+	return c.store.ScanLatestEntities(ctx, entityType, c.chain, maxBlockNumber, fn)
+}
+
 // SetEntities writes entities to persistent storage and updates the local cache.
 func (c *ChainStore) SetEntities(
 	ctx context.Context,
@@ -525,7 +525,7 @@ func (c *ChainStore) tryLoadFullCache(
 		return false, false, knownCount, nil
 	}
 	logger.Debugf("will really load all %d entities from persistent for full cache", count)
-	rows, listErr := c.store.listEntities(ctx, entityType, c.chain, nil, excludeDeleted, math.MaxInt)
+	rows, listErr := c.store.listEntities(ctx, entityType, c.chain, nil, nil, nil, excludeDeleted, math.MaxInt)
 	logger = logger.With("used", time.Since(start).String())
 	if listErr != nil {
 		err = listErr
@@ -0,0 +1,89 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/common/format"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/persistent/persistenttest"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+var _ persistenttest.AggregationReader = (*ChainStore)(nil)
+
+// ListAggregationRows returns the aggregated rows of the interval in the chain, ordered by timestamp and id,
+// so the conformance suite can check the rows written by GrowthAggregation
+func (c *ChainStore) ListAggregationRows(
+	ctx context.Context,
+	agg *schema.Aggregation,
+	itv string,
+) ([]*persistent.EntityBox, error) {
+	kit := c.store.NewEntity(agg)
+	sql := format.Format("SELECT %fields#s "+
+		"FROM %table#s "+
+		"WHERE %gbc#s = ? AND %itv#s = ? "+
+		"ORDER BY %ts#s, %pk#s",
+		map[string]any{
+			"fields": joinWithQuote(kit.fieldNamesForGet(), ","),
+			"table":  c.store.fullName(c.store.TableName(agg)),
+			"gbc":    quote(genBlockChainFieldName),
+			"itv":    quote(aggIntervalFieldName),
+			"ts":     quote(schema.EntityTimestampFieldName),
+			"pk":     quote(schema.EntityPrimaryFieldName),
+		})
+	var boxes []*persistent.EntityBox
+	err := c.store.ctrl.Query(SelectCtx(ctx), func(rows driver.Rows) error {
+		row, scanErr := kit.scanOne(rows)
+		if scanErr != nil || row.Data == nil {
+			return scanErr
+		}
+		row.Entity = agg.Name
+		boxes = append(boxes, &row.EntityBox)
+		return nil
+	}, sql, c.chain, itv)
+	return boxes, err
+}
+
+// Test_conformance runs the conformance suite shared with the embedded store
+func Test_conformance(t *testing.T) {
+	if skip {
+		t.Skip("use local db, will only be executed manually locally")
+	}
+
+	ctx := context.Background()
+	conn := ckhmanager.NewConn(localClickhouseDSN)
+	var seq int
+	persistenttest.Run(t, func(t *testing.T) persistent.ChainStore {
+		seq++
+		prefix := fmt.Sprintf("entitytest_%d_%d_", time.Now().UnixNano(), seq)
+		ctrl := chx.New(conn, chx.WithTableNamePrefix(prefix), chx.WithLogicTableNamePrefix(prefix))
+		s := NewStore(
+			ctrl,
+			Features{
+				VersionedCollapsing:    true,
+				TimestampUseDateTime64: true,
+				BigDecimalUseString:    true,
+			},
+			persistenttest.MustParseSchema(),
+			TableOption{
+				BatchInsertSizeLimit: 2,
+				HugeIDSetSize:        3,
+				TableSettings:        DefaultCreateTableOption.TableSettings,
+			},
+			nil,
+		)
+		require.NoError(t, s.InitEntitySchema(ctx))
+		t.Cleanup(func() {
+			_ = ctrl.DropAll(context.Background())
+		})
+		return NewChainStore(s, "1", 100, 1<<20, 1000)
+	})
+}
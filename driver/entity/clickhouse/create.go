@@ -98,6 +98,17 @@ func (s *Store) loadExists(ctx context.Context, categories []string) (map[string
 	return tvs, nil
 }
 
+var allCategories = []string{
+	"versionedEntity",
+	"versionedLatestEntity",
+	"versionedLatestEntityMV",
+	"entity",
+	"interface",
+	"aggregation",
+	"view",
+	"latestView",
+}
+
 func (s *Store) syncTablesAndViews(ctx context.Context, viewOnly bool) (err error) {
 	startAt := time.Now()
 	_, logger := log.FromContext(ctx, "viewOnly", viewOnly)
@@ -112,16 +123,7 @@ func (s *Store) syncTablesAndViews(ctx context.Context, viewOnly bool) (err erro
 	}()
 
 	// load exists
-	var categories = []string{
-		"versionedEntity",
-		"versionedLatestEntity",
-		"versionedLatestEntityMV",
-		"entity",
-		"interface",
-		"aggregation",
-		"view",
-		"latestView",
-	}
+	var categories = allCategories
 	if viewOnly {
 		categories = []string{"view", "latestView"}
 	}
@@ -164,6 +166,51 @@ func (s *Store) InitEntitySchema(ctx context.Context) error {
 	return s.syncTablesAndViews(ctx, false)
 }
 
+// EvolveEntitySchema upgrades the tables and views created by the schema pre to the current schema in place
+// with ALTER statements, so the stored entities are kept. If any change cannot be applied in place,
+// nothing will be changed and the returned error is schema.ErrIncompatibleChange.
+func (s *Store) EvolveEntitySchema(ctx context.Context, pre *schema.Schema) (schema.Changes, error) {
+	_, logger := log.FromContext(ctx)
+	changes := schema.Diff(pre, s.sch)
+	if err := changes.Check(); err != nil {
+		logger.Warnfe(err, "entity schema cannot be evolved in place")
+		return changes, err
+	}
+	// the compatible schema changes may still be unable to apply to the table because of the features,
+	// for example, widening Int to BigInt when BigInt use Tuple(Bool,Int8,UInt256)
+	exists, err := s.loadExists(ctx, allCategories)
+	if err != nil {
+		return changes, err
+	}
+	expects := s.buildTablesAndViews(false)
+	for _, name := range utils.GetOrderedMapKeys(expects) {
+		for _, tv := range expects[name] {
+			cur, is := tv.(chx.Table)
+			if !is {
+				continue
+			}
+			pre, has := utils.GetFromK2Map(exists, name, cur.Name)
+			if !has {
+				continue
+			}
+			preTable, is := pre.(chx.Table)
+			if !is {
+				err = fmt.Errorf("%w: %s is a %s, not a table", schema.ErrIncompatibleChange, cur.Name, pre.GetKind())
+			} else if err = chx.CheckSyncTable(preTable, cur); err != nil {
+				err = fmt.Errorf("%w: %v", schema.ErrIncompatibleChange, err)
+			}
+			if err != nil {
+				logger.Warnfe(err, "entity schema cannot be evolved in place")
+				return changes, err
+			}
+		}
+	}
+	for _, change := range changes {
+		logger.Infof("will apply entity schema change: %s", change)
+	}
+	return changes, s.syncTablesAndViews(ctx, false)
+}
+
 func (s *Store) CreateViews(ctx context.Context) error {
 	return s.syncTablesAndViews(ctx, true)
 }
@@ -1,14 +1,19 @@
 package clickhouse
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
 	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/persistent"
 	"sentioxyz/sentio-core/driver/entity/schema"
 )
 
@@ -4356,3 +4361,119 @@ type EntityB @entity(immutable: false) {
 	}
 	assert.Equal(t, expected, sqlMap)
 }
+
+const evolvePreSchemaCnt = `
+type EntityA @entity {
+	id: ID!
+	propA: Int!
+	propB: String @index
+}
+`
+
+const evolveCurSchemaCnt = `
+type EntityA @entity {
+	id: ID!
+	propA: BigInt!
+	propB: String @index
+	propC: Int8 @index
+}
+
+type EntityB @entity {
+	id: ID!
+}
+`
+
+func Test_evolveTables(t *testing.T) {
+	pre, err := schema.ParseAndVerifySchema(evolvePreSchemaCnt)
+	assert.NoError(t, err)
+	cur, err := schema.ParseAndVerifySchema(evolveCurSchemaCnt)
+	assert.NoError(t, err)
+	assert.NoError(t, schema.Diff(pre, cur).Check())
+
+	ctrl := chx.New(nil, chx.WithTableNamePrefix("processor0_"), chx.WithLogicTableNamePrefix("processor0_"))
+	checkTables := func(feaOpt Features) error {
+		preStore := NewStore(ctrl, feaOpt, pre, DefaultCreateTableOption, nil)
+		curStore := NewStore(ctrl, feaOpt, cur, DefaultCreateTableOption, nil)
+		preTables := preStore.buildTablesAndViews(false)
+		for name, tvs := range curStore.buildTablesAndViews(false) {
+			for _, tv := range tvs {
+				curTable, is := tv.(chx.Table)
+				if !is {
+					continue
+				}
+				for _, ptv := range preTables[name] {
+					if preTable, is := ptv.(chx.Table); is && preTable.Name == curTable.Name {
+						if err := chx.CheckSyncTable(preTable, curTable); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		return nil
+	}
+	// BigInt use Tuple(Bool,Int8,UInt256), cannot be modified from Int32
+	assert.Error(t, checkTables(Features{}))
+	assert.Error(t, checkTables(Features{VersionedCollapsing: true}))
+	// BigInt use Int256
+	assert.NoError(t, checkTables(Features{BigIntUseInt256: true}))
+	assert.NoError(t, checkTables(Features{BigIntUseInt256: true, VersionedCollapsing: true}))
+}
+
+func Test_EvolveEntitySchema(t *testing.T) {
+	if skip {
+		t.Skip("use local db, will only be executed manually locally")
+	}
+
+	ctx := context.Background()
+	pre, err := schema.ParseAndVerifySchema(evolvePreSchemaCnt)
+	assert.NoError(t, err)
+	cur, err := schema.ParseAndVerifySchema(evolveCurSchemaCnt)
+	assert.NoError(t, err)
+
+	prefix := fmt.Sprintf("entitytest_%d_", time.Now().UnixNano())
+	ctrl := chx.New(ckhmanager.NewConn(localClickhouseDSN),
+		chx.WithTableNamePrefix(prefix), chx.WithLogicTableNamePrefix(prefix))
+	defer func() {
+		_ = ctrl.DropAll(context.Background())
+	}()
+	feaOpt := Features{VersionedCollapsing: true, TimestampUseDateTime64: true, BigIntUseInt256: true}
+
+	preStore := NewStore(ctrl, feaOpt, pre, DefaultCreateTableOption, nil)
+	assert.NoError(t, preStore.InitEntitySchema(ctx))
+	_, err = NewChainStore(preStore, "1", 100, 1<<20, 1000).SetEntities(ctx, preStore.GetEntityType("EntityA"),
+		[]persistent.EntityBox{{
+			Entity:         "EntityA",
+			ID:             "a1",
+			Data:           map[string]any{"id": "a1", "propA": int32(1), "propB": utils.WrapPointer("b1")},
+			GenBlockNumber: 10,
+			GenBlockTime:   time.UnixMilli(10000),
+			GenBlockHash:   "0x0a",
+		}})
+	assert.NoError(t, err)
+
+	// incompatible change
+	narrowed, err := schema.ParseAndVerifySchema(`
+type EntityA @entity {
+	id: ID!
+	propA: Int!
+	propB: String! @index
+}
+`)
+	assert.NoError(t, err)
+	_, err = NewStore(ctrl, feaOpt, narrowed, DefaultCreateTableOption, nil).EvolveEntitySchema(ctx, pre)
+	assert.True(t, errors.Is(err, schema.ErrIncompatibleChange))
+
+	// compatible change, existing entities are kept
+	curStore := NewStore(ctrl, feaOpt, cur, DefaultCreateTableOption, nil)
+	changes, err := curStore.EvolveEntitySchema(ctx, pre)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	box, _, err := NewChainStore(curStore, "1", 100, 1<<20, 1000).GetEntity(ctx, curStore.GetEntityType("EntityA"), "a1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, box) {
+		assert.Equal(t, "a1", box.ID)
+		assert.Equal(t, "1", fmt.Sprintf("%v", box.Data["propA"]))
+		assert.Nil(t, box.Data["propC"])
+	}
+}
@@ -238,7 +238,7 @@ func (s *Store) setEntities(
 					Field: entityType.GetPrimaryKeyField(),
 					Op:    persistent.EntityFilterOpIn,
 					Value: utils.ToAnyArray(utils.GetOrderedMapKeys(newIds)),
-				}}, false, math.MaxInt)
+				}}, nil, nil, false, math.MaxInt)
 				if queryErr != nil {
 					batchLogger.Errorfe(queryErr, "list pre-values for set entities failed")
 					return errors.Wrapf(queryErr, "set %s entities in chain %s failed: list pre-values failed",
@@ -301,11 +301,49 @@ func splitFilters(filters []persistent.EntityFilter) (primaryKeyFilters, otherFi
 	return
 }
 
+// buildOrderBy builds the expressions of ORDER BY, column returns the expression of the order field
+func buildOrderBy(entity Entity, orders []persistent.EntityOrder, column func(field Field) string) string {
+	terms := make([]string, len(orders))
+	for i, o := range orders {
+		terms[i] = column(entity.GetFieldByName(o.Field.Name)) + utils.Select(o.Desc, " DESC", "")
+	}
+	return strings.Join(terms, ", ")
+}
+
+// buildAfterCondition builds the condition of keyset pagination to select the rows after the order key,
+// for orders (a, b DESC, id) the condition is (a > ? OR (a = ? AND (b < ? OR (b = ? AND id > ?)))).
+// Order fields are all non-null, so no null condition is needed.
+func buildAfterCondition(
+	entity Entity,
+	orders []persistent.EntityOrder,
+	after []any,
+	conditionPrefix string,
+) (condition string, params []any) {
+	if after == nil {
+		return "", nil
+	}
+	for i := len(orders) - 1; i >= 0; i-- {
+		field := entity.GetFieldByName(orders[i].Field.Name)
+		column := quote(field.FieldMainName())
+		param, slot := field.FieldValuesForWhereArg(after[i])
+		cmp := fmt.Sprintf("%s %s %s", column, utils.Select(orders[i].Desc, "<", ">"), slot)
+		if condition == "" {
+			condition, params = cmp, param
+			continue
+		}
+		condition = fmt.Sprintf("(%s OR (%s = %s AND %s))", cmp, column, slot, condition)
+		params = append(append(append([]any{}, param...), param...), params...)
+	}
+	return conditionPrefix + condition, params
+}
+
 func (s *Store) listEntities(
 	ctx context.Context,
 	entityType *schema.Entity,
 	chain string,
 	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
+	after []any,
 	excludeDeleted bool,
 	limit int,
 ) ([]*entityRow, error) {
@@ -316,7 +354,7 @@ func (s *Store) listEntities(
 	// and will got a 'Table xxx does not exist' error. In this case, we should retry.
 	for retry := maxRetry; ; retry-- {
 		thisCtx, _ := log.FromContext(ctx, "retry", retry)
-		result, err := s._listEntities(thisCtx, entityType, chain, filters, excludeDeleted, limit)
+		result, err := s._listEntities(thisCtx, entityType, chain, filters, orders, after, excludeDeleted, limit)
 		if err == nil {
 			return result, nil
 		}
@@ -336,6 +374,8 @@ func (s *Store) _listEntities(
 	entityType *schema.Entity,
 	chain string,
 	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
+	after []any,
 	excludeDeleted bool,
 	limit int,
 ) (result []*entityRow, err error) {
@@ -345,6 +385,8 @@ func (s *Store) _listEntities(
 
 	start := time.Now()
 	kit := s.NewEntity(entityType)
+	orders = persistent.CompleteEntityOrders(entityType, orders)
+	afterConditions, afterParams := buildAfterCondition(kit, orders, after, "AND ")
 	var sql string
 	var sqlArgs []any
 	if s.useVersionedCollapsingTable(entityType) {
@@ -361,10 +403,10 @@ func (s *Store) _listEntities(
 		//     any_respect_nulls(__genBlockNumber__) as __any___genBlockNumber__,
 		//     any_respect_nulls(propA) as __any_propA
 		//   FROM versionedEntity
-		//   WHERE __genBlockChain__ = ? AND NOT __deleted__ AND propA > ?
+		//   WHERE __genBlockChain__ = ? AND NOT __deleted__ AND propA > ? AND (propB > ? OR (propB = ? AND id > ?))
 		//   GROUP BY __genBlockChain__, id, __version__
 		//   HAVING SUM(__sign__) > 0
-		//   ORDER BY id
+		//   ORDER BY __any_propB, id
 		//   LIMIT ?
 		// )
 		selects := utils.FilterArr(kit.fieldNamesForGet(), func(fn string) bool {
@@ -389,10 +431,10 @@ func (s *Store) _listEntities(
 			"FROM ("+
 			"SELECT %gbc#s, %pk#s, %version#s, %innerSelects#s "+
 			"FROM %table#s "+
-			"WHERE %gbc#s = ? %edc#s %otc#s "+
+			"WHERE %gbc#s = ? %edc#s %otc#s %afc#s "+
 			"GROUP BY %gbc#s, %pk#s, %version#s "+
 			"HAVING SUM(%sign#s) > 0 "+
-			"ORDER BY %pk#s "+
+			"ORDER BY %order#s "+
 			"LIMIT ?"+
 			")",
 			map[string]any{
@@ -405,15 +447,24 @@ func (s *Store) _listEntities(
 				"table":        s.fullName(s.VersionedTableName(entityType)),
 				"edc":          excludeDeletedConditions,
 				"otc":          filterConditions,
+				"afc":          afterConditions,
+				"order": buildOrderBy(kit, orders, func(field Field) string {
+					if field.Name() == schema.EntityPrimaryFieldName {
+						return quote(field.FieldMainName())
+					}
+					// non-primary fields are aggregated in the inner select
+					return "__any_" + field.FieldMainName()
+				}),
 			})
 		sqlArgs = []any{chain}
 		sqlArgs = append(sqlArgs, params...)
+		sqlArgs = append(sqlArgs, afterParams...)
 		sqlArgs = append(sqlArgs, limit)
 	} else if entityType.IsImmutable() {
 		// SELECT id, propA
 		// FROM entity
-		// WHERE __genBlockChain__ = ? AND NOT __deleted__ AND propA > ?
-		// ORDER BY id
+		// WHERE __genBlockChain__ = ? AND NOT __deleted__ AND propA > ? AND (propB > ? OR (propB = ? AND id > ?))
+		// ORDER BY propB, id
 		// LIMIT ?
 		filterConditions, params, epilogue, err := s.buildConditions(ctx, kit, filters, "AND ")
 		defer epilogue()
@@ -424,15 +475,19 @@ func (s *Store) _listEntities(
 		if excludeDeleted {
 			excludeDeletedConditions = fmt.Sprintf(" AND NOT %s", quote(deletedFieldName))
 		}
-		sql = fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? %s %s ORDER BY %s LIMIT ?",
+		sql = fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? %s %s %s ORDER BY %s LIMIT ?",
 			joinWithQuote(kit.fieldNamesForGet(), ","),
 			s.fullName(s.TableName(entityType)),
 			quote(genBlockChainFieldName),
 			excludeDeletedConditions,
 			filterConditions,
-			quote(schema.EntityPrimaryFieldName))
+			afterConditions,
+			buildOrderBy(kit, orders, func(field Field) string {
+				return quote(field.FieldMainName())
+			}))
 		sqlArgs = []any{chain}
 		sqlArgs = append(sqlArgs, params...)
+		sqlArgs = append(sqlArgs, afterParams...)
 		sqlArgs = append(sqlArgs, limit)
 	} else {
 		// SELECT id, __last__.1 AS __genBlockNumber__, __last__.2 AS __deleted__, __last__.3 AS propA
@@ -442,8 +497,8 @@ func (s *Store) _listEntities(
 		//   WHERE __genBlockChain__ = ? AND id NOT IN ?
 		//   GROUP by id
 		// )
-		// WHERE NOT __last__.2 AND propA > ?
-		// ORDER BY id
+		// WHERE NOT __last__.2 AND propA > ? AND (propB > ? OR (propB = ? AND id > ?))
+		// ORDER BY propB, id
 		// LIMIT ?
 		lastFields := utils.Prepend(utils.FilterArr(kit.fieldNamesForGet(), func(fn string) bool {
 			return fn != schema.EntityPrimaryFieldName &&
@@ -477,8 +532,8 @@ func (s *Store) _listEntities(
 			"  WHERE %gbc#s = ? %pkc#s"+
 			"  GROUP BY %pk#s, %gbc#s"+
 			") "+
-			"WHERE %edc#s %otc#s "+
-			"ORDER BY %pk#s "+
+			"WHERE %edc#s %otc#s %afc#s "+
+			"ORDER BY %order#s "+
 			"LIMIT ?",
 			map[string]any{
 				"pk":     quote(schema.EntityPrimaryFieldName),
@@ -488,12 +543,17 @@ func (s *Store) _listEntities(
 				"pkc":    primaryKeyConditions,
 				"edc":    excludeDeletedConditions,
 				"otc":    otherConditions,
+				"afc":    afterConditions,
 				"last":   joinWithQuote(lastFields, ","),
 				"lastAs": strings.Join(lastAs, ","),
+				"order": buildOrderBy(kit, orders, func(field Field) string {
+					return quote(field.FieldMainName())
+				}),
 			})
 		sqlArgs = []any{chain}
 		sqlArgs = append(sqlArgs, primaryKeyParams...)
 		sqlArgs = append(sqlArgs, otherParams...)
+		sqlArgs = append(sqlArgs, afterParams...)
 		sqlArgs = append(sqlArgs, limit)
 	}
 	// execute query and get the response
@@ -511,6 +571,7 @@ func (s *Store) _listEntities(
 		"entity", entityType.Name,
 		"chain", chain,
 		"excludeDeleted", excludeDeleted,
+		"orders", persistent.EntityOrdersString(orders),
 		"limit", limit,
 		"sql", sql,
 		"sqlArgs", sqlArgs,
@@ -0,0 +1,44 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+func Test_buildOrder(t *testing.T) {
+	sch, err := schema.ParseAndVerifySchema(`
+type EntityX @entity {
+	id: ID!
+	propA: String! @index
+	propB: Int! @index
+}
+`)
+	assert.NoError(t, err)
+	etype := sch.GetEntity("EntityX")
+	kit := newDefaultStore().NewEntity(etype)
+	column := func(field Field) string {
+		return field.FieldMainName()
+	}
+
+	orders := persistent.CompleteEntityOrders(etype, nil)
+	assert.Equal(t, "id", buildOrderBy(kit, orders, column))
+	cond, params := buildAfterCondition(kit, orders, nil, "AND ")
+	assert.Equal(t, "", cond)
+	assert.Nil(t, params)
+	cond, params = buildAfterCondition(kit, orders, []any{"x1"}, "AND ")
+	assert.Equal(t, "AND `id` > ?", cond)
+	assert.Equal(t, []any{"x1"}, params)
+
+	orders = persistent.CompleteEntityOrders(etype, []persistent.EntityOrder{
+		{Field: etype.GetFieldByName("propA")},
+		{Field: etype.GetFieldByName("propB"), Desc: true},
+	})
+	assert.Equal(t, "propA, propB DESC, id", buildOrderBy(kit, orders, column))
+	cond, params = buildAfterCondition(kit, orders, []any{"a", int32(3), "x1"}, "")
+	assert.Equal(t, "(`propA` > ? OR (`propA` = ? AND (`propB` < ? OR (`propB` = ? AND `id` > ?))))", cond)
+	assert.Equal(t, []any{"a", "a", int32(3), int32(3), "x1"}, params)
+}
@@ -0,0 +1,80 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"sentioxyz/sentio-core/common/format"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+// ScanLatestEntities calls fn with the latest version of each entity of the chain generated not after maxBlockNumber,
+// ordered by the ID as a string. Deleted entities are skipped. It is used to compare the outputs of two processors,
+// the rows are streamed so the entity count is not limited by the memory.
+func (s *Store) ScanLatestEntities(
+	ctx context.Context,
+	entityType *schema.Entity,
+	chain string,
+	maxBlockNumber uint64,
+	fn func(box persistent.EntityBox) error,
+) error {
+	if entityType.IsCache() {
+		return nil
+	}
+	kit := s.NewEntity(entityType)
+	var signCondition string
+	tableName := s.TableName(entityType)
+	if s.useVersionedCollapsingTable(entityType) {
+		signCondition = fmt.Sprintf("AND %s > 0", quote(signFieldName))
+		tableName = s.VersionedTableName(entityType)
+	}
+	// SELECT id, propA, __genBlockNumber__, ...
+	// FROM entity
+	// WHERE __genBlockChain__ = ? AND __genBlockNumber__ <= ? [AND __sign__ > 0]
+	// ORDER BY toString(id), __genBlockNumber__ DESC
+	// LIMIT 1 BY id
+	sql := format.Format("SELECT %fields#s "+
+		"FROM %table#s "+
+		"WHERE %gbc#s = ? AND %gbn#s <= ? %sc#s "+
+		"ORDER BY toString(%pk#s), %gbn#s DESC "+
+		"LIMIT 1 BY %pk#s",
+		map[string]any{
+			"fields": joinWithQuote(kit.fieldNamesForGet(), ","),
+			"table":  s.fullName(tableName),
+			"gbc":    quote(genBlockChainFieldName),
+			"gbn":    quote(genBlockNumberFieldName),
+			"sc":     signCondition,
+			"pk":     quote(schema.EntityPrimaryFieldName),
+		})
+	start := time.Now()
+	var count int
+	err := s.ctrl.Query(SelectCtx(ctx), func(rows driver.Rows) error {
+		row, scanErr := kit.scanOne(rows)
+		if scanErr != nil {
+			return scanErr
+		}
+		if row.Data == nil {
+			return nil
+		}
+		count++
+		row.Entity = entityType.Name
+		return fn(row.EntityBox)
+	}, sql, chain, maxBlockNumber)
+	_, logger := log.FromContext(ctx,
+		"entity", entityType.Name,
+		"chain", chain,
+		"maxBlockNumber", maxBlockNumber,
+		"count", count,
+		"used", time.Since(start).String())
+	if err != nil {
+		logger.Errore(err, "scan latest entities failed")
+		return err
+	}
+	logger.Debug("scan latest entities succeed")
+	return nil
+}
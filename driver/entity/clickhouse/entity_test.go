@@ -1925,7 +1925,7 @@ func storeListEntities(
 	filters []persistent.EntityFilter,
 	limit int,
 ) ([]*persistent.EntityBox, error) {
-	rows, err := s.listEntities(ctx, entityType, chain, filters, true, limit)
+	rows, err := s.listEntities(ctx, entityType, chain, filters, nil, nil, true, limit)
 	if err != nil || rows == nil {
 		return nil, err
 	}
@@ -0,0 +1,307 @@
+package embedded
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"github.com/shopspring/decimal"
+
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+	"sentioxyz/sentio-core/driver/entity/schema/exp"
+	"sentioxyz/sentio-core/driver/entity/schema/interval"
+)
+
+// windowStart is the start of the time window containing t, same as toStartOfInterval of clickhouse in UTC
+func windowStart(itv interval.Interval, t time.Time) time.Time {
+	t = t.UTC()
+	switch itv.Text {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "week":
+		// weeks start from monday
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return itv.TimeBucket(t)
+	}
+}
+
+func timestampOf(box *persistent.EntityBox) (int64, error) {
+	v, is := box.Data[schema.EntityTimestampFieldName].(int64)
+	if !is {
+		return 0, fmt.Errorf("entity %s has no valid %s", box.ID, schema.EntityTimestampFieldName)
+	}
+	return v, nil
+}
+
+func sortAggregationRows(boxes []*persistent.EntityBox) {
+	sort.SliceStable(boxes, func(i, j int) bool {
+		ti, _ := timestampOf(boxes[i])
+		tj, _ := timestampOf(boxes[j])
+		return ti < tj
+	})
+}
+
+// toDecimal converts the field value to decimal, null is false
+func toDecimal(val any) (decimal.Decimal, bool, error) {
+	if utils.IsNil(val) {
+		return decimal.Zero, false, nil
+	}
+	if _, is := val.(*big.Int); !is {
+		if rv := reflect.ValueOf(val); rv.Kind() == reflect.Pointer {
+			val = rv.Elem().Interface()
+		}
+	}
+	switch v := val.(type) {
+	case int32:
+		return decimal.NewFromInt32(v), true, nil
+	case int64:
+		return decimal.NewFromInt(v), true, nil
+	case int:
+		return decimal.NewFromInt(int64(v)), true, nil
+	case float64:
+		return decimal.NewFromFloat(v), true, nil
+	case *big.Int:
+		return decimal.NewFromBigInt(v, 0), true, nil
+	case decimal.Decimal:
+		return v, true, nil
+	case bool:
+		return utils.Select(v, decimal.NewFromInt(1), decimal.Zero), true, nil
+	case string:
+		d, err := decimal.NewFromString(v)
+		return d, err == nil, err
+	default:
+		return decimal.Zero, false, fmt.Errorf("cannot convert %T to number", val)
+	}
+}
+
+func truth(b bool) decimal.Decimal {
+	return utils.Select(b, decimal.NewFromInt(1), decimal.Zero)
+}
+
+// evalExp evaluates the aggregate expression with the data of the source entity,
+// the result is null if any variable used by the expression is null
+func evalExp(e *exp.Exp, data map[string]any) (decimal.Decimal, bool, error) {
+	if e.Value != nil {
+		if val, has := data[e.Value.Cnt]; has {
+			return toDecimal(val)
+		}
+		d, err := decimal.NewFromString(e.Value.Cnt)
+		if err != nil {
+			return d, false, e.Value.BuildError("invalid variable")
+		}
+		return d, true, nil
+	}
+	args := make([]decimal.Decimal, len(e.Arguments))
+	for i, arg := range e.Arguments {
+		v, valid, err := evalExp(arg, data)
+		if err != nil || !valid {
+			return decimal.Zero, false, err
+		}
+		args[i] = v
+	}
+	switch op := strings.ToLower(e.Operator.Cnt); op {
+	case "+":
+		return args[0].Add(args[1]), true, nil
+	case "-":
+		return args[0].Sub(args[1]), true, nil
+	case "*":
+		return args[0].Mul(args[1]), true, nil
+	case "/":
+		if args[1].IsZero() {
+			return decimal.Zero, false, e.Operator.BuildError("division by zero")
+		}
+		return args[0].Div(args[1]), true, nil
+	case "and":
+		return truth(!args[0].IsZero() && !args[1].IsZero()), true, nil
+	case "or":
+		return truth(!args[0].IsZero() || !args[1].IsZero()), true, nil
+	case "not":
+		return truth(args[0].IsZero()), true, nil
+	case "greatest", "max":
+		return decimal.Max(args[0], args[1:]...), true, nil
+	case "least", "min":
+		return decimal.Min(args[0], args[1:]...), true, nil
+	default:
+		return decimal.Zero, false, e.Operator.BuildError("unsupported operator")
+	}
+}
+
+// fieldValue converts the aggregated value to the type of the aggregate field
+func fieldValue(field *types.FieldDefinition, d decimal.Decimal) (any, error) {
+	typ := field.Type
+	if nonNull, is := typ.(*types.NonNull); is {
+		typ = nonNull.OfType
+	}
+	scalar, is := typ.(*types.ScalarTypeDefinition)
+	if !is {
+		return nil, fmt.Errorf("invalid type %s of aggregate field %s", field.Type, field.Name)
+	}
+	switch scalar.Name {
+	case "Int":
+		return int32(d.IntPart()), nil
+	case "Int8":
+		return d.IntPart(), nil
+	case "Float":
+		return d.InexactFloat64(), nil
+	case "BigInt":
+		return d.BigInt(), nil
+	case "BigDecimal":
+		return d, nil
+	default:
+		return nil, fmt.Errorf("invalid type %s of aggregate field %s", field.Type, field.Name)
+	}
+}
+
+type aggGroup struct {
+	window time.Time
+	rows   []*persistent.EntityBox
+}
+
+// aggregate appends the rows aggregated from the source rows in the new windows to the aggregation table,
+// the windows must be after the latest aggregated one and ended before the window of curBlockTime
+func aggregate(
+	agg *schema.Aggregation,
+	itv interval.Interval,
+	src *table,
+	dst *table,
+	curBlockTime time.Time,
+) (int, error) {
+	var aggregated int64
+	for _, id := range dst.sortedIDs() {
+		ts, err := timestampOf(dst.latest(id))
+		if err != nil {
+			return 0, err
+		}
+		aggregated = max(aggregated, ts)
+	}
+	curWindow := windowStart(itv, curBlockTime).UnixMicro()
+
+	var dimFields []string // dim fields without id and timestamp field
+	for _, f := range agg.DimFields {
+		if f.Name != schema.EntityPrimaryFieldName && f.Name != schema.EntityTimestampFieldName {
+			dimFields = append(dimFields, f.Name)
+		}
+	}
+	groups := make(map[string]*aggGroup)
+	var keys []string
+	for _, id := range src.sortedIDs() {
+		box := src.latest(id)
+		ts, err := timestampOf(box)
+		if err != nil {
+			return 0, err
+		}
+		window := windowStart(itv, time.UnixMicro(ts))
+		if window.UnixMicro() <= aggregated || ts >= curWindow {
+			continue
+		}
+		dims := make([]any, len(dimFields))
+		for i, name := range dimFields {
+			dims[i] = box.Data[name]
+		}
+		key := strconv.FormatInt(window.UnixMicro(), 10) + utils.MustJSONMarshal(dims)
+		group, has := groups[key]
+		if !has {
+			group = &aggGroup{window: window}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.rows = append(group.rows, box)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		first := group.rows[0]
+		row := persistent.EntityBox{
+			Entity: agg.Name,
+			Data: map[string]any{
+				schema.EntityTimestampFieldName: group.window.UnixMicro(),
+			},
+		}
+		var maxID int64
+		for _, box := range group.rows {
+			id, err := strconv.ParseInt(box.ID, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid id %q of source entity: %w", box.ID, err)
+			}
+			maxID = max(maxID, id)
+			row.GenBlockNumber = max(row.GenBlockNumber, box.GenBlockNumber)
+			if box.GenBlockTime.After(row.GenBlockTime) {
+				row.GenBlockTime = box.GenBlockTime
+			}
+		}
+		row.ID = strconv.FormatInt(maxID, 10)
+		row.Data[schema.EntityPrimaryFieldName] = maxID
+		for _, name := range dimFields {
+			row.Data[name] = first.Data[name]
+		}
+		for _, f := range agg.AggFields {
+			result, err := aggregateField(f, group.rows)
+			if err != nil {
+				return 0, fmt.Errorf("aggregate field %s failed: %w", f.Name, err)
+			}
+			if row.Data[f.Name], err = fieldValue(f.FieldDefinition, result); err != nil {
+				return 0, err
+			}
+		}
+		dst.add(row)
+	}
+	return len(keys), nil
+}
+
+// aggregateField calculates the aggregate function of the field, null values are ignored
+// and the result is zero if there is no value, same as clickhouse inserting into a non-null column
+func aggregateField(f *schema.AggregationAggField, rows []*persistent.EntityBox) (decimal.Decimal, error) {
+	fn := f.GetAggFunc()
+	if fn == "count" {
+		return decimal.NewFromInt(int64(len(rows))), nil
+	}
+	var (
+		result   decimal.Decimal
+		resultBN uint64
+		hasValue bool
+	)
+	for _, box := range rows {
+		v, valid, err := evalExp(f.GetAggExp(), box.Data)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if !valid {
+			continue
+		}
+		switch fn {
+		case "sum":
+			result = result.Add(v)
+		case "min":
+			result = utils.Select(hasValue && result.LessThan(v), result, v)
+		case "max":
+			result = utils.Select(hasValue && result.GreaterThan(v), result, v)
+		case "first":
+			if !hasValue || box.GenBlockNumber < resultBN {
+				result, resultBN = v, box.GenBlockNumber
+			}
+		case "last":
+			if !hasValue || box.GenBlockNumber >= resultBN {
+				result, resultBN = v, box.GenBlockNumber
+			}
+		default:
+			return decimal.Zero, fmt.Errorf("unknown agg fn %q", fn)
+		}
+		hasValue = true
+	}
+	return result, nil
+}
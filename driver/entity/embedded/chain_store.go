@@ -0,0 +1,259 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/types"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+var _ persistent.ChainStore = (*ChainStore)(nil)
+
+// ChainStore is the in-memory persistent.ChainStore bound to a single chain.
+// Different ChainStores created from the same Store can be used concurrently.
+type ChainStore struct {
+	store *Store
+	chain string
+}
+
+func NewChainStore(store *Store, chain string) *ChainStore {
+	return &ChainStore{store: store, chain: chain}
+}
+
+func (c *ChainStore) GetChain() string { return c.chain }
+
+func (c *ChainStore) GetEntityType(entity string) *schema.Entity {
+	return c.store.GetEntityType(entity)
+}
+
+func (c *ChainStore) GetEntityOrInterfaceType(name string) schema.EntityOrInterface {
+	return c.store.GetEntityOrInterfaceType(name)
+}
+
+func (c *ChainStore) entityTable(entityType *schema.Entity) *table {
+	return c.store.table(c.chain, entityType.Name, entityType.IsTimeSeries())
+}
+
+// GetEntity returns the latest version of the entity, all data is in memory so fromCache is always true
+func (c *ChainStore) GetEntity(
+	ctx context.Context,
+	entityType *schema.Entity,
+	id string,
+) (*persistent.EntityBox, bool, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return c.entityTable(entityType).latest(id), true, nil
+}
+
+// ListEntities returns at most limit entities passed all the filters and after the order key
+func (c *ChainStore) ListEntities(
+	ctx context.Context,
+	entityType *schema.Entity,
+	filters []persistent.EntityFilter,
+	orders []persistent.EntityOrder,
+	after []any,
+	limit int,
+) (boxes []*persistent.EntityBox, fromCache bool, err error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	t := c.entityTable(entityType)
+	for _, id := range t.sortedIDs() {
+		box := t.latest(id)
+		var pass bool
+		if pass, err = persistent.CheckFilters(filters, *box); err != nil {
+			return nil, false, err
+		} else if pass {
+			boxes = append(boxes, box)
+		}
+	}
+	orders = persistent.CompleteEntityOrders(entityType, orders)
+	return persistent.PageEntityBoxes(orders, boxes, after, limit), true, nil
+}
+
+// GetTimeSeriesEntityMaxID returns the maximum id of all the versions, including the deleted ones
+func (c *ChainStore) GetTimeSeriesEntityMaxID(ctx context.Context, entityType *schema.Entity) (int64, error) {
+	if !entityType.IsTimeSeries() {
+		return 0, fmt.Errorf("%q is not timeseries entity", entityType.Name)
+	}
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	var maxID int64
+	for id := range c.entityTable(entityType).rows {
+		v, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid id %q of timeseries entity %q: %w", id, entityType.Name, err)
+		}
+		maxID = max(maxID, v)
+	}
+	return maxID, nil
+}
+
+// ScanEntities calls fn with the version of each entity as of maxBlockNumber in the order of the ids,
+// the boxes are copied before the lock is released so fn can access the store
+func (c *ChainStore) ScanEntities(
+	ctx context.Context,
+	entityType *schema.Entity,
+	maxBlockNumber uint64,
+	fn func(box persistent.EntityBox) error,
+) error {
+	if entityType.IsCache() {
+		return nil
+	}
+	c.store.mu.Lock()
+	t := c.entityTable(entityType)
+	var boxes []persistent.EntityBox
+	for _, id := range t.allSortedIDs() {
+		if box := t.at(id, maxBlockNumber); box != nil {
+			boxes = append(boxes, *box)
+		}
+	}
+	c.store.mu.Unlock()
+	for _, box := range boxes {
+		if err := fn(box); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetEntities appends the boxes as the new versions of the entities and returns the number of
+// created entities. Nothing is written if any box updates an existing immutable entity.
+func (c *ChainStore) SetEntities(
+	ctx context.Context,
+	entityType *schema.Entity,
+	boxes []persistent.EntityBox,
+) (created int, err error) {
+	_, logger := log.FromContext(ctx, "entity", entityType.Name, "chainID", c.chain, "count", len(boxes))
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	t := c.entityTable(entityType)
+	if entityType.IsCache() {
+		// cache entities only keep the latest version, same as the cache of the other stores
+		for _, box := range boxes {
+			t.replace(box)
+		}
+		return 0, nil
+	}
+	ids := make(map[string]bool)
+	for _, box := range boxes {
+		ids[box.ID] = true
+	}
+	if !entityType.IsTimeSeries() {
+		// ids of the timeseries entities are always new, no need to check
+		var exists []string
+		for id := range ids {
+			if t.exists(id) {
+				exists = append(exists, id)
+			}
+		}
+		if entityType.IsImmutable() && len(exists) > 0 {
+			summary := fmt.Sprintf("with id %s", utils.ArrSummary(exists))
+			logger.Errorf("set immutable entities %s", summary)
+			return 0, fmt.Errorf("set %s entities in chain %s %s: %w",
+				entityType.Name, c.chain, summary, persistent.ErrUpdateImmutable)
+		}
+		created -= len(exists)
+	}
+	created += len(ids)
+	for _, box := range boxes {
+		t.add(box)
+	}
+	logger.Debugw("set entities succeed", "created", created)
+	return created, nil
+}
+
+// GrowthAggregation aggregates the rows of the source entities in the time windows
+// which ended before the window of curBlockTime and are not aggregated yet.
+func (c *ChainStore) GrowthAggregation(ctx context.Context, curBlockTime time.Time) error {
+	_, logger := log.FromContext(ctx, "chain", c.chain, "curBlockTime", curBlockTime.String())
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	for _, agg := range c.store.sch.ListAggregations() {
+		srcType := c.store.sch.GetEntity(agg.GetSource())
+		if srcType == nil {
+			return fmt.Errorf("source entity %q of aggregation %q not found", agg.GetSource(), agg.Name)
+		}
+		src := c.entityTable(srcType)
+		for _, itv := range agg.GetIntervals() {
+			rows, err := aggregate(agg, itv, src, c.aggregationTable(agg.Name, itv.Text), curBlockTime)
+			if err != nil {
+				logger.With("agg", agg.Name, "interval", itv.String()).Errore(err, "growth aggregation failed")
+				return fmt.Errorf("growth aggregation %q with interval %s failed: %w", agg.Name, itv, err)
+			}
+			logger.Debugw("growth aggregation succeeded", "agg", agg.Name, "interval", itv.String(), "rows", rows)
+		}
+	}
+	return nil
+}
+
+func (c *ChainStore) aggregationTable(agg, itv string) *table {
+	return c.store.table(c.chain, agg+"/"+itv, true)
+}
+
+// ListAggregationRows returns the aggregated rows of the interval, ordered by timestamp and id
+func (c *ChainStore) ListAggregationRows(
+	ctx context.Context,
+	agg *schema.Aggregation,
+	itv string,
+) ([]*persistent.EntityBox, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	t := c.aggregationTable(agg.Name, itv)
+	var boxes []*persistent.EntityBox
+	for _, id := range t.sortedIDs() {
+		boxes = append(boxes, t.latest(id))
+	}
+	sortAggregationRows(boxes)
+	return boxes, nil
+}
+
+// Reorg drops all versions of the entities and the aggregations generated after blockNumber
+func (c *ChainStore) Reorg(ctx context.Context, blockNumber int64) error {
+	_, logger := log.FromContext(ctx, "chain", c.chain, "blockNumber", blockNumber)
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	for name, t := range c.store.tables[c.chain] {
+		if dropped := t.reorg(blockNumber); dropped > 0 {
+			logger.Infof("dropped %d versions of %s", dropped, name)
+		}
+	}
+	return nil
+}
+
+// CheckValue checks the fields exist and the non-null fields have value, values of any size can be stored
+func (c *ChainStore) CheckValue(entityType *schema.Entity, data map[string]any) error {
+	for fieldName, val := range data {
+		field := entityType.GetFieldByName(fieldName)
+		if field == nil {
+			return fmt.Errorf("%s.%s is not exist", entityType.Name, fieldName)
+		}
+		if _, nonNull := field.Type.(*types.NonNull); nonNull && utils.IsNil(val) {
+			return fmt.Errorf("%s.%s cannot be null", entityType.Name, fieldName)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the number of rows and versions of every entity and aggregation interval
+func (c *ChainStore) Snapshot() any {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	tables := make(map[string]any)
+	for name, t := range c.store.tables[c.chain] {
+		tables[name] = map[string]any{
+			"rows":     len(t.sortedIDs()),
+			"versions": t.versions(),
+		}
+	}
+	return map[string]any{
+		"chain":  c.chain,
+		"tables": tables,
+	}
+}
@@ -0,0 +1,104 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/persistent/persistenttest"
+	"sentioxyz/sentio-core/driver/entity/schema/exp"
+	"sentioxyz/sentio-core/driver/entity/schema/interval"
+)
+
+func Test_conformance(t *testing.T) {
+	persistenttest.Run(t, func(t *testing.T) persistent.ChainStore {
+		return NewChainStore(NewStore(persistenttest.MustParseSchema()), "1")
+	})
+}
+
+func Test_chainIsolation(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(persistenttest.MustParseSchema())
+	cs1, cs2 := NewChainStore(store, "1"), NewChainStore(store, "2")
+	entityType := store.GetEntityType("Transfer")
+	box := persistent.EntityBox{
+		Entity:         "Transfer",
+		ID:             "t1",
+		Data:           map[string]any{"id": "t1", "from": "0x01", "amount": int32(1)},
+		GenBlockNumber: 10,
+	}
+	created, err := cs1.SetEntities(ctx, entityType, []persistent.EntityBox{box})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	created, err = cs2.SetEntities(ctx, entityType, []persistent.EntityBox{box})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	require.NoError(t, cs1.Reorg(ctx, 9))
+	got, _, err := cs1.GetEntity(ctx, entityType, "t1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	got, _, err = cs2.GetEntity(ctx, entityType, "t1")
+	require.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func Test_windowStart(t *testing.T) {
+	ts := time.Date(2024, 3, 14, 15, 9, 26, 0, time.UTC) // thursday
+	testcases := map[string]time.Time{
+		"hour":  time.Date(2024, 3, 14, 15, 0, 0, 0, time.UTC),
+		"day":   time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC),
+		"week":  time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		"month": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"year":  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for text, expected := range testcases {
+		itv, err := interval.Parse(text)
+		require.NoError(t, err)
+		assert.Equal(t, expected, windowStart(itv, ts), text)
+	}
+	week, _ := interval.Parse("week")
+	monday := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, windowStart(week, monday))
+	assert.Equal(t, monday, windowStart(week, time.Date(2024, 3, 17, 23, 0, 0, 0, time.UTC)))
+}
+
+func Test_evalExp(t *testing.T) {
+	data := map[string]any{
+		"a": int32(3),
+		"b": decimal.RequireFromString("1.5"),
+		"c": (*int64)(nil),
+	}
+	testcases := []struct {
+		exp      string
+		expected string
+		valid    bool
+	}{
+		{"a", "3", true},
+		{"(a+b)/2", "2.25", true},
+		{"a*b-1", "3.5", true},
+		{"max(a, b, 4)", "4", true},
+		{"least(a, b)", "1.5", true},
+		{"a and not b", "0", true},
+		{"a or c", "0", false},
+		{"c + 1", "0", false},
+	}
+	for _, tc := range testcases {
+		e, err := exp.NewExp(tc.exp)
+		require.NoError(t, err)
+		v, valid, err := evalExp(e, data)
+		require.NoError(t, err, tc.exp)
+		assert.Equal(t, tc.valid, valid, tc.exp)
+		assert.Equal(t, tc.expected, v.String(), tc.exp)
+	}
+
+	e, err := exp.NewExp("a / (b - 1.5)")
+	require.NoError(t, err)
+	_, _, err = evalExp(e, data)
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package embedded
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+// Store keeps the entities of all chains in process memory, it is used to run
+// the entity processing locally and in tests without a database.
+// Every write is kept as a new version of the entity, so Reorg can roll back to
+// the versions generated at or before the reorg block.
+type Store struct {
+	mu  sync.Mutex
+	sch *schema.Schema
+
+	// chain => table name => rows
+	tables map[string]map[string]*table
+}
+
+func NewStore(sch *schema.Schema) *Store {
+	return &Store{
+		sch:    sch,
+		tables: make(map[string]map[string]*table),
+	}
+}
+
+func (s *Store) GetEntityType(entity string) *schema.Entity {
+	return s.sch.GetEntity(entity)
+}
+
+func (s *Store) GetEntityOrInterfaceType(name string) schema.EntityOrInterface {
+	return s.sch.GetEntityOrInterface(name)
+}
+
+// table returns the rows of the entity or the aggregation interval in the chain, creates it if not exists
+func (s *Store) table(chain, name string, numericID bool) *table {
+	chainTables, has := s.tables[chain]
+	if !has {
+		chainTables = make(map[string]*table)
+		s.tables[chain] = chainTables
+	}
+	t, has := chainTables[name]
+	if !has {
+		t = &table{numericID: numericID, rows: make(map[string][]persistent.EntityBox)}
+		chainTables[name] = t
+	}
+	return t
+}
+
+// table holds all versions of the rows, versions of the same id are ordered by ascending GenBlockNumber,
+// the version with nil Data means the row is deleted
+type table struct {
+	// ids of the timeseries entities and the aggregations are Int8, ordered by number
+	numericID bool
+	rows      map[string][]persistent.EntityBox
+}
+
+func (t *table) latest(id string) *persistent.EntityBox {
+	versions := t.rows[id]
+	if len(versions) == 0 || versions[len(versions)-1].Data == nil {
+		return nil
+	}
+	return versions[len(versions)-1].Copy()
+}
+
+func (t *table) exists(id string) bool {
+	versions := t.rows[id]
+	return len(versions) > 0 && versions[len(versions)-1].Data != nil
+}
+
+func (t *table) add(box persistent.EntityBox) {
+	t.rows[box.ID] = append(t.rows[box.ID], *box.Copy())
+}
+
+// replace drops the history, only keeps the box as the latest version
+func (t *table) replace(box persistent.EntityBox) {
+	t.rows[box.ID] = []persistent.EntityBox{*box.Copy()}
+}
+
+// at returns the latest version generated not after blockNumber, nil if not exists or deleted
+func (t *table) at(id string, blockNumber uint64) *persistent.EntityBox {
+	versions := t.rows[id]
+	n := sort.Search(len(versions), func(i int) bool {
+		return versions[i].GenBlockNumber > blockNumber
+	})
+	if n == 0 || versions[n-1].Data == nil {
+		return nil
+	}
+	return versions[n-1].Copy()
+}
+
+// sortedIDs returns the ids of the rows which are not deleted
+func (t *table) sortedIDs() []string {
+	ids := make([]string, 0, len(t.rows))
+	for id := range t.rows {
+		if t.exists(id) {
+			ids = append(ids, id)
+		}
+	}
+	return t.sort(ids)
+}
+
+// allSortedIDs returns the ids of all the rows including the deleted ones
+func (t *table) allSortedIDs() []string {
+	ids := make([]string, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	return t.sort(ids)
+}
+
+func (t *table) sort(ids []string) []string {
+	if !t.numericID {
+		sort.Strings(ids)
+		return ids
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.ParseInt(ids[i], 10, 64)
+		b, _ := strconv.ParseInt(ids[j], 10, 64)
+		return a < b
+	})
+	return ids
+}
+
+// reorg drops the versions generated after blockNumber, returns the number of dropped versions
+func (t *table) reorg(blockNumber int64) (dropped int) {
+	for id, versions := range t.rows {
+		n := sort.Search(len(versions), func(i int) bool {
+			return int64(versions[i].GenBlockNumber) > blockNumber
+		})
+		dropped += len(versions) - n
+		if n == 0 {
+			delete(t.rows, id)
+		} else {
+			t.rows[id] = versions[:n]
+		}
+	}
+	return dropped
+}
+
+func (t *table) versions() (count int) {
+	for _, versions := range t.rows {
+		count += len(versions)
+	}
+	return count
+}
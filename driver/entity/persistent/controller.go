@@ -39,17 +39,32 @@ type ChainStore interface {
 	// Will got nil if entity not exists or deleted before.
 	GetEntity(ctx context.Context, entityType *schema.Entity, id string) (*EntityBox, bool, error)
 
-	// ListEntities returns entities matching the given filters.
+	// ListEntities returns at most limit entities matching the given filters in the orders,
+	// only the ones strictly after the order key after are returned, nil after means from the first.
+	// orders have been checked by CheckEntityOrders and completed by CompleteEntityOrders,
+	// nil orders means order by id.
 	// fromCache is true when the results came entirely from in-memory cache.
 	ListEntities(
 		ctx context.Context,
 		entityType *schema.Entity,
 		filters []EntityFilter,
+		orders []EntityOrder,
+		after []any,
 		limit int,
 	) ([]*EntityBox, bool, error)
 
 	GetTimeSeriesEntityMaxID(ctx context.Context, entityType *schema.Entity) (int64, error)
 
+	// ScanEntities calls fn with the latest version of each entity generated not after maxBlockNumber,
+	// deleted entities and cache entities are skipped. It is used to dump the entities as of a checkpoint,
+	// so the rows are streamed instead of being cached.
+	ScanEntities(
+		ctx context.Context,
+		entityType *schema.Entity,
+		maxBlockNumber uint64,
+		fn func(box EntityBox) error,
+	) error
+
 	// SetEntities writes a batch of entity boxes to persistent storage.
 	//
 	// Ordering contract: within boxes, entries that share the same ID are
@@ -268,20 +283,11 @@ func (c *Controller) getEntity(
 	return
 }
 
-func splitListCursor(cursor string) (persistent bool, id string) {
-	if cursor == "" {
-		return false, ""
-	}
-	return cursor[0] == '@', cursor[1:]
-}
-
-func buildListCursor(persistent bool, id string) string {
-	return utils.Select(persistent, "@", "#") + id
-}
-
 var (
 	ErrInvalidField      = errors.New("invalid field")
 	ErrInvalidListFilter = errors.New("invalid list filter")
+	ErrInvalidListOrder  = errors.New("invalid list order")
+	ErrInvalidListCursor = errors.New("invalid list cursor")
 	ErrUpdateImmutable   = errors.New("update immutable")
 	ErrInvalidFieldValue = errors.New("invalid field value")
 )
@@ -326,6 +332,7 @@ func (c *Controller) ListRelated(
 			ctx,
 			targetEntityType,
 			[]EntityFilter{filter},
+			nil,
 			"",
 			math.MaxInt,
 			true,
@@ -338,32 +345,37 @@ func (c *Controller) ListRelated(
 	return
 }
 
-// ListEntity returns entities matching the given filters.
-// May return ErrInvalidFieldValue or ErrInvalidListFilter.
+// ListEntity returns entities matching the given filters in the orders, the uncommitted changes are
+// merged with the persistent entities. The last term of the orders is always id, it will be appended
+// if not exists. cursor is empty for the first page, otherwise it should be the next cursor returned
+// by the previous page with the same orders, next is nil if there is no more entities.
+// Without orders, the uncommitted entities come first and then the persistent ones, both ordered by id.
+// May return ErrInvalidFieldValue, ErrInvalidListFilter, ErrInvalidListOrder or ErrInvalidListCursor.
 func (c *Controller) ListEntity(
 	ctx context.Context,
 	entityType *schema.Entity,
 	filters []EntityFilter,
+	orders []EntityOrder,
 	cursor string,
 	limit int,
 	blockNumber uint64,
 ) (boxes []*EntityBox, next *string, err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.listEntity(ctx, entityType, filters, cursor, limit, false, blockNumber)
+	return c.listEntity(ctx, entityType, filters, orders, cursor, limit, false, blockNumber)
 }
 
 func (c *Controller) listEntity(
 	ctx context.Context,
 	entityType *schema.Entity,
 	filters []EntityFilter,
+	orders []EntityOrder,
 	cursor string,
 	limit int,
 	loadRelated bool,
 	blockNumber uint64,
 ) (boxes []*EntityBox, next *string, err error) {
 	var persistentPart []*EntityBox
-	var persistentPartFromCache bool
 	var from = "uncommitted"
 
 	start := time.Now()
@@ -388,6 +400,7 @@ func (c *Controller) listEntity(
 			"loadRelated", loadRelated,
 			"entityType", entityType.GetName(),
 			"filters", EntityFiltersString(filters),
+			"orders", EntityOrdersString(orders),
 			"cursor", cursor,
 			"limit", limit,
 			"next", next,
@@ -397,21 +410,41 @@ func (c *Controller) listEntity(
 			"used", used)
 	}()
 
-	if limit == 0 {
-		return nil, nil, nil
+	if err = CheckEntityOrders(entityType, orders); err != nil {
+		return nil, nil, err
+	}
+	if len(orders) == 0 {
+		boxes, next, persistentPart, from, err = c.listEntityInDefaultOrder(
+			ctx, entityType, filters, cursor, limit, blockNumber)
+	} else {
+		boxes, next, persistentPart, from, err = c.listEntityInOrders(
+			ctx, entityType, filters, orders, cursor, limit, blockNumber)
 	}
+	if err != nil {
+		logger.With("used", time.Since(start).String()).Errore(err, "list entity failed")
+		return nil, nil, err
+	}
+	return
+}
 
-	// get uncommitted part result
-	cp, cid := splitListCursor(cursor)
-	checked := make(map[string]bool)
+// listUncommitted returns the latest uncommitted entities matching the filters, the ones skipped by skip
+// are excluded from the result, checked contains the ids of all the uncommitted entities including deleted ones.
+func (c *Controller) listUncommitted(
+	ctx context.Context,
+	entityType *schema.Entity,
+	filters []EntityFilter,
+	blockNumber uint64,
+	skip func(id string) bool,
+) (boxes []*EntityBox, checked map[string]bool, err error) {
+	checked = make(map[string]bool)
 	for _, change := range c.changes[entityType.Name] {
 		uctBox := change.Latest(blockNumber)
 		if uctBox == nil {
 			continue
 		}
 		checked[uctBox.ID] = true
-		if cp || uctBox.ID <= cid {
-			continue // before the cursor
+		if skip != nil && skip(uctBox.ID) {
+			continue
 		}
 		if uctBox.Data == nil {
 			continue // deleted
@@ -420,18 +453,53 @@ func (c *Controller) listEntity(
 			// calculate operators
 			// uctBox will be changed, uctBox.Operator will be set to nil and uctBox.Data will be filled
 			if _, err = c.executeEntityOperator(ctx, entityType, uctBox.ID, blockNumber); err != nil {
-				logger.Errorfe(err, "execute operator failed")
-				return
+				return nil, nil, fmt.Errorf("execute operator of %s failed: %w", uctBox.ID, err)
 			}
 		}
 		if pass, cke := CheckFilters(filters, uctBox.EntityBox); cke != nil {
-			logger.With("used", time.Since(start).String()).Errore(err, "check filters failed")
 			return nil, nil, cke
 		} else if !pass {
 			continue // not match the filter
 		}
 		boxes = append(boxes, &uctBox.EntityBox)
 	}
+	return boxes, checked, nil
+}
+
+func splitListCursor(cursor string) (persistent bool, id string) {
+	if cursor == "" {
+		return false, ""
+	}
+	return cursor[0] == '@', cursor[1:]
+}
+
+func buildListCursor(persistent bool, id string) string {
+	return utils.Select(persistent, "@", "#") + id
+}
+
+// listEntityInDefaultOrder lists the uncommitted entities ordered by id first, then the persistent ones
+// ordered by id, the cursor is the id prefixed by the part it belongs to.
+func (c *Controller) listEntityInDefaultOrder(
+	ctx context.Context,
+	entityType *schema.Entity,
+	filters []EntityFilter,
+	cursor string,
+	limit int,
+	blockNumber uint64,
+) (boxes []*EntityBox, next *string, persistentPart []*EntityBox, from string, err error) {
+	from = "uncommitted"
+	if limit == 0 {
+		return
+	}
+
+	// get uncommitted part result
+	cp, cid := splitListCursor(cursor)
+	boxes, checked, err := c.listUncommitted(ctx, entityType, filters, blockNumber, func(id string) bool {
+		return cp || id <= cid // before the cursor
+	})
+	if err != nil {
+		return nil, nil, nil, from, err
+	}
 	SortEntityBoxes(boxes)
 	if len(boxes) >= limit {
 		boxes = boxes[:limit]
@@ -455,12 +523,11 @@ func (c *Controller) listEntity(
 			Value: []any{cid},
 		})
 	}
-	persistentPart, persistentPartFromCache, err = c.store.ListEntities(ctx, entityType, filters, limit)
+	persistentPart, fromCache, err := c.store.ListEntities(ctx, entityType, filters, nil, nil, limit)
 	if err != nil {
-		logger.With("used", time.Since(start).String()).Errore(err, "list entity in store failed")
-		return nil, nil, err
+		return nil, nil, nil, from, fmt.Errorf("list entity in store failed: %w", err)
 	}
-	from = utils.Select(persistentPartFromCache, "cache", "persistent")
+	from = utils.Select(fromCache, "cache", "persistent")
 
 	// merge result and return
 	boxes = append(boxes, persistentPart...)
@@ -470,6 +537,65 @@ func (c *Controller) listEntity(
 	return
 }
 
+// listEntityInOrders merges the uncommitted entities and the persistent ones in the orders,
+// the cursor is the order key of the last entity, see BuildListCursor.
+func (c *Controller) listEntityInOrders(
+	ctx context.Context,
+	entityType *schema.Entity,
+	filters []EntityFilter,
+	orders []EntityOrder,
+	cursor string,
+	limit int,
+	blockNumber uint64,
+) (boxes []*EntityBox, next *string, persistentPart []*EntityBox, from string, err error) {
+	from = "uncommitted"
+	orders = CompleteEntityOrders(entityType, orders)
+	after, err := ParseListCursor(orders, cursor)
+	if err != nil || limit == 0 {
+		return nil, nil, nil, from, err
+	}
+
+	// get uncommitted part result
+	uncommittedPart, checked, err := c.listUncommitted(ctx, entityType, filters, blockNumber, nil)
+	if err != nil {
+		return nil, nil, nil, from, err
+	}
+	uncommittedPart = PageEntityBoxes(orders, uncommittedPart, after, math.MaxInt)
+
+	// get persistent part result, entities with uncommitted changes are excluded.
+	// The persistent part may be all before the uncommitted part, so always need limit ones,
+	// and one more to know whether there are more entities after this page.
+	filters = append(filters, EntityFilter{
+		Field: entityType.GetFieldByName(schema.EntityPrimaryFieldName),
+		Op:    EntityFilterOpNotIn,
+		Value: utils.ToAnyArray(utils.GetOrderedMapKeys(checked)),
+		idSet: checked,
+	})
+	fetch := utils.Select(limit < math.MaxInt, limit+1, limit)
+	persistentPart, fromCache, err := c.store.ListEntities(ctx, entityType, filters, orders, after, fetch)
+	if err != nil {
+		return nil, nil, nil, from, fmt.Errorf("list entity in store failed: %w", err)
+	}
+	from = utils.Select(fromCache, "cache", "persistent")
+
+	// merge result and return
+	for i, j := 0, 0; i < len(uncommittedPart) || j < len(persistentPart); {
+		if j == len(persistentPart) || (i < len(uncommittedPart) && CompareEntityOrderKey(orders,
+			EntityOrderKey(orders, uncommittedPart[i]), EntityOrderKey(orders, persistentPart[j])) < 0) {
+			boxes = append(boxes, uncommittedPart[i])
+			i++
+		} else {
+			boxes = append(boxes, persistentPart[j])
+			j++
+		}
+	}
+	if len(boxes) > limit {
+		boxes = boxes[:limit]
+		next = utils.WrapPointer(BuildListCursor(orders, EntityOrderKey(orders, boxes[limit-1])))
+	}
+	return
+}
+
 var uniqTimeSeriesID atomic.Int64
 
 // SetEntity stores an entity into the uncommitted change set.
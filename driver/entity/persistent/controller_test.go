@@ -148,6 +148,14 @@ enum EnumA {
 }
 
 
+type EntityF @entity {
+	id: ID!
+	pool: String! @index
+	amount: Int! @index
+	note: String @index
+	plain: Int!
+}
+
 interface EntityE {
 	id: ID!
 	propA: String!
@@ -204,9 +212,11 @@ func (s *mockChainStore) ListEntities(
 	_ context.Context,
 	entityType *schema.Entity,
 	filters []EntityFilter,
+	orders []EntityOrder,
+	after []any,
 	limit int,
 ) ([]*EntityBox, bool, error) {
-	log.Debugf("calling mockChainStore.ListEntities(%s, %v, %d)", entityType.Name, filters, limit)
+	log.Debugf("calling mockChainStore.ListEntities(%s, %v, %v, %v, %d)", entityType.Name, filters, orders, after, limit)
 	fromCache := s.fullLoaded[entityType.Name]
 	s.fullLoaded[entityType.Name] = true
 	var list []*EntityBox
@@ -220,11 +230,7 @@ func (s *mockChainStore) ListEntities(
 		}
 		list = append(list, origin.Copy())
 	}
-	SortEntityBoxes(list)
-	if len(list) > limit {
-		list = list[:limit]
-	}
-	return list, fromCache, nil
+	return PageEntityBoxes(CompleteEntityOrders(entityType, orders), list, after, limit), fromCache, nil
 }
 
 func (s *mockChainStore) GetTimeSeriesEntityMaxID(_ context.Context, _ *schema.Entity) (int64, error) {
@@ -250,6 +256,10 @@ func (s *mockChainStore) CheckValue(_ *schema.Entity, _ map[string]any) error {
 
 func (s *mockChainStore) Reorg(_ context.Context, _ int64) error { panic("not implemented") }
 
+func (s *mockChainStore) ScanEntities(_ context.Context, _ *schema.Entity, _ uint64, _ func(EntityBox) error) error {
+	panic("not implemented")
+}
+
 func (s *mockChainStore) Snapshot() any { return nil }
 
 // newTestStore returns a mockChainStore pre-loaded with EntityA, EntityB and
@@ -364,6 +374,28 @@ func copyWith(origin *EntityBox, gbn uint64, kvs ...any) *EntityBox {
 	return &dest
 }
 
+// listPages lists all pages of the entities with the page size until next cursor is nil.
+func listPages(
+	t *testing.T,
+	ctrl *Controller,
+	entityType *schema.Entity,
+	filters []EntityFilter,
+	orders []EntityOrder,
+	pageSize int,
+	blockNumber uint64,
+) (pages [][]*EntityBox) {
+	var cursor string
+	for {
+		boxes, next, err := ctrl.ListEntity(context.Background(), entityType, filters, orders, cursor, pageSize, blockNumber)
+		assert.NoError(t, err)
+		pages = append(pages, boxes)
+		if err != nil || next == nil {
+			return pages
+		}
+		cursor = *next
+	}
+}
+
 // newCtrl creates a Controller and a reset ReportMonitor bound to s.
 func newCtrl(s ChainStore) (*Controller, *ReportMonitor) {
 	m := NewReportMonitor(nil)
@@ -402,13 +434,13 @@ func TestController_ListEntity(t *testing.T) {
 		}
 
 		// init: [a0, a1]
-		boxes, _, err := ctrl.ListEntity(ctx, eaType, nil, "", 100, 12)
+		boxes, _, err := ctrl.ListEntity(ctx, eaType, nil, nil, "", 100, 12)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{a0, a1}, boxes)
 
 		// insert a2 → [a2, a0, a1]
 		assert.NoError(t, ctrl.SetEntity(ctx, eaType, UncommittedEntityBox{EntityBox: *a2}))
-		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, "", 100, 12)
+		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, nil, "", 100, 12)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{a2, a0, a1}, boxes)
 
@@ -416,7 +448,7 @@ func TestController_ListEntity(t *testing.T) {
 		assert.NoError(t, ctrl.SetEntity(ctx, eaType, UncommittedEntityBox{EntityBox: EntityBox{
 			ID: "0x0a01", GenBlockNumber: 12, GenBlockHash: "0x1234",
 		}}))
-		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, "", 100, 12)
+		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, nil, "", 100, 12)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{a2, a0}, boxes)
 
@@ -424,7 +456,7 @@ func TestController_ListEntity(t *testing.T) {
 		assert.NoError(t, ctrl.SetEntity(ctx, eaType, UncommittedEntityBox{EntityBox: EntityBox{
 			ID: "0x0a00", GenBlockNumber: 13, GenBlockHash: "0x1234",
 		}}))
-		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, "", 100, 13)
+		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, nil, "", 100, 13)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{a2}, boxes)
 
@@ -432,7 +464,7 @@ func TestController_ListEntity(t *testing.T) {
 		assert.NoError(t, ctrl.SetEntity(ctx, eaType, UncommittedEntityBox{EntityBox: EntityBox{
 			ID: "0x0a02", GenBlockNumber: 14, GenBlockHash: "0x1234",
 		}}))
-		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, "", 100, 14)
+		boxes, _, err = ctrl.ListEntity(ctx, eaType, nil, nil, "", 100, 14)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox(nil), boxes)
 	})
@@ -466,18 +498,18 @@ func TestController_ListEntity(t *testing.T) {
 		}
 
 		// init: [c00, c01, c11]
-		boxes, cursor, err := ctrl.ListEntity(ctx, ecType, nil, "", 100, 20)
+		boxes, cursor, err := ctrl.ListEntity(ctx, ecType, nil, nil, "", 100, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c00, c01, c11}, boxes)
 		assert.Nil(t, cursor)
 
 		// insert c10: uncommitted=[c10], persistent=[c00, c01, c11]
 		assert.NoError(t, ctrl.SetEntity(ctx, ecType, UncommittedEntityBox{EntityBox: *c10}))
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c10, c00}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 3, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 3, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -485,57 +517,57 @@ func TestController_ListEntity(t *testing.T) {
 		// update c01: uncommitted=[c01_, c10], persistent=[c00, c11]
 		assert.NoError(t, ctrl.SetEntity(ctx, ecType, UncommittedEntityBox{EntityBox: *c01_}))
 		// page size 1 → [c01_]
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 1, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 1, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01_}, boxes)
 		assert.NotNil(t, cursor)
 		// page size 2 → [c10, c00]
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c10, c00}, boxes)
 		assert.NotNil(t, cursor)
 		// page size 3 → [c11]
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 3, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 3, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c11}, boxes)
 		assert.Nil(t, cursor)
 
 		// page size 2 spans both parts: [c01_, c10] | [c00, c11]
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01_, c10}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c00, c11}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox(nil), boxes)
 		assert.Nil(t, cursor)
 
 		// page size 3: [c01_, c10, c00] | [c11]
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 3, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 3, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01_, c10, c00}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 3, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 3, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c11}, boxes)
 		assert.Nil(t, cursor)
 
 		// page size 4 fits all: [c01_, c10, c00, c11] | []
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 4, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 4, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01_, c10, c00, c11}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 4, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 4, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox(nil), boxes)
 		assert.Nil(t, cursor)
 
 		// page size 5 returns all at once
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 5, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 5, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01_, c10, c00, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -546,24 +578,52 @@ func TestController_ListEntity(t *testing.T) {
 			Op:    EntityFilterOpGe,
 			Value: []any{"0x0a01"},
 		}}
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, filters, "", 1, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, filters, nil, "", 1, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c10}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, filters, *cursor, 1, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, filters, nil, *cursor, 1, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c11}, boxes)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, filters, *cursor, 1, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, filters, nil, *cursor, 1, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox(nil), boxes)
 		assert.Nil(t, cursor)
 
+		// ordered by id, pages straddle the persistent and the uncommitted parts
+		byID := []EntityOrder{{Field: ecType.GetFieldByName("id")}}
+		assert.Equal(t,
+			[][]*EntityBox{{c00}, {c01_}, {c10}, {c11}},
+			listPages(t, ctrl, ecType, nil, byID, 1, 20))
+		assert.Equal(t,
+			[][]*EntityBox{{c00, c01_}, {c10, c11}},
+			listPages(t, ctrl, ecType, nil, byID, 2, 20))
+		assert.Equal(t,
+			[][]*EntityBox{{c00, c01_, c10}, {c11}},
+			listPages(t, ctrl, ecType, nil, byID, 3, 20))
+		assert.Equal(t,
+			[][]*EntityBox{{c00, c01_, c10, c11}},
+			listPages(t, ctrl, ecType, nil, byID, 4, 20))
+		// the old version of c01 is listed before the update
+		assert.Equal(t,
+			[][]*EntityBox{{c00, c01}, {c10, c11}},
+			listPages(t, ctrl, ecType, nil, byID, 2, 11))
+		assert.Equal(t,
+			[][]*EntityBox{{c10}, {c11}},
+			listPages(t, ctrl, ecType, filters, byID, 1, 20))
+
+		// descending: [c11, c10, c01_, c00]
+		desc := []EntityOrder{{Field: ecType.GetFieldByName("id"), Desc: true}}
+		assert.Equal(t,
+			[][]*EntityBox{{c11, c10, c01_}, {c00}},
+			listPages(t, ctrl, ecType, nil, desc, 3, 20))
+
 		// delete c01: uncommitted=[c10], persistent=[c00, c11]
 		assert.NoError(t, ctrl.SetEntity(ctx, ecType, UncommittedEntityBox{EntityBox: EntityBox{
 			ID: "0x0c0001", GenBlockNumber: 13, GenBlockHash: "0x1234",
 		}}))
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 5, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 5, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c10, c00, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -572,12 +632,83 @@ func TestController_ListEntity(t *testing.T) {
 		assert.NoError(t, ctrl.SetEntity(ctx, ecType, UncommittedEntityBox{EntityBox: EntityBox{
 			ID: "0x0c0100", GenBlockNumber: 14, GenBlockHash: "0x1234",
 		}}))
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 5, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 5, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c00, c11}, boxes)
 		assert.Nil(t, cursor)
 	})
 
+	t.Run("order by indexed fields", func(t *testing.T) {
+		_, s := newTestStore(sch, chain)
+		ctrl, _ := newCtrl(s)
+		ctx := context.Background()
+
+		efType := sch.GetEntity("EntityF")
+		pool, amount := efType.GetFieldByName("pool"), efType.GetFieldByName("amount")
+		newF := func(id, pool string, amount int32, gbn uint64) *EntityBox {
+			return &EntityBox{
+				ID:             id,
+				Data:           map[string]any{"id": id, "pool": pool, "amount": amount},
+				Entity:         "EntityF",
+				GenBlockNumber: gbn,
+			}
+		}
+		f1, f2, f3 := newF("f1", "p1", 30, 10), newF("f2", "p1", 10, 10), newF("f3", "p2", 20, 10)
+		for _, box := range []*EntityBox{f1, f2, f3} {
+			assert.NoError(t, ctrl.SetEntity(ctx, efType, UncommittedEntityBox{EntityBox: *box}))
+		}
+		_, _, err = ctrl.Commit(ctx, 10, time.Time{})
+		assert.NoError(t, err)
+
+		// f4 is uncommitted, f1 is updated, and f2 is deleted, all after the commit
+		f4 := newF("f4", "p1", 20, 11)
+		f1_ := newF("f1", "p1", 5, 11)
+		assert.NoError(t, ctrl.SetEntity(ctx, efType, UncommittedEntityBox{EntityBox: *f4}))
+		assert.NoError(t, ctrl.SetEntity(ctx, efType, UncommittedEntityBox{EntityBox: *f1_}))
+
+		byAmount := []EntityOrder{{Field: amount}}
+		assert.Equal(t,
+			[][]*EntityBox{{f1_, f2}, {f3, f4}},
+			listPages(t, ctrl, efType, nil, byAmount, 2, 11))
+		// f3 and f4 have the same amount, ordered by id
+		assert.Equal(t,
+			[][]*EntityBox{{f1_}, {f2}, {f3}, {f4}},
+			listPages(t, ctrl, efType, nil, byAmount, 1, 11))
+		// at block 10 nothing is uncommitted
+		assert.Equal(t,
+			[][]*EntityBox{{f2, f3}, {f1}},
+			listPages(t, ctrl, efType, nil, byAmount, 2, 10))
+
+		byPoolAmountDesc := []EntityOrder{{Field: pool}, {Field: amount, Desc: true}}
+		assert.Equal(t,
+			[][]*EntityBox{{f4, f2, f1_}, {f3}},
+			listPages(t, ctrl, efType, nil, byPoolAmountDesc, 3, 11))
+
+		// iterate entities of one pool
+		inP1 := []EntityFilter{{Field: pool, Op: EntityFilterOpEq, Value: []any{"p1"}}}
+		assert.NoError(t, ctrl.SetEntity(ctx, efType, UncommittedEntityBox{EntityBox: EntityBox{
+			ID: "f2", GenBlockNumber: 12,
+		}}))
+		assert.Equal(t,
+			[][]*EntityBox{{f4}, {f1_}},
+			listPages(t, ctrl, efType, inP1, byPoolAmountDesc, 1, 12))
+
+		// cursor only works with the order it is built for
+		_, cursor, err := ctrl.ListEntity(ctx, efType, nil, byAmount, "", 1, 12)
+		assert.NoError(t, err)
+		assert.NotNil(t, cursor)
+		_, _, err = ctrl.ListEntity(ctx, efType, nil, byPoolAmountDesc, *cursor, 1, 12)
+		assert.ErrorIs(t, err, ErrInvalidListCursor)
+		_, _, err = ctrl.ListEntity(ctx, efType, nil, byAmount, "not a cursor", 1, 12)
+		assert.ErrorIs(t, err, ErrInvalidListCursor)
+
+		// only non-null indexed fields can be used
+		for _, name := range []string{"note", "plain"} {
+			_, _, err = ctrl.ListEntity(ctx, efType, nil, []EntityOrder{{Field: efType.GetFieldByName(name)}}, "", 1, 12)
+			assert.ErrorIs(t, err, ErrInvalidListOrder)
+		}
+	})
+
 	t.Run("persistent cache stats", func(t *testing.T) {
 		ps, s := newTestStore(sch, chain)
 		ctrl, monitor := newCtrl(s)
@@ -607,7 +738,7 @@ func TestController_ListEntity(t *testing.T) {
 		}
 
 		// first list: hits persistent store
-		boxes, cursor, err := ctrl.ListEntity(ctx, ecType, nil, "", 100, 20)
+		boxes, cursor, err := ctrl.ListEntity(ctx, ecType, nil, nil, "", 100, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c00, c01, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -616,7 +747,7 @@ func TestController_ListEntity(t *testing.T) {
 			monitor.report.TotalListFrom)
 
 		// second list: served from cache
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 100, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 100, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c00, c01, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -629,7 +760,7 @@ func TestController_ListEntity(t *testing.T) {
 
 		// insert c10, list partial (2): uncommitted=[c10], cache=[c00, c01, c11]
 		assert.NoError(t, ctrl.SetEntity(ctx, ecType, UncommittedEntityBox{EntityBox: *c10}))
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c10, c00}, boxes)
 		assert.Equal(t,
@@ -639,7 +770,7 @@ func TestController_ListEntity(t *testing.T) {
 			},
 			monitor.report.TotalListFrom)
 		assert.NotNil(t, cursor)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 3, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 3, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -652,7 +783,7 @@ func TestController_ListEntity(t *testing.T) {
 
 		// update c01: uncommitted=[c01_, c10], cache=[c00, c11]
 		assert.NoError(t, ctrl.SetEntity(ctx, ecType, UncommittedEntityBox{EntityBox: *c01_}))
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 1, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 1, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c01_}, boxes)
 		assert.NotNil(t, cursor)
@@ -663,7 +794,7 @@ func TestController_ListEntity(t *testing.T) {
 				"cache":       {ecType.GetName(): 3},
 			},
 			monitor.report.TotalListFrom)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 2, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 2, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c10, c00}, boxes)
 		assert.NotNil(t, cursor)
@@ -674,7 +805,7 @@ func TestController_ListEntity(t *testing.T) {
 				"cache":       {ecType.GetName(): 4},
 			},
 			monitor.report.TotalListFrom)
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, *cursor, 3, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, *cursor, 3, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c11}, boxes)
 		assert.Nil(t, cursor)
@@ -691,7 +822,7 @@ func TestController_ListEntity(t *testing.T) {
 		assert.NoError(t, err)
 		ctrl, monitor = newCtrl(s)
 
-		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, "", 100, 20)
+		boxes, cursor, err = ctrl.ListEntity(ctx, ecType, nil, nil, "", 100, 20)
 		assert.NoError(t, err)
 		assert.Equal(t, []*EntityBox{c00, c01_, c10, c11}, boxes)
 		assert.Nil(t, cursor)
@@ -730,7 +861,7 @@ func TestController_ListRelated(t *testing.T) {
 	b1 := copyWith(rb1, rb1.GenBlockNumber)
 
 	// Verify initial state via ListEntity (populates list cache).
-	boxes, _, err := ctrl.ListEntity(ctx, eaType, nil, "", 100, 11)
+	boxes, _, err := ctrl.ListEntity(ctx, eaType, nil, nil, "", 100, 11)
 	assert.NoError(t, err)
 	assert.Equal(t, []*EntityBox{a0, a1}, boxes)
 
@@ -296,7 +296,7 @@ func compare(fieldType schema.TypeChain, raw1, raw2 any) int {
 				panic(fmt.Errorf("value2 is %T / %#v, not an int32 because %w", value2.Interface(), value2.Interface(), err))
 			}
 			return utils.Cmp(v1, v2)
-		case "Timestamp":
+		case "Int8", "Timestamp":
 			var v1, v2 int64
 			var err error
 			if v1, err = anyutil.ParseInt(value1.Interface()); err != nil {
@@ -0,0 +1,245 @@
+package persistent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/types"
+
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+// EntityOrder is one term of the order of listed entities
+type EntityOrder struct {
+	Field *types.FieldDefinition
+	Desc  bool
+}
+
+func (o EntityOrder) String() string {
+	return o.Field.Name + utils.Select(o.Desc, " desc", " asc")
+}
+
+func EntityOrdersString(orders []EntityOrder) string {
+	str := make([]string, len(orders))
+	for i, o := range orders {
+		str[i] = o.String()
+	}
+	return strings.Join(str, ",")
+}
+
+// CheckEntityOrders checks whether entities of entityType can be listed in the orders.
+// Every order field should be a non-null scalar, enum or foreign key field, and fields other
+// than id should have the @index directive, so the order is stable and can be used by keyset
+// pagination of all the stores.
+func CheckEntityOrders(entityType *schema.Entity, orders []EntityOrder) error {
+	used := make(map[string]bool)
+	for _, o := range orders {
+		if o.Field == nil {
+			return fmt.Errorf("%w: missing field", ErrInvalidListOrder)
+		}
+		field := entityType.GetFieldByName(o.Field.Name)
+		if field == nil {
+			return fmt.Errorf("%w: %s.%s is not exists", ErrInvalidListOrder, entityType.Name, o.Field.Name)
+		}
+		fieldTitle := fmt.Sprintf("%s.%s %s", entityType.Name, field.Name, field.Type.String())
+		if used[field.Name] {
+			return fmt.Errorf("%w: %s is used more than once", ErrInvalidListOrder, fieldTitle)
+		}
+		used[field.Name] = true
+		if _, nonNull := field.Type.(*types.NonNull); !nonNull {
+			return fmt.Errorf("%w: %s is nullable", ErrInvalidListOrder, fieldTitle)
+		}
+		typeChain := schema.BreakType(field.Type)
+		if typeChain.CountListLayer() > 0 {
+			return fmt.Errorf("%w: %s is an array", ErrInvalidListOrder, fieldTitle)
+		}
+		switch typ := typeChain.InnerType().(type) {
+		case *types.ScalarTypeDefinition:
+			if typ.Name == "BigInt" || typ.Name == "BigDecimal" {
+				return fmt.Errorf("%w: %s is not supported", ErrInvalidListOrder, fieldTitle)
+			}
+		case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition:
+			if entityType.GetForeignKeyFieldByName(field.Name).IsReverseField() {
+				return fmt.Errorf("%w: %s is a reverse foreign key", ErrInvalidListOrder, fieldTitle)
+			}
+		}
+		if dbType, has, _ := schema.GetFieldDBType(field); has && strings.ToLower(dbType) == "json" {
+			return fmt.Errorf("%w: %s is stored as json", ErrInvalidListOrder, fieldTitle)
+		}
+		if field.Name == schema.EntityPrimaryFieldName {
+			continue
+		}
+		if _, has, _ := schema.GetIndex(field); !has {
+			return fmt.Errorf("%w: %s has no @index", ErrInvalidListOrder, fieldTitle)
+		}
+	}
+	return nil
+}
+
+// CompleteEntityOrders appends id in ascending order as the last order term if id is not used,
+// so that no two entities are equal in the returned orders. Nil orders means order by id.
+func CompleteEntityOrders(entityType *schema.Entity, orders []EntityOrder) []EntityOrder {
+	for _, o := range orders {
+		if o.Field.Name == schema.EntityPrimaryFieldName {
+			return orders
+		}
+	}
+	return append(slices.Clone(orders), EntityOrder{Field: entityType.GetFieldByName(schema.EntityPrimaryFieldName)})
+}
+
+func isNumericID(field *types.FieldDefinition) bool {
+	scalar, is := schema.BreakType(field.Type).InnerType().(*types.ScalarTypeDefinition)
+	return is && scalar.Name == "Int8"
+}
+
+// orderValue returns the value of the order field of box, id of timeseries entities is Int8,
+// the auto generated ones not committed yet are bigger than all the others.
+func orderValue(o EntityOrder, box *EntityBox) any {
+	if o.Field.Name != schema.EntityPrimaryFieldName {
+		return box.Data[o.Field.Name]
+	}
+	if !isNumericID(o.Field) {
+		return box.ID
+	}
+	id, err := strconv.ParseInt(box.ID, 10, 64)
+	if err != nil {
+		return int64(math.MaxInt64)
+	}
+	return id
+}
+
+// EntityOrderKey returns the values of the order fields of box
+func EntityOrderKey(orders []EntityOrder, box *EntityBox) []any {
+	key := make([]any, len(orders))
+	for i, o := range orders {
+		key[i] = orderValue(o, box)
+	}
+	return key
+}
+
+// CompareEntityOrderKey returns -1 if key1 is before key2 in the orders, 1 if after, otherwise 0
+func CompareEntityOrderKey(orders []EntityOrder, key1, key2 []any) int {
+	for i, o := range orders {
+		cr := compare(schema.BreakType(o.Field.Type), key1[i], key2[i])
+		switch cr {
+		case -1, 1:
+			return utils.Select(o.Desc, -cr, cr)
+		case 0, 3:
+			continue
+		default:
+			// unreachable, the order fields are non-null and not array
+			panic(fmt.Errorf("compare %v and %v of %s got %d", key1[i], key2[i], o.String(), cr))
+		}
+	}
+	return 0
+}
+
+// SortEntityBoxesByOrder sorts the boxes in the orders, orders should be completed by CompleteEntityOrders
+func SortEntityBoxesByOrder(orders []EntityOrder, list []*EntityBox) {
+	keys := make(map[*EntityBox][]any, len(list))
+	for _, box := range list {
+		keys[box] = EntityOrderKey(orders, box)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return CompareEntityOrderKey(orders, keys[list[i]], keys[list[j]]) < 0
+	})
+}
+
+// PageEntityBoxes sorts the boxes in the orders, and returns at most limit ones after the key.
+// If after is nil, returns from the first one.
+func PageEntityBoxes(orders []EntityOrder, list []*EntityBox, after []any, limit int) []*EntityBox {
+	SortEntityBoxesByOrder(orders, list)
+	var page []*EntityBox
+	for _, box := range list {
+		if len(page) >= limit {
+			break
+		}
+		if after != nil && CompareEntityOrderKey(orders, EntityOrderKey(orders, box), after) <= 0 {
+			continue
+		}
+		page = append(page, box)
+	}
+	return page
+}
+
+type listCursor struct {
+	Order string `json:"o"`
+	Key   []any  `json:"k"`
+}
+
+// BuildListCursor returns an opaque cursor points to the entity with the order key,
+// orders should be completed by CompleteEntityOrders
+func BuildListCursor(orders []EntityOrder, key []any) string {
+	raw := utils.MustJSONMarshal(listCursor{Order: EntityOrdersString(orders), Key: key})
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseListCursor returns the order key in the cursor built by BuildListCursor with the same orders.
+// Empty cursor means the beginning and will got nil key.
+func ParseListCursor(orders []EntityOrder, cursor string) ([]any, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not base64 encoded: %v", ErrInvalidListCursor, cursor, err)
+	}
+	var c listCursor
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err = dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: %q is malformed: %v", ErrInvalidListCursor, cursor, err)
+	}
+	if ordersText := EntityOrdersString(orders); c.Order != ordersText || len(c.Key) != len(orders) {
+		return nil, fmt.Errorf("%w: %q is built for order [%s] not [%s]", ErrInvalidListCursor, cursor, c.Order, ordersText)
+	}
+	key := make([]any, len(orders))
+	for i, o := range orders {
+		if key[i], err = parseCursorValue(o.Field, c.Key[i]); err != nil {
+			return nil, fmt.Errorf("%w: value %v of %s in %q is invalid: %v", ErrInvalidListCursor, c.Key[i], o, cursor, err)
+		}
+	}
+	return key, nil
+}
+
+func parseCursorValue(field *types.FieldDefinition, raw any) (any, error) {
+	var scalarName string
+	if scalar, is := schema.BreakType(field.Type).InnerType().(*types.ScalarTypeDefinition); is {
+		scalarName = scalar.Name
+	}
+	switch scalarName {
+	case "Int", "Int8", "Timestamp", "Float":
+		number, is := raw.(json.Number)
+		if !is {
+			return nil, fmt.Errorf("%T is not a number", raw)
+		}
+		switch scalarName {
+		case "Int":
+			v, err := strconv.ParseInt(number.String(), 10, 32)
+			return int32(v), err
+		case "Float":
+			return number.Float64()
+		default:
+			return strconv.ParseInt(number.String(), 10, 64)
+		}
+	case "Boolean":
+		if _, is := raw.(bool); !is {
+			return nil, fmt.Errorf("%T is not a boolean", raw)
+		}
+		return raw, nil
+	default:
+		// ID, String, Bytes, enum and foreign key
+		if _, is := raw.(string); !is {
+			return nil, fmt.Errorf("%T is not a string", raw)
+		}
+		return raw, nil
+	}
+}
@@ -0,0 +1,120 @@
+package persistent
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"github.com/stretchr/testify/assert"
+
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+const orderTestSchema = `
+enum Level { LOW HIGH }
+
+type Pool @entity {
+	id: ID!
+}
+
+type Position @entity {
+	id: ID!
+	pool: Pool! @index
+	owners: [String!]! @index
+	level: Level! @index
+	active: Boolean! @index
+	size: Int! @index
+	value: Float! @index
+	openedAt: Timestamp! @index
+	seq: Int8! @index
+	amount: BigInt! @index
+	note: String @index
+	raw: String! @index @dbType(type: "JSON")
+	plain: Int!
+}
+
+type Swap @entity(timeseries: true) {
+	id: Int8!
+	timestamp: Timestamp!
+}
+`
+
+func Test_CheckEntityOrders(t *testing.T) {
+	sch, err := schema.ParseAndVerifySchema(orderTestSchema)
+	assert.NoError(t, err)
+	posType := sch.GetEntity("Position")
+	order := func(names ...string) (orders []EntityOrder) {
+		for _, name := range names {
+			orders = append(orders, EntityOrder{Field: posType.GetFieldByName(name)})
+		}
+		return orders
+	}
+
+	assert.NoError(t, CheckEntityOrders(posType, nil))
+	assert.NoError(t, CheckEntityOrders(posType, order("id")))
+	assert.NoError(t, CheckEntityOrders(posType, order("pool", "level", "active", "size", "value", "openedAt", "seq")))
+	for _, names := range [][]string{
+		{"owners"},       // array
+		{"amount"},       // BigInt
+		{"note"},         // nullable
+		{"raw"},          // json
+		{"plain"},        // no @index
+		{"size", "size"}, // duplicated
+	} {
+		assert.ErrorIs(t, CheckEntityOrders(posType, order(names...)), ErrInvalidListOrder, "%v", names)
+	}
+	assert.ErrorIs(t,
+		CheckEntityOrders(posType, []EntityOrder{{Field: &types.FieldDefinition{Name: "other"}}}),
+		ErrInvalidListOrder)
+}
+
+func Test_listCursor(t *testing.T) {
+	sch, err := schema.ParseAndVerifySchema(orderTestSchema)
+	assert.NoError(t, err)
+	posType := sch.GetEntity("Position")
+	orders := CompleteEntityOrders(posType, []EntityOrder{
+		{Field: posType.GetFieldByName("pool")},
+		{Field: posType.GetFieldByName("active"), Desc: true},
+		{Field: posType.GetFieldByName("size")},
+		{Field: posType.GetFieldByName("value"), Desc: true},
+		{Field: posType.GetFieldByName("openedAt")},
+		{Field: posType.GetFieldByName("seq")},
+	})
+	assert.Equal(t, "pool asc,active desc,size asc,value desc,openedAt asc,seq asc,id asc", EntityOrdersString(orders))
+
+	box := &EntityBox{ID: "p1", Data: map[string]any{
+		"pool":     "0xpool",
+		"active":   true,
+		"size":     int32(-3),
+		"value":    1.25,
+		"openedAt": int64(1700000000000000),
+		"seq":      int64(1) << 60,
+	}}
+	key := EntityOrderKey(orders, box)
+	assert.Equal(t, []any{"0xpool", true, int32(-3), 1.25, int64(1700000000000000), int64(1) << 60, "p1"}, key)
+	parsed, err := ParseListCursor(orders, BuildListCursor(orders, key))
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+	assert.Equal(t, 0, CompareEntityOrderKey(orders, key, parsed))
+
+	parsed, err = ParseListCursor(orders, "")
+	assert.NoError(t, err)
+	assert.Nil(t, parsed)
+
+	// cursor of other orders
+	_, err = ParseListCursor(orders[1:], BuildListCursor(orders, key))
+	assert.ErrorIs(t, err, ErrInvalidListCursor)
+	// value with wrong type
+	_, err = ParseListCursor(orders, BuildListCursor(orders, []any{"0xpool", "true", -3, 1.25, 1, 1, "p1"}))
+	assert.ErrorIs(t, err, ErrInvalidListCursor)
+	_, err = ParseListCursor(orders, "!!")
+	assert.ErrorIs(t, err, ErrInvalidListCursor)
+
+	// ids of timeseries entities are ordered as numbers
+	swapType := sch.GetEntity("Swap")
+	swapOrders := CompleteEntityOrders(swapType, nil)
+	boxes := []*EntityBox{{ID: "10"}, {ID: "9"}, {ID: "100"}}
+	assert.Equal(t, []*EntityBox{{ID: "9"}, {ID: "10"}, {ID: "100"}},
+		PageEntityBoxes(swapOrders, boxes, nil, 10))
+	assert.Equal(t, []*EntityBox{{ID: "100"}},
+		PageEntityBoxes(swapOrders, boxes, []any{int64(10)}, 10))
+}
@@ -0,0 +1,569 @@
+package persistenttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+)
+
+// Schema is the entity schema used by the suite, the stores being tested must be built with it
+const Schema = `
+type Account @entity {
+  id: ID!
+  name: String!
+  nick: String
+  balance: Int! @index
+  tags: [String!]!
+}
+
+type Transfer @entity(immutable: true) {
+  id: ID!
+  from: String!
+  amount: Int!
+}
+
+type Swap @entity(timeseries: true) {
+  id: Int8!
+  timestamp: Timestamp!
+  pool: String!
+  amount: BigDecimal!
+}
+
+type SwapStats @aggregation(intervals: ["hour", "day"], source: "Swap") {
+  id: Int8!
+  timestamp: Timestamp!
+  pool: String!
+  volume: BigDecimal! @aggregate(fn: "sum", arg: "amount")
+  swaps: Int8! @aggregate(fn: "count", arg: "amount")
+  maxAmount: BigDecimal! @aggregate(fn: "max", arg: "amount")
+  lastAmount: BigDecimal! @aggregate(fn: "last", arg: "amount * 2")
+}
+
+type Session @cache(sizeMB: 1) {
+  id: ID!
+  value: String!
+}
+`
+
+// AggregationReader reads the rows written by GrowthAggregation, the stores under the suite must implement it,
+// in the test code if the store does not need it
+type AggregationReader interface {
+	ListAggregationRows(ctx context.Context, agg *schema.Aggregation, interval string) ([]*persistent.EntityBox, error)
+}
+
+// MustParseSchema parses Schema
+func MustParseSchema() *schema.Schema {
+	sch, err := schema.ParseAndVerifySchema(Schema)
+	if err != nil {
+		panic(err)
+	}
+	return sch
+}
+
+// BlockTime is the block time of the block number used by the suite
+func BlockTime(bn uint64) time.Time {
+	return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(bn) * time.Minute)
+}
+
+func account(id string, bn uint64, balance int32, nick *string, tags ...string) persistent.EntityBox {
+	return persistent.EntityBox{
+		Entity: "Account",
+		ID:     id,
+		Data: map[string]any{
+			"id":      id,
+			"name":    "name-" + id,
+			"nick":    nick,
+			"balance": balance,
+			"tags":    utils.Select(tags == nil, []string{}, tags),
+		},
+		GenBlockNumber: bn,
+		GenBlockTime:   BlockTime(bn),
+		GenBlockHash:   fmt.Sprintf("0x%x", bn),
+	}
+}
+
+func deleted(entity, id string, bn uint64) persistent.EntityBox {
+	return persistent.EntityBox{
+		Entity:         entity,
+		ID:             id,
+		GenBlockNumber: bn,
+		GenBlockTime:   BlockTime(bn),
+		GenBlockHash:   fmt.Sprintf("0x%x", bn),
+	}
+}
+
+func transfer(id string, bn uint64, amount int32) persistent.EntityBox {
+	return persistent.EntityBox{
+		Entity: "Transfer",
+		ID:     id,
+		Data: map[string]any{
+			"id":     id,
+			"from":   "0x01",
+			"amount": amount,
+		},
+		GenBlockNumber: bn,
+		GenBlockTime:   BlockTime(bn),
+		GenBlockHash:   fmt.Sprintf("0x%x", bn),
+	}
+}
+
+func swap(id int64, bn uint64, pool string, amount int64) persistent.EntityBox {
+	return persistent.EntityBox{
+		Entity: "Swap",
+		ID:     strconv.FormatInt(id, 10),
+		Data: map[string]any{
+			"id":        id,
+			"timestamp": BlockTime(bn).UnixMicro(),
+			"pool":      pool,
+			"amount":    decimal.NewFromInt(amount),
+		},
+		GenBlockNumber: bn,
+		GenBlockTime:   BlockTime(bn),
+		GenBlockHash:   fmt.Sprintf("0x%x", bn),
+	}
+}
+
+func field(entityType *schema.Entity, name string) *types.FieldDefinition {
+	return entityType.GetFieldByName(name)
+}
+
+func get(t *testing.T, store persistent.ChainStore, entity, id string) *persistent.EntityBox {
+	box, _, err := store.GetEntity(context.Background(), store.GetEntityType(entity), id)
+	require.NoError(t, err)
+	return box
+}
+
+func set(t *testing.T, store persistent.ChainStore, entity string, boxes ...persistent.EntityBox) int {
+	created, err := store.SetEntities(context.Background(), store.GetEntityType(entity), boxes)
+	require.NoError(t, err)
+	return created
+}
+
+func list(t *testing.T, store persistent.ChainStore, entity string, limit int, filters ...persistent.EntityFilter) []string {
+	boxes, _, err := store.ListEntities(context.Background(), store.GetEntityType(entity), filters, nil, nil, limit)
+	require.NoError(t, err)
+	var ids []string
+	for _, box := range boxes {
+		ids = append(ids, box.ID)
+	}
+	return ids
+}
+
+// listPages lists all the entities page by page in the orders, the last page is the one with less than pageSize
+func listPages(
+	t *testing.T,
+	store persistent.ChainStore,
+	entity string,
+	orders []persistent.EntityOrder,
+	pageSize int,
+	filters ...persistent.EntityFilter,
+) (pages [][]string) {
+	entityType := store.GetEntityType(entity)
+	orders = persistent.CompleteEntityOrders(entityType, orders)
+	var after []any
+	for {
+		boxes, _, err := store.ListEntities(context.Background(), entityType, filters, orders, after, pageSize)
+		require.NoError(t, err)
+		var ids []string
+		for _, box := range boxes {
+			ids = append(ids, box.ID)
+		}
+		pages = append(pages, ids)
+		if len(boxes) < pageSize {
+			return pages
+		}
+		after = persistent.EntityOrderKey(orders, boxes[len(boxes)-1])
+	}
+}
+
+func assertAccount(t *testing.T, store persistent.ChainStore, expected persistent.EntityBox) {
+	box := get(t, store, "Account", expected.ID)
+	require.NotNil(t, box, "account %s", expected.ID)
+	assert.Equal(t, expected.GenBlockNumber, box.GenBlockNumber)
+	assert.Equal(t, expected.Data["name"], box.Data["name"])
+	assert.Equal(t, expected.Data["balance"], box.Data["balance"])
+	assert.Equal(t, expected.Data["tags"], box.Data["tags"])
+}
+
+// Run runs the whole suite, newStore should return an empty store built with Schema every time
+func Run(t *testing.T, newStore func(t *testing.T) persistent.ChainStore) {
+	t.Run("getSetDelete", func(t *testing.T) {
+		RunGetSetDelete(t, newStore(t))
+	})
+	t.Run("immutable", func(t *testing.T) {
+		RunImmutable(t, newStore(t))
+	})
+	t.Run("timeSeries", func(t *testing.T) {
+		RunTimeSeries(t, newStore(t))
+	})
+	t.Run("filters", func(t *testing.T) {
+		RunFilters(t, newStore(t))
+	})
+	t.Run("orders", func(t *testing.T) {
+		RunOrders(t, newStore(t))
+	})
+	t.Run("reorg", func(t *testing.T) {
+		RunReorg(t, newStore(t))
+	})
+	t.Run("scan", func(t *testing.T) {
+		RunScan(t, newStore(t))
+	})
+	t.Run("cacheEntity", func(t *testing.T) {
+		RunCacheEntity(t, newStore(t))
+	})
+	t.Run("growthAggregation", func(t *testing.T) {
+		RunGrowthAggregation(t, newStore(t))
+	})
+}
+
+func RunGetSetDelete(t *testing.T, store persistent.ChainStore) {
+	assert.Nil(t, get(t, store, "Account", "a1"))
+
+	// the same id in one batch is ordered by GenBlockNumber, the latest one wins
+	a1v1, a1v2, a2v1 := account("a1", 10, 100, nil), account("a1", 11, 110, nil), account("a2", 10, 200, nil)
+	assert.Equal(t, 2, set(t, store, "Account", a1v1, a2v1, a1v2))
+	assertAccount(t, store, a1v2)
+	assertAccount(t, store, a2v1)
+
+	// update existing one and create new one
+	a2v2, a3v1 := account("a2", 12, 210, nil), account("a3", 12, 300, nil, "x")
+	assert.Equal(t, 1, set(t, store, "Account", a2v2, a3v1))
+	assertAccount(t, store, a2v2)
+	assertAccount(t, store, a3v1)
+
+	// delete
+	assert.Equal(t, 0, set(t, store, "Account", deleted("Account", "a2", 13)))
+	assert.Nil(t, get(t, store, "Account", "a2"))
+	assert.Equal(t, []string{"a1", "a3"}, list(t, store, "Account", 10))
+
+	// the deleted one is created again
+	a2v3 := account("a2", 14, 220, nil)
+	assert.Equal(t, 1, set(t, store, "Account", a2v3))
+	assertAccount(t, store, a2v3)
+	assert.Equal(t, []string{"a1", "a2", "a3"}, list(t, store, "Account", 10))
+	assert.Equal(t, []string{"a1", "a2"}, list(t, store, "Account", 2))
+}
+
+func RunImmutable(t *testing.T, store persistent.ChainStore) {
+	ctx := context.Background()
+	entityType := store.GetEntityType("Transfer")
+	assert.Equal(t, 2, set(t, store, "Transfer", transfer("t1", 10, 1), transfer("t2", 10, 2)))
+
+	_, err := store.SetEntities(ctx, entityType, []persistent.EntityBox{transfer("t1", 11, 10)})
+	assert.ErrorIs(t, err, persistent.ErrUpdateImmutable)
+	box := get(t, store, "Transfer", "t1")
+	require.NotNil(t, box)
+	assert.Equal(t, int32(1), box.Data["amount"])
+
+	assert.Equal(t, 1, set(t, store, "Transfer", transfer("t3", 12, 3)))
+	assert.Equal(t, []string{"t1", "t2", "t3"}, list(t, store, "Transfer", 10))
+}
+
+func RunTimeSeries(t *testing.T, store persistent.ChainStore) {
+	ctx := context.Background()
+	entityType := store.GetEntityType("Swap")
+	maxID, err := store.GetTimeSeriesEntityMaxID(ctx, entityType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), maxID)
+
+	assert.Equal(t, 3, set(t, store, "Swap", swap(1, 10, "p1", 1), swap(2, 10, "p2", 2), swap(3, 11, "p1", 3)))
+	maxID, err = store.GetTimeSeriesEntityMaxID(ctx, entityType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), maxID)
+
+	// ids are always greater than the max id
+	assert.Equal(t, 2, set(t, store, "Swap", swap(4, 12, "p1", 4), swap(10, 12, "p2", 5)))
+	maxID, err = store.GetTimeSeriesEntityMaxID(ctx, entityType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), maxID)
+
+	box := get(t, store, "Swap", "3")
+	require.NotNil(t, box)
+	assert.Equal(t, "p1", box.Data["pool"])
+	assert.Equal(t, []string{"1", "3", "4"}, list(t, store, "Swap", 10, persistent.EntityFilter{
+		Field: field(entityType, "pool"),
+		Op:    persistent.EntityFilterOpEq,
+		Value: []any{"p1"},
+	}))
+}
+
+func RunFilters(t *testing.T, store persistent.ChainStore) {
+	entityType := store.GetEntityType("Account")
+	nick := "nick"
+	set(t, store, "Account",
+		account("a1", 10, 100, &nick, "x", "y"),
+		account("a2", 10, 200, nil, "y"),
+		account("a3", 10, 300, nil),
+		account("b1", 10, 400, &nick, "x", "z"),
+	)
+	filter := func(name string, op persistent.EntityFilterOp, values ...any) persistent.EntityFilter {
+		return persistent.EntityFilter{Field: field(entityType, name), Op: op, Value: values}
+	}
+	testcases := []struct {
+		filters  []persistent.EntityFilter
+		expected []string
+	}{
+		{[]persistent.EntityFilter{filter("balance", persistent.EntityFilterOpEq, int32(200))}, []string{"a2"}},
+		{[]persistent.EntityFilter{filter("balance", persistent.EntityFilterOpNe, int32(200))}, []string{"a1", "a3", "b1"}},
+		{[]persistent.EntityFilter{filter("balance", persistent.EntityFilterOpGt, int32(200))}, []string{"a3", "b1"}},
+		{[]persistent.EntityFilter{filter("balance", persistent.EntityFilterOpGe, int32(200))}, []string{"a2", "a3", "b1"}},
+		{[]persistent.EntityFilter{filter("balance", persistent.EntityFilterOpLt, int32(200))}, []string{"a1"}},
+		{[]persistent.EntityFilter{filter("balance", persistent.EntityFilterOpLe, int32(200))}, []string{"a1", "a2"}},
+		{[]persistent.EntityFilter{filter("id", persistent.EntityFilterOpIn, "a1", "b1", "c1")}, []string{"a1", "b1"}},
+		{[]persistent.EntityFilter{filter("id", persistent.EntityFilterOpNotIn, "a1", "b1")}, []string{"a2", "a3"}},
+		{[]persistent.EntityFilter{filter("id", persistent.EntityFilterOpIn)}, nil},
+		{[]persistent.EntityFilter{filter("name", persistent.EntityFilterOpLike, "name-a%")}, []string{"a1", "a2", "a3"}},
+		{[]persistent.EntityFilter{filter("name", persistent.EntityFilterOpNotLike, "name-a%")}, []string{"b1"}},
+		{[]persistent.EntityFilter{filter("tags", persistent.EntityFilterOpHasAll, "x", "y")}, []string{"a1"}},
+		{[]persistent.EntityFilter{filter("tags", persistent.EntityFilterOpHasAny, "y", "z")}, []string{"a1", "a2", "b1"}},
+		{[]persistent.EntityFilter{filter("tags", persistent.EntityFilterOpHasAll)}, []string{"a1", "a2", "a3", "b1"}},
+		// null values
+		{[]persistent.EntityFilter{filter("nick", persistent.EntityFilterOpEq, nil)}, []string{"a2", "a3"}},
+		{[]persistent.EntityFilter{filter("nick", persistent.EntityFilterOpNe, nil)}, []string{"a1", "b1"}},
+		{[]persistent.EntityFilter{filter("nick", persistent.EntityFilterOpNe, "other")}, []string{"a1", "b1"}},
+		{[]persistent.EntityFilter{filter("nick", persistent.EntityFilterOpIn, "nick", nil)}, []string{"a1", "a2", "a3", "b1"}},
+		{[]persistent.EntityFilter{filter("nick", persistent.EntityFilterOpNotIn, "nick")}, []string{"a2", "a3"}},
+		// multiple filters
+		{[]persistent.EntityFilter{
+			filter("balance", persistent.EntityFilterOpGe, int32(200)),
+			filter("tags", persistent.EntityFilterOpHasAny, "x", "y"),
+		}, []string{"a2", "b1"}},
+	}
+	for i, tc := range testcases {
+		assert.Equal(t, tc.expected, list(t, store, "Account", 10, tc.filters...),
+			"#%d %s", i, persistent.EntityFiltersString(tc.filters))
+	}
+}
+
+func RunOrders(t *testing.T, store persistent.ChainStore) {
+	accountType := store.GetEntityType("Account")
+	set(t, store, "Account",
+		account("a1", 10, 300, nil),
+		account("a2", 10, 100, nil),
+		account("a3", 10, 200, nil),
+		account("a4", 10, 100, nil),
+		account("a5", 10, 400, nil),
+	)
+	set(t, store, "Account", account("a5", 11, 50, nil), deleted("Account", "a3", 11))
+	byBalance := []persistent.EntityOrder{{Field: field(accountType, "balance")}}
+	byBalanceDesc := []persistent.EntityOrder{{Field: field(accountType, "balance"), Desc: true}}
+	byIDDesc := []persistent.EntityOrder{{Field: field(accountType, "id"), Desc: true}}
+
+	assert.Equal(t, [][]string{{"a1", "a2"}, {"a4", "a5"}, nil}, listPages(t, store, "Account", nil, 2))
+	assert.Equal(t, [][]string{{"a5", "a2", "a4"}, {"a1"}}, listPages(t, store, "Account", byBalance, 3))
+	// a2 and a4 have the same balance, the order of id is ascending for both directions
+	assert.Equal(t, [][]string{{"a1", "a2"}, {"a4", "a5"}, nil}, listPages(t, store, "Account", byBalanceDesc, 2))
+	assert.Equal(t, [][]string{{"a5"}, {"a4"}, {"a2"}, {"a1"}, nil}, listPages(t, store, "Account", byIDDesc, 1))
+	assert.Equal(t, [][]string{{"a2", "a4"}, {"a1"}}, listPages(t, store, "Account", byBalance, 2,
+		persistent.EntityFilter{
+			Field: field(accountType, "balance"),
+			Op:    persistent.EntityFilterOpGe,
+			Value: []any{int32(100)},
+		}))
+
+	// ids of timeseries entities are ordered as numbers
+	swapType := store.GetEntityType("Swap")
+	set(t, store, "Swap", swap(2, 10, "p1", 1), swap(9, 10, "p1", 2), swap(10, 11, "p2", 3), swap(11, 11, "p1", 4))
+	assert.Equal(t, [][]string{{"11", "10", "9"}, {"2"}},
+		listPages(t, store, "Swap", []persistent.EntityOrder{{Field: field(swapType, "id"), Desc: true}}, 3))
+	assert.Equal(t, [][]string{{"2", "9"}, {"11"}}, listPages(t, store, "Swap", nil, 2, persistent.EntityFilter{
+		Field: field(swapType, "pool"),
+		Op:    persistent.EntityFilterOpEq,
+		Value: []any{"p1"},
+	}))
+}
+
+func RunReorg(t *testing.T, store persistent.ChainStore) {
+	ctx := context.Background()
+	a1v1, a2v1 := account("a1", 10, 100, nil), account("a2", 10, 200, nil)
+	set(t, store, "Account", a1v1, a2v1)
+	set(t, store, "Account", account("a1", 12, 110, nil), deleted("Account", "a2", 12), account("a3", 12, 300, nil))
+	set(t, store, "Transfer", transfer("t1", 10, 1), transfer("t2", 12, 2))
+	set(t, store, "Swap", swap(1, 10, "p1", 1), swap(2, 12, "p1", 2))
+
+	require.NoError(t, store.Reorg(ctx, 11))
+
+	// updated one rolls back, deleted one comes back, created one disappears
+	assertAccount(t, store, a1v1)
+	assertAccount(t, store, a2v1)
+	assert.Nil(t, get(t, store, "Account", "a3"))
+	assert.Equal(t, []string{"a1", "a2"}, list(t, store, "Account", 10))
+	assert.Equal(t, []string{"t1"}, list(t, store, "Transfer", 10))
+	maxID, err := store.GetTimeSeriesEntityMaxID(ctx, store.GetEntityType("Swap"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), maxID)
+
+	// the ids dropped by the reorg can be used again
+	assert.Equal(t, 1, set(t, store, "Transfer", transfer("t2", 12, 20)))
+	assert.Equal(t, 1, set(t, store, "Account", account("a3", 12, 310, nil)))
+}
+
+func RunScan(t *testing.T, store persistent.ChainStore) {
+	ctx := context.Background()
+	scan := func(entity string, maxBlockNumber uint64) (boxes []persistent.EntityBox) {
+		err := store.ScanEntities(ctx, store.GetEntityType(entity), maxBlockNumber, func(box persistent.EntityBox) error {
+			boxes = append(boxes, box)
+			return nil
+		})
+		require.NoError(t, err)
+		return boxes
+	}
+	a1v1, a2v1, a1v2 := account("a1", 10, 100, nil), account("a2", 10, 200, nil), account("a1", 12, 110, nil)
+	set(t, store, "Account", a1v1, a2v1)
+	set(t, store, "Account", a1v2, deleted("Account", "a2", 12), account("a3", 12, 300, nil))
+	set(t, store, "Account", deleted("Account", "a3", 13))
+
+	// the versions as of the block, the deleted ones are skipped
+	assert.Nil(t, scan("Account", 9))
+	boxes := scan("Account", 11)
+	require.Len(t, boxes, 2)
+	assert.Equal(t, "a1", boxes[0].ID)
+	assert.Equal(t, uint64(10), boxes[0].GenBlockNumber)
+	assert.Equal(t, a1v1.Data["balance"], boxes[0].Data["balance"])
+	assert.Equal(t, "a2", boxes[1].ID)
+	boxes = scan("Account", 12)
+	require.Len(t, boxes, 2)
+	assert.Equal(t, []string{"a1", "a3"}, []string{boxes[0].ID, boxes[1].ID})
+	assert.Equal(t, a1v2.Data["balance"], boxes[0].Data["balance"])
+	boxes = scan("Account", 100)
+	require.Len(t, boxes, 1)
+	assert.Equal(t, "a1", boxes[0].ID)
+
+	// the error of fn stops the scan
+	stop := errors.New("stop")
+	var count int
+	err := store.ScanEntities(ctx, store.GetEntityType("Account"), 100, func(box persistent.EntityBox) error {
+		count++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, count)
+
+	// cache entities are not persisted, so nothing is scanned
+	set(t, store, "Session", persistent.EntityBox{
+		Entity:         "Session",
+		ID:             "s1",
+		Data:           map[string]any{"id": "s1", "value": "v1"},
+		GenBlockNumber: 10,
+		GenBlockTime:   BlockTime(10),
+	})
+	assert.Nil(t, scan("Session", 100))
+}
+
+func RunCacheEntity(t *testing.T, store persistent.ChainStore) {
+	ctx := context.Background()
+	session := func(id string, bn uint64, value string) persistent.EntityBox {
+		return persistent.EntityBox{
+			Entity:         "Session",
+			ID:             id,
+			Data:           map[string]any{"id": id, "value": value},
+			GenBlockNumber: bn,
+			GenBlockTime:   BlockTime(bn),
+		}
+	}
+	set(t, store, "Session", session("s1", 10, "v1"), session("s2", 10, "v2"))
+	set(t, store, "Session", session("s1", 12, "v1.1"), deleted("Session", "s2", 12), session("s3", 12, "v3"))
+
+	box, fromCache, err := store.GetEntity(ctx, store.GetEntityType("Session"), "s1")
+	require.NoError(t, err)
+	assert.True(t, fromCache)
+	require.NotNil(t, box)
+	assert.Equal(t, "v1.1", box.Data["value"])
+	assert.Nil(t, get(t, store, "Session", "s2"))
+	assert.Equal(t, []string{"s1", "s3"}, list(t, store, "Session", 10))
+
+	// cache entities only have the latest version, the ones updated after the reorg block are dropped
+	require.NoError(t, store.Reorg(ctx, 11))
+	assert.Nil(t, get(t, store, "Session", "s1"))
+	assert.Equal(t, []string(nil), list(t, store, "Session", 10))
+}
+
+func RunGrowthAggregation(t *testing.T, store persistent.ChainStore) {
+	ctx := context.Background()
+	// block n is at minute n of 2024-01-01, so blocks 0..59 are in hour 0 and 60..119 are in hour 1
+	set(t, store, "Swap",
+		swap(1, 10, "p1", 1),
+		swap(2, 20, "p2", 2),
+		swap(3, 30, "p1", 3),
+		swap(4, 70, "p1", 4),
+	)
+	require.NoError(t, store.GrowthAggregation(ctx, BlockTime(80)))
+	reader, ok := store.(AggregationReader)
+	require.Truef(t, ok, "%T does not implement AggregationReader", store)
+	var agg *schema.Aggregation
+	for _, a := range MustParseSchema().ListAggregations() {
+		if a.Name == "SwapStats" {
+			agg = a
+		}
+	}
+	require.NotNil(t, agg)
+	type stat struct {
+		ID, Timestamp, Swaps          int64
+		Pool                          string
+		Volume, MaxAmount, LastAmount string
+		GenBlockNumber                uint64
+	}
+	readStats := func(interval string) (stats []stat) {
+		rows, err := reader.ListAggregationRows(ctx, agg, interval)
+		require.NoError(t, err)
+		for _, row := range rows {
+			stats = append(stats, stat{
+				ID:             row.Data["id"].(int64),
+				Timestamp:      row.Data["timestamp"].(int64),
+				Swaps:          row.Data["swaps"].(int64),
+				Pool:           row.Data["pool"].(string),
+				Volume:         row.Data["volume"].(decimal.Decimal).String(),
+				MaxAmount:      row.Data["maxAmount"].(decimal.Decimal).String(),
+				LastAmount:     row.Data["lastAmount"].(decimal.Decimal).String(),
+				GenBlockNumber: row.GenBlockNumber,
+			})
+		}
+		sort.SliceStable(stats, func(i, j int) bool {
+			return stats[i].Timestamp < stats[j].Timestamp ||
+				stats[i].Timestamp == stats[j].Timestamp && stats[i].Pool < stats[j].Pool
+		})
+		return stats
+	}
+	hour0, hour1 := BlockTime(0).UnixMicro(), BlockTime(60).UnixMicro()
+
+	// only the windows ended before the window of the current block time are aggregated
+	assert.Equal(t, []stat{
+		{ID: 3, Timestamp: hour0, Swaps: 2, Pool: "p1", Volume: "4", MaxAmount: "3", LastAmount: "6", GenBlockNumber: 30},
+		{ID: 2, Timestamp: hour0, Swaps: 1, Pool: "p2", Volume: "2", MaxAmount: "2", LastAmount: "4", GenBlockNumber: 20},
+	}, readStats("hour"))
+	assert.Empty(t, readStats("day"))
+
+	// the aggregated windows will not be aggregated again
+	require.NoError(t, store.GrowthAggregation(ctx, BlockTime(90)))
+	assert.Len(t, readStats("hour"), 2)
+	require.NoError(t, store.GrowthAggregation(ctx, BlockTime(120)))
+	hourStats := readStats("hour")
+	assert.Len(t, hourStats, 3)
+	assert.Equal(t, stat{
+		ID: 4, Timestamp: hour1, Swaps: 1, Pool: "p1", Volume: "4", MaxAmount: "4", LastAmount: "8", GenBlockNumber: 70,
+	}, hourStats[2])
+
+	// reorg drops the aggregated rows generated after the block, they will be aggregated again
+	require.NoError(t, store.Reorg(ctx, 60))
+	assert.Len(t, readStats("hour"), 2)
+	set(t, store, "Swap", swap(5, 65, "p2", 5))
+	require.NoError(t, store.GrowthAggregation(ctx, BlockTime(120)))
+	hourStats = readStats("hour")
+	require.Len(t, hourStats, 3)
+	assert.Equal(t, stat{
+		ID: 5, Timestamp: hour1, Swaps: 1, Pool: "p2", Volume: "5", MaxAmount: "5", LastAmount: "10", GenBlockNumber: 65,
+	}, hourStats[2])
+}
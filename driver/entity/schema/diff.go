@@ -0,0 +1,376 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/types"
+
+	"sentioxyz/sentio-core/common/utils"
+)
+
+var ErrIncompatibleChange = errors.New("incompatible schema change")
+
+type ChangeKind string
+
+const (
+	ChangeEntityAdded              ChangeKind = "EntityAdded"
+	ChangeEntityRemoved            ChangeKind = "EntityRemoved"
+	ChangeEntityKindChanged        ChangeKind = "EntityKindChanged"
+	ChangeImmutableChanged         ChangeKind = "ImmutableChanged"
+	ChangeSparseChanged            ChangeKind = "SparseChanged"
+	ChangeInterfaceAdded           ChangeKind = "InterfaceAdded"
+	ChangeInterfaceRemoved         ChangeKind = "InterfaceRemoved"
+	ChangeAggregationAdded         ChangeKind = "AggregationAdded"
+	ChangeAggregationRemoved       ChangeKind = "AggregationRemoved"
+	ChangeAggregationChanged       ChangeKind = "AggregationChanged"
+	ChangeFieldAdded               ChangeKind = "FieldAdded"
+	ChangeFieldRemoved             ChangeKind = "FieldRemoved"
+	ChangeFieldTypeChanged         ChangeKind = "FieldTypeChanged"
+	ChangeFieldDBTypeChanged       ChangeKind = "FieldDBTypeChanged"
+	ChangeIndexAdded               ChangeKind = "IndexAdded"
+	ChangeIndexRemoved             ChangeKind = "IndexRemoved"
+	ChangeIndexChanged             ChangeKind = "IndexChanged"
+	ChangeDerivedFromChanged       ChangeKind = "DerivedFromChanged"
+	ChangeDerivedFromTargetRemoved ChangeKind = "DerivedFromTargetRemoved"
+)
+
+// Change is one difference between two versions of the schema.
+// Compatible changes can be applied to the stored entities in place,
+// the incompatible ones need to rebuild all the entities from the beginning.
+type Change struct {
+	Kind       ChangeKind
+	Type       string // name of the entity, interface or aggregation
+	Field      string // empty if it is not a field change
+	Compatible bool
+	Detail     string
+}
+
+func (c Change) String() string {
+	target := c.Type
+	if c.Field != "" {
+		target += "." + c.Field
+	}
+	return fmt.Sprintf("%s %s (%s)%s", c.Kind, target, c.Detail,
+		utils.Select(c.Compatible, "", " is incompatible"))
+}
+
+type Changes []Change
+
+// Incompatible returns the changes can not be applied in place
+func (cs Changes) Incompatible() (result Changes) {
+	for _, c := range cs {
+		if !c.Compatible {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Check returns ErrIncompatibleChange with all the incompatible changes if there is any
+func (cs Changes) Check() error {
+	incompatible := cs.Incompatible()
+	if len(incompatible) == 0 {
+		return nil
+	}
+	details := make([]string, len(incompatible))
+	for i, c := range incompatible {
+		details[i] = c.String()
+	}
+	return fmt.Errorf("%w: %s", ErrIncompatibleChange, strings.Join(details, "; "))
+}
+
+// widenedScalars is the scalar types each scalar type can be widened to without losing any value
+var widenedScalars = map[string][]string{
+	"Int":    {"Int8", "Float", "BigInt", "BigDecimal"},
+	"Int8":   {"BigInt", "BigDecimal"},
+	"BigInt": {"BigDecimal"},
+}
+
+// Diff returns all the changes from schema pre to schema cur.
+// Compatible changes are the new entities, interfaces and aggregations, the new nullable fields,
+// the changes of @index, the widened field types (including relaxing non-null and appending enum values)
+// and the changes of the sparse flag. All the other changes are incompatible.
+func Diff(pre, cur *Schema) (changes Changes) {
+	// entities
+	preEntities := make(map[string]*Entity)
+	for _, e := range pre.ListEntities(true) {
+		preEntities[e.Name] = e
+	}
+	for _, ce := range cur.ListEntities(true) {
+		pe, has := preEntities[ce.Name]
+		if !has {
+			changes = append(changes, Change{Kind: ChangeEntityAdded, Type: ce.Name, Compatible: true, Detail: "new entity"})
+			continue
+		}
+		delete(preEntities, ce.Name)
+		changes = append(changes, diffEntity(pe, ce)...)
+	}
+	for _, name := range utils.GetOrderedMapKeys(preEntities) {
+		changes = append(changes, Change{Kind: ChangeEntityRemoved, Type: name, Detail: "stored entities will be lost"})
+	}
+	changes = append(changes, diffDerivedFromTargets(pre, cur)...)
+
+	// interfaces
+	preInterfaces := make(map[string]bool)
+	for _, iface := range pre.ListInterfaces() {
+		preInterfaces[iface.Name] = true
+	}
+	for _, iface := range cur.ListInterfaces() {
+		if !preInterfaces[iface.Name] {
+			changes = append(changes, Change{
+				Kind:       ChangeInterfaceAdded,
+				Type:       iface.Name,
+				Compatible: true,
+				Detail:     "new interface",
+			})
+		}
+		delete(preInterfaces, iface.Name)
+	}
+	for _, name := range utils.GetOrderedMapKeys(preInterfaces) {
+		changes = append(changes, Change{Kind: ChangeInterfaceRemoved, Type: name, Detail: "interface is removed"})
+	}
+
+	// aggregations, all the rows are aggregated by the definition, so any change is incompatible
+	preAggregations := make(map[string]*Aggregation)
+	for _, agg := range pre.ListAggregations() {
+		preAggregations[agg.Name] = agg
+	}
+	for _, ca := range cur.ListAggregations() {
+		pa, has := preAggregations[ca.Name]
+		if !has {
+			changes = append(changes, Change{
+				Kind:       ChangeAggregationAdded,
+				Type:       ca.Name,
+				Compatible: true,
+				Detail:     "new aggregation",
+			})
+			continue
+		}
+		delete(preAggregations, ca.Name)
+		if ps, cs := objectString(pa.ObjectTypeDefinition), objectString(ca.ObjectTypeDefinition); ps != cs {
+			changes = append(changes, Change{
+				Kind:   ChangeAggregationChanged,
+				Type:   ca.Name,
+				Detail: fmt.Sprintf("definition changed from %q to %q", ps, cs),
+			})
+		}
+	}
+	for _, name := range utils.GetOrderedMapKeys(preAggregations) {
+		changes = append(changes, Change{Kind: ChangeAggregationRemoved, Type: name, Detail: "aggregated rows will be lost"})
+	}
+	return changes
+}
+
+func entityKind(e *Entity) string {
+	switch {
+	case e.IsCache():
+		return "cache"
+	case e.IsTimeSeries():
+		return "timeseries"
+	default:
+		return "entity"
+	}
+}
+
+func diffEntity(pe, ce *Entity) (changes Changes) {
+	if pk, ck := entityKind(pe), entityKind(ce); pk != ck {
+		changes = append(changes, Change{
+			Kind:   ChangeEntityKindChanged,
+			Type:   ce.Name,
+			Detail: fmt.Sprintf("changed from %s to %s", pk, ck),
+		})
+	} else if pk != "cache" {
+		if pi, ci := pe.IsImmutable(), ce.IsImmutable(); pi != ci {
+			changes = append(changes, Change{
+				Kind:   ChangeImmutableChanged,
+				Type:   ce.Name,
+				Detail: fmt.Sprintf("immutable changed from %v to %v", pi, ci),
+			})
+		}
+		if ps, cs := pe.IsSparse(), ce.IsSparse(); ps != cs {
+			changes = append(changes, Change{
+				Kind:       ChangeSparseChanged,
+				Type:       ce.Name,
+				Compatible: true,
+				Detail:     fmt.Sprintf("sparse changed from %v to %v", ps, cs),
+			})
+		}
+	}
+	for _, cf := range ce.Fields {
+		pf := pe.GetFieldByName(cf.Name)
+		if pf == nil {
+			change := Change{Kind: ChangeFieldAdded, Type: ce.Name, Field: cf.Name}
+			switch {
+			case ce.GetForeignKeyFieldByName(cf.Name).IsReverseField():
+				change.Compatible, change.Detail = true, "new reverse foreign key field"
+			case isNullable(cf.Type):
+				change.Compatible, change.Detail = true, fmt.Sprintf("new nullable field %s", cf.Type)
+			default:
+				change.Detail = fmt.Sprintf("new non-null field %s has no value in stored entities", cf.Type)
+			}
+			changes = append(changes, change)
+			continue
+		}
+		changes = append(changes, diffField(ce.Name, pe, ce, pf, cf)...)
+	}
+	for _, pf := range pe.Fields {
+		if ce.GetFieldByName(pf.Name) != nil {
+			continue
+		}
+		change := Change{Kind: ChangeFieldRemoved, Type: ce.Name, Field: pf.Name}
+		if pe.GetForeignKeyFieldByName(pf.Name).IsReverseField() {
+			change.Compatible, change.Detail = true, "reverse foreign key field is removed"
+		} else {
+			change.Detail = "stored values will be lost"
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func diffField(typeName string, pe, ce *Entity, pf, cf *types.FieldDefinition) (changes Changes) {
+	pd := pe.GetForeignKeyFieldByName(pf.Name).GetReverseFieldName()
+	cd := ce.GetForeignKeyFieldByName(cf.Name).GetReverseFieldName()
+	if pd != cd {
+		changes = append(changes, Change{
+			Kind:   ChangeDerivedFromChanged,
+			Type:   typeName,
+			Field:  cf.Name,
+			Detail: fmt.Sprintf("@%s changed from %q to %q", DerivedFromDirectiveName, pd, cd),
+		})
+	}
+	if ps, cs := pf.Type.String(), cf.Type.String(); ps != cs {
+		changes = append(changes, Change{
+			Kind:       ChangeFieldTypeChanged,
+			Type:       typeName,
+			Field:      cf.Name,
+			Compatible: isWidened(pf.Type, cf.Type),
+			Detail:     fmt.Sprintf("type changed from %s to %s", ps, cs),
+		})
+	} else if !isWidened(pf.Type, cf.Type) {
+		// same type name but the definition of the enum changed
+		changes = append(changes, Change{
+			Kind:   ChangeFieldTypeChanged,
+			Type:   typeName,
+			Field:  cf.Name,
+			Detail: fmt.Sprintf("values of %s changed", cf.Type),
+		})
+	}
+	pdb, _, _ := GetFieldDBType(pf)
+	cdb, _, _ := GetFieldDBType(cf)
+	if !strings.EqualFold(pdb, cdb) {
+		changes = append(changes, Change{
+			Kind:   ChangeFieldDBTypeChanged,
+			Type:   typeName,
+			Field:  cf.Name,
+			Detail: fmt.Sprintf("@%s changed from %q to %q", DBTypeDirectiveName, pdb, cdb),
+		})
+	}
+	pi, pHas, _ := GetIndex(pf)
+	ci, cHas, _ := GetIndex(cf)
+	change := Change{Type: typeName, Field: cf.Name, Compatible: true}
+	switch {
+	case !pHas && cHas:
+		change.Kind, change.Detail = ChangeIndexAdded, fmt.Sprintf("new @%s(%s)", IndexDirectiveName, ci)
+	case pHas && !cHas:
+		change.Kind, change.Detail = ChangeIndexRemoved, fmt.Sprintf("@%s(%s) is removed", IndexDirectiveName, pi)
+	case pHas && pi != ci:
+		change.Kind, change.Detail = ChangeIndexChanged, fmt.Sprintf("@%s changed from %q to %q", IndexDirectiveName, pi, ci)
+	default:
+		return changes
+	}
+	return append(changes, change)
+}
+
+// diffDerivedFromTargets finds the fields referenced by @derivedFrom in pre which are removed in cur
+func diffDerivedFromTargets(pre, cur *Schema) (changes Changes) {
+	for _, pe := range pre.ListEntities(true) {
+		for _, fk := range pe.ListForeignKeyFields(false, true) {
+			target, name := fk.GetTarget(), fk.GetReverseFieldName()
+			if target == nil || target.GetFieldByName(name) == nil {
+				continue
+			}
+			ct := cur.GetEntityOrInterface(target.GetName())
+			if ct != nil && ct.GetFieldByName(name) != nil {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:  ChangeDerivedFromTargetRemoved,
+				Type:  target.GetName(),
+				Field: name,
+				Detail: fmt.Sprintf("referenced by @%s of %s.%s",
+					DerivedFromDirectiveName, pe.Name, fk.Name),
+			})
+		}
+	}
+	return changes
+}
+
+func isNullable(typ types.Type) bool {
+	_, nonNull := typ.(*types.NonNull)
+	return !nonNull
+}
+
+// isWidened returns true if all the values of type pre are also valid values of type cur
+func isWidened(pre, cur types.Type) bool {
+	if pnn, is := pre.(*types.NonNull); is {
+		pre = pnn.OfType
+		if cnn, is := cur.(*types.NonNull); is {
+			cur = cnn.OfType
+		}
+	} else if _, is := cur.(*types.NonNull); is {
+		// nullable => non-null
+		return false
+	}
+	switch pt := pre.(type) {
+	case *types.List:
+		ct, is := cur.(*types.List)
+		return is && isWidened(pt.OfType, ct.OfType)
+	case *types.ScalarTypeDefinition:
+		ct, is := cur.(*types.ScalarTypeDefinition)
+		return is && (pt.Name == ct.Name || utils.IndexOf(widenedScalars[pt.Name], ct.Name) >= 0)
+	case *types.EnumTypeDefinition:
+		// enum values are stored by position, so only appending is allowed
+		ct, is := cur.(*types.EnumTypeDefinition)
+		if !is || len(pt.EnumValuesDefinition) > len(ct.EnumValuesDefinition) {
+			return false
+		}
+		for i, v := range pt.EnumValuesDefinition {
+			if ct.EnumValuesDefinition[i].EnumValue != v.EnumValue {
+				return false
+			}
+		}
+		return true
+	case *types.ObjectTypeDefinition:
+		ct, is := cur.(*types.ObjectTypeDefinition)
+		return is && pt.Name == ct.Name
+	case *types.InterfaceTypeDefinition:
+		ct, is := cur.(*types.InterfaceTypeDefinition)
+		return is && pt.Name == ct.Name
+	default:
+		return pre.String() == cur.String()
+	}
+}
+
+func directivesString(ds types.DirectiveList) string {
+	var buf strings.Builder
+	for _, d := range ds {
+		buf.WriteString(" @" + d.Name.Name)
+		for _, arg := range d.Arguments {
+			buf.WriteString(fmt.Sprintf(" %s:%s", arg.Name.Name, arg.Value.String()))
+		}
+	}
+	return buf.String()
+}
+
+func objectString(obj *types.ObjectTypeDefinition) string {
+	var buf strings.Builder
+	buf.WriteString(obj.Name + directivesString(obj.Directives) + " {")
+	for _, f := range obj.Fields {
+		buf.WriteString(fmt.Sprintf(" %s:%s%s", f.Name, f.Type.String(), directivesString(f.Directives)))
+	}
+	buf.WriteString(" }")
+	return buf.String()
+}
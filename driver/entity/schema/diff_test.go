@@ -0,0 +1,184 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diff(t *testing.T) {
+	const preSchemaCnt = `
+type EntityA @entity {
+	id: ID!
+	propA: Int!
+	propB: String
+	propC: EnumA
+	propD: [Int8!]!
+	propE: Int! @index
+	foreignA: [EntityB!] @derivedFrom(field: "foreignB")
+}
+
+type EntityB @entity(immutable: true) {
+	id: ID!
+	foreignB: EntityA!
+}
+
+type EntityC @entity {
+	id: ID!
+	propA: String
+}
+
+enum EnumA {
+	AAA
+	BBB
+}
+
+type AggA @aggregation(intervals: ["hour"], source: "EntityD") {
+	id: Int8!
+	timestamp: Timestamp!
+	sum: BigDecimal! @aggregate(fn: "sum", arg: "value")
+}
+
+type EntityD @entity(timeseries: true) {
+	id: Int8!
+	timestamp: Timestamp!
+	value: BigDecimal!
+}
+`
+	pre, err := ParseSchema(preSchemaCnt)
+	assert.NoError(t, err)
+
+	t.Run("same", func(t *testing.T) {
+		cur, err := ParseSchema(preSchemaCnt)
+		assert.NoError(t, err)
+		changes := Diff(pre, cur)
+		assert.Empty(t, changes)
+		assert.NoError(t, changes.Check())
+	})
+
+	t.Run("compatible", func(t *testing.T) {
+		cur, err := ParseSchema(`
+type EntityA @entity {
+	id: ID!
+	propA: BigInt!
+	propB: String @index
+	propC: EnumA
+	propD: [BigDecimal]
+	propE: Int! @index(type: "bloom_filter")
+	propF: Boolean
+	foreignA: [EntityB!] @derivedFrom(field: "foreignB")
+}
+
+type EntityB @entity(immutable: true, sparse: false) {
+	id: ID!
+	foreignB: EntityA!
+}
+
+type EntityC @entity {
+	id: ID!
+	propA: String
+}
+
+type EntityE @entity {
+	id: ID!
+	value: Int!
+}
+
+enum EnumA {
+	AAA
+	BBB
+	CCC
+}
+
+type AggA @aggregation(intervals: ["hour"], source: "EntityD") {
+	id: Int8!
+	timestamp: Timestamp!
+	sum: BigDecimal! @aggregate(fn: "sum", arg: "value")
+}
+
+type EntityD @entity(timeseries: true) {
+	id: Int8!
+	timestamp: Timestamp!
+	value: BigDecimal!
+}
+`)
+		assert.NoError(t, err)
+		changes := Diff(pre, cur)
+		assert.NoError(t, changes.Check())
+		assert.Equal(t, Changes{
+			{Kind: ChangeFieldTypeChanged, Type: "EntityA", Field: "propA", Compatible: true,
+				Detail: "type changed from Int! to BigInt!"},
+			{Kind: ChangeIndexAdded, Type: "EntityA", Field: "propB", Compatible: true,
+				Detail: "new @index()"},
+			{Kind: ChangeFieldTypeChanged, Type: "EntityA", Field: "propD", Compatible: true,
+				Detail: "type changed from [Int8!]! to [BigDecimal]"},
+			{Kind: ChangeIndexChanged, Type: "EntityA", Field: "propE", Compatible: true,
+				Detail: `@index changed from "" to "bloom_filter"`},
+			{Kind: ChangeFieldAdded, Type: "EntityA", Field: "propF", Compatible: true,
+				Detail: "new nullable field Boolean"},
+			{Kind: ChangeSparseChanged, Type: "EntityB", Compatible: true,
+				Detail: "sparse changed from true to false"},
+			{Kind: ChangeEntityAdded, Type: "EntityE", Compatible: true, Detail: "new entity"},
+		}, changes)
+	})
+
+	t.Run("incompatible", func(t *testing.T) {
+		cur, err := ParseSchema(`
+type EntityA @entity {
+	id: ID!
+	propA: Int
+	propB: String!
+	propC: EnumA
+	propD: [Int!]!
+	propE: Int! @index
+	propF: Boolean!
+}
+
+type EntityB @entity {
+	id: ID!
+}
+
+enum EnumA {
+	BBB
+	AAA
+}
+
+type AggA @aggregation(intervals: ["hour", "day"], source: "EntityD") {
+	id: Int8!
+	timestamp: Timestamp!
+	sum: BigDecimal! @aggregate(fn: "sum", arg: "value")
+}
+
+type EntityD @entity(timeseries: true) {
+	id: Int8!
+	timestamp: Timestamp!
+	value: BigDecimal!
+}
+`)
+		assert.NoError(t, err)
+		changes := Diff(pre, cur)
+		assert.Equal(t, Changes{
+			{Kind: ChangeFieldTypeChanged, Type: "EntityA", Field: "propA", Compatible: true,
+				Detail: "type changed from Int! to Int"},
+			{Kind: ChangeFieldTypeChanged, Type: "EntityA", Field: "propB", Detail: "type changed from String to String!"},
+			{Kind: ChangeFieldTypeChanged, Type: "EntityA", Field: "propC", Detail: "values of EnumA changed"},
+			{Kind: ChangeFieldTypeChanged, Type: "EntityA", Field: "propD", Detail: "type changed from [Int8!]! to [Int!]!"},
+			{Kind: ChangeFieldAdded, Type: "EntityA", Field: "propF",
+				Detail: "new non-null field Boolean! has no value in stored entities"},
+			{Kind: ChangeFieldRemoved, Type: "EntityA", Field: "foreignA", Compatible: true,
+				Detail: "reverse foreign key field is removed"},
+			{Kind: ChangeImmutableChanged, Type: "EntityB", Detail: "immutable changed from true to false"},
+			{Kind: ChangeFieldRemoved, Type: "EntityB", Field: "foreignB", Detail: "stored values will be lost"},
+			{Kind: ChangeEntityRemoved, Type: "EntityC", Detail: "stored entities will be lost"},
+			{Kind: ChangeDerivedFromTargetRemoved, Type: "EntityB", Field: "foreignB",
+				Detail: "referenced by @derivedFrom of EntityA.foreignA"},
+			{Kind: ChangeAggregationChanged, Type: "AggA", Detail: `definition changed from ` +
+				`"AggA @aggregation intervals:[\"hour\"] source:\"EntityD\" { id:Int8! timestamp:Timestamp! sum:BigDecimal! @aggregate fn:\"sum\" arg:\"value\" }" to ` +
+				`"AggA @aggregation intervals:[\"hour\", \"day\"] source:\"EntityD\" { id:Int8! timestamp:Timestamp! sum:BigDecimal! @aggregate fn:\"sum\" arg:\"value\" }"`},
+		}, changes)
+		err = changes.Check()
+		assert.True(t, errors.Is(err, ErrIncompatibleChange))
+		assert.Len(t, changes.Incompatible(), 9)
+	})
+}
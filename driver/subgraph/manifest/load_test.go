@@ -191,6 +191,11 @@ repository: https://github.com/graphprotocol/graph-tooling/
 		}},
 		Features: nil,
 	}, mf)
+	assert.Equal(t, uint64(0), mf.GetHandlerGasLimit())
+
+	mf, err = load(bytes.NewReader([]byte("execution:\n  handlerGasLimit: 1000000\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000000), mf.GetHandlerGasLimit())
 }
 
 func Test_fullLoad(t *testing.T) {
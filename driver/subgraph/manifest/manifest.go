@@ -26,6 +26,22 @@ type Manifest struct {
 	DataSources []*DataSource `yaml:"dataSources"`
 	Templates   []*DataSourceTemplate
 	Features    []string // unsupported, will be ignored
+	// Execution is the sentio extension to config the execution of the handlers, nil means the driver defaults
+	Execution *ExecutionConfig `yaml:"execution,omitempty"`
+}
+
+// ExecutionConfig is how the driver executes the handlers of the subgraph
+type ExecutionConfig struct {
+	// HandlerGasLimit is the max gas a handler call can use, zero means the driver default
+	HandlerGasLimit uint64 `yaml:"handlerGasLimit"`
+}
+
+// GetHandlerGasLimit returns the handler gas limit declared in the manifest, zero if not declared
+func (mf *Manifest) GetHandlerGasLimit() uint64 {
+	if mf.Execution == nil {
+		return 0
+	}
+	return mf.Execution.HandlerGasLimit
 }
 
 func (mf *Manifest) TravelDataSourcesAndTemplates(fn func(*DataSource, string) error) error {
@@ -0,0 +1,100 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"sentioxyz/sentio-core/common/format"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/driver/timeseries"
+)
+
+const seriesKeyFieldName = "__series_key__"
+
+// ScanPoints calls fn with the points of the metric of the chain generated not after maxSlotNumber, ordered by the
+// series key, the timestamp and the values. The series key is built from the label values like "a=1,b=x", and the
+// row passed to fn has the chain ID, timestamp, slot number, label and value fields. It is used to compare the
+// outputs of two processors, the rows are streamed so the point count is not limited by the memory.
+func (s *Store) ScanPoints(
+	ctx context.Context,
+	meta timeseries.Meta,
+	chainID string,
+	maxSlotNumber uint64,
+	fn func(seriesKey string, row timeseries.Row) error,
+) error {
+	if meta.Type != timeseries.MetaTypeCounter && meta.Type != timeseries.MetaTypeGauge {
+		return fmt.Errorf("%s is not a metric", meta.GetFullName())
+	}
+	labelFields := meta.GetFieldsByRole(timeseries.FieldRoleSeriesLabel)
+	valueFields := meta.GetFieldsByRole(timeseries.FieldRoleSeriesValue)
+	chainIDField, timestampField, slotNumberField := meta.GetChainIDField(), meta.GetTimestampField(),
+		meta.GetSlotNumberField()
+
+	seriesKey := "''"
+	if len(labelFields) > 0 {
+		parts := make([]string, len(labelFields))
+		for i, field := range labelFields {
+			parts[i] = fmt.Sprintf("'%s%s=', toString(%s)", utils.Select(i == 0, "", ","), field.Name, quote(field.Name))
+		}
+		seriesKey = fmt.Sprintf("concat(%s)", strings.Join(parts, ", "))
+	}
+	scanFields := utils.MergeArr(
+		[]timeseries.Field{
+			{Name: seriesKeyFieldName, Type: timeseries.FieldTypeString},
+			chainIDField,
+			timestampField,
+			slotNumberField,
+		},
+		labelFields,
+		valueFields,
+	)
+	quoteFields := func(fields []timeseries.Field) string {
+		return strings.Join(utils.MapSliceNoError(fields, func(f timeseries.Field) string {
+			return quote(f.Name)
+		}), ", ")
+	}
+	sql := format.Format("SELECT %seriesKey#s AS %seriesKeyField#s, %fields#s "+
+		"FROM %tableName#s "+
+		"WHERE %chainIDField#s = ? AND %slotNumberField#s <= ? "+
+		"ORDER BY %seriesKeyField#s, %timestampField#s%values#s",
+		map[string]any{
+			"seriesKey":       seriesKey,
+			"seriesKeyField":  quote(seriesKeyFieldName),
+			"fields":          quoteFields(scanFields[1:]),
+			"tableName":       s.ctrl.FullLogicName(meta.GetTableName()),
+			"chainIDField":    quote(chainIDField.Name),
+			"slotNumberField": quote(slotNumberField.Name),
+			"timestampField":  quote(timestampField.Name),
+			// points of a series may share the same timestamp, also order by the values to make the order stable
+			"values": utils.Select(len(valueFields) > 0, ", "+quoteFields(valueFields), ""),
+		})
+	start := time.Now()
+	var count int
+	err := s.ctrl.Query(ctx, func(rows driver.Rows) error {
+		row, scanErr := scanRow(rows, scanFields)
+		if scanErr != nil {
+			return scanErr
+		}
+		count++
+		key := row[seriesKeyFieldName].(string)
+		delete(row, seriesKeyFieldName)
+		return fn(key, row)
+	}, sql, chainID, maxSlotNumber)
+	_, logger := log.FromContext(ctx,
+		"meta", meta.GetFullName(),
+		"chainID", chainID,
+		"maxSlotNumber", maxSlotNumber,
+		"count", count,
+		"used", time.Since(start).String())
+	if err != nil {
+		logger.Errore(err, "scan points failed")
+		return fmt.Errorf("scan points of %q failed: %w", meta.GetFullName(), err)
+	}
+	logger.Debug("scan points succeed")
+	return nil
+}
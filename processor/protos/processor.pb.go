@@ -258,6 +258,8 @@ const (
 	HandlerType_COSMOS_CALL       HandlerType = 14
 	HandlerType_STARKNET_EVENT    HandlerType = 15
 	HandlerType_SOL_BLOCK         HandlerType = 21
+	HandlerType_BTC_TRANSACTION   HandlerType = 22
+	HandlerType_BTC_BLOCK         HandlerType = 23
 )
 
 // Enum value maps for HandlerType.
@@ -282,6 +284,8 @@ var (
 		14: "COSMOS_CALL",
 		15: "STARKNET_EVENT",
 		21: "SOL_BLOCK",
+		22: "BTC_TRANSACTION",
+		23: "BTC_BLOCK",
 	}
 	HandlerType_value = map[string]int32{
 		"UNKNOWN":           0,
@@ -303,6 +307,8 @@ var (
 		"COSMOS_CALL":       14,
 		"STARKNET_EVENT":    15,
 		"SOL_BLOCK":         21,
+		"BTC_TRANSACTION":   22,
+		"BTC_BLOCK":         23,
 	}
 )
 
@@ -538,7 +544,7 @@ func (x ProcessStreamResponse_Partitions_Partition_SysValue) Number() protorefle
 
 // Deprecated: Use ProcessStreamResponse_Partitions_Partition_SysValue.Descriptor instead.
 func (ProcessStreamResponse_Partitions_Partition_SysValue) EnumDescriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{43, 0, 0, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{44, 0, 0, 0}
 }
 
 type EntityUpdateData_Operator int32
@@ -587,7 +593,7 @@ func (x EntityUpdateData_Operator) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use EntityUpdateData_Operator.Descriptor instead.
 func (EntityUpdateData_Operator) EnumDescriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{50, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{51, 0}
 }
 
 type DBRequest_DBOperator int32
@@ -663,7 +669,7 @@ func (x DBRequest_DBOperator) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use DBRequest_DBOperator.Descriptor instead.
 func (DBRequest_DBOperator) EnumDescriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 0}
 }
 
 type TimeseriesResult_TimeseriesType int32
@@ -712,7 +718,7 @@ func (x TimeseriesResult_TimeseriesType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use TimeseriesResult_TimeseriesType.Descriptor instead.
 func (TimeseriesResult_TimeseriesType) EnumDescriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{68, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{69, 0}
 }
 
 type ProjectConfig struct {
@@ -776,6 +782,7 @@ type ExecutionConfig struct {
 	SkipStartBlockValidation      bool                                          `protobuf:"varint,4,opt,name=skipStartBlockValidation,proto3" json:"skipStartBlockValidation,omitempty"`
 	RpcRetryTimes                 int32                                         `protobuf:"varint,5,opt,name=rpcRetryTimes,proto3" json:"rpcRetryTimes,omitempty"`
 	EthAbiDecoderConfig           *ExecutionConfig_DecoderWorkerConfig          `protobuf:"bytes,6,opt,name=ethAbiDecoderConfig,proto3,oneof" json:"ethAbiDecoderConfig,omitempty"`
+	BackfillSubRanges             int32                                         `protobuf:"varint,8,opt,name=backfill_sub_ranges,json=backfillSubRanges,proto3" json:"backfill_sub_ranges,omitempty"`
 	unknownFields                 protoimpl.UnknownFields
 	sizeCache                     protoimpl.SizeCache
 }
@@ -859,6 +866,13 @@ func (x *ExecutionConfig) GetEthAbiDecoderConfig() *ExecutionConfig_DecoderWorke
 	return nil
 }
 
+func (x *ExecutionConfig) GetBackfillSubRanges() int32 {
+	if x != nil {
+		return x.BackfillSubRanges
+	}
+	return 0
+}
+
 type ProcessConfigRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -1019,6 +1033,7 @@ type ContractConfig struct {
 	FuelReceiptConfigs        []*FuelReceiptHandlerConfig     `protobuf:"bytes,21,rep,name=fuel_receipt_configs,json=fuelReceiptConfigs,proto3" json:"fuel_receipt_configs,omitempty"`
 	CosmosLogConfigs          []*CosmosLogHandlerConfig       `protobuf:"bytes,16,rep,name=cosmos_log_configs,json=cosmosLogConfigs,proto3" json:"cosmos_log_configs,omitempty"`
 	StarknetEventConfigs      []*StarknetEventHandlerConfig   `protobuf:"bytes,17,rep,name=starknet_event_configs,json=starknetEventConfigs,proto3" json:"starknet_event_configs,omitempty"`
+	BtcTransactionConfigs     []*BTCTransactionHandlerConfig  `protobuf:"bytes,22,rep,name=btc_transaction_configs,json=btcTransactionConfigs,proto3" json:"btc_transaction_configs,omitempty"`
 	InstructionConfig         *InstructionHandlerConfig       `protobuf:"bytes,6,opt,name=instruction_config,json=instructionConfig,proto3" json:"instruction_config,omitempty"`
 	StartBlock                uint64                          `protobuf:"varint,4,opt,name=start_block,json=startBlock,proto3" json:"start_block,omitempty"`
 	EndBlock                  uint64                          `protobuf:"varint,5,opt,name=end_block,json=endBlock,proto3" json:"end_block,omitempty"`
@@ -1155,6 +1170,13 @@ func (x *ContractConfig) GetStarknetEventConfigs() []*StarknetEventHandlerConfig
 	return nil
 }
 
+func (x *ContractConfig) GetBtcTransactionConfigs() []*BTCTransactionHandlerConfig {
+	if x != nil {
+		return x.BtcTransactionConfigs
+	}
+	return nil
+}
+
 func (x *ContractConfig) GetInstructionConfig() *InstructionHandlerConfig {
 	if x != nil {
 		return x.InstructionConfig
@@ -3475,6 +3497,66 @@ func (x *FuelTransactionHandlerConfig) GetHandlerName() string {
 	return ""
 }
 
+type BTCTransactionHandlerConfig struct {
+	state         protoimpl.MessageState                `protogen:"open.v1"`
+	Filters       []*BTCTransactionHandlerConfig_Filter `protobuf:"bytes,1,rep,name=filters,proto3" json:"filters,omitempty"`
+	HandlerId     int32                                 `protobuf:"varint,2,opt,name=handler_id,json=handlerId,proto3" json:"handler_id,omitempty"`
+	HandlerName   string                                `protobuf:"bytes,3,opt,name=handler_name,json=handlerName,proto3" json:"handler_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BTCTransactionHandlerConfig) Reset() {
+	*x = BTCTransactionHandlerConfig{}
+	mi := &file_processor_protos_processor_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BTCTransactionHandlerConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BTCTransactionHandlerConfig) ProtoMessage() {}
+
+func (x *BTCTransactionHandlerConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_processor_protos_processor_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BTCTransactionHandlerConfig.ProtoReflect.Descriptor instead.
+func (*BTCTransactionHandlerConfig) Descriptor() ([]byte, []int) {
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *BTCTransactionHandlerConfig) GetFilters() []*BTCTransactionHandlerConfig_Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *BTCTransactionHandlerConfig) GetHandlerId() int32 {
+	if x != nil {
+		return x.HandlerId
+	}
+	return 0
+}
+
+func (x *BTCTransactionHandlerConfig) GetHandlerName() string {
+	if x != nil {
+		return x.HandlerName
+	}
+	return ""
+}
+
 type Topic struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Hashes        []string               `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
@@ -3484,7 +3566,7 @@ type Topic struct {
 
 func (x *Topic) Reset() {
 	*x = Topic{}
-	mi := &file_processor_protos_processor_proto_msgTypes[39]
+	mi := &file_processor_protos_processor_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3496,7 +3578,7 @@ func (x *Topic) String() string {
 func (*Topic) ProtoMessage() {}
 
 func (x *Topic) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[39]
+	mi := &file_processor_protos_processor_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3509,7 +3591,7 @@ func (x *Topic) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Topic.ProtoReflect.Descriptor instead.
 func (*Topic) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{39}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *Topic) GetHashes() []string {
@@ -3528,7 +3610,7 @@ type ProcessBindingsRequest struct {
 
 func (x *ProcessBindingsRequest) Reset() {
 	*x = ProcessBindingsRequest{}
-	mi := &file_processor_protos_processor_proto_msgTypes[40]
+	mi := &file_processor_protos_processor_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3540,7 +3622,7 @@ func (x *ProcessBindingsRequest) String() string {
 func (*ProcessBindingsRequest) ProtoMessage() {}
 
 func (x *ProcessBindingsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[40]
+	mi := &file_processor_protos_processor_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3553,7 +3635,7 @@ func (x *ProcessBindingsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessBindingsRequest.ProtoReflect.Descriptor instead.
 func (*ProcessBindingsRequest) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{40}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *ProcessBindingsRequest) GetBindings() []*DataBinding {
@@ -3572,7 +3654,7 @@ type ProcessBindingResponse struct {
 
 func (x *ProcessBindingResponse) Reset() {
 	*x = ProcessBindingResponse{}
-	mi := &file_processor_protos_processor_proto_msgTypes[41]
+	mi := &file_processor_protos_processor_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3584,7 +3666,7 @@ func (x *ProcessBindingResponse) String() string {
 func (*ProcessBindingResponse) ProtoMessage() {}
 
 func (x *ProcessBindingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[41]
+	mi := &file_processor_protos_processor_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3597,7 +3679,7 @@ func (x *ProcessBindingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessBindingResponse.ProtoReflect.Descriptor instead.
 func (*ProcessBindingResponse) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{41}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *ProcessBindingResponse) GetResult() *ProcessResult {
@@ -3622,7 +3704,7 @@ type ProcessStreamRequest struct {
 
 func (x *ProcessStreamRequest) Reset() {
 	*x = ProcessStreamRequest{}
-	mi := &file_processor_protos_processor_proto_msgTypes[42]
+	mi := &file_processor_protos_processor_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3634,7 +3716,7 @@ func (x *ProcessStreamRequest) String() string {
 func (*ProcessStreamRequest) ProtoMessage() {}
 
 func (x *ProcessStreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[42]
+	mi := &file_processor_protos_processor_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3647,7 +3729,7 @@ func (x *ProcessStreamRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessStreamRequest.ProtoReflect.Descriptor instead.
 func (*ProcessStreamRequest) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{42}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *ProcessStreamRequest) GetProcessId() int32 {
@@ -3728,7 +3810,7 @@ type ProcessStreamResponse struct {
 
 func (x *ProcessStreamResponse) Reset() {
 	*x = ProcessStreamResponse{}
-	mi := &file_processor_protos_processor_proto_msgTypes[43]
+	mi := &file_processor_protos_processor_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3740,7 +3822,7 @@ func (x *ProcessStreamResponse) String() string {
 func (*ProcessStreamResponse) ProtoMessage() {}
 
 func (x *ProcessStreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[43]
+	mi := &file_processor_protos_processor_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3753,7 +3835,7 @@ func (x *ProcessStreamResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessStreamResponse.ProtoReflect.Descriptor instead.
 func (*ProcessStreamResponse) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{43}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *ProcessStreamResponse) GetProcessId() int32 {
@@ -3836,7 +3918,7 @@ type ProcessStreamResponseV3 struct {
 
 func (x *ProcessStreamResponseV3) Reset() {
 	*x = ProcessStreamResponseV3{}
-	mi := &file_processor_protos_processor_proto_msgTypes[44]
+	mi := &file_processor_protos_processor_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3848,7 +3930,7 @@ func (x *ProcessStreamResponseV3) String() string {
 func (*ProcessStreamResponseV3) ProtoMessage() {}
 
 func (x *ProcessStreamResponseV3) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[44]
+	mi := &file_processor_protos_processor_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3861,7 +3943,7 @@ func (x *ProcessStreamResponseV3) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessStreamResponseV3.ProtoReflect.Descriptor instead.
 func (*ProcessStreamResponseV3) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{44}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *ProcessStreamResponseV3) GetProcessId() int32 {
@@ -3971,7 +4053,7 @@ type PreprocessStreamRequest struct {
 
 func (x *PreprocessStreamRequest) Reset() {
 	*x = PreprocessStreamRequest{}
-	mi := &file_processor_protos_processor_proto_msgTypes[45]
+	mi := &file_processor_protos_processor_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3983,7 +4065,7 @@ func (x *PreprocessStreamRequest) String() string {
 func (*PreprocessStreamRequest) ProtoMessage() {}
 
 func (x *PreprocessStreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[45]
+	mi := &file_processor_protos_processor_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3996,7 +4078,7 @@ func (x *PreprocessStreamRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreprocessStreamRequest.ProtoReflect.Descriptor instead.
 func (*PreprocessStreamRequest) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{45}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *PreprocessStreamRequest) GetProcessId() int32 {
@@ -4057,7 +4139,7 @@ type PreprocessStreamResponse struct {
 
 func (x *PreprocessStreamResponse) Reset() {
 	*x = PreprocessStreamResponse{}
-	mi := &file_processor_protos_processor_proto_msgTypes[46]
+	mi := &file_processor_protos_processor_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4069,7 +4151,7 @@ func (x *PreprocessStreamResponse) String() string {
 func (*PreprocessStreamResponse) ProtoMessage() {}
 
 func (x *PreprocessStreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[46]
+	mi := &file_processor_protos_processor_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4082,7 +4164,7 @@ func (x *PreprocessStreamResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreprocessStreamResponse.ProtoReflect.Descriptor instead.
 func (*PreprocessStreamResponse) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{46}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *PreprocessStreamResponse) GetProcessId() int32 {
@@ -4114,7 +4196,7 @@ type DBResponse struct {
 
 func (x *DBResponse) Reset() {
 	*x = DBResponse{}
-	mi := &file_processor_protos_processor_proto_msgTypes[47]
+	mi := &file_processor_protos_processor_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4126,7 +4208,7 @@ func (x *DBResponse) String() string {
 func (*DBResponse) ProtoMessage() {}
 
 func (x *DBResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[47]
+	mi := &file_processor_protos_processor_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4139,7 +4221,7 @@ func (x *DBResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBResponse.ProtoReflect.Descriptor instead.
 func (*DBResponse) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{47}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *DBResponse) GetOpId() uint64 {
@@ -4210,7 +4292,7 @@ type Entity struct {
 
 func (x *Entity) Reset() {
 	*x = Entity{}
-	mi := &file_processor_protos_processor_proto_msgTypes[48]
+	mi := &file_processor_protos_processor_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4222,7 +4304,7 @@ func (x *Entity) String() string {
 func (*Entity) ProtoMessage() {}
 
 func (x *Entity) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[48]
+	mi := &file_processor_protos_processor_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4235,7 +4317,7 @@ func (x *Entity) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Entity.ProtoReflect.Descriptor instead.
 func (*Entity) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{48}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *Entity) GetEntity() string {
@@ -4282,7 +4364,7 @@ type EntityList struct {
 
 func (x *EntityList) Reset() {
 	*x = EntityList{}
-	mi := &file_processor_protos_processor_proto_msgTypes[49]
+	mi := &file_processor_protos_processor_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4294,7 +4376,7 @@ func (x *EntityList) String() string {
 func (*EntityList) ProtoMessage() {}
 
 func (x *EntityList) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[49]
+	mi := &file_processor_protos_processor_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4307,7 +4389,7 @@ func (x *EntityList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntityList.ProtoReflect.Descriptor instead.
 func (*EntityList) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{49}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *EntityList) GetEntities() []*Entity {
@@ -4326,7 +4408,7 @@ type EntityUpdateData struct {
 
 func (x *EntityUpdateData) Reset() {
 	*x = EntityUpdateData{}
-	mi := &file_processor_protos_processor_proto_msgTypes[50]
+	mi := &file_processor_protos_processor_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4338,7 +4420,7 @@ func (x *EntityUpdateData) String() string {
 func (*EntityUpdateData) ProtoMessage() {}
 
 func (x *EntityUpdateData) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[50]
+	mi := &file_processor_protos_processor_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4351,7 +4433,7 @@ func (x *EntityUpdateData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntityUpdateData.ProtoReflect.Descriptor instead.
 func (*EntityUpdateData) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{50}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *EntityUpdateData) GetFields() map[string]*EntityUpdateData_FieldValue {
@@ -4371,7 +4453,7 @@ type TPLRequest struct {
 
 func (x *TPLRequest) Reset() {
 	*x = TPLRequest{}
-	mi := &file_processor_protos_processor_proto_msgTypes[51]
+	mi := &file_processor_protos_processor_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4383,7 +4465,7 @@ func (x *TPLRequest) String() string {
 func (*TPLRequest) ProtoMessage() {}
 
 func (x *TPLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[51]
+	mi := &file_processor_protos_processor_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4396,7 +4478,7 @@ func (x *TPLRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TPLRequest.ProtoReflect.Descriptor instead.
 func (*TPLRequest) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{51}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *TPLRequest) GetTemplates() []*TemplateInstance {
@@ -4422,7 +4504,7 @@ type TSRequest struct {
 
 func (x *TSRequest) Reset() {
 	*x = TSRequest{}
-	mi := &file_processor_protos_processor_proto_msgTypes[52]
+	mi := &file_processor_protos_processor_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4434,7 +4516,7 @@ func (x *TSRequest) String() string {
 func (*TSRequest) ProtoMessage() {}
 
 func (x *TSRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[52]
+	mi := &file_processor_protos_processor_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4447,7 +4529,7 @@ func (x *TSRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TSRequest.ProtoReflect.Descriptor instead.
 func (*TSRequest) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{52}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53}
 }
 
 func (x *TSRequest) GetData() []*TimeseriesResult {
@@ -4474,7 +4556,7 @@ type DBRequest struct {
 
 func (x *DBRequest) Reset() {
 	*x = DBRequest{}
-	mi := &file_processor_protos_processor_proto_msgTypes[53]
+	mi := &file_processor_protos_processor_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4486,7 +4568,7 @@ func (x *DBRequest) String() string {
 func (*DBRequest) ProtoMessage() {}
 
 func (x *DBRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[53]
+	mi := &file_processor_protos_processor_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4499,7 +4581,7 @@ func (x *DBRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest.ProtoReflect.Descriptor instead.
 func (*DBRequest) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *DBRequest) GetOpId() uint64 {
@@ -4617,6 +4699,8 @@ type Data struct {
 	//	*Data_CosmosCall_
 	//	*Data_StarknetEvents
 	//	*Data_SolBlock_
+	//	*Data_BtcTransaction
+	//	*Data_BtcBlock
 	Value         isData_Value `protobuf_oneof:"value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -4624,7 +4708,7 @@ type Data struct {
 
 func (x *Data) Reset() {
 	*x = Data{}
-	mi := &file_processor_protos_processor_proto_msgTypes[54]
+	mi := &file_processor_protos_processor_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4636,7 +4720,7 @@ func (x *Data) String() string {
 func (*Data) ProtoMessage() {}
 
 func (x *Data) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[54]
+	mi := &file_processor_protos_processor_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4649,7 +4733,7 @@ func (x *Data) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data.ProtoReflect.Descriptor instead.
 func (*Data) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55}
 }
 
 func (x *Data) GetValue() isData_Value {
@@ -4821,6 +4905,24 @@ func (x *Data) GetSolBlock() *Data_SolBlock {
 	return nil
 }
 
+func (x *Data) GetBtcTransaction() *Data_BTCTransaction {
+	if x != nil {
+		if x, ok := x.Value.(*Data_BtcTransaction); ok {
+			return x.BtcTransaction
+		}
+	}
+	return nil
+}
+
+func (x *Data) GetBtcBlock() *Data_BTCBlock {
+	if x != nil {
+		if x, ok := x.Value.(*Data_BtcBlock); ok {
+			return x.BtcBlock
+		}
+	}
+	return nil
+}
+
 type isData_Value interface {
 	isData_Value()
 }
@@ -4897,6 +4999,14 @@ type Data_SolBlock_ struct {
 	SolBlock *Data_SolBlock `protobuf:"bytes,22,opt,name=sol_block,json=solBlock,proto3,oneof"`
 }
 
+type Data_BtcTransaction struct {
+	BtcTransaction *Data_BTCTransaction `protobuf:"bytes,23,opt,name=btc_transaction,json=btcTransaction,proto3,oneof"`
+}
+
+type Data_BtcBlock struct {
+	BtcBlock *Data_BTCBlock `protobuf:"bytes,24,opt,name=btc_block,json=btcBlock,proto3,oneof"`
+}
+
 func (*Data_EthLog_) isData_Value() {}
 
 func (*Data_EthBlock_) isData_Value() {}
@@ -4933,6 +5043,10 @@ func (*Data_StarknetEvents) isData_Value() {}
 
 func (*Data_SolBlock_) isData_Value() {}
 
+func (*Data_BtcTransaction) isData_Value() {}
+
+func (*Data_BtcBlock) isData_Value() {}
+
 type DataBinding struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Data          *Data                  `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
@@ -4945,7 +5059,7 @@ type DataBinding struct {
 
 func (x *DataBinding) Reset() {
 	*x = DataBinding{}
-	mi := &file_processor_protos_processor_proto_msgTypes[55]
+	mi := &file_processor_protos_processor_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4957,7 +5071,7 @@ func (x *DataBinding) String() string {
 func (*DataBinding) ProtoMessage() {}
 
 func (x *DataBinding) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[55]
+	mi := &file_processor_protos_processor_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4970,7 +5084,7 @@ func (x *DataBinding) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DataBinding.ProtoReflect.Descriptor instead.
 func (*DataBinding) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *DataBinding) GetData() *Data {
@@ -5012,7 +5126,7 @@ type StateResult struct {
 
 func (x *StateResult) Reset() {
 	*x = StateResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[56]
+	mi := &file_processor_protos_processor_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5024,7 +5138,7 @@ func (x *StateResult) String() string {
 func (*StateResult) ProtoMessage() {}
 
 func (x *StateResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[56]
+	mi := &file_processor_protos_processor_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5037,7 +5151,7 @@ func (x *StateResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StateResult.ProtoReflect.Descriptor instead.
 func (*StateResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{56}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{57}
 }
 
 // Deprecated: Marked as deprecated in processor/protos/processor.proto.
@@ -5072,7 +5186,7 @@ type ProcessResult struct {
 
 func (x *ProcessResult) Reset() {
 	*x = ProcessResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[57]
+	mi := &file_processor_protos_processor_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5084,7 +5198,7 @@ func (x *ProcessResult) String() string {
 func (*ProcessResult) ProtoMessage() {}
 
 func (x *ProcessResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[57]
+	mi := &file_processor_protos_processor_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5097,7 +5211,7 @@ func (x *ProcessResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessResult.ProtoReflect.Descriptor instead.
 func (*ProcessResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{57}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{58}
 }
 
 // Deprecated: Marked as deprecated in processor/protos/processor.proto.
@@ -5155,7 +5269,7 @@ type EthCallParam struct {
 
 func (x *EthCallParam) Reset() {
 	*x = EthCallParam{}
-	mi := &file_processor_protos_processor_proto_msgTypes[58]
+	mi := &file_processor_protos_processor_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5167,7 +5281,7 @@ func (x *EthCallParam) String() string {
 func (*EthCallParam) ProtoMessage() {}
 
 func (x *EthCallParam) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[58]
+	mi := &file_processor_protos_processor_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5180,7 +5294,7 @@ func (x *EthCallParam) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EthCallParam.ProtoReflect.Descriptor instead.
 func (*EthCallParam) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{58}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *EthCallParam) GetContext() *EthCallContext {
@@ -5208,7 +5322,7 @@ type EthCallContext struct {
 
 func (x *EthCallContext) Reset() {
 	*x = EthCallContext{}
-	mi := &file_processor_protos_processor_proto_msgTypes[59]
+	mi := &file_processor_protos_processor_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5220,7 +5334,7 @@ func (x *EthCallContext) String() string {
 func (*EthCallContext) ProtoMessage() {}
 
 func (x *EthCallContext) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[59]
+	mi := &file_processor_protos_processor_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5233,7 +5347,7 @@ func (x *EthCallContext) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EthCallContext.ProtoReflect.Descriptor instead.
 func (*EthCallContext) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{59}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *EthCallContext) GetChainId() string {
@@ -5266,7 +5380,7 @@ type PreprocessResult struct {
 
 func (x *PreprocessResult) Reset() {
 	*x = PreprocessResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[60]
+	mi := &file_processor_protos_processor_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5278,7 +5392,7 @@ func (x *PreprocessResult) String() string {
 func (*PreprocessResult) ProtoMessage() {}
 
 func (x *PreprocessResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[60]
+	mi := &file_processor_protos_processor_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5291,7 +5405,7 @@ func (x *PreprocessResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreprocessResult.ProtoReflect.Descriptor instead.
 func (*PreprocessResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{60}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *PreprocessResult) GetEthCallParams() []*EthCallParam {
@@ -5310,7 +5424,7 @@ type PreparedData struct {
 
 func (x *PreparedData) Reset() {
 	*x = PreparedData{}
-	mi := &file_processor_protos_processor_proto_msgTypes[61]
+	mi := &file_processor_protos_processor_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5322,7 +5436,7 @@ func (x *PreparedData) String() string {
 func (*PreparedData) ProtoMessage() {}
 
 func (x *PreparedData) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[61]
+	mi := &file_processor_protos_processor_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5335,7 +5449,7 @@ func (x *PreparedData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PreparedData.ProtoReflect.Descriptor instead.
 func (*PreparedData) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{61}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *PreparedData) GetEthCallResults() map[string]string {
@@ -5362,7 +5476,7 @@ type RecordMetaData struct {
 
 func (x *RecordMetaData) Reset() {
 	*x = RecordMetaData{}
-	mi := &file_processor_protos_processor_proto_msgTypes[62]
+	mi := &file_processor_protos_processor_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5374,7 +5488,7 @@ func (x *RecordMetaData) String() string {
 func (*RecordMetaData) ProtoMessage() {}
 
 func (x *RecordMetaData) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[62]
+	mi := &file_processor_protos_processor_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5387,7 +5501,7 @@ func (x *RecordMetaData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RecordMetaData.ProtoReflect.Descriptor instead.
 func (*RecordMetaData) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{62}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *RecordMetaData) GetAddress() string {
@@ -5467,7 +5581,7 @@ type MetricValue struct {
 
 func (x *MetricValue) Reset() {
 	*x = MetricValue{}
-	mi := &file_processor_protos_processor_proto_msgTypes[63]
+	mi := &file_processor_protos_processor_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5479,7 +5593,7 @@ func (x *MetricValue) String() string {
 func (*MetricValue) ProtoMessage() {}
 
 func (x *MetricValue) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[63]
+	mi := &file_processor_protos_processor_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5492,7 +5606,7 @@ func (x *MetricValue) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricValue.ProtoReflect.Descriptor instead.
 func (*MetricValue) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{63}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *MetricValue) GetValue() isMetricValue_Value {
@@ -5560,7 +5674,7 @@ type RuntimeInfo struct {
 
 func (x *RuntimeInfo) Reset() {
 	*x = RuntimeInfo{}
-	mi := &file_processor_protos_processor_proto_msgTypes[64]
+	mi := &file_processor_protos_processor_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5572,7 +5686,7 @@ func (x *RuntimeInfo) String() string {
 func (*RuntimeInfo) ProtoMessage() {}
 
 func (x *RuntimeInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[64]
+	mi := &file_processor_protos_processor_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5585,7 +5699,7 @@ func (x *RuntimeInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RuntimeInfo.ProtoReflect.Descriptor instead.
 func (*RuntimeInfo) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{64}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *RuntimeInfo) GetFrom() HandlerType {
@@ -5607,7 +5721,7 @@ type GaugeResult struct {
 
 func (x *GaugeResult) Reset() {
 	*x = GaugeResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[65]
+	mi := &file_processor_protos_processor_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5619,7 +5733,7 @@ func (x *GaugeResult) String() string {
 func (*GaugeResult) ProtoMessage() {}
 
 func (x *GaugeResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[65]
+	mi := &file_processor_protos_processor_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5632,7 +5746,7 @@ func (x *GaugeResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GaugeResult.ProtoReflect.Descriptor instead.
 func (*GaugeResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{65}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{66}
 }
 
 func (x *GaugeResult) GetMetadata() *RecordMetaData {
@@ -5669,7 +5783,7 @@ type CounterResult struct {
 
 func (x *CounterResult) Reset() {
 	*x = CounterResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[66]
+	mi := &file_processor_protos_processor_proto_msgTypes[67]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5681,7 +5795,7 @@ func (x *CounterResult) String() string {
 func (*CounterResult) ProtoMessage() {}
 
 func (x *CounterResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[66]
+	mi := &file_processor_protos_processor_proto_msgTypes[67]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5694,7 +5808,7 @@ func (x *CounterResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CounterResult.ProtoReflect.Descriptor instead.
 func (*CounterResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{66}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{67}
 }
 
 func (x *CounterResult) GetMetadata() *RecordMetaData {
@@ -5741,7 +5855,7 @@ type EventTrackingResult struct {
 
 func (x *EventTrackingResult) Reset() {
 	*x = EventTrackingResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[67]
+	mi := &file_processor_protos_processor_proto_msgTypes[68]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5753,7 +5867,7 @@ func (x *EventTrackingResult) String() string {
 func (*EventTrackingResult) ProtoMessage() {}
 
 func (x *EventTrackingResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[67]
+	mi := &file_processor_protos_processor_proto_msgTypes[68]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5766,7 +5880,7 @@ func (x *EventTrackingResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventTrackingResult.ProtoReflect.Descriptor instead.
 func (*EventTrackingResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{67}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{68}
 }
 
 func (x *EventTrackingResult) GetMetadata() *RecordMetaData {
@@ -5830,7 +5944,7 @@ type TimeseriesResult struct {
 
 func (x *TimeseriesResult) Reset() {
 	*x = TimeseriesResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[68]
+	mi := &file_processor_protos_processor_proto_msgTypes[69]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5842,7 +5956,7 @@ func (x *TimeseriesResult) String() string {
 func (*TimeseriesResult) ProtoMessage() {}
 
 func (x *TimeseriesResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[68]
+	mi := &file_processor_protos_processor_proto_msgTypes[69]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5855,7 +5969,7 @@ func (x *TimeseriesResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TimeseriesResult.ProtoReflect.Descriptor instead.
 func (*TimeseriesResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{68}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{69}
 }
 
 func (x *TimeseriesResult) GetMetadata() *RecordMetaData {
@@ -5897,7 +6011,7 @@ type ExportResult struct {
 
 func (x *ExportResult) Reset() {
 	*x = ExportResult{}
-	mi := &file_processor_protos_processor_proto_msgTypes[69]
+	mi := &file_processor_protos_processor_proto_msgTypes[70]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5909,7 +6023,7 @@ func (x *ExportResult) String() string {
 func (*ExportResult) ProtoMessage() {}
 
 func (x *ExportResult) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[69]
+	mi := &file_processor_protos_processor_proto_msgTypes[70]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5922,7 +6036,7 @@ func (x *ExportResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExportResult.ProtoReflect.Descriptor instead.
 func (*ExportResult) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{69}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{70}
 }
 
 func (x *ExportResult) GetMetadata() *RecordMetaData {
@@ -5957,7 +6071,7 @@ type ExecutionConfig_DecoderWorkerConfig struct {
 
 func (x *ExecutionConfig_DecoderWorkerConfig) Reset() {
 	*x = ExecutionConfig_DecoderWorkerConfig{}
-	mi := &file_processor_protos_processor_proto_msgTypes[70]
+	mi := &file_processor_protos_processor_proto_msgTypes[71]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5969,7 +6083,7 @@ func (x *ExecutionConfig_DecoderWorkerConfig) String() string {
 func (*ExecutionConfig_DecoderWorkerConfig) ProtoMessage() {}
 
 func (x *ExecutionConfig_DecoderWorkerConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[70]
+	mi := &file_processor_protos_processor_proto_msgTypes[71]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6015,7 +6129,7 @@ type EventLogConfig_StructFieldType struct {
 
 func (x *EventLogConfig_StructFieldType) Reset() {
 	*x = EventLogConfig_StructFieldType{}
-	mi := &file_processor_protos_processor_proto_msgTypes[71]
+	mi := &file_processor_protos_processor_proto_msgTypes[72]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6027,7 +6141,7 @@ func (x *EventLogConfig_StructFieldType) String() string {
 func (*EventLogConfig_StructFieldType) ProtoMessage() {}
 
 func (x *EventLogConfig_StructFieldType) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[71]
+	mi := &file_processor_protos_processor_proto_msgTypes[72]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6065,7 +6179,7 @@ type EventLogConfig_Field struct {
 
 func (x *EventLogConfig_Field) Reset() {
 	*x = EventLogConfig_Field{}
-	mi := &file_processor_protos_processor_proto_msgTypes[72]
+	mi := &file_processor_protos_processor_proto_msgTypes[73]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6077,7 +6191,7 @@ func (x *EventLogConfig_Field) String() string {
 func (*EventLogConfig_Field) ProtoMessage() {}
 
 func (x *EventLogConfig_Field) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[72]
+	mi := &file_processor_protos_processor_proto_msgTypes[73]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6167,7 +6281,7 @@ type FuelAssetHandlerConfig_AssetFilter struct {
 
 func (x *FuelAssetHandlerConfig_AssetFilter) Reset() {
 	*x = FuelAssetHandlerConfig_AssetFilter{}
-	mi := &file_processor_protos_processor_proto_msgTypes[73]
+	mi := &file_processor_protos_processor_proto_msgTypes[74]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6179,7 +6293,7 @@ func (x *FuelAssetHandlerConfig_AssetFilter) String() string {
 func (*FuelAssetHandlerConfig_AssetFilter) ProtoMessage() {}
 
 func (x *FuelAssetHandlerConfig_AssetFilter) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[73]
+	mi := &file_processor_protos_processor_proto_msgTypes[74]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6227,7 +6341,7 @@ type FuelReceiptHandlerConfig_Transfer struct {
 
 func (x *FuelReceiptHandlerConfig_Transfer) Reset() {
 	*x = FuelReceiptHandlerConfig_Transfer{}
-	mi := &file_processor_protos_processor_proto_msgTypes[74]
+	mi := &file_processor_protos_processor_proto_msgTypes[75]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6239,7 +6353,7 @@ func (x *FuelReceiptHandlerConfig_Transfer) String() string {
 func (*FuelReceiptHandlerConfig_Transfer) ProtoMessage() {}
 
 func (x *FuelReceiptHandlerConfig_Transfer) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[74]
+	mi := &file_processor_protos_processor_proto_msgTypes[75]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6285,7 +6399,7 @@ type FuelReceiptHandlerConfig_Log struct {
 
 func (x *FuelReceiptHandlerConfig_Log) Reset() {
 	*x = FuelReceiptHandlerConfig_Log{}
-	mi := &file_processor_protos_processor_proto_msgTypes[75]
+	mi := &file_processor_protos_processor_proto_msgTypes[76]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6297,7 +6411,7 @@ func (x *FuelReceiptHandlerConfig_Log) String() string {
 func (*FuelReceiptHandlerConfig_Log) ProtoMessage() {}
 
 func (x *FuelReceiptHandlerConfig_Log) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[75]
+	mi := &file_processor_protos_processor_proto_msgTypes[76]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6330,7 +6444,7 @@ type MoveCallFilter_FromAndToAddress struct {
 
 func (x *MoveCallFilter_FromAndToAddress) Reset() {
 	*x = MoveCallFilter_FromAndToAddress{}
-	mi := &file_processor_protos_processor_proto_msgTypes[76]
+	mi := &file_processor_protos_processor_proto_msgTypes[77]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6342,7 +6456,7 @@ func (x *MoveCallFilter_FromAndToAddress) String() string {
 func (*MoveCallFilter_FromAndToAddress) ProtoMessage() {}
 
 func (x *MoveCallFilter_FromAndToAddress) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[76]
+	mi := &file_processor_protos_processor_proto_msgTypes[77]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6372,6 +6486,58 @@ func (x *MoveCallFilter_FromAndToAddress) GetTo() string {
 	return ""
 }
 
+type BTCTransactionHandlerConfig_Filter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       *string                `protobuf:"bytes,1,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	Script        *string                `protobuf:"bytes,2,opt,name=script,proto3,oneof" json:"script,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BTCTransactionHandlerConfig_Filter) Reset() {
+	*x = BTCTransactionHandlerConfig_Filter{}
+	mi := &file_processor_protos_processor_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BTCTransactionHandlerConfig_Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BTCTransactionHandlerConfig_Filter) ProtoMessage() {}
+
+func (x *BTCTransactionHandlerConfig_Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_processor_protos_processor_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BTCTransactionHandlerConfig_Filter.ProtoReflect.Descriptor instead.
+func (*BTCTransactionHandlerConfig_Filter) Descriptor() ([]byte, []int) {
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{39, 0}
+}
+
+func (x *BTCTransactionHandlerConfig_Filter) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *BTCTransactionHandlerConfig_Filter) GetScript() string {
+	if x != nil && x.Script != nil {
+		return *x.Script
+	}
+	return ""
+}
+
 type ProcessStreamResponse_Partitions struct {
 	state         protoimpl.MessageState                                `protogen:"open.v1"`
 	Partitions    map[int32]*ProcessStreamResponse_Partitions_Partition `protobuf:"bytes,1,rep,name=partitions,proto3" json:"partitions,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
@@ -6381,7 +6547,7 @@ type ProcessStreamResponse_Partitions struct {
 
 func (x *ProcessStreamResponse_Partitions) Reset() {
 	*x = ProcessStreamResponse_Partitions{}
-	mi := &file_processor_protos_processor_proto_msgTypes[77]
+	mi := &file_processor_protos_processor_proto_msgTypes[79]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6393,7 +6559,7 @@ func (x *ProcessStreamResponse_Partitions) String() string {
 func (*ProcessStreamResponse_Partitions) ProtoMessage() {}
 
 func (x *ProcessStreamResponse_Partitions) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[77]
+	mi := &file_processor_protos_processor_proto_msgTypes[79]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6406,7 +6572,7 @@ func (x *ProcessStreamResponse_Partitions) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessStreamResponse_Partitions.ProtoReflect.Descriptor instead.
 func (*ProcessStreamResponse_Partitions) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{43, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{44, 0}
 }
 
 func (x *ProcessStreamResponse_Partitions) GetPartitions() map[int32]*ProcessStreamResponse_Partitions_Partition {
@@ -6429,7 +6595,7 @@ type ProcessStreamResponse_Partitions_Partition struct {
 
 func (x *ProcessStreamResponse_Partitions_Partition) Reset() {
 	*x = ProcessStreamResponse_Partitions_Partition{}
-	mi := &file_processor_protos_processor_proto_msgTypes[78]
+	mi := &file_processor_protos_processor_proto_msgTypes[80]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6441,7 +6607,7 @@ func (x *ProcessStreamResponse_Partitions_Partition) String() string {
 func (*ProcessStreamResponse_Partitions_Partition) ProtoMessage() {}
 
 func (x *ProcessStreamResponse_Partitions_Partition) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[78]
+	mi := &file_processor_protos_processor_proto_msgTypes[80]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6454,7 +6620,7 @@ func (x *ProcessStreamResponse_Partitions_Partition) ProtoReflect() protoreflect
 
 // Deprecated: Use ProcessStreamResponse_Partitions_Partition.ProtoReflect.Descriptor instead.
 func (*ProcessStreamResponse_Partitions_Partition) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{43, 0, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{44, 0, 0}
 }
 
 func (x *ProcessStreamResponse_Partitions_Partition) GetValue() isProcessStreamResponse_Partitions_Partition_Value {
@@ -6509,7 +6675,7 @@ type PreprocessStreamRequest_DataBindings struct {
 
 func (x *PreprocessStreamRequest_DataBindings) Reset() {
 	*x = PreprocessStreamRequest_DataBindings{}
-	mi := &file_processor_protos_processor_proto_msgTypes[80]
+	mi := &file_processor_protos_processor_proto_msgTypes[82]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6521,7 +6687,7 @@ func (x *PreprocessStreamRequest_DataBindings) String() string {
 func (*PreprocessStreamRequest_DataBindings) ProtoMessage() {}
 
 func (x *PreprocessStreamRequest_DataBindings) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[80]
+	mi := &file_processor_protos_processor_proto_msgTypes[82]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6534,7 +6700,7 @@ func (x *PreprocessStreamRequest_DataBindings) ProtoReflect() protoreflect.Messa
 
 // Deprecated: Use PreprocessStreamRequest_DataBindings.ProtoReflect.Descriptor instead.
 func (*PreprocessStreamRequest_DataBindings) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{45, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{46, 0}
 }
 
 func (x *PreprocessStreamRequest_DataBindings) GetBindings() []*DataBinding {
@@ -6554,7 +6720,7 @@ type EntityUpdateData_FieldValue struct {
 
 func (x *EntityUpdateData_FieldValue) Reset() {
 	*x = EntityUpdateData_FieldValue{}
-	mi := &file_processor_protos_processor_proto_msgTypes[81]
+	mi := &file_processor_protos_processor_proto_msgTypes[83]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6566,7 +6732,7 @@ func (x *EntityUpdateData_FieldValue) String() string {
 func (*EntityUpdateData_FieldValue) ProtoMessage() {}
 
 func (x *EntityUpdateData_FieldValue) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[81]
+	mi := &file_processor_protos_processor_proto_msgTypes[83]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6579,7 +6745,7 @@ func (x *EntityUpdateData_FieldValue) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntityUpdateData_FieldValue.ProtoReflect.Descriptor instead.
 func (*EntityUpdateData_FieldValue) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{50, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{51, 0}
 }
 
 func (x *EntityUpdateData_FieldValue) GetValue() *protos.RichValue {
@@ -6606,7 +6772,7 @@ type DBRequest_DBGet struct {
 
 func (x *DBRequest_DBGet) Reset() {
 	*x = DBRequest_DBGet{}
-	mi := &file_processor_protos_processor_proto_msgTypes[83]
+	mi := &file_processor_protos_processor_proto_msgTypes[85]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6618,7 +6784,7 @@ func (x *DBRequest_DBGet) String() string {
 func (*DBRequest_DBGet) ProtoMessage() {}
 
 func (x *DBRequest_DBGet) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[83]
+	mi := &file_processor_protos_processor_proto_msgTypes[85]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6631,7 +6797,7 @@ func (x *DBRequest_DBGet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest_DBGet.ProtoReflect.Descriptor instead.
 func (*DBRequest_DBGet) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 0}
 }
 
 func (x *DBRequest_DBGet) GetEntity() string {
@@ -6660,7 +6826,7 @@ type DBRequest_DBList struct {
 
 func (x *DBRequest_DBList) Reset() {
 	*x = DBRequest_DBList{}
-	mi := &file_processor_protos_processor_proto_msgTypes[84]
+	mi := &file_processor_protos_processor_proto_msgTypes[86]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6672,7 +6838,7 @@ func (x *DBRequest_DBList) String() string {
 func (*DBRequest_DBList) ProtoMessage() {}
 
 func (x *DBRequest_DBList) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[84]
+	mi := &file_processor_protos_processor_proto_msgTypes[86]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6685,7 +6851,7 @@ func (x *DBRequest_DBList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest_DBList.ProtoReflect.Descriptor instead.
 func (*DBRequest_DBList) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 1}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 1}
 }
 
 func (x *DBRequest_DBList) GetEntity() string {
@@ -6727,7 +6893,7 @@ type DBRequest_DBUpsert struct {
 
 func (x *DBRequest_DBUpsert) Reset() {
 	*x = DBRequest_DBUpsert{}
-	mi := &file_processor_protos_processor_proto_msgTypes[85]
+	mi := &file_processor_protos_processor_proto_msgTypes[87]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6739,7 +6905,7 @@ func (x *DBRequest_DBUpsert) String() string {
 func (*DBRequest_DBUpsert) ProtoMessage() {}
 
 func (x *DBRequest_DBUpsert) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[85]
+	mi := &file_processor_protos_processor_proto_msgTypes[87]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6752,7 +6918,7 @@ func (x *DBRequest_DBUpsert) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest_DBUpsert.ProtoReflect.Descriptor instead.
 func (*DBRequest_DBUpsert) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 2}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 2}
 }
 
 func (x *DBRequest_DBUpsert) GetEntity() []string {
@@ -6787,7 +6953,7 @@ type DBRequest_DBUpdate struct {
 
 func (x *DBRequest_DBUpdate) Reset() {
 	*x = DBRequest_DBUpdate{}
-	mi := &file_processor_protos_processor_proto_msgTypes[86]
+	mi := &file_processor_protos_processor_proto_msgTypes[88]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6799,7 +6965,7 @@ func (x *DBRequest_DBUpdate) String() string {
 func (*DBRequest_DBUpdate) ProtoMessage() {}
 
 func (x *DBRequest_DBUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[86]
+	mi := &file_processor_protos_processor_proto_msgTypes[88]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6812,7 +6978,7 @@ func (x *DBRequest_DBUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest_DBUpdate.ProtoReflect.Descriptor instead.
 func (*DBRequest_DBUpdate) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 3}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 3}
 }
 
 func (x *DBRequest_DBUpdate) GetEntity() []string {
@@ -6846,7 +7012,7 @@ type DBRequest_DBDelete struct {
 
 func (x *DBRequest_DBDelete) Reset() {
 	*x = DBRequest_DBDelete{}
-	mi := &file_processor_protos_processor_proto_msgTypes[87]
+	mi := &file_processor_protos_processor_proto_msgTypes[89]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6858,7 +7024,7 @@ func (x *DBRequest_DBDelete) String() string {
 func (*DBRequest_DBDelete) ProtoMessage() {}
 
 func (x *DBRequest_DBDelete) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[87]
+	mi := &file_processor_protos_processor_proto_msgTypes[89]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6871,7 +7037,7 @@ func (x *DBRequest_DBDelete) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest_DBDelete.ProtoReflect.Descriptor instead.
 func (*DBRequest_DBDelete) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 4}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 4}
 }
 
 func (x *DBRequest_DBDelete) GetEntity() []string {
@@ -6899,7 +7065,7 @@ type DBRequest_DBFilter struct {
 
 func (x *DBRequest_DBFilter) Reset() {
 	*x = DBRequest_DBFilter{}
-	mi := &file_processor_protos_processor_proto_msgTypes[88]
+	mi := &file_processor_protos_processor_proto_msgTypes[90]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6911,7 +7077,7 @@ func (x *DBRequest_DBFilter) String() string {
 func (*DBRequest_DBFilter) ProtoMessage() {}
 
 func (x *DBRequest_DBFilter) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[88]
+	mi := &file_processor_protos_processor_proto_msgTypes[90]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6924,7 +7090,7 @@ func (x *DBRequest_DBFilter) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DBRequest_DBFilter.ProtoReflect.Descriptor instead.
 func (*DBRequest_DBFilter) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{53, 5}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 5}
 }
 
 func (x *DBRequest_DBFilter) GetField() string {
@@ -6961,7 +7127,7 @@ type Data_EthLog struct {
 
 func (x *Data_EthLog) Reset() {
 	*x = Data_EthLog{}
-	mi := &file_processor_protos_processor_proto_msgTypes[89]
+	mi := &file_processor_protos_processor_proto_msgTypes[91]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6973,7 +7139,7 @@ func (x *Data_EthLog) String() string {
 func (*Data_EthLog) ProtoMessage() {}
 
 func (x *Data_EthLog) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[89]
+	mi := &file_processor_protos_processor_proto_msgTypes[91]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6986,7 +7152,7 @@ func (x *Data_EthLog) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_EthLog.ProtoReflect.Descriptor instead.
 func (*Data_EthLog) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 0}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 0}
 }
 
 func (x *Data_EthLog) GetTimestamp() *timestamppb.Timestamp {
@@ -7033,7 +7199,7 @@ type Data_EthBlock struct {
 
 func (x *Data_EthBlock) Reset() {
 	*x = Data_EthBlock{}
-	mi := &file_processor_protos_processor_proto_msgTypes[90]
+	mi := &file_processor_protos_processor_proto_msgTypes[92]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7045,7 +7211,7 @@ func (x *Data_EthBlock) String() string {
 func (*Data_EthBlock) ProtoMessage() {}
 
 func (x *Data_EthBlock) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[90]
+	mi := &file_processor_protos_processor_proto_msgTypes[92]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7058,7 +7224,7 @@ func (x *Data_EthBlock) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_EthBlock.ProtoReflect.Descriptor instead.
 func (*Data_EthBlock) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 1}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 1}
 }
 
 func (x *Data_EthBlock) GetRawBlock() string {
@@ -7081,7 +7247,7 @@ type Data_EthTransaction struct {
 
 func (x *Data_EthTransaction) Reset() {
 	*x = Data_EthTransaction{}
-	mi := &file_processor_protos_processor_proto_msgTypes[91]
+	mi := &file_processor_protos_processor_proto_msgTypes[93]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7093,7 +7259,7 @@ func (x *Data_EthTransaction) String() string {
 func (*Data_EthTransaction) ProtoMessage() {}
 
 func (x *Data_EthTransaction) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[91]
+	mi := &file_processor_protos_processor_proto_msgTypes[93]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7106,7 +7272,7 @@ func (x *Data_EthTransaction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_EthTransaction.ProtoReflect.Descriptor instead.
 func (*Data_EthTransaction) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 2}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 2}
 }
 
 func (x *Data_EthTransaction) GetTimestamp() *timestamppb.Timestamp {
@@ -7157,7 +7323,7 @@ type Data_EthTrace struct {
 
 func (x *Data_EthTrace) Reset() {
 	*x = Data_EthTrace{}
-	mi := &file_processor_protos_processor_proto_msgTypes[92]
+	mi := &file_processor_protos_processor_proto_msgTypes[94]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7169,7 +7335,7 @@ func (x *Data_EthTrace) String() string {
 func (*Data_EthTrace) ProtoMessage() {}
 
 func (x *Data_EthTrace) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[92]
+	mi := &file_processor_protos_processor_proto_msgTypes[94]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7182,7 +7348,7 @@ func (x *Data_EthTrace) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_EthTrace.ProtoReflect.Descriptor instead.
 func (*Data_EthTrace) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 3}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 3}
 }
 
 func (x *Data_EthTrace) GetTimestamp() *timestamppb.Timestamp {
@@ -7234,7 +7400,7 @@ type Data_SolInstruction struct {
 
 func (x *Data_SolInstruction) Reset() {
 	*x = Data_SolInstruction{}
-	mi := &file_processor_protos_processor_proto_msgTypes[93]
+	mi := &file_processor_protos_processor_proto_msgTypes[95]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7246,7 +7412,7 @@ func (x *Data_SolInstruction) String() string {
 func (*Data_SolInstruction) ProtoMessage() {}
 
 func (x *Data_SolInstruction) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[93]
+	mi := &file_processor_protos_processor_proto_msgTypes[95]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7259,7 +7425,7 @@ func (x *Data_SolInstruction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_SolInstruction.ProtoReflect.Descriptor instead.
 func (*Data_SolInstruction) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 4}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 4}
 }
 
 func (x *Data_SolInstruction) GetInstructionData() string {
@@ -7315,7 +7481,7 @@ type Data_SolBlock struct {
 
 func (x *Data_SolBlock) Reset() {
 	*x = Data_SolBlock{}
-	mi := &file_processor_protos_processor_proto_msgTypes[94]
+	mi := &file_processor_protos_processor_proto_msgTypes[96]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7327,7 +7493,7 @@ func (x *Data_SolBlock) String() string {
 func (*Data_SolBlock) ProtoMessage() {}
 
 func (x *Data_SolBlock) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[94]
+	mi := &file_processor_protos_processor_proto_msgTypes[96]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7340,7 +7506,7 @@ func (x *Data_SolBlock) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_SolBlock.ProtoReflect.Descriptor instead.
 func (*Data_SolBlock) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 5}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 5}
 }
 
 func (x *Data_SolBlock) GetRawBlock() string {
@@ -7375,7 +7541,7 @@ type Data_AptEvent struct {
 
 func (x *Data_AptEvent) Reset() {
 	*x = Data_AptEvent{}
-	mi := &file_processor_protos_processor_proto_msgTypes[95]
+	mi := &file_processor_protos_processor_proto_msgTypes[97]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7387,7 +7553,7 @@ func (x *Data_AptEvent) String() string {
 func (*Data_AptEvent) ProtoMessage() {}
 
 func (x *Data_AptEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[95]
+	mi := &file_processor_protos_processor_proto_msgTypes[97]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7400,7 +7566,7 @@ func (x *Data_AptEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_AptEvent.ProtoReflect.Descriptor instead.
 func (*Data_AptEvent) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 6}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 6}
 }
 
 func (x *Data_AptEvent) GetRawEvent() string {
@@ -7433,7 +7599,7 @@ type Data_AptCall struct {
 
 func (x *Data_AptCall) Reset() {
 	*x = Data_AptCall{}
-	mi := &file_processor_protos_processor_proto_msgTypes[96]
+	mi := &file_processor_protos_processor_proto_msgTypes[98]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7445,7 +7611,7 @@ func (x *Data_AptCall) String() string {
 func (*Data_AptCall) ProtoMessage() {}
 
 func (x *Data_AptCall) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[96]
+	mi := &file_processor_protos_processor_proto_msgTypes[98]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7458,7 +7624,7 @@ func (x *Data_AptCall) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_AptCall.ProtoReflect.Descriptor instead.
 func (*Data_AptCall) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 7}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 7}
 }
 
 func (x *Data_AptCall) GetRawTransaction() string {
@@ -7479,7 +7645,7 @@ type Data_AptResource struct {
 
 func (x *Data_AptResource) Reset() {
 	*x = Data_AptResource{}
-	mi := &file_processor_protos_processor_proto_msgTypes[97]
+	mi := &file_processor_protos_processor_proto_msgTypes[99]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7491,7 +7657,7 @@ func (x *Data_AptResource) String() string {
 func (*Data_AptResource) ProtoMessage() {}
 
 func (x *Data_AptResource) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[97]
+	mi := &file_processor_protos_processor_proto_msgTypes[99]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7504,7 +7670,7 @@ func (x *Data_AptResource) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_AptResource.ProtoReflect.Descriptor instead.
 func (*Data_AptResource) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 8}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 8}
 }
 
 func (x *Data_AptResource) GetVersion() int64 {
@@ -7541,7 +7707,7 @@ type Data_SuiEvent struct {
 
 func (x *Data_SuiEvent) Reset() {
 	*x = Data_SuiEvent{}
-	mi := &file_processor_protos_processor_proto_msgTypes[98]
+	mi := &file_processor_protos_processor_proto_msgTypes[100]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7553,7 +7719,7 @@ func (x *Data_SuiEvent) String() string {
 func (*Data_SuiEvent) ProtoMessage() {}
 
 func (x *Data_SuiEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[98]
+	mi := &file_processor_protos_processor_proto_msgTypes[100]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7566,7 +7732,7 @@ func (x *Data_SuiEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_SuiEvent.ProtoReflect.Descriptor instead.
 func (*Data_SuiEvent) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 9}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 9}
 }
 
 func (x *Data_SuiEvent) GetRawEvent() string {
@@ -7615,7 +7781,7 @@ type Data_SuiCall struct {
 
 func (x *Data_SuiCall) Reset() {
 	*x = Data_SuiCall{}
-	mi := &file_processor_protos_processor_proto_msgTypes[99]
+	mi := &file_processor_protos_processor_proto_msgTypes[101]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7627,7 +7793,7 @@ func (x *Data_SuiCall) String() string {
 func (*Data_SuiCall) ProtoMessage() {}
 
 func (x *Data_SuiCall) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[99]
+	mi := &file_processor_protos_processor_proto_msgTypes[101]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7640,7 +7806,7 @@ func (x *Data_SuiCall) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_SuiCall.ProtoReflect.Descriptor instead.
 func (*Data_SuiCall) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 10}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 10}
 }
 
 func (x *Data_SuiCall) GetRawTransaction() string {
@@ -7679,7 +7845,7 @@ type Data_SuiObject struct {
 
 func (x *Data_SuiObject) Reset() {
 	*x = Data_SuiObject{}
-	mi := &file_processor_protos_processor_proto_msgTypes[100]
+	mi := &file_processor_protos_processor_proto_msgTypes[102]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7691,7 +7857,7 @@ func (x *Data_SuiObject) String() string {
 func (*Data_SuiObject) ProtoMessage() {}
 
 func (x *Data_SuiObject) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[100]
+	mi := &file_processor_protos_processor_proto_msgTypes[102]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7704,7 +7870,7 @@ func (x *Data_SuiObject) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_SuiObject.ProtoReflect.Descriptor instead.
 func (*Data_SuiObject) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 11}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 11}
 }
 
 func (x *Data_SuiObject) GetRawObjects() []string {
@@ -7768,7 +7934,7 @@ type Data_SuiObjectChange struct {
 
 func (x *Data_SuiObjectChange) Reset() {
 	*x = Data_SuiObjectChange{}
-	mi := &file_processor_protos_processor_proto_msgTypes[101]
+	mi := &file_processor_protos_processor_proto_msgTypes[103]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7780,7 +7946,7 @@ func (x *Data_SuiObjectChange) String() string {
 func (*Data_SuiObjectChange) ProtoMessage() {}
 
 func (x *Data_SuiObjectChange) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[101]
+	mi := &file_processor_protos_processor_proto_msgTypes[103]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7793,7 +7959,7 @@ func (x *Data_SuiObjectChange) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_SuiObjectChange.ProtoReflect.Descriptor instead.
 func (*Data_SuiObjectChange) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 12}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 12}
 }
 
 func (x *Data_SuiObjectChange) GetRawChanges() []string {
@@ -7835,7 +8001,7 @@ type Data_FuelReceipt struct {
 
 func (x *Data_FuelReceipt) Reset() {
 	*x = Data_FuelReceipt{}
-	mi := &file_processor_protos_processor_proto_msgTypes[102]
+	mi := &file_processor_protos_processor_proto_msgTypes[104]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7847,7 +8013,7 @@ func (x *Data_FuelReceipt) String() string {
 func (*Data_FuelReceipt) ProtoMessage() {}
 
 func (x *Data_FuelReceipt) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[102]
+	mi := &file_processor_protos_processor_proto_msgTypes[104]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7860,7 +8026,7 @@ func (x *Data_FuelReceipt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_FuelReceipt.ProtoReflect.Descriptor instead.
 func (*Data_FuelReceipt) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 13}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 13}
 }
 
 func (x *Data_FuelReceipt) GetTransaction() *structpb.Struct {
@@ -7894,7 +8060,7 @@ type Data_FuelTransaction struct {
 
 func (x *Data_FuelTransaction) Reset() {
 	*x = Data_FuelTransaction{}
-	mi := &file_processor_protos_processor_proto_msgTypes[103]
+	mi := &file_processor_protos_processor_proto_msgTypes[105]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7906,7 +8072,7 @@ func (x *Data_FuelTransaction) String() string {
 func (*Data_FuelTransaction) ProtoMessage() {}
 
 func (x *Data_FuelTransaction) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[103]
+	mi := &file_processor_protos_processor_proto_msgTypes[105]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7919,7 +8085,7 @@ func (x *Data_FuelTransaction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_FuelTransaction.ProtoReflect.Descriptor instead.
 func (*Data_FuelTransaction) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 14}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 14}
 }
 
 func (x *Data_FuelTransaction) GetTransaction() *structpb.Struct {
@@ -7946,7 +8112,7 @@ type Data_FuelBlock struct {
 
 func (x *Data_FuelBlock) Reset() {
 	*x = Data_FuelBlock{}
-	mi := &file_processor_protos_processor_proto_msgTypes[104]
+	mi := &file_processor_protos_processor_proto_msgTypes[106]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7958,7 +8124,7 @@ func (x *Data_FuelBlock) String() string {
 func (*Data_FuelBlock) ProtoMessage() {}
 
 func (x *Data_FuelBlock) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[104]
+	mi := &file_processor_protos_processor_proto_msgTypes[106]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7971,7 +8137,7 @@ func (x *Data_FuelBlock) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_FuelBlock.ProtoReflect.Descriptor instead.
 func (*Data_FuelBlock) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 15}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 15}
 }
 
 func (x *Data_FuelBlock) GetBlock() *structpb.Struct {
@@ -7998,7 +8164,7 @@ type Data_CosmosCall struct {
 
 func (x *Data_CosmosCall) Reset() {
 	*x = Data_CosmosCall{}
-	mi := &file_processor_protos_processor_proto_msgTypes[105]
+	mi := &file_processor_protos_processor_proto_msgTypes[107]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -8010,7 +8176,7 @@ func (x *Data_CosmosCall) String() string {
 func (*Data_CosmosCall) ProtoMessage() {}
 
 func (x *Data_CosmosCall) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[105]
+	mi := &file_processor_protos_processor_proto_msgTypes[107]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8023,7 +8189,7 @@ func (x *Data_CosmosCall) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_CosmosCall.ProtoReflect.Descriptor instead.
 func (*Data_CosmosCall) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 16}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 16}
 }
 
 func (x *Data_CosmosCall) GetTransaction() *structpb.Struct {
@@ -8050,7 +8216,7 @@ type Data_StarknetEvent struct {
 
 func (x *Data_StarknetEvent) Reset() {
 	*x = Data_StarknetEvent{}
-	mi := &file_processor_protos_processor_proto_msgTypes[106]
+	mi := &file_processor_protos_processor_proto_msgTypes[108]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -8062,7 +8228,7 @@ func (x *Data_StarknetEvent) String() string {
 func (*Data_StarknetEvent) ProtoMessage() {}
 
 func (x *Data_StarknetEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_processor_protos_processor_proto_msgTypes[106]
+	mi := &file_processor_protos_processor_proto_msgTypes[108]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -8075,7 +8241,7 @@ func (x *Data_StarknetEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Data_StarknetEvent.ProtoReflect.Descriptor instead.
 func (*Data_StarknetEvent) Descriptor() ([]byte, []int) {
-	return file_processor_protos_processor_proto_rawDescGZIP(), []int{54, 17}
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 17}
 }
 
 func (x *Data_StarknetEvent) GetResult() *structpb.Struct {
@@ -8092,6 +8258,110 @@ func (x *Data_StarknetEvent) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
+type Data_BTCTransaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transaction   *structpb.Struct       `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_BTCTransaction) Reset() {
+	*x = Data_BTCTransaction{}
+	mi := &file_processor_protos_processor_proto_msgTypes[109]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_BTCTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_BTCTransaction) ProtoMessage() {}
+
+func (x *Data_BTCTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_processor_protos_processor_proto_msgTypes[109]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_BTCTransaction.ProtoReflect.Descriptor instead.
+func (*Data_BTCTransaction) Descriptor() ([]byte, []int) {
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 18}
+}
+
+func (x *Data_BTCTransaction) GetTransaction() *structpb.Struct {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *Data_BTCTransaction) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type Data_BTCBlock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Block         *structpb.Struct       `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Data_BTCBlock) Reset() {
+	*x = Data_BTCBlock{}
+	mi := &file_processor_protos_processor_proto_msgTypes[110]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Data_BTCBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data_BTCBlock) ProtoMessage() {}
+
+func (x *Data_BTCBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_processor_protos_processor_proto_msgTypes[110]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data_BTCBlock.ProtoReflect.Descriptor instead.
+func (*Data_BTCBlock) Descriptor() ([]byte, []int) {
+	return file_processor_protos_processor_proto_rawDescGZIP(), []int{55, 19}
+}
+
+func (x *Data_BTCBlock) GetBlock() *structpb.Struct {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *Data_BTCBlock) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
 var File_processor_protos_processor_proto protoreflect.FileDescriptor
 
 const file_processor_protos_processor_proto_rawDesc = "" +
@@ -8099,7 +8369,7 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	" processor/protos/processor.proto\x12\tprocessor\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\"service/common/protos/common.proto\"=\n" +
 	"\rProjectConfig\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
-	"\aversion\x18\x03 \x01(\tR\aversion\"\x94\x06\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"\xc4\x06\n" +
 	"\x0fExecutionConfig\x12\x1e\n" +
 	"\n" +
 	"sequential\x18\x01 \x01(\bR\n" +
@@ -8109,7 +8379,8 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\x15processBindingTimeout\x18\x03 \x01(\x05R\x15processBindingTimeout\x12:\n" +
 	"\x18skipStartBlockValidation\x18\x04 \x01(\bR\x18skipStartBlockValidation\x12$\n" +
 	"\rrpcRetryTimes\x18\x05 \x01(\x05R\rrpcRetryTimes\x12e\n" +
-	"\x13ethAbiDecoderConfig\x18\x06 \x01(\v2..processor.ExecutionConfig.DecoderWorkerConfigH\x00R\x13ethAbiDecoderConfig\x88\x01\x01\x1a\xc0\x01\n" +
+	"\x13ethAbiDecoderConfig\x18\x06 \x01(\v2..processor.ExecutionConfig.DecoderWorkerConfigH\x00R\x13ethAbiDecoderConfig\x88\x01\x01\x12.\n" +
+	"\x13backfill_sub_ranges\x18\b \x01(\x05R\x11backfillSubRanges\x1a\xc0\x01\n" +
 	"\x13DecoderWorkerConfig\x12\x18\n" +
 	"\aenabled\x18\x01 \x01(\bR\aenabled\x12&\n" +
 	"\fworker_count\x18\x02 \x01(\x05H\x00R\vworkerCount\x88\x01\x01\x12:\n" +
@@ -8131,8 +8402,7 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\x0eexport_configs\x18\a \x03(\v2\x17.processor.ExportConfigR\rexportConfigs\x12E\n" +
 	"\x11event_log_configs\x18\b \x03(\v2\x19.processor.EventLogConfigR\x0feventLogConfigs\x126\n" +
 	"\tdb_schema\x18\n" +
-	" \x01(\v2\x19.processor.DataBaseSchemaR\bdbSchema\"\xa6\n" +
-	"\n" +
+	" \x01(\v2\x19.processor.DataBaseSchemaR\bdbSchema\"\x86\v\n" +
 	"\x0eContractConfig\x123\n" +
 	"\bcontract\x18\x01 \x01(\v2\x17.processor.ContractInfoR\bcontract\x12F\n" +
 	"\x10interval_configs\x18\v \x03(\v2\x1b.processor.OnIntervalConfigR\x0fintervalConfigs\x12S\n" +
@@ -8149,7 +8419,8 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\rasset_configs\x18\x0e \x03(\v2!.processor.FuelAssetHandlerConfigR\fassetConfigs\x12U\n" +
 	"\x14fuel_receipt_configs\x18\x15 \x03(\v2#.processor.FuelReceiptHandlerConfigR\x12fuelReceiptConfigs\x12O\n" +
 	"\x12cosmos_log_configs\x18\x10 \x03(\v2!.processor.CosmosLogHandlerConfigR\x10cosmosLogConfigs\x12[\n" +
-	"\x16starknet_event_configs\x18\x11 \x03(\v2%.processor.StarknetEventHandlerConfigR\x14starknetEventConfigs\x12R\n" +
+	"\x16starknet_event_configs\x18\x11 \x03(\v2%.processor.StarknetEventHandlerConfigR\x14starknetEventConfigs\x12^\n" +
+	"\x17btc_transaction_configs\x18\x16 \x03(\v2&.processor.BTCTransactionHandlerConfigR\x15btcTransactionConfigs\x12R\n" +
 	"\x12instruction_config\x18\x06 \x01(\v2#.processor.InstructionHandlerConfigR\x11instructionConfig\x12\x1f\n" +
 	"\vstart_block\x18\x04 \x01(\x04R\n" +
 	"startBlock\x12\x1b\n" +
@@ -8380,7 +8651,18 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\x1cFuelTransactionHandlerConfig\x12\x1d\n" +
 	"\n" +
 	"handler_id\x18\x01 \x01(\x05R\thandlerId\x12!\n" +
-	"\fhandler_name\x18\x02 \x01(\tR\vhandlerName\"\x1f\n" +
+	"\fhandler_name\x18\x02 \x01(\tR\vhandlerName\"\x85\x02\n" +
+	"\x1bBTCTransactionHandlerConfig\x12G\n" +
+	"\afilters\x18\x01 \x03(\v2-.processor.BTCTransactionHandlerConfig.FilterR\afilters\x12\x1d\n" +
+	"\n" +
+	"handler_id\x18\x02 \x01(\x05R\thandlerId\x12!\n" +
+	"\fhandler_name\x18\x03 \x01(\tR\vhandlerName\x1a[\n" +
+	"\x06Filter\x12\x1d\n" +
+	"\aaddress\x18\x01 \x01(\tH\x00R\aaddress\x88\x01\x01\x12\x1b\n" +
+	"\x06script\x18\x02 \x01(\tH\x01R\x06script\x88\x01\x01B\n" +
+	"\n" +
+	"\b_addressB\t\n" +
+	"\a_script\"\x1f\n" +
 	"\x05Topic\x12\x16\n" +
 	"\x06hashes\x18\x01 \x03(\tR\x06hashes\"L\n" +
 	"\x16ProcessBindingsRequest\x122\n" +
@@ -8536,7 +8818,7 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\aHAS_ALL\x10\n" +
 	"\x12\v\n" +
 	"\aHAS_ANY\x10\vB\x04\n" +
-	"\x02op\"\xf7 \n" +
+	"\x02op\"\xf8#\n" +
 	"\x04Data\x121\n" +
 	"\aeth_log\x18\x02 \x01(\v2\x16.processor.Data.EthLogH\x00R\x06ethLog\x127\n" +
 	"\teth_block\x18\x03 \x01(\v2\x18.processor.Data.EthBlockH\x00R\bethBlock\x12I\n" +
@@ -8559,7 +8841,9 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\vcosmos_call\x18\x0f \x01(\v2\x1a.processor.Data.CosmosCallH\x00R\n" +
 	"cosmosCall\x12H\n" +
 	"\x0fstarknet_events\x18\x10 \x01(\v2\x1d.processor.Data.StarknetEventH\x00R\x0estarknetEvents\x127\n" +
-	"\tsol_block\x18\x16 \x01(\v2\x18.processor.Data.SolBlockH\x00R\bsolBlock\x1a\xa6\x02\n" +
+	"\tsol_block\x18\x16 \x01(\v2\x18.processor.Data.SolBlockH\x00R\bsolBlock\x12I\n" +
+	"\x0fbtc_transaction\x18\x17 \x01(\v2\x1e.processor.Data.BTCTransactionH\x00R\x0ebtcTransaction\x127\n" +
+	"\tbtc_block\x18\x18 \x01(\v2\x18.processor.Data.BTCBlockH\x00R\bbtcBlock\x1a\xa6\x02\n" +
 	"\x06EthLog\x128\n" +
 	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x17\n" +
 	"\araw_log\x18\a \x01(\tR\x06rawLog\x12,\n" +
@@ -8664,6 +8948,12 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1az\n" +
 	"\rStarknetEvent\x12/\n" +
 	"\x06result\x18\x01 \x01(\v2\x17.google.protobuf.StructR\x06result\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1a\x85\x01\n" +
+	"\x0eBTCTransaction\x129\n" +
+	"\vtransaction\x18\x01 \x01(\v2\x17.google.protobuf.StructR\vtransaction\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1as\n" +
+	"\bBTCBlock\x12-\n" +
+	"\x05block\x18\x01 \x01(\v2\x17.google.protobuf.StructR\x05block\x128\n" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestampB\a\n" +
 	"\x05value\"\xa9\x01\n" +
 	"\vDataBinding\x12#\n" +
@@ -8775,7 +9065,7 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"\x05ERC20\x10\x00\x12\n" +
 	"\n" +
 	"\x06ERC721\x10\x01\x12\v\n" +
-	"\aERC1155\x10\x02*\xce\x02\n" +
+	"\aERC1155\x10\x02*\xf2\x02\n" +
 	"\vHandlerType\x12\v\n" +
 	"\aUNKNOWN\x10\x00\x12\v\n" +
 	"\aETH_LOG\x10\x01\x12\r\n" +
@@ -8798,7 +9088,9 @@ const file_processor_protos_processor_proto_rawDesc = "" +
 	"FUEL_BLOCK\x10\x11\x12\x0f\n" +
 	"\vCOSMOS_CALL\x10\x0e\x12\x12\n" +
 	"\x0eSTARKNET_EVENT\x10\x0f\x12\r\n" +
-	"\tSOL_BLOCK\x10\x15*E\n" +
+	"\tSOL_BLOCK\x10\x15\x12\x13\n" +
+	"\x0fBTC_TRANSACTION\x10\x16\x12\r\n" +
+	"\tBTC_BLOCK\x10\x17*E\n" +
 	"\bLogLevel\x12\t\n" +
 	"\x05DEBUG\x10\x00\x12\b\n" +
 	"\x04INFO\x10\x01\x12\v\n" +
@@ -8831,7 +9123,7 @@ func file_processor_protos_processor_proto_rawDescGZIP() []byte {
 }
 
 var file_processor_protos_processor_proto_enumTypes = make([]protoimpl.EnumInfo, 12)
-var file_processor_protos_processor_proto_msgTypes = make([]protoimpl.MessageInfo, 109)
+var file_processor_protos_processor_proto_msgTypes = make([]protoimpl.MessageInfo, 113)
 var file_processor_protos_processor_proto_goTypes = []any{
 	(MetricType)(0),      // 0: processor.MetricType
 	(AggregationType)(0), // 1: processor.AggregationType
@@ -8884,88 +9176,92 @@ var file_processor_protos_processor_proto_goTypes = []any{
 	(*StarknetEventHandlerConfig)(nil),                       // 48: processor.StarknetEventHandlerConfig
 	(*StarknetEventFilter)(nil),                              // 49: processor.StarknetEventFilter
 	(*FuelTransactionHandlerConfig)(nil),                     // 50: processor.FuelTransactionHandlerConfig
-	(*Topic)(nil),                                            // 51: processor.Topic
-	(*ProcessBindingsRequest)(nil),                           // 52: processor.ProcessBindingsRequest
-	(*ProcessBindingResponse)(nil),                           // 53: processor.ProcessBindingResponse
-	(*ProcessStreamRequest)(nil),                             // 54: processor.ProcessStreamRequest
-	(*ProcessStreamResponse)(nil),                            // 55: processor.ProcessStreamResponse
-	(*ProcessStreamResponseV3)(nil),                          // 56: processor.ProcessStreamResponseV3
-	(*PreprocessStreamRequest)(nil),                          // 57: processor.PreprocessStreamRequest
-	(*PreprocessStreamResponse)(nil),                         // 58: processor.PreprocessStreamResponse
-	(*DBResponse)(nil),                                       // 59: processor.DBResponse
-	(*Entity)(nil),                                           // 60: processor.Entity
-	(*EntityList)(nil),                                       // 61: processor.EntityList
-	(*EntityUpdateData)(nil),                                 // 62: processor.EntityUpdateData
-	(*TPLRequest)(nil),                                       // 63: processor.TPLRequest
-	(*TSRequest)(nil),                                        // 64: processor.TSRequest
-	(*DBRequest)(nil),                                        // 65: processor.DBRequest
-	(*Data)(nil),                                             // 66: processor.Data
-	(*DataBinding)(nil),                                      // 67: processor.DataBinding
-	(*StateResult)(nil),                                      // 68: processor.StateResult
-	(*ProcessResult)(nil),                                    // 69: processor.ProcessResult
-	(*EthCallParam)(nil),                                     // 70: processor.EthCallParam
-	(*EthCallContext)(nil),                                   // 71: processor.EthCallContext
-	(*PreprocessResult)(nil),                                 // 72: processor.PreprocessResult
-	(*PreparedData)(nil),                                     // 73: processor.PreparedData
-	(*RecordMetaData)(nil),                                   // 74: processor.RecordMetaData
-	(*MetricValue)(nil),                                      // 75: processor.MetricValue
-	(*RuntimeInfo)(nil),                                      // 76: processor.RuntimeInfo
-	(*GaugeResult)(nil),                                      // 77: processor.GaugeResult
-	(*CounterResult)(nil),                                    // 78: processor.CounterResult
-	(*EventTrackingResult)(nil),                              // 79: processor.EventTrackingResult
-	(*TimeseriesResult)(nil),                                 // 80: processor.TimeseriesResult
-	(*ExportResult)(nil),                                     // 81: processor.ExportResult
-	(*ExecutionConfig_DecoderWorkerConfig)(nil),              // 82: processor.ExecutionConfig.DecoderWorkerConfig
-	(*EventLogConfig_StructFieldType)(nil),                   // 83: processor.EventLogConfig.StructFieldType
-	(*EventLogConfig_Field)(nil),                             // 84: processor.EventLogConfig.Field
-	(*FuelAssetHandlerConfig_AssetFilter)(nil),               // 85: processor.FuelAssetHandlerConfig.AssetFilter
-	(*FuelReceiptHandlerConfig_Transfer)(nil),                // 86: processor.FuelReceiptHandlerConfig.Transfer
-	(*FuelReceiptHandlerConfig_Log)(nil),                     // 87: processor.FuelReceiptHandlerConfig.Log
-	(*MoveCallFilter_FromAndToAddress)(nil),                  // 88: processor.MoveCallFilter.FromAndToAddress
-	(*ProcessStreamResponse_Partitions)(nil),                 // 89: processor.ProcessStreamResponse.Partitions
-	(*ProcessStreamResponse_Partitions_Partition)(nil),       // 90: processor.ProcessStreamResponse.Partitions.Partition
-	nil, // 91: processor.ProcessStreamResponse.Partitions.PartitionsEntry
-	(*PreprocessStreamRequest_DataBindings)(nil), // 92: processor.PreprocessStreamRequest.DataBindings
-	(*EntityUpdateData_FieldValue)(nil),          // 93: processor.EntityUpdateData.FieldValue
-	nil,                                          // 94: processor.EntityUpdateData.FieldsEntry
-	(*DBRequest_DBGet)(nil),                      // 95: processor.DBRequest.DBGet
-	(*DBRequest_DBList)(nil),                     // 96: processor.DBRequest.DBList
-	(*DBRequest_DBUpsert)(nil),                   // 97: processor.DBRequest.DBUpsert
-	(*DBRequest_DBUpdate)(nil),                   // 98: processor.DBRequest.DBUpdate
-	(*DBRequest_DBDelete)(nil),                   // 99: processor.DBRequest.DBDelete
-	(*DBRequest_DBFilter)(nil),                   // 100: processor.DBRequest.DBFilter
-	(*Data_EthLog)(nil),                          // 101: processor.Data.EthLog
-	(*Data_EthBlock)(nil),                        // 102: processor.Data.EthBlock
-	(*Data_EthTransaction)(nil),                  // 103: processor.Data.EthTransaction
-	(*Data_EthTrace)(nil),                        // 104: processor.Data.EthTrace
-	(*Data_SolInstruction)(nil),                  // 105: processor.Data.SolInstruction
-	(*Data_SolBlock)(nil),                        // 106: processor.Data.SolBlock
-	(*Data_AptEvent)(nil),                        // 107: processor.Data.AptEvent
-	(*Data_AptCall)(nil),                         // 108: processor.Data.AptCall
-	(*Data_AptResource)(nil),                     // 109: processor.Data.AptResource
-	(*Data_SuiEvent)(nil),                        // 110: processor.Data.SuiEvent
-	(*Data_SuiCall)(nil),                         // 111: processor.Data.SuiCall
-	(*Data_SuiObject)(nil),                       // 112: processor.Data.SuiObject
-	(*Data_SuiObjectChange)(nil),                 // 113: processor.Data.SuiObjectChange
-	(*Data_FuelReceipt)(nil),                     // 114: processor.Data.FuelReceipt
-	(*Data_FuelTransaction)(nil),                 // 115: processor.Data.FuelTransaction
-	(*Data_FuelBlock)(nil),                       // 116: processor.Data.FuelBlock
-	(*Data_CosmosCall)(nil),                      // 117: processor.Data.CosmosCall
-	(*Data_StarknetEvent)(nil),                   // 118: processor.Data.StarknetEvent
-	nil,                                          // 119: processor.PreparedData.EthCallResultsEntry
-	nil,                                          // 120: processor.RecordMetaData.LabelsEntry
-	(*structpb.Struct)(nil),                      // 121: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil),                // 122: google.protobuf.Timestamp
-	(*protos.RichStruct)(nil),                    // 123: common.RichStruct
-	(*protos.BigInteger)(nil),                    // 124: common.BigInteger
-	(*protos.CoinID)(nil),                        // 125: common.CoinID
-	(*protos.RichValue)(nil),                     // 126: common.RichValue
-	(*protos.RichValueList)(nil),                 // 127: common.RichValueList
-	(*emptypb.Empty)(nil),                        // 128: google.protobuf.Empty
+	(*BTCTransactionHandlerConfig)(nil),                      // 51: processor.BTCTransactionHandlerConfig
+	(*Topic)(nil),                                            // 52: processor.Topic
+	(*ProcessBindingsRequest)(nil),                           // 53: processor.ProcessBindingsRequest
+	(*ProcessBindingResponse)(nil),                           // 54: processor.ProcessBindingResponse
+	(*ProcessStreamRequest)(nil),                             // 55: processor.ProcessStreamRequest
+	(*ProcessStreamResponse)(nil),                            // 56: processor.ProcessStreamResponse
+	(*ProcessStreamResponseV3)(nil),                          // 57: processor.ProcessStreamResponseV3
+	(*PreprocessStreamRequest)(nil),                          // 58: processor.PreprocessStreamRequest
+	(*PreprocessStreamResponse)(nil),                         // 59: processor.PreprocessStreamResponse
+	(*DBResponse)(nil),                                       // 60: processor.DBResponse
+	(*Entity)(nil),                                           // 61: processor.Entity
+	(*EntityList)(nil),                                       // 62: processor.EntityList
+	(*EntityUpdateData)(nil),                                 // 63: processor.EntityUpdateData
+	(*TPLRequest)(nil),                                       // 64: processor.TPLRequest
+	(*TSRequest)(nil),                                        // 65: processor.TSRequest
+	(*DBRequest)(nil),                                        // 66: processor.DBRequest
+	(*Data)(nil),                                             // 67: processor.Data
+	(*DataBinding)(nil),                                      // 68: processor.DataBinding
+	(*StateResult)(nil),                                      // 69: processor.StateResult
+	(*ProcessResult)(nil),                                    // 70: processor.ProcessResult
+	(*EthCallParam)(nil),                                     // 71: processor.EthCallParam
+	(*EthCallContext)(nil),                                   // 72: processor.EthCallContext
+	(*PreprocessResult)(nil),                                 // 73: processor.PreprocessResult
+	(*PreparedData)(nil),                                     // 74: processor.PreparedData
+	(*RecordMetaData)(nil),                                   // 75: processor.RecordMetaData
+	(*MetricValue)(nil),                                      // 76: processor.MetricValue
+	(*RuntimeInfo)(nil),                                      // 77: processor.RuntimeInfo
+	(*GaugeResult)(nil),                                      // 78: processor.GaugeResult
+	(*CounterResult)(nil),                                    // 79: processor.CounterResult
+	(*EventTrackingResult)(nil),                              // 80: processor.EventTrackingResult
+	(*TimeseriesResult)(nil),                                 // 81: processor.TimeseriesResult
+	(*ExportResult)(nil),                                     // 82: processor.ExportResult
+	(*ExecutionConfig_DecoderWorkerConfig)(nil),              // 83: processor.ExecutionConfig.DecoderWorkerConfig
+	(*EventLogConfig_StructFieldType)(nil),                   // 84: processor.EventLogConfig.StructFieldType
+	(*EventLogConfig_Field)(nil),                             // 85: processor.EventLogConfig.Field
+	(*FuelAssetHandlerConfig_AssetFilter)(nil),               // 86: processor.FuelAssetHandlerConfig.AssetFilter
+	(*FuelReceiptHandlerConfig_Transfer)(nil),                // 87: processor.FuelReceiptHandlerConfig.Transfer
+	(*FuelReceiptHandlerConfig_Log)(nil),                     // 88: processor.FuelReceiptHandlerConfig.Log
+	(*MoveCallFilter_FromAndToAddress)(nil),                  // 89: processor.MoveCallFilter.FromAndToAddress
+	(*BTCTransactionHandlerConfig_Filter)(nil),               // 90: processor.BTCTransactionHandlerConfig.Filter
+	(*ProcessStreamResponse_Partitions)(nil),                 // 91: processor.ProcessStreamResponse.Partitions
+	(*ProcessStreamResponse_Partitions_Partition)(nil),       // 92: processor.ProcessStreamResponse.Partitions.Partition
+	nil, // 93: processor.ProcessStreamResponse.Partitions.PartitionsEntry
+	(*PreprocessStreamRequest_DataBindings)(nil), // 94: processor.PreprocessStreamRequest.DataBindings
+	(*EntityUpdateData_FieldValue)(nil),          // 95: processor.EntityUpdateData.FieldValue
+	nil,                                          // 96: processor.EntityUpdateData.FieldsEntry
+	(*DBRequest_DBGet)(nil),                      // 97: processor.DBRequest.DBGet
+	(*DBRequest_DBList)(nil),                     // 98: processor.DBRequest.DBList
+	(*DBRequest_DBUpsert)(nil),                   // 99: processor.DBRequest.DBUpsert
+	(*DBRequest_DBUpdate)(nil),                   // 100: processor.DBRequest.DBUpdate
+	(*DBRequest_DBDelete)(nil),                   // 101: processor.DBRequest.DBDelete
+	(*DBRequest_DBFilter)(nil),                   // 102: processor.DBRequest.DBFilter
+	(*Data_EthLog)(nil),                          // 103: processor.Data.EthLog
+	(*Data_EthBlock)(nil),                        // 104: processor.Data.EthBlock
+	(*Data_EthTransaction)(nil),                  // 105: processor.Data.EthTransaction
+	(*Data_EthTrace)(nil),                        // 106: processor.Data.EthTrace
+	(*Data_SolInstruction)(nil),                  // 107: processor.Data.SolInstruction
+	(*Data_SolBlock)(nil),                        // 108: processor.Data.SolBlock
+	(*Data_AptEvent)(nil),                        // 109: processor.Data.AptEvent
+	(*Data_AptCall)(nil),                         // 110: processor.Data.AptCall
+	(*Data_AptResource)(nil),                     // 111: processor.Data.AptResource
+	(*Data_SuiEvent)(nil),                        // 112: processor.Data.SuiEvent
+	(*Data_SuiCall)(nil),                         // 113: processor.Data.SuiCall
+	(*Data_SuiObject)(nil),                       // 114: processor.Data.SuiObject
+	(*Data_SuiObjectChange)(nil),                 // 115: processor.Data.SuiObjectChange
+	(*Data_FuelReceipt)(nil),                     // 116: processor.Data.FuelReceipt
+	(*Data_FuelTransaction)(nil),                 // 117: processor.Data.FuelTransaction
+	(*Data_FuelBlock)(nil),                       // 118: processor.Data.FuelBlock
+	(*Data_CosmosCall)(nil),                      // 119: processor.Data.CosmosCall
+	(*Data_StarknetEvent)(nil),                   // 120: processor.Data.StarknetEvent
+	(*Data_BTCTransaction)(nil),                  // 121: processor.Data.BTCTransaction
+	(*Data_BTCBlock)(nil),                        // 122: processor.Data.BTCBlock
+	nil,                                          // 123: processor.PreparedData.EthCallResultsEntry
+	nil,                                          // 124: processor.RecordMetaData.LabelsEntry
+	(*structpb.Struct)(nil),                      // 125: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),                // 126: google.protobuf.Timestamp
+	(*protos.RichStruct)(nil),                    // 127: common.RichStruct
+	(*protos.BigInteger)(nil),                    // 128: common.BigInteger
+	(*protos.CoinID)(nil),                        // 129: common.CoinID
+	(*protos.RichValue)(nil),                     // 130: common.RichValue
+	(*protos.RichValueList)(nil),                 // 131: common.RichValueList
+	(*emptypb.Empty)(nil),                        // 132: google.protobuf.Empty
 }
 var file_processor_protos_processor_proto_depIdxs = []int32{
 	6,   // 0: processor.ExecutionConfig.handler_order_inside_transaction:type_name -> processor.ExecutionConfig.HandlerOrderInsideTransaction
-	82,  // 1: processor.ExecutionConfig.ethAbiDecoderConfig:type_name -> processor.ExecutionConfig.DecoderWorkerConfig
+	83,  // 1: processor.ExecutionConfig.ethAbiDecoderConfig:type_name -> processor.ExecutionConfig.DecoderWorkerConfig
 	12,  // 2: processor.ProcessConfigResponse.config:type_name -> processor.ProjectConfig
 	13,  // 3: processor.ProcessConfigResponse.execution_config:type_name -> processor.ExecutionConfig
 	16,  // 4: processor.ProcessConfigResponse.contract_configs:type_name -> processor.ContractConfig
@@ -8989,184 +9285,192 @@ var file_processor_protos_processor_proto_depIdxs = []int32{
 	36,  // 22: processor.ContractConfig.fuel_receipt_configs:type_name -> processor.FuelReceiptHandlerConfig
 	37,  // 23: processor.ContractConfig.cosmos_log_configs:type_name -> processor.CosmosLogHandlerConfig
 	48,  // 24: processor.ContractConfig.starknet_event_configs:type_name -> processor.StarknetEventHandlerConfig
-	39,  // 25: processor.ContractConfig.instruction_config:type_name -> processor.InstructionHandlerConfig
-	0,   // 26: processor.MetricConfig.type:type_name -> processor.MetricType
-	21,  // 27: processor.MetricConfig.aggregation_config:type_name -> processor.AggregationConfig
-	84,  // 28: processor.EventLogConfig.fields:type_name -> processor.EventLogConfig.Field
-	1,   // 29: processor.AggregationConfig.types:type_name -> processor.AggregationType
-	24,  // 30: processor.AccountConfig.interval_configs:type_name -> processor.OnIntervalConfig
-	25,  // 31: processor.AccountConfig.move_interval_configs:type_name -> processor.MoveOnIntervalConfig
-	45,  // 32: processor.AccountConfig.move_call_configs:type_name -> processor.MoveCallHandlerConfig
-	46,  // 33: processor.AccountConfig.move_resource_change_configs:type_name -> processor.MoveResourceChangeConfig
-	34,  // 34: processor.AccountConfig.log_configs:type_name -> processor.LogHandlerConfig
-	23,  // 35: processor.OnIntervalConfig.minutes_interval:type_name -> processor.HandleInterval
-	23,  // 36: processor.OnIntervalConfig.slot_interval:type_name -> processor.HandleInterval
-	31,  // 37: processor.OnIntervalConfig.fetch_config:type_name -> processor.EthFetchConfig
-	24,  // 38: processor.MoveOnIntervalConfig.interval_config:type_name -> processor.OnIntervalConfig
-	2,   // 39: processor.MoveOnIntervalConfig.owner_type:type_name -> processor.MoveOwnerType
-	42,  // 40: processor.MoveOnIntervalConfig.resource_fetch_config:type_name -> processor.MoveAccountFetchConfig
-	41,  // 41: processor.MoveOnIntervalConfig.fetch_config:type_name -> processor.MoveFetchConfig
-	26,  // 42: processor.TemplateInstance.contract:type_name -> processor.ContractInfo
-	121, // 43: processor.TemplateInstance.base_labels:type_name -> google.protobuf.Struct
-	17,  // 44: processor.InitResponse.db_schema:type_name -> processor.DataBaseSchema
-	12,  // 45: processor.InitResponse.config:type_name -> processor.ProjectConfig
-	13,  // 46: processor.InitResponse.execution_config:type_name -> processor.ExecutionConfig
-	19,  // 47: processor.InitResponse.metric_configs:type_name -> processor.MetricConfig
-	18,  // 48: processor.InitResponse.export_configs:type_name -> processor.ExportConfig
-	20,  // 49: processor.InitResponse.event_log_configs:type_name -> processor.EventLogConfig
-	27,  // 50: processor.UpdateTemplatesRequest.template_instances:type_name -> processor.TemplateInstance
-	27,  // 51: processor.StartRequest.template_instances:type_name -> processor.TemplateInstance
-	31,  // 52: processor.TraceHandlerConfig.fetch_config:type_name -> processor.EthFetchConfig
-	31,  // 53: processor.TransactionHandlerConfig.fetch_config:type_name -> processor.EthFetchConfig
-	38,  // 54: processor.LogHandlerConfig.filters:type_name -> processor.LogFilter
-	31,  // 55: processor.LogHandlerConfig.fetch_config:type_name -> processor.EthFetchConfig
-	85,  // 56: processor.FuelAssetHandlerConfig.filters:type_name -> processor.FuelAssetHandlerConfig.AssetFilter
-	87,  // 57: processor.FuelReceiptHandlerConfig.log:type_name -> processor.FuelReceiptHandlerConfig.Log
-	86,  // 58: processor.FuelReceiptHandlerConfig.transfer:type_name -> processor.FuelReceiptHandlerConfig.Transfer
-	51,  // 59: processor.LogFilter.topics:type_name -> processor.Topic
-	3,   // 60: processor.LogFilter.address_type:type_name -> processor.AddressType
-	40,  // 61: processor.MoveFetchConfig.resource_config:type_name -> processor.ResourceConfig
-	44,  // 62: processor.MoveEventHandlerConfig.filters:type_name -> processor.MoveEventFilter
-	41,  // 63: processor.MoveEventHandlerConfig.fetch_config:type_name -> processor.MoveFetchConfig
-	47,  // 64: processor.MoveCallHandlerConfig.filters:type_name -> processor.MoveCallFilter
-	41,  // 65: processor.MoveCallHandlerConfig.fetch_config:type_name -> processor.MoveFetchConfig
-	88,  // 66: processor.MoveCallFilter.from_and_to_address:type_name -> processor.MoveCallFilter.FromAndToAddress
-	49,  // 67: processor.StarknetEventHandlerConfig.filters:type_name -> processor.StarknetEventFilter
-	67,  // 68: processor.ProcessBindingsRequest.bindings:type_name -> processor.DataBinding
-	69,  // 69: processor.ProcessBindingResponse.result:type_name -> processor.ProcessResult
-	67,  // 70: processor.ProcessStreamRequest.binding:type_name -> processor.DataBinding
-	59,  // 71: processor.ProcessStreamRequest.db_result:type_name -> processor.DBResponse
-	65,  // 72: processor.ProcessStreamResponse.db_request:type_name -> processor.DBRequest
-	69,  // 73: processor.ProcessStreamResponse.result:type_name -> processor.ProcessResult
-	89,  // 74: processor.ProcessStreamResponse.partitions:type_name -> processor.ProcessStreamResponse.Partitions
-	89,  // 75: processor.ProcessStreamResponseV3.partitions:type_name -> processor.ProcessStreamResponse.Partitions
-	65,  // 76: processor.ProcessStreamResponseV3.db_request:type_name -> processor.DBRequest
-	64,  // 77: processor.ProcessStreamResponseV3.ts_request:type_name -> processor.TSRequest
-	63,  // 78: processor.ProcessStreamResponseV3.tpl_request:type_name -> processor.TPLRequest
-	69,  // 79: processor.ProcessStreamResponseV3.result:type_name -> processor.ProcessResult
-	92,  // 80: processor.PreprocessStreamRequest.bindings:type_name -> processor.PreprocessStreamRequest.DataBindings
-	59,  // 81: processor.PreprocessStreamRequest.db_result:type_name -> processor.DBResponse
-	65,  // 82: processor.PreprocessStreamResponse.db_request:type_name -> processor.DBRequest
-	61,  // 83: processor.DBResponse.entity_list:type_name -> processor.EntityList
-	122, // 84: processor.Entity.gen_block_time:type_name -> google.protobuf.Timestamp
-	123, // 85: processor.Entity.data:type_name -> common.RichStruct
-	60,  // 86: processor.EntityList.entities:type_name -> processor.Entity
-	94,  // 87: processor.EntityUpdateData.fields:type_name -> processor.EntityUpdateData.FieldsEntry
-	27,  // 88: processor.TPLRequest.templates:type_name -> processor.TemplateInstance
-	80,  // 89: processor.TSRequest.data:type_name -> processor.TimeseriesResult
-	95,  // 90: processor.DBRequest.get:type_name -> processor.DBRequest.DBGet
-	97,  // 91: processor.DBRequest.upsert:type_name -> processor.DBRequest.DBUpsert
-	98,  // 92: processor.DBRequest.update:type_name -> processor.DBRequest.DBUpdate
-	99,  // 93: processor.DBRequest.delete:type_name -> processor.DBRequest.DBDelete
-	96,  // 94: processor.DBRequest.list:type_name -> processor.DBRequest.DBList
-	101, // 95: processor.Data.eth_log:type_name -> processor.Data.EthLog
-	102, // 96: processor.Data.eth_block:type_name -> processor.Data.EthBlock
-	103, // 97: processor.Data.eth_transaction:type_name -> processor.Data.EthTransaction
-	104, // 98: processor.Data.eth_trace:type_name -> processor.Data.EthTrace
-	105, // 99: processor.Data.sol_instruction:type_name -> processor.Data.SolInstruction
-	107, // 100: processor.Data.apt_event:type_name -> processor.Data.AptEvent
-	108, // 101: processor.Data.apt_call:type_name -> processor.Data.AptCall
-	109, // 102: processor.Data.apt_resource:type_name -> processor.Data.AptResource
-	110, // 103: processor.Data.sui_event:type_name -> processor.Data.SuiEvent
-	111, // 104: processor.Data.sui_call:type_name -> processor.Data.SuiCall
-	112, // 105: processor.Data.sui_object:type_name -> processor.Data.SuiObject
-	113, // 106: processor.Data.sui_object_change:type_name -> processor.Data.SuiObjectChange
-	114, // 107: processor.Data.fuel_receipt:type_name -> processor.Data.FuelReceipt
-	115, // 108: processor.Data.fuel_transaction:type_name -> processor.Data.FuelTransaction
-	116, // 109: processor.Data.fuel_block:type_name -> processor.Data.FuelBlock
-	117, // 110: processor.Data.cosmos_call:type_name -> processor.Data.CosmosCall
-	118, // 111: processor.Data.starknet_events:type_name -> processor.Data.StarknetEvent
-	106, // 112: processor.Data.sol_block:type_name -> processor.Data.SolBlock
-	66,  // 113: processor.DataBinding.data:type_name -> processor.Data
-	4,   // 114: processor.DataBinding.handler_type:type_name -> processor.HandlerType
-	77,  // 115: processor.ProcessResult.gauges:type_name -> processor.GaugeResult
-	78,  // 116: processor.ProcessResult.counters:type_name -> processor.CounterResult
-	79,  // 117: processor.ProcessResult.events:type_name -> processor.EventTrackingResult
-	81,  // 118: processor.ProcessResult.exports:type_name -> processor.ExportResult
-	68,  // 119: processor.ProcessResult.states:type_name -> processor.StateResult
-	80,  // 120: processor.ProcessResult.timeseries_result:type_name -> processor.TimeseriesResult
-	71,  // 121: processor.EthCallParam.context:type_name -> processor.EthCallContext
-	70,  // 122: processor.PreprocessResult.ethCallParams:type_name -> processor.EthCallParam
-	119, // 123: processor.PreparedData.eth_call_results:type_name -> processor.PreparedData.EthCallResultsEntry
-	120, // 124: processor.RecordMetaData.labels:type_name -> processor.RecordMetaData.LabelsEntry
-	124, // 125: processor.MetricValue.big_integer:type_name -> common.BigInteger
-	4,   // 126: processor.RuntimeInfo.from:type_name -> processor.HandlerType
-	74,  // 127: processor.GaugeResult.metadata:type_name -> processor.RecordMetaData
-	75,  // 128: processor.GaugeResult.metric_value:type_name -> processor.MetricValue
-	76,  // 129: processor.GaugeResult.runtime_info:type_name -> processor.RuntimeInfo
-	74,  // 130: processor.CounterResult.metadata:type_name -> processor.RecordMetaData
-	75,  // 131: processor.CounterResult.metric_value:type_name -> processor.MetricValue
-	76,  // 132: processor.CounterResult.runtime_info:type_name -> processor.RuntimeInfo
-	74,  // 133: processor.EventTrackingResult.metadata:type_name -> processor.RecordMetaData
-	121, // 134: processor.EventTrackingResult.attributes:type_name -> google.protobuf.Struct
-	5,   // 135: processor.EventTrackingResult.severity:type_name -> processor.LogLevel
-	76,  // 136: processor.EventTrackingResult.runtime_info:type_name -> processor.RuntimeInfo
-	123, // 137: processor.EventTrackingResult.attributes2:type_name -> common.RichStruct
-	74,  // 138: processor.TimeseriesResult.metadata:type_name -> processor.RecordMetaData
-	11,  // 139: processor.TimeseriesResult.type:type_name -> processor.TimeseriesResult.TimeseriesType
-	123, // 140: processor.TimeseriesResult.data:type_name -> common.RichStruct
-	76,  // 141: processor.TimeseriesResult.runtime_info:type_name -> processor.RuntimeInfo
-	74,  // 142: processor.ExportResult.metadata:type_name -> processor.RecordMetaData
-	76,  // 143: processor.ExportResult.runtime_info:type_name -> processor.RuntimeInfo
-	84,  // 144: processor.EventLogConfig.StructFieldType.fields:type_name -> processor.EventLogConfig.Field
-	7,   // 145: processor.EventLogConfig.Field.basic_type:type_name -> processor.EventLogConfig.BasicFieldType
-	125, // 146: processor.EventLogConfig.Field.coin_type:type_name -> common.CoinID
-	83,  // 147: processor.EventLogConfig.Field.struct_type:type_name -> processor.EventLogConfig.StructFieldType
-	91,  // 148: processor.ProcessStreamResponse.Partitions.partitions:type_name -> processor.ProcessStreamResponse.Partitions.PartitionsEntry
-	8,   // 149: processor.ProcessStreamResponse.Partitions.Partition.sys_value:type_name -> processor.ProcessStreamResponse.Partitions.Partition.SysValue
-	90,  // 150: processor.ProcessStreamResponse.Partitions.PartitionsEntry.value:type_name -> processor.ProcessStreamResponse.Partitions.Partition
-	67,  // 151: processor.PreprocessStreamRequest.DataBindings.bindings:type_name -> processor.DataBinding
-	126, // 152: processor.EntityUpdateData.FieldValue.value:type_name -> common.RichValue
-	9,   // 153: processor.EntityUpdateData.FieldValue.op:type_name -> processor.EntityUpdateData.Operator
-	93,  // 154: processor.EntityUpdateData.FieldsEntry.value:type_name -> processor.EntityUpdateData.FieldValue
-	100, // 155: processor.DBRequest.DBList.filters:type_name -> processor.DBRequest.DBFilter
-	123, // 156: processor.DBRequest.DBUpsert.entity_data:type_name -> common.RichStruct
-	62,  // 157: processor.DBRequest.DBUpdate.entity_data:type_name -> processor.EntityUpdateData
-	10,  // 158: processor.DBRequest.DBFilter.op:type_name -> processor.DBRequest.DBOperator
-	127, // 159: processor.DBRequest.DBFilter.value:type_name -> common.RichValueList
-	122, // 160: processor.Data.EthLog.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 161: processor.Data.EthTransaction.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 162: processor.Data.EthTrace.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 163: processor.Data.SolBlock.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 164: processor.Data.SuiEvent.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 165: processor.Data.SuiCall.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 166: processor.Data.SuiObject.timestamp:type_name -> google.protobuf.Timestamp
-	122, // 167: processor.Data.SuiObjectChange.timestamp:type_name -> google.protobuf.Timestamp
-	121, // 168: processor.Data.FuelReceipt.transaction:type_name -> google.protobuf.Struct
-	122, // 169: processor.Data.FuelReceipt.timestamp:type_name -> google.protobuf.Timestamp
-	121, // 170: processor.Data.FuelTransaction.transaction:type_name -> google.protobuf.Struct
-	122, // 171: processor.Data.FuelTransaction.timestamp:type_name -> google.protobuf.Timestamp
-	121, // 172: processor.Data.FuelBlock.block:type_name -> google.protobuf.Struct
-	122, // 173: processor.Data.FuelBlock.timestamp:type_name -> google.protobuf.Timestamp
-	121, // 174: processor.Data.CosmosCall.transaction:type_name -> google.protobuf.Struct
-	122, // 175: processor.Data.CosmosCall.timestamp:type_name -> google.protobuf.Timestamp
-	121, // 176: processor.Data.StarknetEvent.result:type_name -> google.protobuf.Struct
-	122, // 177: processor.Data.StarknetEvent.timestamp:type_name -> google.protobuf.Timestamp
-	30,  // 178: processor.Processor.Start:input_type -> processor.StartRequest
-	128, // 179: processor.Processor.Stop:input_type -> google.protobuf.Empty
-	14,  // 180: processor.Processor.GetConfig:input_type -> processor.ProcessConfigRequest
-	52,  // 181: processor.Processor.ProcessBindings:input_type -> processor.ProcessBindingsRequest
-	54,  // 182: processor.Processor.ProcessBindingsStream:input_type -> processor.ProcessStreamRequest
-	57,  // 183: processor.Processor.PreprocessBindingsStream:input_type -> processor.PreprocessStreamRequest
-	30,  // 184: processor.ProcessorV3.Start:input_type -> processor.StartRequest
-	14,  // 185: processor.ProcessorV3.GetConfig:input_type -> processor.ProcessConfigRequest
-	29,  // 186: processor.ProcessorV3.UpdateTemplates:input_type -> processor.UpdateTemplatesRequest
-	54,  // 187: processor.ProcessorV3.ProcessBindingsStream:input_type -> processor.ProcessStreamRequest
-	128, // 188: processor.Processor.Start:output_type -> google.protobuf.Empty
-	128, // 189: processor.Processor.Stop:output_type -> google.protobuf.Empty
-	15,  // 190: processor.Processor.GetConfig:output_type -> processor.ProcessConfigResponse
-	53,  // 191: processor.Processor.ProcessBindings:output_type -> processor.ProcessBindingResponse
-	55,  // 192: processor.Processor.ProcessBindingsStream:output_type -> processor.ProcessStreamResponse
-	58,  // 193: processor.Processor.PreprocessBindingsStream:output_type -> processor.PreprocessStreamResponse
-	128, // 194: processor.ProcessorV3.Start:output_type -> google.protobuf.Empty
-	15,  // 195: processor.ProcessorV3.GetConfig:output_type -> processor.ProcessConfigResponse
-	128, // 196: processor.ProcessorV3.UpdateTemplates:output_type -> google.protobuf.Empty
-	56,  // 197: processor.ProcessorV3.ProcessBindingsStream:output_type -> processor.ProcessStreamResponseV3
-	188, // [188:198] is the sub-list for method output_type
-	178, // [178:188] is the sub-list for method input_type
-	178, // [178:178] is the sub-list for extension type_name
-	178, // [178:178] is the sub-list for extension extendee
-	0,   // [0:178] is the sub-list for field type_name
+	51,  // 25: processor.ContractConfig.btc_transaction_configs:type_name -> processor.BTCTransactionHandlerConfig
+	39,  // 26: processor.ContractConfig.instruction_config:type_name -> processor.InstructionHandlerConfig
+	0,   // 27: processor.MetricConfig.type:type_name -> processor.MetricType
+	21,  // 28: processor.MetricConfig.aggregation_config:type_name -> processor.AggregationConfig
+	85,  // 29: processor.EventLogConfig.fields:type_name -> processor.EventLogConfig.Field
+	1,   // 30: processor.AggregationConfig.types:type_name -> processor.AggregationType
+	24,  // 31: processor.AccountConfig.interval_configs:type_name -> processor.OnIntervalConfig
+	25,  // 32: processor.AccountConfig.move_interval_configs:type_name -> processor.MoveOnIntervalConfig
+	45,  // 33: processor.AccountConfig.move_call_configs:type_name -> processor.MoveCallHandlerConfig
+	46,  // 34: processor.AccountConfig.move_resource_change_configs:type_name -> processor.MoveResourceChangeConfig
+	34,  // 35: processor.AccountConfig.log_configs:type_name -> processor.LogHandlerConfig
+	23,  // 36: processor.OnIntervalConfig.minutes_interval:type_name -> processor.HandleInterval
+	23,  // 37: processor.OnIntervalConfig.slot_interval:type_name -> processor.HandleInterval
+	31,  // 38: processor.OnIntervalConfig.fetch_config:type_name -> processor.EthFetchConfig
+	24,  // 39: processor.MoveOnIntervalConfig.interval_config:type_name -> processor.OnIntervalConfig
+	2,   // 40: processor.MoveOnIntervalConfig.owner_type:type_name -> processor.MoveOwnerType
+	42,  // 41: processor.MoveOnIntervalConfig.resource_fetch_config:type_name -> processor.MoveAccountFetchConfig
+	41,  // 42: processor.MoveOnIntervalConfig.fetch_config:type_name -> processor.MoveFetchConfig
+	26,  // 43: processor.TemplateInstance.contract:type_name -> processor.ContractInfo
+	125, // 44: processor.TemplateInstance.base_labels:type_name -> google.protobuf.Struct
+	17,  // 45: processor.InitResponse.db_schema:type_name -> processor.DataBaseSchema
+	12,  // 46: processor.InitResponse.config:type_name -> processor.ProjectConfig
+	13,  // 47: processor.InitResponse.execution_config:type_name -> processor.ExecutionConfig
+	19,  // 48: processor.InitResponse.metric_configs:type_name -> processor.MetricConfig
+	18,  // 49: processor.InitResponse.export_configs:type_name -> processor.ExportConfig
+	20,  // 50: processor.InitResponse.event_log_configs:type_name -> processor.EventLogConfig
+	27,  // 51: processor.UpdateTemplatesRequest.template_instances:type_name -> processor.TemplateInstance
+	27,  // 52: processor.StartRequest.template_instances:type_name -> processor.TemplateInstance
+	31,  // 53: processor.TraceHandlerConfig.fetch_config:type_name -> processor.EthFetchConfig
+	31,  // 54: processor.TransactionHandlerConfig.fetch_config:type_name -> processor.EthFetchConfig
+	38,  // 55: processor.LogHandlerConfig.filters:type_name -> processor.LogFilter
+	31,  // 56: processor.LogHandlerConfig.fetch_config:type_name -> processor.EthFetchConfig
+	86,  // 57: processor.FuelAssetHandlerConfig.filters:type_name -> processor.FuelAssetHandlerConfig.AssetFilter
+	88,  // 58: processor.FuelReceiptHandlerConfig.log:type_name -> processor.FuelReceiptHandlerConfig.Log
+	87,  // 59: processor.FuelReceiptHandlerConfig.transfer:type_name -> processor.FuelReceiptHandlerConfig.Transfer
+	52,  // 60: processor.LogFilter.topics:type_name -> processor.Topic
+	3,   // 61: processor.LogFilter.address_type:type_name -> processor.AddressType
+	40,  // 62: processor.MoveFetchConfig.resource_config:type_name -> processor.ResourceConfig
+	44,  // 63: processor.MoveEventHandlerConfig.filters:type_name -> processor.MoveEventFilter
+	41,  // 64: processor.MoveEventHandlerConfig.fetch_config:type_name -> processor.MoveFetchConfig
+	47,  // 65: processor.MoveCallHandlerConfig.filters:type_name -> processor.MoveCallFilter
+	41,  // 66: processor.MoveCallHandlerConfig.fetch_config:type_name -> processor.MoveFetchConfig
+	89,  // 67: processor.MoveCallFilter.from_and_to_address:type_name -> processor.MoveCallFilter.FromAndToAddress
+	49,  // 68: processor.StarknetEventHandlerConfig.filters:type_name -> processor.StarknetEventFilter
+	90,  // 69: processor.BTCTransactionHandlerConfig.filters:type_name -> processor.BTCTransactionHandlerConfig.Filter
+	68,  // 70: processor.ProcessBindingsRequest.bindings:type_name -> processor.DataBinding
+	70,  // 71: processor.ProcessBindingResponse.result:type_name -> processor.ProcessResult
+	68,  // 72: processor.ProcessStreamRequest.binding:type_name -> processor.DataBinding
+	60,  // 73: processor.ProcessStreamRequest.db_result:type_name -> processor.DBResponse
+	66,  // 74: processor.ProcessStreamResponse.db_request:type_name -> processor.DBRequest
+	70,  // 75: processor.ProcessStreamResponse.result:type_name -> processor.ProcessResult
+	91,  // 76: processor.ProcessStreamResponse.partitions:type_name -> processor.ProcessStreamResponse.Partitions
+	91,  // 77: processor.ProcessStreamResponseV3.partitions:type_name -> processor.ProcessStreamResponse.Partitions
+	66,  // 78: processor.ProcessStreamResponseV3.db_request:type_name -> processor.DBRequest
+	65,  // 79: processor.ProcessStreamResponseV3.ts_request:type_name -> processor.TSRequest
+	64,  // 80: processor.ProcessStreamResponseV3.tpl_request:type_name -> processor.TPLRequest
+	70,  // 81: processor.ProcessStreamResponseV3.result:type_name -> processor.ProcessResult
+	94,  // 82: processor.PreprocessStreamRequest.bindings:type_name -> processor.PreprocessStreamRequest.DataBindings
+	60,  // 83: processor.PreprocessStreamRequest.db_result:type_name -> processor.DBResponse
+	66,  // 84: processor.PreprocessStreamResponse.db_request:type_name -> processor.DBRequest
+	62,  // 85: processor.DBResponse.entity_list:type_name -> processor.EntityList
+	126, // 86: processor.Entity.gen_block_time:type_name -> google.protobuf.Timestamp
+	127, // 87: processor.Entity.data:type_name -> common.RichStruct
+	61,  // 88: processor.EntityList.entities:type_name -> processor.Entity
+	96,  // 89: processor.EntityUpdateData.fields:type_name -> processor.EntityUpdateData.FieldsEntry
+	27,  // 90: processor.TPLRequest.templates:type_name -> processor.TemplateInstance
+	81,  // 91: processor.TSRequest.data:type_name -> processor.TimeseriesResult
+	97,  // 92: processor.DBRequest.get:type_name -> processor.DBRequest.DBGet
+	99,  // 93: processor.DBRequest.upsert:type_name -> processor.DBRequest.DBUpsert
+	100, // 94: processor.DBRequest.update:type_name -> processor.DBRequest.DBUpdate
+	101, // 95: processor.DBRequest.delete:type_name -> processor.DBRequest.DBDelete
+	98,  // 96: processor.DBRequest.list:type_name -> processor.DBRequest.DBList
+	103, // 97: processor.Data.eth_log:type_name -> processor.Data.EthLog
+	104, // 98: processor.Data.eth_block:type_name -> processor.Data.EthBlock
+	105, // 99: processor.Data.eth_transaction:type_name -> processor.Data.EthTransaction
+	106, // 100: processor.Data.eth_trace:type_name -> processor.Data.EthTrace
+	107, // 101: processor.Data.sol_instruction:type_name -> processor.Data.SolInstruction
+	109, // 102: processor.Data.apt_event:type_name -> processor.Data.AptEvent
+	110, // 103: processor.Data.apt_call:type_name -> processor.Data.AptCall
+	111, // 104: processor.Data.apt_resource:type_name -> processor.Data.AptResource
+	112, // 105: processor.Data.sui_event:type_name -> processor.Data.SuiEvent
+	113, // 106: processor.Data.sui_call:type_name -> processor.Data.SuiCall
+	114, // 107: processor.Data.sui_object:type_name -> processor.Data.SuiObject
+	115, // 108: processor.Data.sui_object_change:type_name -> processor.Data.SuiObjectChange
+	116, // 109: processor.Data.fuel_receipt:type_name -> processor.Data.FuelReceipt
+	117, // 110: processor.Data.fuel_transaction:type_name -> processor.Data.FuelTransaction
+	118, // 111: processor.Data.fuel_block:type_name -> processor.Data.FuelBlock
+	119, // 112: processor.Data.cosmos_call:type_name -> processor.Data.CosmosCall
+	120, // 113: processor.Data.starknet_events:type_name -> processor.Data.StarknetEvent
+	108, // 114: processor.Data.sol_block:type_name -> processor.Data.SolBlock
+	121, // 115: processor.Data.btc_transaction:type_name -> processor.Data.BTCTransaction
+	122, // 116: processor.Data.btc_block:type_name -> processor.Data.BTCBlock
+	67,  // 117: processor.DataBinding.data:type_name -> processor.Data
+	4,   // 118: processor.DataBinding.handler_type:type_name -> processor.HandlerType
+	78,  // 119: processor.ProcessResult.gauges:type_name -> processor.GaugeResult
+	79,  // 120: processor.ProcessResult.counters:type_name -> processor.CounterResult
+	80,  // 121: processor.ProcessResult.events:type_name -> processor.EventTrackingResult
+	82,  // 122: processor.ProcessResult.exports:type_name -> processor.ExportResult
+	69,  // 123: processor.ProcessResult.states:type_name -> processor.StateResult
+	81,  // 124: processor.ProcessResult.timeseries_result:type_name -> processor.TimeseriesResult
+	72,  // 125: processor.EthCallParam.context:type_name -> processor.EthCallContext
+	71,  // 126: processor.PreprocessResult.ethCallParams:type_name -> processor.EthCallParam
+	123, // 127: processor.PreparedData.eth_call_results:type_name -> processor.PreparedData.EthCallResultsEntry
+	124, // 128: processor.RecordMetaData.labels:type_name -> processor.RecordMetaData.LabelsEntry
+	128, // 129: processor.MetricValue.big_integer:type_name -> common.BigInteger
+	4,   // 130: processor.RuntimeInfo.from:type_name -> processor.HandlerType
+	75,  // 131: processor.GaugeResult.metadata:type_name -> processor.RecordMetaData
+	76,  // 132: processor.GaugeResult.metric_value:type_name -> processor.MetricValue
+	77,  // 133: processor.GaugeResult.runtime_info:type_name -> processor.RuntimeInfo
+	75,  // 134: processor.CounterResult.metadata:type_name -> processor.RecordMetaData
+	76,  // 135: processor.CounterResult.metric_value:type_name -> processor.MetricValue
+	77,  // 136: processor.CounterResult.runtime_info:type_name -> processor.RuntimeInfo
+	75,  // 137: processor.EventTrackingResult.metadata:type_name -> processor.RecordMetaData
+	125, // 138: processor.EventTrackingResult.attributes:type_name -> google.protobuf.Struct
+	5,   // 139: processor.EventTrackingResult.severity:type_name -> processor.LogLevel
+	77,  // 140: processor.EventTrackingResult.runtime_info:type_name -> processor.RuntimeInfo
+	127, // 141: processor.EventTrackingResult.attributes2:type_name -> common.RichStruct
+	75,  // 142: processor.TimeseriesResult.metadata:type_name -> processor.RecordMetaData
+	11,  // 143: processor.TimeseriesResult.type:type_name -> processor.TimeseriesResult.TimeseriesType
+	127, // 144: processor.TimeseriesResult.data:type_name -> common.RichStruct
+	77,  // 145: processor.TimeseriesResult.runtime_info:type_name -> processor.RuntimeInfo
+	75,  // 146: processor.ExportResult.metadata:type_name -> processor.RecordMetaData
+	77,  // 147: processor.ExportResult.runtime_info:type_name -> processor.RuntimeInfo
+	85,  // 148: processor.EventLogConfig.StructFieldType.fields:type_name -> processor.EventLogConfig.Field
+	7,   // 149: processor.EventLogConfig.Field.basic_type:type_name -> processor.EventLogConfig.BasicFieldType
+	129, // 150: processor.EventLogConfig.Field.coin_type:type_name -> common.CoinID
+	84,  // 151: processor.EventLogConfig.Field.struct_type:type_name -> processor.EventLogConfig.StructFieldType
+	93,  // 152: processor.ProcessStreamResponse.Partitions.partitions:type_name -> processor.ProcessStreamResponse.Partitions.PartitionsEntry
+	8,   // 153: processor.ProcessStreamResponse.Partitions.Partition.sys_value:type_name -> processor.ProcessStreamResponse.Partitions.Partition.SysValue
+	92,  // 154: processor.ProcessStreamResponse.Partitions.PartitionsEntry.value:type_name -> processor.ProcessStreamResponse.Partitions.Partition
+	68,  // 155: processor.PreprocessStreamRequest.DataBindings.bindings:type_name -> processor.DataBinding
+	130, // 156: processor.EntityUpdateData.FieldValue.value:type_name -> common.RichValue
+	9,   // 157: processor.EntityUpdateData.FieldValue.op:type_name -> processor.EntityUpdateData.Operator
+	95,  // 158: processor.EntityUpdateData.FieldsEntry.value:type_name -> processor.EntityUpdateData.FieldValue
+	102, // 159: processor.DBRequest.DBList.filters:type_name -> processor.DBRequest.DBFilter
+	127, // 160: processor.DBRequest.DBUpsert.entity_data:type_name -> common.RichStruct
+	63,  // 161: processor.DBRequest.DBUpdate.entity_data:type_name -> processor.EntityUpdateData
+	10,  // 162: processor.DBRequest.DBFilter.op:type_name -> processor.DBRequest.DBOperator
+	131, // 163: processor.DBRequest.DBFilter.value:type_name -> common.RichValueList
+	126, // 164: processor.Data.EthLog.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 165: processor.Data.EthTransaction.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 166: processor.Data.EthTrace.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 167: processor.Data.SolBlock.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 168: processor.Data.SuiEvent.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 169: processor.Data.SuiCall.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 170: processor.Data.SuiObject.timestamp:type_name -> google.protobuf.Timestamp
+	126, // 171: processor.Data.SuiObjectChange.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 172: processor.Data.FuelReceipt.transaction:type_name -> google.protobuf.Struct
+	126, // 173: processor.Data.FuelReceipt.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 174: processor.Data.FuelTransaction.transaction:type_name -> google.protobuf.Struct
+	126, // 175: processor.Data.FuelTransaction.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 176: processor.Data.FuelBlock.block:type_name -> google.protobuf.Struct
+	126, // 177: processor.Data.FuelBlock.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 178: processor.Data.CosmosCall.transaction:type_name -> google.protobuf.Struct
+	126, // 179: processor.Data.CosmosCall.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 180: processor.Data.StarknetEvent.result:type_name -> google.protobuf.Struct
+	126, // 181: processor.Data.StarknetEvent.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 182: processor.Data.BTCTransaction.transaction:type_name -> google.protobuf.Struct
+	126, // 183: processor.Data.BTCTransaction.timestamp:type_name -> google.protobuf.Timestamp
+	125, // 184: processor.Data.BTCBlock.block:type_name -> google.protobuf.Struct
+	126, // 185: processor.Data.BTCBlock.timestamp:type_name -> google.protobuf.Timestamp
+	30,  // 186: processor.Processor.Start:input_type -> processor.StartRequest
+	132, // 187: processor.Processor.Stop:input_type -> google.protobuf.Empty
+	14,  // 188: processor.Processor.GetConfig:input_type -> processor.ProcessConfigRequest
+	53,  // 189: processor.Processor.ProcessBindings:input_type -> processor.ProcessBindingsRequest
+	55,  // 190: processor.Processor.ProcessBindingsStream:input_type -> processor.ProcessStreamRequest
+	58,  // 191: processor.Processor.PreprocessBindingsStream:input_type -> processor.PreprocessStreamRequest
+	30,  // 192: processor.ProcessorV3.Start:input_type -> processor.StartRequest
+	14,  // 193: processor.ProcessorV3.GetConfig:input_type -> processor.ProcessConfigRequest
+	29,  // 194: processor.ProcessorV3.UpdateTemplates:input_type -> processor.UpdateTemplatesRequest
+	55,  // 195: processor.ProcessorV3.ProcessBindingsStream:input_type -> processor.ProcessStreamRequest
+	132, // 196: processor.Processor.Start:output_type -> google.protobuf.Empty
+	132, // 197: processor.Processor.Stop:output_type -> google.protobuf.Empty
+	15,  // 198: processor.Processor.GetConfig:output_type -> processor.ProcessConfigResponse
+	54,  // 199: processor.Processor.ProcessBindings:output_type -> processor.ProcessBindingResponse
+	56,  // 200: processor.Processor.ProcessBindingsStream:output_type -> processor.ProcessStreamResponse
+	59,  // 201: processor.Processor.PreprocessBindingsStream:output_type -> processor.PreprocessStreamResponse
+	132, // 202: processor.ProcessorV3.Start:output_type -> google.protobuf.Empty
+	15,  // 203: processor.ProcessorV3.GetConfig:output_type -> processor.ProcessConfigResponse
+	132, // 204: processor.ProcessorV3.UpdateTemplates:output_type -> google.protobuf.Empty
+	57,  // 205: processor.ProcessorV3.ProcessBindingsStream:output_type -> processor.ProcessStreamResponseV3
+	196, // [196:206] is the sub-list for method output_type
+	186, // [186:196] is the sub-list for method input_type
+	186, // [186:186] is the sub-list for extension type_name
+	186, // [186:186] is the sub-list for extension extendee
+	0,   // [0:186] is the sub-list for field type_name
 }
 
 func init() { file_processor_protos_processor_proto_init() }
@@ -9186,39 +9490,39 @@ func file_processor_protos_processor_proto_init() {
 	}
 	file_processor_protos_processor_proto_msgTypes[29].OneofWrappers = []any{}
 	file_processor_protos_processor_proto_msgTypes[35].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[42].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[43].OneofWrappers = []any{
 		(*ProcessStreamRequest_Binding)(nil),
 		(*ProcessStreamRequest_DbResult)(nil),
 		(*ProcessStreamRequest_Start)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[43].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[44].OneofWrappers = []any{
 		(*ProcessStreamResponse_DbRequest)(nil),
 		(*ProcessStreamResponse_Result)(nil),
 		(*ProcessStreamResponse_Partitions_)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[44].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[45].OneofWrappers = []any{
 		(*ProcessStreamResponseV3_Partitions)(nil),
 		(*ProcessStreamResponseV3_DbRequest)(nil),
 		(*ProcessStreamResponseV3_TsRequest)(nil),
 		(*ProcessStreamResponseV3_TplRequest)(nil),
 		(*ProcessStreamResponseV3_Result)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[45].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[46].OneofWrappers = []any{
 		(*PreprocessStreamRequest_Bindings)(nil),
 		(*PreprocessStreamRequest_DbResult)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[47].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[48].OneofWrappers = []any{
 		(*DBResponse_Error)(nil),
 		(*DBResponse_EntityList)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[53].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[54].OneofWrappers = []any{
 		(*DBRequest_Get)(nil),
 		(*DBRequest_Upsert)(nil),
 		(*DBRequest_Update)(nil),
 		(*DBRequest_Delete)(nil),
 		(*DBRequest_List)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[54].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[55].OneofWrappers = []any{
 		(*Data_EthLog_)(nil),
 		(*Data_EthBlock_)(nil),
 		(*Data_EthTransaction_)(nil),
@@ -9237,37 +9541,40 @@ func file_processor_protos_processor_proto_init() {
 		(*Data_CosmosCall_)(nil),
 		(*Data_StarknetEvents)(nil),
 		(*Data_SolBlock_)(nil),
+		(*Data_BtcTransaction)(nil),
+		(*Data_BtcBlock)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[56].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[63].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[57].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[64].OneofWrappers = []any{
 		(*MetricValue_BigDecimal)(nil),
 		(*MetricValue_DoubleValue)(nil),
 		(*MetricValue_BigInteger)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[70].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[72].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[71].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[73].OneofWrappers = []any{
 		(*EventLogConfig_Field_BasicType)(nil),
 		(*EventLogConfig_Field_CoinType)(nil),
 		(*EventLogConfig_Field_StructType)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[73].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[78].OneofWrappers = []any{
+	file_processor_protos_processor_proto_msgTypes[74].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[78].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[80].OneofWrappers = []any{
 		(*ProcessStreamResponse_Partitions_Partition_UserValue)(nil),
 		(*ProcessStreamResponse_Partitions_Partition_SysValue_)(nil),
 	}
-	file_processor_protos_processor_proto_msgTypes[84].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[89].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[86].OneofWrappers = []any{}
 	file_processor_protos_processor_proto_msgTypes[91].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[92].OneofWrappers = []any{}
 	file_processor_protos_processor_proto_msgTypes[93].OneofWrappers = []any{}
-	file_processor_protos_processor_proto_msgTypes[100].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[94].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[95].OneofWrappers = []any{}
+	file_processor_protos_processor_proto_msgTypes[102].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_processor_protos_processor_proto_rawDesc), len(file_processor_protos_processor_proto_rawDesc)),
 			NumEnums:      12,
-			NumMessages:   109,
+			NumMessages:   113,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
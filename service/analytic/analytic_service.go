@@ -0,0 +1,516 @@
+package analytic
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/common/gonanoid"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/pager"
+	"sentioxyz/sentio-core/service/analytic/clients/rewriter"
+	"sentioxyz/sentio-core/service/analytic/protos"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	"sentioxyz/sentio-core/service/processor/models"
+	processorrepo "sentioxyz/sentio-core/service/processor/repository"
+	projectprotos "sentioxyz/sentio-core/service/project/protos"
+	rewriterprotos "sentioxyz/sentio-core/service/rewriter/protos"
+)
+
+type Config struct {
+	// the time series tables are read through the clickhouse manager config if it is set, the sharding of
+	// a processor is picked by its clickhouse sharding index, otherwise all processors are read from ClickhouseDSN
+	ClickhouseConfig string `yaml:"clickhouse_config"`
+	ClickhouseDSN    string `yaml:"clickhouse_dsn"`
+	RewriterAddr     string `yaml:"rewriter_addr"`
+
+	StoreCacheSize      int           `yaml:"store_cache_size"`
+	StoreCacheTTL       time.Duration `yaml:"store_cache_ttl"`
+	MetaRefreshInterval time.Duration `yaml:"meta_refresh_interval"`
+
+	// the limit of the sql is replaced by MaxRows if it is missing or greater
+	MaxRows          int32         `yaml:"max_rows"`
+	MaxExecutionTime time.Duration `yaml:"max_execution_time"`
+
+	// at most AsyncWorkers async executions run at the same time in an instance, the others are pending,
+	// the results are kept in redis for ExecutionTTL after the executions finished
+	AsyncWorkers int           `yaml:"async_workers"`
+	ExecutionTTL time.Duration `yaml:"execution_ttl"`
+	// a running execution is cancelled within KillCheckInterval after it is killed through any instance
+	KillCheckInterval time.Duration `yaml:"kill_check_interval"`
+
+	// the rows of a page are adapted to keep the encoded size of the pages near PageTargetBytes
+	PageTargetBytes uint64 `yaml:"page_target_bytes"`
+	PageMinRows     uint64 `yaml:"page_min_rows"`
+	PageMaxRows     uint64 `yaml:"page_max_rows"`
+	PageInitialRows uint64 `yaml:"page_initial_rows"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		StoreCacheSize:      1000,
+		StoreCacheTTL:       time.Minute * 30,
+		MetaRefreshInterval: time.Minute,
+		MaxRows:             10000,
+		MaxExecutionTime:    time.Minute,
+		AsyncWorkers:        4,
+		ExecutionTTL:        time.Hour,
+		KillCheckInterval:   time.Second,
+		PageTargetBytes:     1 << 20,
+		PageMinRows:         10,
+		PageMaxRows:         1000,
+		PageInitialRows:     100,
+	}
+}
+
+func (c Config) pageConfig() pager.Config {
+	return pager.Config{
+		Target:  c.PageTargetBytes,
+		Min:     c.PageMinRows,
+		Max:     c.PageMaxRows,
+		Step:    1,
+		Initial: c.PageInitialRows,
+	}
+}
+
+// ProjectService resolves the project of the requests, it is implemented by project.ProjectService
+type ProjectService interface {
+	GetProject(ctx context.Context, req *projectprotos.ProjectOwnerAndSlug) (*projectprotos.GetProjectResponse, error)
+	GetProjectById(ctx context.Context, req *projectprotos.GetProjectByIdRequest) (*commonprotos.ProjectInfo, error)
+}
+
+type Service struct {
+	protos.UnimplementedAnalyticServiceServer
+
+	// bounds the async executions, they are cancelled if it is done
+	ctx        context.Context
+	config     Config
+	projects   ProjectService
+	processors processorrepo.RedisProcessorRepoInterface
+	stores     StoreProvider
+	rewriter   rewriter.Client
+	executions *executionTable
+	workers    chan struct{}
+	// the number of the async executions of the instance waiting for a worker
+	queued atomic.Int32
+}
+
+func NewService(
+	ctx context.Context,
+	projects ProjectService,
+	processors processorrepo.RedisProcessorRepoInterface,
+	stores StoreProvider,
+	rewriterClient rewriter.Client,
+	redisClient *redis.Client,
+	config Config,
+) *Service {
+	return &Service{
+		ctx:        ctx,
+		config:     config,
+		projects:   projects,
+		processors: processors,
+		stores:     stores,
+		rewriter:   rewriterClient,
+		executions: newExecutionTable(redisClient, config.ExecutionTTL),
+		workers:    make(chan struct{}, max(config.AsyncWorkers, 1)),
+	}
+}
+
+// resolveProject returns the id of the project specified by the id or the owner and slug,
+// both of them must point to the same project if they are all specified
+func (s *Service) resolveProject(ctx context.Context, projectID, owner, slug string) (string, error) {
+	if owner != "" && slug != "" {
+		resp, err := s.projects.GetProject(ctx, &projectprotos.ProjectOwnerAndSlug{OwnerName: owner, Slug: slug})
+		if err != nil {
+			return "", err
+		}
+		if projectID != "" && projectID != resp.GetProject().GetId() {
+			return "", status.Errorf(codes.PermissionDenied, "project %s is not %s/%s", projectID, owner, slug)
+		}
+		return resp.GetProject().GetId(), nil
+	}
+	if projectID != "" {
+		project, err := s.projects.GetProjectById(ctx, &projectprotos.GetProjectByIdRequest{ProjectId: projectID})
+		if err != nil {
+			return "", err
+		}
+		return project.GetId(), nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "project id or project owner and slug is required")
+}
+
+// resolveProcessor returns the processor holding the tables of the project, the active version is used
+// if version is not specified, and the latest version if there is no active one
+func (s *Service) resolveProcessor(ctx context.Context, projectID string, version int32) (*models.Processor, error) {
+	var (
+		processor *models.Processor
+		err       error
+	)
+	if version > 0 {
+		processor, err = s.processors.FindProcessorByVersion(ctx, projectID, version)
+	} else if processor, err = s.processors.FindActiveProcessor(ctx, projectID); err != nil {
+		processor, err = s.processors.FindLatestProcessor(ctx, projectID)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "processor not found: %v", err)
+	}
+	// processors referencing another project read the tables of the referenced one
+	if processor, err = s.processors.ResolveReferenceProcessor(ctx, processor); err != nil {
+		return nil, status.Errorf(codes.NotFound, "reference processor not found: %v", err)
+	}
+	return processor, nil
+}
+
+// engineRole returns the readonly role of the clickhouse user executing the sql with the engine
+func engineRole(engine protos.ExecuteEngine) ckhmanager.Role {
+	switch engine {
+	case protos.ExecuteEngine_PRO, protos.ExecuteEngine_MEDIUM:
+		return ckhmanager.MediumEngineRole
+	case protos.ExecuteEngine_MAX, protos.ExecuteEngine_LARGE:
+		return ckhmanager.LargeEngineRole
+	case protos.ExecuteEngine_ULTRA:
+		return ckhmanager.UltraEngineRole
+	default:
+		return ckhmanager.SmallEngineRole
+	}
+}
+
+// rowLimit returns the number of rows the sql may return, the size of the query is used if it is smaller
+func (s *Service) rowLimit(size int32) int32 {
+	if size > 0 && size < s.config.MaxRows {
+		return size
+	}
+	return s.config.MaxRows
+}
+
+// newExecution checks the sql of the request is read-only and rewrites it to the tables of the processor,
+// the returned execution is pending
+func (s *Service) newExecution(ctx context.Context, req *protos.SQLRequest) (*execution, error) {
+	projectID, err := s.resolveProject(ctx, req.GetProjectId(), req.GetProjectOwner(), req.GetProjectSlug())
+	if err != nil {
+		return nil, err
+	}
+	query := req.GetSqlQuery()
+	if query.GetSql() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "sql is required")
+	}
+	if err = checkReadOnly(query.GetSql()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	processor, err := s.resolveProcessor(ctx, projectID, req.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.stores.GetStore(ctx, processor)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+	conn, err := s.stores.GetConn(processor, engineRole(req.GetEngine()))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+
+	rowLimit := s.rowLimit(query.GetSize())
+	resp, err := s.rewriter.Rewrite(ctx, &rewriterprotos.RewriteSQLRequest{
+		Sql:     query.GetSql(),
+		Options: rewriteOptions(storeRewriteArgs(store), rowLimit, s.config.MaxExecutionTime),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "rewrite sql failed: %v", err)
+	}
+	if resp.GetCode() != rewriterprotos.RewriteCode_Success {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", resp.GetMessage())
+	}
+
+	id, err := gonanoid.GenerateLongID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate execution id failed: %v", err)
+	}
+	queryID, err := gonanoid.GenerateID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate query id failed: %v", err)
+	}
+	return &execution{
+		executionRecord: executionRecord{
+			ID:               id,
+			QueryID:          queryID,
+			ProjectID:        projectID,
+			ProcessorVersion: processor.Version,
+			Status:           protos.ExecutionStatus_PENDING,
+			ScheduledAt:      time.Now(),
+		},
+		sql:      resp.GetSqlAfterRewrite(),
+		rowLimit: int(rowLimit),
+		conn:     conn,
+	}, nil
+}
+
+// readOnlySettings are applied to the user sql, so the server rejects the statements changing the data or the
+// schema even if they pass checkReadOnly. Readonly 2 still allows the settings in the sql, such as the
+// max_execution_time added by the rewriter, but not changing readonly itself.
+var readOnlySettings = clickhouse.Settings{"readonly": 2, "allow_ddl": 0}
+
+// query executes the sql of the execution and returns the encoded result set
+func (s *Service) query(ctx context.Context, e *execution) ([]byte, error) {
+	log.WithContext(ctx).Debugf("executing sql of execution %s: %s", e.ID, e.sql)
+	rows, err := e.conn.Query(ckhmanager.ContextMergeSettings(ctx, readOnlySettings), e.sql)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	result, err := newResultSet(rows, e.rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	return result.encode()
+}
+
+// watchKilled cancels the running execution once it is killed, it may be killed through any instance
+func (s *Service) watchKilled(ctx context.Context, id string, cancel context.CancelFunc) {
+	ticker := time.NewTicker(s.config.KillCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r, err := s.executions.load(ctx, s.executions.client, id)
+		if err != nil {
+			log.WithContext(ctx).Warnfe(err, "check status of execution %s failed", id)
+			continue
+		}
+		if r == nil || r.Status == protos.ExecutionStatus_KILLED {
+			cancel()
+			return
+		}
+	}
+}
+
+// run executes the sql of the execution and returns the latest state of it,
+// it is skipped if the execution is already killed
+func (s *Service) run(ctx context.Context, e *execution) (*executionRecord, error) {
+	r, started, err := s.executions.update(ctx, e.ID, func(r *executionRecord) bool {
+		if r.Status != protos.ExecutionStatus_PENDING {
+			return false
+		}
+		r.Status = protos.ExecutionStatus_RUNNING
+		r.StartedAt = time.Now()
+		return true
+	})
+	if err != nil || !started {
+		return r, err
+	}
+	queryCtx, cancel := context.WithTimeout(ctx, s.config.MaxExecutionTime)
+	defer cancel()
+	go s.watchKilled(queryCtx, e.ID, cancel)
+	result, queryErr := s.query(queryCtx, e)
+	r, _, err = s.executions.update(ctx, e.ID, func(r *executionRecord) bool {
+		if r.Status == protos.ExecutionStatus_KILLED {
+			return false
+		}
+		r.Status = protos.ExecutionStatus_FINISHED
+		r.FinishedAt = time.Now()
+		if queryErr != nil {
+			r.Error = queryErr.Error()
+		} else {
+			r.Result = result
+		}
+		return true
+	})
+	return r, err
+}
+
+// kill marks the execution killed, false is returned if it is already finished
+func (s *Service) kill(ctx context.Context, id string) (bool, error) {
+	var finished bool
+	_, _, err := s.executions.update(ctx, id, func(r *executionRecord) bool {
+		switch r.Status {
+		case protos.ExecutionStatus_FINISHED:
+			finished = true
+			return false
+		case protos.ExecutionStatus_KILLED:
+			return false
+		}
+		r.Status = protos.ExecutionStatus_KILLED
+		r.FinishedAt = time.Now()
+		return true
+	})
+	return !finished, err
+}
+
+func (s *Service) getExecution(
+	ctx context.Context,
+	projectID, owner, slug, executionID string,
+) (*executionRecord, error) {
+	projectID, err := s.resolveProject(ctx, projectID, owner, slug)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.executions.get(ctx, projectID, executionID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load execution %s failed: %v", executionID, err)
+	}
+	if r == nil {
+		return nil, status.Errorf(codes.NotFound, "execution %s not found or expired", executionID)
+	}
+	return r, nil
+}
+
+// nextPage returns the page of the finished execution at the cursor
+func (s *Service) nextPage(ctx context.Context, req *protos.SQLRequest) (*protos.SyncExecuteSQLResponse, error) {
+	at, err := decodeCursor(req.GetCursor())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	r, err := s.getExecution(ctx, req.GetProjectId(), req.GetProjectOwner(), req.GetProjectSlug(), at.ExecutionID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.result()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load result of execution %s failed: %v", r.ID, err)
+	}
+	if result == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "execution %s has no result", r.ID)
+	}
+	cfg := s.config.pageConfig()
+	at.Size = min(at.Size, cfg.Max)
+	return &protos.SyncExecuteSQLResponse{
+		ResponseType: &protos.SyncExecuteSQLResponse_Result{Result: result.page(at, cfg)},
+		ComputeStats: r.computeStats(),
+	}, nil
+}
+
+// info returns the state of the execution with the first page of the result
+func (s *Service) info(r *executionRecord) (*protos.ExecutionInfo, error) {
+	info, err := r.info(s.config.PageInitialRows, s.config.pageConfig())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "load result of execution %s failed: %v", r.ID, err)
+	}
+	return info, nil
+}
+
+func (s *Service) ExecuteSQL(ctx context.Context, req *protos.SQLRequest) (*protos.SyncExecuteSQLResponse, error) {
+	start := time.Now()
+	if req.GetCursor() != "" {
+		return s.nextPage(ctx, req)
+	}
+	e, err := s.newExecution(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// the finished execution is kept to serve the next pages
+	if err = s.executions.add(ctx, &e.executionRecord); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "save execution failed: %v", err)
+	}
+	r, err := s.run(ctx, e)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "save execution failed: %v", err)
+	}
+	if r == nil {
+		return nil, status.Errorf(codes.NotFound, "execution %s expired", e.ID)
+	}
+	info, err := s.info(r)
+	if err != nil {
+		return nil, err
+	}
+	resp := &protos.SyncExecuteSQLResponse{
+		RuntimeCost:  time.Since(start).Milliseconds(),
+		ComputeStats: info.GetComputeStats(),
+	}
+	if info.GetError() != "" {
+		resp.ResponseType = &protos.SyncExecuteSQLResponse_Error{Error: info.GetError()}
+	} else {
+		resp.ResponseType = &protos.SyncExecuteSQLResponse_Result{Result: info.GetResult()}
+	}
+	return resp, nil
+}
+
+func (s *Service) ExecuteSQLAsync(ctx context.Context, req *protos.SQLRequest) (*protos.AsyncExecuteSQLResponse, error) {
+	if req.GetCursor() != "" {
+		return nil, status.Errorf(codes.InvalidArgument, "cursor is not supported by async execution")
+	}
+	e, err := s.newExecution(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.executions.add(ctx, &e.executionRecord); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "save execution failed: %v", err)
+	}
+	queueLength := s.queued.Add(1)
+	go func() {
+		_, logger := log.FromContext(s.ctx, "executionID", e.ID)
+		select {
+		case s.workers <- struct{}{}:
+			s.queued.Add(-1)
+		case <-s.ctx.Done():
+			s.queued.Add(-1)
+			// the instance is stopping, the execution will never run
+			if _, err := s.kill(context.WithoutCancel(s.ctx), e.ID); err != nil {
+				logger.Warnfe(err, "kill execution failed")
+			}
+			return
+		}
+		defer func() {
+			<-s.workers
+		}()
+		if _, err := s.run(s.ctx, e); err != nil {
+			logger.Warnfe(err, "save execution failed")
+		}
+	}()
+	return &protos.AsyncExecuteSQLResponse{
+		QueryId:      e.QueryID,
+		ExecutionId:  e.ID,
+		QueueLength:  queueLength,
+		ComputeStats: &commonprotos.ComputeStats{ComputedAt: timestamppb.New(e.ScheduledAt)},
+	}, nil
+}
+
+func (s *Service) QuerySQLResult(ctx context.Context, req *protos.QuerySQLResultRequest) (*protos.QuerySQLResultResponse, error) {
+	r, err := s.getExecution(ctx, req.GetProjectId(), req.GetProjectOwner(), req.GetProjectSlug(), req.GetExecutionId())
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.info(r)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.QuerySQLResultResponse{ExecutionInfo: info}, nil
+}
+
+func (s *Service) CancelSQLQuery(ctx context.Context, req *protos.CancelSQLQueryRequest) (*emptypb.Empty, error) {
+	r, err := s.getExecution(ctx, req.GetProjectId(), req.GetProjectOwner(), req.GetProjectSlug(), req.GetExecutionId())
+	if err != nil {
+		return nil, err
+	}
+	killed, err := s.kill(ctx, r.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "save execution failed: %v", err)
+	}
+	if !killed {
+		return nil, status.Errorf(codes.FailedPrecondition, "execution %s is already finished", r.ID)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Service) QuerySQLExecutionDetail(
+	ctx context.Context,
+	req *protos.QuerySQLExecutionDetailRequest,
+) (*protos.QuerySQLExecutionDetailResponse, error) {
+	r, err := s.getExecution(ctx, req.GetProjectId(), req.GetProjectOwner(), req.GetProjectSlug(), req.GetExecutionId())
+	if err != nil {
+		return nil, err
+	}
+	return &protos.QuerySQLExecutionDetailResponse{ComputeStats: r.computeStats()}, nil
+}
@@ -0,0 +1,553 @@
+package analytic
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	clickhouselib "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/driver/timeseries"
+	"sentioxyz/sentio-core/service/analytic/protos"
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	"sentioxyz/sentio-core/service/processor/models"
+	processorprotos "sentioxyz/sentio-core/service/processor/protos"
+	processorrepo "sentioxyz/sentio-core/service/processor/repository"
+	"sentioxyz/sentio-core/service/project"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+	rewriterprotos "sentioxyz/sentio-core/service/rewriter/protos"
+)
+
+type fakeColumnType struct {
+	name         string
+	databaseType string
+	scanType     reflect.Type
+}
+
+func (c fakeColumnType) Name() string             { return c.name }
+func (c fakeColumnType) ScanType() reflect.Type   { return c.scanType }
+func (c fakeColumnType) Nullable() bool           { return false }
+func (c fakeColumnType) DatabaseTypeName() string { return c.databaseType }
+
+type fakeRows struct {
+	clickhouselib.Rows
+	columns []fakeColumnType
+	data    [][]any
+	current int
+}
+
+func (r *fakeRows) Next() bool {
+	if r.current >= len(r.data) {
+		return false
+	}
+	r.current++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r.data[r.current-1][i]))
+	}
+	return nil
+}
+
+func (r *fakeRows) Columns() []string {
+	var names []string
+	for _, c := range r.columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+func (r *fakeRows) ColumnTypes() []clickhouselib.ColumnType {
+	var types []clickhouselib.ColumnType
+	for _, c := range r.columns {
+		types = append(types, c)
+	}
+	return types
+}
+
+func (r *fakeRows) Err() error   { return nil }
+func (r *fakeRows) Close() error { return nil }
+
+// fakeConn returns the transfers for all queries, the queries wait for release if it is set
+type fakeConn struct {
+	ckhmanager.Conn
+
+	mu       sync.Mutex
+	queries  []string
+	settings []clickhouse.Settings
+	rows     int
+	release  chan struct{}
+}
+
+func (c *fakeConn) GetDatabase() string { return "test" }
+
+func (c *fakeConn) Query(ctx context.Context, sql string, _ ...any) (clickhouselib.Rows, error) {
+	c.mu.Lock()
+	c.queries = append(c.queries, sql)
+	c.settings = append(c.settings, ckhmanager.ContextSettings(ctx))
+	c.mu.Unlock()
+	if c.release != nil {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	rows := &fakeRows{columns: []fakeColumnType{
+		{name: "from", databaseType: "String", scanType: reflect.TypeOf("")},
+		{name: "value", databaseType: "UInt64", scanType: reflect.TypeOf(uint64(0))},
+		{name: "timestamp", databaseType: "DateTime64(6, 'UTC')", scanType: reflect.TypeOf(time.Time{})},
+	}}
+	for i := 0; i < c.rows; i++ {
+		rows.data = append(rows.data, []any{
+			fmt.Sprintf("0x%d", i), uint64(i), time.Date(2025, 1, 1, 0, 0, i, 0, time.UTC)})
+	}
+	return rows, nil
+}
+
+func (c *fakeConn) lastQuery() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queries[len(c.queries)-1]
+}
+
+type fakeStoreMeta struct {
+	timeseries.StoreMeta
+	metas map[timeseries.MetaType]map[string]timeseries.Meta
+}
+
+func (m *fakeStoreMeta) MetaByType(t timeseries.MetaType) map[string]timeseries.Meta {
+	return m.metas[t]
+}
+
+type fakeStore struct {
+	meta *fakeStoreMeta
+	conn *fakeConn
+}
+
+func (s *fakeStore) Meta() timeseries.StoreMeta {
+	return s.meta
+}
+
+func (s *fakeStore) MetaTableName(meta timeseries.Meta) string {
+	return fmt.Sprintf("`p1_%s_%s`", meta.Type, meta.Name)
+}
+
+func (s *fakeStore) Client() chx.Conn {
+	return s.conn
+}
+
+type fakeStoreProvider struct {
+	store *fakeStore
+	roles []ckhmanager.Role
+}
+
+func (p *fakeStoreProvider) GetStore(_ context.Context, _ *models.Processor) (Store, error) {
+	return p.store, nil
+}
+
+func (p *fakeStoreProvider) GetConn(_ *models.Processor, role ckhmanager.Role) (ckhmanager.Conn, error) {
+	p.roles = append(p.roles, role)
+	return p.store.conn, nil
+}
+
+// fakeRewriter replaces the quoted table names by the tables in the options
+type fakeRewriter struct {
+	requests []*rewriterprotos.RewriteSQLRequest
+}
+
+func (r *fakeRewriter) Rewrite(_ context.Context, req *rewriterprotos.RewriteSQLRequest) (*rewriterprotos.RewriteSQLResponse, error) {
+	r.requests = append(r.requests, req)
+	if strings.Contains(req.GetSql(), "syntax error") {
+		return &rewriterprotos.RewriteSQLResponse{Code: rewriterprotos.RewriteCode_SyntaxError, Message: "syntax error"}, nil
+	}
+	sql := req.GetSql()
+	for _, op := range req.GetOptions() {
+		for name, table := range op.GetTableNameArgs().GetTableWithDatabaseMap() {
+			sql = strings.ReplaceAll(sql, "`"+name+"`", fmt.Sprintf("`%s`.`%s`", table.GetDatabase(), table.GetTable()))
+		}
+	}
+	return &rewriterprotos.RewriteSQLResponse{Code: rewriterprotos.RewriteCode_Success, SqlAfterRewrite: sql}, nil
+}
+
+func (r *fakeRewriter) RewriteErrorMessage(
+	context.Context,
+	*rewriterprotos.RewriteErrorMessageRequest,
+) (*rewriterprotos.RewriteErrorMessageResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fakeRewriter) Format(context.Context, *rewriterprotos.FormatSQLRequest) (*rewriterprotos.FormatSQLResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+type testEnv struct {
+	service *Service
+	// another instance sharing the same redis
+	other    *Service
+	conn     *fakeConn
+	provider *fakeStoreProvider
+	rewriter *fakeRewriter
+}
+
+func newTestEnv(t *testing.T, config Config) *testEnv {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	projects := projectrepo.NewRedisProjectRepository(redisClient)
+	require.NoError(t, projects.SaveProject(ctx, &commonmodels.Project{ID: "project", Slug: "slug", OwnerName: "owner"}))
+	require.NoError(t, projects.SaveProject(ctx, &commonmodels.Project{ID: "other", Slug: "other", OwnerName: "owner"}))
+	processors := processorrepo.NewRedisProcessorRepo(redisClient)
+	require.NoError(t, processors.SaveProcessor(ctx, &models.Processor{
+		ID: "p1", ProjectID: "project", Version: 1, VersionState: int32(processorprotos.ProcessorVersionState_ACTIVE)}))
+
+	conn := &fakeConn{rows: 8}
+	provider := &fakeStoreProvider{store: &fakeStore{
+		conn: conn,
+		meta: &fakeStoreMeta{metas: map[timeseries.MetaType]map[string]timeseries.Meta{
+			timeseries.MetaTypeEvent: {
+				"Transfer": {Name: "Transfer", Type: timeseries.MetaTypeEvent},
+				"balance":  {Name: "balance", Type: timeseries.MetaTypeEvent},
+			},
+			timeseries.MetaTypeGauge: {
+				"balance": {Name: "balance", Type: timeseries.MetaTypeGauge},
+			},
+		}},
+	}}
+	rewriter := &fakeRewriter{}
+	return &testEnv{
+		service:  NewService(ctx, project.NewProjectService(projects), processors, provider, rewriter, redisClient, config),
+		other:    NewService(ctx, project.NewProjectService(projects), processors, provider, rewriter, redisClient, config),
+		conn:     conn,
+		provider: provider,
+		rewriter: rewriter,
+	}
+}
+
+func sqlRequest(sql string, size int32) *protos.SQLRequest {
+	return &protos.SQLRequest{
+		ProjectOwner: "owner",
+		ProjectSlug:  "slug",
+		QueryType:    &protos.SQLRequest_SqlQuery{SqlQuery: &protos.SQLQuery{Sql: sql, Size: size}},
+	}
+}
+
+func Test_checkReadOnly(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT * FROM `Transfer`",
+		"  select 1;  ",
+		"WITH t AS (SELECT 1) SELECT * FROM t",
+		"(SELECT 1) UNION ALL (SELECT 2)",
+		"-- drop table\nSELECT 'a;b', \"x;y\" /* ; DELETE */",
+		"SELECT 'it''s; INSERT INTO t'",
+		"SELECT number FROM numbers(10)",
+		"SELECT * FROM t JOIN (SELECT 1) AS s ON true",
+		"SELECT extract(DAY FROM ts), substring(s FROM 1 FOR 2) FROM t",
+		"SELECT 'url(1)' FROM `url`",
+		"SELECT extract(DAY FROM toDate(ts)) FROM t",
+		"SELECT a FROM t ARRAY JOIN arrayMap(x -> x * 2, arr) AS a, range(3) AS b",
+		"SELECT * FROM db.t AS x, numbers(10) AS n",
+		"SELECT * FROM (SELECT 1) AS a, (SELECT 2) AS b",
+	} {
+		assert.NoError(t, checkReadOnly(sql), sql)
+	}
+	for _, sql := range []string{
+		"",
+		" -- comment only",
+		"INSERT INTO t VALUES (1)",
+		"DROP TABLE `Transfer`",
+		"SELECT 1; DROP TABLE t",
+		"SELECT 1; 'x'",
+		"SELECT * FROM t INTO OUTFILE 'a.csv'",
+		"SELECT 'unterminated",
+		"SELECT 1 /* unterminated",
+		"SELECT * FROM url('http://example.com/a.csv', CSV)",
+		"SELECT * FROM S3('https://bucket/a.parquet')",
+		"select * from t join file('/etc/passwd', 'LineAsString') as f on true",
+		"SELECT * FROM t WHERE x IN (SELECT x FROM remote('127.0.0.1', system.users))",
+		"SELECT * FROM `remoteSecure` ('127.0.0.1', db.t)",
+		"SELECT * FROM /* c */ mysql('h', 'db', 't', 'u', 'p')",
+		"SELECT * FROM t, url('http://example.com/a.csv', CSV)",
+		"SELECT * FROM t AS a, `s3`('https://bucket/a.parquet') AS b",
+		"SELECT * FROM t ARRAY JOIN arr AS a LEFT JOIN file('a.csv') AS f ON true",
+		"SELECT * FROM (SELECT * FROM t, (SELECT 1) AS x, remote('h', db.t)) AS s",
+		"SELECT (SELECT count() FROM t, url('http://example.com/a.csv', CSV))",
+		"SELECT * FROM t WHERE (1",
+	} {
+		assert.Error(t, checkReadOnly(sql), sql)
+	}
+}
+
+func Test_rewriteOptions(t *testing.T) {
+	env := newTestEnv(t, DefaultConfig())
+	options := rewriteOptions(storeRewriteArgs(env.provider.store), 100, time.Minute)
+	require.Len(t, options, 3)
+
+	tables := options[0].GetTableNameArgs().GetTableWithDatabaseMap()
+	assert.Equal(t, rewriterprotos.RewriteOp_TableNameRewrite, options[0].GetOp())
+	assert.Len(t, tables, 2)
+	assert.Equal(t, "test", tables["Transfer"].GetDatabase())
+	assert.Equal(t, "p1_event_Transfer", tables["Transfer"].GetTable())
+	// events take precedence over the metrics with the same name
+	assert.Equal(t, "p1_event_balance", tables["balance"].GetTable())
+
+	assert.Equal(t, rewriterprotos.RewriteOp_LimitRewrite, options[1].GetOp())
+	assert.Equal(t, int32(100), options[1].GetLimitArgs().GetReplaceLimit().GetThreshold())
+	assert.Equal(t, int32(100), options[1].GetLimitArgs().GetReplaceLimit().GetReplaceTo())
+
+	assert.Equal(t, rewriterprotos.RewriteOp_SettingsRewrite, options[2].GetOp())
+	settings := options[2].GetSettingsArgs().GetSettings()
+	require.Len(t, settings, 1)
+	assert.Equal(t, "max_execution_time", settings[0].GetKey())
+	assert.Equal(t, int32(60), settings[0].GetIntValue())
+
+	// the execution time is not limited by the rewriter if it is less than a second
+	assert.Len(t, rewriteOptions(storeRewriteArgs(env.provider.store), 100, time.Millisecond), 2)
+}
+
+func Test_ExecuteSQL(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxRows = 6
+	config.PageMinRows = 1
+	config.PageMaxRows = 3
+	config.PageInitialRows = 2
+	env := newTestEnv(t, config)
+	ctx := context.Background()
+
+	resp, err := env.service.ExecuteSQL(ctx, sqlRequest("SELECT * FROM `Transfer`", 0))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `test`.`p1_event_Transfer`", env.conn.lastQuery())
+	// the server rejects the statements changing anything
+	assert.Equal(t, clickhouse.Settings{"readonly": 2, "allow_ddl": 0}, env.conn.settings[0])
+	assert.Equal(t, []ckhmanager.Role{ckhmanager.SmallEngineRole}, env.provider.roles)
+	limit := env.rewriter.requests[0].GetOptions()[1].GetLimitArgs().GetReplaceLimit()
+	assert.Equal(t, int32(6), limit.GetReplaceTo())
+
+	// the rows are limited even if the sql returns more, and returned page by page
+	result := resp.GetResult()
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"from", "value", "timestamp"}, result.GetColumns())
+	assert.Equal(t, commonprotos.TabularData_NUMBER, result.GetColumnTypes()["value"])
+	assert.Equal(t, commonprotos.TabularData_TIME, result.GetColumnTypes()["timestamp"])
+	assert.Len(t, result.GetRows(), 2)
+	assert.Equal(t, "0x0", result.GetRows()[0].GetFields()["from"].GetStringValue())
+	assert.Equal(t, "2025-01-01T00:00:00Z", result.GetRows()[0].GetFields()["timestamp"].GetStringValue())
+
+	var (
+		values []float64
+		pages  []int
+	)
+	for {
+		pages = append(pages, len(result.GetRows()))
+		for _, row := range result.GetRows() {
+			values = append(values, row.GetFields()["value"].GetNumberValue())
+		}
+		if result.GetCursor() == "" {
+			break
+		}
+		resp, err = env.service.ExecuteSQL(ctx, &protos.SQLRequest{
+			ProjectOwner: "owner",
+			ProjectSlug:  "slug",
+			QueryType:    &protos.SQLRequest_Cursor{Cursor: result.GetCursor()},
+		})
+		require.NoError(t, err)
+		result = resp.GetResult()
+	}
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5}, values)
+	assert.Equal(t, []int{2, 3, 1}, pages)
+
+	// the size of the query is used if it is smaller than the max rows
+	resp, err = env.service.ExecuteSQL(ctx, sqlRequest("SELECT * FROM `Transfer`", 1))
+	require.NoError(t, err)
+	assert.Len(t, resp.GetResult().GetRows(), 1)
+	assert.Empty(t, resp.GetResult().GetCursor())
+	limit = env.rewriter.requests[1].GetOptions()[1].GetLimitArgs().GetReplaceLimit()
+	assert.Equal(t, int32(1), limit.GetReplaceTo())
+
+	// the cursor can not be used by other projects
+	_, err = env.service.ExecuteSQL(ctx, &protos.SQLRequest{
+		ProjectId: "other",
+		QueryType: &protos.SQLRequest_Cursor{Cursor: cursor{ExecutionID: "unknown", Size: 1}.encode()},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = env.service.ExecuteSQL(ctx, &protos.SQLRequest{
+		ProjectId: "project",
+		QueryType: &protos.SQLRequest_Cursor{Cursor: "invalid"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_ExecuteSQL_rejected(t *testing.T) {
+	env := newTestEnv(t, DefaultConfig())
+	ctx := context.Background()
+
+	_, err := env.service.ExecuteSQL(ctx, sqlRequest("ALTER TABLE `Transfer` DELETE WHERE 1", 0))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = env.service.ExecuteSQL(ctx, sqlRequest("SELECT 1; DROP TABLE `Transfer`", 0))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = env.service.ExecuteSQL(ctx, sqlRequest("", 0))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Empty(t, env.rewriter.requests)
+
+	_, err = env.service.ExecuteSQL(ctx, sqlRequest("SELECT syntax error", 0))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Empty(t, env.conn.queries)
+}
+
+func Test_ExecuteSQL_timeout(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxExecutionTime = time.Millisecond * 50
+	env := newTestEnv(t, config)
+	env.conn.release = make(chan struct{})
+
+	resp, err := env.service.ExecuteSQL(context.Background(), sqlRequest("SELECT * FROM `Transfer`", 0))
+	require.NoError(t, err)
+	assert.Contains(t, resp.GetError(), context.DeadlineExceeded.Error())
+}
+
+func Test_ExecuteSQLAsync(t *testing.T) {
+	config := DefaultConfig()
+	config.AsyncWorkers = 1
+	env := newTestEnv(t, config)
+	env.conn.release = make(chan struct{})
+	ctx := context.Background()
+
+	req := sqlRequest("SELECT * FROM `Transfer`", 0)
+	req.Engine = protos.ExecuteEngine_LARGE.Enum()
+	first, err := env.service.ExecuteSQLAsync(ctx, req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.GetQueryId())
+	assert.Equal(t, []ckhmanager.Role{ckhmanager.LargeEngineRole}, env.provider.roles)
+	second, err := env.service.ExecuteSQLAsync(ctx, req)
+	require.NoError(t, err)
+
+	getStatus := func(executionID string) *protos.ExecutionInfo {
+		resp, err := env.service.QuerySQLResult(ctx, &protos.QuerySQLResultRequest{
+			ProjectId:   "project",
+			ExecutionId: executionID,
+		})
+		require.NoError(t, err)
+		return resp.GetExecutionInfo()
+	}
+	// only one execution runs at the same time
+	require.Eventually(t, func() bool {
+		return getStatus(first.GetExecutionId()).GetStatus() == protos.ExecutionStatus_RUNNING ||
+			getStatus(second.GetExecutionId()).GetStatus() == protos.ExecutionStatus_RUNNING
+	}, time.Second, time.Millisecond*10)
+	running, pending := first, second
+	if getStatus(second.GetExecutionId()).GetStatus() == protos.ExecutionStatus_RUNNING {
+		running, pending = second, first
+	}
+	assert.Equal(t, protos.ExecutionStatus_PENDING, getStatus(pending.GetExecutionId()).GetStatus())
+
+	// the executions of other projects are invisible
+	_, err = env.service.QuerySQLResult(ctx, &protos.QuerySQLResultRequest{
+		ProjectId:   "other",
+		ExecutionId: running.GetExecutionId(),
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = env.service.CancelSQLQuery(ctx, &protos.CancelSQLQueryRequest{
+		ProjectId:   "project",
+		ExecutionId: pending.GetExecutionId(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, protos.ExecutionStatus_KILLED, getStatus(pending.GetExecutionId()).GetStatus())
+
+	close(env.conn.release)
+	require.Eventually(t, func() bool {
+		return getStatus(running.GetExecutionId()).GetStatus() == protos.ExecutionStatus_FINISHED
+	}, time.Second, time.Millisecond*10)
+	info := getStatus(running.GetExecutionId())
+	assert.Len(t, info.GetResult().GetRows(), 8)
+	assert.Empty(t, info.GetResult().GetCursor())
+	assert.Equal(t, protos.ExecutionStatus_KILLED, getStatus(pending.GetExecutionId()).GetStatus())
+	assert.Len(t, env.conn.queries, 1)
+
+	_, err = env.service.CancelSQLQuery(ctx, &protos.CancelSQLQueryRequest{
+		ProjectId:   "project",
+		ExecutionId: running.GetExecutionId(),
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func Test_ExecuteSQLAsync_otherInstance(t *testing.T) {
+	config := DefaultConfig()
+	config.PageMinRows = 1
+	config.PageMaxRows = 3
+	config.PageInitialRows = 3
+	config.KillCheckInterval = time.Millisecond * 10
+	env := newTestEnv(t, config)
+	env.conn.release = make(chan struct{})
+	ctx := context.Background()
+
+	getStatus := func(executionID string) *protos.ExecutionInfo {
+		resp, err := env.other.QuerySQLResult(ctx, &protos.QuerySQLResultRequest{
+			ProjectId:   "project",
+			ExecutionId: executionID,
+		})
+		require.NoError(t, err)
+		return resp.GetExecutionInfo()
+	}
+
+	// the running execution is cancelled after it is killed through the other instance
+	killed, err := env.service.ExecuteSQLAsync(ctx, sqlRequest("SELECT * FROM `Transfer`", 0))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return getStatus(killed.GetExecutionId()).GetStatus() == protos.ExecutionStatus_RUNNING
+	}, time.Second, time.Millisecond*10)
+	_, err = env.other.CancelSQLQuery(ctx, &protos.CancelSQLQueryRequest{
+		ProjectId:   "project",
+		ExecutionId: killed.GetExecutionId(),
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(env.service.workers) == 0
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, protos.ExecutionStatus_KILLED, getStatus(killed.GetExecutionId()).GetStatus())
+
+	// the result is paged through the other instance
+	close(env.conn.release)
+	finished, err := env.service.ExecuteSQLAsync(ctx, sqlRequest("SELECT * FROM `Transfer`", 0))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return getStatus(finished.GetExecutionId()).GetStatus() == protos.ExecutionStatus_FINISHED
+	}, time.Second, time.Millisecond*10)
+	result := getStatus(finished.GetExecutionId()).GetResult()
+	var values []float64
+	for {
+		for _, row := range result.GetRows() {
+			values = append(values, row.GetFields()["value"].GetNumberValue())
+		}
+		if result.GetCursor() == "" {
+			break
+		}
+		resp, err := env.other.ExecuteSQL(ctx, &protos.SQLRequest{
+			ProjectId: "project",
+			QueryType: &protos.SQLRequest_Cursor{Cursor: result.GetCursor()},
+		})
+		require.NoError(t, err)
+		result = resp.GetResult()
+	}
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5, 6, 7}, values)
+}
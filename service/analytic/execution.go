@@ -0,0 +1,184 @@
+package analytic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/common/pager"
+	"sentioxyz/sentio-core/service/analytic/protos"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+)
+
+// executionRecord is the state of an execution, it is saved in the execution table and shared by all instances
+type executionRecord struct {
+	ID               string                 `json:"id"`
+	QueryID          string                 `json:"queryId"`
+	ProjectID        string                 `json:"projectId"`
+	ProcessorVersion int32                  `json:"processorVersion"`
+	Status           protos.ExecutionStatus `json:"status"`
+	ScheduledAt      time.Time              `json:"scheduledAt"`
+	StartedAt        time.Time              `json:"startedAt"`
+	FinishedAt       time.Time              `json:"finishedAt"`
+	Error            string                 `json:"error,omitempty"`
+	// Result is the encoded result set, see resultSet.encode
+	Result []byte `json:"result,omitempty"`
+}
+
+// execution is a run of the rewritten sql, the sql and the connection are only known by the instance running it
+type execution struct {
+	executionRecord
+
+	sql      string
+	rowLimit int
+	conn     ckhmanager.Conn
+}
+
+func (r *executionRecord) computeStats() *commonprotos.ComputeStats {
+	stats := &commonprotos.ComputeStats{}
+	if !r.StartedAt.IsZero() {
+		stats.ComputedAt = timestamppb.New(r.StartedAt)
+		if !r.FinishedAt.IsZero() {
+			stats.ComputeCostMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+		}
+	}
+	return stats
+}
+
+// result returns the result set of the finished execution, nil if it has no result
+func (r *executionRecord) result() (*resultSet, error) {
+	if r.Result == nil {
+		return nil, nil
+	}
+	return decodeResultSet(r.Result)
+}
+
+// info returns the state of the execution, the first page of the result is included if it is finished
+func (r *executionRecord) info(firstPage uint64, cfg pager.Config) (*protos.ExecutionInfo, error) {
+	info := &protos.ExecutionInfo{
+		QueryId:          r.QueryID,
+		ExecutionId:      r.ID,
+		Status:           r.Status,
+		ScheduledAt:      timestamppb.New(r.ScheduledAt),
+		ComputeStats:     r.computeStats(),
+		ProcessorVersion: r.ProcessorVersion,
+	}
+	if !r.StartedAt.IsZero() {
+		info.StartedAt = timestamppb.New(r.StartedAt)
+	}
+	if !r.FinishedAt.IsZero() {
+		info.FinishedAt = timestamppb.New(r.FinishedAt)
+	}
+	result, err := r.result()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case r.Error != "":
+		info.ResponseType = &protos.ExecutionInfo_Error{Error: r.Error}
+	case result != nil:
+		info.ResponseType = &protos.ExecutionInfo_Result{
+			Result: result.page(cursor{ExecutionID: r.ID, Size: firstPage}, cfg),
+		}
+	}
+	return info, nil
+}
+
+const executionKeyPrefix = "analytic:execution:"
+
+// maxUpdateRetries bounds the retries of an update conflicting with the updates from other instances
+const maxUpdateRetries = 10
+
+// executionTable keeps the executions in redis, so an execution started by one instance can be queried, paged
+// and cancelled through any instance, also after the instance restarts. The executions expire after the ttl
+// since they were updated last time.
+type executionTable struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func newExecutionTable(client *redis.Client, ttl time.Duration) *executionTable {
+	return &executionTable{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func executionKey(id string) string {
+	return executionKeyPrefix + id
+}
+
+func (t *executionTable) add(ctx context.Context, r *executionRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal execution %s failed: %w", r.ID, err)
+	}
+	return t.client.Set(ctx, executionKey(r.ID), data, t.ttl).Err()
+}
+
+func (t *executionTable) load(ctx context.Context, cmd redis.Cmdable, id string) (*executionRecord, error) {
+	data, err := cmd.Get(ctx, executionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r executionRecord
+	if err = json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("unmarshal execution %s failed: %w", id, err)
+	}
+	return &r, nil
+}
+
+// get returns the execution only if it belongs to the project, nil means it is not found or expired
+func (t *executionTable) get(ctx context.Context, projectID, id string) (*executionRecord, error) {
+	r, err := t.load(ctx, t.client, id)
+	if err != nil || r == nil || r.ProjectID != projectID {
+		return nil, err
+	}
+	return r, nil
+}
+
+// update changes the execution by fn and saves it if fn returns true, other instances may update the same
+// execution at the same time, so fn may be called more than once. The latest state of the execution is
+// returned, nil means it is not found or expired.
+func (t *executionTable) update(
+	ctx context.Context,
+	id string,
+	fn func(r *executionRecord) bool,
+) (r *executionRecord, updated bool, err error) {
+	key := executionKey(id)
+	for i := 0; i < maxUpdateRetries; i++ {
+		err = t.client.Watch(ctx, func(tx *redis.Tx) error {
+			r, updated = nil, false
+			cur, loadErr := t.load(ctx, tx, id)
+			if loadErr != nil || cur == nil {
+				return loadErr
+			}
+			r = cur
+			if !fn(r) {
+				return nil
+			}
+			data, marshalErr := json.Marshal(r)
+			if marshalErr != nil {
+				return fmt.Errorf("marshal execution %s failed: %w", id, marshalErr)
+			}
+			_, txErr := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				return pipe.Set(ctx, key, data, t.ttl).Err()
+			})
+			updated = txErr == nil
+			return txErr
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return r, updated, err
+		}
+	}
+	return nil, false, fmt.Errorf("update execution %s failed: %w", id, err)
+}
@@ -0,0 +1,180 @@
+package analytic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	clickhouselib "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sentioxyz/sentio-core/common/pager"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	"sentioxyz/sentio-core/service/common/timeseries/converter"
+	"sentioxyz/sentio-core/service/common/timeseries/matrix"
+)
+
+// resultSet is the whole result of an execution, the pages of it are returned to the client
+type resultSet struct {
+	columns     []string
+	columnTypes map[string]commonprotos.TabularData_ColumnType
+	rows        []*structpb.Struct
+	// the encoded size of each row, used to adapt the size of the pages
+	sizes       []uint64
+	generatedAt time.Time
+}
+
+// columnType maps the clickhouse type of a column to the type of the tabular data
+func columnType(databaseType string) commonprotos.TabularData_ColumnType {
+	t := databaseType
+	for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+		for strings.HasPrefix(t, wrapper) {
+			t = strings.TrimSuffix(strings.TrimPrefix(t, wrapper), ")")
+		}
+	}
+	switch {
+	case t == "Bool":
+		return commonprotos.TabularData_BOOLEAN
+	case strings.HasPrefix(t, "Int"), strings.HasPrefix(t, "UInt"),
+		strings.HasPrefix(t, "Float"), strings.HasPrefix(t, "Decimal"):
+		return commonprotos.TabularData_NUMBER
+	case strings.HasPrefix(t, "Date"):
+		return commonprotos.TabularData_TIME
+	case strings.HasPrefix(t, "Array("):
+		return commonprotos.TabularData_LIST
+	case strings.HasPrefix(t, "Map("), strings.HasPrefix(t, "Tuple("):
+		return commonprotos.TabularData_MAP
+	case strings.HasPrefix(t, "JSON"), strings.HasPrefix(t, "Object("):
+		return commonprotos.TabularData_JSON
+	case t == "Dynamic":
+		return commonprotos.TabularData_DYNAMIC
+	default:
+		return commonprotos.TabularData_STRING
+	}
+}
+
+func toValue(v any) *structpb.Value {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewStringValue(fmt.Sprint(v))
+	}
+	return value
+}
+
+// newResultSet reads all rows, at most rowLimit rows are kept even if the sql returns more
+func newResultSet(rows clickhouselib.Rows, rowLimit int) (*resultSet, error) {
+	m, err := matrix.NewMatrix(rows)
+	if err != nil {
+		return nil, err
+	}
+	r := &resultSet{
+		columns:     m.ColumnNames(),
+		columnTypes: make(map[string]commonprotos.TabularData_ColumnType),
+		generatedAt: time.Now(),
+	}
+	for i, name := range r.columns {
+		r.columnTypes[name] = columnType(m.ColumnType(i).DatabaseTypeName())
+	}
+	for i, data := range m.Data() {
+		if i >= rowLimit {
+			break
+		}
+		row := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(data))}
+		for j, v := range data {
+			row.Fields[r.columns[j]] = toValue(converter.ConvertAny(v, &converter.ConvertOption{
+				Timezone:     time.UTC,
+				DatabaseType: m.ColumnType(j).DatabaseTypeName(),
+			}))
+		}
+		r.rows = append(r.rows, row)
+		r.sizes = append(r.sizes, uint64(proto.Size(row)))
+	}
+	return r, nil
+}
+
+// encode returns the result set with all rows as an encoded commonprotos.TabularData
+func (r *resultSet) encode() ([]byte, error) {
+	return proto.Marshal(&commonprotos.TabularData{
+		Columns:     r.columns,
+		ColumnTypes: r.columnTypes,
+		Rows:        r.rows,
+		GeneratedAt: timestamppb.New(r.generatedAt),
+	})
+}
+
+// decodeResultSet loads the result set saved by resultSet.encode
+func decodeResultSet(data []byte) (*resultSet, error) {
+	var d commonprotos.TabularData
+	if err := proto.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("decode result failed: %w", err)
+	}
+	r := &resultSet{
+		columns:     d.GetColumns(),
+		columnTypes: d.GetColumnTypes(),
+		rows:        d.GetRows(),
+		generatedAt: d.GetGeneratedAt().AsTime(),
+	}
+	for _, row := range r.rows {
+		r.sizes = append(r.sizes, uint64(proto.Size(row)))
+	}
+	return r, nil
+}
+
+// cursor points to the next page of the result of an execution
+type cursor struct {
+	ExecutionID string `json:"e"`
+	Offset      uint64 `json:"o"`
+	Size        uint64 `json:"s"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if err = json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if c.ExecutionID == "" || c.Size == 0 {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// page returns the rows of the page at the cursor, the cursor of the next page is set if there are more rows.
+// The size of the next page is chosen by the pager to keep the encoded size of the pages near its target.
+func (r *resultSet) page(at cursor, cfg pager.Config) *commonprotos.TabularData {
+	data := &commonprotos.TabularData{
+		Columns:     r.columns,
+		ColumnTypes: r.columnTypes,
+		GeneratedAt: timestamppb.New(r.generatedAt),
+	}
+	total := uint64(len(r.rows))
+	if at.Offset >= total {
+		return data
+	}
+	end := min(at.Offset+at.Size, total)
+	data.Rows = r.rows[at.Offset:end]
+	if end < total {
+		var size uint64
+		for _, s := range r.sizes[at.Offset:end] {
+			size += s
+		}
+		data.Cursor = cursor{
+			ExecutionID: at.ExecutionID,
+			Offset:      end,
+			Size:        cfg.NextSize(end-at.Offset, size),
+		}.encode()
+	}
+	return data
+}
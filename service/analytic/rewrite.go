@@ -0,0 +1,395 @@
+package analytic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"sentioxyz/sentio-core/driver/timeseries"
+	"sentioxyz/sentio-core/service/common/timeseriessql/rewriteimpl"
+	rewriterprotos "sentioxyz/sentio-core/service/rewriter/protos"
+)
+
+// rewriteArgs are the tables, remote tables and common table expressions the user sql is rewritten with
+type rewriteArgs struct {
+	ctes    map[string]rewriteimpl.CommonTableExpression
+	tables  map[string]rewriteimpl.DatabaseTableArgs
+	remotes map[string]rewriteimpl.RemoteArgs
+}
+
+func newRewriteArgs() *rewriteArgs {
+	return &rewriteArgs{
+		ctes:    make(map[string]rewriteimpl.CommonTableExpression),
+		tables:  make(map[string]rewriteimpl.DatabaseTableArgs),
+		remotes: make(map[string]rewriteimpl.RemoteArgs),
+	}
+}
+
+func (a *rewriteArgs) GetCommonTableExpressions() map[string]rewriteimpl.CommonTableExpression {
+	return a.ctes
+}
+
+func (a *rewriteArgs) GetDatabaseTables() map[string]rewriteimpl.DatabaseTableArgs {
+	return a.tables
+}
+
+func (a *rewriteArgs) GetRemotes() map[string]rewriteimpl.RemoteArgs {
+	return a.remotes
+}
+
+// Merge adds the args of other, the existing ones are kept if both have the same name
+func (a *rewriteArgs) Merge(other rewriteimpl.Args) {
+	for name, cte := range other.GetCommonTableExpressions() {
+		if _, has := a.ctes[name]; !has {
+			a.ctes[name] = cte
+		}
+	}
+	for name, table := range other.GetDatabaseTables() {
+		if _, has := a.tables[name]; !has {
+			a.tables[name] = table
+		}
+	}
+	for name, remote := range other.GetRemotes() {
+		if _, has := a.remotes[name]; !has {
+			a.remotes[name] = remote
+		}
+	}
+}
+
+// tableMetaTypes are the meta types visible to the user sql, in the priority of the table names
+var tableMetaTypes = []timeseries.MetaType{timeseries.MetaTypeEvent, timeseries.MetaTypeGauge, timeseries.MetaTypeCounter}
+
+// storeRewriteArgs maps the names of the events and metrics of the store to their physical tables
+func storeRewriteArgs(store Store) rewriteimpl.Args {
+	args := newRewriteArgs()
+	database := store.Client().GetDatabase()
+	for _, metaType := range tableMetaTypes {
+		for name, meta := range store.Meta().MetaByType(metaType) {
+			if _, has := args.tables[name]; has {
+				continue
+			}
+			args.tables[name] = &rewriterprotos.RewriteTableNameArgs_TableWithDatabase{
+				Database: database,
+				Table:    strings.Trim(store.MetaTableName(meta), "`"),
+			}
+		}
+	}
+	return args
+}
+
+// rewriteOptions builds the options replacing the tables of the args, and limiting the rows and
+// the execution time of the sql
+func rewriteOptions(args rewriteimpl.Args, rowLimit int32, maxExecutionTime time.Duration) []*rewriterprotos.RewriteOption {
+	tableArgs := &rewriterprotos.RewriteTableNameArgs{
+		TableWithDatabaseMap: make(map[string]*rewriterprotos.RewriteTableNameArgs_TableWithDatabase),
+		RemoteTableMap:       make(map[string]*rewriterprotos.RewriteTableNameArgs_RemoteTable),
+	}
+	for name, table := range args.GetDatabaseTables() {
+		tableArgs.TableWithDatabaseMap[name] = &rewriterprotos.RewriteTableNameArgs_TableWithDatabase{
+			Database: table.GetDatabase(),
+			Table:    table.GetTable(),
+		}
+	}
+	for name, remote := range args.GetRemotes() {
+		tableArgs.RemoteTableMap[name] = &rewriterprotos.RewriteTableNameArgs_RemoteTable{
+			Addr:     remote.GetAddr(),
+			Database: remote.GetDatabase(),
+			Table:    remote.GetTable(),
+			User:     remote.GetUser(),
+			Password: remote.GetPassword(),
+		}
+	}
+	options := []*rewriterprotos.RewriteOption{{
+		Op:    rewriterprotos.RewriteOp_TableNameRewrite,
+		Value: &rewriterprotos.RewriteOption_TableNameArgs{TableNameArgs: tableArgs},
+	}}
+	if ctes := args.GetCommonTableExpressions(); len(ctes) > 0 {
+		cteArgs := &rewriterprotos.RewriteCommonTableExprArgs{
+			CteMap: make(map[string]*rewriterprotos.RewriteCommonTableExprArgs_CommonTableExpr),
+		}
+		for name, cte := range ctes {
+			cteArgs.CteMap[name] = &rewriterprotos.RewriteCommonTableExprArgs_CommonTableExpr{
+				Alias: cte.GetAlias(),
+				Sql:   cte.GetSql(),
+			}
+		}
+		options = append(options, &rewriterprotos.RewriteOption{
+			Op:    rewriterprotos.RewriteOp_CommonTableExprRewrite,
+			Value: &rewriterprotos.RewriteOption_CommonTableExprArgs{CommonTableExprArgs: cteArgs},
+		})
+	}
+	// the limit of the sql is replaced if it is missing or greater than the row limit
+	options = append(options, &rewriterprotos.RewriteOption{
+		Op: rewriterprotos.RewriteOp_LimitRewrite,
+		Value: &rewriterprotos.RewriteOption_LimitArgs{LimitArgs: &rewriterprotos.RewriteLimitArgs{
+			Value: &rewriterprotos.RewriteLimitArgs_ReplaceLimit_{ReplaceLimit: &rewriterprotos.RewriteLimitArgs_ReplaceLimit{
+				Threshold: rowLimit,
+				ReplaceTo: rowLimit,
+			}},
+		}},
+	})
+	// settings of the sql are overridden by the rewriter, so the user can not raise the execution time
+	if seconds := int32(maxExecutionTime / time.Second); seconds > 0 {
+		options = append(options, &rewriterprotos.RewriteOption{
+			Op: rewriterprotos.RewriteOp_SettingsRewrite,
+			Value: &rewriterprotos.RewriteOption_SettingsArgs{SettingsArgs: &rewriterprotos.RewriteSettingsArgs{
+				Settings: []*rewriterprotos.RewriteSettingsArgs_Setting{{
+					Key:   "max_execution_time",
+					Type:  rewriterprotos.SettingType_Int,
+					Value: &rewriterprotos.RewriteSettingsArgs_Setting_IntValue{IntValue: seconds},
+				}},
+			}},
+		})
+	}
+	return options
+}
+
+// readOnlyKeywords are the keywords a read-only statement may start with
+var readOnlyKeywords = map[string]bool{"SELECT": true, "WITH": true}
+
+// allowedTableFunctions are the table functions generating data without reading anything outside the tables
+// of the project, others like url, s3, file and remote are not allowed
+var allowedTableFunctions = map[string]bool{
+	"NUMBERS":         true,
+	"NUMBERS_MT":      true,
+	"ZEROS":           true,
+	"ZEROS_MT":        true,
+	"GENERATE_SERIES": true,
+	"VALUES":          true,
+}
+
+type sqlTokenKind int
+
+const (
+	sqlTokenWord sqlTokenKind = iota
+	// quoted by backticks or double quotes
+	sqlTokenIdentifier
+	// quoted by single quotes
+	sqlTokenString
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	// upper case for the words and the identifiers, the quotes are removed
+	text string
+}
+
+// tokenizeSQL splits the sql into statements of tokens, the comments are skipped
+func tokenizeSQL(sql string) ([][]sqlToken, error) {
+	var (
+		statements [][]sqlToken
+		cur        []sqlToken
+	)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for ; end < len(sql) && sql[end] != c; end++ {
+				if sql[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(sql) {
+				return nil, fmt.Errorf("unterminated quote %c", c)
+			}
+			token := sqlToken{kind: sqlTokenIdentifier, text: strings.ToUpper(sql[i+1 : end])}
+			if c == '\'' {
+				token.kind = sqlTokenString
+			}
+			cur = append(cur, token)
+			i = end + 1
+		case c == ';':
+			if len(cur) > 0 {
+				statements = append(statements, cur)
+				cur = nil
+			}
+			i++
+		case unicode.IsSpace(rune(c)):
+			i++
+		case isWordChar(c):
+			end := i
+			for end < len(sql) && isWordChar(sql[end]) {
+				end++
+			}
+			cur = append(cur, sqlToken{kind: sqlTokenWord, text: strings.ToUpper(sql[i:end])})
+			i = end
+		default:
+			cur = append(cur, sqlToken{kind: sqlTokenSymbol, text: string(c)})
+			i++
+		}
+	}
+	if len(cur) > 0 {
+		statements = append(statements, cur)
+	}
+	return statements, nil
+}
+
+// sqlNode is a token or a group of the nodes in parentheses
+type sqlNode struct {
+	token sqlToken
+	// the nodes between the parentheses, the token is the open parenthesis
+	group []sqlNode
+}
+
+func (n sqlNode) isGroup() bool {
+	return n.token.kind == sqlTokenSymbol && n.token.text == "("
+}
+
+func (n sqlNode) isWord(words ...string) bool {
+	return n.token.kind == sqlTokenWord && slices.Contains(words, n.token.text)
+}
+
+func (n sqlNode) isSymbol(symbol string) bool {
+	return n.token.kind == sqlTokenSymbol && n.token.text == symbol
+}
+
+func (n sqlNode) isName() bool {
+	return n.token.kind == sqlTokenWord || n.token.kind == sqlTokenIdentifier
+}
+
+// groupSQLTokens builds the nodes of the tokens, the tokens in parentheses are grouped
+func groupSQLTokens(tokens []sqlToken) ([]sqlNode, error) {
+	stack := [][]sqlNode{nil}
+	for _, token := range tokens {
+		switch {
+		case token.kind == sqlTokenSymbol && token.text == "(":
+			stack = append(stack, nil)
+		case token.kind == sqlTokenSymbol && token.text == ")":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+			group := sqlNode{token: sqlToken{kind: sqlTokenSymbol, text: "("}, group: stack[len(stack)-1]}
+			stack = stack[:len(stack)-1]
+			stack[len(stack)-1] = append(stack[len(stack)-1], group)
+		default:
+			stack[len(stack)-1] = append(stack[len(stack)-1], sqlNode{token: token})
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return stack[0], nil
+}
+
+// fromClauseEnds are the keywords ending the FROM clause of a query
+var fromClauseEnds = []string{
+	"WHERE", "PREWHERE", "GROUP", "HAVING", "WINDOW", "QUALIFY", "ORDER", "LIMIT", "OFFSET", "FETCH",
+	"SETTINGS", "FORMAT", "INTO", "UNION", "EXCEPT", "INTERSECT", "SELECT",
+}
+
+// walkTableExpressions calls fn with the nodes starting from each table expression in the FROM clauses of the
+// queries in the nodes, including the subqueries. The table expressions follow FROM, the commas and JOIN in a FROM
+// clause, the expressions of ARRAY JOIN are not table expressions. The nodes in parentheses are a query only if
+// there is SELECT in them, so FROM in the functions like extract(DAY FROM ts) is not a FROM clause.
+func walkTableExpressions(nodes []sqlNode, fn func(expr []sqlNode) error) error {
+	if slices.ContainsFunc(nodes, func(n sqlNode) bool { return n.isWord("SELECT") }) {
+		for i := range nodes {
+			if !nodes[i].isWord("FROM") {
+				continue
+			}
+			arrayJoin := false
+			for j := i + 1; j < len(nodes) && !nodes[j].isWord(fromClauseEnds...); j++ {
+				switch pre := nodes[j-1]; {
+				case j == i+1:
+				case pre.isWord("JOIN"):
+					arrayJoin = nodes[j-2].isWord("ARRAY")
+					if arrayJoin {
+						continue
+					}
+				case pre.isSymbol(",") && !arrayJoin:
+				default:
+					continue
+				}
+				if err := fn(nodes[j:]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, n := range nodes {
+		if !n.isGroup() {
+			continue
+		}
+		if err := walkTableExpressions(n.group, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTableFunction returns an error if the table expression is a call of a table function not allowed
+func checkTableFunction(expr []sqlNode) error {
+	if len(expr) < 2 || !expr[0].isName() {
+		return nil
+	}
+	name, next := expr[0], expr[1]
+	// database.table
+	if next.isSymbol(".") && len(expr) >= 4 && expr[2].isName() {
+		name, next = expr[2], expr[3]
+	}
+	if next.isGroup() && !allowedTableFunctions[name.token.text] {
+		return fmt.Errorf("table function %s is not allowed", strings.ToLower(name.token.text))
+	}
+	return nil
+}
+
+// checkReadOnly returns an error if the sql is not a single SELECT statement, or it reads data outside the tables
+// by table functions in any table expression. The comments, quoted strings and identifiers are skipped so the
+// keywords inside them are not treated as a part of the statement. It is only a precheck, the sql is also executed
+// with readOnlySettings.
+func checkReadOnly(sql string) error {
+	statements, err := tokenizeSQL(sql)
+	if err != nil {
+		return err
+	}
+	switch len(statements) {
+	case 0:
+		return fmt.Errorf("sql is empty")
+	case 1:
+	default:
+		return fmt.Errorf("only one statement is allowed")
+	}
+	var words []string
+	for _, token := range statements[0] {
+		if token.kind == sqlTokenWord {
+			words = append(words, token.text)
+		}
+	}
+	switch {
+	case len(words) == 0:
+		return fmt.Errorf("only SELECT statement is allowed")
+	case !readOnlyKeywords[words[0]]:
+		return fmt.Errorf("only SELECT statement is allowed, got %s", words[0])
+	}
+	for i := 0; i+1 < len(words); i++ {
+		if words[i] == "INTO" && words[i+1] == "OUTFILE" {
+			return fmt.Errorf("INTO OUTFILE is not allowed")
+		}
+	}
+	nodes, err := groupSQLTokens(statements[0])
+	if err != nil {
+		return err
+	}
+	return walkTableExpressions(nodes, checkTableFunction)
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
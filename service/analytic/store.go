@@ -0,0 +1,96 @@
+package analytic
+
+import (
+	"context"
+	"fmt"
+
+	"sentioxyz/sentio-core/common/cache"
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/driver/timeseries"
+	"sentioxyz/sentio-core/driver/timeseries/cacheablelib"
+	"sentioxyz/sentio-core/driver/timeseries/clickhouse"
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
+// Store is the time series store of a processor, the tables of its metas are the tables visible to the user sql
+type Store interface {
+	Meta() timeseries.StoreMeta
+	MetaTableName(meta timeseries.Meta) string
+	Client() chx.Conn
+}
+
+// StoreProvider returns the time series store of a processor and the connection executing the user sql on it
+type StoreProvider interface {
+	GetStore(ctx context.Context, processor *models.Processor) (Store, error)
+	GetConn(processor *models.Processor, role ckhmanager.Role) (ckhmanager.Conn, error)
+}
+
+type clickhouseStoreProvider struct {
+	config  Config
+	conn    ckhmanager.Conn
+	manager ckhmanager.Manager
+	stores  *cache.Cache[*clickhouse.Store]
+}
+
+// NewClickhouseStoreProvider creates a StoreProvider reading the time series tables written by the drivers,
+// the metas of the stores are cached and reloaded in background until ctx is done
+func NewClickhouseStoreProvider(ctx context.Context, config Config) (StoreProvider, error) {
+	p := &clickhouseStoreProvider{config: config}
+	switch {
+	case config.ClickhouseConfig != "":
+		p.manager = ckhmanager.LoadManager(config.ClickhouseConfig)
+	case config.ClickhouseDSN != "":
+		p.conn = ckhmanager.NewOrGetConn(config.ClickhouseDSN)
+	default:
+		return nil, fmt.Errorf("either clickhouse_config or clickhouse_dsn is required")
+	}
+	cacheConfig := cache.DefaultConfig()
+	cacheConfig.MaxSize = config.StoreCacheSize
+	p.stores = cache.New[*clickhouse.Store](ctx, cacheConfig)
+	return p, nil
+}
+
+func (p *clickhouseStoreProvider) getConn(processor *models.Processor, opts ...func(*ckhmanager.ShardingParameter)) (ckhmanager.Conn, error) {
+	if p.manager == nil {
+		return p.conn, nil
+	}
+	shard := p.manager.GetShardByIndex(ckhmanager.ShardingIndex(processor.ClickhouseShardingIndex))
+	if shard == nil {
+		return nil, fmt.Errorf("clickhouse sharding %d of processor %s not found",
+			processor.ClickhouseShardingIndex, processor.ID)
+	}
+	return shard.GetConn(append([]func(*ckhmanager.ShardingParameter){ckhmanager.WithCategory(ckhmanager.SentioCategory)}, opts...)...)
+}
+
+// GetConn returns the connection of the sharding of the processor with the readonly role of an engine,
+// all roles share the same connection if ClickhouseDSN is used
+func (p *clickhouseStoreProvider) GetConn(processor *models.Processor, role ckhmanager.Role) (ckhmanager.Conn, error) {
+	return p.getConn(processor, ckhmanager.WithRole(role))
+}
+
+func (p *clickhouseStoreProvider) GetStore(_ context.Context, processor *models.Processor) (Store, error) {
+	conn, err := p.getConn(processor)
+	if err != nil {
+		return nil, err
+	}
+	item, err := cacheablelib.NewCacheableMetaWithTTL(
+		processor.ID,
+		int(processor.Version),
+		0,
+		processor.TablePattern,
+		processor.ProjectID,
+		conn,
+		p.config.StoreCacheTTL,
+		p.config.MetaRefreshInterval,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	store, err := p.stores.Get(item)
+	if err != nil {
+		return nil, fmt.Errorf("load time series meta of processor %s failed: %w", processor.ID, err)
+	}
+	return store, nil
+}
@@ -140,11 +140,13 @@ func (l *logAdaptor) timeRangeCondString() string {
 	return strings.Join(conditions, " AND ")
 }
 
-func (l *logAdaptor) buildMetaQuery(meta timeseries.Meta) string {
+// buildMetaQuery builds the query of the events of the meta, the event and field names come from the
+// processor, so they are bound as the string literals
+func (l *logAdaptor) buildMetaQuery(meta timeseries.Meta) (string, error) {
 	const (
 		tpl = "SELECT " +
 			"{preset}, " +
-			"'{event_name}' AS " + EventNameColumn + ", " +
+			"{event_name} AS " + EventNameColumn + ", " +
 			"{attributes_field} AS " + AttributesColumn + " " +
 			"FROM {table} " +
 			"WHERE {time_range}"
@@ -158,17 +160,17 @@ func (l *logAdaptor) buildMetaQuery(meta timeseries.Meta) string {
 			continue
 		}
 		attributes = append(attributes,
-			"'"+field.Name+"'", timeseries.EscapeEventlogFieldName(field.Name)+"::Dynamic")
+			builder.QuoteString(field.Name), timeseries.EscapeEventlogFieldName(field.Name)+"::Dynamic")
 	}
 	lo.ForEach(utils.GetOrderedMapKeys(l.presetColumn), func(column string, _ int) {
 		preset = append(preset, timeseries.EscapeEventlogFieldName(column))
 	})
-	return builder.FormatSQLTemplate(tpl, map[string]any{
-		"preset":           strings.Join(preset, ", "),
+	return builder.BindSQLTemplate(tpl, map[string]any{
+		"preset":           builder.Raw(strings.Join(preset, ", ")),
 		"event_name":       meta.Name,
-		"attributes_field": "map(" + strings.Join(attributes, ", ") + ")::JSON",
-		"table":            l.store.MetaTableName(meta),
-		"time_range":       l.timeRangeCondString(),
+		"attributes_field": builder.Raw("map(" + strings.Join(attributes, ", ") + ")::JSON"),
+		"table":            builder.Raw(l.store.MetaTableName(meta)),
+		"time_range":       builder.Raw(l.timeRangeCondString()),
 	})
 }
 
@@ -176,7 +178,12 @@ func (l *logAdaptor) buildQuery() {
 	l.once.Do(func() {
 		var queries []string
 		for _, meta := range l.store.Meta().MetaByType(timeseries.MetaTypeEvent) {
-			queries = append(queries, l.buildMetaQuery(meta))
+			q, err := l.buildMetaQuery(meta)
+			if err != nil {
+				l.logger.Errorfe(err, "build query of event %q failed, skipped", meta.Name)
+				continue
+			}
+			queries = append(queries, q)
 		}
 		q := strings.Join(queries, " UNION ALL ")
 		l.logger = l.logger.With("query", q)
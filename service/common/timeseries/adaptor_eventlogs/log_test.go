@@ -13,6 +13,8 @@ import (
 	processormodels "sentioxyz/sentio-core/service/processor/models"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -158,3 +160,19 @@ func (s *LogAdaptorSuite) Test_BuildCountQueryWithCustomStepAndAlignStart() {
 	sql := wq.BuildCountQuery(true)
 	s.check(getCurrentFunctionName(), sql)
 }
+
+func Test_buildMetaQuery(t *testing.T) {
+	store := mock.NewMockStore(mockProcessor, nil)
+	wq, err := NewLogAdaptor(mockCtx, store, mockProcessor, mock.NewTimeRange(), "")
+	require.NoError(t, err)
+	sql, err := wq.(*logAdaptor).buildMetaQuery(timeseries.Meta{
+		Name: "it's",
+		Type: timeseries.MetaTypeEvent,
+		Fields: map[string]timeseries.Field{
+			"o'k": {Name: "o'k", Type: timeseries.FieldTypeString},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, sql, `'it\'s' AS `+EventNameColumn)
+	assert.Contains(t, sql, `map('o\'k', `)
+}
@@ -11,13 +11,17 @@ import (
 	"sentioxyz/sentio-core/service/common/timerange"
 	cascade "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/cascade_function"
 	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/absent"
 	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/filter"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/label"
 	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/math"
 	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/rank"
 	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/rate"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/regression"
 	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/sample"
 	slidingwindow "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/sliding_window"
 	prebuilttime "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/time"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/selector"
 
 	"github.com/samber/lo"
 )
@@ -91,6 +95,29 @@ func (fa *functionAdaptor) convertDurationValue(v *protoscommon.Duration) time.D
 	}
 }
 
+func (fa *functionAdaptor) convertNumberValue(v *protoscommon.Argument) (float64, error) {
+	switch v.GetArgumentValue().(type) {
+	case *protoscommon.Argument_DoubleValue:
+		return v.GetDoubleValue(), nil
+	case *protoscommon.Argument_IntValue:
+		return float64(v.GetIntValue()), nil
+	default:
+		return 0, fmt.Errorf("argument is not a number")
+	}
+}
+
+// convertOffsetValue accepts a duration or a number of seconds like prometheus
+func (fa *functionAdaptor) convertOffsetValue(v *protoscommon.Argument) (time.Duration, error) {
+	if d := v.GetDurationValue(); d != nil {
+		return fa.convertDurationValue(d), nil
+	}
+	seconds, err := fa.convertNumberValue(v)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
 func (fa *functionAdaptor) verifyArguments(arguments []*protoscommon.Argument, idx int) error {
 	if len(arguments) <= idx {
 		return fmt.Errorf("missing argument at index %d", idx)
@@ -110,12 +137,20 @@ func (fa *functionAdaptor) convert() (err error) {
 	var (
 		withFill                   = false
 		extendPrevious, extendNext time.Duration
+		// label_replace, label_join and absent change the labels of the following functions
+		labels        = fa.labels
+		labelSelector selector.Selector
+		nextLabels    func() []string
 	)
-	if fa.parameter != nil && fa.parameter.timeRange != nil {
-		fa.extendTimeRange = fa.parameter.timeRange.Copy()
+	if fa.parameter != nil {
+		labelSelector = fa.parameter.labelSelector
+		if fa.parameter.timeRange != nil {
+			fa.extendTimeRange = fa.parameter.timeRange.Copy()
+		}
 	}
 	for _, f := range fa.functions {
 		var pf prebuilt.Function
+		nextLabels = nil
 		switch f.Name {
 		case "abs":
 			pf = math.NewMathFunction(fa.meta, fa.store).Math().WithOp(prebuilt.OperatorAbs)
@@ -353,11 +388,138 @@ func (fa *functionAdaptor) convert() (err error) {
 				return fmt.Errorf("missing argument at index 0")
 			}
 			pf = rate.NewRateFunction(fa.meta, fa.store).Rate(fa.convertDurationValue(f.Arguments[0].GetDurationValue())).WithOp(prebuilt.OperatorIRate)
+		case "increase":
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			pf = rate.NewRateFunction(fa.meta, fa.store).Rate(fa.convertDurationValue(f.Arguments[0].GetDurationValue())).WithOp(prebuilt.OperatorIncrease)
+		case "changes":
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			pf = rate.NewRateFunction(fa.meta, fa.store).Rate(fa.convertDurationValue(f.Arguments[0].GetDurationValue())).WithOp(prebuilt.OperatorChanges)
+		case "resets":
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			pf = rate.NewRateFunction(fa.meta, fa.store).Rate(fa.convertDurationValue(f.Arguments[0].GetDurationValue())).WithOp(prebuilt.OperatorResets)
+		case "delta":
+			withFill = true
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			d := fa.convertDurationValue(f.Arguments[0].GetDurationValue())
+			if extendPrevious == 0 || extendPrevious < d {
+				extendPrevious = d
+			}
+			pf = slidingwindow.NewAggregatedSlidingWindowFunction(fa.meta, fa.store).
+				AggregatedWindowSize(d).
+				WithOp(prebuilt.OperatorDelta)
+		case "clamp_min":
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			bound, err := fa.convertNumberValue(f.Arguments[0])
+			if err != nil {
+				return fmt.Errorf("invalid argument at index 0: %w", err)
+			}
+			pf = math.NewMathFunction(fa.meta, fa.store).Clamp(bound).WithOp(prebuilt.OperatorClampMin)
+		case "clamp_max":
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			bound, err := fa.convertNumberValue(f.Arguments[0])
+			if err != nil {
+				return fmt.Errorf("invalid argument at index 0: %w", err)
+			}
+			pf = math.NewMathFunction(fa.meta, fa.store).Clamp(bound).WithOp(prebuilt.OperatorClampMax)
+		case "deriv":
+			withFill = true
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			d := fa.convertDurationValue(f.Arguments[0].GetDurationValue())
+			if extendPrevious == 0 || extendPrevious < d {
+				extendPrevious = d
+			}
+			pf = regression.NewRegressionFunction(fa.meta, fa.store).Regression(d).WithOp(prebuilt.OperatorDeriv)
+		case "predict_linear":
+			withFill = true
+			if fa.verifyArguments(f.Arguments, 1) != nil {
+				return fmt.Errorf("missing argument at index 1")
+			}
+			d := fa.convertDurationValue(f.Arguments[0].GetDurationValue())
+			if extendPrevious == 0 || extendPrevious < d {
+				extendPrevious = d
+			}
+			after, err := fa.convertOffsetValue(f.Arguments[1])
+			if err != nil {
+				return fmt.Errorf("invalid argument at index 1: %w", err)
+			}
+			pf = regression.NewRegressionFunction(fa.meta, fa.store).Regression(d).
+				PredictAfter(after).
+				WithOp(prebuilt.OperatorPredictLinear)
+		case "holt_winters":
+			withFill = true
+			if fa.verifyArguments(f.Arguments, 2) != nil {
+				return fmt.Errorf("missing argument at index 2")
+			}
+			d := fa.convertDurationValue(f.Arguments[0].GetDurationValue())
+			if extendPrevious == 0 || extendPrevious < d {
+				extendPrevious = d
+			}
+			sf, err := fa.convertNumberValue(f.Arguments[1])
+			if err != nil {
+				return fmt.Errorf("invalid argument at index 1: %w", err)
+			}
+			tf, err := fa.convertNumberValue(f.Arguments[2])
+			if err != nil {
+				return fmt.Errorf("invalid argument at index 2: %w", err)
+			}
+			pf = regression.NewRegressionFunction(fa.meta, fa.store).Regression(d).
+				Smoothing(sf, tf).
+				WithOp(prebuilt.OperatorHoltWinters)
+		case "label_replace":
+			if fa.verifyArguments(f.Arguments, 3) != nil {
+				return fmt.Errorf("missing argument at index 3")
+			}
+			lf := label.NewLabelFunction(fa.meta, fa.store).Replace(f.Arguments[0].GetStringValue(),
+				f.Arguments[1].GetStringValue(), f.Arguments[2].GetStringValue(), f.Arguments[3].GetStringValue())
+			nextLabels = lf.OutputLabels
+			pf = lf.WithOp(prebuilt.OperatorLabelReplace)
+		case "label_join":
+			if fa.verifyArguments(f.Arguments, 1) != nil {
+				return fmt.Errorf("missing argument at index 1")
+			}
+			src := lo.Map(f.Arguments[2:], func(arg *protoscommon.Argument, _ int) string {
+				return arg.GetStringValue()
+			})
+			lf := label.NewLabelFunction(fa.meta, fa.store).Join(f.Arguments[0].GetStringValue(),
+				f.Arguments[1].GetStringValue(), src...)
+			nextLabels = lf.OutputLabels
+			pf = lf.WithOp(prebuilt.OperatorLabelJoin)
+		case "absent":
+			nextLabels = func() []string { return nil }
+			pf = absent.NewAbsentFunction(fa.meta, fa.store).Absent(0).WithOp(prebuilt.OperatorAbsent)
+		case "absent_over_time":
+			if fa.verifyArguments(f.Arguments, 0) != nil {
+				return fmt.Errorf("missing argument at index 0")
+			}
+			d := fa.convertDurationValue(f.Arguments[0].GetDurationValue())
+			if extendPrevious == 0 || extendPrevious < d {
+				extendPrevious = d
+			}
+			nextLabels = func() []string { return nil }
+			pf = absent.NewAbsentFunction(fa.meta, fa.store).Absent(d).WithOp(prebuilt.OperatorAbsentOverTime)
 		default:
 			return fmt.Errorf("unknown function: %s", f.Name)
 		}
 
-		pf = pf.WithLabels(fa.labels).WithSelector(fa.parameter.labelSelector)
+		pf = pf.WithLabels(labels).WithSelector(labelSelector)
+		if nextLabels != nil {
+			// the selector is already applied by the filter, and may not match the rewritten labels
+			labels, labelSelector = nextLabels(), nil
+		}
 		fa.prebuilt = append(fa.prebuilt, pf)
 	}
 
@@ -377,8 +539,9 @@ func (fa *functionAdaptor) convert() (err error) {
 		}
 		fa.prebuilt = append(fa.prebuilt, sample.NewSampleFunction(fa.meta, fa.store).
 			Sample(fa.extendTimeRange.Step).WithTimeRange(fa.extendTimeRange).
-			WithLabels(fa.labels).WithSelector(fa.parameter.labelSelector))
+			WithLabels(labels).WithSelector(labelSelector))
 	}
+	fa.labels = labels
 
 	for _, f := range fa.prebuilt {
 		fa.cascade.Add(f)
@@ -216,3 +216,107 @@ func (s *FunctionSuite) TestNoArguments() {
 	_, err := NewFunctionAdaptor(s.Store.Meta().MustMeta(timeseries.MetaTypeGauge, "Transfer"), s.Store, functions, params)
 	s.NotNil(err)
 }
+
+func durationArgument(value float64, unit string) *protoscommon.Argument {
+	return &protoscommon.Argument{ArgumentValue: &protoscommon.Argument_DurationValue{
+		DurationValue: &protoscommon.Duration{Value: value, Unit: unit},
+	}}
+}
+
+func stringArgument(value string) *protoscommon.Argument {
+	return &protoscommon.Argument{ArgumentValue: &protoscommon.Argument_StringValue{StringValue: value}}
+}
+
+func doubleArgument(value float64) *protoscommon.Argument {
+	return &protoscommon.Argument{ArgumentValue: &protoscommon.Argument_DoubleValue{DoubleValue: value}}
+}
+
+func (s *FunctionSuite) TestConvertPromQLFunctions() {
+	params := &Parameters{
+		groups:    []string{},
+		timeRange: &timerange.TimeRange{Start: time.Now(), End: time.Now().Add(time.Hour), Step: time.Minute},
+	}
+	for _, functions := range [][]*protoscommon.Function{
+		{{Name: "increase", Arguments: []*protoscommon.Argument{durationArgument(5, "m")}}},
+		{{Name: "delta", Arguments: []*protoscommon.Argument{durationArgument(5, "m")}}},
+		{{Name: "changes", Arguments: []*protoscommon.Argument{durationArgument(5, "m")}}},
+		{{Name: "resets", Arguments: []*protoscommon.Argument{durationArgument(5, "m")}}},
+		{{Name: "deriv", Arguments: []*protoscommon.Argument{durationArgument(10, "m")}}},
+		{{Name: "predict_linear", Arguments: []*protoscommon.Argument{durationArgument(10, "m"), doubleArgument(3600)}}},
+		{{Name: "holt_winters", Arguments: []*protoscommon.Argument{durationArgument(10, "m"), doubleArgument(0.5), doubleArgument(0.5)}}},
+		{
+			{Name: "clamp_min", Arguments: []*protoscommon.Argument{doubleArgument(0)}},
+			{Name: "clamp_max", Arguments: []*protoscommon.Argument{doubleArgument(100)}},
+		},
+	} {
+		adaptor, err := NewFunctionAdaptor(s.Store.Meta().MustMeta(timeseries.MetaTypeGauge, "Transfer"), s.Store, functions, params)
+		s.NoError(err, functions[0].Name)
+		code, err := adaptor.Generate()
+		s.NoError(err, functions[0].Name)
+		s.Check(testsuite.GetCurrentFunctionName()+"/"+functions[0].Name, code)
+	}
+}
+
+func (s *FunctionSuite) TestConvertInvalidNumberArgument() {
+	params := &Parameters{
+		groups:    []string{},
+		timeRange: &timerange.TimeRange{Start: time.Now(), End: time.Now().Add(time.Hour), Step: time.Minute},
+	}
+	for _, f := range []*protoscommon.Function{
+		{Name: "clamp_min", Arguments: []*protoscommon.Argument{stringArgument("0")}},
+		{Name: "predict_linear", Arguments: []*protoscommon.Argument{durationArgument(10, "m"), stringArgument("1h")}},
+		{Name: "holt_winters", Arguments: []*protoscommon.Argument{durationArgument(10, "m"), doubleArgument(0.5), stringArgument("0.5")}},
+	} {
+		_, err := NewFunctionAdaptor(s.Store.Meta().MustMeta(timeseries.MetaTypeGauge, "Transfer"), s.Store,
+			[]*protoscommon.Function{f}, params)
+		s.ErrorContains(err, "argument is not a number", f.Name)
+		s.NotContains(err.Error(), "panic", f.Name)
+	}
+}
+
+func (s *FunctionSuite) TestConvertLabelFunctions() {
+	functions := []*protoscommon.Function{
+		{Name: "label_replace", Arguments: []*protoscommon.Argument{
+			stringArgument("short_from"), stringArgument("from"), stringArgument("0x(.{4}).*"), stringArgument("$1"),
+		}},
+		{Name: "label_join", Arguments: []*protoscommon.Argument{
+			stringArgument("route"), stringArgument("-"), stringArgument("short_from"), stringArgument("to"),
+		}},
+		{Name: "avg_over_time", Arguments: []*protoscommon.Argument{durationArgument(10, "m")}},
+	}
+	params := &Parameters{
+		groups:    []string{},
+		timeRange: &timerange.TimeRange{Start: time.Now(), End: time.Now().Add(time.Hour), Step: time.Minute},
+	}
+
+	meta := s.Store.Meta().MustMeta(timeseries.MetaTypeGauge, "Transfer")
+	base, err := NewFunctionAdaptor(meta, s.Store, []*protoscommon.Function{{Name: "abs"}}, params)
+	s.NoError(err)
+	adaptor, err := NewFunctionAdaptor(meta, s.Store, functions, params)
+	s.NoError(err)
+	s.Equal(append(base.SeriesLabel(), "short_from", "route"), adaptor.SeriesLabel())
+
+	code, err := adaptor.Generate()
+	s.NoError(err)
+	s.Check(testsuite.GetCurrentFunctionName(), code)
+}
+
+func (s *FunctionSuite) TestConvertAbsentFunctions() {
+	params := &Parameters{
+		groups:    []string{},
+		timeRange: &timerange.TimeRange{Start: time.Now(), End: time.Now().Add(time.Hour), Step: time.Minute},
+	}
+	for _, f := range []*protoscommon.Function{
+		{Name: "absent"},
+		{Name: "absent_over_time", Arguments: []*protoscommon.Argument{durationArgument(10, "m")}},
+	} {
+		adaptor, err := NewFunctionAdaptor(s.Store.Meta().MustMeta(timeseries.MetaTypeGauge, "Transfer"), s.Store,
+			[]*protoscommon.Function{f}, params)
+		s.NoError(err)
+		s.Empty(adaptor.SeriesLabel())
+
+		sql, err := NewQueryRangeAdaptor(adaptor, params).Build()
+		s.NoError(err)
+		s.Check(testsuite.GetCurrentFunctionName()+"/"+f.Name, sql)
+	}
+}
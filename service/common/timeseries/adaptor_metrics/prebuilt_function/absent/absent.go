@@ -0,0 +1,99 @@
+package absent
+
+import (
+	"fmt"
+	"time"
+
+	builder "sentioxyz/sentio-core/common/sqlbuilder"
+	"sentioxyz/sentio-core/driver/timeseries"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+
+	"github.com/samber/lo"
+)
+
+type absentFunction struct {
+	*prebuilt.BaseFunction
+	window time.Duration
+}
+
+func NewAbsentFunction(meta timeseries.Meta, store prebuilt.Store) prebuilt.AbsentFunction {
+	return &absentFunction{
+		BaseFunction: prebuilt.NewBaseFunction(meta, store, "absent"),
+	}
+}
+
+// Absent sets the window looked back from every step, the sample interval of the time range is used if it is 0
+func (f *absentFunction) Absent(window time.Duration) prebuilt.AbsentFunction {
+	defer f.Init(f)
+	f.window = window
+	return f
+}
+
+func (f *absentFunction) windowSeconds() int64 {
+	if f.window > 0 {
+		return int64(f.window.Seconds())
+	}
+	return int64(lo.If(f.TimeRange.SampleRate > 0, f.TimeRange.SampleRate).Else(f.TimeRange.Step).Seconds())
+}
+
+func (f *absentFunction) Generate() (string, error) {
+	if f.TimeRange == nil {
+		return "", fmt.Errorf("absent function must have timerange")
+	}
+	if f.Operator != prebuilt.OperatorAbsent && f.Operator != prebuilt.OperatorAbsentOverTime {
+		return "", fmt.Errorf("unsupported operator: %v", f.Operator)
+	}
+	if f.windowSeconds() <= 0 {
+		return "", fmt.Errorf("window size is required")
+	}
+
+	// the steps and the data points are merged and ordered by time, data points go first at the same time,
+	// so the last data point seen by a step is the latest one at or before it
+	const tpl = `
+	SELECT
+		{timestamp},
+		{milli_timestamp},
+		toFloat64(1) AS {result_alias}
+	FROM (
+		SELECT
+			{timestamp},
+			is_step,
+			max(data_second) OVER (ORDER BY toUnixTimestamp({timestamp}) ASC, is_step ASC ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS last_data_second
+		FROM (
+			SELECT
+				{timestamp},
+				0 AS is_step,
+				toNullable(toUnixTimestamp({timestamp})) AS data_second
+			FROM {table} {where_clause}
+			UNION ALL
+			SELECT
+				{timestamp},
+				1 AS is_step,
+				NULL AS data_second
+			FROM (
+				SELECT {start_time} AS {timestamp}
+				ORDER BY {timestamp} ASC
+				WITH FILL FROM {start_time} TO {end_time} STEP {step}
+			)
+		) AS merged
+	) AS absent_table
+	WHERE is_step = 1 AND (last_data_second IS NULL OR last_data_second <= toUnixTimestamp({timestamp}) - {window})
+`
+	whereClause := f.WhereClause(f.TimeRange)
+	return builder.FormatSQLTemplate(tpl, map[string]any{
+		"timestamp":       timeseries.SystemTimestamp,
+		"milli_timestamp": prebuilt.MilliTimestamp,
+		"result_alias":    f.GetResultAlias(),
+		"table":           f.GetTableName(),
+		"where_clause": lo.If(whereClause == "", " WHERE "+f.GetValueField()+" IS NOT NULL").
+			Else(whereClause + " AND " + f.GetValueField() + " IS NOT NULL"),
+		"start_time": f.StartAlignedTime(f.TimeRange),
+		"end_time":   f.EndAlignedTime(f.TimeRange),
+		"step":       f.RateSampleAlignedInterval(f.TimeRange),
+		"window":     f.windowSeconds(),
+	}), nil
+}
+
+func (f *absentFunction) GetFuncName() string {
+	return "absent_function"
+}
@@ -0,0 +1,78 @@
+package absent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sentioxyz/sentio-core/driver/timeseries"
+	"sentioxyz/sentio-core/service/common/timerange"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/testsuite"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type AbsentFunctionSuite struct {
+	testsuite.Suite
+}
+
+func Test_RunAbsentFunctionSuite(t *testing.T) {
+	opt, err := clickhouse.ParseDSN(testsuite.LocalClickhouseDSN)
+	if err != nil {
+		panic(err)
+	}
+	conn, err := clickhouse.Open(opt)
+	if err != nil {
+		t.Skipf("failed to open clickhouse, skip test: %v", err)
+	}
+	if err := conn.QueryRow(context.Background(), "select 1").Err(); err != nil {
+		t.Skipf("failed to query clickhouse, skip test: %v", err)
+	}
+
+	suite.Run(t, new(AbsentFunctionSuite))
+}
+
+func (s *AbsentFunctionSuite) timeRange() *timerange.TimeRange {
+	return &timerange.TimeRange{
+		Start:    time.Now().Add(-time.Hour * 24),
+		End:      time.Now(),
+		Step:     time.Hour,
+		Timezone: time.UTC,
+	}
+}
+
+func (s *AbsentFunctionSuite) Test_Absent() {
+	f := NewAbsentFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Absent(0)
+	f.WithTimeRange(s.timeRange()).WithOp(prebuilt.OperatorAbsent)
+	sql, err := f.Generate()
+	s.Nil(err)
+	s.Contains(sql, "toUnixTimestamp(timestamp) - 3600")
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *AbsentFunctionSuite) Test_AbsentOverTime() {
+	f := NewAbsentFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Absent(time.Hour * 6)
+	f.WithTimeRange(s.timeRange()).WithOp(prebuilt.OperatorAbsentOverTime)
+	sql, err := f.Generate()
+	s.Nil(err)
+	s.Contains(sql, "toUnixTimestamp(timestamp) - 21600")
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *AbsentFunctionSuite) Test_NoTimeRange_Error() {
+	_, err := NewAbsentFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Absent(time.Hour).
+		WithOp(prebuilt.OperatorAbsentOverTime).
+		Generate()
+	s.NotNil(err)
+}
@@ -37,6 +37,18 @@ const (
 	OperatorMinute
 	OperatorRate
 	OperatorIRate
+	OperatorIncrease
+	OperatorChanges
+	OperatorResets
+	OperatorClampMin
+	OperatorClampMax
+	OperatorDeriv
+	OperatorPredictLinear
+	OperatorHoltWinters
+	OperatorLabelReplace
+	OperatorLabelJoin
+	OperatorAbsent
+	OperatorAbsentOverTime
 )
 
 type Function interface {
@@ -68,6 +80,7 @@ type RollupFunction interface {
 type MathFunction interface {
 	Function
 	Math() MathFunction
+	Clamp(bound float64) MathFunction
 }
 
 type RankFunction interface {
@@ -85,6 +98,29 @@ type RateFunction interface {
 	Rate(step time.Duration) RateFunction
 }
 
+// RegressionFunction fits the samples in the window with a linear regression or double exponential smoothing
+type RegressionFunction interface {
+	Function
+	Regression(window time.Duration) RegressionFunction
+	PredictAfter(d time.Duration) RegressionFunction
+	Smoothing(smoothingFactor, trendFactor float64) RegressionFunction
+}
+
+// LabelFunction rewrites a label of every series, the label is added if it does not exist
+type LabelFunction interface {
+	Function
+	Replace(dst, src, regex, replacement string) LabelFunction
+	Join(dst, separator string, src ...string) LabelFunction
+	OutputLabels() []string
+}
+
+// AbsentFunction returns 1 at the steps without any data point in the window,
+// the result has no label
+type AbsentFunction interface {
+	Function
+	Absent(window time.Duration) AbsentFunction
+}
+
 // FilterFunction is a function that filters out data points based on a condition
 // usually used as first in a cascade of functions
 type FilterFunction interface {
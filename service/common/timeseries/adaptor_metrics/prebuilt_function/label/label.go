@@ -0,0 +1,150 @@
+package label
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	builder "sentioxyz/sentio-core/common/sqlbuilder"
+	"sentioxyz/sentio-core/driver/timeseries"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+
+	"github.com/samber/lo"
+)
+
+const newLabelField = "new_label_value"
+
+var (
+	labelNamePattern   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	replacementPattern = regexp.MustCompile(`\$(\d|\{\d\})`)
+)
+
+type labelFunction struct {
+	*prebuilt.BaseFunction
+	dst         string
+	src         []string
+	regex       string
+	replacement string
+	separator   string
+}
+
+func NewLabelFunction(meta timeseries.Meta, store prebuilt.Store) prebuilt.LabelFunction {
+	return &labelFunction{
+		BaseFunction: prebuilt.NewBaseFunction(meta, store, "label"),
+	}
+}
+
+// Replace sets dst to the replacement if regex fully matches the value of src, like label_replace of prometheus.
+// Only $1 to $9 and ${1} to ${9} are supported in the replacement.
+func (f *labelFunction) Replace(dst, src, regex, replacement string) prebuilt.LabelFunction {
+	defer f.Init(f)
+	f.dst, f.src, f.regex, f.replacement = dst, []string{src}, regex, replacement
+	return f
+}
+
+// Join sets dst to the values of src joined by separator, like label_join of prometheus
+func (f *labelFunction) Join(dst, separator string, src ...string) prebuilt.LabelFunction {
+	defer f.Init(f)
+	f.dst, f.separator, f.src = dst, separator, src
+	return f
+}
+
+// OutputLabels returns the labels of the result, dst is appended if it is not one of the labels
+func (f *labelFunction) OutputLabels() []string {
+	if lo.Contains(f.Labels, f.dst) {
+		return f.Labels
+	}
+	return append(slices.Clone(f.Labels), f.dst)
+}
+
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// labelValue returns the value of the label as string, the label which does not exist is empty
+func (f *labelFunction) labelValue(label string) string {
+	if !lo.Contains(f.Labels, label) {
+		return "''"
+	}
+	return "toString(" + label + ")"
+}
+
+// replacementTemplate converts the replacement of prometheus to the one of replaceRegexpOne
+func replacementTemplate(replacement string) string {
+	replacement = strings.ReplaceAll(replacement, `\`, `\\`)
+	return replacementPattern.ReplaceAllStringFunc(replacement, func(group string) string {
+		return `\` + strings.Trim(group, "${}")
+	})
+}
+
+func (f *labelFunction) opString() (string, error) {
+	if !labelNamePattern.MatchString(f.dst) {
+		return "", fmt.Errorf("invalid destination label name: %q", f.dst)
+	}
+	switch f.Operator {
+	case prebuilt.OperatorLabelReplace:
+		if _, err := regexp.Compile(f.regex); err != nil {
+			return "", fmt.Errorf("invalid regex %q: %w", f.regex, err)
+		}
+		var (
+			src     = f.labelValue(f.src[0])
+			pattern = quote("^(?:" + f.regex + ")$")
+		)
+		return fmt.Sprintf("if(match(%s, %s), replaceRegexpOne(%s, %s, %s), %s)",
+			src, pattern, src, pattern, quote(replacementTemplate(f.replacement)), f.labelValue(f.dst)), nil
+	case prebuilt.OperatorLabelJoin:
+		if len(f.src) == 0 {
+			return "''", nil
+		}
+		return fmt.Sprintf("concatWithSeparator(%s, %s)", quote(f.separator),
+			strings.Join(lo.Map(f.src, func(l string, _ int) string {
+				return f.labelValue(l)
+			}), ", ")), nil
+	default:
+		return "", fmt.Errorf("unsupported operator: %v", f.Operator)
+	}
+}
+
+func (f *labelFunction) Generate() (string, error) {
+	labelField, err := f.opString()
+	if err != nil {
+		return "", err
+	}
+	// the new value is calculated in the inner query, so it can be aliased to the name of an existing label
+	outputLabels := lo.Map(f.OutputLabels(), func(l string, _ int) string {
+		return lo.If(l == f.dst, newLabelField+" AS "+l).Else(l)
+	})
+
+	const tpl = `
+	SELECT
+		{timestamp},
+		{milli_timestamp},
+		{output_labels},
+		{value_field} AS {result_alias}
+	FROM (
+		SELECT
+			{timestamp},
+			{label_fields}
+			{value_field},
+			{label_field} AS {new_label_field}
+		FROM {table} {where_clause}
+	) AS label_table
+`
+	return builder.FormatSQLTemplate(tpl, map[string]any{
+		"timestamp":       timeseries.SystemTimestamp,
+		"milli_timestamp": prebuilt.MilliTimestamp,
+		"output_labels":   strings.Join(outputLabels, ","),
+		"value_field":     f.GetValueField(),
+		"result_alias":    f.GetResultAlias(),
+		"label_fields":    f.GetLabelFields(),
+		"label_field":     labelField,
+		"new_label_field": newLabelField,
+		"table":           f.GetTableName(),
+		"where_clause":    f.WhereClause(f.TimeRange),
+	}), nil
+}
+
+func (f *labelFunction) GetFuncName() string {
+	return "label_function"
+}
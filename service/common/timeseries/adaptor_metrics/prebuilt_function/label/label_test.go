@@ -0,0 +1,98 @@
+package label
+
+import (
+	"context"
+	"testing"
+
+	"sentioxyz/sentio-core/driver/timeseries"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/testsuite"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func Test_replacementTemplate(t *testing.T) {
+	assert.Equal(t, `\1-\2`, replacementTemplate("$1-${2}"))
+	assert.Equal(t, `a\\b\1`, replacementTemplate(`a\b$1`))
+	assert.Equal(t, "$a", replacementTemplate("$a"))
+}
+
+func Test_quote(t *testing.T) {
+	assert.Equal(t, `'it\'s \\1'`, quote(`it's \1`))
+}
+
+type LabelFunctionSuite struct {
+	testsuite.Suite
+}
+
+func Test_RunLabelFunctionSuite(t *testing.T) {
+	opt, err := clickhouse.ParseDSN(testsuite.LocalClickhouseDSN)
+	if err != nil {
+		panic(err)
+	}
+	conn, err := clickhouse.Open(opt)
+	if err != nil {
+		t.Skipf("failed to open clickhouse, skip test: %v", err)
+	}
+	if err := conn.QueryRow(context.Background(), "select 1").Err(); err != nil {
+		t.Skipf("failed to query clickhouse, skip test: %v", err)
+	}
+
+	suite.Run(t, new(LabelFunctionSuite))
+}
+
+func (s *LabelFunctionSuite) Test_LabelReplace() {
+	f := NewLabelFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Replace("short_from", "from", "0x(.{4}).*", "$1")
+	f.WithLabels([]string{"meta.chain", "from"}).WithOp(prebuilt.OperatorLabelReplace)
+	s.Equal([]string{"meta.chain", "from", "short_from"}, f.OutputLabels())
+	sql, err := f.Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *LabelFunctionSuite) Test_LabelReplaceExisting() {
+	f := NewLabelFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Replace("from", "from", "(.*)", "from_$1")
+	f.WithLabels([]string{"meta.chain", "from"}).WithOp(prebuilt.OperatorLabelReplace)
+	s.Equal([]string{"meta.chain", "from"}, f.OutputLabels())
+	sql, err := f.Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *LabelFunctionSuite) Test_LabelJoin() {
+	f := NewLabelFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Join("route", "->", "from", "to", "missing")
+	f.WithLabels([]string{"meta.chain", "from", "to"}).WithOp(prebuilt.OperatorLabelJoin)
+	sql, err := f.Generate()
+	s.Nil(err)
+	s.Contains(sql, "concatWithSeparator('->', toString(from), toString(to), '')")
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *LabelFunctionSuite) Test_InvalidArguments_Error() {
+	_, err := NewLabelFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Replace("bad-name", "from", ".*", "").
+		WithOp(prebuilt.OperatorLabelReplace).
+		Generate()
+	s.NotNil(err)
+
+	_, err = NewLabelFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Replace("dst", "from", "(", "").
+		WithOp(prebuilt.OperatorLabelReplace).
+		Generate()
+	s.NotNil(err)
+}
@@ -2,6 +2,8 @@ package math
 
 import (
 	"fmt"
+	stdmath "math"
+	"strconv"
 
 	builder "sentioxyz/sentio-core/common/sqlbuilder"
 	"sentioxyz/sentio-core/driver/timeseries"
@@ -10,6 +12,7 @@ import (
 
 type mathFunction struct {
 	*prebuilt.BaseFunction
+	bound float64
 }
 
 func NewMathFunction(meta timeseries.Meta, store prebuilt.Store) prebuilt.MathFunction {
@@ -23,6 +26,20 @@ func (f *mathFunction) Math() prebuilt.MathFunction {
 	return f
 }
 
+// Clamp sets the bound used by OperatorClampMin and OperatorClampMax
+func (f *mathFunction) Clamp(bound float64) prebuilt.MathFunction {
+	defer f.Init(f)
+	f.bound = bound
+	return f
+}
+
+func (f *mathFunction) boundString() (string, error) {
+	if stdmath.IsNaN(f.bound) || stdmath.IsInf(f.bound, 0) {
+		return "", fmt.Errorf("clamp bound must be a finite number, got: %v", f.bound)
+	}
+	return strconv.FormatFloat(f.bound, 'g', -1, 64), nil
+}
+
 func (f *mathFunction) OpString() (string, error) {
 	switch f.Operator {
 	case prebuilt.OperatorAbs:
@@ -39,6 +56,18 @@ func (f *mathFunction) OpString() (string, error) {
 		return "log10(" + f.GetValueField() + ") AS " + f.ResultAlias, nil
 	case prebuilt.OperatorLn:
 		return "log(" + f.GetValueField() + ") AS " + f.ResultAlias, nil
+	case prebuilt.OperatorClampMin:
+		bound, err := f.boundString()
+		if err != nil {
+			return "", err
+		}
+		return "greatest(" + f.GetValueField() + ", " + bound + ") AS " + f.ResultAlias, nil
+	case prebuilt.OperatorClampMax:
+		bound, err := f.boundString()
+		if err != nil {
+			return "", err
+		}
+		return "least(" + f.GetValueField() + ", " + bound + ") AS " + f.ResultAlias, nil
 	default:
 		return "", fmt.Errorf("unsupported operator: %v", f.Operator)
 	}
@@ -2,6 +2,7 @@ package math
 
 import (
 	"context"
+	stdmath "math"
 	"testing"
 
 	"sentioxyz/sentio-core/driver/timeseries"
@@ -119,6 +120,42 @@ func (s *MathFunctionSuite) Test_Ln_NoLabels() {
 	s.Check(testsuite.GetCurrentFunctionName(), sql)
 }
 
+func (s *MathFunctionSuite) Test_ClampMin() {
+	sql, err := NewMathFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Clamp(0).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorClampMin).
+		Generate()
+	s.Nil(err)
+	s.Contains(sql, "greatest(value, 0)")
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *MathFunctionSuite) Test_ClampMax() {
+	sql, err := NewMathFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Clamp(-1.5).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorClampMax).
+		Generate()
+	s.Nil(err)
+	s.Contains(sql, "least(value, -1.5)")
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *MathFunctionSuite) Test_ClampNaN_Error() {
+	_, err := NewMathFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Clamp(stdmath.NaN()).
+		WithOp(prebuilt.OperatorClampMin).
+		Generate()
+	s.NotNil(err)
+}
+
 func (s *MathFunctionSuite) Test_CustomValueField_And_Table() {
 	// Use Withdraw meta to test custom numeric field 'amount' and explicit table override
 	meta := timeseries.Meta{Name: "Withdraw", Type: timeseries.MetaTypeGauge}
@@ -43,7 +43,9 @@ func (f *rateFunction) rateDiffTable() string {
 		{label_fields}
 		{value_field},
 		if (lagInFrame(toNullable({value_field})) OVER w IS NULL OR {value_field} < lagInFrame(toNullable({value_field})) OVER w, 0, {value_field} - lagInFrame(toNullable({value_field})) OVER w) AS delta,
-		if (lagInFrame(toNullable({timestamp})) OVER w IS NULL, 0, intDiv(toUnixTimestamp({timestamp}) - toUnixTimestamp(lagInFrame(toNullable({timestamp})) OVER w), 1)) AS delta_seconds
+		if (lagInFrame(toNullable({timestamp})) OVER w IS NULL, 0, intDiv(toUnixTimestamp({timestamp}) - toUnixTimestamp(lagInFrame(toNullable({timestamp})) OVER w), 1)) AS delta_seconds,
+		if (lagInFrame(toNullable({value_field})) OVER w IS NULL OR {value_field} = lagInFrame(toNullable({value_field})) OVER w, 0, 1) AS changed,
+		if (lagInFrame(toNullable({value_field})) OVER w IS NOT NULL AND {value_field} < lagInFrame(toNullable({value_field})) OVER w, 1, 0) AS reset
 	FROM {table} {where_clause}
 	WINDOW w AS (
 		{partition_clause}
@@ -63,7 +65,8 @@ func (f *rateFunction) rateDiffTable() string {
 	})
 }
 
-func (f *rateFunction) rate() string {
+// rangeAggregate aggregates the diff table over the window of step
+func (f *rateFunction) rangeAggregate(aggrFields string) string {
 	const (
 		tpl = `
 	SELECT
@@ -71,9 +74,7 @@ func (f *rateFunction) rate() string {
 		{second_timestamp},
 		{label_fields}
 		{value_field},
-		sum(delta) OVER rw AS sum_delta,
-		sum(delta_seconds) OVER rw AS sum_delta_seconds,
-		if (sum_delta_seconds = 0, 0, sum_delta / sum_delta_seconds) AS {result_alias}
+		{aggr_fields}
 	FROM ({diff_table}) AS diff_table
 	WINDOW rw AS (
 		{partition_clause}
@@ -97,10 +98,29 @@ func (f *rateFunction) rate() string {
 		"diff_table":       f.rateDiffTable(),
 		"partition_clause": partitionClause,
 		"order_by_clause":  orderByClause,
-		"result_alias":     f.GetResultAlias(),
+		"aggr_fields":      aggrFields,
 	})
 }
 
+func (f *rateFunction) rate() string {
+	return f.rangeAggregate(`sum(delta) OVER rw AS sum_delta,
+		sum(delta_seconds) OVER rw AS sum_delta_seconds,
+		if (sum_delta_seconds = 0, 0, sum_delta / sum_delta_seconds) AS ` + f.GetResultAlias())
+}
+
+// increase is the sum of the non-negative deltas in the window, same as the numerator of rate
+func (f *rateFunction) increase() string {
+	return f.rangeAggregate("sum(delta) OVER rw AS " + f.GetResultAlias())
+}
+
+func (f *rateFunction) changes() string {
+	return f.rangeAggregate("sum(changed) OVER rw AS " + f.GetResultAlias())
+}
+
+func (f *rateFunction) resets() string {
+	return f.rangeAggregate("sum(reset) OVER rw AS " + f.GetResultAlias())
+}
+
 func (f *rateFunction) iRate() string {
 	var (
 		labelFields     = f.GetLabelFields()
@@ -157,6 +177,12 @@ func (f *rateFunction) Generate() (string, error) {
 		return f.rate(), nil
 	case prebuilt.OperatorIRate:
 		return f.iRate(), nil
+	case prebuilt.OperatorIncrease:
+		return f.increase(), nil
+	case prebuilt.OperatorChanges:
+		return f.changes(), nil
+	case prebuilt.OperatorResets:
+		return f.resets(), nil
 	default:
 		return "", fmt.Errorf("unsupported operator: %v", f.Operator)
 	}
@@ -55,3 +55,39 @@ func (s *RateFunctionSuite) Test_IRate() {
 	s.Nil(err)
 	s.Check(testsuite.GetCurrentFunctionName(), sql)
 }
+
+func (s *RateFunctionSuite) Test_Increase() {
+	sql, err := NewRateFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Rate(time.Minute).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorIncrease).
+		Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *RateFunctionSuite) Test_Changes() {
+	sql, err := NewRateFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Rate(time.Minute).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorChanges).
+		Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *RateFunctionSuite) Test_Resets() {
+	sql, err := NewRateFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Rate(time.Minute).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorResets).
+		Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
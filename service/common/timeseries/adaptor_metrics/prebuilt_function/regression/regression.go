@@ -0,0 +1,176 @@
+package regression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	builder "sentioxyz/sentio-core/common/sqlbuilder"
+	"sentioxyz/sentio-core/driver/timeseries"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+
+	"github.com/samber/lo"
+)
+
+type regressionFunction struct {
+	*prebuilt.BaseFunction
+	window          time.Duration
+	predictAfter    time.Duration
+	smoothingFactor float64
+	trendFactor     float64
+}
+
+func NewRegressionFunction(meta timeseries.Meta, store prebuilt.Store) prebuilt.RegressionFunction {
+	return &regressionFunction{
+		BaseFunction: prebuilt.NewBaseFunction(meta, store, "regression"),
+	}
+}
+
+func (f *regressionFunction) Regression(window time.Duration) prebuilt.RegressionFunction {
+	defer f.Init(f)
+	f.window = window
+	return f
+}
+
+// PredictAfter sets how far the OperatorPredictLinear predicts from the current timestamp
+func (f *regressionFunction) PredictAfter(d time.Duration) prebuilt.RegressionFunction {
+	f.predictAfter = d
+	return f
+}
+
+// Smoothing sets the factors of OperatorHoltWinters, both must be in (0, 1)
+func (f *regressionFunction) Smoothing(smoothingFactor, trendFactor float64) prebuilt.RegressionFunction {
+	f.smoothingFactor = smoothingFactor
+	f.trendFactor = trendFactor
+	return f
+}
+
+func (f *regressionFunction) windowClause() string {
+	var (
+		orderByClause   = "ORDER BY " + prebuilt.SecondTimestampField + " ASC"
+		partitionClause = lo.If(len(f.Labels) > 0, "PARTITION BY ("+strings.Join(f.Labels, ",")+")").Else("")
+		rangeClause     = fmt.Sprintf("RANGE BETWEEN %d PRECEDING AND CURRENT ROW", int64(f.window.Seconds()-1))
+	)
+	return fmt.Sprintf(" OVER (%s %s %s)", partitionClause, orderByClause, rangeClause)
+}
+
+// linearRegression fits the value against the timestamp in seconds by least squares,
+// slope is the per-second derivative and the intercept is moved to the current timestamp
+func (f *regressionFunction) linearRegression(predictAfter time.Duration) string {
+	var (
+		valueField = f.GetValueField()
+		over       = f.windowClause()
+		// only the timestamps with value take part in the regression
+		x = "if(isNull(" + valueField + "), NULL, toFloat64(" + prebuilt.SecondTimestampField + "))"
+		y = "toFloat64(" + valueField + ")"
+	)
+	fields := []string{
+		"count(" + valueField + ")" + over + " AS samples",
+		"covarPopStable(" + x + ", " + y + ")" + over + " / varPopStable(" + x + ")" + over + " AS slope",
+		"avg(" + y + ")" + over + " + slope * (toFloat64(" + prebuilt.SecondTimestampField + ") - avg(" + x + ")" + over + ") AS intercept",
+	}
+	if predictAfter == 0 {
+		fields = append(fields, "if(samples < 2, NULL, slope) AS "+f.GetResultAlias())
+	} else {
+		fields = append(fields, fmt.Sprintf("if(samples < 2, NULL, intercept + slope * %d) AS %s",
+			int64(predictAfter.Seconds()), f.GetResultAlias()))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// holtWinters is the double exponential smoothing of the samples in the window, same as prometheus:
+//
+//	s1 = v[0], b = v[1] - v[0]
+//	for i in 1..n-1: b = (i == 1 ? b : tf*(s1-s0) + (1-tf)*b), s0, s1 = s1, sf*v[i] + (1-sf)*(s1+b)
+//
+// the state of arrayFold is the tuple (s0, s1, b, i)
+func (f *regressionFunction) holtWinters() (string, error) {
+	if f.smoothingFactor <= 0 || f.smoothingFactor >= 1 {
+		return "", fmt.Errorf("smoothing factor must be in (0, 1), got: %v", f.smoothingFactor)
+	}
+	if f.trendFactor <= 0 || f.trendFactor >= 1 {
+		return "", fmt.Errorf("trend factor must be in (0, 1), got: %v", f.trendFactor)
+	}
+	const tpl = `groupArrayIf(toFloat64(ifNull({value_field}, 0)), {value_field} IS NOT NULL){over} AS samples_array,
+		if(length(samples_array) < 2, NULL, tupleElement(arrayFold(
+			(acc, x) -> (
+				tupleElement(acc, 2),
+				{sf} * x + (1 - {sf}) * (tupleElement(acc, 2) + if(tupleElement(acc, 4) = 0, tupleElement(acc, 3), {tf} * (tupleElement(acc, 2) - tupleElement(acc, 1)) + (1 - {tf}) * tupleElement(acc, 3))),
+				if(tupleElement(acc, 4) = 0, tupleElement(acc, 3), {tf} * (tupleElement(acc, 2) - tupleElement(acc, 1)) + (1 - {tf}) * tupleElement(acc, 3)),
+				tupleElement(acc, 4) + 1
+			),
+			arraySlice(samples_array, 2),
+			(toFloat64(0), samples_array[1], samples_array[2] - samples_array[1], toUInt64(0))
+		), 2)) AS {result_alias}`
+	return builder.FormatSQLTemplate(tpl, map[string]any{
+		"value_field":  f.GetValueField(),
+		"over":         f.windowClause(),
+		"sf":           strconv.FormatFloat(f.smoothingFactor, 'g', -1, 64),
+		"tf":           strconv.FormatFloat(f.trendFactor, 'g', -1, 64),
+		"result_alias": f.GetResultAlias(),
+	}), nil
+}
+
+func (f *regressionFunction) opString() (string, error) {
+	switch f.Operator {
+	case prebuilt.OperatorDeriv:
+		return f.linearRegression(0), nil
+	case prebuilt.OperatorPredictLinear:
+		return f.linearRegression(f.predictAfter), nil
+	case prebuilt.OperatorHoltWinters:
+		return f.holtWinters()
+	default:
+		return "", fmt.Errorf("unsupported operator: %v", f.Operator)
+	}
+}
+
+func (f *regressionFunction) Generate() (string, error) {
+	if f.window == 0 {
+		return "", fmt.Errorf("window size is required")
+	}
+	var (
+		labelFields    = f.GetLabelFields()
+		orderByClause  = "ORDER BY " + prebuilt.MilliTimestampField + " ASC"
+		whereClause    = f.WhereClause(f.TimeRange)
+		aggrField, err = f.opString()
+	)
+	if err != nil {
+		return "", err
+	}
+
+	const tpl = `
+	SELECT
+		{timestamp},
+		{milli_timestamp},
+		{label_fields}
+		{result_alias} AS {result_alias}
+	FROM (
+		SELECT
+			{timestamp},
+			{second_timestamp},
+			{label_fields}
+			{aggr_field}
+		FROM {table} {where_clause} {order_by_clause}
+	) AS regression_table
+	WHERE {timestamp} = date_trunc('{step_unit}', {timestamp}, '{timezone}') AND {result_alias} IS NOT NULL
+`
+
+	return builder.FormatSQLTemplate(tpl, map[string]any{
+		"timestamp":        timeseries.SystemTimestamp,
+		"milli_timestamp":  prebuilt.MilliTimestamp,
+		"second_timestamp": prebuilt.SecondTimestamp,
+		"label_fields":     labelFields,
+		"aggr_field":       aggrField,
+		"result_alias":     f.GetResultAlias(),
+		"table":            f.GetTableName(),
+		"where_clause":     whereClause,
+		"order_by_clause":  orderByClause,
+		"step_unit":        f.StepUnit(f.TimeRange),
+		"timezone":         lo.IfF(f.TimeRange != nil, func() string { return f.TimeRange.Timezone.String() }).Else("UTC"),
+	}), nil
+}
+
+func (f *regressionFunction) GetFuncName() string {
+	return "regression_function"
+}
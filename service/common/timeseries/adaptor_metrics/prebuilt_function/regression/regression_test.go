@@ -0,0 +1,94 @@
+package regression
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sentioxyz/sentio-core/driver/timeseries"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function/testsuite"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegressionFunctionSuite struct {
+	testsuite.Suite
+}
+
+func Test_RunRegressionFunctionSuite(t *testing.T) {
+	opt, err := clickhouse.ParseDSN(testsuite.LocalClickhouseDSN)
+	if err != nil {
+		panic(err)
+	}
+	conn, err := clickhouse.Open(opt)
+	if err != nil {
+		t.Skipf("failed to open clickhouse, skip test: %v", err)
+	}
+	if err := conn.QueryRow(context.Background(), "select 1").Err(); err != nil {
+		t.Skipf("failed to query clickhouse, skip test: %v", err)
+	}
+
+	suite.Run(t, new(RegressionFunctionSuite))
+}
+
+func (s *RegressionFunctionSuite) Test_Deriv() {
+	sql, err := NewRegressionFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Regression(time.Hour).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorDeriv).
+		Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *RegressionFunctionSuite) Test_PredictLinear() {
+	sql, err := NewRegressionFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Regression(time.Hour).
+		PredictAfter(time.Hour * 4).
+		WithLabels([]string{"meta.chain", "from"}).
+		WithOp(prebuilt.OperatorPredictLinear).
+		Generate()
+	s.Nil(err)
+	s.Contains(sql, "intercept + slope * 14400")
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *RegressionFunctionSuite) Test_HoltWinters() {
+	sql, err := NewRegressionFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Regression(time.Hour).
+		Smoothing(0.5, 0.1).
+		WithLabels([]string{"meta.chain"}).
+		WithOp(prebuilt.OperatorHoltWinters).
+		Generate()
+	s.Nil(err)
+	s.Check(testsuite.GetCurrentFunctionName(), sql)
+}
+
+func (s *RegressionFunctionSuite) Test_HoltWintersInvalidFactor_Error() {
+	_, err := NewRegressionFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Regression(time.Hour).
+		Smoothing(1, 0.1).
+		WithOp(prebuilt.OperatorHoltWinters).
+		Generate()
+	s.NotNil(err)
+}
+
+func (s *RegressionFunctionSuite) Test_NoWindow_Error() {
+	_, err := NewRegressionFunction(timeseries.Meta{
+		Name: "Transfer",
+		Type: timeseries.MetaTypeGauge,
+	}, s.Store).Regression(0).
+		WithOp(prebuilt.OperatorDeriv).
+		Generate()
+	s.NotNil(err)
+}
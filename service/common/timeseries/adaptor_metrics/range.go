@@ -143,7 +143,7 @@ func (qra *queryRangeAdaptor) Build() (string, error) {
 		}).Else("")
 		labels = lo.IfF(qra.params != nil && qra.params.operator != nil && len(qra.params.groups) > 0, func() string {
 			return strings.Join(qra.params.groups, ",") + ","
-		}).ElseIfF(qra.params.operator == nil, func() string {
+		}).ElseIfF(qra.params.operator == nil && len(qra.functionAdaptor.SeriesLabel()) > 0, func() string {
 			return strings.Join(qra.functionAdaptor.SeriesLabel(), ",") + ","
 		}).Else("")
 	)
@@ -167,7 +167,7 @@ func (qra *queryRangeAdaptor) Scan(ctx context.Context, scan adaptor.ScanFunc, s
 	if err := qra.Error(); err != nil {
 		return nil, err
 	}
-	rows, err := scan(ctx, sql, args)
+	rows, err := scan(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
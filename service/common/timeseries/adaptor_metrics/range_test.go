@@ -2,6 +2,7 @@ package adaptor_metrics
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -12,7 +13,9 @@ import (
 	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/selector"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	clickhouselib "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -150,3 +153,16 @@ func (s *RangeSuite) Test_BuildError_NoTimeRange() {
 	_, err = queryRange.Build()
 	s.NotNil(err)
 }
+
+// Scan should pass the args to the scan function one by one, not as a single slice
+func TestScanArgs(t *testing.T) {
+	queryErr := errors.New("query error")
+	var scanned []any
+	scan := func(ctx context.Context, sql string, args ...any) (clickhouselib.Rows, error) {
+		scanned = args
+		return nil, queryErr
+	}
+	_, err := (&queryRangeAdaptor{}).Scan(context.Background(), scan, "select ?, ?", "a", 1)
+	assert.ErrorIs(t, err, queryErr)
+	assert.Equal(t, []any{"a", 1}, scanned)
+}
@@ -60,11 +60,25 @@ type RedisConfig struct {
 
 // StorageConfig contains storage settings
 type StorageConfig struct {
-	DefaultStorage      string `yaml:"default_storage"`
-	IpfsApiUrl          string `yaml:"ipfs_api_url"`
-	IpfsGatewayUrl      string `yaml:"ipfs_gateway_url"`
-	LocalStoragePath    string `yaml:"local_storage_path"`
-	LocalStorageBaseURL string `yaml:"local_storage_base_url"`
+	DefaultStorage      string          `yaml:"default_storage"`
+	IpfsApiUrl          string          `yaml:"ipfs_api_url"`
+	IpfsGatewayUrl      string          `yaml:"ipfs_gateway_url"`
+	LocalStoragePath    string          `yaml:"local_storage_path"`
+	LocalStorageBaseURL string          `yaml:"local_storage_base_url"`
+	S3                  S3StorageConfig `yaml:"s3"`
+}
+
+// S3StorageConfig contains settings of the S3 compatible storage, used when DefaultStorage is "s3"
+type S3StorageConfig struct {
+	Endpoint         string `yaml:"endpoint"`
+	InternalEndpoint string `yaml:"internal_endpoint"`
+	Region           string `yaml:"region"`
+	AccessKeyID      string `yaml:"access_key_id"`
+	SecretKey        string `yaml:"secret_key"`
+	Bucket           string `yaml:"bucket"`
+	PathStyle        bool   `yaml:"path_style"`
+	Prefix           string `yaml:"prefix"`
+	UploadPrefix     string `yaml:"upload_prefix"`
 }
 
 // ServerConfig represents configuration for a single gRPC server
@@ -216,6 +216,9 @@ func (sm *ServiceManager) registerServices() error {
 	sm.services["processor"] = NewProcessorService()
 	sm.services["localstorage"] = NewLocalStorageService()
 	sm.services["project"] = NewProjectServiceFactory()
+	sm.services["usage"] = NewUsageServiceFactory()
+	sm.services["observability"] = NewObservabilityServiceFactory()
+	sm.services["analytic"] = NewAnalyticServiceFactory()
 
 	return nil
 }
@@ -0,0 +1,211 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/service/analytic"
+	"sentioxyz/sentio-core/service/analytic/clients/rewriter"
+	analyticprotos "sentioxyz/sentio-core/service/analytic/protos"
+	"sentioxyz/sentio-core/service/common/rpc"
+	processorrepo "sentioxyz/sentio-core/service/processor/repository"
+	projectservice "sentioxyz/sentio-core/service/project"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+)
+
+// AnalyticServiceFactory implements the Service interface for the analytic service
+type AnalyticServiceFactory struct{}
+
+// NewAnalyticServiceFactory creates a new analytic service factory
+func NewAnalyticServiceFactory() Service {
+	return &AnalyticServiceFactory{}
+}
+
+// Create creates a new analytic service instance
+func (af *AnalyticServiceFactory) Create(name string, serviceConfig *ServiceConfig, sharedConfig *SharedConfig) (ServiceInstance, error) {
+	return &AnalyticServiceInstance{
+		name:          name,
+		serviceConfig: serviceConfig,
+		sharedConfig:  sharedConfig,
+		status:        StatusStopped,
+	}, nil
+}
+
+// AnalyticServiceInstance represents a running analytic service instance
+type AnalyticServiceInstance struct {
+	name          string
+	serviceConfig *ServiceConfig
+	sharedConfig  *SharedConfig
+	status        ServiceStatus
+	analyticSvc   *analytic.Service
+	cancelFunc    context.CancelFunc
+	mutex         sync.RWMutex
+}
+
+// extractConfig overrides the default analytic config with the service-specific configuration
+func (asi *AnalyticServiceInstance) extractConfig() (analytic.Config, error) {
+	cfg := analytic.DefaultConfig()
+	if asi.serviceConfig.Config == nil {
+		return cfg, nil
+	}
+	data, err := yaml.Marshal(asi.serviceConfig.Config)
+	if err != nil {
+		return cfg, err
+	}
+	err = yaml.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// Initialize initializes the analytic service dependencies
+func (asi *AnalyticServiceInstance) Initialize(ctx context.Context) error {
+	asi.mutex.Lock()
+	defer asi.mutex.Unlock()
+
+	if asi.status == StatusRunning {
+		return fmt.Errorf("service %s is already initialized", asi.name)
+	}
+
+	asi.status = StatusStarting
+	log.Infof("Initializing analytic service %s", asi.name)
+
+	cfg, err := asi.extractConfig()
+	if err != nil {
+		asi.status = StatusError
+		return errors.Wrapf(err, "invalid config of service %s", asi.name)
+	}
+
+	// Setup Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     asi.sharedConfig.Redis.URL,
+		Password: asi.sharedConfig.Redis.Password,
+		DB:       asi.sharedConfig.Redis.DB,
+	})
+
+	// Test Redis connection
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		asi.status = StatusError
+		return errors.Wrapf(err, "failed to connect to Redis")
+	}
+	log.Infof("Connected to Redis at %s", asi.sharedConfig.Redis.URL)
+
+	// the metas of the stores are refreshed and the async executions run in background until Stop
+	storeCtx, cancel := context.WithCancel(context.Background())
+	stores, err := analytic.NewClickhouseStoreProvider(storeCtx, cfg)
+	if err != nil {
+		cancel()
+		asi.status = StatusError
+		return errors.Wrapf(err, "failed to create clickhouse store provider")
+	}
+	asi.cancelFunc = cancel
+
+	if cfg.RewriterAddr == "" {
+		cancel()
+		asi.status = StatusError
+		return errors.Errorf("rewriter_addr of service %s is required", asi.name)
+	}
+	rewriterClient, err := rewriter.NewRewriterClient(cfg.RewriterAddr)
+	if err != nil {
+		cancel()
+		asi.status = StatusError
+		return errors.Wrapf(err, "failed to create rewriter client")
+	}
+
+	asi.analyticSvc = analytic.NewService(
+		storeCtx,
+		projectservice.NewProjectService(projectrepo.NewRedisProjectRepository(redisClient)),
+		processorrepo.NewRedisProcessorRepo(redisClient),
+		stores,
+		rewriterClient,
+		redisClient,
+		cfg,
+	)
+
+	asi.status = StatusStopped
+	log.Infof("%s initialized successfully", asi.name)
+
+	return nil
+}
+
+// Register registers the analytic service on the provided gRPC server and HTTP mux
+func (asi *AnalyticServiceInstance) Register(grpcServer *grpc.Server, mux *runtime.ServeMux, httpPort int) error {
+	asi.mutex.Lock()
+	defer asi.mutex.Unlock()
+
+	if asi.analyticSvc == nil {
+		return fmt.Errorf("analytic service %s not initialized", asi.name)
+	}
+
+	analyticprotos.RegisterAnalyticServiceServer(grpcServer, asi.analyticSvc)
+
+	err := analyticprotos.RegisterAnalyticServiceHandlerFromEndpoint(context.Background(),
+		mux,
+		fmt.Sprintf(":%d", httpPort),
+		rpc.GRPCGatewayDialOptions)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%s registered on gRPC server and HTTP mux", asi.name)
+	return nil
+}
+
+// Start starts the analytic service
+func (asi *AnalyticServiceInstance) Start(ctx context.Context) error {
+	asi.mutex.Lock()
+	defer asi.mutex.Unlock()
+
+	if asi.status == StatusRunning {
+		return fmt.Errorf("service %s is already running", asi.name)
+	}
+
+	asi.status = StatusRunning
+	log.Infof("Service %s started successfully", asi.name)
+	return nil
+}
+
+// Stop stops the analytic service
+func (asi *AnalyticServiceInstance) Stop(ctx context.Context) error {
+	asi.mutex.Lock()
+	defer asi.mutex.Unlock()
+
+	if asi.status == StatusStopped {
+		return nil
+	}
+
+	asi.status = StatusStopping
+	log.Infof("Stopping analytic service %s", asi.name)
+
+	if asi.cancelFunc != nil {
+		asi.cancelFunc()
+	}
+
+	asi.status = StatusStopped
+	log.Infof("Analytic service %s stopped", asi.name)
+
+	return nil
+}
+
+// Status returns the current status of the service
+func (asi *AnalyticServiceInstance) Status() string {
+	asi.mutex.RLock()
+	defer asi.mutex.RUnlock()
+	return string(asi.status)
+}
+
+// Name returns the name of the service instance
+func (asi *AnalyticServiceInstance) Name() string {
+	return asi.name
+}
+
+// Type returns the type of the service
+func (asi *AnalyticServiceInstance) Type() string {
+	return "analytic"
+}
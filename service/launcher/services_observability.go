@@ -0,0 +1,195 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/service/common/rpc"
+	"sentioxyz/sentio-core/service/observability"
+	observabilityprotos "sentioxyz/sentio-core/service/observability/protos"
+	processorrepo "sentioxyz/sentio-core/service/processor/repository"
+	projectservice "sentioxyz/sentio-core/service/project"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+)
+
+// ObservabilityServiceFactory implements the Service interface for the observability service
+type ObservabilityServiceFactory struct{}
+
+// NewObservabilityServiceFactory creates a new observability service factory
+func NewObservabilityServiceFactory() Service {
+	return &ObservabilityServiceFactory{}
+}
+
+// Create creates a new observability service instance
+func (of *ObservabilityServiceFactory) Create(name string, serviceConfig *ServiceConfig, sharedConfig *SharedConfig) (ServiceInstance, error) {
+	return &ObservabilityServiceInstance{
+		name:          name,
+		serviceConfig: serviceConfig,
+		sharedConfig:  sharedConfig,
+		status:        StatusStopped,
+	}, nil
+}
+
+// ObservabilityServiceInstance represents a running observability service instance
+type ObservabilityServiceInstance struct {
+	name             string
+	serviceConfig    *ServiceConfig
+	sharedConfig     *SharedConfig
+	status           ServiceStatus
+	observabilitySvc *observability.Service
+	cancelFunc       context.CancelFunc
+	mutex            sync.RWMutex
+}
+
+// extractConfig overrides the default observability config with the service-specific configuration
+func (osi *ObservabilityServiceInstance) extractConfig() (observability.Config, error) {
+	cfg := observability.DefaultConfig()
+	if osi.serviceConfig.Config == nil {
+		return cfg, nil
+	}
+	data, err := yaml.Marshal(osi.serviceConfig.Config)
+	if err != nil {
+		return cfg, err
+	}
+	err = yaml.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// Initialize initializes the observability service dependencies
+func (osi *ObservabilityServiceInstance) Initialize(ctx context.Context) error {
+	osi.mutex.Lock()
+	defer osi.mutex.Unlock()
+
+	if osi.status == StatusRunning {
+		return fmt.Errorf("service %s is already initialized", osi.name)
+	}
+
+	osi.status = StatusStarting
+	log.Infof("Initializing observability service %s", osi.name)
+
+	cfg, err := osi.extractConfig()
+	if err != nil {
+		osi.status = StatusError
+		return errors.Wrapf(err, "invalid config of service %s", osi.name)
+	}
+
+	// Setup Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     osi.sharedConfig.Redis.URL,
+		Password: osi.sharedConfig.Redis.Password,
+		DB:       osi.sharedConfig.Redis.DB,
+	})
+
+	// Test Redis connection
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		osi.status = StatusError
+		return errors.Wrapf(err, "failed to connect to Redis")
+	}
+	log.Infof("Connected to Redis at %s", osi.sharedConfig.Redis.URL)
+
+	// the metas of the stores are refreshed in background until Stop
+	storeCtx, cancel := context.WithCancel(context.Background())
+	stores, err := observability.NewClickhouseStoreProvider(storeCtx, cfg)
+	if err != nil {
+		cancel()
+		osi.status = StatusError
+		return errors.Wrapf(err, "failed to create clickhouse store provider")
+	}
+	osi.cancelFunc = cancel
+
+	osi.observabilitySvc = observability.NewService(
+		projectservice.NewProjectService(projectrepo.NewRedisProjectRepository(redisClient)),
+		processorrepo.NewRedisProcessorRepo(redisClient),
+		stores,
+		cfg,
+	)
+
+	osi.status = StatusStopped
+	log.Infof("%s initialized successfully", osi.name)
+
+	return nil
+}
+
+// Register registers the observability service on the provided gRPC server and HTTP mux
+func (osi *ObservabilityServiceInstance) Register(grpcServer *grpc.Server, mux *runtime.ServeMux, httpPort int) error {
+	osi.mutex.Lock()
+	defer osi.mutex.Unlock()
+
+	if osi.observabilitySvc == nil {
+		return fmt.Errorf("observability service %s not initialized", osi.name)
+	}
+
+	observabilityprotos.RegisterObservabilityServiceServer(grpcServer, osi.observabilitySvc)
+
+	err := observabilityprotos.RegisterObservabilityServiceHandlerFromEndpoint(context.Background(),
+		mux,
+		fmt.Sprintf(":%d", httpPort),
+		rpc.GRPCGatewayDialOptions)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%s registered on gRPC server and HTTP mux", osi.name)
+	return nil
+}
+
+// Start starts the observability service
+func (osi *ObservabilityServiceInstance) Start(ctx context.Context) error {
+	osi.mutex.Lock()
+	defer osi.mutex.Unlock()
+
+	if osi.status == StatusRunning {
+		return fmt.Errorf("service %s is already running", osi.name)
+	}
+
+	osi.status = StatusRunning
+	log.Infof("Service %s started successfully", osi.name)
+	return nil
+}
+
+// Stop stops the observability service
+func (osi *ObservabilityServiceInstance) Stop(ctx context.Context) error {
+	osi.mutex.Lock()
+	defer osi.mutex.Unlock()
+
+	if osi.status == StatusStopped {
+		return nil
+	}
+
+	osi.status = StatusStopping
+	log.Infof("Stopping observability service %s", osi.name)
+
+	if osi.cancelFunc != nil {
+		osi.cancelFunc()
+	}
+
+	osi.status = StatusStopped
+	log.Infof("Observability service %s stopped", osi.name)
+
+	return nil
+}
+
+// Status returns the current status of the service
+func (osi *ObservabilityServiceInstance) Status() string {
+	osi.mutex.RLock()
+	defer osi.mutex.RUnlock()
+	return string(osi.status)
+}
+
+// Name returns the name of the service instance
+func (osi *ObservabilityServiceInstance) Name() string {
+	return osi.name
+}
+
+// Type returns the type of the service
+func (osi *ObservabilityServiceInstance) Type() string {
+	return "observability"
+}
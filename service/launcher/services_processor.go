@@ -17,6 +17,7 @@ import (
 	processorservice "sentioxyz/sentio-core/service/processor"
 	coreprotos "sentioxyz/sentio-core/service/processor/protos"
 	"sentioxyz/sentio-core/service/processor/repository"
+	"sentioxyz/sentio-core/service/processor/shadow"
 	"sentioxyz/sentio-core/service/processor/storage"
 )
 
@@ -47,6 +48,7 @@ type ProcessorServiceInstance struct {
 	processorSvc      *processorservice.Service
 	graphNodeService  interface{}
 	fileStorageSystem storagesystem.FileStorageSystemInterface
+	driverJobManager  driverjob.DriverJobManager
 	cancelFunc        context.CancelFunc
 	mutex             sync.RWMutex
 }
@@ -125,12 +127,47 @@ func (psi *ProcessorServiceInstance) Initialize(ctx context.Context) error {
 			psi.sharedConfig.Storage.IpfsApiUrl,
 			psi.sharedConfig.Storage.IpfsGatewayUrl)
 
+	case "s3":
+		s3Config := psi.sharedConfig.Storage.S3
+		s3StorageSystem, err := storage.NewS3StorageSystem(storage.S3StorageSystemConfig{
+			S3Config: storage.S3Config{
+				Endpoint:         s3Config.Endpoint,
+				InternalEndpoint: s3Config.InternalEndpoint,
+				Region:           s3Config.Region,
+				AccessKeyID:      s3Config.AccessKeyID,
+				SecretKey:        s3Config.SecretKey,
+				Bucket:           s3Config.Bucket,
+				PathStyle:        s3Config.PathStyle,
+			},
+			Prefix:       s3Config.Prefix,
+			UploadPrefix: s3Config.UploadPrefix,
+		})
+		if err != nil {
+			psi.status = StatusError
+			return errors.Wrapf(err, "failed to create s3 storage system")
+		}
+		fileStorageSystem = s3StorageSystem
+		log.Infof("Created s3 storage system with endpoint: %s, bucket: %s", s3Config.Endpoint, s3Config.Bucket)
+
 	default:
 		psi.status = StatusError
-		return fmt.Errorf("unsupported storage type: %s (supported: local, ipfs)", storageType)
+		return fmt.Errorf("unsupported storage type: %s (supported: local, ipfs, s3)", storageType)
 	}
 
-	driverJobManager, err := driverjob.NewDockerSwarmManager(ctx, psi.sharedConfig.Driver)
+	var (
+		driverJobManager driverjob.DriverJobManager
+		err              error
+	)
+	switch managerType := psi.sharedConfig.Driver.Manager; managerType {
+	case "", "docker_swarm":
+		driverJobManager, err = driverjob.NewDockerSwarmManager(ctx, psi.sharedConfig.Driver)
+	case "local":
+		driverJobManager, err = driverjob.NewLocalProcessManager(psi.sharedConfig.Driver)
+	case "kubernetes":
+		driverJobManager, err = driverjob.NewKubernetesManager(psi.sharedConfig.Driver, processorRepo, chainStateRepo)
+	default:
+		err = fmt.Errorf("unsupported driver manager: %s (supported: docker_swarm, local, kubernetes)", managerType)
+	}
 	if err != nil {
 		psi.status = StatusError
 		return errors.Wrapf(err, "failed to create driver job manager")
@@ -148,6 +185,9 @@ func (psi *ProcessorServiceInstance) Initialize(ctx context.Context) error {
 		nil, // lifecycleHook
 		redisClient,
 	)
+	if path := psi.sharedConfig.ClickHouse.Path; path != "" {
+		processorSvc.SetShadowSourceProvider(shadow.NewClickhouseSourceProvider(path))
+	}
 
 	// Store processor service for later use
 	psi.processorSvc = processorSvc
@@ -155,6 +195,7 @@ func (psi *ProcessorServiceInstance) Initialize(ctx context.Context) error {
 
 	// Store file storage system for later use
 	psi.fileStorageSystem = fileStorageSystem
+	psi.driverJobManager = driverJobManager
 
 	psi.status = StatusStopped
 	log.Infof("%s initialized successfully", psi.name)
@@ -199,10 +240,18 @@ func (psi *ProcessorServiceInstance) Start(ctx context.Context) error {
 		return fmt.Errorf("service %s is already running", psi.name)
 	}
 
-	// Create context for background processes
-	_, cancel := context.WithCancel(ctx)
+	// Create context for background processes, ctx is only for starting
+	serviceCtx, cancel := context.WithCancel(context.Background())
 	psi.cancelFunc = cancel
 
+	// The kubernetes manager reconciles the driver jobs in background
+	if manager, ok := psi.driverJobManager.(*driverjob.KubernetesManager); ok {
+		if err := manager.StartManager(serviceCtx); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to start driver job manager")
+		}
+	}
+
 	// Start GraphNodeService if enabled
 	/*	if cfg.GraphNodeService.Enabled && cfg.GraphNodeService.Port > 0 && psi.graphNodeService != nil {
 		if graphNodeSvc, ok := psi.graphNodeService.(*processorservice.GraphNodeService); ok {
@@ -0,0 +1,215 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+
+	"sentioxyz/sentio-core/common/log"
+	commonrepo "sentioxyz/sentio-core/service/common/repository"
+	"sentioxyz/sentio-core/service/common/rpc"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+	"sentioxyz/sentio-core/service/usage"
+	usageprotos "sentioxyz/sentio-core/service/usage/protos"
+	usagerepo "sentioxyz/sentio-core/service/usage/repository"
+)
+
+// UsageServiceFactory implements the Service interface for the usage service
+type UsageServiceFactory struct{}
+
+// NewUsageServiceFactory creates a new usage service factory
+func NewUsageServiceFactory() Service {
+	return &UsageServiceFactory{}
+}
+
+// Create creates a new usage service instance
+func (us *UsageServiceFactory) Create(name string, serviceConfig *ServiceConfig, sharedConfig *SharedConfig) (ServiceInstance, error) {
+	return &UsageServiceInstance{
+		name:          name,
+		serviceConfig: serviceConfig,
+		sharedConfig:  sharedConfig,
+		status:        StatusStopped,
+	}, nil
+}
+
+// UsageServiceInstance represents a running usage service instance
+type UsageServiceInstance struct {
+	name          string
+	serviceConfig *ServiceConfig
+	sharedConfig  *SharedConfig
+	status        ServiceStatus
+	usageSvc      *usage.Service
+	cancelFunc    context.CancelFunc
+	done          chan struct{}
+	mutex         sync.RWMutex
+}
+
+// extractConfig overrides the default usage config with the service-specific configuration
+func (usi *UsageServiceInstance) extractConfig() (usage.Config, error) {
+	cfg := usage.DefaultConfig()
+	if usi.serviceConfig.Config == nil {
+		return cfg, nil
+	}
+	data, err := yaml.Marshal(usi.serviceConfig.Config)
+	if err != nil {
+		return cfg, err
+	}
+	err = yaml.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// Initialize initializes the usage service dependencies
+func (usi *UsageServiceInstance) Initialize(ctx context.Context) error {
+	usi.mutex.Lock()
+	defer usi.mutex.Unlock()
+
+	if usi.status == StatusRunning {
+		return fmt.Errorf("service %s is already initialized", usi.name)
+	}
+
+	usi.status = StatusStarting
+	log.Infof("Initializing usage service %s", usi.name)
+
+	cfg, err := usi.extractConfig()
+	if err != nil {
+		usi.status = StatusError
+		return errors.Wrapf(err, "invalid config of service %s", usi.name)
+	}
+
+	if usi.sharedConfig.Database.URL == "" {
+		usi.status = StatusError
+		return fmt.Errorf("database url is required for usage service")
+	}
+	db, err := commonrepo.SetupDBWithoutCache(usi.sharedConfig.Database.URL, usagerepo.Models...)
+	if err != nil {
+		usi.status = StatusError
+		return errors.Wrapf(err, "failed to setup database")
+	}
+
+	// Setup Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     usi.sharedConfig.Redis.URL,
+		Password: usi.sharedConfig.Redis.Password,
+		DB:       usi.sharedConfig.Redis.DB,
+	})
+
+	// Test Redis connection
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		usi.status = StatusError
+		return errors.Wrapf(err, "failed to connect to Redis")
+	}
+	log.Infof("Connected to Redis at %s", usi.sharedConfig.Redis.URL)
+
+	usageSvc, err := usage.NewService(
+		usagerepo.NewUsageRepo(db),
+		projectrepo.NewRedisProjectRepository(redisClient),
+		redisClient,
+		cfg,
+	)
+	if err != nil {
+		usi.status = StatusError
+		return errors.Wrapf(err, "failed to create usage service")
+	}
+	usi.usageSvc = usageSvc
+
+	usi.status = StatusStopped
+	log.Infof("%s initialized successfully", usi.name)
+
+	return nil
+}
+
+// Register registers the usage service on the provided gRPC server and HTTP mux
+func (usi *UsageServiceInstance) Register(grpcServer *grpc.Server, mux *runtime.ServeMux, httpPort int) error {
+	usi.mutex.Lock()
+	defer usi.mutex.Unlock()
+
+	if usi.usageSvc == nil {
+		return fmt.Errorf("usage service %s not initialized", usi.name)
+	}
+
+	usageprotos.RegisterUsageServiceServer(grpcServer, usi.usageSvc)
+
+	err := usageprotos.RegisterUsageServiceHandlerFromEndpoint(context.Background(),
+		mux,
+		fmt.Sprintf(":%d", httpPort),
+		rpc.GRPCGatewayDialOptions)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%s registered on gRPC server and HTTP mux", usi.name)
+	return nil
+}
+
+// Start starts the background flusher of the dialogues
+func (usi *UsageServiceInstance) Start(ctx context.Context) error {
+	usi.mutex.Lock()
+	defer usi.mutex.Unlock()
+
+	if usi.status == StatusRunning {
+		return fmt.Errorf("service %s is already running", usi.name)
+	}
+
+	// ctx passed in is only for the starting, the flusher lives until Stop
+	runCtx, cancel := context.WithCancel(context.Background())
+	usi.cancelFunc = cancel
+	usi.done = make(chan struct{})
+	go func() {
+		defer close(usi.done)
+		usi.usageSvc.Run(runCtx)
+	}()
+
+	usi.status = StatusRunning
+	log.Infof("Service %s started successfully", usi.name)
+	return nil
+}
+
+// Stop stops the usage service, buffered dialogues are flushed before return
+func (usi *UsageServiceInstance) Stop(ctx context.Context) error {
+	usi.mutex.Lock()
+	defer usi.mutex.Unlock()
+
+	if usi.status == StatusStopped {
+		return nil
+	}
+
+	usi.status = StatusStopping
+	log.Infof("Stopping usage service %s", usi.name)
+
+	if usi.cancelFunc != nil {
+		usi.cancelFunc()
+		select {
+		case <-usi.done:
+		case <-ctx.Done():
+			log.Warnf("Usage service %s stop timed out before all dialogues flushed", usi.name)
+		}
+	}
+
+	usi.status = StatusStopped
+	log.Infof("Usage service %s stopped", usi.name)
+
+	return nil
+}
+
+// Status returns the current status of the service
+func (usi *UsageServiceInstance) Status() string {
+	usi.mutex.RLock()
+	defer usi.mutex.RUnlock()
+	return string(usi.status)
+}
+
+// Name returns the name of the service instance
+func (usi *UsageServiceInstance) Name() string {
+	return usi.name
+}
+
+// Type returns the type of the service
+func (usi *UsageServiceInstance) Type() string {
+	return "usage"
+}
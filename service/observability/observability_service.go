@@ -0,0 +1,297 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/driver/timeseries"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	"sentioxyz/sentio-core/service/common/timeseries/compatible"
+	"sentioxyz/sentio-core/service/observability/protos"
+	"sentioxyz/sentio-core/service/processor/models"
+	processorrepo "sentioxyz/sentio-core/service/processor/repository"
+	projectprotos "sentioxyz/sentio-core/service/project/protos"
+)
+
+type Config struct {
+	// the time series tables are read through the clickhouse manager config if it is set, the sharding of
+	// a processor is picked by its clickhouse sharding index, otherwise all processors are read from ClickhouseDSN
+	ClickhouseConfig string `yaml:"clickhouse_config"`
+	ClickhouseDSN    string `yaml:"clickhouse_dsn"`
+
+	StoreCacheSize      int           `yaml:"store_cache_size"`
+	StoreCacheTTL       time.Duration `yaml:"store_cache_ttl"`
+	MetaRefreshInterval time.Duration `yaml:"meta_refresh_interval"`
+
+	// used if the limit is not specified in the request
+	LabelValuesLimit uint64 `yaml:"label_values_limit"`
+	SamplesLimit     int32  `yaml:"samples_limit"`
+	// instant queries take the last value of the series in the window ending at the requested time
+	InstantLookback time.Duration `yaml:"instant_lookback"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		StoreCacheSize:      1000,
+		StoreCacheTTL:       time.Minute * 30,
+		MetaRefreshInterval: time.Minute,
+		LabelValuesLimit:    100,
+		SamplesLimit:        20,
+		InstantLookback:     time.Hour,
+	}
+}
+
+// ProjectService resolves the project of the requests, it is implemented by project.ProjectService
+type ProjectService interface {
+	GetProject(ctx context.Context, req *projectprotos.ProjectOwnerAndSlug) (*projectprotos.GetProjectResponse, error)
+	GetProjectById(ctx context.Context, req *projectprotos.GetProjectByIdRequest) (*commonprotos.ProjectInfo, error)
+}
+
+type Service struct {
+	protos.UnimplementedObservabilityServiceServer
+
+	config     Config
+	projects   ProjectService
+	processors processorrepo.RedisProcessorRepoInterface
+	stores     StoreProvider
+}
+
+func NewService(
+	projects ProjectService,
+	processors processorrepo.RedisProcessorRepoInterface,
+	stores StoreProvider,
+	config Config,
+) *Service {
+	return &Service{
+		config:     config,
+		projects:   projects,
+		processors: processors,
+		stores:     stores,
+	}
+}
+
+// resolveProject returns the id of the project specified by the id or the owner and slug,
+// both of them must point to the same project if they are all specified
+func (s *Service) resolveProject(ctx context.Context, projectID, owner, slug string) (string, error) {
+	if owner != "" && slug != "" {
+		resp, err := s.projects.GetProject(ctx, &projectprotos.ProjectOwnerAndSlug{OwnerName: owner, Slug: slug})
+		if err != nil {
+			return "", err
+		}
+		if projectID != "" && projectID != resp.GetProject().GetId() {
+			return "", status.Errorf(codes.PermissionDenied, "project %s is not %s/%s", projectID, owner, slug)
+		}
+		return resp.GetProject().GetId(), nil
+	}
+	if projectID != "" {
+		project, err := s.projects.GetProjectById(ctx, &projectprotos.GetProjectByIdRequest{ProjectId: projectID})
+		if err != nil {
+			return "", err
+		}
+		return project.GetId(), nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "project id or project owner and slug is required")
+}
+
+// resolveProcessor returns the processor holding the metrics of the project, the active version is used
+// if version is not specified, and the latest version if there is no active one
+func (s *Service) resolveProcessor(ctx context.Context, projectID string, version int32) (*models.Processor, error) {
+	var (
+		processor *models.Processor
+		err       error
+	)
+	if version > 0 {
+		processor, err = s.processors.FindProcessorByVersion(ctx, projectID, version)
+	} else if processor, err = s.processors.FindActiveProcessor(ctx, projectID); err != nil {
+		processor, err = s.processors.FindLatestProcessor(ctx, projectID)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "processor not found: %v", err)
+	}
+	// processors referencing another project read the metrics of the referenced one
+	if processor, err = s.processors.ResolveReferenceProcessor(ctx, processor); err != nil {
+		return nil, status.Errorf(codes.NotFound, "reference processor not found: %v", err)
+	}
+	return processor, nil
+}
+
+func (s *Service) getStore(ctx context.Context, projectID string, version int32) (Store, error) {
+	processor, err := s.resolveProcessor(ctx, projectID, version)
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.stores.GetStore(ctx, processor)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+	return store, nil
+}
+
+// metricTypes are the meta types queryable as metrics, in the priority of the name lookup
+var metricTypes = []timeseries.MetaType{timeseries.MetaTypeGauge, timeseries.MetaTypeCounter}
+
+// labelNames maps the field names of the system labels back to the names used by the requests
+var labelNames = func() map[string]string {
+	m := make(map[string]string)
+	for name, field := range compatible.FieldNameTransform {
+		m[field] = name
+	}
+	return m
+}()
+
+func labelName(fieldName string) string {
+	if name, has := labelNames[fieldName]; has {
+		return name
+	}
+	return fieldName
+}
+
+// metricLabels returns the field names of the labels of the metric in a stable order
+func metricLabels(meta timeseries.Meta) []string {
+	var labels = []string{meta.GetChainIDField().Name}
+	for _, name := range []string{"contract", "address"} {
+		if field, has := meta.Fields[compatible.FieldNameTransform[name]]; has {
+			labels = append(labels, field.Name)
+		}
+	}
+	var series []string
+	for _, field := range meta.GetFieldsByRole(timeseries.FieldRoleSeriesLabel) {
+		if !strings.HasPrefix(field.Name, timeseries.SystemFieldPrefix) {
+			series = append(series, field.Name)
+		}
+	}
+	sort.Strings(series)
+	return append(labels, series...)
+}
+
+func (s *Service) GetMetrics(ctx context.Context, req *protos.GetMetricsRequest) (*protos.GetMetricsResponse, error) {
+	start := time.Now()
+	projectID, err := s.resolveProject(ctx, req.GetProjectId(), "", "")
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.getStore(ctx, projectID, req.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	labelLimit := req.GetLabelLimit()
+	if labelLimit == 0 {
+		labelLimit = s.config.LabelValuesLimit
+	}
+
+	resp := &protos.GetMetricsResponse{}
+	for _, metaType := range metricTypes {
+		metas := store.Meta().MetaByType(metaType)
+		names := make([]string, 0, len(metas))
+		for name := range metas {
+			if req.GetName() == "" || req.GetName() == name {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			info, err := s.getMetricInfo(ctx, store, metas[name], labelLimit, req.GetLabelSearchQuery())
+			if err != nil {
+				log.WithContext(ctx).Errorfe(err, "get info of metric %s failed", name)
+				return nil, status.Errorf(codes.Internal, "get info of metric %s failed: %v", name, err)
+			}
+			info.ProjectId = projectID
+			resp.Metrics = append(resp.Metrics, info)
+		}
+	}
+	resp.ComputeStats = &commonprotos.ComputeStats{
+		ComputedAt:    timestamppb.New(start),
+		ComputeCostMs: time.Since(start).Milliseconds(),
+	}
+	return resp, nil
+}
+
+// getMetricInfo loads the distinct values of the labels and the last seen time of the metric in one query,
+// the values are filtered by the search case-insensitively if it is not empty
+func (s *Service) getMetricInfo(
+	ctx context.Context,
+	store Store,
+	meta timeseries.Meta,
+	labelLimit uint64,
+	search string,
+) (*protos.MetricInfo, error) {
+	var (
+		labels  = metricLabels(meta)
+		columns = []string{"max(" + timeseries.SystemTimestamp + ")"}
+		args    []any
+	)
+	for _, label := range labels {
+		value := "toString(" + timeseries.EscapeMetricsFieldName(label) + ")"
+		cond := "1"
+		if search != "" {
+			cond = "positionCaseInsensitive(" + value + ", ?) > 0"
+			args = append(args, search, search)
+		}
+		columns = append(columns,
+			fmt.Sprintf("groupUniqArrayIf(%d)(%s, %s)", labelLimit, value, cond),
+			fmt.Sprintf("uniqExactIf(%s, %s)", value, cond))
+	}
+	sql := "SELECT " + strings.Join(columns, ", ") + " FROM " + store.MetaTableName(meta)
+
+	rows, err := store.Client().Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var (
+		lastSeen time.Time
+		values   = make([][]string, len(labels))
+		counts   = make([]uint64, len(labels))
+		dest     = []any{&lastSeen}
+	)
+	for i := range labels {
+		dest = append(dest, &values[i], &counts[i])
+	}
+	if rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	info := &protos.MetricInfo{
+		Name:        meta.Name,
+		DisplayName: meta.Name,
+		Labels:      make(map[string]*protos.MetricInfo_LabelValues),
+		Metadata: &protos.MetricMetadata{
+			Type: string(meta.Type),
+		},
+	}
+	if lastSeen.Unix() > 0 {
+		info.Metadata.LastSeen = lastSeen.Unix()
+	}
+	for i, label := range labels {
+		sort.Strings(values[i])
+		name := labelName(label)
+		info.Labels[name] = &protos.MetricInfo_LabelValues{
+			Values:     values[i],
+			TotalCount: counts[i],
+		}
+		switch name {
+		case "chain":
+			info.ChainId = values[i]
+		case "contract":
+			info.ContractName = values[i]
+		case "address":
+			info.ContractAddress = values[i]
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,459 @@
+package observability
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clickhouselib "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/driver/timeseries"
+	chstimeseries "sentioxyz/sentio-core/driver/timeseries/clickhouse"
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	"sentioxyz/sentio-core/service/observability/protos"
+	"sentioxyz/sentio-core/service/processor/models"
+	processorprotos "sentioxyz/sentio-core/service/processor/protos"
+	processorrepo "sentioxyz/sentio-core/service/processor/repository"
+	"sentioxyz/sentio-core/service/project"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+)
+
+type fakeColumnType struct {
+	name     string
+	scanType reflect.Type
+}
+
+func (c fakeColumnType) Name() string             { return c.name }
+func (c fakeColumnType) ScanType() reflect.Type   { return c.scanType }
+func (c fakeColumnType) Nullable() bool           { return false }
+func (c fakeColumnType) DatabaseTypeName() string { return c.scanType.String() }
+
+// fakeRows returns the recorded rows, the types of the columns are the types of the values in the first row
+type fakeRows struct {
+	clickhouselib.Rows
+	columns []string
+	data    [][]any
+	current int
+}
+
+func (r *fakeRows) Next() bool {
+	if r.current >= len(r.data) {
+		return false
+	}
+	r.current++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r.data[r.current-1][i]))
+	}
+	return nil
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) ColumnTypes() []clickhouselib.ColumnType {
+	var types []clickhouselib.ColumnType
+	for i, name := range r.columns {
+		types = append(types, fakeColumnType{name: name, scanType: reflect.TypeOf(r.data[0][i])})
+	}
+	return types
+}
+
+func (r *fakeRows) Err() error   { return nil }
+func (r *fakeRows) Close() error { return nil }
+
+type recordedQuery struct {
+	sql  string
+	args []any
+}
+
+// fakeConn answers the queries with the rows registered by a substring of the sql
+type fakeConn struct {
+	ckhmanager.Conn
+
+	mu      sync.Mutex
+	results map[string]*fakeRows
+	queries []recordedQuery
+}
+
+func (c *fakeConn) GetDatabase() string { return "test" }
+func (c *fakeConn) GetCluster() string  { return "" }
+func (c *fakeConn) GetHost() string     { return "fake" }
+
+func (c *fakeConn) Query(_ context.Context, sql string, args ...any) (clickhouselib.Rows, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, recordedQuery{sql: sql, args: args})
+	for pattern, rows := range c.results {
+		if strings.Contains(sql, pattern) {
+			return &fakeRows{columns: rows.columns, data: rows.data}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+func (c *fakeConn) lastQuery() recordedQuery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queries[len(c.queries)-1]
+}
+
+type fakeStoreMeta struct {
+	timeseries.StoreMeta
+	metas map[timeseries.MetaType]map[string]timeseries.Meta
+}
+
+func (m *fakeStoreMeta) MetaByType(t timeseries.MetaType) map[string]timeseries.Meta {
+	return m.metas[t]
+}
+
+func (m *fakeStoreMeta) Meta(t timeseries.MetaType, name string) (timeseries.Meta, bool) {
+	meta, has := m.metas[t][name]
+	return meta, has
+}
+
+type fakeStore struct {
+	*chstimeseries.Store
+	meta *fakeStoreMeta
+}
+
+func (s *fakeStore) Meta() timeseries.StoreMeta {
+	return s.meta
+}
+
+type fakeStoreProvider struct {
+	store      *fakeStore
+	processors []string
+}
+
+func (p *fakeStoreProvider) GetStore(_ context.Context, processor *models.Processor) (Store, error) {
+	p.processors = append(p.processors, processor.ID)
+	return p.store, nil
+}
+
+func newMeta(name string, metaType timeseries.MetaType, labels ...string) timeseries.Meta {
+	fields := map[string]timeseries.Field{
+		"meta.chain":             {Name: "meta.chain", Type: timeseries.FieldTypeString, Role: timeseries.FieldRoleChainID, BuiltIn: true},
+		"meta.address":           {Name: "meta.address", Type: timeseries.FieldTypeString, BuiltIn: true},
+		"meta.block_number":      {Name: "meta.block_number", Type: timeseries.FieldTypeInt, BuiltIn: true},
+		"meta.transaction_index": {Name: "meta.transaction_index", Type: timeseries.FieldTypeInt, BuiltIn: true},
+		"meta.log_index":         {Name: "meta.log_index", Type: timeseries.FieldTypeInt, BuiltIn: true},
+		timeseries.SystemTimestamp: {
+			Name: timeseries.SystemTimestamp, Type: timeseries.FieldTypeTime, Role: timeseries.FieldRoleTimestamp, BuiltIn: true},
+		timeseries.MetricValueFieldName: {
+			Name: timeseries.MetricValueFieldName, Type: timeseries.FieldTypeBigFloat, Role: timeseries.FieldRoleSeriesValue, BuiltIn: true},
+	}
+	for _, label := range labels {
+		fields[label] = timeseries.Field{Name: label, Type: timeseries.FieldTypeString, Role: timeseries.FieldRoleSeriesLabel}
+	}
+	return timeseries.Meta{Name: name, Type: metaType, Fields: fields}
+}
+
+type testEnv struct {
+	service  *Service
+	conn     *fakeConn
+	provider *fakeStoreProvider
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	ctx := context.Background()
+
+	projects := projectrepo.NewRedisProjectRepository(redisClient)
+	require.NoError(t, projects.SaveProject(ctx, &commonmodels.Project{ID: "project", Slug: "slug", OwnerName: "owner"}))
+	require.NoError(t, projects.SaveProject(ctx, &commonmodels.Project{ID: "other", Slug: "other", OwnerName: "owner"}))
+	processors := processorrepo.NewRedisProcessorRepo(redisClient)
+	for _, p := range []*models.Processor{
+		{ID: "p1", ProjectID: "project", Version: 1, VersionState: int32(processorprotos.ProcessorVersionState_ACTIVE)},
+		{ID: "p2", ProjectID: "project", Version: 2, VersionState: int32(processorprotos.ProcessorVersionState_PENDING)},
+		{ID: "p3", ProjectID: "other", Version: 1, VersionState: int32(processorprotos.ProcessorVersionState_PENDING)},
+	} {
+		require.NoError(t, processors.SaveProcessor(ctx, p))
+	}
+
+	conn := &fakeConn{results: make(map[string]*fakeRows)}
+	provider := &fakeStoreProvider{store: &fakeStore{
+		Store: chstimeseries.NewStore(chx.New(conn, chx.WithTableNamePrefix("p1_")), chstimeseries.Option{}, nil),
+		meta: &fakeStoreMeta{metas: map[timeseries.MetaType]map[string]timeseries.Meta{
+			timeseries.MetaTypeGauge: {
+				"balance": newMeta("balance", timeseries.MetaTypeGauge, "token"),
+			},
+			timeseries.MetaTypeCounter: {
+				"transfers": newMeta("transfers", timeseries.MetaTypeCounter),
+			},
+		}},
+	}}
+	return &testEnv{
+		service:  NewService(project.NewProjectService(projects), processors, provider, DefaultConfig()),
+		conn:     conn,
+		provider: provider,
+	}
+}
+
+func Test_resolveProject(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	s := env.service
+
+	id, err := s.resolveProject(ctx, "", "owner", "slug")
+	require.NoError(t, err)
+	assert.Equal(t, "project", id)
+	id, err = s.resolveProject(ctx, "project", "owner", "slug")
+	require.NoError(t, err)
+	assert.Equal(t, "project", id)
+	id, err = s.resolveProject(ctx, "other", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "other", id)
+
+	_, err = s.resolveProject(ctx, "other", "owner", "slug")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.resolveProject(ctx, "unknown", "", "")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.resolveProject(ctx, "", "owner", "")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	processor, err := s.resolveProcessor(ctx, "project", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "p1", processor.ID)
+	processor, err = s.resolveProcessor(ctx, "project", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "p2", processor.ID)
+	// no active version
+	processor, err = s.resolveProcessor(ctx, "other", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "p3", processor.ID)
+	_, err = s.resolveProcessor(ctx, "project", 3)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_GetMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	lastSeen := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	env.conn.results["`p1_gauge_balance`"] = &fakeRows{
+		columns: []string{"last_seen", "chain", "chain_count", "address", "address_count", "token", "token_count"},
+		data: [][]any{{
+			lastSeen,
+			[]string{"137", "1"}, uint64(2),
+			[]string{"0xabc"}, uint64(1),
+			[]string{"USDT", "USDC"}, uint64(5),
+		}},
+	}
+
+	resp, err := env.service.GetMetrics(context.Background(), &protos.GetMetricsRequest{
+		ProjectId:        "project",
+		LabelLimit:       2,
+		LabelSearchQuery: "us",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Metrics, 2)
+	assert.Equal(t, []string{"p1"}, env.provider.processors)
+
+	balance := resp.Metrics[0]
+	assert.Equal(t, "balance", balance.Name)
+	assert.Equal(t, "project", balance.ProjectId)
+	assert.Equal(t, []string{"1", "137"}, balance.ChainId)
+	assert.Equal(t, []string{"0xabc"}, balance.ContractAddress)
+	assert.Empty(t, balance.ContractName)
+	assert.Equal(t, []string{"USDC", "USDT"}, balance.Labels["token"].Values)
+	assert.Equal(t, uint64(5), balance.Labels["token"].TotalCount)
+	assert.Equal(t, uint64(2), balance.Labels["chain"].TotalCount)
+	assert.Equal(t, "gauge", balance.Metadata.Type)
+	assert.Equal(t, lastSeen.Unix(), balance.Metadata.LastSeen)
+
+	// the empty result of the counter
+	transfers := resp.Metrics[1]
+	assert.Equal(t, "transfers", transfers.Name)
+	assert.Equal(t, "counter", transfers.Metadata.Type)
+	assert.Equal(t, int64(0), transfers.Metadata.LastSeen)
+	assert.Len(t, transfers.Labels, 2)
+
+	query := env.conn.queries[0]
+	assert.Contains(t, query.sql, "groupUniqArrayIf(2)(toString(`token`), positionCaseInsensitive(toString(`token`), ?) > 0)")
+	assert.Contains(t, query.sql, "uniqExactIf(toString(`meta.chain`), positionCaseInsensitive(toString(`meta.chain`), ?) > 0)")
+	assert.Len(t, query.args, 6)
+	assert.Equal(t, "us", query.args[0])
+
+	resp, err = env.service.GetMetrics(context.Background(), &protos.GetMetricsRequest{
+		ProjectId: "project",
+		Name:      "transfers",
+		Version:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Metrics, 1)
+	assert.Equal(t, "transfers", resp.Metrics[0].Name)
+	assert.Equal(t, []string{"p1", "p2"}, env.provider.processors)
+	query = env.conn.lastQuery()
+	assert.Contains(t, query.sql, "groupUniqArrayIf(100)(toString(`meta.chain`), 1)")
+	assert.Empty(t, query.args)
+
+	_, err = env.service.GetMetrics(context.Background(), &protos.GetMetricsRequest{ProjectId: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_QueryRange(t *testing.T) {
+	env := newTestEnv(t)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	env.conn.results["sum(value_"] = &fakeRows{
+		columns: []string{"timestamp", "meta.chain", "agg"},
+		data: [][]any{
+			{t0, "1", float64(10)},
+			{t0.Add(time.Hour), "1", float64(20)},
+			{t0, "137", float64(30)},
+			{t0.Add(time.Hour), "137", float64(40)},
+			{t0, "56", float64(5)},
+		},
+	}
+
+	resp, err := env.service.QueryRange(context.Background(), &protos.QueryRangeRequest{
+		ProjectOwner: "owner",
+		ProjectSlug:  "slug",
+		Queries: []*commonprotos.Query{
+			{
+				Query:     "balance",
+				Alias:     "total",
+				Id:        "a",
+				Color:     "red",
+				Aggregate: &commonprotos.Aggregate{Op: commonprotos.Aggregate_SUM, Grouping: []string{"chain"}},
+				LabelSelector: map[string]string{
+					"token": "USDT",
+				},
+			},
+			{Query: "balance", Id: "b", Aggregate: &commonprotos.Aggregate{Op: commonprotos.Aggregate_SUM, Grouping: []string{"unknown"}}},
+			{Query: "unknown", Id: "c"},
+			{Query: "balance", Id: "d", Disabled: true},
+		},
+		Formulas: []*commonprotos.Formula{
+			{Expression: "a*2", Id: "e"},
+		},
+		SamplesLimit:  2,
+		SamplesOffset: 0,
+		TimeRange: &commonprotos.TimeRangeLite{
+			Start: "1735689600",
+			End:   "1735696800",
+			Step:  3600,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 4)
+
+	result := resp.Results[0]
+	assert.Equal(t, "total", result.Alias)
+	assert.Equal(t, "a", result.Id)
+	assert.Equal(t, "red", result.Color)
+	assert.NotNil(t, result.ComputeStats)
+	matrix := result.GetMatrix()
+	require.NotNil(t, matrix)
+	assert.Equal(t, int32(3), matrix.TotalSamples)
+	require.Len(t, matrix.Samples, 2)
+	// ordered by the last value
+	assert.Equal(t, "137", matrix.Samples[0].Metric.Labels["meta.chain"])
+	assert.Equal(t, "1", matrix.Samples[1].Metric.Labels["meta.chain"])
+	assert.Equal(t, "sum by (chain) (balance)", matrix.Samples[0].Metric.DisplayName)
+	values := make(map[int64]float64)
+	for _, v := range matrix.Samples[0].Values {
+		values[v.Timestamp] = v.Value
+	}
+	assert.Equal(t, float64(30), values[t0.Unix()])
+	assert.Equal(t, float64(40), values[t0.Add(time.Hour).Unix()])
+
+	sql := env.conn.queries[0].sql
+	assert.Contains(t, sql, "`p1_gauge_balance`")
+	assert.Contains(t, sql, "`token`=CAST('USDT', 'String')")
+	assert.Contains(t, sql, "GROUP BY `meta.chain`,")
+
+	assert.Equal(t, "label unknown not found in metric balance", resp.Results[1].GetError())
+	assert.Equal(t, "metric unknown not found", resp.Results[2].GetError())
+	assert.Equal(t, "e", resp.Results[3].Id)
+	assert.Equal(t, errFormulaNotSupported.Error(), resp.Results[3].GetError())
+	// the failed queries do not reach clickhouse
+	assert.Len(t, env.conn.queries, 1)
+
+	// the page after the limit
+	resp, err = env.service.QueryRange(context.Background(), &protos.QueryRangeRequest{
+		ProjectId: "project",
+		Queries: []*commonprotos.Query{
+			{Query: "balance", Aggregate: &commonprotos.Aggregate{Op: commonprotos.Aggregate_SUM, Grouping: []string{"chain"}}},
+		},
+		SamplesLimit:  2,
+		SamplesOffset: 2,
+		TimeRange:     &commonprotos.TimeRangeLite{Start: "1735689600", End: "1735696800", Step: 3600},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results[0].GetMatrix().Samples, 1)
+	assert.Equal(t, "56", resp.Results[0].GetMatrix().Samples[0].Metric.Labels["meta.chain"])
+
+	_, err = env.service.QueryRange(context.Background(), &protos.QueryRangeRequest{
+		ProjectId:    "other",
+		ProjectOwner: "owner",
+		ProjectSlug:  "slug",
+		TimeRange:    &commonprotos.TimeRangeLite{Start: "1735689600", End: "1735696800", Step: 3600},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = env.service.QueryRange(context.Background(), &protos.QueryRangeRequest{ProjectId: "project"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_Query(t *testing.T) {
+	env := newTestEnv(t)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	env.conn.results["last_value(value_"] = &fakeRows{
+		columns: []string{"timestamp", "meta.chain", "agg"},
+		data: [][]any{
+			{t0.Add(-2 * time.Minute), "1", float64(10)},
+			{t0.Add(-time.Minute), "1", float64(15)},
+			{t0.Add(-time.Minute), "137", float64(7)},
+		},
+	}
+
+	resp, err := env.service.Query(context.Background(), &protos.QueryValueRequest{
+		ProjectOwner: "owner",
+		ProjectSlug:  "slug",
+		Queries: []*commonprotos.Query{
+			{Query: "transfers", Alias: "transfers", Id: "a"},
+		},
+		Formulas: []*commonprotos.Formula{{Expression: "a", Id: "b", Disabled: true}},
+		Time:     "1735689600",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	result := resp.Results[0]
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "transfers", result.Alias)
+	require.Len(t, result.Sample, 2)
+	assert.Equal(t, "1", result.Sample[0].Metric.Labels["meta.chain"])
+	require.Len(t, result.Sample[0].Values, 1)
+	assert.Equal(t, float64(15), result.Sample[0].Values[0].Value)
+	assert.Equal(t, float64(7), result.Sample[1].Values[0].Value)
+
+	// the window ends at the requested time
+	sql := env.conn.lastQuery().sql
+	assert.Contains(t, sql, "`p1_counter_transfers`")
+	assert.Contains(t, sql, "timestamp<=toDateTime64('2025-01-01 00:00:00', 6, 'UTC')")
+	assert.Contains(t, sql, "timestamp>=toDateTime64('2024-12-31 23:00:00', 6, 'UTC')")
+
+	_, err = env.service.Query(context.Background(), &protos.QueryValueRequest{
+		ProjectOwner: "owner",
+		ProjectSlug:  "slug",
+		Timezone:     "Unknown/Zone",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
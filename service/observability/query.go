@@ -0,0 +1,265 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sentioxyz/sentio-core/driver/timeseries"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	"sentioxyz/sentio-core/service/common/timerange"
+	metrics "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics"
+	"sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/selector"
+	"sentioxyz/sentio-core/service/common/timeseries/compatible"
+	"sentioxyz/sentio-core/service/common/timeseries/converter"
+	"sentioxyz/sentio-core/service/observability/protos"
+)
+
+var errFormulaNotSupported = fmt.Errorf("formula is not supported")
+
+// findMetric looks up the meta of the metric by name, gauges take precedence over counters
+func findMetric(store Store, name string) (timeseries.Meta, error) {
+	for _, metaType := range metricTypes {
+		if meta, has := store.Meta().Meta(metaType, name); has {
+			return meta, nil
+		}
+	}
+	return timeseries.Meta{}, fmt.Errorf("metric %s not found", name)
+}
+
+// groupFields converts the grouping labels of the query to the label fields of the metric,
+// only the labels of the series are allowed because the groups are selected from the function results
+func groupFields(meta timeseries.Meta, grouping []string) ([]string, error) {
+	labels := map[string]bool{meta.GetChainIDField().Name: true}
+	for _, field := range meta.GetFieldsByRole(timeseries.FieldRoleSeriesLabel) {
+		labels[field.Name] = true
+	}
+	var groups []string
+	for _, group := range grouping {
+		name := group
+		if transformed, has := compatible.FieldNameTransform[group]; has {
+			name = transformed
+		}
+		if !labels[name] {
+			return nil, fmt.Errorf("label %s not found in metric %s", group, meta.Name)
+		}
+		groups = append(groups, timeseries.EscapeMetricsFieldName(name))
+	}
+	return groups, nil
+}
+
+// queryMatrix executes the query through the metrics adaptor and converts the result into time series
+func queryMatrix(
+	ctx context.Context,
+	store Store,
+	query *commonprotos.Query,
+	timeRange *timerange.TimeRange,
+) (converter.TimeSeriesMatrix, error) {
+	meta, err := findMetric(store, query.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+	params := metrics.NewParameters().
+		SetName(query.GetQuery()).
+		SetAlias(query.GetAlias()).
+		SetId(query.GetId()).
+		SetTimeRange(timeRange)
+	if len(query.GetLabelSelector()) > 0 {
+		params.SetLabelSelector(selector.NewSelector(meta, query.GetLabelSelector()))
+	}
+	if aggregate := query.GetAggregate(); aggregate != nil {
+		groups, err := groupFields(meta, aggregate.GetGrouping())
+		if err != nil {
+			return nil, err
+		}
+		params.SetOperator(aggregate.GetOp()).SetGroups(groups)
+	}
+
+	function, err := metrics.NewFunctionAdaptor(meta, store, query.GetFunctions(), params)
+	if err != nil {
+		return nil, err
+	}
+	queryRange := metrics.NewQueryRangeAdaptor(function, params)
+	sql, err := queryRange.Build()
+	if err != nil {
+		return nil, err
+	}
+	m, err := queryRange.Scan(ctx, store.Client().Query, sql)
+	if err != nil {
+		return nil, err
+	}
+	result := converter.NewTimeSeriesMatrix(ctx, converter.Query{Query: query}, timeRange)
+	result.Apply(m, true)
+	return result, nil
+}
+
+// pageSamples fills the samples and returns the requested page of them ordered by the last value descending
+func pageSamples(samples map[string]converter.Sample, limit, offset int32) []converter.Sample {
+	sorted := make([]converter.Sample, 0, len(samples))
+	for _, sample := range samples {
+		sample.WithFill()
+		sorted = append(sorted, sample)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if wi, wj := sorted[i].Weight(), sorted[j].Weight(); wi != wj {
+			return wi > wj
+		}
+		return sorted[i].Hash() < sorted[j].Hash()
+	})
+	if offset < 0 || int(offset) >= len(sorted) {
+		return nil
+	}
+	sorted = sorted[offset:]
+	if limit > 0 && int(limit) < len(sorted) {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+func (s *Service) samplesLimit(limit int32) int32 {
+	if limit > 0 {
+		return limit
+	}
+	return s.config.SamplesLimit
+}
+
+func (s *Service) QueryRange(ctx context.Context, req *protos.QueryRangeRequest) (*protos.MetricsQueryResponse, error) {
+	projectID, err := s.resolveProject(ctx, req.GetProjectId(), req.GetProjectOwner(), req.GetProjectSlug())
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.getStore(ctx, projectID, req.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	timeRange, err := timerange.NewTimeRangeFromLite(ctx, req.GetTimeRange())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &protos.MetricsQueryResponse{}
+	for _, query := range req.GetQueries() {
+		if query.GetDisabled() {
+			continue
+		}
+		start := time.Now()
+		result := &protos.MetricsQueryResponse_Result{
+			Alias: query.GetAlias(),
+			Id:    query.GetId(),
+			Color: query.GetColor(),
+		}
+		m, err := queryMatrix(ctx, store, query, timeRange.Copy())
+		if err != nil {
+			result.MetricsQueryResponseType = &protos.MetricsQueryResponse_Result_Error{Error: err.Error()}
+		} else {
+			matrix := &protos.MetricsQueryResponse_Matrix{TotalSamples: int32(len(m.GetSamples()))}
+			for _, sample := range pageSamples(m.GetSamples(), s.samplesLimit(req.GetSamplesLimit()), req.GetSamplesOffset()) {
+				matrix.Samples = append(matrix.Samples, sample.ToO11yProto())
+			}
+			result.MetricsQueryResponseType = &protos.MetricsQueryResponse_Result_Matrix{Matrix: matrix}
+		}
+		result.ComputeStats = &commonprotos.ComputeStats{
+			ComputedAt:    timestamppb.New(start),
+			ComputeCostMs: time.Since(start).Milliseconds(),
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	for _, formula := range req.GetFormulas() {
+		if formula.GetDisabled() {
+			continue
+		}
+		resp.Results = append(resp.Results, &protos.MetricsQueryResponse_Result{
+			Alias:                    formula.GetAlias(),
+			Id:                       formula.GetId(),
+			Color:                    formula.GetColor(),
+			MetricsQueryResponseType: &protos.MetricsQueryResponse_Result_Error{Error: errFormulaNotSupported.Error()},
+		})
+	}
+	return resp, nil
+}
+
+// instantTimeRange returns the window of the instant query ending at the requested time
+func (s *Service) instantTimeRange(req *protos.QueryValueRequest) (*timerange.TimeRange, error) {
+	tz := time.UTC
+	if req.GetTimezone() != "" {
+		var err error
+		if tz, err = time.LoadLocation(req.GetTimezone()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid timezone %s: %v", req.GetTimezone(), err)
+		}
+	}
+	end := time.Now().In(tz)
+	if req.GetTime() != "" {
+		var err error
+		if end, err = timerange.ResolveTimeStrWithAlign(req.GetTime(), false, tz); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid time %s: %v", req.GetTime(), err)
+		}
+	}
+	start := end.Add(-s.config.InstantLookback)
+	step := timerange.GetEventMinStep(start, end)
+	return &timerange.TimeRange{
+		Start:      start,
+		End:        end,
+		Step:       step,
+		SampleRate: step,
+		Timezone:   tz,
+		RangeMode:  timerange.ClosedRange,
+	}, nil
+}
+
+func (s *Service) Query(ctx context.Context, req *protos.QueryValueRequest) (*protos.QueryValueResponse, error) {
+	projectID, err := s.resolveProject(ctx, "", req.GetProjectOwner(), req.GetProjectSlug())
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.getStore(ctx, projectID, req.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	timeRange, err := s.instantTimeRange(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &protos.QueryValueResponse{}
+	for _, query := range req.GetQueries() {
+		if query.GetDisabled() {
+			continue
+		}
+		result := &protos.QueryValueResponse_Result{
+			Alias: query.GetAlias(),
+			Id:    query.GetId(),
+			Color: query.GetColor(),
+		}
+		m, err := queryMatrix(ctx, store, query, timeRange.Copy())
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			for _, sample := range pageSamples(m.GetSamples(), s.samplesLimit(req.GetSamplesLimit()), req.GetSamplesOffset()) {
+				value := sample.ToO11yProto()
+				// only the last value of the window is the value at the requested time
+				if n := len(value.Values); n > 0 {
+					value.Values = value.Values[n-1:]
+				}
+				result.Sample = append(result.Sample, value)
+			}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	for _, formula := range req.GetFormulas() {
+		if formula.GetDisabled() {
+			continue
+		}
+		resp.Results = append(resp.Results, &protos.QueryValueResponse_Result{
+			Alias: formula.GetAlias(),
+			Id:    formula.GetId(),
+			Color: formula.GetColor(),
+			Error: errFormulaNotSupported.Error(),
+		})
+	}
+	return resp, nil
+}
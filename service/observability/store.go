@@ -0,0 +1,89 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"sentioxyz/sentio-core/common/cache"
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/driver/timeseries/cacheablelib"
+	"sentioxyz/sentio-core/driver/timeseries/clickhouse"
+	prebuilt "sentioxyz/sentio-core/service/common/timeseries/adaptor_metrics/prebuilt_function"
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
+// Store is the time series store of a processor, the queries built by the metrics adaptor are executed
+// through its client
+type Store interface {
+	prebuilt.Store
+	Client() chx.Conn
+}
+
+// StoreProvider returns the time series store of a processor
+type StoreProvider interface {
+	GetStore(ctx context.Context, processor *models.Processor) (Store, error)
+}
+
+type clickhouseStoreProvider struct {
+	config  Config
+	conn    ckhmanager.Conn
+	manager ckhmanager.Manager
+	stores  *cache.Cache[*clickhouse.Store]
+}
+
+// NewClickhouseStoreProvider creates a StoreProvider reading the time series tables written by the drivers,
+// the metas of the stores are cached and reloaded in background until ctx is done
+func NewClickhouseStoreProvider(ctx context.Context, config Config) (StoreProvider, error) {
+	p := &clickhouseStoreProvider{config: config}
+	switch {
+	case config.ClickhouseConfig != "":
+		p.manager = ckhmanager.LoadManager(config.ClickhouseConfig)
+	case config.ClickhouseDSN != "":
+		p.conn = ckhmanager.NewOrGetConn(config.ClickhouseDSN)
+	default:
+		return nil, fmt.Errorf("either clickhouse_config or clickhouse_dsn is required")
+	}
+	cacheConfig := cache.DefaultConfig()
+	cacheConfig.MaxSize = config.StoreCacheSize
+	p.stores = cache.New[*clickhouse.Store](ctx, cacheConfig)
+	return p, nil
+}
+
+func (p *clickhouseStoreProvider) getConn(processor *models.Processor) (ckhmanager.Conn, error) {
+	if p.manager == nil {
+		return p.conn, nil
+	}
+	shard := p.manager.GetShardByIndex(ckhmanager.ShardingIndex(processor.ClickhouseShardingIndex))
+	if shard == nil {
+		return nil, fmt.Errorf("clickhouse sharding %d of processor %s not found",
+			processor.ClickhouseShardingIndex, processor.ID)
+	}
+	return shard.GetConn(ckhmanager.WithCategory(ckhmanager.SentioCategory))
+}
+
+func (p *clickhouseStoreProvider) GetStore(_ context.Context, processor *models.Processor) (Store, error) {
+	conn, err := p.getConn(processor)
+	if err != nil {
+		return nil, err
+	}
+	item, err := cacheablelib.NewCacheableMetaWithTTL(
+		processor.ID,
+		int(processor.Version),
+		0,
+		processor.TablePattern,
+		processor.ProjectID,
+		conn,
+		p.config.StoreCacheTTL,
+		p.config.MetaRefreshInterval,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	store, err := p.stores.Get(item)
+	if err != nil {
+		return nil, fmt.Errorf("load time series meta of processor %s failed: %w", processor.ID, err)
+	}
+	return store, nil
+}
@@ -110,76 +110,15 @@ func (d *DockerSwarmManager) buildDriverServiceSpec(processor *models.Processor,
 		"sentio.service.type": "driver",
 	}
 
-	processorServiceName := d.makeProcessorServiceName(processor)
-
-	// Construct command line arguments
-	args := []string{
-		fmt.Sprintf("-processor-service=%s", d.config.ProcessorService),
-		fmt.Sprintf("-webhook-service=%s", ""),
-		fmt.Sprintf("-billing-server=%s", d.config.BillingServer),
-		// db-registry-service is a logically separate service from
-		// billing even though sentio-node currently serves both on the
-		// same port. Declared explicitly so deployments that split the
-		// two don't have to overload -billing-server.
-		fmt.Sprintf("-db-registry-service=%s", d.config.DBRegistryService),
-		fmt.Sprintf("-rpcnode-service=%s", ""),
-		// Swarm-managed services talk plaintext gRPC in-cluster; the driver
-		// binary's enable_tls flag defaults to true (hosted mTLS), which would
-		// make it attempt TLS handshakes against these plaintext endpoints.
-		"-enable_tls=false",
-		fmt.Sprintf("-pubsub-topic=%s", ""),
-		fmt.Sprintf("-timescale-db-config=%s", ""),
-		// Point to the processor service on the internal network
-		fmt.Sprintf("-external-processor=%s:9999", processorServiceName),
-		fmt.Sprintf("-processor-id=%s", processor.ID),
-		fmt.Sprintf("-redis=%s", d.config.Redis.Address),
-		fmt.Sprintf("-cache-dir=%s", CacheDirMountPath),
-		fmt.Sprintf("-chains-config=%s", ChainConfigMountPath),
-		fmt.Sprintf("-clickhouse-config-path=%s", ClickhouseConfigMountPath),
-		// Replica index identifies this driver instance within a processor
-		// deployment. sentio-network uses it to derive the on-chain
-		// database id as "${processorID}_${replicaIndex}". Current testnet
-		// runs a single replica; this leaves room for future scale-out
-		// where each replica would be launched with a distinct index.
-		fmt.Sprintf("-processor-replica=%d", 0),
-	}
-
-	if d.config.Driver.LogFormat != "" {
-		args = append(args, fmt.Sprintf("-log-format=%s", d.config.Driver.LogFormat))
-	}
-	if d.config.Driver.RealtimeProcessingOwnerWhitelist != "" {
-		args = append(args, fmt.Sprintf("-realtime-processing-owner-whitelist=%s", d.config.Driver.RealtimeProcessingOwnerWhitelist))
-	}
-	if d.config.Driver.AllowSingleBlockBackfillOwnerWhitelist != "" {
-		args = append(args, fmt.Sprintf("-allow-single-block-backfill-owner-whitelist=%s", d.config.Driver.AllowSingleBlockBackfillOwnerWhitelist))
-	}
-	if d.config.Driver.Verbose != "" {
-		args = append(args, fmt.Sprintf("-verbose=%s", d.config.Driver.Verbose))
-	}
-	if d.config.Driver.EntityStoreCacheSize > 0 {
-		args = append(args, fmt.Sprintf("-entity-store-cache-size=%d", d.config.Driver.EntityStoreCacheSize))
-	}
-	if d.config.Driver.ProcessorUseChainServer {
-		args = append(args, "-use-chain-server=true")
-	}
-	if d.config.Driver.SamplingInterval > 0 {
-		args = append(args, fmt.Sprintf("-sampling-interval=%d", d.config.Driver.SamplingInterval))
-	}
-	if d.config.Clickhouse.ReadTimeout > 0 {
-		args = append(args, fmt.Sprintf("-clickhouse-read-timeout=%d", d.config.Clickhouse.ReadTimeout))
-	}
-	if d.config.Clickhouse.DialTimeout > 0 {
-		args = append(args, fmt.Sprintf("-clickhouse-dial-timeout=%d", d.config.Clickhouse.DialTimeout))
-	}
-	if d.config.Clickhouse.MaxIdleConns > 0 {
-		args = append(args, fmt.Sprintf("-clickhouse-max-idle-conns=%d", d.config.Clickhouse.MaxIdleConns))
-	}
-	if d.config.Clickhouse.MaxOpenConns > 0 {
-		args = append(args, fmt.Sprintf("-clickhouse-max-open-conns=%d", d.config.Clickhouse.MaxOpenConns))
-	}
-	if d.config.Redis.PoolSize > 0 {
-		args = append(args, fmt.Sprintf("-redis-pool=%d", d.config.Redis.PoolSize))
-	}
+	// Point to the processor service on the internal network
+	args := buildDriverArgs(
+		d.config,
+		processor,
+		fmt.Sprintf("%s:9999", d.makeProcessorServiceName(processor)),
+		CacheDirMountPath,
+		ChainConfigMountPath,
+		ClickhouseConfigMountPath,
+	)
 
 	replicas := uint64(1)
 	if processor.Pause {
@@ -213,14 +152,6 @@ func (d *DockerSwarmManager) buildDriverServiceSpec(processor *models.Processor,
 		})
 	}
 
-	var envs []string
-	if d.config.HousegateDSN != "" {
-		envs = append(envs, "SENTIO_NETWORK_HOUSEGATE_DSN="+d.config.HousegateDSN)
-	}
-	if d.config.HousegateDB != "" {
-		envs = append(envs, "SENTIO_NETWORK_HOUSEGATE_DB="+d.config.HousegateDB)
-	}
-
 	return swarm.ServiceSpec{
 		Annotations: swarm.Annotations{
 			Name:   name,
@@ -232,7 +163,7 @@ func (d *DockerSwarmManager) buildDriverServiceSpec(processor *models.Processor,
 				Labels:  labels,
 				Command: []string{"/app/driver/cmd/cmd_/cmd"},
 				Args:    args,
-				Env:     envs,
+				Env:     buildDriverEnvs(d.config, processor),
 				Mounts:  mounts,
 				Hosts:   []string{"host-gateway host.docker.internal"},
 			},
@@ -409,6 +340,26 @@ fi
 `
 
 func (d *DockerSwarmManager) generateProcessorScript(processor *models.Processor) (string, error) {
+	script, err := renderProcessorScript(d.config, processor)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to temp file
+	tmpDir := os.TempDir()
+	fileName := fmt.Sprintf("start-processor-%s.sh", processor.ID)
+	filePath := path.Join(tmpDir, fileName)
+
+	err = os.WriteFile(filePath, script, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// renderProcessorScript renders the start script of the processor container, the paths are the mount paths
+func renderProcessorScript(config DriverConfig, processor *models.Processor) ([]byte, error) {
 	data := struct {
 		ProcessorService     string
 		CacheDirMountPath    string
@@ -417,7 +368,7 @@ func (d *DockerSwarmManager) generateProcessorScript(processor *models.Processor
 		ClickhouseConfigPath string
 		CacheDir             string
 	}{
-		ProcessorService:     d.config.ProcessorService,
+		ProcessorService:     config.ProcessorService,
 		CacheDirMountPath:    CacheDirMountPath,
 		ChainConfigMountPath: ChainConfigMountPath,
 		ProcessorID:          processor.ID,
@@ -427,25 +378,14 @@ func (d *DockerSwarmManager) generateProcessorScript(processor *models.Processor
 
 	tmpl, err := template.New("processor-script").Parse(processorScriptTemplate)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse processor script template: %w", err)
+		return nil, fmt.Errorf("failed to parse processor script template: %w", err)
 	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute processor script template: %w", err)
-	}
-
-	// Write to temp file
-	tmpDir := os.TempDir()
-	fileName := fmt.Sprintf("start-processor-%s.sh", processor.ID)
-	filePath := path.Join(tmpDir, fileName)
-
-	err = os.WriteFile(filePath, buf.Bytes(), 0755)
-	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to execute processor script template: %w", err)
 	}
-
-	return filePath, nil
+	return buf.Bytes(), nil
 }
 
 // StartJob starts a driver job for the given processor using Docker Swarm
@@ -897,32 +837,45 @@ func (d *DockerSwarmManager) GetLogs(ctx context.Context, processor *models.Proc
 				break
 			}
 
-			line := string(data)
-			// Docker timestamps are at the beginning of each line if Timestamps: true
-			// Format is like "2023-10-27T12:00:00.000000000Z message"
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) < 2 {
-				continue
+			if l, ok := parseLogLine(string(data), logType, query); ok {
+				allLogs = append(allLogs, l)
 			}
-			tsStr := parts[0]
-			msg := parts[1]
+		}
+	}
 
-			ts, err := time.Parse(time.RFC3339Nano, tsStr)
-			if err != nil {
-				continue
-			}
+	allLogs, nextUntil := sortAndLimitLogs(allLogs, limit)
+	return allLogs, nextUntil, nil
+}
 
-			if len(query) > 0 && !strings.Contains(strings.ToLower(msg), strings.ToLower(query)) {
-				continue
-			}
-			allLogs = append(allLogs, logLine{
-				msg:     strings.TrimSpace(msg),
-				ts:      ts,
-				logType: logType,
-			})
-		}
+// parseLogLine parses a log line prefixed with the timestamp, the line is dropped if it
+// cannot be parsed or does not contain the query
+func parseLogLine(line, logType, query string) (logLine, bool) {
+	// Docker timestamps are at the beginning of each line if Timestamps: true
+	// Format is like "2023-10-27T12:00:00.000000000Z message"
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) < 2 {
+		return logLine{}, false
+	}
+	tsStr := parts[0]
+	msg := parts[1]
+
+	ts, err := time.Parse(time.RFC3339Nano, tsStr)
+	if err != nil {
+		return logLine{}, false
+	}
+
+	if len(query) > 0 && !strings.Contains(strings.ToLower(msg), strings.ToLower(query)) {
+		return logLine{}, false
 	}
+	return logLine{
+		msg:     strings.TrimSpace(msg),
+		ts:      ts,
+		logType: logType,
+	}, true
+}
 
+// sortAndLimitLogs keeps the newest logs and returns the timestamp for fetching the next page
+func sortAndLimitLogs(allLogs []Log, limit int32) ([]Log, string) {
 	// Sort logs by timestamp descending (newest to oldest)
 	sort.Slice(allLogs, func(i, j int) bool {
 		return allLogs[i].Timestamp().After(allLogs[j].Timestamp())
@@ -937,8 +890,7 @@ func (d *DockerSwarmManager) GetLogs(ctx context.Context, processor *models.Proc
 	if len(allLogs) > 0 {
 		nextUntil = allLogs[len(allLogs)-1].Timestamp().Format(time.RFC3339Nano)
 	}
-
-	return allLogs, nextUntil, nil
+	return allLogs, nextUntil
 }
 
 type logLine struct {
@@ -1,5 +1,12 @@
 package driverjob
 
+import (
+	"fmt"
+	"time"
+
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
 // ClickhouseConfig holds configuration for Clickhouse
 type ClickhouseConfig struct {
 	ReadTimeout  int    `yaml:"read_timeout"`
@@ -46,9 +53,163 @@ type DriverConfig struct {
 	HousegateDSN string `yaml:"housegate_dsn,omitempty"`
 	HousegateDB  string `yaml:"housegate_db,omitempty"`
 
+	// Manager selects the DriverJobManager implementation, "docker_swarm" (default), "local" or "kubernetes"
+	Manager string `yaml:"manager,omitempty"`
+	// Local is used by the local process manager
+	Local LocalProcessConfig `yaml:"local,omitempty"`
+	// Kubernetes is used by the kubernetes manager
+	Kubernetes KubernetesConfig `yaml:"kubernetes,omitempty"`
+
 	// Specific Configs
 	Driver DriverSpecificConfig `yaml:"driver"`
 
 	Clickhouse ClickhouseConfig `yaml:"clickhouse"`
 	Redis      RedisConfig      `yaml:"redis"`
 }
+
+// LocalProcessConfig holds configuration for running the driver and processor as local processes
+type LocalProcessConfig struct {
+	// DriverBinary is the path of the driver binary, also used to prepare the processor environment
+	DriverBinary string `yaml:"driver_binary"`
+	// WorkDir is the working directory of the driver and processor processes, the runfiles
+	// directory of the driver binary is usually needed here
+	WorkDir string `yaml:"work_dir,omitempty"`
+	// DataDir keeps the log files and the start scripts of each processor
+	DataDir string `yaml:"data_dir"`
+	// RestartBackoffMin and RestartBackoffMax bound the delay before restarting a crashed process,
+	// the delay doubles on every consecutive crash
+	RestartBackoffMin time.Duration `yaml:"restart_backoff_min,omitempty"`
+	RestartBackoffMax time.Duration `yaml:"restart_backoff_max,omitempty"`
+	// StopTimeout is how long a process has to exit after SIGTERM before it is killed
+	StopTimeout time.Duration `yaml:"stop_timeout,omitempty"`
+}
+
+// KubernetesConfig holds configuration for running the driver and processor as Kubernetes Deployments
+type KubernetesConfig struct {
+	// Clusters are keyed by the K8sClusterID of the processors
+	Clusters []KubernetesClusterConfig `yaml:"clusters"`
+	// ChainsConfigMap and ClickhouseConfigMap are the ConfigMaps mounted as the chains config and the
+	// clickhouse config, the file is the only key of the ConfigMap
+	ChainsConfigMap     string `yaml:"chains_config_map,omitempty"`
+	ClickhouseConfigMap string `yaml:"clickhouse_config_map,omitempty"`
+	ServiceAccount      string `yaml:"service_account,omitempty"`
+	// DriverResources and ProcessorResources are the resources of the driver and processor containers
+	DriverResources    ResourceConfig `yaml:"driver_resources,omitempty"`
+	ProcessorResources ResourceConfig `yaml:"processor_resources,omitempty"`
+	// ResyncPeriod is the resync period of the informers used by the reconciler
+	ResyncPeriod time.Duration `yaml:"resync_period,omitempty"`
+}
+
+// KubernetesClusterConfig holds configuration of a Kubernetes cluster
+type KubernetesClusterConfig struct {
+	ID int `yaml:"id"`
+	// Kubeconfig is the path of the kubeconfig file, the in-cluster config is used if empty
+	Kubeconfig string `yaml:"kubeconfig,omitempty"`
+	// Context is the context in the kubeconfig, the current context is used if empty
+	Context   string `yaml:"context,omitempty"`
+	Namespace string `yaml:"namespace"`
+}
+
+// ResourceConfig holds the resource requests and limits of a container, in Kubernetes quantities
+type ResourceConfig struct {
+	CPURequest    string `yaml:"cpu_request,omitempty"`
+	MemoryRequest string `yaml:"memory_request,omitempty"`
+	CPULimit      string `yaml:"cpu_limit,omitempty"`
+	MemoryLimit   string `yaml:"memory_limit,omitempty"`
+}
+
+// buildDriverArgs constructs the command line arguments of the driver, the paths are the ones
+// seen by the driver process
+func buildDriverArgs(
+	config DriverConfig,
+	processor *models.Processor,
+	externalProcessor string,
+	cacheDir string,
+	chainsConfig string,
+	clickhouseConfigPath string,
+) []string {
+	// Construct command line arguments
+	args := []string{
+		fmt.Sprintf("-processor-service=%s", config.ProcessorService),
+		fmt.Sprintf("-webhook-service=%s", ""),
+		fmt.Sprintf("-billing-server=%s", config.BillingServer),
+		// db-registry-service is a logically separate service from
+		// billing even though sentio-node currently serves both on the
+		// same port. Declared explicitly so deployments that split the
+		// two don't have to overload -billing-server.
+		fmt.Sprintf("-db-registry-service=%s", config.DBRegistryService),
+		fmt.Sprintf("-rpcnode-service=%s", ""),
+		// Swarm-managed services talk plaintext gRPC in-cluster; the driver
+		// binary's enable_tls flag defaults to true (hosted mTLS), which would
+		// make it attempt TLS handshakes against these plaintext endpoints.
+		"-enable_tls=false",
+		fmt.Sprintf("-pubsub-topic=%s", ""),
+		fmt.Sprintf("-timescale-db-config=%s", ""),
+		fmt.Sprintf("-external-processor=%s", externalProcessor),
+		fmt.Sprintf("-processor-id=%s", processor.ID),
+		fmt.Sprintf("-redis=%s", config.Redis.Address),
+		fmt.Sprintf("-cache-dir=%s", cacheDir),
+		fmt.Sprintf("-chains-config=%s", chainsConfig),
+		fmt.Sprintf("-clickhouse-config-path=%s", clickhouseConfigPath),
+		// Replica index identifies this driver instance within a processor
+		// deployment. sentio-network uses it to derive the on-chain
+		// database id as "${processorID}_${replicaIndex}". Current testnet
+		// runs a single replica; this leaves room for future scale-out
+		// where each replica would be launched with a distinct index.
+		fmt.Sprintf("-processor-replica=%d", 0),
+	}
+
+	if config.Driver.LogFormat != "" {
+		args = append(args, fmt.Sprintf("-log-format=%s", config.Driver.LogFormat))
+	}
+	if config.Driver.RealtimeProcessingOwnerWhitelist != "" {
+		args = append(args, fmt.Sprintf("-realtime-processing-owner-whitelist=%s", config.Driver.RealtimeProcessingOwnerWhitelist))
+	}
+	if config.Driver.AllowSingleBlockBackfillOwnerWhitelist != "" {
+		args = append(args, fmt.Sprintf("-allow-single-block-backfill-owner-whitelist=%s", config.Driver.AllowSingleBlockBackfillOwnerWhitelist))
+	}
+	if config.Driver.Verbose != "" {
+		args = append(args, fmt.Sprintf("-verbose=%s", config.Driver.Verbose))
+	}
+	if config.Driver.EntityStoreCacheSize > 0 {
+		args = append(args, fmt.Sprintf("-entity-store-cache-size=%d", config.Driver.EntityStoreCacheSize))
+	}
+	if config.Driver.ProcessorUseChainServer {
+		args = append(args, "-use-chain-server=true")
+	}
+	if config.Driver.SamplingInterval > 0 {
+		args = append(args, fmt.Sprintf("-sampling-interval=%d", config.Driver.SamplingInterval))
+	}
+	if config.Clickhouse.ReadTimeout > 0 {
+		args = append(args, fmt.Sprintf("-clickhouse-read-timeout=%d", config.Clickhouse.ReadTimeout))
+	}
+	if config.Clickhouse.DialTimeout > 0 {
+		args = append(args, fmt.Sprintf("-clickhouse-dial-timeout=%d", config.Clickhouse.DialTimeout))
+	}
+	if config.Clickhouse.MaxIdleConns > 0 {
+		args = append(args, fmt.Sprintf("-clickhouse-max-idle-conns=%d", config.Clickhouse.MaxIdleConns))
+	}
+	if config.Clickhouse.MaxOpenConns > 0 {
+		args = append(args, fmt.Sprintf("-clickhouse-max-open-conns=%d", config.Clickhouse.MaxOpenConns))
+	}
+	if config.Redis.PoolSize > 0 {
+		args = append(args, fmt.Sprintf("-redis-pool=%d", config.Redis.PoolSize))
+	}
+
+	return args
+}
+
+// buildDriverEnvs constructs the extra environment variables of the driver
+func buildDriverEnvs(config DriverConfig, processor *models.Processor) []string {
+	var envs []string
+	if len(processor.ShadowStopAtBlocks) > 0 {
+		envs = append(envs, models.ShadowStopAtBlocksEnv+"="+models.EncodeStopAtBlocks(processor.ShadowStopAtBlocks))
+	}
+	if config.HousegateDSN != "" {
+		envs = append(envs, "SENTIO_NETWORK_HOUSEGATE_DSN="+config.HousegateDSN)
+	}
+	if config.HousegateDB != "" {
+		envs = append(envs, "SENTIO_NETWORK_HOUSEGATE_DB="+config.HousegateDB)
+	}
+	return envs
+}
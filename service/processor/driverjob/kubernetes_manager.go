@@ -0,0 +1,664 @@
+package driverjob
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/service/processor/models"
+	"sentioxyz/sentio-core/service/processor/repository"
+)
+
+const (
+	labelOwnerID     = "sentio.processor.owner_id"
+	labelOwnerType   = "sentio.processor.owner_type"
+	labelServiceType = "sentio.service.type"
+
+	// annotationRestartedAt is the annotation used by `kubectl rollout restart`
+	annotationRestartedAt = "kubectl.kubernetes.io/restartedAt"
+
+	driverContainerName    = "driver"
+	processorContainerName = "processor"
+	processorScriptKey     = "start-processor.sh"
+	processorPort          = 9999
+)
+
+// KubernetesManager implements DriverJobManager for Kubernetes, every processor runs as a Deployment
+// with the driver and processor containers in the same pod. A reconciler started by StartManager
+// watches the Deployments and pods, keeps them in sync with the processor repository and reports
+// the failing pods into the chain states.
+type KubernetesManager struct {
+	config         DriverConfig
+	clusters       map[int]*kubernetesCluster
+	processorRepo  repository.ProcessorRepo
+	chainStateRepo repository.ChainStateRepo
+
+	driverResources    corev1.ResourceRequirements
+	processorResources corev1.ResourceRequirements
+}
+
+type kubernetesCluster struct {
+	id        int
+	namespace string
+	client    kubernetes.Interface
+}
+
+// NewKubernetesManager creates a new Kubernetes manager, a client is created for every configured cluster
+func NewKubernetesManager(
+	config DriverConfig,
+	processorRepo repository.ProcessorRepo,
+	chainStateRepo repository.ChainStateRepo,
+) (*KubernetesManager, error) {
+	clients := make(map[int]kubernetes.Interface)
+	for _, cluster := range config.Kubernetes.Clusters {
+		var restConfig *rest.Config
+		var err error
+		if cluster.Kubeconfig == "" {
+			restConfig, err = rest.InClusterConfig()
+		} else {
+			restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				&clientcmd.ClientConfigLoadingRules{ExplicitPath: cluster.Kubeconfig},
+				&clientcmd.ConfigOverrides{CurrentContext: cluster.Context},
+			).ClientConfig()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config of cluster %d: %w", cluster.ID, err)
+		}
+		if clients[cluster.ID], err = kubernetes.NewForConfig(restConfig); err != nil {
+			return nil, fmt.Errorf("failed to create client of cluster %d: %w", cluster.ID, err)
+		}
+	}
+	return newKubernetesManager(config, clients, processorRepo, chainStateRepo)
+}
+
+func newKubernetesManager(
+	config DriverConfig,
+	clients map[int]kubernetes.Interface,
+	processorRepo repository.ProcessorRepo,
+	chainStateRepo repository.ChainStateRepo,
+) (*KubernetesManager, error) {
+	if len(config.Kubernetes.Clusters) == 0 {
+		return nil, fmt.Errorf("no kubernetes cluster configured")
+	}
+	m := &KubernetesManager{
+		config:         config,
+		clusters:       make(map[int]*kubernetesCluster),
+		processorRepo:  processorRepo,
+		chainStateRepo: chainStateRepo,
+	}
+	for _, cluster := range config.Kubernetes.Clusters {
+		if _, has := m.clusters[cluster.ID]; has {
+			return nil, fmt.Errorf("duplicated kubernetes cluster %d", cluster.ID)
+		}
+		if cluster.Namespace == "" {
+			return nil, fmt.Errorf("namespace of kubernetes cluster %d cannot be empty", cluster.ID)
+		}
+		m.clusters[cluster.ID] = &kubernetesCluster{
+			id:        cluster.ID,
+			namespace: cluster.Namespace,
+			client:    clients[cluster.ID],
+		}
+	}
+	var err error
+	if m.driverResources, err = buildResourceRequirements(config.Kubernetes.DriverResources); err != nil {
+		return nil, fmt.Errorf("invalid driver resources: %w", err)
+	}
+	if m.processorResources, err = buildResourceRequirements(config.Kubernetes.ProcessorResources); err != nil {
+		return nil, fmt.Errorf("invalid processor resources: %w", err)
+	}
+	return m, nil
+}
+
+func buildResourceRequirements(config ResourceConfig) (corev1.ResourceRequirements, error) {
+	var requirements corev1.ResourceRequirements
+	for _, r := range []struct {
+		list  *corev1.ResourceList
+		name  corev1.ResourceName
+		value string
+	}{
+		{list: &requirements.Requests, name: corev1.ResourceCPU, value: config.CPURequest},
+		{list: &requirements.Requests, name: corev1.ResourceMemory, value: config.MemoryRequest},
+		{list: &requirements.Limits, name: corev1.ResourceCPU, value: config.CPULimit},
+		{list: &requirements.Limits, name: corev1.ResourceMemory, value: config.MemoryLimit},
+	} {
+		if r.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(r.value)
+		if err != nil {
+			return requirements, fmt.Errorf("invalid %s quantity %q: %w", r.name, r.value, err)
+		}
+		if *r.list == nil {
+			*r.list = make(corev1.ResourceList)
+		}
+		(*r.list)[r.name] = quantity
+	}
+	return requirements, nil
+}
+
+func (m *KubernetesManager) getCluster(clusterID int) (*kubernetesCluster, error) {
+	cluster, has := m.clusters[clusterID]
+	if !has {
+		return nil, fmt.Errorf("kubernetes cluster %d not found", clusterID)
+	}
+	return cluster, nil
+}
+
+func (m *KubernetesManager) makeDriverName(processor *models.Processor) string {
+	return makeKubernetesDriverName(processor.ID)
+}
+
+func makeKubernetesDriverName(processorID string) string {
+	driverName := fmt.Sprintf("driver-%s", CleanupName(processorID))
+
+	// Keep the same length limit as the Docker Swarm service names
+	const maxBaseNameLength = 54
+	if len(driverName) > maxBaseNameLength {
+		driverName = driverName[:maxBaseNameLength]
+	}
+	return driverName
+}
+
+func (m *KubernetesManager) makeScriptConfigMapName(processor *models.Processor) string {
+	return fmt.Sprintf("%s-script", m.makeDriverName(processor))
+}
+
+// buildLabels returns the labels of the Deployment and its pods, the owner labels are set
+// only if the project is loaded and the values are valid label values
+func (m *KubernetesManager) buildLabels(processor *models.Processor) map[string]string {
+	result := map[string]string{
+		labelProcessorID: processor.ID,
+		labelProjectID:   processor.ProjectID,
+		labelVersion:     strconv.Itoa(int(processor.Version)),
+		labelManagedBy:   "sentio-core",
+	}
+	if project := processor.GetProject(); project != nil {
+		result[labelOwnerID] = project.OwnerID
+		result[labelOwnerType] = project.OwnerType
+	}
+	for k, v := range result {
+		if len(validation.IsValidLabelValue(v)) > 0 {
+			delete(result, k)
+		}
+	}
+	return result
+}
+
+func (m *KubernetesManager) buildScriptConfigMap(processor *models.Processor, namespace string) (*corev1.ConfigMap, error) {
+	script, err := renderProcessorScript(m.config, processor)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.makeScriptConfigMapName(processor),
+			Namespace: namespace,
+			Labels:    m.buildLabels(processor),
+		},
+		Data: map[string]string{
+			processorScriptKey: string(script),
+		},
+	}, nil
+}
+
+// buildDeployment creates the Deployment of the processor, the driver talks to the processor
+// container through localhost
+func (m *KubernetesManager) buildDeployment(processor *models.Processor, namespace string) *appsv1.Deployment {
+	podLabels := m.buildLabels(processor)
+	driverLabels := make(map[string]string)
+	for k, v := range podLabels {
+		driverLabels[k] = v
+	}
+	driverLabels[labelServiceType] = "driver"
+
+	replicas := int32(1)
+	if processor.Pause {
+		replicas = 0
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name:         "cache",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		{
+			Name: "script",
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: m.makeScriptConfigMapName(processor)},
+			}},
+		},
+	}
+	mounts := []corev1.VolumeMount{
+		{Name: "cache", MountPath: CacheDirMountPath},
+	}
+	addConfigMap := func(volumeName, configMap, mountPath string) {
+		if configMap == "" {
+			return
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+			}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: mountPath,
+			SubPath:   path.Base(mountPath),
+		})
+	}
+	addConfigMap("chains-config", m.config.Kubernetes.ChainsConfigMap, ChainConfigMountPath)
+	addConfigMap("clickhouse-config", m.config.Kubernetes.ClickhouseConfigMap, ClickhouseConfigMountPath)
+
+	var driverEnvs []corev1.EnvVar
+	for _, env := range buildDriverEnvs(m.config, processor) {
+		name, value, _ := strings.Cut(env, "=")
+		driverEnvs = append(driverEnvs, corev1.EnvVar{Name: name, Value: value})
+	}
+
+	processorMounts := append([]corev1.VolumeMount{
+		{Name: "script", MountPath: "/start-processor.sh", SubPath: processorScriptKey},
+	}, mounts...)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.makeDriverName(processor),
+			Namespace: namespace,
+			Labels:    driverLabels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{labelProcessorID: processor.ID},
+			},
+			// the driver must not run twice for the same processor
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: podLabels,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: m.config.Kubernetes.ServiceAccount,
+					Containers: []corev1.Container{
+						{
+							Name:    driverContainerName,
+							Image:   m.config.DriverImage,
+							Command: []string{"/app/driver/cmd/cmd_/cmd"},
+							Args: buildDriverArgs(
+								m.config,
+								processor,
+								fmt.Sprintf("localhost:%d", processorPort),
+								CacheDirMountPath,
+								ChainConfigMountPath,
+								ClickhouseConfigMountPath,
+							),
+							Env:          driverEnvs,
+							Resources:    m.driverResources,
+							VolumeMounts: mounts,
+						},
+						{
+							Name:         processorContainerName,
+							Image:        m.config.DriverImage,
+							Command:      []string{"/bin/sh", "/start-processor.sh"},
+							Env:          []corev1.EnvVar{{Name: "WRITE_V2_EVENT_LOGS", Value: "false"}},
+							Resources:    m.processorResources,
+							VolumeMounts: processorMounts,
+							Ports:        []corev1.ContainerPort{{Name: "grpc", ContainerPort: processorPort}},
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// apply creates the objects of the processor, or updates them if they exist. If restart is true,
+// the pods are recreated even if the pod template is not changed.
+func (m *KubernetesManager) apply(ctx context.Context, processor *models.Processor, createOnly, restart bool) error {
+	cluster, err := m.getCluster(int(processor.K8sClusterID))
+	if err != nil {
+		return err
+	}
+	configMap, err := m.buildScriptConfigMap(processor, cluster.namespace)
+	if err != nil {
+		return err
+	}
+	deployment := m.buildDeployment(processor, cluster.namespace)
+
+	configMaps := cluster.client.CoreV1().ConfigMaps(cluster.namespace)
+	existingConfigMap, err := configMaps.Get(ctx, configMap.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create config map %s: %w", configMap.Name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get config map %s: %w", configMap.Name, err)
+	default:
+		existingConfigMap.Labels = configMap.Labels
+		existingConfigMap.Data = configMap.Data
+		if _, err = configMaps.Update(ctx, existingConfigMap, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update config map %s: %w", configMap.Name, err)
+		}
+	}
+
+	deployments := cluster.client.AppsV1().Deployments(cluster.namespace)
+	existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err = deployments.Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create deployment %s: %w", deployment.Name, err)
+		}
+		log.Infow("Created Kubernetes deployment for processor",
+			"processorID", processor.ID,
+			"clusterID", cluster.id,
+			"deployment", deployment.Name,
+		)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s: %w", deployment.Name, err)
+	}
+	if createOnly {
+		return fmt.Errorf("deployment %s already exists", deployment.Name)
+	}
+
+	// keep the restart annotation, otherwise the pods are recreated by every update
+	restartedAt := existing.Spec.Template.Annotations[annotationRestartedAt]
+	if restart {
+		restartedAt = time.Now().Format(time.RFC3339)
+	}
+	if restartedAt != "" {
+		deployment.Spec.Template.Annotations = map[string]string{annotationRestartedAt: restartedAt}
+	}
+	existing.Labels = deployment.Labels
+	existing.Spec = deployment.Spec
+	if _, err = deployments.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update deployment %s: %w", deployment.Name, err)
+	}
+	log.Infow("Updated Kubernetes deployment for processor",
+		"processorID", processor.ID,
+		"clusterID", cluster.id,
+		"deployment", deployment.Name,
+		"restart", restart,
+	)
+	return nil
+}
+
+// StartJob creates the Deployment for the given processor
+func (m *KubernetesManager) StartJob(ctx context.Context, processor *models.Processor) error {
+	return m.apply(ctx, processor, true, false)
+}
+
+// RestartJob updates the Deployment of the given processor and recreates the pods
+func (m *KubernetesManager) RestartJob(ctx context.Context, processor *models.Processor) error {
+	return m.apply(ctx, processor, false, true)
+}
+
+// DeleteJob deletes the Deployment of the given processor
+func (m *KubernetesManager) DeleteJob(ctx context.Context, processor *models.Processor) error {
+	cluster, err := m.getCluster(int(processor.K8sClusterID))
+	if err != nil {
+		return err
+	}
+	return m.deleteObjects(ctx, cluster, processor.ID)
+}
+
+func (m *KubernetesManager) deleteObjects(ctx context.Context, cluster *kubernetesCluster, processorID string) error {
+	name := makeKubernetesDriverName(processorID)
+	// the pods are deleted in the background by the garbage collector
+	propagation := metav1.DeletePropagationBackground
+	err := cluster.client.AppsV1().Deployments(cluster.namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete deployment %s: %w", name, err)
+	}
+	configMapName := name + "-script"
+	err = cluster.client.CoreV1().ConfigMaps(cluster.namespace).Delete(ctx, configMapName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete config map %s: %w", configMapName, err)
+	}
+	log.Infow("Deleted Kubernetes deployment for processor",
+		"processorID", processorID,
+		"clusterID", cluster.id,
+		"deployment", name,
+	)
+	return nil
+}
+
+// StartOrUpdateDriverJob creates or updates the Deployment for the given processor
+func (m *KubernetesManager) StartOrUpdateDriverJob(ctx context.Context, processor *models.Processor) error {
+	return m.apply(ctx, processor, false, false)
+}
+
+// IsProcessorRunning checks if the Deployment of the processor exists and is not scaled to zero
+func (m *KubernetesManager) IsProcessorRunning(processorID string, k8sClusterID int) bool {
+	cluster, err := m.getCluster(k8sClusterID)
+	if err != nil {
+		return false
+	}
+	deployment, err := cluster.client.AppsV1().Deployments(cluster.namespace).Get(
+		context.Background(), makeKubernetesDriverName(processorID), metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	return deployment.Spec.Replicas == nil || *deployment.Spec.Replicas > 0
+}
+
+// RestartProcessorByID recreates the pods of a specific processor
+func (m *KubernetesManager) RestartProcessorByID(ctx context.Context, processorID string, k8sClusterID int) error {
+	cluster, err := m.getCluster(k8sClusterID)
+	if err != nil {
+		return err
+	}
+	deployments := cluster.client.AppsV1().Deployments(cluster.namespace)
+	name := makeKubernetesDriverName(processorID)
+	deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s: %w", name, err)
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = make(map[string]string)
+	}
+	deployment.Spec.Template.Annotations[annotationRestartedAt] = time.Now().Format(time.RFC3339)
+	if _, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to restart deployment %s: %w", name, err)
+	}
+
+	log.Infow("Restarted processor by ID",
+		"processorID", processorID,
+		"clusterID", k8sClusterID,
+	)
+	return nil
+}
+
+// RestartWaitingProcessorsByOwner deletes the pods of the given owner that are waiting to be started,
+// like the pods in crash loop backoff, so that they are recreated without the backoff
+func (m *KubernetesManager) RestartWaitingProcessorsByOwner(ctx context.Context, ownerID, ownerType string) error {
+	selector := labels.SelectorFromSet(map[string]string{
+		labelManagedBy: "sentio-core",
+		labelOwnerID:   ownerID,
+		labelOwnerType: ownerType,
+	}).String()
+
+	count := 0
+	for _, cluster := range m.sortedClusters() {
+		pods := cluster.client.CoreV1().Pods(cluster.namespace)
+		list, err := pods.List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list pods in cluster %d: %w", cluster.id, err)
+		}
+		for _, pod := range list.Items {
+			if !isPodWaiting(&pod) {
+				continue
+			}
+			if err = pods.Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				log.Warnw("Failed to restart pod", "pod", pod.Name, "clusterID", cluster.id, "error", err)
+				continue
+			}
+			count++
+		}
+	}
+
+	log.Infow("Restarted waiting processors by owner",
+		"ownerID", ownerID,
+		"ownerType", ownerType,
+		"count", count,
+	)
+	return nil
+}
+
+// isPodWaiting checks if any container of the pod is waiting to be started
+func isPodWaiting(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodPending {
+		return true
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCluster checks if the given cluster ID is configured
+func (m *KubernetesManager) HasCluster(clusterID int) bool {
+	_, has := m.clusters[clusterID]
+	return has
+}
+
+func (m *KubernetesManager) sortedClusters() []*kubernetesCluster {
+	clusters := make([]*kubernetesCluster, 0, len(m.clusters))
+	for _, cluster := range m.clusters {
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].id < clusters[j].id
+	})
+	return clusters
+}
+
+// ListDriverJobPodsByProcessor lists the pods of the given processor in all clusters
+func (m *KubernetesManager) ListDriverJobPodsByProcessor(ctx context.Context, processorID string) (map[int][]Pod, error) {
+	selector := labels.SelectorFromSet(map[string]string{labelProcessorID: processorID}).String()
+	result := make(map[int][]Pod)
+	for _, cluster := range m.sortedClusters() {
+		list, err := cluster.client.CoreV1().Pods(cluster.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods in cluster %d: %w", cluster.id, err)
+		}
+		if len(list.Items) == 0 {
+			continue
+		}
+		pods := make([]Pod, 0, len(list.Items))
+		for i := range list.Items {
+			pods = append(pods, &KubernetesPod{pod: &list.Items[i]})
+		}
+		result[cluster.id] = pods
+	}
+	return result, nil
+}
+
+// GetNamespaceForCluster returns the namespace for the given cluster ID
+func (m *KubernetesManager) GetNamespaceForCluster(clusterID int) string {
+	if cluster, has := m.clusters[clusterID]; has {
+		return cluster.namespace
+	}
+	return ""
+}
+
+// GetLogs fetches the logs of the driver and processor containers, until is exclusive
+func (m *KubernetesManager) GetLogs(ctx context.Context, processor *models.Processor, limit int32, until, query string) ([]Log, string, error) {
+	cluster, err := m.getCluster(int(processor.K8sClusterID))
+	if err != nil {
+		return nil, "", err
+	}
+	var untilTime time.Time
+	if until != "" {
+		if untilTime, err = time.Parse(time.RFC3339Nano, until); err != nil {
+			return nil, "", fmt.Errorf("invalid until %q: %w", until, err)
+		}
+	}
+
+	pods := cluster.client.CoreV1().Pods(cluster.namespace)
+	list, err := pods.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{labelProcessorID: processor.ID}).String(),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var allLogs []Log
+	for _, pod := range list.Items {
+		for _, container := range []string{driverContainerName, processorContainerName} {
+			options := &corev1.PodLogOptions{
+				Container:  container,
+				Timestamps: true,
+			}
+			if until == "" {
+				// the lines before until are unknown, so the tail can only be used for the first page
+				tail := int64(limit)
+				options.TailLines = &tail
+			}
+			stream, err := pods.GetLogs(pod.Name, options).Stream(ctx)
+			if err != nil {
+				log.Warnw("Failed to get logs for pod", "pod", pod.Name, "container", container, "error", err)
+				continue
+			}
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				l, ok := parseLogLine(scanner.Text(), container, query)
+				if !ok || (!untilTime.IsZero() && !l.ts.Before(untilTime)) {
+					continue
+				}
+				allLogs = append(allLogs, l)
+			}
+			_ = stream.Close()
+		}
+	}
+
+	allLogs, nextUntil := sortAndLimitLogs(allLogs, limit)
+	return allLogs, nextUntil, nil
+}
+
+// KubernetesPod implements the Pod interface for Kubernetes pods
+type KubernetesPod struct {
+	pod *corev1.Pod
+}
+
+func (p *KubernetesPod) Name() string {
+	return p.pod.Name
+}
+
+func (p *KubernetesPod) Namespace() string {
+	return p.pod.Namespace
+}
+
+func (p *KubernetesPod) PodIP() string {
+	return p.pod.Status.PodIP
+}
+
+// Ensure KubernetesManager implements DriverJobManager
+var _ DriverJobManager = (*KubernetesManager)(nil)
@@ -0,0 +1,250 @@
+package driverjob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	commonerrors "sentioxyz/sentio-core/service/common/errors"
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	"sentioxyz/sentio-core/service/processor/models"
+	"sentioxyz/sentio-core/service/processor/protos"
+	"sentioxyz/sentio-core/service/processor/repository"
+)
+
+type testKubernetesEnv struct {
+	manager        *KubernetesManager
+	clients        map[int]*fake.Clientset
+	processorRepo  *repository.RedisProcessorRepo
+	chainStateRepo *repository.RedisChainStateRepo
+}
+
+func newTestKubernetesEnv(t *testing.T) *testKubernetesEnv {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	env := &testKubernetesEnv{
+		clients:        map[int]*fake.Clientset{0: fake.NewClientset(), 1: fake.NewClientset()},
+		processorRepo:  repository.NewRedisProcessorRepo(redisClient),
+		chainStateRepo: repository.NewRedisChainStateRepo(redisClient),
+	}
+	clients := make(map[int]kubernetes.Interface)
+	for id, client := range env.clients {
+		clients[id] = client
+	}
+	env.manager, err = newKubernetesManager(DriverConfig{
+		DriverImage:      "driver:test",
+		ProcessorService: "processor-service:10020",
+		Kubernetes: KubernetesConfig{
+			Clusters: []KubernetesClusterConfig{
+				{ID: 0, Namespace: "driver-0"},
+				{ID: 1, Namespace: "driver-1"},
+			},
+			ChainsConfigMap: "chains-config",
+			DriverResources: ResourceConfig{
+				CPURequest:    "500m",
+				MemoryRequest: "1Gi",
+				MemoryLimit:   "2Gi",
+			},
+		},
+	}, clients, env.processorRepo, env.chainStateRepo)
+	require.NoError(t, err)
+	return env
+}
+
+func (env *testKubernetesEnv) createPod(t *testing.T, clusterID int, name string, processor *models.Processor, waiting string) {
+	namespace := env.manager.GetNamespaceForCluster(clusterID)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    env.manager.buildLabels(processor),
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: "10.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: processorContainerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: driverContainerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	if waiting != "" {
+		pod.Status.ContainerStatuses[1].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+			Reason:  waiting,
+			Message: "back-off 5m0s restarting failed container",
+		}}
+	}
+	_, err := env.clients[clusterID].CoreV1().Pods(namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func Test_kubernetesManagerDeployment(t *testing.T) {
+	env := newTestKubernetesEnv(t)
+	m := env.manager
+	ctx := context.Background()
+	processor := &models.Processor{ID: "0XhWA854", ProjectID: "project", Version: 3, K8sClusterID: 1}
+
+	assert.True(t, m.HasCluster(0))
+	assert.True(t, m.HasCluster(1))
+	assert.False(t, m.HasCluster(2))
+	assert.Equal(t, "driver-1", m.GetNamespaceForCluster(1))
+
+	require.NoError(t, m.StartJob(ctx, processor))
+	assert.Error(t, m.StartJob(ctx, processor))
+	assert.True(t, m.IsProcessorRunning(processor.ID, 1))
+	assert.False(t, m.IsProcessorRunning(processor.ID, 0))
+
+	deployment, err := env.clients[1].AppsV1().Deployments("driver-1").Get(ctx, "driver-0xhwa854", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), *deployment.Spec.Replicas)
+	assert.Equal(t, "3", deployment.Labels[labelVersion])
+	assert.Equal(t, "driver", deployment.Labels[labelServiceType])
+	podSpec := deployment.Spec.Template.Spec
+	require.Len(t, podSpec.Containers, 2)
+	driver := podSpec.Containers[0]
+	assert.Equal(t, "driver:test", driver.Image)
+	assert.Contains(t, driver.Args, "-external-processor=localhost:9999")
+	assert.Contains(t, driver.Args, "-processor-id=0XhWA854")
+	assert.Contains(t, driver.Args, "-chains-config="+ChainConfigMountPath)
+	assert.Equal(t, "500m", driver.Resources.Requests.Cpu().String())
+	assert.Equal(t, "1Gi", driver.Resources.Requests.Memory().String())
+	assert.Equal(t, "2Gi", driver.Resources.Limits.Memory().String())
+	assert.Nil(t, podSpec.Containers[1].Resources.Requests)
+	assert.Len(t, podSpec.Volumes, 3)
+
+	configMap, err := env.clients[1].CoreV1().ConfigMaps("driver-1").Get(ctx, "driver-0xhwa854-script", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, configMap.Data[processorScriptKey], "-processor-id=0XhWA854")
+
+	// pause scales the deployment to zero
+	processor.Pause = true
+	require.NoError(t, m.StartOrUpdateDriverJob(ctx, processor))
+	assert.False(t, m.IsProcessorRunning(processor.ID, 1))
+
+	processor.Pause = false
+	require.NoError(t, m.RestartJob(ctx, processor))
+	assert.True(t, m.IsProcessorRunning(processor.ID, 1))
+	deployment, err = env.clients[1].AppsV1().Deployments("driver-1").Get(ctx, "driver-0xhwa854", metav1.GetOptions{})
+	require.NoError(t, err)
+	restartedAt := deployment.Spec.Template.Annotations[annotationRestartedAt]
+	assert.NotEmpty(t, restartedAt)
+
+	// the update keeps the restart annotation
+	require.NoError(t, m.StartOrUpdateDriverJob(ctx, processor))
+	deployment, err = env.clients[1].AppsV1().Deployments("driver-1").Get(ctx, "driver-0xhwa854", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, restartedAt, deployment.Spec.Template.Annotations[annotationRestartedAt])
+
+	require.NoError(t, m.RestartProcessorByID(ctx, processor.ID, 1))
+	assert.Error(t, m.RestartProcessorByID(ctx, processor.ID, 0))
+	assert.Error(t, m.RestartProcessorByID(ctx, processor.ID, 2))
+
+	require.NoError(t, m.DeleteJob(ctx, processor))
+	require.NoError(t, m.DeleteJob(ctx, processor))
+	assert.False(t, m.IsProcessorRunning(processor.ID, 1))
+	_, err = env.clients[1].CoreV1().ConfigMaps("driver-1").Get(ctx, "driver-0xhwa854-script", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	processor.K8sClusterID = 2
+	assert.Error(t, m.StartJob(ctx, processor))
+}
+
+func Test_kubernetesManagerPods(t *testing.T) {
+	env := newTestKubernetesEnv(t)
+	m := env.manager
+	ctx := context.Background()
+	owner := &commonmodels.Project{OwnerID: "owner", OwnerType: "user"}
+	p1 := &models.Processor{ID: "p1", Project: owner}
+	p2 := &models.Processor{ID: "p2", Project: owner, K8sClusterID: 1}
+	other := &models.Processor{ID: "p3", Project: &commonmodels.Project{OwnerID: "other", OwnerType: "user"}}
+
+	env.createPod(t, 0, "p1-a", p1, "")
+	env.createPod(t, 0, "p1-b", p1, "CrashLoopBackOff")
+	env.createPod(t, 1, "p2-a", p2, "ImagePullBackOff")
+	env.createPod(t, 0, "p3-a", other, "CrashLoopBackOff")
+
+	pods, err := m.ListDriverJobPodsByProcessor(ctx, "p1")
+	require.NoError(t, err)
+	require.Len(t, pods[0], 2)
+	assert.Equal(t, "p1-a", pods[0][0].Name())
+	assert.Equal(t, "driver-0", pods[0][0].Namespace())
+	assert.Equal(t, "10.0.0.1", pods[0][0].PodIP())
+	pods, err = m.ListDriverJobPodsByProcessor(ctx, "p2")
+	require.NoError(t, err)
+	assert.Len(t, pods[1], 1)
+	assert.Empty(t, pods[0])
+
+	// only the waiting pods of the owner are deleted
+	require.NoError(t, m.RestartWaitingProcessorsByOwner(ctx, "owner", "user"))
+	podNames := func(clusterID int) (names []string) {
+		list, err := env.clients[clusterID].CoreV1().Pods(m.GetNamespaceForCluster(clusterID)).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		for _, pod := range list.Items {
+			names = append(names, pod.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"p1-a", "p3-a"}, podNames(0))
+	assert.Empty(t, podNames(1))
+}
+
+func Test_kubernetesReconciler(t *testing.T) {
+	env := newTestKubernetesEnv(t)
+	m := env.manager
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	paused := &models.Processor{ID: "paused"}
+	removed := &models.Processor{ID: "removed"}
+	obsolete := &models.Processor{ID: "obsolete", VersionState: int32(protos.ProcessorVersionState_OBSOLETE)}
+	moved := &models.Processor{ID: "moved", K8sClusterID: 1}
+	failing := &models.Processor{ID: "failing"}
+	for _, p := range []*models.Processor{paused, obsolete, failing} {
+		require.NoError(t, m.StartJob(ctx, p))
+		require.NoError(t, env.processorRepo.SaveProcessor(ctx, p))
+	}
+	require.NoError(t, m.StartJob(ctx, removed))
+	require.NoError(t, m.StartJob(ctx, moved))
+	moved.K8sClusterID = 0
+	require.NoError(t, env.processorRepo.SaveProcessor(ctx, moved))
+	paused.Pause = true
+	require.NoError(t, env.processorRepo.SaveProcessor(ctx, paused))
+
+	require.NoError(t, m.StartManager(ctx))
+	require.Eventually(t, func() bool {
+		return !m.IsProcessorRunning("paused", 0) &&
+			!m.IsProcessorRunning("removed", 0) &&
+			!m.IsProcessorRunning("obsolete", 0) &&
+			!m.IsProcessorRunning("moved", 1)
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.True(t, m.IsProcessorRunning("failing", 0))
+	// the paused deployment is kept
+	_, err := env.clients[0].AppsV1().Deployments("driver-0").Get(ctx, "driver-paused", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// the failing container is reported into the meta chain state
+	env.createPod(t, 0, "failing-a", failing, "CrashLoopBackOff")
+	var meta models.ChainState
+	require.Eventually(t, func() bool {
+		meta, err = env.chainStateRepo.GetChainState(ctx, "failing", metaChainID)
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(protos.ChainState_Status_ERROR), meta.State)
+	assert.Equal(t, commonerrors.ErrorNamespace(commonerrors.DRIVER), meta.ErrorRecord.Namespace)
+	assert.Equal(t,
+		"driver container of pod failing-a is failing: CrashLoopBackOff: back-off 5m0s restarting failed container",
+		meta.ErrorRecord.Message)
+}
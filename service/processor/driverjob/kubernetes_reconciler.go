@@ -0,0 +1,271 @@
+package driverjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"gorm.io/gorm"
+
+	"sentioxyz/sentio-core/common/log"
+	commonerrors "sentioxyz/sentio-core/service/common/errors"
+	"sentioxyz/sentio-core/service/processor/models"
+	"sentioxyz/sentio-core/service/processor/protos"
+)
+
+const (
+	// metaChainID is the chain state holding the processor level status
+	metaChainID = "meta"
+
+	cacheSyncTimeout = time.Minute
+)
+
+// failingWaitingReasons are the reasons of the waiting containers that need an action to recover
+var failingWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// kubernetesReconciler watches the Deployments and pods of a cluster through informers and
+// reconciles them with the processor repository:
+//   - the Deployment of a processor removed from the repository, obsoleted or moved to another
+//     cluster is deleted
+//   - the replicas of the Deployment follow the pause state of the processor
+//   - a failing container is reported as the error of the meta chain state
+type kubernetesReconciler struct {
+	manager     *KubernetesManager
+	cluster     *kubernetesCluster
+	deployments appslisters.DeploymentLister
+	pods        corelisters.PodLister
+	queue       workqueue.TypedRateLimitingInterface[string]
+}
+
+// StartManager starts the reconciler of every cluster, it returns after the informer caches are synced
+// and the reconcilers are stopped when ctx is done
+func (m *KubernetesManager) StartManager(ctx context.Context) error {
+	for _, cluster := range m.sortedClusters() {
+		r := &kubernetesReconciler{
+			manager: m,
+			cluster: cluster,
+			queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+				workqueue.DefaultTypedControllerRateLimiter[string](),
+				workqueue.TypedRateLimitingQueueConfig[string]{Name: fmt.Sprintf("driverjob-%d", cluster.id)},
+			),
+		}
+		if err := r.start(ctx); err != nil {
+			return fmt.Errorf("failed to start reconciler of cluster %d: %w", cluster.id, err)
+		}
+	}
+	log.Infow("Kubernetes manager started successfully", "clusters", len(m.clusters))
+	return nil
+}
+
+func (r *kubernetesReconciler) start(ctx context.Context) error {
+	selector := labels.SelectorFromSet(map[string]string{labelManagedBy: "sentio-core"}).String()
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		r.cluster.client,
+		r.manager.config.Kubernetes.ResyncPeriod,
+		informers.WithNamespace(r.cluster.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = selector
+		}),
+	)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	podInformer := factory.Core().V1().Pods()
+	r.deployments = deploymentInformer.Lister()
+	r.pods = podInformer.Lister()
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: r.enqueue,
+		UpdateFunc: func(_, obj any) {
+			r.enqueue(obj)
+		},
+		DeleteFunc: r.enqueue,
+	}
+	if _, err := deploymentInformer.Informer().AddEventHandler(handler); err != nil {
+		return err
+	}
+	if _, err := podInformer.Informer().AddEventHandler(handler); err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	syncCtx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), deploymentInformer.Informer().HasSynced, podInformer.Informer().HasSynced) {
+		r.queue.ShutDown()
+		return fmt.Errorf("failed to sync informer caches in %s", cacheSyncTimeout)
+	}
+
+	go func() {
+		<-ctx.Done()
+		r.queue.ShutDown()
+	}()
+	go func() {
+		for r.processNext(ctx) {
+		}
+	}()
+	return nil
+}
+
+func (r *kubernetesReconciler) enqueue(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	if processorID := object.GetLabels()[labelProcessorID]; processorID != "" {
+		r.queue.Add(processorID)
+	}
+}
+
+func (r *kubernetesReconciler) processNext(ctx context.Context) bool {
+	processorID, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(processorID)
+
+	if err := r.reconcile(ctx, processorID); err != nil {
+		log.Warnw("Failed to reconcile processor",
+			"processorID", processorID,
+			"clusterID", r.cluster.id,
+			"error", err,
+		)
+		r.queue.AddRateLimited(processorID)
+		return true
+	}
+	r.queue.Forget(processorID)
+	return true
+}
+
+// isNotFound checks the not found errors of the repositories
+func isNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || strings.Contains(err.Error(), "not found")
+}
+
+func (r *kubernetesReconciler) reconcile(ctx context.Context, processorID string) error {
+	deployment, err := r.deployments.Deployments(r.cluster.namespace).Get(makeKubernetesDriverName(processorID))
+	if apierrors.IsNotFound(err) {
+		// deleted, the pods are removed by the garbage collector
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	processor, err := r.manager.processorRepo.GetProcessor(ctx, processorID, false)
+	if err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+		log.Infow("Deleting deployment of the removed processor", "processorID", processorID, "clusterID", r.cluster.id)
+		return r.manager.deleteObjects(ctx, r.cluster, processorID)
+	}
+	if processor.VersionState == int32(protos.ProcessorVersionState_OBSOLETE) {
+		log.Infow("Deleting deployment of the obsolete processor", "processorID", processorID, "clusterID", r.cluster.id)
+		return r.manager.deleteObjects(ctx, r.cluster, processorID)
+	}
+	if int(processor.K8sClusterID) != r.cluster.id {
+		log.Infow("Deleting deployment of the processor moved to another cluster",
+			"processorID", processorID,
+			"clusterID", r.cluster.id,
+			"newClusterID", processor.K8sClusterID,
+		)
+		return r.manager.deleteObjects(ctx, r.cluster, processorID)
+	}
+
+	replicas := int32(1)
+	if processor.Pause {
+		replicas = 0
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != replicas {
+		// objects from the lister are shared, never modify them
+		updated := deployment.DeepCopy()
+		updated.Spec.Replicas = &replicas
+		_, err = r.cluster.client.AppsV1().Deployments(r.cluster.namespace).Update(ctx, updated, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to scale deployment %s: %w", deployment.Name, err)
+		}
+		log.Infow("Scaled deployment of the processor",
+			"processorID", processorID,
+			"clusterID", r.cluster.id,
+			"replicas", replicas,
+		)
+	}
+	return r.reportFailure(ctx, processorID)
+}
+
+// podFailure returns the message of the first failing container of the pods
+func podFailure(pods []*corev1.Pod) string {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting == nil || !failingWaitingReasons[status.State.Waiting.Reason] {
+				continue
+			}
+			msg := fmt.Sprintf("%s container of pod %s is failing: %s", status.Name, pod.Name, status.State.Waiting.Reason)
+			if status.State.Waiting.Message != "" {
+				msg += ": " + status.State.Waiting.Message
+			}
+			return msg
+		}
+	}
+	return ""
+}
+
+// reportFailure sets the meta chain state of the processor to error if a container is failing,
+// the driver overwrites the state after it is running again
+func (r *kubernetesReconciler) reportFailure(ctx context.Context, processorID string) error {
+	pods, err := r.pods.Pods(r.cluster.namespace).List(labels.SelectorFromSet(map[string]string{labelProcessorID: processorID}))
+	if err != nil {
+		return err
+	}
+	msg := podFailure(pods)
+	if msg == "" {
+		return nil
+	}
+
+	meta, err := r.manager.chainStateRepo.GetChainState(ctx, processorID, metaChainID)
+	if err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+		meta = models.ChainState{ProcessorID: processorID, ChainID: metaChainID}
+	}
+	if meta.State == int32(protos.ChainState_Status_ERROR) && meta.ErrorRecord.Message == msg {
+		return nil
+	}
+	meta.State = int32(protos.ChainState_Status_ERROR)
+	meta.ErrorRecord = commonerrors.NewErrorRecord(commonerrors.DRIVER, errors.New(msg))
+	if err = r.manager.chainStateRepo.UpdateChainState(ctx, &meta); err != nil {
+		return fmt.Errorf("failed to update meta chain state: %w", err)
+	}
+	log.Infow("Reported failing pod of the processor",
+		"processorID", processorID,
+		"clusterID", r.cluster.id,
+		"message", msg,
+	)
+	return nil
+}
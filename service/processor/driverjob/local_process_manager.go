@@ -0,0 +1,753 @@
+package driverjob
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
+const (
+	localDriverLogFile       = "driver.log"
+	localProcessorLogFile    = "processor.log"
+	localProcessorScriptFile = "start-processor.sh"
+
+	defaultLocalRestartBackoffMin = time.Second
+	defaultLocalRestartBackoffMax = time.Minute
+	defaultLocalStopTimeout       = 10 * time.Second
+)
+
+// LocalProcessManager implements DriverJobManager by running the driver and the processor of each
+// processor as local processes, which is enough for a dev box or a single VM deployment.
+// Crashed processes are restarted with exponential backoff, and their output is written to the
+// log files under {DataDir}/{driver name}/ with the timestamp of each line.
+type LocalProcessManager struct {
+	config DriverConfig
+
+	mu   sync.Mutex
+	jobs map[string]*localJob
+}
+
+// NewLocalProcessManager creates a new local process manager
+func NewLocalProcessManager(config DriverConfig) (*LocalProcessManager, error) {
+	local := &config.Local
+	if local.DriverBinary == "" {
+		return nil, fmt.Errorf("driver binary cannot be empty")
+	}
+	driverBinary, err := exec.LookPath(local.DriverBinary)
+	if err != nil {
+		return nil, fmt.Errorf("driver binary %q not found: %w", local.DriverBinary, err)
+	}
+	if local.DriverBinary, err = filepath.Abs(driverBinary); err != nil {
+		return nil, fmt.Errorf("failed to resolve driver binary path: %w", err)
+	}
+	if local.DataDir == "" {
+		return nil, fmt.Errorf("data dir cannot be empty")
+	}
+	if config.CacheDir == "" {
+		config.CacheDir = filepath.Join(local.DataDir, "cache")
+	}
+	// the processes may run in another working directory, so all paths are made absolute
+	for _, p := range []*string{&local.DataDir, &local.WorkDir, &config.CacheDir, &config.ChainsConfig, &config.Clickhouse.ConfigPath} {
+		if *p == "" {
+			continue
+		}
+		if *p, err = filepath.Abs(*p); err != nil {
+			return nil, fmt.Errorf("failed to resolve path %q: %w", *p, err)
+		}
+	}
+	for _, dir := range []string{local.DataDir, config.CacheDir} {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	if local.RestartBackoffMin <= 0 {
+		local.RestartBackoffMin = defaultLocalRestartBackoffMin
+	}
+	if local.RestartBackoffMax < local.RestartBackoffMin {
+		local.RestartBackoffMax = max(defaultLocalRestartBackoffMax, local.RestartBackoffMin)
+	}
+	if local.StopTimeout <= 0 {
+		local.StopTimeout = defaultLocalStopTimeout
+	}
+	return &LocalProcessManager{
+		config: config,
+		jobs:   make(map[string]*localJob),
+	}, nil
+}
+
+// localJob is the driver and processor processes of one processor
+type localJob struct {
+	processor  *models.Processor
+	port       int
+	spec       string
+	script     []byte
+	scriptPath string
+	processes  []*localProcess
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// start starts the processes in background after the replaced job is stopped, so the processes of the
+// two jobs never run at the same time and the caller need not wait for the replaced processes to exit
+func (j *localJob) start(replaced *localJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		replaced.stop()
+		if ctx.Err() != nil {
+			return
+		}
+		for _, p := range j.processes {
+			j.wg.Add(1)
+			go func() {
+				defer j.wg.Done()
+				p.run(ctx)
+			}()
+		}
+	}()
+}
+
+// stop terminates all processes and waits for them to exit, including the processes of the replaced job
+func (j *localJob) stop() {
+	if j == nil {
+		return
+	}
+	j.cancel()
+	j.wg.Wait()
+}
+
+// localProcess is a supervised process, restarted with backoff after it exits
+type localProcess struct {
+	name        string
+	logType     string
+	path        string
+	args        []string
+	env         []string
+	dir         string
+	logPath     string
+	backoffMin  time.Duration
+	backoffMax  time.Duration
+	stopTimeout time.Duration
+
+	// wake skips the backoff of a waiting process
+	wake chan struct{}
+
+	mu       sync.Mutex
+	pid      int
+	waiting  bool
+	restarts int
+}
+
+func (p *localProcess) status() (pid int, waiting bool, restarts int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pid, p.waiting, p.restarts
+}
+
+func (p *localProcess) run(ctx context.Context) {
+	failures := 0
+	for {
+		startAt := time.Now()
+		err := p.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// a process that has been up for a while is not crash looping
+		if time.Since(startAt) >= p.backoffMax {
+			failures = 0
+		}
+		backoff := p.backoffMax
+		if failures < 32 {
+			backoff = min(p.backoffMin<<failures, p.backoffMax)
+		}
+		failures++
+		if err == nil {
+			err = fmt.Errorf("exit status 0")
+		}
+		p.appendEvent(fmt.Sprintf("process exited: %v, restarting in %s", err, backoff))
+		log.Warnw("Local process exited, will be restarted",
+			"name", p.name,
+			"error", err,
+			"backoff", backoff,
+		)
+
+		p.mu.Lock()
+		p.waiting = true
+		p.mu.Unlock()
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-p.wake:
+			timer.Stop()
+		}
+		p.mu.Lock()
+		p.waiting = false
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+func (p *localProcess) runOnce(ctx context.Context) error {
+	logFile, err := os.OpenFile(p.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+	out := &timestampWriter{w: logFile}
+	defer out.flush()
+
+	cmd := exec.CommandContext(ctx, p.path, p.args...)
+	cmd.Dir = p.dir
+	cmd.Env = append(os.Environ(), p.env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// give the process a chance to exit gracefully, it is killed after the stop timeout
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = p.stopTimeout
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start process: %w", err)
+	}
+	p.mu.Lock()
+	p.pid = cmd.Process.Pid
+	p.mu.Unlock()
+
+	err = cmd.Wait()
+
+	p.mu.Lock()
+	p.pid = 0
+	p.mu.Unlock()
+	return err
+}
+
+// appendEvent writes a line of the supervisor into the log file, so it is visible through GetLogs
+func (p *localProcess) appendEvent(msg string) {
+	logFile, err := os.OpenFile(p.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Warnw("Failed to open log file", "path", p.logPath, "error", err)
+		return
+	}
+	defer logFile.Close()
+	out := &timestampWriter{w: logFile}
+	_, _ = out.Write([]byte("[supervisor] " + msg + "\n"))
+}
+
+// timestampWriter prefixes every line with the timestamp, in the same format as the docker logs
+type timestampWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (t *timestampWriter) Write(data []byte) (int, error) {
+	t.buf = append(t.buf, data...)
+	for {
+		i := bytes.IndexByte(t.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := t.writeLine(t.buf[:i]); err != nil {
+			return 0, err
+		}
+		t.buf = append(t.buf[:0], t.buf[i+1:]...)
+	}
+	return len(data), nil
+}
+
+func (t *timestampWriter) writeLine(line []byte) error {
+	_, err := fmt.Fprintf(t.w, "%s %s\n", time.Now().UTC().Format(time.RFC3339Nano), line)
+	return err
+}
+
+// flush writes the last line without the line break
+func (t *timestampWriter) flush() {
+	if len(t.buf) > 0 {
+		_ = t.writeLine(t.buf)
+		t.buf = t.buf[:0]
+	}
+}
+
+const localProcessorScriptTemplate = `#!/bin/sh
+set -e
+# prepare
+{{quote .DriverBinary}} -processor-service={{quote .ProcessorService}} \
+        -cache-dir={{quote .CacheDir}} \
+        -prepare-processor-env-only=true \
+        -chains-config={{quote .ChainsConfig}} \
+        -processor-id={{quote .ProcessorID}} \
+        -use-pnpm=true \
+        -rpcnode-service= \
+        -enable_tls=false \
+        -clickhouse-config-path={{quote .ClickhouseConfigPath}}
+
+mkdir -p {{quote .CacheDir}}/.pnpm-store/dumps
+
+# The cache dir is shared by all processors, refuse to run the code of another processor.
+TARGET_DIR=$(head -n 1 {{quote .CacheDir}}/.processor-path)
+TARGET_PATH=$(tail -n 1 {{quote .CacheDir}}/.processor-path)
+case "$TARGET_PATH" in
+    */sentio/{{.ProcessorID}}/*) ;;
+    *)
+        echo "FATAL: .processor-path points to $TARGET_PATH which is not for processor {{.ProcessorID}}" >&2
+        exit 1
+        ;;
+esac
+
+# start processor, every processor listens on its own port
+CHAINS_CONFIG_FILE=${TARGET_DIR}/chains-config.json
+case "$TARGET_PATH" in
+    */main)
+        chmod +x "$TARGET_PATH"
+        exec "$TARGET_PATH" --port={{.Port}} --chains-config="$CHAINS_CONFIG_FILE"
+        ;;
+    *)
+        exec /usr/bin/env node "$TARGET_DIR/node_modules/.bin/processor-runner" --port {{.Port}} --use-chainserver --concurrency=128 --log-format=json --chains-config="$CHAINS_CONFIG_FILE" "$TARGET_PATH"
+        ;;
+esac
+`
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (m *LocalProcessManager) generateProcessorScript(processor *models.Processor, port int) ([]byte, error) {
+	data := struct {
+		DriverBinary         string
+		ProcessorService     string
+		CacheDir             string
+		ChainsConfig         string
+		ProcessorID          string
+		ClickhouseConfigPath string
+		Port                 int
+	}{
+		DriverBinary:         m.config.Local.DriverBinary,
+		ProcessorService:     m.config.ProcessorService,
+		CacheDir:             m.config.CacheDir,
+		ChainsConfig:         m.config.ChainsConfig,
+		ProcessorID:          processor.ID,
+		ClickhouseConfigPath: m.config.Clickhouse.ConfigPath,
+		Port:                 port,
+	}
+
+	tmpl, err := template.New("local-processor-script").
+		Funcs(template.FuncMap{"quote": shellQuote}).
+		Parse(localProcessorScriptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse processor script template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute processor script template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// freePort asks the kernel for a free local port
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (m *LocalProcessManager) makeDriverName(processor *models.Processor) string {
+	return fmt.Sprintf("driver-%s", CleanupName(processor.ID))
+}
+
+func (m *LocalProcessManager) processorDir(processor *models.Processor) string {
+	return filepath.Join(m.config.Local.DataDir, m.makeDriverName(processor))
+}
+
+// buildJob builds the processes of the processor, the port of the processor is kept if it is not zero
+func (m *LocalProcessManager) buildJob(processor *models.Processor, port int) (*localJob, error) {
+	var err error
+	if port == 0 {
+		if port, err = freePort(); err != nil {
+			return nil, fmt.Errorf("failed to allocate port for processor %s: %w", processor.ID, err)
+		}
+	}
+	dir := m.processorDir(processor)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for processor %s: %w", processor.ID, err)
+	}
+
+	script, err := m.generateProcessorScript(processor, port)
+	if err != nil {
+		return nil, err
+	}
+	scriptPath := filepath.Join(dir, localProcessorScriptFile)
+
+	driverArgs := buildDriverArgs(
+		m.config,
+		processor,
+		fmt.Sprintf("127.0.0.1:%d", port),
+		m.config.CacheDir,
+		m.config.ChainsConfig,
+		m.config.Clickhouse.ConfigPath,
+	)
+	driverEnvs := buildDriverEnvs(m.config, processor)
+
+	newProcess := func(name, logType, path string, args, env []string, logFile string) *localProcess {
+		return &localProcess{
+			name:        name,
+			logType:     logType,
+			path:        path,
+			args:        args,
+			env:         env,
+			dir:         m.config.Local.WorkDir,
+			logPath:     filepath.Join(dir, logFile),
+			backoffMin:  m.config.Local.RestartBackoffMin,
+			backoffMax:  m.config.Local.RestartBackoffMax,
+			stopTimeout: m.config.Local.StopTimeout,
+			wake:        make(chan struct{}, 1),
+		}
+	}
+	driverName := m.makeDriverName(processor)
+	return &localJob{
+		processor: processor,
+		port:      port,
+		// the processes are restarted by StartOrUpdateDriverJob only if the spec changes
+		spec:       strings.Join(append(append(driverArgs, driverEnvs...), string(script)), "\n"),
+		script:     script,
+		scriptPath: scriptPath,
+		processes: []*localProcess{
+			newProcess(driverName+"-processor", "processor", "/bin/sh", []string{scriptPath},
+				[]string{"WRITE_V2_EVENT_LOGS=false"}, localProcessorLogFile),
+			newProcess(driverName+"-driver", "driver", m.config.Local.DriverBinary, driverArgs,
+				driverEnvs, localDriverLogFile),
+		},
+	}, nil
+}
+
+// writeScript writes the wrapper script of the processor, the script is replaced by renaming a temporary
+// file so a running shell never reads a partially written script
+func (j *localJob) writeScript() error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.scriptPath), localProcessorScriptFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(j.script); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.scriptPath)
+}
+
+// replaceJob replaces the running processes of the processor with the new ones, m.mu must be held.
+// The replaced processes are stopped by the new job before its processes start. If job is nil, the
+// replaced job is returned, the caller should stop it after releasing m.mu.
+func (m *LocalProcessManager) replaceJob(processor *models.Processor, job *localJob) (stopping *localJob) {
+	old := m.jobs[processor.ID]
+	delete(m.jobs, processor.ID)
+	if job == nil {
+		return old
+	}
+	job.start(old)
+	m.jobs[processor.ID] = job
+	log.Infow("Started local processes for processor",
+		"processorID", processor.ID,
+		"port", job.port,
+		"dir", m.processorDir(processor),
+	)
+	return nil
+}
+
+// startOrUpdate starts the processes of the processor, the running processes are restarted
+// if force is true or the spec changed, m.mu must be held. The returned job should be stopped
+// by the caller after releasing m.mu, see replaceJob.
+func (m *LocalProcessManager) startOrUpdate(processor *models.Processor, force bool) (*localJob, error) {
+	if processor.Pause {
+		if _, has := m.jobs[processor.ID]; has {
+			log.Infow("Stopping local processes of paused processor", "processorID", processor.ID)
+		}
+		return m.replaceJob(processor, nil), nil
+	}
+	port := 0
+	old, has := m.jobs[processor.ID]
+	if has {
+		port = old.port
+	}
+	job, err := m.buildJob(processor, port)
+	if err != nil {
+		return nil, err
+	}
+	if has && old.spec == job.spec {
+		if !force {
+			old.processor = processor
+			return nil, nil
+		}
+	} else if err = job.writeScript(); err != nil {
+		return nil, fmt.Errorf("failed to write processor script for processor %s: %w", processor.ID, err)
+	}
+	return m.replaceJob(processor, job), nil
+}
+
+// StartJob starts the driver and processor processes for the given processor
+func (m *LocalProcessManager) StartJob(ctx context.Context, processor *models.Processor) error {
+	m.mu.Lock()
+	if _, has := m.jobs[processor.ID]; has {
+		m.mu.Unlock()
+		return fmt.Errorf("processes of processor %s are already running", processor.ID)
+	}
+	stopping, err := m.startOrUpdate(processor, false)
+	m.mu.Unlock()
+	stopping.stop()
+	return err
+}
+
+// RestartJob restarts the processes of the given processor, they are started if not running
+func (m *LocalProcessManager) RestartJob(ctx context.Context, processor *models.Processor) error {
+	m.mu.Lock()
+	stopping, err := m.startOrUpdate(processor, true)
+	m.mu.Unlock()
+	stopping.stop()
+	return err
+}
+
+// DeleteJob stops the processes of the given processor, the log files are kept
+func (m *LocalProcessManager) DeleteJob(ctx context.Context, processor *models.Processor) error {
+	m.mu.Lock()
+	if _, has := m.jobs[processor.ID]; !has {
+		m.mu.Unlock()
+		log.Debugw("Local processes not found during deletion", "processorID", processor.ID)
+		return nil
+	}
+	stopping := m.replaceJob(processor, nil)
+	m.mu.Unlock()
+	stopping.stop()
+	log.Infow("Stopped local processes for processor", "processorID", processor.ID)
+	return nil
+}
+
+// StartOrUpdateDriverJob starts the processes of the given processor, or restarts them if the spec changed
+func (m *LocalProcessManager) StartOrUpdateDriverJob(ctx context.Context, processor *models.Processor) error {
+	m.mu.Lock()
+	stopping, err := m.startOrUpdate(processor, false)
+	m.mu.Unlock()
+	stopping.stop()
+	return err
+}
+
+// StartManager does nothing, the processes are supervised since they are started
+func (m *LocalProcessManager) StartManager(ctx context.Context) error {
+	log.Infow("Local process manager started",
+		"driverBinary", m.config.Local.DriverBinary,
+		"dataDir", m.config.Local.DataDir,
+	)
+	return nil
+}
+
+// IsProcessorRunning checks if the processes of the processor are supervised, a crashed process
+// waiting for the restart is still treated as running
+func (m *LocalProcessManager) IsProcessorRunning(processorID string, _ int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, has := m.jobs[processorID]
+	return has
+}
+
+// RestartProcessorByID restarts the processes of a specific processor
+func (m *LocalProcessManager) RestartProcessorByID(ctx context.Context, processorID string, k8sClusterID int) error {
+	m.mu.Lock()
+	job, has := m.jobs[processorID]
+	if !has {
+		m.mu.Unlock()
+		return fmt.Errorf("processes not found for processor %s", processorID)
+	}
+	stopping, err := m.startOrUpdate(job.processor, true)
+	m.mu.Unlock()
+	stopping.stop()
+	if err != nil {
+		return err
+	}
+	log.Infow("Restarted processor by ID",
+		"processorID", processorID,
+		"clusterID", k8sClusterID,
+	)
+	return nil
+}
+
+// RestartWaitingProcessorsByOwner restarts the crashed processes waiting for the restart backoff
+// immediately, only the processors of the given owner are affected
+func (m *LocalProcessManager) RestartWaitingProcessorsByOwner(ctx context.Context, ownerID, ownerType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, job := range m.jobs {
+		project := job.processor.GetProject()
+		if project == nil || project.OwnerID != ownerID || project.OwnerType != ownerType {
+			continue
+		}
+		for _, p := range job.processes {
+			if _, waiting, _ := p.status(); !waiting {
+				continue
+			}
+			select {
+			case p.wake <- struct{}{}:
+				count++
+			default:
+			}
+		}
+	}
+
+	log.Infow("Restarted waiting processors by owner",
+		"ownerID", ownerID,
+		"ownerType", ownerType,
+		"count", count,
+	)
+	return nil
+}
+
+// HasCluster checks if the given cluster ID exists
+func (m *LocalProcessManager) HasCluster(clusterID int) bool {
+	return clusterID == 0
+}
+
+// ListDriverJobPodsByProcessor lists the running processes of the given processor
+func (m *LocalProcessManager) ListDriverJobPodsByProcessor(ctx context.Context, processorID string) (map[int][]Pod, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pods := make([]Pod, 0)
+	if job, has := m.jobs[processorID]; has {
+		for _, p := range job.processes {
+			if pid, _, _ := p.status(); pid != 0 {
+				pods = append(pods, &LocalProcess{name: p.name, pid: pid})
+			}
+		}
+	}
+	return map[int][]Pod{0: pods}, nil
+}
+
+// GetNamespaceForCluster returns the namespace for the given cluster ID
+func (m *LocalProcessManager) GetNamespaceForCluster(_ int) string {
+	return ""
+}
+
+// GetLogs reads the logs of the given processor from the log files, until is exclusive
+func (m *LocalProcessManager) GetLogs(ctx context.Context, processor *models.Processor, limit int32, until, query string) ([]Log, string, error) {
+	var untilTime time.Time
+	if until != "" {
+		var err error
+		if untilTime, err = time.Parse(time.RFC3339Nano, until); err != nil {
+			return nil, "", fmt.Errorf("invalid until %q: %w", until, err)
+		}
+	}
+
+	dir := m.processorDir(processor)
+	var allLogs []Log
+	for _, f := range []struct{ logType, file string }{
+		{logType: "driver", file: localDriverLogFile},
+		{logType: "processor", file: localProcessorLogFile},
+	} {
+		logs, err := readLogFile(filepath.Join(dir, f.file), f.logType, query, untilTime, int(limit))
+		if err != nil {
+			return nil, "", err
+		}
+		allLogs = append(allLogs, logs...)
+	}
+
+	allLogs, nextUntil := sortAndLimitLogs(allLogs, limit)
+	return allLogs, nextUntil, nil
+}
+
+// readLogFile returns the last limit lines before until in the log file
+func readLogFile(path, logType, query string, until time.Time, limit int) ([]Log, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open log file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	limit = max(limit, 0)
+	var logs []Log
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		l, ok := parseLogLine(scanner.Text(), logType, query)
+		if !ok || (!until.IsZero() && !l.ts.Before(until)) {
+			continue
+		}
+		logs = append(logs, l)
+		// lines are appended in time order, only the last ones are needed
+		if len(logs) > 2*limit+1024 {
+			logs = append(logs[:0], logs[len(logs)-limit:]...)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file %s: %w", path, err)
+	}
+	if len(logs) > limit {
+		logs = logs[len(logs)-limit:]
+	}
+	return logs, nil
+}
+
+// Close stops all processes
+func (m *LocalProcessManager) Close() error {
+	m.mu.Lock()
+	jobs := m.jobs
+	m.jobs = make(map[string]*localJob)
+	m.mu.Unlock()
+	for _, job := range jobs {
+		job.stop()
+	}
+	return nil
+}
+
+// LocalProcess implements the Pod interface for local processes
+type LocalProcess struct {
+	name string
+	pid  int
+}
+
+func (p *LocalProcess) Name() string {
+	return p.name
+}
+
+func (p *LocalProcess) Namespace() string {
+	return ""
+}
+
+func (p *LocalProcess) PodIP() string {
+	return "127.0.0.1"
+}
+
+// Pid returns the process id
+func (p *LocalProcess) Pid() int {
+	return p.pid
+}
+
+// Ensure LocalProcessManager implements DriverJobManager
+var _ DriverJobManager = (*LocalProcessManager)(nil)
@@ -0,0 +1,250 @@
+package driverjob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
+// fakeDriverScript acts like the driver binary. With -prepare-processor-env-only it installs a fake
+// processor binary and writes the .processor-path, otherwise it runs until being killed. The first
+// {FAILS} runs of the driver exit with an error, the number of runs is counted in {DIR}/driver.runs.
+const fakeDriverScript = `#!/bin/sh
+for arg in "$@"; do
+  case "$arg" in
+    -prepare-processor-env-only=true) prepare=1 ;;
+    -cache-dir=*) cache="${arg#-cache-dir=}" ;;
+    -processor-id=*) id="${arg#-processor-id=}" ;;
+  esac
+done
+if [ -n "$prepare" ]; then
+  mkdir -p "$cache/sentio/$id"
+  printf '#!/bin/sh\necho "processor started $*"\nexec sleep 1000\n' > "$cache/sentio/$id/main"
+  printf '%s\n%s\n' "$cache/sentio/$id" "$cache/sentio/$id/main" > "$cache/.processor-path"
+  exit 0
+fi
+echo run >> {DIR}/driver.runs
+runs=$(wc -l < {DIR}/driver.runs)
+echo "driver started run=$runs $*"
+if [ "$runs" -le {FAILS} ]; then
+  echo "driver crashed" >&2
+  exit 1
+fi
+exec sleep 1000
+`
+
+func newTestLocalProcessManager(t *testing.T, fails int, backoff time.Duration) (*LocalProcessManager, string) {
+	dir := t.TempDir()
+	script := strings.NewReplacer("{DIR}", dir, "{FAILS}", fmt.Sprint(fails)).Replace(fakeDriverScript)
+	driverBinary := filepath.Join(dir, "driver")
+	require.NoError(t, os.WriteFile(driverBinary, []byte(script), 0755))
+
+	m, err := NewLocalProcessManager(DriverConfig{
+		ProcessorService: "127.0.0.1:10020",
+		Local: LocalProcessConfig{
+			DriverBinary:      driverBinary,
+			DataDir:           filepath.Join(dir, "data"),
+			RestartBackoffMin: backoff,
+			RestartBackoffMax: backoff * 4,
+			StopTimeout:       time.Second,
+		},
+		Driver: DriverSpecificConfig{
+			Verbose: "debug",
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = m.Close()
+	})
+	return m, dir
+}
+
+func driverRuns(dir string) int {
+	data, _ := os.ReadFile(filepath.Join(dir, "driver.runs"))
+	return strings.Count(string(data), "\n")
+}
+
+func waitForPods(t *testing.T, m *LocalProcessManager, processorID string, n int) []Pod {
+	var pods []Pod
+	require.Eventually(t, func() bool {
+		result, err := m.ListDriverJobPodsByProcessor(context.Background(), processorID)
+		require.NoError(t, err)
+		pods = result[0]
+		return len(pods) == n
+	}, 10*time.Second, 10*time.Millisecond)
+	return pods
+}
+
+func waitForLog(t *testing.T, m *LocalProcessManager, processor *models.Processor, query string) []Log {
+	var logs []Log
+	require.Eventually(t, func() bool {
+		var err error
+		logs, _, err = m.GetLogs(context.Background(), processor, 100, "", query)
+		require.NoError(t, err)
+		return len(logs) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	return logs
+}
+
+func Test_localProcessManager(t *testing.T) {
+	m, dir := newTestLocalProcessManager(t, 0, time.Second)
+	ctx := context.Background()
+	processor := &models.Processor{ID: "0XhWA854", Version: 1}
+
+	assert.False(t, m.IsProcessorRunning(processor.ID, 0))
+	require.NoError(t, m.StartJob(ctx, processor))
+	assert.Error(t, m.StartJob(ctx, processor))
+	assert.True(t, m.IsProcessorRunning(processor.ID, 0))
+
+	pods := waitForPods(t, m, processor.ID, 2)
+	assert.Equal(t, "driver-0xhwa854-processor", pods[0].Name())
+	assert.Equal(t, "driver-0xhwa854-driver", pods[1].Name())
+	assert.Equal(t, "127.0.0.1", pods[1].PodIP())
+
+	// the driver connects to the processor on its own port
+	port := m.jobs[processor.ID].port
+	driverLogs := waitForLog(t, m, processor, "driver started")
+	assert.Equal(t, "driver", driverLogs[0].Type())
+	assert.Contains(t, driverLogs[0].Message(), fmt.Sprintf("-external-processor=127.0.0.1:%d", port))
+	assert.Contains(t, driverLogs[0].Message(), "-processor-id=0XhWA854")
+	assert.Contains(t, driverLogs[0].Message(), "-verbose=debug")
+	processorLogs := waitForLog(t, m, processor, "processor started")
+	assert.Equal(t, "processor", processorLogs[0].Type())
+	assert.Contains(t, processorLogs[0].Message(), fmt.Sprintf("--port=%d", port))
+
+	// paging by until
+	logs, nextUntil, err := m.GetLogs(ctx, processor, 1, "", "started")
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	older, _, err := m.GetLogs(ctx, processor, 100, nextUntil, "started")
+	require.NoError(t, err)
+	for _, l := range older {
+		assert.True(t, l.Timestamp().Before(logs[0].Timestamp()))
+	}
+
+	// nothing changed, the processes and the script are kept
+	scriptPath := filepath.Join(m.processorDir(processor), localProcessorScriptFile)
+	script, err := os.Stat(scriptPath)
+	require.NoError(t, err)
+	require.NoError(t, m.StartOrUpdateDriverJob(ctx, processor))
+	assert.Equal(t, 1, driverRuns(dir))
+
+	require.NoError(t, m.RestartProcessorByID(ctx, processor.ID, 0))
+	waitForPods(t, m, processor.ID, 2)
+	waitForLog(t, m, processor, "run=2")
+	assert.Equal(t, 2, driverRuns(dir))
+	assert.Equal(t, port, m.jobs[processor.ID].port)
+	current, err := os.Stat(scriptPath)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(script, current))
+	assert.Error(t, m.RestartProcessorByID(ctx, "unknown", 0))
+
+	// a new version changes nothing in the args, but the paused processor is stopped
+	paused := *processor
+	paused.Pause = true
+	require.NoError(t, m.StartOrUpdateDriverJob(ctx, &paused))
+	assert.False(t, m.IsProcessorRunning(processor.ID, 0))
+	waitForPods(t, m, processor.ID, 0)
+
+	require.NoError(t, m.RestartJob(ctx, processor))
+	assert.True(t, m.IsProcessorRunning(processor.ID, 0))
+	waitForPods(t, m, processor.ID, 2)
+	waitForLog(t, m, processor, "run=3")
+
+	require.NoError(t, m.DeleteJob(ctx, processor))
+	assert.False(t, m.IsProcessorRunning(processor.ID, 0))
+	waitForPods(t, m, processor.ID, 0)
+	require.NoError(t, m.DeleteJob(ctx, processor))
+
+	// the logs are kept after the deletion
+	logs, _, err = m.GetLogs(ctx, processor, 100, "", "driver started")
+	require.NoError(t, err)
+	assert.Len(t, logs, 3)
+}
+
+func Test_localProcessManagerCrashRestart(t *testing.T) {
+	m, dir := newTestLocalProcessManager(t, 3, 20*time.Millisecond)
+	processor := &models.Processor{ID: "crash"}
+
+	require.NoError(t, m.StartJob(context.Background(), processor))
+	waitForPods(t, m, processor.ID, 2)
+	require.Eventually(t, func() bool { return driverRuns(dir) == 4 }, 10*time.Second, 10*time.Millisecond)
+
+	driver := m.jobs[processor.ID].processes[1]
+	require.Eventually(t, func() bool {
+		pid, waiting, restarts := driver.status()
+		return pid != 0 && !waiting && restarts == 3
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// the backoff doubles on every crash
+	logs := waitForLog(t, m, processor, "[supervisor]")
+	require.Len(t, logs, 3)
+	assert.Contains(t, logs[2].Message(), "exit status 1, restarting in 20ms")
+	assert.Contains(t, logs[1].Message(), "restarting in 40ms")
+	assert.Contains(t, logs[0].Message(), "restarting in 80ms")
+	logs = waitForLog(t, m, processor, "driver crashed")
+	assert.Len(t, logs, 3)
+	assert.True(t, m.IsProcessorRunning(processor.ID, 0))
+}
+
+func Test_localProcessManagerRestartWaiting(t *testing.T) {
+	m, dir := newTestLocalProcessManager(t, 1, time.Hour)
+	ctx := context.Background()
+	processor := &models.Processor{
+		ID: "waiting",
+		Project: &commonmodels.Project{
+			OwnerID:   "owner",
+			OwnerType: "user",
+		},
+	}
+
+	require.NoError(t, m.StartJob(ctx, processor))
+	driver := m.jobs[processor.ID].processes[1]
+	require.Eventually(t, func() bool {
+		_, waiting, _ := driver.status()
+		return waiting
+	}, 10*time.Second, 10*time.Millisecond)
+	// crashed processes waiting for the restart are still running
+	assert.True(t, m.IsProcessorRunning(processor.ID, 0))
+
+	// other owners are not affected
+	require.NoError(t, m.RestartWaitingProcessorsByOwner(ctx, "owner", "org"))
+	require.NoError(t, m.RestartWaitingProcessorsByOwner(ctx, "other", "user"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, driverRuns(dir))
+
+	require.NoError(t, m.RestartWaitingProcessorsByOwner(ctx, "owner", "user"))
+	require.Eventually(t, func() bool {
+		pid, waiting, restarts := driver.status()
+		return pid != 0 && !waiting && restarts == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	waitForLog(t, m, processor, "run=2")
+}
+
+func Test_timestampWriter(t *testing.T) {
+	var sb strings.Builder
+	w := &timestampWriter{w: &sb}
+	_, err := w.Write([]byte("line1\nli"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("ne2\nline3"))
+	require.NoError(t, err)
+	w.flush()
+
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	for i, line := range lines {
+		l, ok := parseLogLine(line, "driver", "")
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("line%d", i+1), l.Message())
+	}
+}
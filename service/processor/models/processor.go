@@ -422,6 +422,10 @@ type Processor struct {
 	PauseAt     time.Time
 	PauseReason string
 
+	// ShadowStopAtBlocks is not empty if the processor is running as the shadow of the active version,
+	// the driver stops each chain at the block, see ShadowRun
+	ShadowStopAtBlocks map[string]uint64 `gorm:"serializer:json"`
+
 	// properties for sentio processor
 	SentioProcessorProperties
 
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/service/processor/protos"
+)
+
+// ShadowStopAtBlocksEnv is the environment variable of the driver carrying the stop blocks of a shadow run,
+// the value is built by EncodeStopAtBlocks
+const ShadowStopAtBlocksEnv = "SENTIO_SHADOW_STOP_AT_BLOCKS"
+
+// EncodeStopAtBlocks encodes the stop block of each chain as "<chainID>:<blockNumber>,..." ordered by chain ID
+func EncodeStopAtBlocks(stopAtBlocks map[string]uint64) string {
+	items := make([]string, 0, len(stopAtBlocks))
+	for _, chainID := range utils.GetOrderedMapKeys(stopAtBlocks) {
+		items = append(items, fmt.Sprintf("%s:%d", chainID, stopAtBlocks[chainID]))
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseStopAtBlocks is the reverse of EncodeStopAtBlocks, empty text means no stop blocks
+func ParseStopAtBlocks(text string) (map[string]uint64, error) {
+	if text == "" {
+		return nil, nil
+	}
+	stopAtBlocks := make(map[string]uint64)
+	for _, item := range strings.Split(text, ",") {
+		chainID, blockNumber, ok := strings.Cut(item, ":")
+		if !ok || chainID == "" {
+			return nil, fmt.Errorf("invalid stop block %q", item)
+		}
+		bn, err := strconv.ParseUint(blockNumber, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number of stop block %q: %w", item, err)
+		}
+		stopAtBlocks[chainID] = bn
+	}
+	return stopAtBlocks, nil
+}
+
+type ShadowRunState string
+
+const (
+	// ShadowRunStateRunning the shadow version is processing the blocks
+	ShadowRunStateRunning ShadowRunState = "RUNNING"
+	// ShadowRunStateDiffing the outputs of the two versions are being compared
+	ShadowRunStateDiffing ShadowRunState = "DIFFING"
+	ShadowRunStateDone    ShadowRunState = "DONE"
+	ShadowRunStateFailed  ShadowRunState = "FAILED"
+)
+
+// ShadowRun runs a pending processor version as the shadow of the active version: the shadow version processes
+// the blocks the active version had processed when the run started and then stops. Each version writes to the
+// tables of its own processor ID, so the outputs are isolated and can be compared once the shadow version is done.
+type ShadowRun struct {
+	ShadowProcessorID string
+	BaseProcessorID   string
+	ProjectID         string
+	// StopAtBlocks is the last block of each chain to be processed by the shadow version,
+	// and also the last block of the base version outputs to be compared
+	StopAtBlocks map[string]uint64
+	State        ShadowRunState
+	Error        string `json:",omitempty"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Report       *ShadowDiffReport `json:",omitempty"`
+}
+
+type ShadowMismatchKind string
+
+const (
+	// ShadowMismatchKindMissing the row only exists in the output of the base version
+	ShadowMismatchKindMissing ShadowMismatchKind = "MISSING"
+	// ShadowMismatchKindExtra the row only exists in the output of the shadow version
+	ShadowMismatchKindExtra ShadowMismatchKind = "EXTRA"
+	// ShadowMismatchKindDifferent the row exists in both outputs but the values are different
+	ShadowMismatchKindDifferent ShadowMismatchKind = "DIFFERENT"
+)
+
+// ShadowMismatch is a sample of the rows that are not the same in the outputs of the two versions
+type ShadowMismatch struct {
+	Kind  ShadowMismatchKind
+	Chain string
+	// Key is the entity ID, or the series key of the time series point
+	Key string
+	// Timestamp is only used by the time series points
+	Timestamp *time.Time `json:",omitempty"`
+	// BlockNumber is the block the divergence is attributed to, the smaller one of the blocks
+	// that generated the two rows if both exist
+	BlockNumber uint64
+	// Fields are the names of the fields with different values
+	Fields []string       `json:",omitempty"`
+	Base   map[string]any `json:",omitempty"`
+	Shadow map[string]any `json:",omitempty"`
+}
+
+// ShadowDiffItem is the comparison result of an entity type or a metric
+type ShadowDiffItem struct {
+	Name        string
+	BaseCount   uint64
+	ShadowCount uint64
+	Matched     uint64
+	Different   uint64
+	Missing     uint64
+	Extra       uint64
+	Samples     []ShadowMismatch `json:",omitempty"`
+}
+
+func (i ShadowDiffItem) Same() bool {
+	return i.Different == 0 && i.Missing == 0 && i.Extra == 0
+}
+
+// ShadowDiffReport compares the entity rows by ID and the time series points by series and timestamp
+type ShadowDiffReport struct {
+	Entities []ShadowDiffItem
+	Metrics  []ShadowDiffItem
+	// FirstDivergentBlocks is the first block of each chain having divergent outputs,
+	// chains without divergence are not included
+	FirstDivergentBlocks map[string]uint64 `json:",omitempty"`
+	GeneratedAt          time.Time
+}
+
+func (r *ShadowDiffReport) Same() bool {
+	return len(r.FirstDivergentBlocks) == 0
+}
+
+func (r *ShadowRun) ToPB() (*protos.ShadowRun, error) {
+	run := &protos.ShadowRun{
+		ShadowProcessorId: r.ShadowProcessorID,
+		BaseProcessorId:   r.BaseProcessorID,
+		ProjectId:         r.ProjectID,
+		StopAtBlocks:      r.StopAtBlocks,
+		State:             string(r.State),
+		Error:             r.Error,
+		CreatedAt:         timestamppb.New(r.CreatedAt),
+		UpdatedAt:         timestamppb.New(r.UpdatedAt),
+	}
+	if r.Report != nil {
+		report, err := r.Report.ToPB()
+		if err != nil {
+			return nil, err
+		}
+		run.Report = report
+	}
+	return run, nil
+}
+
+func (r *ShadowDiffReport) ToPB() (*protos.ShadowDiffReport, error) {
+	report := &protos.ShadowDiffReport{
+		FirstDivergentBlocks: r.FirstDivergentBlocks,
+		GeneratedAt:          timestamppb.New(r.GeneratedAt),
+	}
+	for _, item := range r.Entities {
+		pb, err := item.ToPB()
+		if err != nil {
+			return nil, err
+		}
+		report.Entities = append(report.Entities, pb)
+	}
+	for _, item := range r.Metrics {
+		pb, err := item.ToPB()
+		if err != nil {
+			return nil, err
+		}
+		report.Metrics = append(report.Metrics, pb)
+	}
+	return report, nil
+}
+
+func (i ShadowDiffItem) ToPB() (*protos.ShadowDiffItem, error) {
+	item := &protos.ShadowDiffItem{
+		Name:        i.Name,
+		BaseCount:   i.BaseCount,
+		ShadowCount: i.ShadowCount,
+		Matched:     i.Matched,
+		Different:   i.Different,
+		Missing:     i.Missing,
+		Extra:       i.Extra,
+	}
+	for _, m := range i.Samples {
+		sample, err := m.ToPB()
+		if err != nil {
+			return nil, err
+		}
+		item.Samples = append(item.Samples, sample)
+	}
+	return item, nil
+}
+
+func (m ShadowMismatch) ToPB() (*protos.ShadowMismatch, error) {
+	mismatch := &protos.ShadowMismatch{
+		Kind:        string(m.Kind),
+		Chain:       m.Chain,
+		Key:         m.Key,
+		BlockNumber: m.BlockNumber,
+		Fields:      m.Fields,
+	}
+	if m.Timestamp != nil {
+		mismatch.Timestamp = timestamppb.New(*m.Timestamp)
+	}
+	var err error
+	if mismatch.Base, err = rowToStruct(m.Base); err != nil {
+		return nil, err
+	}
+	if mismatch.Shadow, err = rowToStruct(m.Shadow); err != nil {
+		return nil, err
+	}
+	return mismatch, nil
+}
+
+// rowToStruct converts the row through json, the values of the rows may be of types not accepted
+// by structpb.NewStruct, such as decimals and big integers
+func rowToStruct(row map[string]any) (*structpb.Struct, error) {
+	if row == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("encode row failed: %w", err)
+	}
+	var st structpb.Struct
+	if err = protojson.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("decode row failed: %w", err)
+	}
+	return &st, nil
+}
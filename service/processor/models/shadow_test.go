@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopAtBlocks(t *testing.T) {
+	stopAtBlocks := map[string]uint64{"56": 200, "1": 100, "sui_mainnet": 0}
+	text := EncodeStopAtBlocks(stopAtBlocks)
+	assert.Equal(t, "1:100,56:200,sui_mainnet:0", text)
+	parsed, err := ParseStopAtBlocks(text)
+	assert.NoError(t, err)
+	assert.Equal(t, stopAtBlocks, parsed)
+
+	parsed, err = ParseStopAtBlocks("")
+	assert.NoError(t, err)
+	assert.Nil(t, parsed)
+
+	for _, invalid := range []string{"1", ":100", "1:abc", "1:100,"} {
+		_, err = ParseStopAtBlocks(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestShadowRunToPB(t *testing.T) {
+	ts := time.Unix(1700000000, 0).UTC()
+	run := &ShadowRun{
+		ShadowProcessorID: "p2",
+		BaseProcessorID:   "p1",
+		StopAtBlocks:      map[string]uint64{"1": 100},
+		State:             ShadowRunStateDone,
+		Report: &ShadowDiffReport{
+			Entities: []ShadowDiffItem{{
+				Name:      "Account",
+				Different: 1,
+				Samples: []ShadowMismatch{{
+					Kind:        ShadowMismatchKindDifferent,
+					Chain:       "1",
+					Key:         "a1",
+					BlockNumber: 10,
+					Fields:      []string{"balance"},
+					Base:        map[string]any{"balance": decimal.NewFromInt(1)},
+					Shadow:      map[string]any{"balance": decimal.NewFromInt(2)},
+				}},
+			}},
+			Metrics:              []ShadowDiffItem{{Name: "volume", Missing: 1, Samples: []ShadowMismatch{{Timestamp: &ts}}}},
+			FirstDivergentBlocks: map[string]uint64{"1": 10},
+			GeneratedAt:          ts,
+		},
+	}
+	pb, err := run.ToPB()
+	assert.NoError(t, err)
+	assert.Equal(t, "DONE", pb.GetState())
+	assert.Equal(t, map[string]uint64{"1": 100}, pb.GetStopAtBlocks())
+	sample := pb.GetReport().GetEntities()[0].GetSamples()[0]
+	assert.Equal(t, "DIFFERENT", sample.GetKind())
+	assert.Equal(t, "1", sample.GetBase().GetFields()["balance"].GetStringValue())
+	assert.Equal(t, "2", sample.GetShadow().GetFields()["balance"].GetStringValue())
+	assert.Equal(t, ts, pb.GetReport().GetMetrics()[0].GetSamples()[0].GetTimestamp().AsTime())
+	assert.Nil(t, pb.GetReport().GetMetrics()[0].GetSamples()[0].GetBase())
+}
@@ -14,6 +14,7 @@ import (
 	"sentioxyz/sentio-core/service/processor/models"
 	"sentioxyz/sentio-core/service/processor/protos"
 	"sentioxyz/sentio-core/service/processor/repository"
+	"sentioxyz/sentio-core/service/processor/shadow"
 	"strconv"
 	"time"
 
@@ -58,6 +59,7 @@ type Service struct {
 	FileStorageSystem storagesystem.FileStorageSystemInterface
 	processorFactory  ProcessorFactory
 	lifecycleHook     ProcessorLifecycleHook
+	shadowSources     shadow.SourceProvider
 }
 
 func (s *Service) GetProcessor(
@@ -488,6 +490,8 @@ func (s *Service) SetVersionActive(ctx context.Context, req *protos.GetProcessor
 	}
 
 	processor.VersionState = int32(protos.ProcessorVersionState_ACTIVE)
+	// the promoted version should not stop at the blocks of its shadow run
+	wasShadow := clearShadowStopAtBlocks(processor)
 	err = s.processorRepo.WithTransaction(ctx, func(ctx context.Context) error {
 		if err := s.activateProcessor(ctx, processor, false); err != nil {
 			return err
@@ -497,6 +501,11 @@ func (s *Service) SetVersionActive(ctx context.Context, req *protos.GetProcessor
 	if err != nil {
 		return nil, err
 	}
+	if wasShadow {
+		if err = s.restartDriverJob(ctx, processor); err != nil {
+			return nil, err
+		}
+	}
 	return &emptypb.Empty{}, nil
 }
 
@@ -517,6 +526,8 @@ func (s *Service) ActivatePendingVersion(ctx context.Context, req *protos.Activa
 	latestProcessor := lo.MaxBy(processors, func(a, b *models.Processor) bool {
 		return a.Version < b.Version
 	})
+	// the promoted version should not stop at the blocks of its shadow run
+	wasShadow := clearShadowStopAtBlocks(latestProcessor)
 	err = s.processorRepo.WithTransaction(ctx, func(ctx context.Context) error {
 		latestProcessor.VersionState = int32(protos.ProcessorVersionState_ACTIVE)
 		if err := s.activateProcessor(ctx, latestProcessor, false); err != nil {
@@ -527,6 +538,11 @@ func (s *Service) ActivatePendingVersion(ctx context.Context, req *protos.Activa
 	if err != nil {
 		return nil, err
 	}
+	if wasShadow {
+		if err = s.restartDriverJob(ctx, latestProcessor); err != nil {
+			return nil, err
+		}
+	}
 
 	return &emptypb.Empty{}, nil
 }
@@ -3512,6 +3512,478 @@ func (x *ReconcileProcessorResponse) GetAction() ReconcileProcessorResponse_Acti
 	return ReconcileProcessorResponse_NONE
 }
 
+type ShadowRunRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProcessorId   string                 `protobuf:"bytes,1,opt,name=processor_id,json=processorId,proto3" json:"processor_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShadowRunRequest) Reset() {
+	*x = ShadowRunRequest{}
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShadowRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShadowRunRequest) ProtoMessage() {}
+
+func (x *ShadowRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShadowRunRequest.ProtoReflect.Descriptor instead.
+func (*ShadowRunRequest) Descriptor() ([]byte, []int) {
+	return file_service_processor_protos_processor_service_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ShadowRunRequest) GetProcessorId() string {
+	if x != nil {
+		return x.ProcessorId
+	}
+	return ""
+}
+
+type DiffShadowRunRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProcessorId   string                 `protobuf:"bytes,1,opt,name=processor_id,json=processorId,proto3" json:"processor_id,omitempty"`
+	Force         bool                   `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffShadowRunRequest) Reset() {
+	*x = DiffShadowRunRequest{}
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffShadowRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffShadowRunRequest) ProtoMessage() {}
+
+func (x *DiffShadowRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffShadowRunRequest.ProtoReflect.Descriptor instead.
+func (*DiffShadowRunRequest) Descriptor() ([]byte, []int) {
+	return file_service_processor_protos_processor_service_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DiffShadowRunRequest) GetProcessorId() string {
+	if x != nil {
+		return x.ProcessorId
+	}
+	return ""
+}
+
+func (x *DiffShadowRunRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type ShadowRun struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ShadowProcessorId string                 `protobuf:"bytes,1,opt,name=shadow_processor_id,json=shadowProcessorId,proto3" json:"shadow_processor_id,omitempty"`
+	BaseProcessorId   string                 `protobuf:"bytes,2,opt,name=base_processor_id,json=baseProcessorId,proto3" json:"base_processor_id,omitempty"`
+	ProjectId         string                 `protobuf:"bytes,3,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	StopAtBlocks      map[string]uint64      `protobuf:"bytes,4,rep,name=stop_at_blocks,json=stopAtBlocks,proto3" json:"stop_at_blocks,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	State             string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	Error             string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Report            *ShadowDiffReport      `protobuf:"bytes,9,opt,name=report,proto3" json:"report,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ShadowRun) Reset() {
+	*x = ShadowRun{}
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShadowRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShadowRun) ProtoMessage() {}
+
+func (x *ShadowRun) ProtoReflect() protoreflect.Message {
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShadowRun.ProtoReflect.Descriptor instead.
+func (*ShadowRun) Descriptor() ([]byte, []int) {
+	return file_service_processor_protos_processor_service_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ShadowRun) GetShadowProcessorId() string {
+	if x != nil {
+		return x.ShadowProcessorId
+	}
+	return ""
+}
+
+func (x *ShadowRun) GetBaseProcessorId() string {
+	if x != nil {
+		return x.BaseProcessorId
+	}
+	return ""
+}
+
+func (x *ShadowRun) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *ShadowRun) GetStopAtBlocks() map[string]uint64 {
+	if x != nil {
+		return x.StopAtBlocks
+	}
+	return nil
+}
+
+func (x *ShadowRun) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ShadowRun) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ShadowRun) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ShadowRun) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *ShadowRun) GetReport() *ShadowDiffReport {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+type ShadowDiffReport struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Entities             []*ShadowDiffItem      `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+	Metrics              []*ShadowDiffItem      `protobuf:"bytes,2,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	FirstDivergentBlocks map[string]uint64      `protobuf:"bytes,3,rep,name=first_divergent_blocks,json=firstDivergentBlocks,proto3" json:"first_divergent_blocks,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	GeneratedAt          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ShadowDiffReport) Reset() {
+	*x = ShadowDiffReport{}
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShadowDiffReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShadowDiffReport) ProtoMessage() {}
+
+func (x *ShadowDiffReport) ProtoReflect() protoreflect.Message {
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShadowDiffReport.ProtoReflect.Descriptor instead.
+func (*ShadowDiffReport) Descriptor() ([]byte, []int) {
+	return file_service_processor_protos_processor_service_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ShadowDiffReport) GetEntities() []*ShadowDiffItem {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+func (x *ShadowDiffReport) GetMetrics() []*ShadowDiffItem {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *ShadowDiffReport) GetFirstDivergentBlocks() map[string]uint64 {
+	if x != nil {
+		return x.FirstDivergentBlocks
+	}
+	return nil
+}
+
+func (x *ShadowDiffReport) GetGeneratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return nil
+}
+
+type ShadowDiffItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	BaseCount     uint64                 `protobuf:"varint,2,opt,name=base_count,json=baseCount,proto3" json:"base_count,omitempty"`
+	ShadowCount   uint64                 `protobuf:"varint,3,opt,name=shadow_count,json=shadowCount,proto3" json:"shadow_count,omitempty"`
+	Matched       uint64                 `protobuf:"varint,4,opt,name=matched,proto3" json:"matched,omitempty"`
+	Different     uint64                 `protobuf:"varint,5,opt,name=different,proto3" json:"different,omitempty"`
+	Missing       uint64                 `protobuf:"varint,6,opt,name=missing,proto3" json:"missing,omitempty"`
+	Extra         uint64                 `protobuf:"varint,7,opt,name=extra,proto3" json:"extra,omitempty"`
+	Samples       []*ShadowMismatch      `protobuf:"bytes,8,rep,name=samples,proto3" json:"samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShadowDiffItem) Reset() {
+	*x = ShadowDiffItem{}
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShadowDiffItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShadowDiffItem) ProtoMessage() {}
+
+func (x *ShadowDiffItem) ProtoReflect() protoreflect.Message {
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShadowDiffItem.ProtoReflect.Descriptor instead.
+func (*ShadowDiffItem) Descriptor() ([]byte, []int) {
+	return file_service_processor_protos_processor_service_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ShadowDiffItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ShadowDiffItem) GetBaseCount() uint64 {
+	if x != nil {
+		return x.BaseCount
+	}
+	return 0
+}
+
+func (x *ShadowDiffItem) GetShadowCount() uint64 {
+	if x != nil {
+		return x.ShadowCount
+	}
+	return 0
+}
+
+func (x *ShadowDiffItem) GetMatched() uint64 {
+	if x != nil {
+		return x.Matched
+	}
+	return 0
+}
+
+func (x *ShadowDiffItem) GetDifferent() uint64 {
+	if x != nil {
+		return x.Different
+	}
+	return 0
+}
+
+func (x *ShadowDiffItem) GetMissing() uint64 {
+	if x != nil {
+		return x.Missing
+	}
+	return 0
+}
+
+func (x *ShadowDiffItem) GetExtra() uint64 {
+	if x != nil {
+		return x.Extra
+	}
+	return 0
+}
+
+func (x *ShadowDiffItem) GetSamples() []*ShadowMismatch {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type ShadowMismatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Chain         string                 `protobuf:"bytes,2,opt,name=chain,proto3" json:"chain,omitempty"`
+	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	BlockNumber   uint64                 `protobuf:"varint,5,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Fields        []string               `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields,omitempty"`
+	Base          *structpb.Struct       `protobuf:"bytes,7,opt,name=base,proto3" json:"base,omitempty"`
+	Shadow        *structpb.Struct       `protobuf:"bytes,8,opt,name=shadow,proto3" json:"shadow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShadowMismatch) Reset() {
+	*x = ShadowMismatch{}
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShadowMismatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShadowMismatch) ProtoMessage() {}
+
+func (x *ShadowMismatch) ProtoReflect() protoreflect.Message {
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShadowMismatch.ProtoReflect.Descriptor instead.
+func (*ShadowMismatch) Descriptor() ([]byte, []int) {
+	return file_service_processor_protos_processor_service_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ShadowMismatch) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ShadowMismatch) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+func (x *ShadowMismatch) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ShadowMismatch) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ShadowMismatch) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *ShadowMismatch) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *ShadowMismatch) GetBase() *structpb.Struct {
+	if x != nil {
+		return x.Base
+	}
+	return nil
+}
+
+func (x *ShadowMismatch) GetShadow() *structpb.Struct {
+	if x != nil {
+		return x.Shadow
+	}
+	return nil
+}
+
 type UploadPayload_ObjectPayload struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PutUrl        string                 `protobuf:"bytes,1,opt,name=put_url,json=putUrl,proto3" json:"put_url,omitempty"`
@@ -3524,7 +3996,7 @@ type UploadPayload_ObjectPayload struct {
 
 func (x *UploadPayload_ObjectPayload) Reset() {
 	*x = UploadPayload_ObjectPayload{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[45]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3536,7 +4008,7 @@ func (x *UploadPayload_ObjectPayload) String() string {
 func (*UploadPayload_ObjectPayload) ProtoMessage() {}
 
 func (x *UploadPayload_ObjectPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[45]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3593,7 +4065,7 @@ type UploadPayload_WalrusPayload struct {
 
 func (x *UploadPayload_WalrusPayload) Reset() {
 	*x = UploadPayload_WalrusPayload{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[46]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3605,7 +4077,7 @@ func (x *UploadPayload_WalrusPayload) String() string {
 func (*UploadPayload_WalrusPayload) ProtoMessage() {}
 
 func (x *UploadPayload_WalrusPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[46]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3668,7 +4140,7 @@ type UploadPayload_IpfsPayload struct {
 
 func (x *UploadPayload_IpfsPayload) Reset() {
 	*x = UploadPayload_IpfsPayload{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[47]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3680,7 +4152,7 @@ func (x *UploadPayload_IpfsPayload) String() string {
 func (*UploadPayload_IpfsPayload) ProtoMessage() {}
 
 func (x *UploadPayload_IpfsPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[47]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3734,7 +4206,7 @@ type ChainState_Status struct {
 
 func (x *ChainState_Status) Reset() {
 	*x = ChainState_Status{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[52]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3746,7 +4218,7 @@ func (x *ChainState_Status) String() string {
 func (*ChainState_Status) ProtoMessage() {}
 
 func (x *ChainState_Status) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[52]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3811,7 +4283,7 @@ type GetProcessorStatusResponse_ProcessorEx struct {
 
 func (x *GetProcessorStatusResponse_ProcessorEx) Reset() {
 	*x = GetProcessorStatusResponse_ProcessorEx{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[53]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3823,7 +4295,7 @@ func (x *GetProcessorStatusResponse_ProcessorEx) String() string {
 func (*GetProcessorStatusResponse_ProcessorEx) ProtoMessage() {}
 
 func (x *GetProcessorStatusResponse_ProcessorEx) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[53]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4038,7 +4510,7 @@ type GetProcessorStatusResponse_ProcessorStatus struct {
 
 func (x *GetProcessorStatusResponse_ProcessorStatus) Reset() {
 	*x = GetProcessorStatusResponse_ProcessorStatus{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[54]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4050,7 +4522,7 @@ func (x *GetProcessorStatusResponse_ProcessorStatus) String() string {
 func (*GetProcessorStatusResponse_ProcessorStatus) ProtoMessage() {}
 
 func (x *GetProcessorStatusResponse_ProcessorStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[54]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4096,7 +4568,7 @@ type GetLogsResponse_Log struct {
 
 func (x *GetLogsResponse_Log) Reset() {
 	*x = GetLogsResponse_Log{}
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[55]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4108,7 +4580,7 @@ func (x *GetLogsResponse_Log) String() string {
 func (*GetLogsResponse_Log) ProtoMessage() {}
 
 func (x *GetLogsResponse_Log) ProtoReflect() protoreflect.Message {
-	mi := &file_service_processor_protos_processor_service_proto_msgTypes[55]
+	mi := &file_service_processor_protos_processor_service_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4572,7 +5044,55 @@ const file_service_processor_protos_processor_service_proto_rawDesc = "" +
 	"\x06Action\x12\b\n" +
 	"\x04NONE\x10\x00\x12\r\n" +
 	"\tRESTARTED\x10\x01\x12\v\n" +
-	"\aCREATED\x10\x02*Q\n" +
+	"\aCREATED\x10\x02\"5\n" +
+	"\x10ShadowRunRequest\x12!\n" +
+	"\fprocessor_id\x18\x01 \x01(\tR\vprocessorId\"O\n" +
+	"\x14DiffShadowRunRequest\x12!\n" +
+	"\fprocessor_id\x18\x01 \x01(\tR\vprocessorId\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"\xfc\x03\n" +
+	"\tShadowRun\x12.\n" +
+	"\x13shadow_processor_id\x18\x01 \x01(\tR\x11shadowProcessorId\x12*\n" +
+	"\x11base_processor_id\x18\x02 \x01(\tR\x0fbaseProcessorId\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x03 \x01(\tR\tprojectId\x12T\n" +
+	"\x0estop_at_blocks\x18\x04 \x03(\v2..processor_service.ShadowRun.StopAtBlocksEntryR\fstopAtBlocks\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12;\n" +
+	"\x06report\x18\t \x01(\v2#.processor_service.ShadowDiffReportR\x06report\x1a?\n" +
+	"\x11StopAtBlocksEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\x8b\x03\n" +
+	"\x10ShadowDiffReport\x12=\n" +
+	"\bentities\x18\x01 \x03(\v2!.processor_service.ShadowDiffItemR\bentities\x12;\n" +
+	"\ametrics\x18\x02 \x03(\v2!.processor_service.ShadowDiffItemR\ametrics\x12s\n" +
+	"\x16first_divergent_blocks\x18\x03 \x03(\v2=.processor_service.ShadowDiffReport.FirstDivergentBlocksEntryR\x14firstDivergentBlocks\x12=\n" +
+	"\fgenerated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\x1aG\n" +
+	"\x19FirstDivergentBlocksEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\x8b\x02\n" +
+	"\x0eShadowDiffItem\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"base_count\x18\x02 \x01(\x04R\tbaseCount\x12!\n" +
+	"\fshadow_count\x18\x03 \x01(\x04R\vshadowCount\x12\x18\n" +
+	"\amatched\x18\x04 \x01(\x04R\amatched\x12\x1c\n" +
+	"\tdifferent\x18\x05 \x01(\x04R\tdifferent\x12\x18\n" +
+	"\amissing\x18\x06 \x01(\x04R\amissing\x12\x14\n" +
+	"\x05extra\x18\a \x01(\x04R\x05extra\x12;\n" +
+	"\asamples\x18\b \x03(\v2!.processor_service.ShadowMismatchR\asamples\"\x9f\x02\n" +
+	"\x0eShadowMismatch\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05chain\x18\x02 \x01(\tR\x05chain\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12!\n" +
+	"\fblock_number\x18\x05 \x01(\x04R\vblockNumber\x12\x16\n" +
+	"\x06fields\x18\x06 \x03(\tR\x06fields\x12+\n" +
+	"\x04base\x18\a \x01(\v2\x17.google.protobuf.StructR\x04base\x12/\n" +
+	"\x06shadow\x18\b \x01(\v2\x17.google.protobuf.StructR\x06shadow*Q\n" +
 	"\rStorageEngine\x12\v\n" +
 	"\aDEFAULT\x10\x00\x12\x06\n" +
 	"\x02S3\x10\x01\x12\a\n" +
@@ -4645,7 +5165,7 @@ const file_service_processor_protos_processor_service_proto_rawDesc = "" +
 	"\x11FinishBatchUpload\x12+.processor_service.FinishBatchUploadRequest\x1a,.processor_service.FinishBatchUploadResponse\"T\x92\xb5\x18\x0f\n" +
 	"\rproject:write\xfa\xd2\xe4\x93\x02\n" +
 	"\x12\bINTERNAL\x82\xd3\xe4\x93\x02+:\x01*\"&/api/v1/processors/finish_batch_upload\x1a\x0e\x92A\v\n" +
-	"\tProcessor2\xa3\x06\n" +
+	"\tProcessor2\xf7\t\n" +
 	"\x17ProcessorRuntimeService\x12\x87\x01\n" +
 	"\fRunProcessor\x12&.processor_service.RunProcessorRequest\x1a\x1c.processor_service.Processor\"1\xfa\xd2\xe4\x93\x02\n" +
 	"\x12\bINTERNAL\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/api/v1/processors/run\x12\x84\x01\n" +
@@ -4656,7 +5176,13 @@ const file_service_processor_protos_processor_service_proto_rawDesc = "" +
 	"\aGetLogs\x12!.processor_service.GetLogsRequest\x1a\".processor_service.GetLogsResponse\"A\xfa\xd2\xe4\x93\x02\n" +
 	"\x12\bINTERNAL\x82\xd3\xe4\x93\x02+:\x01*\"&/api/v1/processors/{processor_id}/logs\x12\xb9\x01\n" +
 	"\x12ReconcileProcessor\x12,.processor_service.ReconcileProcessorRequest\x1a-.processor_service.ReconcileProcessorResponse\"F\xfa\xd2\xe4\x93\x02\n" +
-	"\x12\bINTERNAL\x82\xd3\xe4\x93\x020:\x01*\"+/api/v1/processors/{processor_id}/reconcileB0Z.sentioxyz/sentio-core/service/processor/protosb\x06proto3"
+	"\x12\bINTERNAL\x82\xd3\xe4\x93\x020:\x01*\"+/api/v1/processors/{processor_id}/reconcile\x12\x98\x01\n" +
+	"\x0eStartShadowRun\x12#.processor_service.ShadowRunRequest\x1a\x1c.processor_service.ShadowRun\"C\xfa\xd2\xe4\x93\x02\n" +
+	"\x12\bINTERNAL\x82\xd3\xe4\x93\x02-:\x01*\"(/api/v1/processors/{processor_id}/shadow\x12\x93\x01\n" +
+	"\fGetShadowRun\x12#.processor_service.ShadowRunRequest\x1a\x1c.processor_service.ShadowRun\"@\xfa\xd2\xe4\x93\x02\n" +
+	"\x12\bINTERNAL\x82\xd3\xe4\x93\x02*\x12(/api/v1/processors/{processor_id}/shadow\x12\xa0\x01\n" +
+	"\rDiffShadowRun\x12'.processor_service.DiffShadowRunRequest\x1a\x1c.processor_service.ShadowRun\"H\xfa\xd2\xe4\x93\x02\n" +
+	"\x12\bINTERNAL\x82\xd3\xe4\x93\x022:\x01*\"-/api/v1/processors/{processor_id}/shadow/diffB0Z.sentioxyz/sentio-core/service/processor/protosb\x06proto3"
 
 var (
 	file_service_processor_protos_processor_service_proto_rawDescOnce sync.Once
@@ -4671,13 +5197,13 @@ func file_service_processor_protos_processor_service_proto_rawDescGZIP() []byte
 }
 
 var file_service_processor_protos_processor_service_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
-var file_service_processor_protos_processor_service_proto_msgTypes = make([]protoimpl.MessageInfo, 56)
+var file_service_processor_protos_processor_service_proto_msgTypes = make([]protoimpl.MessageInfo, 64)
 var file_service_processor_protos_processor_service_proto_goTypes = []any{
-	(StorageEngine)(0),           // 0: processor_service.StorageEngine
-	(FileType)(0),                // 1: processor_service.FileType
-	(ProcessorVersionState)(0),   // 2: processor_service.ProcessorVersionState
-	(ReasonKind)(0),              // 3: processor_service.ReasonKind
-	(ChainState_Status_State)(0), // 4: processor_service.ChainState.Status.State
+	(StorageEngine)(0),                                    // 0: processor_service.StorageEngine
+	(FileType)(0),                                         // 1: processor_service.FileType
+	(ProcessorVersionState)(0),                            // 2: processor_service.ProcessorVersionState
+	(ReasonKind)(0),                                       // 3: processor_service.ReasonKind
+	(ChainState_Status_State)(0),                          // 4: processor_service.ChainState.Status.State
 	(GetProcessorStatusRequestV2_VersionSelector)(0),      // 5: processor_service.GetProcessorStatusRequestV2.VersionSelector
 	(GetProcessorStatusResponse_ProcessorStatus_State)(0), // 6: processor_service.GetProcessorStatusResponse.ProcessorStatus.State
 	(ReconcileProcessorResponse_Action)(0),                // 7: processor_service.ReconcileProcessorResponse.Action
@@ -4724,149 +5250,175 @@ var file_service_processor_protos_processor_service_proto_goTypes = []any{
 	(*GetLogsResponse)(nil),                               // 48: processor_service.GetLogsResponse
 	(*ReconcileProcessorRequest)(nil),                     // 49: processor_service.ReconcileProcessorRequest
 	(*ReconcileProcessorResponse)(nil),                    // 50: processor_service.ReconcileProcessorResponse
-	nil,                                                   // 51: processor_service.FinishUploadRequest.RollbackEntry
-	nil,                                                   // 52: processor_service.InitBatchUploadRequest.FileTypesEntry
-	(*UploadPayload_ObjectPayload)(nil),                   // 53: processor_service.UploadPayload.ObjectPayload
-	(*UploadPayload_WalrusPayload)(nil),                   // 54: processor_service.UploadPayload.WalrusPayload
-	(*UploadPayload_IpfsPayload)(nil),                     // 55: processor_service.UploadPayload.IpfsPayload
-	nil,                                                   // 56: processor_service.InitBatchUploadResponse.PayloadsEntry
-	nil,                                                   // 57: processor_service.FinishBatchUploadRequest.Sha256MapEntry
-	nil,                                                   // 58: processor_service.FinishBatchUploadRequest.RollbackEntry
-	nil,                                                   // 59: processor_service.FinishBatchUploadRequest.PayloadsEntry
-	(*ChainState_Status)(nil),                             // 60: processor_service.ChainState.Status
-	(*GetProcessorStatusResponse_ProcessorEx)(nil),        // 61: processor_service.GetProcessorStatusResponse.ProcessorEx
-	(*GetProcessorStatusResponse_ProcessorStatus)(nil),    // 62: processor_service.GetProcessorStatusResponse.ProcessorStatus
-	(*GetLogsResponse_Log)(nil),                           // 63: processor_service.GetLogsResponse.Log
-	(*protos.Project)(nil),                                // 64: common.Project
-	(*timestamppb.Timestamp)(nil),                         // 65: google.protobuf.Timestamp
-	(*protos.Any)(nil),                                    // 66: common.Any
-	(*protos.ErrorRecord)(nil),                            // 67: common.ErrorRecord
-	(*protos.UserInfo)(nil),                               // 68: common.UserInfo
-	(*structpb.Struct)(nil),                               // 69: google.protobuf.Struct
-	(*protos.ProjectVariables)(nil),                       // 70: common.ProjectVariables
-	(*emptypb.Empty)(nil),                                 // 71: google.protobuf.Empty
+	(*ShadowRunRequest)(nil),                              // 51: processor_service.ShadowRunRequest
+	(*DiffShadowRunRequest)(nil),                          // 52: processor_service.DiffShadowRunRequest
+	(*ShadowRun)(nil),                                     // 53: processor_service.ShadowRun
+	(*ShadowDiffReport)(nil),                              // 54: processor_service.ShadowDiffReport
+	(*ShadowDiffItem)(nil),                                // 55: processor_service.ShadowDiffItem
+	(*ShadowMismatch)(nil),                                // 56: processor_service.ShadowMismatch
+	nil,                                                   // 57: processor_service.FinishUploadRequest.RollbackEntry
+	nil,                                                   // 58: processor_service.InitBatchUploadRequest.FileTypesEntry
+	(*UploadPayload_ObjectPayload)(nil),                   // 59: processor_service.UploadPayload.ObjectPayload
+	(*UploadPayload_WalrusPayload)(nil),                   // 60: processor_service.UploadPayload.WalrusPayload
+	(*UploadPayload_IpfsPayload)(nil),                     // 61: processor_service.UploadPayload.IpfsPayload
+	nil,                                                   // 62: processor_service.InitBatchUploadResponse.PayloadsEntry
+	nil,                                                   // 63: processor_service.FinishBatchUploadRequest.Sha256MapEntry
+	nil,                                                   // 64: processor_service.FinishBatchUploadRequest.RollbackEntry
+	nil,                                                   // 65: processor_service.FinishBatchUploadRequest.PayloadsEntry
+	(*ChainState_Status)(nil),                             // 66: processor_service.ChainState.Status
+	(*GetProcessorStatusResponse_ProcessorEx)(nil),        // 67: processor_service.GetProcessorStatusResponse.ProcessorEx
+	(*GetProcessorStatusResponse_ProcessorStatus)(nil),    // 68: processor_service.GetProcessorStatusResponse.ProcessorStatus
+	(*GetLogsResponse_Log)(nil),                           // 69: processor_service.GetLogsResponse.Log
+	nil,                                                   // 70: processor_service.ShadowRun.StopAtBlocksEntry
+	nil,                                                   // 71: processor_service.ShadowDiffReport.FirstDivergentBlocksEntry
+	(*protos.Project)(nil),                                // 72: common.Project
+	(*timestamppb.Timestamp)(nil),                         // 73: google.protobuf.Timestamp
+	(*protos.Any)(nil),                                    // 74: common.Any
+	(*structpb.Struct)(nil),                               // 75: google.protobuf.Struct
+	(*protos.ErrorRecord)(nil),                            // 76: common.ErrorRecord
+	(*protos.UserInfo)(nil),                               // 77: common.UserInfo
+	(*protos.ProjectVariables)(nil),                       // 78: common.ProjectVariables
+	(*emptypb.Empty)(nil),                                 // 79: google.protobuf.Empty
 }
 var file_service_processor_protos_processor_service_proto_depIdxs = []int32{
-	51, // 0: processor_service.FinishUploadRequest.rollback:type_name -> processor_service.FinishUploadRequest.RollbackEntry
-	10, // 1: processor_service.FinishUploadRequest.network_overrides:type_name -> processor_service.NetworkOverride
-	0,  // 2: processor_service.InitBatchUploadRequest.engine:type_name -> processor_service.StorageEngine
-	52, // 3: processor_service.InitBatchUploadRequest.file_types:type_name -> processor_service.InitBatchUploadRequest.FileTypesEntry
-	53, // 4: processor_service.UploadPayload.object:type_name -> processor_service.UploadPayload.ObjectPayload
-	54, // 5: processor_service.UploadPayload.walrus:type_name -> processor_service.UploadPayload.WalrusPayload
-	55, // 6: processor_service.UploadPayload.ipfs:type_name -> processor_service.UploadPayload.IpfsPayload
-	1,  // 7: processor_service.UploadPayload.file_type:type_name -> processor_service.FileType
-	0,  // 8: processor_service.InitBatchUploadResponse.engine:type_name -> processor_service.StorageEngine
-	56, // 9: processor_service.InitBatchUploadResponse.payloads:type_name -> processor_service.InitBatchUploadResponse.PayloadsEntry
-	57, // 10: processor_service.FinishBatchUploadRequest.sha256_map:type_name -> processor_service.FinishBatchUploadRequest.Sha256MapEntry
-	58, // 11: processor_service.FinishBatchUploadRequest.rollback:type_name -> processor_service.FinishBatchUploadRequest.RollbackEntry
-	10, // 12: processor_service.FinishBatchUploadRequest.network_overrides:type_name -> processor_service.NetworkOverride
-	0,  // 13: processor_service.FinishBatchUploadRequest.engine:type_name -> processor_service.StorageEngine
-	59, // 14: processor_service.FinishBatchUploadRequest.payloads:type_name -> processor_service.FinishBatchUploadRequest.PayloadsEntry
-	28, // 15: processor_service.GetProcessorsResponse.processors:type_name -> processor_service.Processor
-	28, // 16: processor_service.GetProcessorResponse.processor:type_name -> processor_service.Processor
-	28, // 17: processor_service.GetProcessorWithProjectResponse.processor:type_name -> processor_service.Processor
-	64, // 18: processor_service.GetProcessorWithProjectResponse.project:type_name -> common.Project
-	27, // 19: processor_service.UpdateChainProcessorStatusRequest.chain_state:type_name -> processor_service.ChainState
-	60, // 20: processor_service.ChainState.status:type_name -> processor_service.ChainState.Status
-	65, // 21: processor_service.ChainState.updated_at:type_name -> google.protobuf.Timestamp
-	27, // 22: processor_service.Processor.chain_states:type_name -> processor_service.ChainState
-	2,  // 23: processor_service.Processor.version_state:type_name -> processor_service.ProcessorVersionState
-	10, // 24: processor_service.Processor.network_overrides:type_name -> processor_service.NetworkOverride
-	65, // 25: processor_service.Processor.pause_at:type_name -> google.protobuf.Timestamp
-	65, // 26: processor_service.ProcessorUpgradeHistory.uploaded_at:type_name -> google.protobuf.Timestamp
-	65, // 27: processor_service.ProcessorUpgradeHistory.obsolete_at:type_name -> google.protobuf.Timestamp
-	28, // 28: processor_service.ProcessorUpgradeHistory.snapshot:type_name -> processor_service.Processor
-	65, // 29: processor_service.ProcessorStateHistory.created_at:type_name -> google.protobuf.Timestamp
-	3,  // 30: processor_service.ProcessorStateHistory.reason_kind:type_name -> processor_service.ReasonKind
-	30, // 31: processor_service.GetProcessorStateHistoryResponse.histories:type_name -> processor_service.ProcessorStateHistory
-	5,  // 32: processor_service.GetProcessorStatusRequestV2.version:type_name -> processor_service.GetProcessorStatusRequestV2.VersionSelector
-	61, // 33: processor_service.GetProcessorStatusResponse.processors:type_name -> processor_service.GetProcessorStatusResponse.ProcessorEx
-	28, // 34: processor_service.RemoveProcessorResponse.deleted:type_name -> processor_service.Processor
-	29, // 35: processor_service.GetProcessorUpgradeHistoryResponse.histories:type_name -> processor_service.ProcessorUpgradeHistory
-	3,  // 36: processor_service.PauseProcessorRequest.reason_kind:type_name -> processor_service.ReasonKind
-	3,  // 37: processor_service.ResumeProcessorInternalRequest.reason_kind:type_name -> processor_service.ReasonKind
-	65, // 38: processor_service.RunProcessorRequest.created_at:type_name -> google.protobuf.Timestamp
-	66, // 39: processor_service.GetLogsRequest.until:type_name -> common.Any
-	63, // 40: processor_service.GetLogsResponse.logs:type_name -> processor_service.GetLogsResponse.Log
-	66, // 41: processor_service.GetLogsResponse.until:type_name -> common.Any
-	7,  // 42: processor_service.ReconcileProcessorResponse.action:type_name -> processor_service.ReconcileProcessorResponse.Action
-	1,  // 43: processor_service.InitBatchUploadRequest.FileTypesEntry.value:type_name -> processor_service.FileType
-	14, // 44: processor_service.InitBatchUploadResponse.PayloadsEntry.value:type_name -> processor_service.UploadPayload
-	14, // 45: processor_service.FinishBatchUploadRequest.PayloadsEntry.value:type_name -> processor_service.UploadPayload
-	4,  // 46: processor_service.ChainState.Status.state:type_name -> processor_service.ChainState.Status.State
-	67, // 47: processor_service.ChainState.Status.error_record:type_name -> common.ErrorRecord
-	27, // 48: processor_service.GetProcessorStatusResponse.ProcessorEx.states:type_name -> processor_service.ChainState
-	68, // 49: processor_service.GetProcessorStatusResponse.ProcessorEx.uploaded_by:type_name -> common.UserInfo
-	65, // 50: processor_service.GetProcessorStatusResponse.ProcessorEx.uploaded_at:type_name -> google.protobuf.Timestamp
-	62, // 51: processor_service.GetProcessorStatusResponse.ProcessorEx.processor_status:type_name -> processor_service.GetProcessorStatusResponse.ProcessorStatus
-	2,  // 52: processor_service.GetProcessorStatusResponse.ProcessorEx.version_state:type_name -> processor_service.ProcessorVersionState
-	65, // 53: processor_service.GetProcessorStatusResponse.ProcessorEx.pause_at:type_name -> google.protobuf.Timestamp
-	10, // 54: processor_service.GetProcessorStatusResponse.ProcessorEx.network_overrides:type_name -> processor_service.NetworkOverride
-	6,  // 55: processor_service.GetProcessorStatusResponse.ProcessorStatus.state:type_name -> processor_service.GetProcessorStatusResponse.ProcessorStatus.State
-	67, // 56: processor_service.GetProcessorStatusResponse.ProcessorStatus.error_record:type_name -> common.ErrorRecord
-	65, // 57: processor_service.GetLogsResponse.Log.timestamp:type_name -> google.protobuf.Timestamp
-	69, // 58: processor_service.GetLogsResponse.Log.attributes:type_name -> google.protobuf.Struct
-	20, // 59: processor_service.ProcessorService.GetProcessors:input_type -> processor_service.GetProcessorsRequest
-	22, // 60: processor_service.ProcessorService.GetProcessor:input_type -> processor_service.GetProcessorRequest
-	22, // 61: processor_service.ProcessorService.GetProcessorWithProject:input_type -> processor_service.GetProcessorRequest
-	41, // 62: processor_service.ProcessorService.GetProjectVariables:input_type -> processor_service.GetProjectVariablesRequest
-	42, // 63: processor_service.ProcessorService.SetProcessorEntitySchema:input_type -> processor_service.SetProcessorEntitySchemaRequest
-	25, // 64: processor_service.ProcessorService.UpdateChainProcessorStatus:input_type -> processor_service.UpdateChainProcessorStatusRequest
-	18, // 65: processor_service.ProcessorService.DownloadProcessor:input_type -> processor_service.DownloadProcessorRequest
-	33, // 66: processor_service.ProcessorService.GetProcessorStatusInternal:input_type -> processor_service.GetProcessorStatusRequest
-	43, // 67: processor_service.ProcessorService.PauseProcessorInternal:input_type -> processor_service.PauseProcessorRequest
-	44, // 68: processor_service.ProcessorService.ResumeProcessorInternal:input_type -> processor_service.ResumeProcessorInternalRequest
-	34, // 69: processor_service.ProcessorService.GetProcessorStatusV2:input_type -> processor_service.GetProcessorStatusRequestV2
-	36, // 70: processor_service.ProcessorService.RemoveProcessor:input_type -> processor_service.ProcessorIdRequest
-	39, // 71: processor_service.ProcessorService.GetProcessorUpgradeHistories:input_type -> processor_service.GetProcessorUpgradeHistoryRequest
-	31, // 72: processor_service.ProcessorService.GetProcessorStateHistories:input_type -> processor_service.GetProcessorStateHistoryRequest
-	43, // 73: processor_service.ProcessorService.PauseProcessor:input_type -> processor_service.PauseProcessorRequest
-	22, // 74: processor_service.ProcessorService.ResumeProcessor:input_type -> processor_service.GetProcessorRequest
-	22, // 75: processor_service.ProcessorService.SetVersionActive:input_type -> processor_service.GetProcessorRequest
-	38, // 76: processor_service.ProcessorService.ActivatePendingVersion:input_type -> processor_service.ActivatePendingRequest
-	22, // 77: processor_service.ProcessorService.RestartProcessor:input_type -> processor_service.GetProcessorRequest
-	8,  // 78: processor_service.ProcessorService.InitUpload:input_type -> processor_service.InitUploadRequest
-	11, // 79: processor_service.ProcessorService.FinishUpload:input_type -> processor_service.FinishUploadRequest
-	13, // 80: processor_service.ProcessorService.InitBatchUpload:input_type -> processor_service.InitBatchUploadRequest
-	16, // 81: processor_service.ProcessorService.FinishBatchUpload:input_type -> processor_service.FinishBatchUploadRequest
-	45, // 82: processor_service.ProcessorRuntimeService.RunProcessor:input_type -> processor_service.RunProcessorRequest
-	46, // 83: processor_service.ProcessorRuntimeService.StopProcessor:input_type -> processor_service.StopProcessorRequest
-	33, // 84: processor_service.ProcessorRuntimeService.GetProcessorStatus:input_type -> processor_service.GetProcessorStatusRequest
-	47, // 85: processor_service.ProcessorRuntimeService.GetLogs:input_type -> processor_service.GetLogsRequest
-	49, // 86: processor_service.ProcessorRuntimeService.ReconcileProcessor:input_type -> processor_service.ReconcileProcessorRequest
-	21, // 87: processor_service.ProcessorService.GetProcessors:output_type -> processor_service.GetProcessorsResponse
-	23, // 88: processor_service.ProcessorService.GetProcessor:output_type -> processor_service.GetProcessorResponse
-	24, // 89: processor_service.ProcessorService.GetProcessorWithProject:output_type -> processor_service.GetProcessorWithProjectResponse
-	70, // 90: processor_service.ProcessorService.GetProjectVariables:output_type -> common.ProjectVariables
-	71, // 91: processor_service.ProcessorService.SetProcessorEntitySchema:output_type -> google.protobuf.Empty
-	26, // 92: processor_service.ProcessorService.UpdateChainProcessorStatus:output_type -> processor_service.UpdateChainProcessorStatusResponse
-	19, // 93: processor_service.ProcessorService.DownloadProcessor:output_type -> processor_service.DownloadProcessorResponse
-	35, // 94: processor_service.ProcessorService.GetProcessorStatusInternal:output_type -> processor_service.GetProcessorStatusResponse
-	71, // 95: processor_service.ProcessorService.PauseProcessorInternal:output_type -> google.protobuf.Empty
-	71, // 96: processor_service.ProcessorService.ResumeProcessorInternal:output_type -> google.protobuf.Empty
-	35, // 97: processor_service.ProcessorService.GetProcessorStatusV2:output_type -> processor_service.GetProcessorStatusResponse
-	37, // 98: processor_service.ProcessorService.RemoveProcessor:output_type -> processor_service.RemoveProcessorResponse
-	40, // 99: processor_service.ProcessorService.GetProcessorUpgradeHistories:output_type -> processor_service.GetProcessorUpgradeHistoryResponse
-	32, // 100: processor_service.ProcessorService.GetProcessorStateHistories:output_type -> processor_service.GetProcessorStateHistoryResponse
-	71, // 101: processor_service.ProcessorService.PauseProcessor:output_type -> google.protobuf.Empty
-	71, // 102: processor_service.ProcessorService.ResumeProcessor:output_type -> google.protobuf.Empty
-	71, // 103: processor_service.ProcessorService.SetVersionActive:output_type -> google.protobuf.Empty
-	71, // 104: processor_service.ProcessorService.ActivatePendingVersion:output_type -> google.protobuf.Empty
-	71, // 105: processor_service.ProcessorService.RestartProcessor:output_type -> google.protobuf.Empty
-	9,  // 106: processor_service.ProcessorService.InitUpload:output_type -> processor_service.InitUploadResponse
-	12, // 107: processor_service.ProcessorService.FinishUpload:output_type -> processor_service.FinishUploadResponse
-	15, // 108: processor_service.ProcessorService.InitBatchUpload:output_type -> processor_service.InitBatchUploadResponse
-	17, // 109: processor_service.ProcessorService.FinishBatchUpload:output_type -> processor_service.FinishBatchUploadResponse
-	28, // 110: processor_service.ProcessorRuntimeService.RunProcessor:output_type -> processor_service.Processor
-	71, // 111: processor_service.ProcessorRuntimeService.StopProcessor:output_type -> google.protobuf.Empty
-	35, // 112: processor_service.ProcessorRuntimeService.GetProcessorStatus:output_type -> processor_service.GetProcessorStatusResponse
-	48, // 113: processor_service.ProcessorRuntimeService.GetLogs:output_type -> processor_service.GetLogsResponse
-	50, // 114: processor_service.ProcessorRuntimeService.ReconcileProcessor:output_type -> processor_service.ReconcileProcessorResponse
-	87, // [87:115] is the sub-list for method output_type
-	59, // [59:87] is the sub-list for method input_type
-	59, // [59:59] is the sub-list for extension type_name
-	59, // [59:59] is the sub-list for extension extendee
-	0,  // [0:59] is the sub-list for field type_name
+	57,  // 0: processor_service.FinishUploadRequest.rollback:type_name -> processor_service.FinishUploadRequest.RollbackEntry
+	10,  // 1: processor_service.FinishUploadRequest.network_overrides:type_name -> processor_service.NetworkOverride
+	0,   // 2: processor_service.InitBatchUploadRequest.engine:type_name -> processor_service.StorageEngine
+	58,  // 3: processor_service.InitBatchUploadRequest.file_types:type_name -> processor_service.InitBatchUploadRequest.FileTypesEntry
+	59,  // 4: processor_service.UploadPayload.object:type_name -> processor_service.UploadPayload.ObjectPayload
+	60,  // 5: processor_service.UploadPayload.walrus:type_name -> processor_service.UploadPayload.WalrusPayload
+	61,  // 6: processor_service.UploadPayload.ipfs:type_name -> processor_service.UploadPayload.IpfsPayload
+	1,   // 7: processor_service.UploadPayload.file_type:type_name -> processor_service.FileType
+	0,   // 8: processor_service.InitBatchUploadResponse.engine:type_name -> processor_service.StorageEngine
+	62,  // 9: processor_service.InitBatchUploadResponse.payloads:type_name -> processor_service.InitBatchUploadResponse.PayloadsEntry
+	63,  // 10: processor_service.FinishBatchUploadRequest.sha256_map:type_name -> processor_service.FinishBatchUploadRequest.Sha256MapEntry
+	64,  // 11: processor_service.FinishBatchUploadRequest.rollback:type_name -> processor_service.FinishBatchUploadRequest.RollbackEntry
+	10,  // 12: processor_service.FinishBatchUploadRequest.network_overrides:type_name -> processor_service.NetworkOverride
+	0,   // 13: processor_service.FinishBatchUploadRequest.engine:type_name -> processor_service.StorageEngine
+	65,  // 14: processor_service.FinishBatchUploadRequest.payloads:type_name -> processor_service.FinishBatchUploadRequest.PayloadsEntry
+	28,  // 15: processor_service.GetProcessorsResponse.processors:type_name -> processor_service.Processor
+	28,  // 16: processor_service.GetProcessorResponse.processor:type_name -> processor_service.Processor
+	28,  // 17: processor_service.GetProcessorWithProjectResponse.processor:type_name -> processor_service.Processor
+	72,  // 18: processor_service.GetProcessorWithProjectResponse.project:type_name -> common.Project
+	27,  // 19: processor_service.UpdateChainProcessorStatusRequest.chain_state:type_name -> processor_service.ChainState
+	66,  // 20: processor_service.ChainState.status:type_name -> processor_service.ChainState.Status
+	73,  // 21: processor_service.ChainState.updated_at:type_name -> google.protobuf.Timestamp
+	27,  // 22: processor_service.Processor.chain_states:type_name -> processor_service.ChainState
+	2,   // 23: processor_service.Processor.version_state:type_name -> processor_service.ProcessorVersionState
+	10,  // 24: processor_service.Processor.network_overrides:type_name -> processor_service.NetworkOverride
+	73,  // 25: processor_service.Processor.pause_at:type_name -> google.protobuf.Timestamp
+	73,  // 26: processor_service.ProcessorUpgradeHistory.uploaded_at:type_name -> google.protobuf.Timestamp
+	73,  // 27: processor_service.ProcessorUpgradeHistory.obsolete_at:type_name -> google.protobuf.Timestamp
+	28,  // 28: processor_service.ProcessorUpgradeHistory.snapshot:type_name -> processor_service.Processor
+	73,  // 29: processor_service.ProcessorStateHistory.created_at:type_name -> google.protobuf.Timestamp
+	3,   // 30: processor_service.ProcessorStateHistory.reason_kind:type_name -> processor_service.ReasonKind
+	30,  // 31: processor_service.GetProcessorStateHistoryResponse.histories:type_name -> processor_service.ProcessorStateHistory
+	5,   // 32: processor_service.GetProcessorStatusRequestV2.version:type_name -> processor_service.GetProcessorStatusRequestV2.VersionSelector
+	67,  // 33: processor_service.GetProcessorStatusResponse.processors:type_name -> processor_service.GetProcessorStatusResponse.ProcessorEx
+	28,  // 34: processor_service.RemoveProcessorResponse.deleted:type_name -> processor_service.Processor
+	29,  // 35: processor_service.GetProcessorUpgradeHistoryResponse.histories:type_name -> processor_service.ProcessorUpgradeHistory
+	3,   // 36: processor_service.PauseProcessorRequest.reason_kind:type_name -> processor_service.ReasonKind
+	3,   // 37: processor_service.ResumeProcessorInternalRequest.reason_kind:type_name -> processor_service.ReasonKind
+	73,  // 38: processor_service.RunProcessorRequest.created_at:type_name -> google.protobuf.Timestamp
+	74,  // 39: processor_service.GetLogsRequest.until:type_name -> common.Any
+	69,  // 40: processor_service.GetLogsResponse.logs:type_name -> processor_service.GetLogsResponse.Log
+	74,  // 41: processor_service.GetLogsResponse.until:type_name -> common.Any
+	7,   // 42: processor_service.ReconcileProcessorResponse.action:type_name -> processor_service.ReconcileProcessorResponse.Action
+	70,  // 43: processor_service.ShadowRun.stop_at_blocks:type_name -> processor_service.ShadowRun.StopAtBlocksEntry
+	73,  // 44: processor_service.ShadowRun.created_at:type_name -> google.protobuf.Timestamp
+	73,  // 45: processor_service.ShadowRun.updated_at:type_name -> google.protobuf.Timestamp
+	54,  // 46: processor_service.ShadowRun.report:type_name -> processor_service.ShadowDiffReport
+	55,  // 47: processor_service.ShadowDiffReport.entities:type_name -> processor_service.ShadowDiffItem
+	55,  // 48: processor_service.ShadowDiffReport.metrics:type_name -> processor_service.ShadowDiffItem
+	71,  // 49: processor_service.ShadowDiffReport.first_divergent_blocks:type_name -> processor_service.ShadowDiffReport.FirstDivergentBlocksEntry
+	73,  // 50: processor_service.ShadowDiffReport.generated_at:type_name -> google.protobuf.Timestamp
+	56,  // 51: processor_service.ShadowDiffItem.samples:type_name -> processor_service.ShadowMismatch
+	73,  // 52: processor_service.ShadowMismatch.timestamp:type_name -> google.protobuf.Timestamp
+	75,  // 53: processor_service.ShadowMismatch.base:type_name -> google.protobuf.Struct
+	75,  // 54: processor_service.ShadowMismatch.shadow:type_name -> google.protobuf.Struct
+	1,   // 55: processor_service.InitBatchUploadRequest.FileTypesEntry.value:type_name -> processor_service.FileType
+	14,  // 56: processor_service.InitBatchUploadResponse.PayloadsEntry.value:type_name -> processor_service.UploadPayload
+	14,  // 57: processor_service.FinishBatchUploadRequest.PayloadsEntry.value:type_name -> processor_service.UploadPayload
+	4,   // 58: processor_service.ChainState.Status.state:type_name -> processor_service.ChainState.Status.State
+	76,  // 59: processor_service.ChainState.Status.error_record:type_name -> common.ErrorRecord
+	27,  // 60: processor_service.GetProcessorStatusResponse.ProcessorEx.states:type_name -> processor_service.ChainState
+	77,  // 61: processor_service.GetProcessorStatusResponse.ProcessorEx.uploaded_by:type_name -> common.UserInfo
+	73,  // 62: processor_service.GetProcessorStatusResponse.ProcessorEx.uploaded_at:type_name -> google.protobuf.Timestamp
+	68,  // 63: processor_service.GetProcessorStatusResponse.ProcessorEx.processor_status:type_name -> processor_service.GetProcessorStatusResponse.ProcessorStatus
+	2,   // 64: processor_service.GetProcessorStatusResponse.ProcessorEx.version_state:type_name -> processor_service.ProcessorVersionState
+	73,  // 65: processor_service.GetProcessorStatusResponse.ProcessorEx.pause_at:type_name -> google.protobuf.Timestamp
+	10,  // 66: processor_service.GetProcessorStatusResponse.ProcessorEx.network_overrides:type_name -> processor_service.NetworkOverride
+	6,   // 67: processor_service.GetProcessorStatusResponse.ProcessorStatus.state:type_name -> processor_service.GetProcessorStatusResponse.ProcessorStatus.State
+	76,  // 68: processor_service.GetProcessorStatusResponse.ProcessorStatus.error_record:type_name -> common.ErrorRecord
+	73,  // 69: processor_service.GetLogsResponse.Log.timestamp:type_name -> google.protobuf.Timestamp
+	75,  // 70: processor_service.GetLogsResponse.Log.attributes:type_name -> google.protobuf.Struct
+	20,  // 71: processor_service.ProcessorService.GetProcessors:input_type -> processor_service.GetProcessorsRequest
+	22,  // 72: processor_service.ProcessorService.GetProcessor:input_type -> processor_service.GetProcessorRequest
+	22,  // 73: processor_service.ProcessorService.GetProcessorWithProject:input_type -> processor_service.GetProcessorRequest
+	41,  // 74: processor_service.ProcessorService.GetProjectVariables:input_type -> processor_service.GetProjectVariablesRequest
+	42,  // 75: processor_service.ProcessorService.SetProcessorEntitySchema:input_type -> processor_service.SetProcessorEntitySchemaRequest
+	25,  // 76: processor_service.ProcessorService.UpdateChainProcessorStatus:input_type -> processor_service.UpdateChainProcessorStatusRequest
+	18,  // 77: processor_service.ProcessorService.DownloadProcessor:input_type -> processor_service.DownloadProcessorRequest
+	33,  // 78: processor_service.ProcessorService.GetProcessorStatusInternal:input_type -> processor_service.GetProcessorStatusRequest
+	43,  // 79: processor_service.ProcessorService.PauseProcessorInternal:input_type -> processor_service.PauseProcessorRequest
+	44,  // 80: processor_service.ProcessorService.ResumeProcessorInternal:input_type -> processor_service.ResumeProcessorInternalRequest
+	34,  // 81: processor_service.ProcessorService.GetProcessorStatusV2:input_type -> processor_service.GetProcessorStatusRequestV2
+	36,  // 82: processor_service.ProcessorService.RemoveProcessor:input_type -> processor_service.ProcessorIdRequest
+	39,  // 83: processor_service.ProcessorService.GetProcessorUpgradeHistories:input_type -> processor_service.GetProcessorUpgradeHistoryRequest
+	31,  // 84: processor_service.ProcessorService.GetProcessorStateHistories:input_type -> processor_service.GetProcessorStateHistoryRequest
+	43,  // 85: processor_service.ProcessorService.PauseProcessor:input_type -> processor_service.PauseProcessorRequest
+	22,  // 86: processor_service.ProcessorService.ResumeProcessor:input_type -> processor_service.GetProcessorRequest
+	22,  // 87: processor_service.ProcessorService.SetVersionActive:input_type -> processor_service.GetProcessorRequest
+	38,  // 88: processor_service.ProcessorService.ActivatePendingVersion:input_type -> processor_service.ActivatePendingRequest
+	22,  // 89: processor_service.ProcessorService.RestartProcessor:input_type -> processor_service.GetProcessorRequest
+	8,   // 90: processor_service.ProcessorService.InitUpload:input_type -> processor_service.InitUploadRequest
+	11,  // 91: processor_service.ProcessorService.FinishUpload:input_type -> processor_service.FinishUploadRequest
+	13,  // 92: processor_service.ProcessorService.InitBatchUpload:input_type -> processor_service.InitBatchUploadRequest
+	16,  // 93: processor_service.ProcessorService.FinishBatchUpload:input_type -> processor_service.FinishBatchUploadRequest
+	45,  // 94: processor_service.ProcessorRuntimeService.RunProcessor:input_type -> processor_service.RunProcessorRequest
+	46,  // 95: processor_service.ProcessorRuntimeService.StopProcessor:input_type -> processor_service.StopProcessorRequest
+	33,  // 96: processor_service.ProcessorRuntimeService.GetProcessorStatus:input_type -> processor_service.GetProcessorStatusRequest
+	47,  // 97: processor_service.ProcessorRuntimeService.GetLogs:input_type -> processor_service.GetLogsRequest
+	49,  // 98: processor_service.ProcessorRuntimeService.ReconcileProcessor:input_type -> processor_service.ReconcileProcessorRequest
+	51,  // 99: processor_service.ProcessorRuntimeService.StartShadowRun:input_type -> processor_service.ShadowRunRequest
+	51,  // 100: processor_service.ProcessorRuntimeService.GetShadowRun:input_type -> processor_service.ShadowRunRequest
+	52,  // 101: processor_service.ProcessorRuntimeService.DiffShadowRun:input_type -> processor_service.DiffShadowRunRequest
+	21,  // 102: processor_service.ProcessorService.GetProcessors:output_type -> processor_service.GetProcessorsResponse
+	23,  // 103: processor_service.ProcessorService.GetProcessor:output_type -> processor_service.GetProcessorResponse
+	24,  // 104: processor_service.ProcessorService.GetProcessorWithProject:output_type -> processor_service.GetProcessorWithProjectResponse
+	78,  // 105: processor_service.ProcessorService.GetProjectVariables:output_type -> common.ProjectVariables
+	79,  // 106: processor_service.ProcessorService.SetProcessorEntitySchema:output_type -> google.protobuf.Empty
+	26,  // 107: processor_service.ProcessorService.UpdateChainProcessorStatus:output_type -> processor_service.UpdateChainProcessorStatusResponse
+	19,  // 108: processor_service.ProcessorService.DownloadProcessor:output_type -> processor_service.DownloadProcessorResponse
+	35,  // 109: processor_service.ProcessorService.GetProcessorStatusInternal:output_type -> processor_service.GetProcessorStatusResponse
+	79,  // 110: processor_service.ProcessorService.PauseProcessorInternal:output_type -> google.protobuf.Empty
+	79,  // 111: processor_service.ProcessorService.ResumeProcessorInternal:output_type -> google.protobuf.Empty
+	35,  // 112: processor_service.ProcessorService.GetProcessorStatusV2:output_type -> processor_service.GetProcessorStatusResponse
+	37,  // 113: processor_service.ProcessorService.RemoveProcessor:output_type -> processor_service.RemoveProcessorResponse
+	40,  // 114: processor_service.ProcessorService.GetProcessorUpgradeHistories:output_type -> processor_service.GetProcessorUpgradeHistoryResponse
+	32,  // 115: processor_service.ProcessorService.GetProcessorStateHistories:output_type -> processor_service.GetProcessorStateHistoryResponse
+	79,  // 116: processor_service.ProcessorService.PauseProcessor:output_type -> google.protobuf.Empty
+	79,  // 117: processor_service.ProcessorService.ResumeProcessor:output_type -> google.protobuf.Empty
+	79,  // 118: processor_service.ProcessorService.SetVersionActive:output_type -> google.protobuf.Empty
+	79,  // 119: processor_service.ProcessorService.ActivatePendingVersion:output_type -> google.protobuf.Empty
+	79,  // 120: processor_service.ProcessorService.RestartProcessor:output_type -> google.protobuf.Empty
+	9,   // 121: processor_service.ProcessorService.InitUpload:output_type -> processor_service.InitUploadResponse
+	12,  // 122: processor_service.ProcessorService.FinishUpload:output_type -> processor_service.FinishUploadResponse
+	15,  // 123: processor_service.ProcessorService.InitBatchUpload:output_type -> processor_service.InitBatchUploadResponse
+	17,  // 124: processor_service.ProcessorService.FinishBatchUpload:output_type -> processor_service.FinishBatchUploadResponse
+	28,  // 125: processor_service.ProcessorRuntimeService.RunProcessor:output_type -> processor_service.Processor
+	79,  // 126: processor_service.ProcessorRuntimeService.StopProcessor:output_type -> google.protobuf.Empty
+	35,  // 127: processor_service.ProcessorRuntimeService.GetProcessorStatus:output_type -> processor_service.GetProcessorStatusResponse
+	48,  // 128: processor_service.ProcessorRuntimeService.GetLogs:output_type -> processor_service.GetLogsResponse
+	50,  // 129: processor_service.ProcessorRuntimeService.ReconcileProcessor:output_type -> processor_service.ReconcileProcessorResponse
+	53,  // 130: processor_service.ProcessorRuntimeService.StartShadowRun:output_type -> processor_service.ShadowRun
+	53,  // 131: processor_service.ProcessorRuntimeService.GetShadowRun:output_type -> processor_service.ShadowRun
+	53,  // 132: processor_service.ProcessorRuntimeService.DiffShadowRun:output_type -> processor_service.ShadowRun
+	102, // [102:133] is the sub-list for method output_type
+	71,  // [71:102] is the sub-list for method input_type
+	71,  // [71:71] is the sub-list for extension type_name
+	71,  // [71:71] is the sub-list for extension extendee
+	0,   // [0:71] is the sub-list for field type_name
 }
 
 func init() { file_service_processor_protos_processor_service_proto_init() }
@@ -4891,7 +5443,7 @@ func file_service_processor_protos_processor_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_service_processor_protos_processor_service_proto_rawDesc), len(file_service_processor_protos_processor_service_proto_rawDesc)),
 			NumEnums:      8,
-			NumMessages:   56,
+			NumMessages:   64,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -964,6 +964,9 @@ const (
 	ProcessorRuntimeService_GetProcessorStatus_FullMethodName = "/processor_service.ProcessorRuntimeService/GetProcessorStatus"
 	ProcessorRuntimeService_GetLogs_FullMethodName            = "/processor_service.ProcessorRuntimeService/GetLogs"
 	ProcessorRuntimeService_ReconcileProcessor_FullMethodName = "/processor_service.ProcessorRuntimeService/ReconcileProcessor"
+	ProcessorRuntimeService_StartShadowRun_FullMethodName     = "/processor_service.ProcessorRuntimeService/StartShadowRun"
+	ProcessorRuntimeService_GetShadowRun_FullMethodName       = "/processor_service.ProcessorRuntimeService/GetShadowRun"
+	ProcessorRuntimeService_DiffShadowRun_FullMethodName      = "/processor_service.ProcessorRuntimeService/DiffShadowRun"
 )
 
 // ProcessorRuntimeServiceClient is the client API for ProcessorRuntimeService service.
@@ -975,6 +978,9 @@ type ProcessorRuntimeServiceClient interface {
 	GetProcessorStatus(ctx context.Context, in *GetProcessorStatusRequest, opts ...grpc.CallOption) (*GetProcessorStatusResponse, error)
 	GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsResponse, error)
 	ReconcileProcessor(ctx context.Context, in *ReconcileProcessorRequest, opts ...grpc.CallOption) (*ReconcileProcessorResponse, error)
+	StartShadowRun(ctx context.Context, in *ShadowRunRequest, opts ...grpc.CallOption) (*ShadowRun, error)
+	GetShadowRun(ctx context.Context, in *ShadowRunRequest, opts ...grpc.CallOption) (*ShadowRun, error)
+	DiffShadowRun(ctx context.Context, in *DiffShadowRunRequest, opts ...grpc.CallOption) (*ShadowRun, error)
 }
 
 type processorRuntimeServiceClient struct {
@@ -1035,6 +1041,36 @@ func (c *processorRuntimeServiceClient) ReconcileProcessor(ctx context.Context,
 	return out, nil
 }
 
+func (c *processorRuntimeServiceClient) StartShadowRun(ctx context.Context, in *ShadowRunRequest, opts ...grpc.CallOption) (*ShadowRun, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShadowRun)
+	err := c.cc.Invoke(ctx, ProcessorRuntimeService_StartShadowRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *processorRuntimeServiceClient) GetShadowRun(ctx context.Context, in *ShadowRunRequest, opts ...grpc.CallOption) (*ShadowRun, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShadowRun)
+	err := c.cc.Invoke(ctx, ProcessorRuntimeService_GetShadowRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *processorRuntimeServiceClient) DiffShadowRun(ctx context.Context, in *DiffShadowRunRequest, opts ...grpc.CallOption) (*ShadowRun, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShadowRun)
+	err := c.cc.Invoke(ctx, ProcessorRuntimeService_DiffShadowRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProcessorRuntimeServiceServer is the server API for ProcessorRuntimeService service.
 // All implementations must embed UnimplementedProcessorRuntimeServiceServer
 // for forward compatibility.
@@ -1044,6 +1080,9 @@ type ProcessorRuntimeServiceServer interface {
 	GetProcessorStatus(context.Context, *GetProcessorStatusRequest) (*GetProcessorStatusResponse, error)
 	GetLogs(context.Context, *GetLogsRequest) (*GetLogsResponse, error)
 	ReconcileProcessor(context.Context, *ReconcileProcessorRequest) (*ReconcileProcessorResponse, error)
+	StartShadowRun(context.Context, *ShadowRunRequest) (*ShadowRun, error)
+	GetShadowRun(context.Context, *ShadowRunRequest) (*ShadowRun, error)
+	DiffShadowRun(context.Context, *DiffShadowRunRequest) (*ShadowRun, error)
 	mustEmbedUnimplementedProcessorRuntimeServiceServer()
 }
 
@@ -1069,6 +1108,15 @@ func (UnimplementedProcessorRuntimeServiceServer) GetLogs(context.Context, *GetL
 func (UnimplementedProcessorRuntimeServiceServer) ReconcileProcessor(context.Context, *ReconcileProcessorRequest) (*ReconcileProcessorResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReconcileProcessor not implemented")
 }
+func (UnimplementedProcessorRuntimeServiceServer) StartShadowRun(context.Context, *ShadowRunRequest) (*ShadowRun, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartShadowRun not implemented")
+}
+func (UnimplementedProcessorRuntimeServiceServer) GetShadowRun(context.Context, *ShadowRunRequest) (*ShadowRun, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShadowRun not implemented")
+}
+func (UnimplementedProcessorRuntimeServiceServer) DiffShadowRun(context.Context, *DiffShadowRunRequest) (*ShadowRun, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffShadowRun not implemented")
+}
 func (UnimplementedProcessorRuntimeServiceServer) mustEmbedUnimplementedProcessorRuntimeServiceServer() {
 }
 func (UnimplementedProcessorRuntimeServiceServer) testEmbeddedByValue() {}
@@ -1181,6 +1229,60 @@ func _ProcessorRuntimeService_ReconcileProcessor_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProcessorRuntimeService_StartShadowRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShadowRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProcessorRuntimeServiceServer).StartShadowRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProcessorRuntimeService_StartShadowRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProcessorRuntimeServiceServer).StartShadowRun(ctx, req.(*ShadowRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProcessorRuntimeService_GetShadowRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShadowRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProcessorRuntimeServiceServer).GetShadowRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProcessorRuntimeService_GetShadowRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProcessorRuntimeServiceServer).GetShadowRun(ctx, req.(*ShadowRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProcessorRuntimeService_DiffShadowRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffShadowRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProcessorRuntimeServiceServer).DiffShadowRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProcessorRuntimeService_DiffShadowRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProcessorRuntimeServiceServer).DiffShadowRun(ctx, req.(*DiffShadowRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ProcessorRuntimeService_ServiceDesc is the grpc.ServiceDesc for ProcessorRuntimeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1208,6 +1310,18 @@ var ProcessorRuntimeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReconcileProcessor",
 			Handler:    _ProcessorRuntimeService_ReconcileProcessor_Handler,
 		},
+		{
+			MethodName: "StartShadowRun",
+			Handler:    _ProcessorRuntimeService_StartShadowRun_Handler,
+		},
+		{
+			MethodName: "GetShadowRun",
+			Handler:    _ProcessorRuntimeService_GetShadowRun_Handler,
+		},
+		{
+			MethodName: "DiffShadowRun",
+			Handler:    _ProcessorRuntimeService_DiffShadowRun_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "service/processor/protos/processor_service.proto",
@@ -24,6 +24,7 @@ const (
 	chainStatesKeyPrefix      = "chain_states:"
 	upgradeHistoryKeyPrefix   = "upgrade_history:"
 	stateHistoryKeyPrefix     = "state_history:"
+	shadowRunKeyPrefix        = "shadow_run:"
 	projectKeyPrefix          = "project:"
 	projectVersionsKeyPrefix  = "project:versions:"
 	projectVariablesKeyPrefix = "project:variables:"
@@ -69,6 +70,9 @@ type RedisProcessorRepoInterface interface {
 	ListProcessorStateHistory(ctx context.Context, processorID string) ([]models.ProcessorStateHistory, error)
 	SaveProcessorStateHistory(ctx context.Context, history *models.ProcessorStateHistory) error
 
+	GetShadowRun(ctx context.Context, shadowProcessorID string) (*models.ShadowRun, error)
+	SaveShadowRun(ctx context.Context, run *models.ShadowRun) error
+
 	GetProjectByID(ctx context.Context, projectID string) (*commonmodels.Project, error)
 	GetProjectVersions(ctx context.Context, projectID string) ([]*models.Processor, error)
 	GetProjectVariables(ctx context.Context, projectID string) ([]*commonmodels.ProjectVariable, error)
@@ -254,6 +258,7 @@ func (r *RedisProcessorRepo) RemoveProcessor(ctx context.Context, processorID st
 	pipe.Del(ctx, key)
 	pipe.Del(ctx, projectKey)
 	pipe.Del(ctx, chainStatesKeyPrefix+processorID)
+	pipe.Del(ctx, shadowRunKeyPrefix+processorID)
 
 	// Remove chain states
 	chainStatePattern := chainStateKeyPrefix + processorID + ":*"
@@ -613,6 +618,37 @@ func (r *RedisProcessorRepo) SaveProcessorStateHistory(ctx context.Context, hist
 	return r.client.Set(ctx, key, data, 0).Err()
 }
 
+// GetShadowRun gets the shadow run of a processor, returns nil if the processor has no shadow run
+func (r *RedisProcessorRepo) GetShadowRun(ctx context.Context, shadowProcessorID string) (*models.ShadowRun, error) {
+	data, err := r.client.Get(ctx, shadowRunKeyPrefix+shadowProcessorID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var run models.ShadowRun
+	if err := json.Unmarshal([]byte(data), &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// SaveShadowRun saves the shadow run of a processor
+func (r *RedisProcessorRepo) SaveShadowRun(ctx context.Context, run *models.ShadowRun) error {
+	now := time.Now()
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = now
+	}
+	run.UpdatedAt = now
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, shadowRunKeyPrefix+run.ShadowProcessorID, data, 0).Err()
+}
+
 // GetProjectByID gets a project by ID
 func (r *RedisProcessorRepo) GetProjectByID(ctx context.Context, projectID string) (*commonmodels.Project, error) {
 	if projectID == "" || projectID == "default" {
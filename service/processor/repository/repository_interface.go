@@ -46,6 +46,9 @@ type ProcessorRepo interface {
 	ListProcessorStateHistory(ctx context.Context, processorID string) ([]models.ProcessorStateHistory, error)
 	SaveProcessorStateHistory(ctx context.Context, history *models.ProcessorStateHistory) error
 
+	GetShadowRun(ctx context.Context, shadowProcessorID string) (*models.ShadowRun, error)
+	SaveShadowRun(ctx context.Context, run *models.ShadowRun) error
+
 	GetProjectByID(ctx context.Context, projectID string) (*commonmodels.Project, error)
 	GetProjectVersions(ctx context.Context, projectID string) ([]*models.Processor, error)
 	GetProjectVariables(ctx context.Context, projectID string) ([]*commonmodels.ProjectVariable, error)
@@ -0,0 +1,177 @@
+package shadow
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"sentioxyz/sentio-core/common/chx"
+	ckhmanager "sentioxyz/sentio-core/common/clickhousemanager"
+	"sentioxyz/sentio-core/common/utils"
+	entitychs "sentioxyz/sentio-core/driver/entity/clickhouse"
+	"sentioxyz/sentio-core/driver/entity/persistent"
+	"sentioxyz/sentio-core/driver/entity/schema"
+	"sentioxyz/sentio-core/driver/timeseries"
+	tschs "sentioxyz/sentio-core/driver/timeseries/clickhouse"
+	"sentioxyz/sentio-core/service/processor/models"
+
+	"github.com/pkg/errors"
+)
+
+type clickhouseSourceProvider struct {
+	manager ckhmanager.Manager
+}
+
+// NewClickhouseSourceProvider creates a SourceProvider reading the tables written by the drivers,
+// the connection of a processor is got from the sharding of the processor in the clickhouse config file
+func NewClickhouseSourceProvider(clickhouseConfigPath string) SourceProvider {
+	return &clickhouseSourceProvider{manager: ckhmanager.LoadManager(clickhouseConfigPath)}
+}
+
+func (p *clickhouseSourceProvider) getConn(processor *models.Processor) (ckhmanager.Conn, error) {
+	shard := p.manager.GetShardByIndex(ckhmanager.ShardingIndex(processor.ClickhouseShardingIndex))
+	if shard == nil {
+		return nil, fmt.Errorf("clickhouse sharding %d of processor %s not found",
+			processor.ClickhouseShardingIndex, processor.ID)
+	}
+	return shard.GetConn(ckhmanager.WithCategory(ckhmanager.SentioCategory))
+}
+
+func (p *clickhouseSourceProvider) NewSource(
+	ctx context.Context,
+	processor *models.Processor,
+	stopAtBlocks map[string]uint64,
+) (Source, error) {
+	conn, err := p.getConn(processor)
+	if err != nil {
+		return nil, err
+	}
+	tableNamePrefix, logicDatabase, logicTableNamePrefix := processor.TablePattern.GetProcessorDBConfig(
+		conn.GetDatabase(), processor.ID, 0)
+	ctrl := chx.New(
+		conn,
+		chx.WithTableNamePrefix(tableNamePrefix),
+		chx.WithLogicDatabase(logicDatabase),
+		chx.WithLogicTableNamePrefix(logicTableNamePrefix),
+	)
+	s := &clickhouseSource{
+		chains:       utils.GetOrderedMapKeys(stopAtBlocks),
+		stopAtBlocks: stopAtBlocks,
+		metrics:      make(map[string]timeseries.Meta),
+	}
+	// time series
+	s.tsStore = tschs.NewStore(ctrl, tschs.Option{}, nil)
+	if err = s.tsStore.ReloadMeta(ctx); err != nil {
+		return nil, errors.Wrapf(err, "load time series meta of processor %s failed", processor.ID)
+	}
+	for _, metaType := range []timeseries.MetaType{timeseries.MetaTypeCounter, timeseries.MetaTypeGauge} {
+		for name, meta := range s.tsStore.Meta().MetaByType(metaType) {
+			// the aggregations are computed from the source metrics
+			if meta.Aggregation == nil {
+				s.metrics[name] = meta
+			}
+		}
+	}
+	// entities
+	if processor.EntitySchema != "" {
+		feaOpt := entitychs.BuildFeatures(processor.EntitySchemaVersion)
+		sch, err := schema.ParseAndVerifySchema(processor.EntitySchema, feaOpt.BuildVerifyOptions()...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse entity schema of processor %s failed", processor.ID)
+		}
+		s.entityStore = entitychs.NewStore(ctrl, feaOpt, sch, entitychs.DefaultCreateTableOption, nil)
+		for _, entityType := range sch.ListEntities(false) {
+			s.entityTypes = append(s.entityTypes, entityType.GetName())
+		}
+	}
+	return s, nil
+}
+
+// clickhouseSource reads the outputs of a processor chain by chain, the chains are the ones of the stop blocks
+type clickhouseSource struct {
+	chains       []string
+	stopAtBlocks map[string]uint64
+
+	entityStore *entitychs.Store
+	entityTypes []string
+	tsStore     *tschs.Store
+	metrics     map[string]timeseries.Meta
+}
+
+func (s *clickhouseSource) EntityTypes() []string {
+	return s.entityTypes
+}
+
+func (s *clickhouseSource) Entities(ctx context.Context, entity string) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		if s.entityStore == nil {
+			return
+		}
+		entityType := s.entityStore.GetEntityType(entity)
+		if entityType == nil {
+			return
+		}
+		for _, chain := range s.chains {
+			var stopped bool
+			err := s.entityStore.ScanLatestEntities(ctx, entityType, chain, s.stopAtBlocks[chain],
+				func(box persistent.EntityBox) error {
+					if !yield(Row{Chain: chain, Key: box.ID, BlockNumber: box.GenBlockNumber, Values: box.Data}, nil) {
+						stopped = true
+						return errStopScan
+					}
+					return nil
+				})
+			if stopped {
+				return
+			}
+			if err != nil {
+				yield(Row{}, err)
+				return
+			}
+		}
+	}
+}
+
+func (s *clickhouseSource) Metrics() []string {
+	return utils.GetOrderedMapKeys(s.metrics)
+}
+
+func (s *clickhouseSource) Points(ctx context.Context, metric string) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		meta, has := s.metrics[metric]
+		if !has {
+			return
+		}
+		chainIDField, timestampField, slotNumberField := meta.GetChainIDField(), meta.GetTimestampField(),
+			meta.GetSlotNumberField()
+		for _, chain := range s.chains {
+			var stopped bool
+			err := s.tsStore.ScanPoints(ctx, meta, chain, s.stopAtBlocks[chain],
+				func(seriesKey string, row timeseries.Row) error {
+					point := Row{Chain: chain, Key: seriesKey, Values: row}
+					point.Timestamp, _ = row[timestampField.Name].(time.Time)
+					if slotNumber, is := row[slotNumberField.Name].(int64); is {
+						point.BlockNumber = uint64(slotNumber)
+					}
+					delete(row, chainIDField.Name)
+					delete(row, timestampField.Name)
+					delete(row, slotNumberField.Name)
+					if !yield(point, nil) {
+						stopped = true
+						return errStopScan
+					}
+					return nil
+				})
+			if stopped {
+				return
+			}
+			if err != nil {
+				yield(Row{}, err)
+				return
+			}
+		}
+	}
+}
+
+var errStopScan = errors.New("scan stopped")
@@ -0,0 +1,193 @@
+package shadow
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/utils"
+	"sentioxyz/sentio-core/service/processor/models"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxSamples is the default number of mismatch samples kept for each entity type or metric
+const DefaultMaxSamples = 20
+
+// Diff compares the entity rows by ID and the time series points by series and timestamp of the two sources.
+// For each entity type and metric at most maxSamples mismatches with the smallest block numbers are kept.
+func Diff(ctx context.Context, base, shadow Source, maxSamples int) (*models.ShadowDiffReport, error) {
+	_, logger := log.FromContext(ctx)
+	report := &models.ShadowDiffReport{FirstDivergentBlocks: make(map[string]uint64)}
+	diverge := func(chain string, blockNumber uint64) {
+		if first, has := report.FirstDivergentBlocks[chain]; !has || blockNumber < first {
+			report.FirstDivergentBlocks[chain] = blockNumber
+		}
+	}
+	for _, entity := range unionNames(base.EntityTypes(), shadow.EntityTypes()) {
+		item, err := diffRows(ctx, entity, base.Entities(ctx, entity), shadow.Entities(ctx, entity), maxSamples, diverge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "diff entity %s failed", entity)
+		}
+		logger.Infow("entity compared", "entity", entity, "same", item.Same())
+		report.Entities = append(report.Entities, item)
+	}
+	for _, metric := range unionNames(base.Metrics(), shadow.Metrics()) {
+		item, err := diffRows(ctx, metric, base.Points(ctx, metric), shadow.Points(ctx, metric), maxSamples, diverge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "diff metric %s failed", metric)
+		}
+		logger.Infow("metric compared", "metric", metric, "same", item.Same())
+		report.Metrics = append(report.Metrics, item)
+	}
+	if len(report.FirstDivergentBlocks) == 0 {
+		report.FirstDivergentBlocks = nil
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+func unionNames(a, b []string) []string {
+	names := slices.Concat(a, b)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// diffRows merges the two ordered row sequences
+func diffRows(
+	ctx context.Context,
+	name string,
+	base, shadow iter.Seq2[Row, error],
+	maxSamples int,
+	diverge func(chain string, blockNumber uint64),
+) (item models.ShadowDiffItem, err error) {
+	item.Name = name
+	nextBase, stopBase := iter.Pull2(base)
+	defer stopBase()
+	nextShadow, stopShadow := iter.Pull2(shadow)
+	defer stopShadow()
+	pull := func(next func() (Row, error, bool), prev *Row, side string) (*Row, error) {
+		row, err, ok := next()
+		if !ok {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s rows failed", side)
+		}
+		if prev != nil && compareRows(*prev, row) > 0 {
+			return nil, errors.Errorf("%s rows are not ordered, %s/%s is after %s/%s",
+				side, row.Chain, row.Key, prev.Chain, prev.Key)
+		}
+		return &row, nil
+	}
+	addSample := func(sample models.ShadowMismatch) {
+		diverge(sample.Chain, sample.BlockNumber)
+		// keep the samples with the smallest block numbers
+		i, _ := slices.BinarySearchFunc(item.Samples, sample.BlockNumber, func(s models.ShadowMismatch, bn uint64) int {
+			return utils.Select(s.BlockNumber <= bn, -1, 1)
+		})
+		if i < maxSamples {
+			item.Samples = slices.Insert(item.Samples, i, sample)
+			item.Samples = item.Samples[:min(len(item.Samples), maxSamples)]
+		}
+	}
+	var b, s *Row
+	if b, err = pull(nextBase, nil, "base"); err != nil {
+		return item, err
+	}
+	if s, err = pull(nextShadow, nil, "shadow"); err != nil {
+		return item, err
+	}
+	for b != nil || s != nil {
+		if err = ctx.Err(); err != nil {
+			return item, err
+		}
+		var c int
+		switch {
+		case b == nil:
+			c = 1
+		case s == nil:
+			c = -1
+		default:
+			c = compareRows(*b, *s)
+		}
+		switch {
+		case c < 0:
+			item.BaseCount++
+			item.Missing++
+			addSample(newMismatch(models.ShadowMismatchKindMissing, b, nil, nil))
+		case c > 0:
+			item.ShadowCount++
+			item.Extra++
+			addSample(newMismatch(models.ShadowMismatchKindExtra, nil, s, nil))
+		default:
+			item.BaseCount++
+			item.ShadowCount++
+			if fields := diffValues(b.Values, s.Values); len(fields) == 0 {
+				item.Matched++
+			} else {
+				item.Different++
+				addSample(newMismatch(models.ShadowMismatchKindDifferent, b, s, fields))
+			}
+		}
+		if c <= 0 {
+			if b, err = pull(nextBase, b, "base"); err != nil {
+				return item, err
+			}
+		}
+		if c >= 0 {
+			if s, err = pull(nextShadow, s, "shadow"); err != nil {
+				return item, err
+			}
+		}
+	}
+	return item, nil
+}
+
+func newMismatch(kind models.ShadowMismatchKind, base, shadow *Row, fields []string) models.ShadowMismatch {
+	row := utils.Select(base != nil, base, shadow)
+	m := models.ShadowMismatch{
+		Kind:        kind,
+		Chain:       row.Chain,
+		Key:         row.Key,
+		BlockNumber: row.BlockNumber,
+		Fields:      fields,
+	}
+	if !row.Timestamp.IsZero() {
+		m.Timestamp = utils.WrapPointer(row.Timestamp)
+	}
+	if base != nil {
+		m.Base = maps.Clone(base.Values)
+	}
+	if shadow != nil {
+		m.Shadow = maps.Clone(shadow.Values)
+		m.BlockNumber = min(m.BlockNumber, shadow.BlockNumber)
+	}
+	return m
+}
+
+// diffValues returns the names of the fields with different values in order, a field missing on one side is
+// different from any value. Values are the same if they have the same type and the same text, so the values
+// like *big.Int and decimal.Decimal can be compared.
+func diffValues(base, shadow map[string]any) (fields []string) {
+	for _, field := range unionNames(utils.GetMapKeys(base), utils.GetMapKeys(shadow)) {
+		bv, bHas := base[field]
+		sv, sHas := shadow[field]
+		if bHas != sHas || !sameValue(bv, sv) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func sameValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && fmt.Sprint(a) == fmt.Sprint(b)
+}
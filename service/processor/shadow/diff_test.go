@@ -0,0 +1,206 @@
+package shadow
+
+import (
+	"context"
+	"iter"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"sentioxyz/sentio-core/service/processor/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySource struct {
+	entities map[string][]Row
+	points   map[string][]Row
+}
+
+func (s memorySource) EntityTypes() []string {
+	return slices.Sorted(func(yield func(string) bool) {
+		for name := range s.entities {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+}
+
+func (s memorySource) rows(rows []Row) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s memorySource) Entities(_ context.Context, entity string) iter.Seq2[Row, error] {
+	return s.rows(s.entities[entity])
+}
+
+func (s memorySource) Metrics() []string {
+	return slices.Sorted(func(yield func(string) bool) {
+		for name := range s.points {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+}
+
+func (s memorySource) Points(_ context.Context, metric string) iter.Seq2[Row, error] {
+	return s.rows(s.points[metric])
+}
+
+func entityRow(chain, id string, bn uint64, values map[string]any) Row {
+	return Row{Chain: chain, Key: id, BlockNumber: bn, Values: values}
+}
+
+func pointRow(chain, series string, ts int64, bn uint64, value float64) Row {
+	return Row{Chain: chain, Key: series, Timestamp: time.Unix(ts, 0), BlockNumber: bn, Values: map[string]any{"value": value}}
+}
+
+func Test_Diff_same(t *testing.T) {
+	src := memorySource{
+		entities: map[string][]Row{
+			"Pool": {
+				entityRow("1", "a", 10, map[string]any{"tvl": big.NewInt(100)}),
+				entityRow("1", "b", 12, map[string]any{"tvl": big.NewInt(0)}),
+			},
+		},
+		points: map[string][]Row{
+			"volume": {pointRow("1", "pool=a", 100, 10, 1.5), pointRow("1", "pool=a", 100, 10, 2)},
+		},
+	}
+	// the same values built separately
+	other := memorySource{
+		entities: map[string][]Row{
+			"Pool": {
+				entityRow("1", "a", 10, map[string]any{"tvl": new(big.Int).SetInt64(100)}),
+				entityRow("1", "b", 12, map[string]any{"tvl": new(big.Int)}),
+			},
+		},
+		points: src.points,
+	}
+	report, err := Diff(context.Background(), src, other, DefaultMaxSamples)
+	require.NoError(t, err)
+	assert.True(t, report.Same())
+	assert.Equal(t, []models.ShadowDiffItem{{Name: "Pool", BaseCount: 2, ShadowCount: 2, Matched: 2}}, report.Entities)
+	assert.Equal(t, []models.ShadowDiffItem{{Name: "volume", BaseCount: 2, ShadowCount: 2, Matched: 2}}, report.Metrics)
+}
+
+func Test_Diff_mismatch(t *testing.T) {
+	base := memorySource{
+		entities: map[string][]Row{
+			"Pool": {
+				entityRow("1", "a", 10, map[string]any{"tvl": 100, "name": "a"}),
+				entityRow("1", "b", 12, map[string]any{"tvl": 200, "name": "b"}),
+				entityRow("1", "c", 8, map[string]any{"tvl": 300, "name": "c"}),
+				entityRow("56", "a", 30, map[string]any{"tvl": 1, "name": "a"}),
+			},
+			"Token": {entityRow("1", "x", 3, map[string]any{})},
+		},
+		points: map[string][]Row{
+			"volume": {
+				pointRow("1", "pool=a", 100, 10, 1),
+				pointRow("1", "pool=a", 200, 20, 2),
+				pointRow("56", "pool=a", 100, 40, 1),
+			},
+		},
+	}
+	shadow := memorySource{
+		entities: map[string][]Row{
+			"Pool": {
+				entityRow("1", "a", 10, map[string]any{"tvl": 100, "name": "a"}),
+				entityRow("1", "b", 15, map[string]any{"tvl": 201, "name": "b"}),
+				entityRow("1", "d", 9, map[string]any{"tvl": 400, "name": "d"}),
+				entityRow("56", "a", 30, map[string]any{"tvl": 1}),
+			},
+		},
+		points: map[string][]Row{
+			"volume": {
+				pointRow("1", "pool=a", 100, 10, 1),
+				pointRow("1", "pool=a", 200, 20, 3),
+				pointRow("56", "pool=a", 100, 40, 1),
+				pointRow("56", "pool=b", 100, 35, 1),
+			},
+		},
+	}
+	report, err := Diff(context.Background(), base, shadow, 2)
+	require.NoError(t, err)
+	assert.False(t, report.Same())
+	assert.Equal(t, map[string]uint64{"1": 3, "56": 30}, report.FirstDivergentBlocks)
+
+	require.Len(t, report.Entities, 2)
+	pool := report.Entities[0]
+	assert.Equal(t, "Pool", pool.Name)
+	assert.Equal(t, uint64(4), pool.BaseCount)
+	assert.Equal(t, uint64(4), pool.ShadowCount)
+	assert.Equal(t, uint64(1), pool.Matched)
+	assert.Equal(t, uint64(2), pool.Different)
+	assert.Equal(t, uint64(1), pool.Missing)
+	assert.Equal(t, uint64(1), pool.Extra)
+	// only the 2 samples with the smallest block numbers are kept
+	require.Len(t, pool.Samples, 2)
+	assert.Equal(t, models.ShadowMismatch{
+		Kind:        models.ShadowMismatchKindMissing,
+		Chain:       "1",
+		Key:         "c",
+		BlockNumber: 8,
+		Base:        map[string]any{"tvl": 300, "name": "c"},
+	}, pool.Samples[0])
+	assert.Equal(t, models.ShadowMismatchKindExtra, pool.Samples[1].Kind)
+	assert.Equal(t, "d", pool.Samples[1].Key)
+	assert.Equal(t, uint64(9), pool.Samples[1].BlockNumber)
+
+	token := report.Entities[1]
+	assert.Equal(t, models.ShadowDiffItem{
+		Name:      "Token",
+		BaseCount: 1,
+		Missing:   1,
+		Samples: []models.ShadowMismatch{{
+			Kind:        models.ShadowMismatchKindMissing,
+			Chain:       "1",
+			Key:         "x",
+			BlockNumber: 3,
+			Base:        map[string]any{},
+		}},
+	}, token)
+
+	require.Len(t, report.Metrics, 1)
+	volume := report.Metrics[0]
+	assert.Equal(t, uint64(3), volume.BaseCount)
+	assert.Equal(t, uint64(4), volume.ShadowCount)
+	assert.Equal(t, uint64(2), volume.Matched)
+	assert.Equal(t, uint64(1), volume.Different)
+	assert.Equal(t, uint64(1), volume.Extra)
+	require.Len(t, volume.Samples, 2)
+	assert.Equal(t, models.ShadowMismatchKindDifferent, volume.Samples[0].Kind)
+	assert.Equal(t, []string{"value"}, volume.Samples[0].Fields)
+	assert.Equal(t, time.Unix(200, 0), *volume.Samples[0].Timestamp)
+	assert.Equal(t, "pool=b", volume.Samples[1].Key)
+}
+
+func Test_Diff_unordered(t *testing.T) {
+	base := memorySource{
+		entities: map[string][]Row{
+			"Pool": {entityRow("1", "b", 1, nil), entityRow("1", "a", 1, nil)},
+		},
+	}
+	_, err := Diff(context.Background(), base, memorySource{}, DefaultMaxSamples)
+	assert.ErrorContains(t, err, "not ordered")
+}
+
+func Test_diffValues(t *testing.T) {
+	assert.Empty(t, diffValues(
+		map[string]any{"a": big.NewInt(0), "b": nil, "c": []string{"x"}},
+		map[string]any{"a": new(big.Int), "b": nil, "c": []string{"x"}}))
+	assert.Equal(t, []string{"a", "b", "c"}, diffValues(
+		map[string]any{"a": int64(1), "b": nil},
+		map[string]any{"a": int32(1), "b": "", "c": nil}))
+}
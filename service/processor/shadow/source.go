@@ -0,0 +1,50 @@
+package shadow
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"time"
+
+	"sentioxyz/sentio-core/service/processor/models"
+)
+
+// Row is an output row of a processor. An entity row is keyed by the entity ID and has a zero Timestamp,
+// a time series point is keyed by the series key and the timestamp.
+type Row struct {
+	Chain       string
+	Key         string
+	Timestamp   time.Time
+	BlockNumber uint64
+	Values      map[string]any
+}
+
+// compareRows compares the rows by chain, key and timestamp, which is the order of the rows of a Source
+func compareRows(a, b Row) int {
+	if c := cmp.Compare(a.Chain, b.Chain); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Key, b.Key); c != 0 {
+		return c
+	}
+	return a.Timestamp.Compare(b.Timestamp)
+}
+
+// Source reads the outputs of a processor generated not after the stop blocks of a shadow run
+type Source interface {
+	// EntityTypes returns the names of the entity types
+	EntityTypes() []string
+	// Entities returns the latest version of each entity of the type ordered by chain and ID,
+	// deleted entities are not included. Unknown entity type has no rows.
+	Entities(ctx context.Context, entity string) iter.Seq2[Row, error]
+	// Metrics returns the names of the counters and gauges
+	Metrics() []string
+	// Points returns the points of the metric ordered by chain, series key and timestamp,
+	// points with the same timestamp of a series are ordered by the values. Unknown metric has no points.
+	Points(ctx context.Context, metric string) iter.Seq2[Row, error]
+}
+
+// SourceProvider builds the Source of a processor
+type SourceProvider interface {
+	NewSource(ctx context.Context, processor *models.Processor, stopAtBlocks map[string]uint64) (Source, error)
+}
@@ -0,0 +1,228 @@
+package processor
+
+import (
+	"context"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/service/processor/models"
+	"sentioxyz/sentio-core/service/processor/protos"
+	"sentioxyz/sentio-core/service/processor/shadow"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetShadowSourceProvider sets the provider used to read the outputs of the processors while diffing a shadow run,
+// shadow runs can be started without it but can not be diffed
+func (s *Service) SetShadowSourceProvider(provider shadow.SourceProvider) {
+	s.shadowSources = provider
+}
+
+// StartShadowRun runs the pending processor as the shadow of the active version of the project. The shadow version
+// stops at the blocks the active version has processed now, so the outputs of both can be diffed later.
+func (s *Service) StartShadowRun(ctx context.Context, req *protos.ShadowRunRequest) (*protos.ShadowRun, error) {
+	run, err := s.startShadowRun(ctx, req.GetProcessorId())
+	if err != nil {
+		return nil, err
+	}
+	return run.ToPB()
+}
+
+// GetShadowRun returns the shadow run of the processor with the diff report if the diff is done
+func (s *Service) GetShadowRun(ctx context.Context, req *protos.ShadowRunRequest) (*protos.ShadowRun, error) {
+	run, err := s.getShadowRun(ctx, req.GetProcessorId())
+	if err != nil {
+		return nil, err
+	}
+	return run.ToPB()
+}
+
+// DiffShadowRun starts diffing the outputs of the shadow run in background, the report can be got by GetShadowRun
+// after the run is DONE. The shadow version should have processed all the stop blocks, unless force is true.
+func (s *Service) DiffShadowRun(ctx context.Context, req *protos.DiffShadowRunRequest) (*protos.ShadowRun, error) {
+	run, err := s.diffShadowRun(ctx, req.GetProcessorId(), req.GetForce())
+	if err != nil {
+		return nil, err
+	}
+	return run.ToPB()
+}
+
+func (s *Service) startShadowRun(ctx context.Context, processorID string) (*models.ShadowRun, error) {
+	processor, err := s.processorRepo.GetProcessor(ctx, processorID, false)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if processor.VersionState != int32(protos.ProcessorVersionState_PENDING) {
+		return nil, status.Errorf(codes.FailedPrecondition, "processor %s is not a pending version", processorID)
+	}
+	base, err := s.processorRepo.FindActiveProcessor(ctx, processor.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "project %s has no active version", processor.ProjectID)
+	}
+	chainStates, err := s.chainStateRepo.GetChainStates(ctx, base.ID)
+	if err != nil {
+		return nil, err
+	}
+	stopAtBlocks := make(map[string]uint64)
+	for _, cs := range chainStates {
+		// chains not processed by the base version yet have nothing to compare
+		if cs.ProcessedBlockNumber >= 0 {
+			stopAtBlocks[cs.ChainID] = uint64(cs.ProcessedBlockNumber)
+		}
+	}
+	if len(stopAtBlocks) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "active version %s has not processed any block", base.ID)
+	}
+
+	run := &models.ShadowRun{
+		ShadowProcessorID: processor.ID,
+		BaseProcessorID:   base.ID,
+		ProjectID:         processor.ProjectID,
+		StopAtBlocks:      stopAtBlocks,
+		State:             models.ShadowRunStateRunning,
+	}
+	processor.ShadowStopAtBlocks = stopAtBlocks
+	err = s.processorRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.processorRepo.SaveProcessor(ctx, processor); err != nil {
+			return err
+		}
+		return s.processorRepo.SaveShadowRun(ctx, run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the driver reads the stop blocks on start up, so the job is restarted after the stop blocks are committed.
+	// Outputs after the stop blocks are ignored by the diff, so it is fine if the pending version has already
+	// processed more blocks.
+	if err = s.restartDriverJob(ctx, processor); err != nil {
+		return nil, err
+	}
+	log.WithContext(ctx).Infof("shadow run of processor %s started, base: %s, stop at blocks: %v",
+		processor.ID, base.ID, stopAtBlocks)
+	return run, nil
+}
+
+func (s *Service) getShadowRun(ctx context.Context, processorID string) (*models.ShadowRun, error) {
+	run, err := s.processorRepo.GetShadowRun(ctx, processorID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, status.Errorf(codes.NotFound, "processor %s has no shadow run", processorID)
+	}
+	return run, nil
+}
+
+func (s *Service) diffShadowRun(ctx context.Context, processorID string, force bool) (*models.ShadowRun, error) {
+	if s.shadowSources == nil {
+		return nil, status.Error(codes.Unimplemented, "shadow diff is not configured")
+	}
+	run, err := s.getShadowRun(ctx, processorID)
+	if err != nil {
+		return nil, err
+	}
+	if run.State == models.ShadowRunStateDiffing {
+		return nil, status.Errorf(codes.FailedPrecondition, "shadow run of processor %s is diffing", processorID)
+	}
+	if !force {
+		chainStates, err := s.chainStateRepo.GetChainStates(ctx, processorID)
+		if err != nil {
+			return nil, err
+		}
+		processed := make(map[string]int64)
+		for _, cs := range chainStates {
+			processed[cs.ChainID] = cs.ProcessedBlockNumber
+		}
+		for chain, stop := range run.StopAtBlocks {
+			if bn, has := processed[chain]; !has || bn < int64(stop) {
+				return nil, status.Errorf(codes.FailedPrecondition,
+					"shadow version has not reached the stop block %d of chain %s", stop, chain)
+			}
+		}
+	}
+	base, err := s.processorRepo.GetProcessor(ctx, run.BaseProcessorID, false)
+	if err != nil {
+		return nil, err
+	}
+	processor, err := s.processorRepo.GetProcessor(ctx, run.ShadowProcessorID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	run.State, run.Error, run.Report = models.ShadowRunStateDiffing, "", nil
+	if err = s.processorRepo.SaveShadowRun(ctx, run); err != nil {
+		return nil, err
+	}
+	go s.runShadowDiff(context.WithoutCancel(ctx), *run, base, processor)
+	return run, nil
+}
+
+func (s *Service) runShadowDiff(ctx context.Context, run models.ShadowRun, base, processor *models.Processor) {
+	ctx, logger := log.FromContext(ctx, "shadowProcessorID", run.ShadowProcessorID, "baseProcessorID", base.ID)
+	report, err := func() (*models.ShadowDiffReport, error) {
+		baseSource, err := s.shadowSources.NewSource(ctx, base, run.StopAtBlocks)
+		if err != nil {
+			return nil, errors.Wrapf(err, "open outputs of base version %s failed", base.ID)
+		}
+		shadowSource, err := s.shadowSources.NewSource(ctx, processor, run.StopAtBlocks)
+		if err != nil {
+			return nil, errors.Wrapf(err, "open outputs of shadow version %s failed", processor.ID)
+		}
+		return shadow.Diff(ctx, baseSource, shadowSource, shadow.DefaultMaxSamples)
+	}()
+	if err != nil {
+		logger.Errore(err, "diff shadow run failed")
+		run.State, run.Error = models.ShadowRunStateFailed, err.Error()
+	} else {
+		logger.Infow("diff shadow run succeed", "same", report.Same())
+		run.State, run.Report = models.ShadowRunStateDone, report
+	}
+	if err = s.processorRepo.SaveShadowRun(ctx, &run); err != nil {
+		logger.Errore(err, "save shadow run failed")
+	}
+	// the run is finished, the shadow version continues processing the blocks after the stop blocks
+	if err = s.endShadow(ctx, processor.ID); err != nil {
+		logger.Errore(err, "end shadow of processor failed")
+	}
+}
+
+// clearShadowStopAtBlocks clears the stop blocks of the processor, returns true if the processor was running
+// as a shadow. The driver job should be restarted by restartDriverJob after the processor is saved.
+func clearShadowStopAtBlocks(processor *models.Processor) bool {
+	if len(processor.ShadowStopAtBlocks) == 0 {
+		return false
+	}
+	processor.ShadowStopAtBlocks = nil
+	return true
+}
+
+// endShadow clears the stop blocks of the processor and restarts its driver job without them
+func (s *Service) endShadow(ctx context.Context, processorID string) error {
+	processor, err := s.processorRepo.GetProcessor(ctx, processorID, false)
+	if err != nil {
+		return err
+	}
+	if !clearShadowStopAtBlocks(processor) {
+		return nil
+	}
+	if err = s.processorRepo.SaveProcessor(ctx, processor); err != nil {
+		return err
+	}
+	return s.restartDriverJob(ctx, processor)
+}
+
+// restartDriverJob updates the driver job with the saved processor and restarts it, it must not be called
+// inside a transaction because the driver reads the processor once restarted
+func (s *Service) restartDriverJob(ctx context.Context, processor *models.Processor) error {
+	if err := s.driverJobManager.StartOrUpdateDriverJob(ctx, processor); err != nil {
+		return errors.Wrapf(err, "update driver job of processor %s failed", processor.ID)
+	}
+	if err := s.driverJobManager.RestartJob(ctx, processor); err != nil {
+		return errors.Wrapf(err, "restart driver job of processor %s failed", processor.ID)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4, see https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+
+const (
+	s3SignAlgorithm     = "AWS4-HMAC-SHA256"
+	s3TimeFormat        = "20060102T150405Z"
+	s3DateFormat        = "20060102"
+	s3UnsignedPayload   = "UNSIGNED-PAYLOAD"
+	s3EmptyPayloadHash  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3MaxPresignExpires = 7 * 24 * time.Hour
+)
+
+type s3Signer struct {
+	accessKeyID string
+	secretKey   string
+	region      string
+	now         func() time.Time
+}
+
+// s3EscapePath escapes each segment of the path as S3 wants, the slashes are kept
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		segments[i] = s3Escape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape escapes all characters except the unreserved characters of RFC 3986
+func s3Escape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3CanonicalHeaders returns the canonical headers and the signed headers, the host is always signed
+func s3CanonicalHeaders(host string, header http.Header) (string, string) {
+	values := map[string]string{"host": host}
+	for k, vs := range header {
+		trimmed := make([]string, len(vs))
+		for i, v := range vs {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[strings.ToLower(k)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name + ":" + values[name] + "\n")
+	}
+	return sb.String(), strings.Join(names, ";")
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *s3Signer) scope(t time.Time) string {
+	return t.Format(s3DateFormat) + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *s3Signer) signature(t time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3SignAlgorithm,
+		t.Format(s3TimeFormat),
+		s.scope(t),
+		s3SHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	key := s3HMAC([]byte("AWS4"+s.secretKey), t.Format(s3DateFormat))
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+// presign returns the url with the authentication in the query string, the headers in signedHeader
+// must be sent with the same values by the user of the url
+func (s *s3Signer) presign(method string, u *url.URL, expires time.Duration, signedHeader http.Header) (string, error) {
+	if expires <= 0 || expires > s3MaxPresignExpires {
+		return "", fmt.Errorf("invalid expire duration %s, should be in (0, %s]", expires, s3MaxPresignExpires)
+	}
+	t := s.now().UTC()
+	canonicalHeaders, signedHeaders := s3CanonicalHeaders(u.Host, signedHeader)
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", s3SignAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+s.scope(t))
+	query.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	canonicalQuery := s3CanonicalQuery(query)
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery,
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	signed := *u
+	signed.RawQuery = canonicalQuery + "&X-Amz-Signature=" + s.signature(t, canonicalRequest)
+	return signed.String(), nil
+}
+
+// sign adds the authentication headers to the request, payloadHash is the hex sha256 of the body
+func (s *s3Signer) sign(req *http.Request, payloadHash string) {
+	t := s.now().UTC()
+	req.Header.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	canonicalHeaders, signedHeaders := s3CanonicalHeaders(req.URL.Host, req.Header)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SignAlgorithm, s.accessKeyID, s.scope(t), signedHeaders, s.signature(t, canonicalRequest)))
+}
@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sentioxyz/sentio-core/service/common/storagesystem"
+	"sentioxyz/sentio-core/service/processor/protos"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// S3StorageEngine implements FileStorageEngine for S3 compatible object storage, such as AWS S3, MinIO or R2
+type S3StorageEngine struct {
+	config   S3Config
+	endpoint *url.URL
+	internal *url.URL
+	signer   *s3Signer
+	client   *http.Client
+}
+
+// S3Config configures the S3 storage engine
+type S3Config struct {
+	// Endpoint is the base URL of the S3 service used in the presigned URLs (e.g., "https://s3.us-east-1.amazonaws.com")
+	Endpoint string
+	// InternalEndpoint is used for the requests sent by the server itself, Endpoint is used if empty
+	InternalEndpoint string
+	// Region is the region used to sign the requests, "us-east-1" is used if empty
+	Region      string
+	AccessKeyID string
+	SecretKey   string
+	// Bucket is the default bucket
+	Bucket string
+	// PathStyle puts the bucket in the path instead of the host, most S3 compatible services need it
+	PathStyle bool
+	// HTTPClient is used to send the requests, http.DefaultClient is used if nil
+	HTTPClient *http.Client
+}
+
+// NewS3StorageEngine creates a new S3 storage engine
+func NewS3StorageEngine(config S3Config) (*S3StorageEngine, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("s3 endpoint cannot be empty")
+	}
+	if config.AccessKeyID == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("s3 access key id and secret key cannot be empty")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.InternalEndpoint == "" {
+		config.InternalEndpoint = config.Endpoint
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid s3 endpoint %q", config.Endpoint)
+	}
+	internal, err := url.Parse(config.InternalEndpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid s3 internal endpoint %q", config.InternalEndpoint)
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3StorageEngine{
+		config:   config,
+		endpoint: endpoint,
+		internal: internal,
+		signer: &s3Signer{
+			accessKeyID: config.AccessKeyID,
+			secretKey:   config.SecretKey,
+			region:      config.Region,
+			now:         time.Now,
+		},
+		client: client,
+	}, nil
+}
+
+// objectURL builds the URL of the object in path style or virtual hosted style
+func (e *S3StorageEngine) objectURL(base *url.URL, bucket, object string) *url.URL {
+	u := *base
+	basePath := strings.TrimSuffix(base.Path, "/")
+	if e.config.PathStyle {
+		u.Path = basePath + "/" + bucket + "/" + object
+	} else {
+		u.Host = bucket + "." + base.Host
+		u.Path = basePath + "/" + object
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = ""
+	return &u
+}
+
+// PreSignedPutUrl returns a URL for uploading a file, the uploader must send the same Content-Type
+func (e *S3StorageEngine) PreSignedPutUrl(ctx context.Context, bucket, object, contentType string, expireDuration time.Duration) (string, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return e.signer.presign(http.MethodPut, e.objectURL(e.endpoint, bucket, object), expireDuration, header)
+}
+
+// PreSignedGetUrl returns a URL for downloading a file
+func (e *S3StorageEngine) PreSignedGetUrl(ctx context.Context, bucket, object string, expireDuration time.Duration) (string, error) {
+	return e.signer.presign(http.MethodGet, e.objectURL(e.endpoint, bucket, object), expireDuration, nil)
+}
+
+// do sends a signed request to the internal endpoint, the caller must close the response body
+func (e *S3StorageEngine) do(
+	ctx context.Context,
+	method, bucket, object string,
+	header http.Header,
+	body io.Reader,
+	payloadHash string,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, e.objectURL(e.internal, bucket, object).String(), body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build s3 request")
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	e.signer.sign(req, payloadHash)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "s3 %s %s/%s failed", method, bucket, object)
+	}
+	return resp, nil
+}
+
+// checkResponse returns an error with the S3 error message if the status is not 2xx
+func checkS3Response(resp *http.Response, method, bucket, object string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("s3 %s %s/%s failed, status code: %d, message: %s",
+		method, bucket, object, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// CopyFile copies an object on the server side
+func (e *S3StorageEngine) CopyFile(ctx context.Context, srcBucket, srcObject, destBucket, destObject string) error {
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", s3EscapePath("/"+srcBucket+"/"+srcObject))
+	resp, err := e.do(ctx, http.MethodPut, destBucket, destObject, header, nil, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err = checkS3Response(resp, "copy", destBucket, destObject); err != nil {
+		return err
+	}
+	// the copy may fail after the 200 status is sent, then the error is in the body
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read copy result")
+	}
+	if bytes.Contains(result, []byte("<Error>")) {
+		return fmt.Errorf("s3 copy %s/%s to %s/%s failed: %s", srcBucket, srcObject, destBucket, destObject, result)
+	}
+	return nil
+}
+
+// GetUrl returns the URL for accessing the file
+func (e *S3StorageEngine) GetUrl(ctx context.Context, bucket, object string) string {
+	return fmt.Sprintf("s3://%s/%s", bucket, object)
+}
+
+// Delete removes an object, deleting a missing object is not an error
+func (e *S3StorageEngine) Delete(ctx context.Context, bucket, object string) error {
+	resp, err := e.do(ctx, http.MethodDelete, bucket, object, nil, nil, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkS3Response(resp, "delete", bucket, object)
+}
+
+// UploadLocalFile uploads a local file as the object
+func (e *S3StorageEngine) UploadLocalFile(ctx context.Context, bucket, object, contentType, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open source file")
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to stat source file")
+	}
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", errors.Wrapf(err, "failed to hash source file")
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return "", errors.Wrapf(err, "failed to rewind source file")
+	}
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, e.objectURL(e.internal, bucket, object).String(), file)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to build s3 request")
+	}
+	req.ContentLength = stat.Size()
+	req.Header = header
+	e.signer.sign(req, hex.EncodeToString(hash.Sum(nil)))
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "s3 upload %s/%s failed", bucket, object)
+	}
+	defer resp.Body.Close()
+	if err = checkS3Response(resp, "upload", bucket, object); err != nil {
+		return "", err
+	}
+	return object, nil
+}
+
+// DownloadToLocalFile downloads the object to a local file, the file is replaced only if the download succeeds
+func (e *S3StorageEngine) DownloadToLocalFile(ctx context.Context, bucket, object, filePath string) error {
+	resp, err := e.do(ctx, http.MethodGet, bucket, object, nil, nil, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.Wrapf(os.ErrNotExist, "s3 object %s/%s", bucket, object)
+	}
+	if err = checkS3Response(resp, "download", bucket, object); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temp file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, resp.Body); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "s3 download %s/%s failed", bucket, object)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close temp file")
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+// Name returns the name of the storage engine
+func (e *S3StorageEngine) Name() string {
+	return "s3"
+}
+
+// ObjectExists checks if an object exists
+func (e *S3StorageEngine) ObjectExists(ctx context.Context, bucket string, object string) (bool, error) {
+	resp, err := e.do(ctx, http.MethodHead, bucket, object, nil, nil, s3EmptyPayloadHash)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err = checkS3Response(resp, "head", bucket, object); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (e *S3StorageEngine) ToPayload(file *storagesystem.FileObject, fileID, url string, fileType protos.FileType) *protos.UploadPayload {
+	return &protos.UploadPayload{
+		Payload: &protos.UploadPayload_Object{
+			Object: &protos.UploadPayload_ObjectPayload{
+				PutUrl:   url,
+				Bucket:   file.Bucket,
+				ObjectId: file.GetObject(),
+				FileId:   fileID,
+			},
+		},
+		FileType: fileType,
+	}
+}
+
+// Ensure S3StorageEngine implements FileStorageEngine interface
+var _ storagesystem.FileStorageEngine = (*S3StorageEngine)(nil)
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sentioxyz/sentio-core/service/common/storagesystem"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// S3StorageSystem implements FileStorageSystemInterface for S3 compatible object storage
+// In this implementation:
+// - Files are uploaded to {UploadPrefix}{fileID} in the default bucket
+// - FinalizeUpload moves them to {Prefix}{fileID}
+type S3StorageSystem struct {
+	config        S3StorageSystemConfig
+	defaultEngine *S3StorageEngine
+}
+
+// S3StorageSystemConfig configures the S3 storage system
+type S3StorageSystemConfig struct {
+	S3Config S3Config
+	// Prefix is the object key prefix of the finalized files
+	Prefix string
+	// UploadPrefix is the object key prefix of the files being uploaded
+	UploadPrefix string
+}
+
+// NewS3StorageSystem creates a new S3 storage system
+func NewS3StorageSystem(config S3StorageSystemConfig) (*S3StorageSystem, error) {
+	if config.S3Config.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket cannot be empty")
+	}
+	engine, err := NewS3StorageEngine(config.S3Config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create default s3 storage engine")
+	}
+	return &S3StorageSystem{
+		config:        config,
+		defaultEngine: engine,
+	}, nil
+}
+
+// CreateDefaultStorage returns the default s3 storage engine
+func (s *S3StorageSystem) CreateDefaultStorage(ctx context.Context, preferEngine string) (storagesystem.FileStorageEngine, error) {
+	return s.defaultEngine, nil
+}
+
+// GetUploadFileByID retrieves the file being uploaded by ID
+func (s *S3StorageSystem) GetUploadFileByID(engine storagesystem.FileStorageEngine, fileID string) *storagesystem.FileObject {
+	return s.NewUploadFileWithEngine(engine, fileID, "")
+}
+
+// NewUploadFileWithEngine creates a new file object for upload with a specific engine
+func (s *S3StorageSystem) NewUploadFileWithEngine(engine storagesystem.FileStorageEngine, fileId string, contentType string) *storagesystem.FileObject {
+	if engine == nil {
+		engine = s.defaultEngine
+	}
+	return &storagesystem.FileObject{
+		Engine:      engine,
+		Bucket:      s.config.S3Config.Bucket,
+		Object:      s.config.UploadPrefix + fileId,
+		ContentType: contentType,
+	}
+}
+
+// FinalizeUpload checks the uploaded file and moves it out of the upload prefix
+func (s *S3StorageSystem) FinalizeUpload(ctx context.Context, fileID string, storage storagesystem.FileStorageEngine) (*storagesystem.FileObject, error) {
+	file := s.GetUploadFileByID(storage, fileID)
+	exists, err := file.Engine.ObjectExists(ctx, file.Bucket, file.Object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify file existence")
+	}
+	if !exists {
+		return nil, fmt.Errorf("file %s does not exist", fileID)
+	}
+	if object := s.config.Prefix + fileID; object != file.Object {
+		if err = file.MoveTo(ctx, file.Bucket, object); err != nil {
+			return nil, errors.Wrapf(err, "failed to move uploaded file %s", fileID)
+		}
+		file.Object = object
+	}
+	return file, nil
+}
+
+// GetFromUrl gets a file object from an s3 URL
+// Supports URLs like: s3://{bucket}/{object}
+func (s *S3StorageSystem) GetFromUrl(ctx context.Context, urlStr string) (*storagesystem.FileObject, error) {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse URL")
+	}
+	if parsedURL.Scheme != "s3" {
+		return nil, fmt.Errorf("invalid s3 URL scheme, expected s3://")
+	}
+	object := strings.TrimPrefix(parsedURL.Path, "/")
+	if parsedURL.Host == "" || object == "" {
+		return nil, fmt.Errorf("invalid s3 URL %q, expected s3://{bucket}/{object}", urlStr)
+	}
+	return &storagesystem.FileObject{
+		Engine: s.defaultEngine,
+		Bucket: parsedURL.Host,
+		Object: object,
+	}, nil
+}
+
+// NewUploadFile creates a new file object for upload using the default engine
+func (s *S3StorageSystem) NewUploadFile(ctx context.Context, fileId string, contentType string) (*storagesystem.FileObject, error) {
+	return s.NewUploadFileWithEngine(s.defaultEngine, fileId, contentType), nil
+}
+
+// Ensure S3StorageSystem implements FileStorageSystemInterface
+var _ storagesystem.FileStorageSystemInterface = (*S3StorageSystem)(nil)
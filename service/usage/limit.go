@@ -0,0 +1,200 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/period"
+	"sentioxyz/sentio-core/common/tokenbucket"
+	"sentioxyz/sentio-core/service/usage/models"
+	"sentioxyz/sentio-core/service/usage/protos"
+	"sentioxyz/sentio-core/service/usage/repository"
+)
+
+type limiterEntry struct {
+	limiters []models.Limiter
+	expireAt time.Time
+}
+
+func (s *Service) listLimiters(ctx context.Context, o owner) ([]models.Limiter, error) {
+	cacheKey := o.Type + "/" + o.ID
+	cached, _ := s.limiters.Get(ctx, cacheKey)
+	if entry, has := cached[cacheKey]; has && time.Now().Before(entry.expireAt) {
+		return entry.limiters, nil
+	}
+	limiters, err := s.repo.ListLimiters(ctx, o.ID, o.Type, o.Tier)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list limiters of owner %s/%s failed", o.Type, o.ID)
+	}
+	_ = s.limiters.Set(ctx, map[string]limiterEntry{cacheKey: {
+		limiters: limiters,
+		expireAt: time.Now().Add(s.config.LimiterCacheTTL),
+	}})
+	return limiters, nil
+}
+
+// effectiveLimiters returns the limiters effective at now and match the project and sku,
+// empty project id or sku matches all limiters.
+// The default tier limiters are used only if there is no effective owner limiter.
+func (s *Service) effectiveLimiters(ctx context.Context, o owner, projectID, sku string, now time.Time) ([]models.Limiter, error) {
+	limiters, err := s.listLimiters(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	var ownerLimiters, tierLimiters []models.Limiter
+	for _, l := range limiters {
+		if !l.Effective(now) || l.Period().IsZero() {
+			continue
+		}
+		if (projectID != "" && l.ProjectID != "" && l.ProjectID != projectID) || (sku != "" && l.Sku != "" && l.Sku != sku) {
+			continue
+		}
+		switch l.Category {
+		case models.LimiterCategoryOwner:
+			ownerLimiters = append(ownerLimiters, l)
+		case models.LimiterCategoryDefaultTier:
+			tierLimiters = append(tierLimiters, l)
+		}
+	}
+	if len(ownerLimiters) > 0 {
+		return ownerLimiters, nil
+	}
+	return tierLimiters, nil
+}
+
+func (s *Service) useTokenBucket(l models.Limiter) bool {
+	return l.Succeed > 0 && !l.NaturalMonth && time.Duration(l.PeriodSeconds)*time.Second <= s.config.TokenBucketWindow
+}
+
+func (s *Service) tokenBucketConfig(o owner, l models.Limiter) *tokenbucket.RateLimitConfig {
+	return &tokenbucket.RateLimitConfig{
+		Key:    fmt.Sprintf("usage_limiter:%d", l.ID),
+		Limit:  int64(l.Succeed),
+		Window: time.Duration(l.PeriodSeconds) * time.Second,
+		UserID: o.Type + "/" + o.ID,
+	}
+}
+
+// recordSucceed counts the succeed dialogue in the token buckets of the short period limiters
+func (s *Service) recordSucceed(ctx context.Context, o owner, d *models.Dialogue) {
+	_, logger := log.FromContext(ctx)
+	limiters, err := s.effectiveLimiters(ctx, o, d.ProjectID, d.Sku, d.RequestTime)
+	if err != nil {
+		logger.Warnfe(err, "get limiters of owner %s/%s failed", o.Type, o.ID)
+		return
+	}
+	for _, l := range limiters {
+		if !s.useTokenBucket(l) {
+			continue
+		}
+		// the bucket is full means already over limit, it is fine to drop the record
+		if _, _, err = s.tokenBucket.Allow(ctx, s.tokenBucketConfig(o, l)); err != nil {
+			logger.Warnfe(err, "record succeed dialogue for limiter %d failed", l.ID)
+		}
+	}
+}
+
+func (s *Service) limiterStatus(
+	ctx context.Context,
+	o owner,
+	l models.Limiter,
+	now time.Time,
+) (*protos.CheckOverLimitResponse_OverLimiterStatus, error) {
+	st := &protos.CheckOverLimitResponse_OverLimiterStatus{Limiter: l.ToPB()}
+	p := l.Period()
+	if l.Cost > 0 || (l.Succeed > 0 && !s.useTokenBucket(l)) {
+		start := p.TimeBucket(now)
+		rows, err := s.repo.Aggregate(ctx, repository.AggregateQuery{
+			OwnerID:   o.ID,
+			OwnerType: o.Type,
+			ProjectID: l.ProjectID,
+			Sku:       l.Sku,
+			StartTime: start,
+			EndTime:   period.NewTicker(p, start).Next(),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "aggregate usage for limiter %d failed", l.ID)
+		}
+		for _, row := range rows {
+			st.CurrentCost += row.Cost
+			st.CurrentSucceed += row.Succeed
+		}
+	}
+	if s.useTokenBucket(l) {
+		remaining, _, err := s.tokenBucket.GetRemainingTokens(ctx, s.tokenBucketConfig(o, l))
+		if err != nil {
+			return nil, errors.Wrapf(err, "get remaining tokens for limiter %d failed", l.ID)
+		}
+		st.CurrentSucceed = l.Succeed - uint64(remaining)
+	}
+	return st, nil
+}
+
+func over(l models.Limiter, st *protos.CheckOverLimitResponse_OverLimiterStatus) bool {
+	return (l.Cost > 0 && st.CurrentCost >= l.Cost) || (l.Succeed > 0 && st.CurrentSucceed >= l.Succeed)
+}
+
+func (s *Service) checkOverLimit(
+	ctx context.Context,
+	o owner,
+	projectID, sku string,
+	now time.Time,
+) (*protos.CheckOverLimitResponse, error) {
+	limiters, err := s.effectiveLimiters(ctx, o, projectID, sku, now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	resp := &protos.CheckOverLimitResponse{Status: &protos.CheckOverLimitResponse_Status{}}
+	for _, l := range limiters {
+		st, err := s.limiterStatus(ctx, o, l, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "%v", err)
+		}
+		if !over(l, st) {
+			continue
+		}
+		scope := "all projects"
+		if l.ProjectID != "" {
+			scope = "project " + l.ProjectID
+		}
+		if l.Sku != "" {
+			scope += " of sku " + l.Sku
+		}
+		resp.Over = append(resp.Over, fmt.Sprintf("usage of %s is over the limit in the %s period", scope, l.Period()))
+		resp.OverDetail = append(resp.OverDetail, fmt.Sprintf(
+			"limiter %d: cost %d/%d, succeed %d/%d, period started at %s",
+			l.ID, st.CurrentCost, l.Cost, st.CurrentSucceed, l.Succeed, l.Period().TimeBucket(now).Format(time.RFC3339)))
+		resp.Status.Limiters = append(resp.Status.Limiters, st)
+	}
+	return resp, nil
+}
+
+func (s *Service) CheckOverLimit(ctx context.Context, req *protos.CheckOverLimitRequest) (*protos.CheckOverLimitResponse, error) {
+	now := time.Now()
+	if req.GetNow() != nil {
+		now = req.GetNow().AsTime()
+	}
+	o, err := s.resolveOwner(ctx, req.GetOwnerId(), req.GetOwnerType(), req.GetProjectId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return s.checkOverLimit(ctx, o, req.GetProjectId(), req.GetSku(), now)
+}
+
+func (s *Service) CheckOverLimitExt(ctx context.Context, req *protos.CheckOverLimitExtRequest) (*protos.CheckOverLimitResponse, error) {
+	project, err := s.projectRepo.GetProject(ctx, req.GetProjectOwner(), req.GetProjectSlug())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "project not found: %v", err)
+	}
+	o, err := s.resolveOwner(ctx, project.OwnerID, project.OwnerType, project.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return s.checkOverLimit(ctx, o, project.ID, "", time.Now())
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/datatypes"
+
+	"sentioxyz/sentio-core/common/period"
+	"sentioxyz/sentio-core/service/usage/protos"
+)
+
+// Dialogue is one entry of the usage ledger. Every AsyncSave request appends one row per dialogue,
+// the cost is calculated with the sku cost config effective at RequestTime when the row is saved.
+type Dialogue struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement"`
+	RequestTime time.Time `gorm:"index:idx_usage_dialogue_owner,priority:3;index:idx_usage_dialogue_time;not null"`
+	OwnerID     string    `gorm:"index:idx_usage_dialogue_owner,priority:1;not null"`
+	OwnerType   string    `gorm:"index:idx_usage_dialogue_owner,priority:2;not null"`
+	ProjectID   string    `gorm:"index:idx_usage_dialogue_project"`
+	ProcessorID string
+	UserID      string
+	Sku         string         `gorm:"not null"`
+	CustomTags  datatypes.JSON `gorm:"type:jsonb"`
+	Succeed     bool
+	LatencyMs   uint32
+	Units       uint64
+	Cost        uint64
+}
+
+func (Dialogue) TableName() string {
+	return "usage_dialogues"
+}
+
+func (d *Dialogue) FromPB(dialogue *protos.Dialogue) error {
+	d.RequestTime = dialogue.GetRequestTime().AsTime()
+	if dialogue.GetRequestTime() == nil {
+		d.RequestTime = time.Now()
+	}
+	d.OwnerID = dialogue.GetOwnerId()
+	d.OwnerType = dialogue.GetOwnerType()
+	d.ProjectID = dialogue.GetTags().GetProjectId()
+	d.ProcessorID = dialogue.GetTags().GetProcessorId()
+	d.UserID = dialogue.GetTags().GetUserId()
+	d.Sku = dialogue.GetTags().GetSku()
+	d.Succeed = dialogue.GetSucceed()
+	d.LatencyMs = dialogue.GetLatencyMs()
+	d.Units = dialogue.GetUnits()
+	d.CustomTags = nil
+	if len(dialogue.GetTags().GetCustomTags()) > 0 {
+		raw, err := json.Marshal(dialogue.GetTags().GetCustomTags())
+		if err != nil {
+			return err
+		}
+		d.CustomTags = raw
+	}
+	return nil
+}
+
+// SkuCost is the cost of one unit of the sku, effective from EffectiveFrom until the next config of the same sku.
+type SkuCost struct {
+	Sku           string    `gorm:"primaryKey"`
+	EffectiveFrom time.Time `gorm:"primaryKey"`
+	Cost          uint64
+}
+
+func (SkuCost) TableName() string {
+	return "usage_sku_costs"
+}
+
+func (c *SkuCost) ToPB() *protos.SkuCostConfig {
+	return &protos.SkuCostConfig{
+		Sku:           c.Sku,
+		Cost:          c.Cost,
+		EffectiveFrom: timestamppb.New(c.EffectiveFrom),
+	}
+}
+
+func (c *SkuCost) FromPB(config *protos.SkuCostConfig) {
+	c.Sku = config.GetSku()
+	c.Cost = config.GetCost()
+	c.EffectiveFrom = config.GetEffectiveFrom().AsTime()
+}
+
+// Sku holds the display meta of a sku, the cost is maintained in SkuCost
+type Sku struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	Desc      string
+	Visible   bool
+}
+
+func (Sku) TableName() string {
+	return "usage_skus"
+}
+
+func (s *Sku) ToPB(cost uint64) *protos.Sku {
+	return &protos.Sku{
+		Id:        s.ID,
+		CreatedAt: timestamppb.New(s.CreatedAt),
+		UpdatedAt: timestamppb.New(s.UpdatedAt),
+		Name:      s.Name,
+		Desc:      s.Desc,
+		Cost:      cost,
+		Visible:   s.Visible,
+	}
+}
+
+type LimiterCategory string
+
+const (
+	LimiterCategoryOwner       LimiterCategory = "owner"
+	LimiterCategoryDefaultTier LimiterCategory = "default_tier"
+)
+
+// Limiter limits the cost or the succeed count of an owner in every period.
+// Owner limiters take precedence, the default tier limiters of the owner tier only take effect
+// if the owner has no owner limiter.
+type Limiter struct {
+	ID            uint64          `gorm:"primaryKey;autoIncrement"`
+	Category      LimiterCategory `gorm:"index:idx_usage_limiter_owner,priority:1;not null"`
+	OwnerID       string          `gorm:"index:idx_usage_limiter_owner,priority:2"`
+	OwnerType     string
+	Tier          string `gorm:"index:idx_usage_limiter_owner,priority:3"`
+	ProjectID     string // empty means *
+	Sku           string // empty means *
+	EffectiveTime time.Time
+	ExpireTime    *time.Time
+	NaturalMonth  bool
+	PeriodSeconds uint64
+	// zero means no limit
+	Cost    uint64
+	Succeed uint64
+}
+
+func (Limiter) TableName() string {
+	return "usage_limiters"
+}
+
+func (l *Limiter) Period() period.Period {
+	if l.NaturalMonth {
+		return period.Month
+	}
+	return period.Second.Multi(l.PeriodSeconds)
+}
+
+func (l *Limiter) Effective(now time.Time) bool {
+	if now.Before(l.EffectiveTime) {
+		return false
+	}
+	return l.ExpireTime == nil || now.Before(*l.ExpireTime)
+}
+
+func (l *Limiter) ToPB() *protos.CheckOverLimitResponse_Limiter {
+	pb := &protos.CheckOverLimitResponse_Limiter{
+		Category:      protos.CheckOverLimitResponse_Limiter_OWNER_LIMITER,
+		EffectiveTime: timestamppb.New(l.EffectiveTime),
+		OwnerId:       l.OwnerID,
+		OwnerType:     l.OwnerType,
+		ProjectId:     l.ProjectID,
+		Sku:           l.Sku,
+		Period: &protos.Period{
+			NatualMonth: l.NaturalMonth,
+			Seconds:     l.PeriodSeconds,
+		},
+		Cost:    l.Cost,
+		Succeed: l.Succeed,
+	}
+	if l.Category == LimiterCategoryDefaultTier {
+		pb.Category = protos.CheckOverLimitResponse_Limiter_DEFAULT_TIER_LIMITER
+	}
+	if l.ExpireTime != nil {
+		pb.ExpireTime = timestamppb.New(*l.ExpireTime)
+	}
+	return pb
+}
+
+// PeriodFromPB converts the period in the request, a natual month period ignores the seconds
+func PeriodFromPB(p *protos.Period) period.Period {
+	if p.GetNatualMonth() {
+		return period.Month
+	}
+	return period.Second.Multi(p.GetSeconds())
+}
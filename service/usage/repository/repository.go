@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sentioxyz/sentio-core/common/period"
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	"sentioxyz/sentio-core/service/usage/models"
+)
+
+type GroupField string
+
+const (
+	GroupByOwner      GroupField = "owner"
+	GroupByProject    GroupField = "project"
+	GroupByUser       GroupField = "user"
+	GroupBySku        GroupField = "sku"
+	GroupByCustomTags GroupField = "custom_tags"
+)
+
+// AggregateQuery filters the dialogues in [StartTime, EndTime) and aggregates them by the time bucket of Period
+// and GroupBy fields. Zero Period means the whole time range is one bucket, which will be StartTime.
+type AggregateQuery struct {
+	OwnerID   string
+	OwnerType string
+	ProjectID string
+	UserID    string
+	Sku       string
+	StartTime time.Time
+	EndTime   time.Time
+	Period    period.Period
+	GroupBy   []GroupField
+	// if OrderByCost is true, rows are sorted by cost desc, otherwise by time window asc
+	OrderByCost bool
+	Offset      int
+	Limit       int
+}
+
+type AggregateRow struct {
+	TimeWindow   time.Time
+	OwnerID      string
+	OwnerType    string
+	ProjectID    string
+	UserID       string
+	Sku          string
+	CustomTags   datatypes.JSON
+	Cost         uint64
+	Units        uint64
+	Succeed      uint64
+	Total        uint64
+	LatencyMsMax uint32
+	LatencyMsSum uint64
+}
+
+type UsageRepo interface {
+	SaveDialogues(ctx context.Context, dialogues []*models.Dialogue) error
+	Aggregate(ctx context.Context, query AggregateQuery) ([]AggregateRow, error)
+
+	ListSkuCosts(ctx context.Context) ([]models.SkuCost, error)
+	SaveSkuCosts(ctx context.Context, configs []models.SkuCost) error
+	DeleteSkuCost(ctx context.Context, sku string, effectiveFrom time.Time) error
+
+	ListSkus(ctx context.Context) ([]models.Sku, error)
+	SaveSku(ctx context.Context, sku *models.Sku) error
+
+	// ListLimiters returns the owner limiters of the owner and the default tier limiters of the tier
+	ListLimiters(ctx context.Context, ownerID, ownerType, tier string) ([]models.Limiter, error)
+	SaveLimiter(ctx context.Context, limiter *models.Limiter) error
+	// GetOwnerTier returns the tier of the user or organization, empty string means unknown
+	GetOwnerTier(ctx context.Context, ownerID, ownerType string) (string, error)
+}
+
+// Models are the tables should be migrated before using UsageRepo
+var Models = []any{
+	&models.Dialogue{},
+	&models.SkuCost{},
+	&models.Sku{},
+	&models.Limiter{},
+}
+
+type usageRepo struct {
+	db *gorm.DB
+}
+
+func NewUsageRepo(db *gorm.DB) UsageRepo {
+	return &usageRepo{db: db}
+}
+
+func (r *usageRepo) SaveDialogues(ctx context.Context, dialogues []*models.Dialogue) error {
+	if len(dialogues) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(dialogues, 1000).Error
+}
+
+// pgBucketBase is the same as the base time used by period.TimeBucket
+const pgBucketBase = "TIMESTAMPTZ '2000-01-01 00:00:00+00'"
+
+func bucketExpr(p period.Period, start time.Time) (string, []any) {
+	if p.IsZero() {
+		return "?::timestamptz", []any{start}
+	}
+	if months, ok := p.Div(period.Month); ok && months > 0 {
+		// month index since 2000-01, floor divided by months
+		idx := "((EXTRACT(YEAR FROM request_time AT TIME ZONE 'UTC')::int - 2000) * 12" +
+			" + EXTRACT(MONTH FROM request_time AT TIME ZONE 'UTC')::int - 1)"
+		return fmt.Sprintf("(%s + make_interval(months => (FLOOR(%s / %d.0) * %d)::int))",
+			pgBucketBase, idx, months, months), nil
+	}
+	seconds, _ := p.Div(period.Second)
+	return fmt.Sprintf("(%s + make_interval(secs => FLOOR(EXTRACT(EPOCH FROM request_time - %s) / %d) * %d))",
+		pgBucketBase, pgBucketBase, seconds, seconds), nil
+}
+
+var groupColumns = map[GroupField][]string{
+	GroupByOwner:      {"owner_id", "owner_type"},
+	GroupByProject:    {"project_id"},
+	GroupByUser:       {"user_id"},
+	GroupBySku:        {"sku"},
+	GroupByCustomTags: {"custom_tags"},
+}
+
+func (r *usageRepo) Aggregate(ctx context.Context, query AggregateQuery) ([]AggregateRow, error) {
+	bucket, bucketArgs := bucketExpr(query.Period, query.StartTime)
+	selects := []string{bucket + " AS time_window"}
+	groups := []string{"time_window"}
+	for _, field := range query.GroupBy {
+		columns, has := groupColumns[field]
+		if !has {
+			return nil, errors.Errorf("unknown group field %q", field)
+		}
+		selects = append(selects, columns...)
+		groups = append(groups, columns...)
+	}
+	selects = append(selects,
+		"COALESCE(SUM(cost), 0) AS cost",
+		"COALESCE(SUM(units), 0) AS units",
+		"COUNT(*) FILTER (WHERE succeed) AS succeed",
+		"COUNT(*) AS total",
+		"COALESCE(MAX(latency_ms), 0) AS latency_ms_max",
+		"COALESCE(SUM(latency_ms), 0) AS latency_ms_sum",
+	)
+	db := r.db.WithContext(ctx).
+		Model(&models.Dialogue{}).
+		Select(strings.Join(selects, ", "), bucketArgs...).
+		Where("request_time >= ? AND request_time < ?", query.StartTime, query.EndTime)
+	for column, value := range map[string]string{
+		"owner_id":   query.OwnerID,
+		"owner_type": query.OwnerType,
+		"project_id": query.ProjectID,
+		"user_id":    query.UserID,
+		"sku":        query.Sku,
+	} {
+		if value != "" {
+			db = db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+		}
+	}
+	db = db.Group(strings.Join(groups, ", "))
+	if query.OrderByCost {
+		db = db.Order("cost DESC")
+	} else {
+		db = db.Order("time_window")
+	}
+	if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	var rows []AggregateRow
+	if err := db.Scan(&rows).Error; err != nil {
+		return nil, errors.Wrapf(err, "aggregate dialogues failed")
+	}
+	return rows, nil
+}
+
+func (r *usageRepo) ListSkuCosts(ctx context.Context) ([]models.SkuCost, error) {
+	var configs []models.SkuCost
+	err := r.db.WithContext(ctx).Order("sku, effective_from").Find(&configs).Error
+	return configs, err
+}
+
+func (r *usageRepo) SaveSkuCosts(ctx context.Context, configs []models.SkuCost) error {
+	if len(configs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sku"}, {Name: "effective_from"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cost"}),
+	}).Create(&configs).Error
+}
+
+func (r *usageRepo) DeleteSkuCost(ctx context.Context, sku string, effectiveFrom time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("sku = ? AND effective_from = ?", sku, effectiveFrom).
+		Delete(&models.SkuCost{}).Error
+}
+
+func (r *usageRepo) ListSkus(ctx context.Context) ([]models.Sku, error) {
+	var skus []models.Sku
+	err := r.db.WithContext(ctx).Order("id").Find(&skus).Error
+	return skus, err
+}
+
+func (r *usageRepo) SaveSku(ctx context.Context, sku *models.Sku) error {
+	return r.db.WithContext(ctx).Save(sku).Error
+}
+
+func (r *usageRepo) ListLimiters(ctx context.Context, ownerID, ownerType, tier string) ([]models.Limiter, error) {
+	var limiters []models.Limiter
+	err := r.db.WithContext(ctx).
+		Where("(category = ? AND owner_id = ? AND owner_type = ?) OR (category = ? AND tier = ?)",
+			models.LimiterCategoryOwner, ownerID, ownerType,
+			models.LimiterCategoryDefaultTier, tier).
+		Order("id").
+		Find(&limiters).Error
+	return limiters, err
+}
+
+func (r *usageRepo) SaveLimiter(ctx context.Context, limiter *models.Limiter) error {
+	return r.db.WithContext(ctx).Save(limiter).Error
+}
+
+func (r *usageRepo) GetOwnerTier(ctx context.Context, ownerID, ownerType string) (string, error) {
+	var join string
+	switch ownerType {
+	case commonmodels.ProjectOwnerTypeUser:
+		join = "JOIN users ON users.username = owners.name AND users.id = ?"
+	case commonmodels.ProjectOwnerTypeOrg:
+		join = "JOIN organizations ON organizations.name = owners.name AND organizations.id = ?"
+	default:
+		return "", nil
+	}
+	var tiers []string
+	err := r.db.WithContext(ctx).Table("owners").Joins(join, ownerID).Limit(1).Pluck("owners.tier", &tiers).Error
+	if err != nil || len(tiers) == 0 {
+		return "", err
+	}
+	return tiers[0], nil
+}
@@ -0,0 +1,189 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sentioxyz/sentio-core/common/period"
+	"sentioxyz/sentio-core/common/requestlimiter"
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	"sentioxyz/sentio-core/service/usage/models"
+	"sentioxyz/sentio-core/service/usage/protos"
+	"sentioxyz/sentio-core/service/usage/repository"
+)
+
+const (
+	defaultRankingLimit = 100
+	maxRankingLimit     = 1000
+)
+
+// acquireQuery limits the concurrent aggregation queries of one owner, the returned function releases the quota
+func (s *Service) acquireQuery(ctx context.Context, ownerID string, req requestlimiter.RequestData) (func(), error) {
+	vars := requestlimiter.RequestVars{OwnerID: ownerID, Data: req}
+	limiterID, ok, err := s.queryLimiter.Acquire(ctx, vars)
+	if err != nil && !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many concurrent usage queries: %v", err)
+	}
+	if err != nil {
+		// the limiter itself is broken, do not block the query
+		return func() {}, nil
+	}
+	return func() {
+		s.queryLimiter.Release(ctx, vars, limiterID)
+	}, nil
+}
+
+func toStat(row repository.AggregateRow) (*protos.StatisticResponse_Stat, error) {
+	stat := &protos.StatisticResponse_Stat{
+		TimeWindow: timestamppb.New(row.TimeWindow),
+		OwnerId:    row.OwnerID,
+		OwnerType:  row.OwnerType,
+		Tags: &protos.Tags{
+			ProjectId: row.ProjectID,
+			UserId:    row.UserID,
+			Sku:       row.Sku,
+		},
+		Cost:         row.Cost,
+		Units:        row.Units,
+		Succeed:      row.Succeed,
+		Total:        row.Total,
+		LatencyMsMax: row.LatencyMsMax,
+	}
+	if row.Total > 0 {
+		stat.LatencyMsAvg = uint32(row.LatencyMsSum / row.Total)
+	}
+	if len(row.CustomTags) > 0 {
+		if err := json.Unmarshal(row.CustomTags, &stat.Tags.CustomTags); err != nil {
+			return nil, err
+		}
+	}
+	return stat, nil
+}
+
+func toStatisticResponse(rows []repository.AggregateRow) (*protos.StatisticResponse, error) {
+	var resp protos.StatisticResponse
+	for _, row := range rows {
+		stat, err := toStat(row)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid custom tags: %v", err)
+		}
+		resp.Stats = append(resp.Stats, stat)
+	}
+	return &resp, nil
+}
+
+func (s *Service) statistic(ctx context.Context, req *protos.StatisticRequest) (*protos.StatisticResponse, error) {
+	if req.GetStartTime() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "start_time is required")
+	}
+	query := repository.AggregateQuery{
+		OwnerID:   req.GetOwnerId(),
+		OwnerType: req.GetOwnerType(),
+		ProjectID: req.GetTags().GetProjectId(),
+		UserID:    req.GetTags().GetUserId(),
+		Sku:       req.GetTags().GetSku(),
+		StartTime: req.GetStartTime().AsTime(),
+		EndTime:   time.Now(),
+		Period:    models.PeriodFromPB(req.GetPeriod()),
+		GroupBy: []repository.GroupField{
+			repository.GroupByOwner,
+			repository.GroupByProject,
+			repository.GroupByUser,
+			repository.GroupBySku,
+		},
+	}
+	if req.GetEndTime() != nil {
+		query.EndTime = req.GetEndTime().AsTime()
+	}
+	if !query.StartTime.Before(query.EndTime) {
+		return nil, status.Errorf(codes.InvalidArgument, "start_time should be before end_time")
+	}
+	if req.GetTags().GetSplitCustomTags() {
+		query.GroupBy = append(query.GroupBy, repository.GroupByCustomTags)
+	}
+	release, err := s.acquireQuery(ctx, query.OwnerID, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := s.repo.Aggregate(ctx, query)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return toStatisticResponse(rows)
+}
+
+func (s *Service) Statistic(ctx context.Context, req *protos.StatisticRequest) (*protos.StatisticResponse, error) {
+	if req.GetOwnerId() == "" && req.GetTags().GetProjectId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "owner_id or project_id is required")
+	}
+	return s.statistic(ctx, req)
+}
+
+// StatisticExt is exposed by the gateway, the caller must specify the owner
+func (s *Service) StatisticExt(ctx context.Context, req *protos.StatisticRequest) (*protos.StatisticResponse, error) {
+	if req.GetOwnerId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "owner_id is required")
+	}
+	return s.statistic(ctx, req)
+}
+
+func (s *Service) StatisticAdmin(ctx context.Context, req *protos.StatisticRequest) (*protos.StatisticResponse, error) {
+	return s.statistic(ctx, req)
+}
+
+func (s *Service) Ranking(ctx context.Context, req *protos.RankingRequest) (*protos.StatisticResponse, error) {
+	p := models.PeriodFromPB(req.GetPeriod())
+	if p.IsZero() {
+		return nil, status.Errorf(codes.InvalidArgument, "period is required")
+	}
+	at := time.Now()
+	if req.GetTime() != nil {
+		at = req.GetTime().AsTime()
+	}
+	start := p.TimeBucket(at)
+	query := repository.AggregateQuery{
+		UserID:      req.GetUserId(),
+		Sku:         req.GetSku(),
+		StartTime:   start,
+		EndTime:     period.NewTicker(p, start).Next(),
+		GroupBy:     []repository.GroupField{repository.GroupByOwner},
+		OrderByCost: true,
+		Offset:      int(req.GetOffset()),
+		Limit:       int(req.GetLimit()),
+	}
+	switch req.GetOwnerType() {
+	case protos.RankingRequest_USER:
+		query.OwnerType = commonmodels.ProjectOwnerTypeUser
+	case protos.RankingRequest_ORG:
+		query.OwnerType = commonmodels.ProjectOwnerTypeOrg
+	}
+	if req.GetTarget() == protos.RankingRequest_PROJECT {
+		query.OwnerID = req.GetOwnerId()
+		query.GroupBy = append(query.GroupBy, repository.GroupByProject)
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultRankingLimit
+	} else if query.Limit > maxRankingLimit {
+		query.Limit = maxRankingLimit
+	}
+	release, err := s.acquireQuery(ctx, query.OwnerID, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	rows, err := s.repo.Aggregate(ctx, query)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return toStatisticResponse(rows)
+}
+
+func (s *Service) RankingAdmin(ctx context.Context, req *protos.RankingRequest) (*protos.StatisticResponse, error) {
+	return s.Ranking(ctx, req)
+}
@@ -0,0 +1,449 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"sentioxyz/sentio-core/common/kvstore"
+	"sentioxyz/sentio-core/common/log"
+	"sentioxyz/sentio-core/common/requestlimiter"
+	"sentioxyz/sentio-core/common/tokenbucket"
+	commonprotos "sentioxyz/sentio-core/service/common/protos"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+	"sentioxyz/sentio-core/service/usage/models"
+	"sentioxyz/sentio-core/service/usage/protos"
+	"sentioxyz/sentio-core/service/usage/repository"
+)
+
+type Config struct {
+	// dialogues saved by AsyncSave are buffered and flushed in batch
+	QueueSize      int           `yaml:"queue_size"`
+	FlushInterval  time.Duration `yaml:"flush_interval"`
+	FlushBatchSize int           `yaml:"flush_batch_size"`
+	// a failed flush is retried up to FlushRetries times, the backoff starts from FlushRetryBackoff and doubles
+	FlushRetries      int           `yaml:"flush_retries"`
+	FlushRetryBackoff time.Duration `yaml:"flush_retry_backoff"`
+
+	OwnerCacheSize   int           `yaml:"owner_cache_size"`
+	OwnerCacheTTL    time.Duration `yaml:"owner_cache_ttl"`
+	LimiterCacheSize int           `yaml:"limiter_cache_size"`
+	LimiterCacheTTL  time.Duration `yaml:"limiter_cache_ttl"`
+	SkuCostCacheTTL  time.Duration `yaml:"sku_cost_cache_ttl"`
+	// succeed limit of the limiter whose period is not longer than this is counted by the token bucket in redis,
+	// the other limits are checked by aggregating the dialogues
+	TokenBucketWindow time.Duration `yaml:"token_bucket_window"`
+
+	// concurrent Statistic/Ranking queries, zero means no limit
+	QueryLimiter        requestlimiter.LimiterConfig `yaml:"query_limiter"`
+	QueryLimiterTimeout time.Duration                `yaml:"query_limiter_timeout"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		QueueSize:           10000,
+		FlushInterval:       time.Second * 5,
+		FlushBatchSize:      1000,
+		FlushRetries:        3,
+		FlushRetryBackoff:   time.Second,
+		OwnerCacheSize:      10000,
+		OwnerCacheTTL:       time.Minute * 5,
+		LimiterCacheSize:    10000,
+		LimiterCacheTTL:     time.Second * 30,
+		SkuCostCacheTTL:     time.Minute,
+		TokenBucketWindow:   time.Hour,
+		QueryLimiterTimeout: time.Minute,
+	}
+}
+
+type Service struct {
+	protos.UnimplementedUsageServiceServer
+
+	config       Config
+	repo         repository.UsageRepo
+	projectRepo  projectrepo.ProjectRepository
+	tokenBucket  tokenbucket.TokenBucket
+	queryLimiter requestlimiter.Limiter
+
+	queue chan *models.Dialogue
+
+	owners   *kvstore.LRUKVStore[ownerEntry]
+	limiters *kvstore.LRUKVStore[limiterEntry]
+	skuCosts skuCostTable
+}
+
+func NewService(
+	repo repository.UsageRepo,
+	projectRepo projectrepo.ProjectRepository,
+	redisClient *redis.Client,
+	config Config,
+) (*Service, error) {
+	owners, err := kvstore.NewLRUKVStore[ownerEntry](config.OwnerCacheSize)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create owner cache failed")
+	}
+	limiters, err := kvstore.NewLRUKVStore[limiterEntry](config.LimiterCacheSize)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create limiter cache failed")
+	}
+	return &Service{
+		config:      config,
+		repo:        repo,
+		projectRepo: projectRepo,
+		tokenBucket: tokenbucket.NewTokenBucket(redisClient),
+		queryLimiter: requestlimiter.NewLimiterWithConfig(
+			"usage_query", redisClient, config.QueryLimiterTimeout, config.QueryLimiter, nil),
+		queue:    make(chan *models.Dialogue, config.QueueSize),
+		owners:   owners,
+		limiters: limiters,
+	}, nil
+}
+
+// Run flushes the dialogues saved by AsyncSave until ctx is done, the remaining dialogues in the queue
+// will be flushed before return. A batch failed to be saved is retried with backoff before it is dropped.
+func (s *Service) Run(ctx context.Context) {
+	_, logger := log.FromContext(ctx, "svrName", "usage")
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]*models.Dialogue, 0, s.config.FlushBatchSize)
+	flush := func(flushCtx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.saveWithRetry(flushCtx, s.prepareDialogues(flushCtx, batch)); err != nil {
+			logger.Errorfe(err, "flush %d dialogues failed, dropped", len(batch))
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			for drained := false; !drained; {
+				select {
+				case d := <-s.queue:
+					batch = append(batch, d)
+				default:
+					drained = true
+				}
+			}
+			flushCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			flush(flushCtx)
+			cancel()
+			return
+		case d := <-s.queue:
+			batch = append(batch, d)
+			if len(batch) >= s.config.FlushBatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+func (s *Service) AsyncSave(ctx context.Context, req *protos.AsyncSaveRequest) (*protos.AsyncSaveResponse, error) {
+	var overflow []*models.Dialogue
+	for _, dialogue := range req.GetDialogues() {
+		var d models.Dialogue
+		if err := d.FromPB(dialogue); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid dialogue: %v", err)
+		}
+		if d.Sku == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "dialogue without sku")
+		}
+		select {
+		case s.queue <- &d:
+		default:
+			overflow = append(overflow, &d)
+		}
+	}
+	if len(overflow) > 0 {
+		// queue is full, save the rest synchronously as backpressure to the callers
+		if err := s.saveDialogues(ctx, overflow); err != nil {
+			return nil, status.Errorf(codes.Internal, "save dialogues failed: %v", err)
+		}
+	}
+	return &protos.AsyncSaveResponse{}, nil
+}
+
+func (s *Service) saveDialogues(ctx context.Context, dialogues []*models.Dialogue) error {
+	return s.repo.SaveDialogues(ctx, s.prepareDialogues(ctx, dialogues))
+}
+
+// saveWithRetry saves the prepared dialogues, retrying with backoff. The dialogues are already counted by the
+// token buckets in prepareDialogues, so only the saving is retried.
+func (s *Service) saveWithRetry(ctx context.Context, dialogues []*models.Dialogue) error {
+	_, logger := log.FromContext(ctx)
+	backoff := s.config.FlushRetryBackoff
+	for i := 0; ; i++ {
+		err := s.repo.SaveDialogues(ctx, dialogues)
+		if err == nil || i >= s.config.FlushRetries {
+			return err
+		}
+		logger.Warnfe(err, "save %d dialogues failed, will retry after %s", len(dialogues), backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// prepareDialogues fills the owner and the cost of the dialogues and counts the succeed ones, the dialogues
+// whose owner or cost is unknown are dropped
+func (s *Service) prepareDialogues(ctx context.Context, dialogues []*models.Dialogue) []*models.Dialogue {
+	_, logger := log.FromContext(ctx)
+	valid := make([]*models.Dialogue, 0, len(dialogues))
+	for _, d := range dialogues {
+		o, err := s.resolveOwner(ctx, d.OwnerID, d.OwnerType, d.ProjectID)
+		if err != nil {
+			logger.Warnfe(err, "resolve owner of dialogue failed, project: %q, sku: %q", d.ProjectID, d.Sku)
+			continue
+		}
+		d.OwnerID, d.OwnerType = o.ID, o.Type
+		cost, err := s.skuCost(ctx, d.Sku, d.RequestTime)
+		if err != nil {
+			logger.Warnfe(err, "get cost of dialogue failed, project: %q, sku: %q", d.ProjectID, d.Sku)
+			continue
+		}
+		d.Cost = cost * d.Units
+		if d.Succeed {
+			s.recordSucceed(ctx, o, d)
+		}
+		valid = append(valid, d)
+	}
+	return valid
+}
+
+type ownerEntry struct {
+	owner
+	expireAt time.Time
+}
+
+type owner struct {
+	ID   string
+	Type string
+	Tier string
+}
+
+// resolveOwner returns the owner by the owner id, if the owner id is empty, use the owner of the project
+func (s *Service) resolveOwner(ctx context.Context, ownerID, ownerType, projectID string) (owner, error) {
+	cacheKey := ownerType + "/" + ownerID
+	if ownerID == "" {
+		if projectID == "" {
+			return owner{}, errors.Errorf("both owner id and project id are empty")
+		}
+		cacheKey = "project/" + projectID
+	}
+	cached, _ := s.owners.Get(ctx, cacheKey)
+	if entry, has := cached[cacheKey]; has && time.Now().Before(entry.expireAt) {
+		return entry.owner, nil
+	}
+	o := owner{ID: ownerID, Type: ownerType}
+	if ownerID == "" {
+		project, err := s.projectRepo.GetProjectById(ctx, projectID)
+		if err != nil {
+			return owner{}, errors.Wrapf(err, "get project %q failed", projectID)
+		}
+		o.ID, o.Type = project.OwnerID, project.OwnerType
+	}
+	tier, err := s.repo.GetOwnerTier(ctx, o.ID, o.Type)
+	if err != nil {
+		return owner{}, errors.Wrapf(err, "get tier of owner %s/%s failed", o.Type, o.ID)
+	}
+	o.Tier = tier
+	if o.Tier == "" {
+		o.Tier = commonprotos.Tier_FREE.String()
+	}
+	_ = s.owners.Set(ctx, map[string]ownerEntry{cacheKey: {owner: o, expireAt: time.Now().Add(s.config.OwnerCacheTTL)}})
+	return o, nil
+}
+
+type skuCostTable struct {
+	sync.Mutex
+	loadedAt time.Time
+	// sku => configs sorted by effective time
+	configs map[string][]models.SkuCost
+}
+
+func (s *Service) loadSkuCosts(ctx context.Context) (map[string][]models.SkuCost, error) {
+	s.skuCosts.Lock()
+	defer s.skuCosts.Unlock()
+	if s.skuCosts.configs != nil && time.Since(s.skuCosts.loadedAt) < s.config.SkuCostCacheTTL {
+		return s.skuCosts.configs, nil
+	}
+	configs, err := s.repo.ListSkuCosts(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list sku cost configs failed")
+	}
+	table := make(map[string][]models.SkuCost)
+	for _, c := range configs {
+		table[c.Sku] = append(table[c.Sku], c)
+	}
+	for _, list := range table {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].EffectiveFrom.Before(list[j].EffectiveFrom)
+		})
+	}
+	s.skuCosts.configs, s.skuCosts.loadedAt = table, time.Now()
+	return table, nil
+}
+
+func (s *Service) invalidateSkuCosts() {
+	s.skuCosts.Lock()
+	defer s.skuCosts.Unlock()
+	s.skuCosts.configs = nil
+}
+
+// effectiveSkuCost returns the config effective at the time, nil means the sku has no cost at the time
+func effectiveSkuCost(configs []models.SkuCost, at time.Time) *models.SkuCost {
+	var effective *models.SkuCost
+	for i := range configs {
+		if configs[i].EffectiveFrom.After(at) {
+			break
+		}
+		effective = &configs[i]
+	}
+	return effective
+}
+
+func (s *Service) skuCost(ctx context.Context, sku string, at time.Time) (uint64, error) {
+	table, err := s.loadSkuCosts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if c := effectiveSkuCost(table[sku], at); c != nil {
+		return c.Cost, nil
+	}
+	return 0, nil
+}
+
+func (s *Service) ListSkuCostConfigAdmin(
+	ctx context.Context,
+	req *protos.ListSkuCostConfigRequest,
+) (*protos.ListSkuCostConfigResponse, error) {
+	s.invalidateSkuCosts()
+	table, err := s.loadSkuCosts(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	now := time.Now()
+	skus := make([]string, 0, len(table))
+	for sku := range table {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+	var resp protos.ListSkuCostConfigResponse
+	for _, sku := range skus {
+		current := effectiveSkuCost(table[sku], now)
+		for _, c := range table[sku] {
+			if req.GetIgnoreFuture() && c.EffectiveFrom.After(now) {
+				continue
+			}
+			if req.GetIgnoreHistory() && current != nil && c.EffectiveFrom.Before(current.EffectiveFrom) {
+				continue
+			}
+			resp.Configs = append(resp.Configs, c.ToPB())
+		}
+	}
+	return &resp, nil
+}
+
+func (s *Service) SetSkuCostAdmin(ctx context.Context, req *protos.SetSkuCostRequest) (*protos.SetSkuCostResponse, error) {
+	configs := make([]models.SkuCost, len(req.GetConfigs()))
+	for i, config := range req.GetConfigs() {
+		if config.GetSku() == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "sku cost config without sku")
+		}
+		configs[i].FromPB(config)
+		if config.GetEffectiveFrom() == nil {
+			configs[i].EffectiveFrom = time.Now()
+		}
+	}
+	if err := s.repo.SaveSkuCosts(ctx, configs); err != nil {
+		return nil, status.Errorf(codes.Internal, "save sku cost configs failed: %v", err)
+	}
+	s.invalidateSkuCosts()
+	return &protos.SetSkuCostResponse{}, nil
+}
+
+func (s *Service) DelSkuCostAdmin(ctx context.Context, req *protos.DelSkuCostRequest) (*protos.DelSkuCostResponse, error) {
+	if req.GetSku() == "" || req.GetEffectiveFrom() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "sku and effective_from are required")
+	}
+	if err := s.repo.DeleteSkuCost(ctx, req.GetSku(), req.GetEffectiveFrom().AsTime()); err != nil {
+		return nil, status.Errorf(codes.Internal, "delete sku cost config failed: %v", err)
+	}
+	s.invalidateSkuCosts()
+	return &protos.DelSkuCostResponse{}, nil
+}
+
+func (s *Service) ListSKU(ctx context.Context, req *protos.ListSKURequest) (*protos.ListSKUResponse, error) {
+	at := time.Now()
+	if req.GetTime() != nil {
+		at = req.GetTime().AsTime()
+	}
+	table, err := s.loadSkuCosts(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	metas, err := s.repo.ListSkus(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list skus failed: %v", err)
+	}
+	skus := make(map[string]models.Sku)
+	for _, meta := range metas {
+		skus[meta.ID] = meta
+	}
+	for sku := range table {
+		if _, has := skus[sku]; !has {
+			// sku without meta is visible and named by its id
+			skus[sku] = models.Sku{ID: sku, Name: sku, Visible: true}
+		}
+	}
+	var resp protos.ListSKUResponse
+	for _, sku := range skus {
+		if !sku.Visible && !req.GetWithInvisible() {
+			continue
+		}
+		var cost uint64
+		if c := effectiveSkuCost(table[sku.ID], at); c != nil {
+			cost = c.Cost
+		}
+		resp.SkuList = append(resp.SkuList, sku.ToPB(cost))
+	}
+	sort.Slice(resp.SkuList, func(i, j int) bool {
+		return resp.SkuList[i].Id < resp.SkuList[j].Id
+	})
+	return &resp, nil
+}
+
+func (s *Service) UpdateSKUMetaAdmin(ctx context.Context, req *protos.UpdateSKUMetaRequest) (*emptypb.Empty, error) {
+	if req.GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "empty sku id")
+	}
+	metas, err := s.repo.ListSkus(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list skus failed: %v", err)
+	}
+	sku := models.Sku{ID: req.GetId()}
+	for _, meta := range metas {
+		if meta.ID == req.GetId() {
+			sku = meta
+			break
+		}
+	}
+	sku.Name, sku.Desc, sku.Visible = req.GetName(), req.GetDesc(), req.GetVisible()
+	if err = s.repo.SaveSku(ctx, &sku); err != nil {
+		return nil, status.Errorf(codes.Internal, "save sku failed: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,404 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonmodels "sentioxyz/sentio-core/service/common/models"
+	projectrepo "sentioxyz/sentio-core/service/project/repository"
+	"sentioxyz/sentio-core/service/usage/models"
+	"sentioxyz/sentio-core/service/usage/protos"
+	"sentioxyz/sentio-core/service/usage/repository"
+)
+
+type memoryRepo struct {
+	sync.Mutex
+	dialogues []models.Dialogue
+	skuCosts  map[string]models.SkuCost
+	skus      map[string]models.Sku
+	limiters  []models.Limiter
+	tiers     map[string]string
+	// the next saveFailures calls of SaveDialogues fail
+	saveFailures int
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{
+		skuCosts: make(map[string]models.SkuCost),
+		skus:     make(map[string]models.Sku),
+		tiers:    make(map[string]string),
+	}
+}
+
+func (r *memoryRepo) SaveDialogues(_ context.Context, dialogues []*models.Dialogue) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.saveFailures > 0 {
+		r.saveFailures--
+		return errors.New("save dialogues failed")
+	}
+	for _, d := range dialogues {
+		r.dialogues = append(r.dialogues, *d)
+	}
+	return nil
+}
+
+func (r *memoryRepo) Aggregate(_ context.Context, query repository.AggregateQuery) ([]repository.AggregateRow, error) {
+	r.Lock()
+	defer r.Unlock()
+	var row repository.AggregateRow
+	for _, d := range r.dialogues {
+		if d.RequestTime.Before(query.StartTime) || !d.RequestTime.Before(query.EndTime) {
+			continue
+		}
+		if (query.OwnerID != "" && d.OwnerID != query.OwnerID) ||
+			(query.ProjectID != "" && d.ProjectID != query.ProjectID) ||
+			(query.Sku != "" && d.Sku != query.Sku) {
+			continue
+		}
+		row.Cost += d.Cost
+		row.Units += d.Units
+		row.Total++
+		if d.Succeed {
+			row.Succeed++
+		}
+	}
+	if row.Total == 0 {
+		return nil, nil
+	}
+	return []repository.AggregateRow{row}, nil
+}
+
+func (r *memoryRepo) ListSkuCosts(context.Context) ([]models.SkuCost, error) {
+	r.Lock()
+	defer r.Unlock()
+	var configs []models.SkuCost
+	for _, c := range r.skuCosts {
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
+
+func (r *memoryRepo) SaveSkuCosts(_ context.Context, configs []models.SkuCost) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, c := range configs {
+		r.skuCosts[c.Sku+c.EffectiveFrom.String()] = c
+	}
+	return nil
+}
+
+func (r *memoryRepo) DeleteSkuCost(_ context.Context, sku string, effectiveFrom time.Time) error {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.skuCosts, sku+effectiveFrom.String())
+	return nil
+}
+
+func (r *memoryRepo) ListSkus(context.Context) ([]models.Sku, error) {
+	r.Lock()
+	defer r.Unlock()
+	var skus []models.Sku
+	for _, s := range r.skus {
+		skus = append(skus, s)
+	}
+	sort.Slice(skus, func(i, j int) bool { return skus[i].ID < skus[j].ID })
+	return skus, nil
+}
+
+func (r *memoryRepo) SaveSku(_ context.Context, sku *models.Sku) error {
+	r.Lock()
+	defer r.Unlock()
+	r.skus[sku.ID] = *sku
+	return nil
+}
+
+func (r *memoryRepo) ListLimiters(_ context.Context, ownerID, ownerType, tier string) ([]models.Limiter, error) {
+	r.Lock()
+	defer r.Unlock()
+	var limiters []models.Limiter
+	for _, l := range r.limiters {
+		if (l.Category == models.LimiterCategoryOwner && l.OwnerID == ownerID && l.OwnerType == ownerType) ||
+			(l.Category == models.LimiterCategoryDefaultTier && l.Tier == tier) {
+			limiters = append(limiters, l)
+		}
+	}
+	return limiters, nil
+}
+
+func (r *memoryRepo) SaveLimiter(_ context.Context, limiter *models.Limiter) error {
+	r.Lock()
+	defer r.Unlock()
+	limiter.ID = uint64(len(r.limiters) + 1)
+	r.limiters = append(r.limiters, *limiter)
+	return nil
+}
+
+func (r *memoryRepo) GetOwnerTier(_ context.Context, ownerID, _ string) (string, error) {
+	r.Lock()
+	defer r.Unlock()
+	return r.tiers[ownerID], nil
+}
+
+func newTestService(t *testing.T) (*Service, *memoryRepo) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	projects := projectrepo.NewRedisProjectRepository(client)
+	require.NoError(t, projects.SaveProject(context.Background(), &commonmodels.Project{
+		ID:        "p1",
+		Slug:      "project1",
+		OwnerID:   "u1",
+		OwnerType: commonmodels.ProjectOwnerTypeUser,
+		OwnerName: "alice",
+	}))
+
+	repo := newMemoryRepo()
+	config := DefaultConfig()
+	config.FlushInterval = time.Millisecond * 10
+	config.FlushRetryBackoff = time.Millisecond
+	svc, err := NewService(repo, projects, client, config)
+	require.NoError(t, err)
+	return svc, repo
+}
+
+func Test_skuCost(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := svc.SetSkuCostAdmin(ctx, &protos.SetSkuCostRequest{Configs: []*protos.SkuCostConfig{
+		{Sku: "metric", Cost: 1, EffectiveFrom: timestamppb.New(now.Add(-time.Hour * 2))},
+		{Sku: "metric", Cost: 2, EffectiveFrom: timestamppb.New(now.Add(-time.Hour))},
+		{Sku: "metric", Cost: 3, EffectiveFrom: timestamppb.New(now.Add(time.Hour))},
+		{Sku: "entity", Cost: 5, EffectiveFrom: timestamppb.New(now.Add(-time.Hour))},
+	}})
+	require.NoError(t, err)
+
+	all, err := svc.ListSkuCostConfigAdmin(ctx, &protos.ListSkuCostConfigRequest{})
+	require.NoError(t, err)
+	assert.Len(t, all.Configs, 4)
+
+	current, err := svc.ListSkuCostConfigAdmin(ctx, &protos.ListSkuCostConfigRequest{IgnoreHistory: true, IgnoreFuture: true})
+	require.NoError(t, err)
+	require.Len(t, current.Configs, 2)
+	assert.Equal(t, "entity", current.Configs[0].Sku)
+	assert.Equal(t, uint64(2), current.Configs[1].Cost)
+
+	cost, err := svc.skuCost(ctx, "metric", now.Add(-time.Hour*90/60))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cost)
+	cost, err = svc.skuCost(ctx, "metric", now.Add(time.Hour*2))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), cost)
+
+	_, err = svc.DelSkuCostAdmin(ctx, &protos.DelSkuCostRequest{Sku: "metric", EffectiveFrom: timestamppb.New(now.Add(time.Hour))})
+	require.NoError(t, err)
+	cost, err = svc.skuCost(ctx, "metric", now.Add(time.Hour*2))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), cost)
+
+	_, err = svc.UpdateSKUMetaAdmin(ctx, &protos.UpdateSKUMetaRequest{Id: "entity", Name: "Entity", Visible: false})
+	require.NoError(t, err)
+	visible, err := svc.ListSKU(ctx, &protos.ListSKURequest{})
+	require.NoError(t, err)
+	require.Len(t, visible.SkuList, 1)
+	assert.Equal(t, "metric", visible.SkuList[0].Id)
+	assert.Equal(t, uint64(2), visible.SkuList[0].Cost)
+	withInvisible, err := svc.ListSKU(ctx, &protos.ListSKURequest{WithInvisible: true})
+	require.NoError(t, err)
+	require.Len(t, withInvisible.SkuList, 2)
+	assert.Equal(t, "Entity", withInvisible.SkuList[0].Name)
+}
+
+func Test_asyncSaveAndCheckOverLimit(t *testing.T) {
+	svc, repo := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.Run(ctx)
+	}()
+
+	now := time.Now()
+	_, err := svc.SetSkuCostAdmin(ctx, &protos.SetSkuCostRequest{Configs: []*protos.SkuCostConfig{
+		{Sku: "metricv3", Cost: 10, EffectiveFrom: timestamppb.New(now.Add(-time.Hour))},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveLimiter(ctx, &models.Limiter{
+		Category:      models.LimiterCategoryDefaultTier,
+		Tier:          "FREE",
+		EffectiveTime: now.Add(-time.Hour),
+		NaturalMonth:  true,
+		Cost:          100,
+	}))
+
+	check := func() *protos.CheckOverLimitResponse {
+		resp, err := svc.CheckOverLimit(ctx, &protos.CheckOverLimitRequest{ProjectId: "p1", Sku: "metric"})
+		require.NoError(t, err)
+		return resp
+	}
+	assert.Empty(t, check().Over)
+
+	save := func(units uint64) {
+		_, err := svc.AsyncSave(ctx, &protos.AsyncSaveRequest{Dialogues: []*protos.Dialogue{{
+			RequestTime: timestamppb.New(now),
+			Succeed:     true,
+			Units:       units,
+			Tags:        &protos.Tags{ProjectId: "p1", Sku: "metricv3", CustomTags: map[string]string{"name": "m"}},
+		}}})
+		require.NoError(t, err)
+	}
+	save(5)
+	assert.Eventually(t, func() bool {
+		repo.Lock()
+		defer repo.Unlock()
+		return len(repo.dialogues) == 1
+	}, time.Second, time.Millisecond*10)
+	repo.Lock()
+	assert.Equal(t, "u1", repo.dialogues[0].OwnerID)
+	assert.Equal(t, commonmodels.ProjectOwnerTypeUser, repo.dialogues[0].OwnerType)
+	assert.Equal(t, uint64(50), repo.dialogues[0].Cost)
+	assert.JSONEq(t, `{"name":"m"}`, string(repo.dialogues[0].CustomTags))
+	repo.Unlock()
+	assert.Empty(t, check().Over)
+
+	save(5)
+	assert.Eventually(t, func() bool {
+		return len(check().Over) == 1
+	}, time.Second, time.Millisecond*10)
+	resp := check()
+	require.Len(t, resp.Status.Limiters, 1)
+	assert.Equal(t, uint64(100), resp.Status.Limiters[0].CurrentCost)
+	assert.Equal(t, protos.CheckOverLimitResponse_Limiter_DEFAULT_TIER_LIMITER, resp.Status.Limiters[0].Limiter.Category)
+
+	// an owner limiter overrides the default tier limiters
+	require.NoError(t, repo.SaveLimiter(ctx, &models.Limiter{
+		Category:      models.LimiterCategoryOwner,
+		OwnerID:       "u1",
+		OwnerType:     commonmodels.ProjectOwnerTypeUser,
+		EffectiveTime: now.Add(-time.Hour),
+		NaturalMonth:  true,
+		Cost:          1000,
+	}))
+	svc.limiters.Del(ctx, commonmodels.ProjectOwnerTypeUser+"/u1")
+	assert.Empty(t, check().Over)
+
+	cancel()
+	<-done
+}
+
+func Test_flushRetry(t *testing.T) {
+	svc, repo := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	save := func() {
+		_, err := svc.AsyncSave(ctx, &protos.AsyncSaveRequest{Dialogues: []*protos.Dialogue{{
+			RequestTime: timestamppb.Now(),
+			Succeed:     true,
+			Units:       1,
+			Tags:        &protos.Tags{ProjectId: "p1", Sku: "metricv3"},
+		}}})
+		require.NoError(t, err)
+	}
+	saved := func(n int) func() bool {
+		return func() bool {
+			repo.Lock()
+			defer repo.Unlock()
+			return len(repo.dialogues) == n && repo.saveFailures == 0
+		}
+	}
+
+	// saved by the retries
+	repo.Lock()
+	repo.saveFailures = svc.config.FlushRetries
+	repo.Unlock()
+	save()
+	assert.Eventually(t, saved(1), time.Second, time.Millisecond*10)
+
+	// dropped after all the retries failed
+	repo.Lock()
+	repo.saveFailures = svc.config.FlushRetries + 1
+	repo.Unlock()
+	save()
+	assert.Eventually(t, saved(1), time.Second, time.Millisecond*10)
+	save()
+	assert.Eventually(t, saved(2), time.Second, time.Millisecond*10)
+}
+
+func Test_succeedLimitByTokenBucket(t *testing.T) {
+	svc, repo := newTestService(t)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, repo.SaveLimiter(ctx, &models.Limiter{
+		Category:      models.LimiterCategoryOwner,
+		OwnerID:       "u1",
+		OwnerType:     commonmodels.ProjectOwnerTypeUser,
+		Sku:           "webhook",
+		EffectiveTime: now.Add(-time.Hour),
+		PeriodSeconds: 60,
+		Succeed:       3,
+	}))
+
+	var dialogues []*models.Dialogue
+	for i := 0; i < 3; i++ {
+		dialogues = append(dialogues, &models.Dialogue{
+			RequestTime: now,
+			ProjectID:   "p1",
+			Sku:         "webhook",
+			Succeed:     true,
+			Units:       1,
+		})
+	}
+	require.NoError(t, svc.saveDialogues(ctx, dialogues[:2]))
+
+	resp, err := svc.CheckOverLimit(ctx, &protos.CheckOverLimitRequest{ProjectId: "p1", Sku: "webhook"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Over)
+
+	require.NoError(t, svc.saveDialogues(ctx, dialogues[2:]))
+	resp, err = svc.CheckOverLimit(ctx, &protos.CheckOverLimitRequest{ProjectId: "p1", Sku: "webhook"})
+	require.NoError(t, err)
+	require.Len(t, resp.Status.Limiters, 1)
+	assert.Equal(t, uint64(3), resp.Status.Limiters[0].CurrentSucceed)
+
+	// limiter of other sku does not take effect
+	resp, err = svc.CheckOverLimit(ctx, &protos.CheckOverLimitRequest{ProjectId: "p1", Sku: "metric"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Over)
+}
+
+func Test_statisticValidation(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Statistic(ctx, &protos.StatisticRequest{StartTime: timestamppb.Now()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.StatisticExt(ctx, &protos.StatisticRequest{Tags: &protos.QueryTags{ProjectId: "p1"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.Ranking(ctx, &protos.RankingRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}